@@ -0,0 +1,71 @@
+// Переигрывает webhook-события Stripe, обработка которых завершилась ошибкой.
+//
+//	go run ./cmd/replay-stripe-events              # все события в статусе failed
+//	go run ./cmd/replay-stripe-events -event evt_… # одно событие
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"moveshare/internal/config"
+	"moveshare/internal/repository/admin"
+	"moveshare/internal/repository/ledger"
+	"moveshare/internal/repository/payment"
+	"moveshare/internal/repository/payout"
+	"moveshare/internal/repository/subscription"
+	"moveshare/internal/repository/user"
+	"moveshare/internal/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func main() {
+	eventID := flag.String("event", "", "Stripe event ID to replay (default: all failed events)")
+	limit := flag.Int("limit", 100, "maximum number of failed events to replay")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := pgxpool.New(context.Background(), cfg.Database.URL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(context.Background()); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+
+	userService := service.NewUserService(user.NewUserRepository(db))
+	paymentGateway := service.NewPaymentGateway(cfg)
+	// Счета выставляются в фоне и не успели бы сформироваться до выхода; их выставляет сервер при подтверждении платежа
+	ledgerService := service.NewLedgerService(ledger.NewLedgerRepository(db))
+	// Прошедшие при переигрывании чаевые сразу начисляются исполнителю
+	adminService := service.NewAdminService(admin.NewAdminRepository(db))
+	paymentRepo := payment.NewPaymentRepository(db)
+	// События подписок синхронизируют их состояние; письма о неудачном списании отправляются как обычно
+	subscriptionService := service.NewSubscriptionService(subscription.NewSubscriptionRepository(db), paymentRepo, paymentGateway, userService, service.NewEmailService(), &cfg.Stripe)
+	payoutService := service.NewPayoutService(payout.NewPayoutRepository(db), paymentGateway, adminService, userService, ledgerService, subscriptionService)
+	paymentService := service.NewPaymentService(paymentRepo, paymentGateway, userService, nil, ledgerService, payoutService, subscriptionService)
+
+	ctx := context.Background()
+
+	if *eventID != "" {
+		if err := paymentService.ReplayStripeEvent(ctx, *eventID); err != nil {
+			log.Fatalf("Failed to replay event %s: %v", *eventID, err)
+		}
+		log.Printf("Event %s replayed successfully", *eventID)
+		return
+	}
+
+	replayed, failed, err := paymentService.ReplayFailedStripeEvents(ctx, *limit)
+	if err != nil {
+		log.Fatalf("Failed to replay events: %v", err)
+	}
+
+	log.Printf("Replayed %d events, %d still failing", replayed, failed)
+}
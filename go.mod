@@ -3,6 +3,7 @@ module moveshare
 go 1.24.4
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/google/uuid v1.6.0
 	github.com/jackc/pgx/v5 v5.7.5
 	github.com/stripe/stripe-go/v82 v82.4.0
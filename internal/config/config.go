@@ -10,7 +10,9 @@ type Config struct {
 	Database   DatabaseConfig
 	Minio      MinioConfig
 	Stripe     StripeConfig
+	Payment    PaymentConfig
 	GoogleMaps GoogleMapsConfig
+	Distance   DistanceConfig
 }
 
 type MinioConfig struct {
@@ -30,12 +32,24 @@ type StripeConfig struct {
 	PrivateKey    string
 	RestrictedKey string
 	WebhookSecret string
+
+	// Stripe Price планов подписки; без цены план нельзя оформить
+	ProPlanPriceID   string
+	FleetPlanPriceID string
+}
+
+type PaymentConfig struct {
+	Gateway string // stripe или fake
 }
 
 type GoogleMapsConfig struct {
 	APIKey string
 }
 
+type DistanceConfig struct {
+	Provider string // google или offline
+}
+
 func Load() (*Config, error) {
 	dbConfig, err := loadDatabaseConfig()
 	if err != nil {
@@ -44,18 +58,23 @@ func Load() (*Config, error) {
 
 	minioConfig := loadMinioConfig()
 
-	stripeConfig, err := loadStripeConfig()
+	paymentConfig := loadPaymentConfig()
+
+	stripeConfig, err := loadStripeConfig(paymentConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	googleMapsConfig := loadGoogleMapsConfig()
+	distanceConfig := loadDistanceConfig(googleMapsConfig)
 
 	return &Config{
 		Database:   *dbConfig,
 		Minio:      *minioConfig,
 		Stripe:     *stripeConfig,
+		Payment:    *paymentConfig,
 		GoogleMaps: *googleMapsConfig,
+		Distance:   *distanceConfig,
 	}, nil
 }
 
@@ -102,24 +121,58 @@ func loadMinioConfig() *MinioConfig {
 	}
 }
 
-func loadStripeConfig() (*StripeConfig, error) {
+func loadStripeConfig(payment *PaymentConfig) (*StripeConfig, error) {
 	publicKey := os.Getenv("STRIPE_PUBLIC_KEY")
 	privateKey := os.Getenv("STRIPE_PRIVATE_KEY")
 	restrictedKey := os.Getenv("STRIPE_RESTRICTED_KEY")
 	webhookSecret := os.Getenv("STRIPE_WEBHOOK_SECRET")
+	proPlanPriceID := os.Getenv("STRIPE_PRICE_PRO")
+	fleetPlanPriceID := os.Getenv("STRIPE_PRICE_FLEET")
 
-	if publicKey == "" || privateKey == "" {
+	// Фейковому шлюзу ключи Stripe не нужны
+	if payment.Gateway == "stripe" && (publicKey == "" || privateKey == "") {
 		return nil, fmt.Errorf("missing required Stripe environment variables (STRIPE_PUBLIC_KEY, STRIPE_PRIVATE_KEY)")
 	}
 
+	// Фейковый шлюз сам заводит цены планов
+	if payment.Gateway == "fake" {
+		if proPlanPriceID == "" {
+			proPlanPriceID = "price_fake_pro"
+		}
+		if fleetPlanPriceID == "" {
+			fleetPlanPriceID = "price_fake_fleet"
+		}
+	} else if proPlanPriceID == "" || fleetPlanPriceID == "" {
+		log.Println("WARNING: STRIPE_PRICE_PRO or STRIPE_PRICE_FLEET is not set, subscription plans without a price are unavailable")
+	}
+
 	return &StripeConfig{
 		PublicKey:     publicKey,
 		PrivateKey:    privateKey,
 		RestrictedKey: restrictedKey,
 		WebhookSecret: webhookSecret,
+
+		ProPlanPriceID:   proPlanPriceID,
+		FleetPlanPriceID: fleetPlanPriceID,
 	}, nil
 }
 
+func loadPaymentConfig() *PaymentConfig {
+	gateway := os.Getenv("PAYMENT_GATEWAY")
+	if gateway == "" {
+		gateway = "stripe"
+	}
+
+	if gateway != "stripe" && gateway != "fake" {
+		log.Printf("WARNING: unknown PAYMENT_GATEWAY %q, falling back to stripe", gateway)
+		gateway = "stripe"
+	}
+
+	return &PaymentConfig{
+		Gateway: gateway,
+	}
+}
+
 func loadGoogleMapsConfig() *GoogleMapsConfig {
 	apiKey := os.Getenv("GOOGLE_MAPS_API_KEY")
 	
@@ -131,3 +184,24 @@ func loadGoogleMapsConfig() *GoogleMapsConfig {
 		APIKey: apiKey,
 	}
 }
+
+func loadDistanceConfig(googleMaps *GoogleMapsConfig) *DistanceConfig {
+	provider := os.Getenv("DISTANCE_PROVIDER")
+
+	// Без ключа Google по умолчанию считаем расстояние офлайн
+	if provider == "" {
+		provider = "google"
+		if googleMaps.APIKey == "" {
+			provider = "offline"
+		}
+	}
+
+	if provider != "google" && provider != "offline" {
+		log.Printf("WARNING: unknown DISTANCE_PROVIDER %q, falling back to offline", provider)
+		provider = "offline"
+	}
+
+	return &DistanceConfig{
+		Provider: provider,
+	}
+}
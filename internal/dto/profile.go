@@ -14,6 +14,7 @@ type CompanyResponse struct {
 	City               string `json:"city" example:"Los Angeles"`
 	ZipCode            string `json:"zip_code" example:"90001"`
 	DotNumber          string `json:"dot_number" example:"DOT1234567"`
+	TaxID              string `json:"tax_id" example:"12-3456789"`
 }
 
 func NewCompanyResponse(c *models.Company) CompanyResponse {
@@ -29,6 +30,7 @@ func NewCompanyResponse(c *models.Company) CompanyResponse {
 		City:               c.City,
 		ZipCode:            c.ZipCode,
 		DotNumber:          c.DotNumber,
+		TaxID:              c.TaxID,
 	}
 }
 
@@ -43,6 +45,7 @@ type UpdateCompanyRequest struct {
 	City               string `json:"city" example:"Los Angeles"`
 	ZipCode            string `json:"zip_code" example:"90001"`
 	DotNumber          string `json:"dot_number" example:"DOT1234567"`
+	TaxID              string `json:"tax_id" example:"12-3456789"`
 }
 
 type TruckResponse struct {
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"moveshare/internal/handlers"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResolveJobCompletionDispute handles an admin decision on a disputed job completion or a failed capture
+// @Summary Resolve a job completion dispute
+// @Description Resolves a dispute opened by the job owner or a capture that failed permanently: capture charges the escrowed payment (retrying a failed capture) and records the mover's payout, release returns the authorization to the job owner
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Job ID"
+// @Param resolution body models.ResolveCompletionDisputeRequest true "Resolution"
+// @Success 200 {object} models.EscrowCapture
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/jobs/{id}/completion/resolve [post]
+// @Security     BearerAuth
+func ResolveJobCompletionDispute(escrowCaptureService service.EscrowCaptureService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || jobID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		var req models.ResolveCompletionDisputeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		completion, err := escrowCaptureService.ResolveDispute(c.Request.Context(), jobID, &req)
+		if err != nil {
+			c.JSON(handlers.EscrowCaptureErrorStatus(err), gin.H{"error": "Failed to resolve dispute", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, completion)
+	}
+}
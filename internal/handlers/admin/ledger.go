@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"moveshare/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrialBalance handles the ledger trial balance report
+// @Summary Get ledger trial balance
+// @Description Gets debit/credit turnover and balance of every ledger account, totals and a list of unbalanced transactions
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.TrialBalance
+// @Failure 500 {object} map[string]string
+// @Router /admin/ledger/trial-balance [get]
+// @Security     BearerAuth
+func GetTrialBalance(ledgerService service.LedgerService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := ledgerService.GetTrialBalance(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trial balance", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// GetLedgerAccount handles getting a ledger account balance with its entries
+// @Summary Get ledger account
+// @Description Gets the balance and paginated entries of a ledger account (stripe_clearing, escrow, platform_revenue, refunds or user:<id>)
+// @Tags Admin
+// @Produce json
+// @Param code path string true "Account code"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/ledger/accounts/{code} [get]
+// @Security     BearerAuth
+func GetLedgerAccount(ledgerService service.LedgerService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.Param("code")
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+			return
+		}
+
+		balance, err := ledgerService.GetAccountBalance(c.Request.Context(), code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get ledger account", "details": err.Error()})
+			return
+		}
+		if balance == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ledger account not found"})
+			return
+		}
+
+		entries, total, err := ledgerService.GetAccountEntries(c.Request.Context(), code, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get ledger entries", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"account": balance,
+			"entries": entries,
+			"pagination": gin.H{
+				"limit":  limit,
+				"offset": offset,
+				"count":  len(entries),
+				"total":  total,
+			},
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package admin
+
+import (
+	"errors"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPromoCodes handles getting promo codes with usage statistics
+// @Summary List promo codes
+// @Description Gets promo codes with the number of redemptions and the total discount given
+// @Tags Admin
+// @Produce json
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/promo-codes [get]
+// @Security     BearerAuth
+func ListPromoCodes(promoService service.PromoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+			return
+		}
+
+		codes, total, err := promoService.ListPromoCodes(c.Request.Context(), limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get promo codes", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"promo_codes": codes,
+			"pagination": gin.H{
+				"total":  total,
+				"limit":  limit,
+				"offset": offset,
+			},
+		})
+	}
+}
+
+// CreatePromoCode handles creating a promo code
+// @Summary Create promo code
+// @Description Creates a percentage or fixed discount on the job processing fee with optional usage limit, per-user limit, validity period and first-job-only rule
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param promo_code body models.CreatePromoCodeRequest true "Promo code"
+// @Success 201 {object} models.PromoCode
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/promo-codes [post]
+// @Security     BearerAuth
+func CreatePromoCode(promoService service.PromoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req models.CreatePromoCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		code, err := promoService.CreatePromoCode(c.Request.Context(), adminID, &req)
+		if err != nil {
+			c.JSON(promoErrorStatus(err), gin.H{"error": "Failed to create promo code", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, code)
+	}
+}
+
+// UpdatePromoCode handles updating a promo code
+// @Summary Update promo code
+// @Description Updates limits, validity period or active flag of a promo code. The code and discount cannot be changed
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Promo code ID"
+// @Param promo_code body models.UpdatePromoCodeRequest true "Fields to update"
+// @Success 200 {object} models.PromoCode
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/promo-codes/{id} [patch]
+// @Security     BearerAuth
+func UpdatePromoCode(promoService service.PromoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code ID"})
+			return
+		}
+
+		var req models.UpdatePromoCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		code, err := promoService.UpdatePromoCode(c.Request.Context(), id, &req)
+		if err != nil {
+			c.JSON(promoErrorStatus(err), gin.H{"error": "Failed to update promo code", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, code)
+	}
+}
+
+// GetPromoCodeRedemptions handles the promo code redemption report
+// @Summary Get promo code redemptions
+// @Description Gets jobs the promo code was applied to with the fee before and after the discount
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Promo code ID"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/promo-codes/{id}/redemptions [get]
+// @Security     BearerAuth
+func GetPromoCodeRedemptions(promoService service.PromoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code ID"})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+			return
+		}
+
+		redemptions, total, err := promoService.GetPromoRedemptions(c.Request.Context(), id, limit, offset)
+		if err != nil {
+			c.JSON(promoErrorStatus(err), gin.H{"error": "Failed to get redemptions", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"redemptions": redemptions,
+			"pagination": gin.H{
+				"total":  total,
+				"limit":  limit,
+				"offset": offset,
+			},
+		})
+	}
+}
+
+// GetUserCredits handles getting a user's credit balance
+// @Summary Get user credits
+// @Description Gets the credit balance of a user with recent credit transactions
+// @Tags Admin
+// @Produce json
+// @Param userID path int true "User ID"
+// @Success 200 {object} models.CreditBalance
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/user/{userID}/credits [get]
+// @Security     BearerAuth
+func GetUserCredits(promoService service.PromoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		balance, err := promoService.GetCreditBalance(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credits", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, balance)
+	}
+}
+
+// AdjustUserCredits handles granting or deducting account credits
+// @Summary Adjust user credits
+// @Description Grants (positive amount) or deducts (negative amount) account credits that are applied to platform fees
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param userID path int true "User ID"
+// @Param credits body models.AdjustCreditsRequest true "Credit adjustment"
+// @Success 200 {object} models.CreditBalance
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/user/{userID}/credits [post]
+// @Security     BearerAuth
+func AdjustUserCredits(promoService service.PromoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var req models.AdjustCreditsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		balance, err := promoService.AdjustCredits(c.Request.Context(), adminID, userID, &req)
+		if err != nil {
+			c.JSON(promoErrorStatus(err), gin.H{"error": "Failed to adjust credits", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, balance)
+	}
+}
+
+func promoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPromoCodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrPromoCodeExists):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrPromoCodeInvalid), errors.Is(err, service.ErrInsufficientCredits):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
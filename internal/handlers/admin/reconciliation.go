@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReconciliationReport handles the Stripe reconciliation report
+// @Summary Get Stripe reconciliation report
+// @Description Gets the latest reconciliation run, open payment discrepancies that need manual review and recent automatic fixes
+// @Tags Admin
+// @Produce json
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} models.ReconciliationReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reconciliation [get]
+// @Security     BearerAuth
+func GetReconciliationReport(reconciliationService service.ReconciliationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+			return
+		}
+
+		report, err := reconciliationService.GetReport(c.Request.Context(), limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reconciliation report", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// RunReconciliation handles a manual Stripe reconciliation run
+// @Summary Run Stripe reconciliation
+// @Description Compares recent Stripe PaymentIntents with payment records, fixes safe discrepancies and flags the rest
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.ReconciliationRun
+// @Failure 500 {object} map[string]string
+// @Router /admin/reconciliation/run [post]
+// @Security     BearerAuth
+func RunReconciliation(reconciliationService service.ReconciliationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		run, err := reconciliationService.Reconcile(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Reconciliation failed", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, run)
+	}
+}
+
+// ResolveReconciliationIssue handles marking a flagged discrepancy as resolved
+// @Summary Resolve reconciliation issue
+// @Description Marks an open reconciliation issue as resolved with a note about the manual action taken
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Issue ID"
+// @Param request body models.ResolveReconciliationIssueRequest true "Resolution note"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/reconciliation/issues/{id}/resolve [patch]
+// @Security     BearerAuth
+func ResolveReconciliationIssue(reconciliationService service.ReconciliationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		issueID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || issueID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid issue ID"})
+			return
+		}
+
+		var req models.ResolveReconciliationIssueRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		if err := reconciliationService.ResolveIssue(c.Request.Context(), issueID, adminID, req.Note); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Failed to resolve issue", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Issue resolved successfully"})
+	}
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"moveshare/internal/handlers/payment"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefundPayment handles an admin refund of any payment (disputes, goodwill, duplicates)
+// @Summary Refund a payment
+// @Description Issues a full or partial refund of any payment. Omit amount_cents to refund the remaining balance.
+// @Description A refund of a job payment or tip reduces the mover's pending payout; it is refused (403) once the payout
+// @Description has been transferred and returns 409 while the transfer is in progress
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Param refund body models.CreateRefundRequest true "Refund data"
+// @Success 201 {object} models.PaymentRefund
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/payments/{id}/refunds [post]
+// @Security     BearerAuth
+func RefundPayment(paymentService service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || paymentID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+			return
+		}
+
+		var req models.CreateRefundRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		refund, err := paymentService.RefundPayment(c.Request.Context(), adminID, true, paymentID, &req)
+		if err != nil {
+			c.JSON(payment.RefundErrorStatus(err), gin.H{"error": "Failed to refund payment", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, refund)
+	}
+}
+
+// GetPaymentRefunds handles getting refunds of any payment
+// @Summary Get payment refunds
+// @Description Gets all refunds of a payment with their current status
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Success 200 {array} models.PaymentRefund
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/payments/{id}/refunds [get]
+// @Security     BearerAuth
+func GetPaymentRefunds(paymentService service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || paymentID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+			return
+		}
+
+		refunds, err := paymentService.GetPaymentRefunds(c.Request.Context(), 0, true, paymentID)
+		if err != nil {
+			c.JSON(payment.RefundErrorStatus(err), gin.H{"error": "Failed to get refunds", "details": err.Error()})
+			return
+		}
+		if refunds == nil {
+			refunds = []models.PaymentRefund{}
+		}
+
+		c.JSON(http.StatusOK, refunds)
+	}
+}
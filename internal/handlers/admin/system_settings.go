@@ -70,6 +70,31 @@ func UpdateSystemSettings(adminService service.AdminService) gin.HandlerFunc {
 			return
 		}
 
+		if settings.UrgentBoostPriceCents < 0 || settings.PinBoostPriceCents < 0 || settings.NearbyPushBoostPriceCents < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Boost prices must be positive"})
+			return
+		}
+
+		if settings.BoostDurationHours < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Boost duration hours must be positive"})
+			return
+		}
+		if settings.BoostDurationHours == 0 {
+			settings.BoostDurationHours = 24
+		}
+
+		if settings.InvoiceTaxRate < 0 || settings.InvoiceTaxRate > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invoice tax rate must be between 0 and 100"})
+			return
+		}
+
+		if settings.FeeSchedule != nil {
+			if err := settings.FeeSchedule.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fee schedule", "details": err.Error()})
+				return
+			}
+		}
+
 		err := adminService.UpdateSystemSettings(c.Request.Context(), &settings)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update system settings"})
@@ -0,0 +1,36 @@
+package boost
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBoostOptions godoc
+// @Summary      Get listing boost options
+// @Description  Returns the paid listing upgrades (urgent badge, pin to top, push to nearby movers) with current prices and the number of free boosts per billing period included in the subscription plan
+// @Tags         Boosts
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]string
+// @Router       /jobs/boost-options/ [get]
+func GetBoostOptions(boostService service.BoostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		options, err := boostService.GetBoostOptions(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get boost options", "details": err.Error()})
+			return
+		}
+
+		response := gin.H{"options": options}
+		if entitlements, err := utils.GetEntitlementsFromContext(c); err == nil {
+			response["plan"] = entitlements.Plan
+			response["included_boosts"] = entitlements.IncludedBoosts
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
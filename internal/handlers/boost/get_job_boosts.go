@@ -0,0 +1,38 @@
+package boost
+
+import (
+	"moveshare/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJobBoosts godoc
+// @Summary      Get active boosts of a job
+// @Description  Returns listing upgrades that are currently active for the job
+// @Tags         Boosts
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "Job ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /jobs/{id}/boosts/ [get]
+func GetJobBoosts(boostService service.BoostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		boosts, err := boostService.GetJobBoosts(c.Request.Context(), jobID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job boosts", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"boosts": boosts})
+	}
+}
@@ -0,0 +1,54 @@
+package boost
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PurchaseJobBoosts godoc
+// @Summary      Boost an existing job
+// @Description  Charges the contractor for the selected listing upgrades. Boosts included in the subscription plan start right away; paid boosts start for the configured duration once the returned payment is confirmed (boosts lists only the boosts already running)
+// @Tags         Boosts
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                           true  "Job ID"
+// @Param        boosts  body      models.PurchaseBoostsRequest  true  "Boosts to purchase"
+// @Success      201     {object}  models.PurchaseBoostsResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Router       /jobs/{id}/boosts/ [post]
+func PurchaseJobBoosts(boostService service.BoostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		var req models.PurchaseBoostsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		response, err := boostService.PurchaseBoosts(c.Request.Context(), userID, jobID, &req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to boost job", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, response)
+	}
+}
@@ -0,0 +1,51 @@
+package crew
+
+import (
+	"errors"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AcceptTeamInvite godoc
+// @Summary      Accept a team invite
+// @Description  Joins the company team the user was invited to. Only members who accepted the invite can be assigned to jobs
+// @Tags         Crew
+// @Security     BearerAuth
+// @Produce      json
+// @Param        ownerId  path  int  true  "Team owner user ID"
+// @Success      200  {object}  models.TeamMember
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /crew/invites/{ownerId}/accept/ [post]
+func AcceptTeamInvite(crewService service.CrewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		ownerID, err := strconv.ParseInt(c.Param("ownerId"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid owner ID"})
+			return
+		}
+
+		member, err := crewService.AcceptTeamInvite(c.Request.Context(), userID, ownerID)
+		if err != nil {
+			if errors.Is(err, service.ErrTeamInviteNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Team invite not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept team invite", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, member)
+	}
+}
@@ -0,0 +1,52 @@
+package crew
+
+import (
+	"errors"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddTeamMember godoc
+// @Summary      Invite a team member
+// @Description  Invites an existing user (by email or username) to the company team as a driver or helper. The user joins the team and can be assigned to jobs only after accepting the invite; pending invites count toward the team size limited by the subscription plan. For an existing member only the role is changed
+// @Tags         Crew
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        member  body      models.AddTeamMemberRequest  true  "Team member data"
+// @Success      201     {object}  models.TeamMember
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Router       /crew/team/ [post]
+func AddTeamMember(crewService service.CrewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		var req models.AddTeamMemberRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		member, err := crewService.AddTeamMember(c.Request.Context(), userID, &req)
+		if err != nil {
+			if errors.Is(err, service.ErrTeamSeatLimit) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Team seat limit reached, upgrade your plan to add more members", "details": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to add team member", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, member)
+	}
+}
@@ -0,0 +1,54 @@
+package crew
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignJobCrew godoc
+// @Summary      Assign crew and truck to a claimed job
+// @Description  Replaces the drivers, helpers and truck assigned to a job claimed by the authenticated company. Assigned members are notified.
+// @Tags         Crew
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                       true  "Job ID"
+// @Param        crew  body      models.AssignCrewRequest  true  "Crew assignment"
+// @Success      200   {object}  models.JobCrew
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Router       /crew/jobs/{id}/ [put]
+func AssignJobCrew(crewService service.CrewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		var req models.AssignCrewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		jobCrew, err := crewService.AssignJobCrew(c.Request.Context(), userID, jobID, &req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to assign crew", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, jobCrew)
+	}
+}
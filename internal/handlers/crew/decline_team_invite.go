@@ -0,0 +1,43 @@
+package crew
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeclineTeamInvite godoc
+// @Summary      Decline a team invite
+// @Description  Declines an invitation to a company team
+// @Tags         Crew
+// @Security     BearerAuth
+// @Param        ownerId  path  int  true  "Team owner user ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /crew/invites/{ownerId}/ [delete]
+func DeclineTeamInvite(crewService service.CrewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		ownerID, err := strconv.ParseInt(c.Param("ownerId"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid owner ID"})
+			return
+		}
+
+		if err := crewService.DeclineTeamInvite(c.Request.Context(), userID, ownerID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to decline team invite", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Team invite declined"})
+	}
+}
@@ -0,0 +1,56 @@
+package crew
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyAssignments godoc
+// @Summary      Get my assignments
+// @Description  Returns jobs the authenticated driver or helper has been assigned to, ordered by pickup date
+// @Tags         Crew
+// @Security     BearerAuth
+// @Produce      json
+// @Param        page   query  int  false  "Page number" default(1)
+// @Param        limit  query  int  false  "Items per page" default(10)
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /crew/my-assignments/ [get]
+func GetMyAssignments(crewService service.CrewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		var pagination models.PaginationQuery
+		if err := c.ShouldBindQuery(&pagination); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		assignments, total, err := crewService.GetMyAssignments(c.Request.Context(), userID, pagination.Page, pagination.Limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get assignments", "details": err.Error()})
+			return
+		}
+
+		totalPages := (total + pagination.Limit - 1) / pagination.Limit
+
+		c.JSON(http.StatusOK, gin.H{
+			"assignments": assignments,
+			"pagination": gin.H{
+				"page":        pagination.Page,
+				"limit":       pagination.Limit,
+				"total":       total,
+				"total_pages": totalPages,
+			},
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package crew
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTeamInvites godoc
+// @Summary      Get team invites
+// @Description  Returns company team invitations that the authenticated user has not accepted yet
+// @Tags         Crew
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Router       /crew/invites/ [get]
+func GetTeamInvites(crewService service.CrewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		invites, err := crewService.GetTeamInvites(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team invites", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"invites": invites})
+	}
+}
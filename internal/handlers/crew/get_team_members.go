@@ -0,0 +1,36 @@
+package crew
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTeamMembers godoc
+// @Summary      Get team members
+// @Description  Returns drivers and helpers of the authenticated user's company
+// @Tags         Crew
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Router       /crew/team/ [get]
+func GetTeamMembers(crewService service.CrewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		members, err := crewService.GetTeamMembers(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team members", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"members": members})
+	}
+}
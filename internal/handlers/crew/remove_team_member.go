@@ -0,0 +1,43 @@
+package crew
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RemoveTeamMember godoc
+// @Summary      Remove a team member
+// @Description  Removes a driver or helper from the company team and unassigns them from the crew of the company's claimed and in-progress jobs
+// @Tags         Crew
+// @Security     BearerAuth
+// @Param        memberId  path  int  true  "Member user ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /crew/team/{memberId}/ [delete]
+func RemoveTeamMember(crewService service.CrewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		memberID, err := strconv.ParseInt(c.Param("memberId"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+			return
+		}
+
+		if err := crewService.RemoveTeamMember(c.Request.Context(), userID, memberID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to remove team member", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Team member removed successfully"})
+	}
+}
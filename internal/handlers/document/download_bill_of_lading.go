@@ -0,0 +1,49 @@
+package document
+
+import (
+	"fmt"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadBillOfLading godoc
+// @Summary      Download bill of lading
+// @Description  Downloads the latest bill of lading PDF for a job, generating it first if none exists
+// @Tags         Documents
+// @Security     BearerAuth
+// @Produce      application/pdf
+// @Param        id   path  int  true  "Job ID"
+// @Success      200  {file}  file
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /jobs/{id}/bill-of-lading/ [get]
+func DownloadBillOfLading(documentService service.DocumentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		file, stream, err := documentService.GetBillOfLading(c.Request.Context(), userID, jobID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get bill of lading", "details": err.Error()})
+			return
+		}
+		defer stream.Close()
+
+		c.DataFromReader(http.StatusOK, file.FileSize, "application/pdf", stream, map[string]string{
+			"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, file.FileName),
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package document
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GenerateBillOfLading godoc
+// @Summary      Generate bill of lading
+// @Description  Generates a bill of lading / order for service PDF for a claimed job and stores it with the job files
+// @Tags         Documents
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "Job ID"
+// @Success      201  {object}  models.JobFile
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /jobs/{id}/bill-of-lading/ [post]
+func GenerateBillOfLading(documentService service.DocumentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		file, err := documentService.GenerateBillOfLading(c.Request.Context(), userID, jobID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to generate bill of lading", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, file)
+	}
+}
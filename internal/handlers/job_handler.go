@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"math"
 	"moveshare/internal/models"
 	"moveshare/internal/repository"
 	"moveshare/internal/service"
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -22,33 +25,46 @@ type JobHandler struct {
 	chatService         service.ChatService
 	notificationService service.NotificationService
 	minioRepo           *repository.Repository
-	paymentService      service.PaymentService
+	jobPostingService   service.JobPostingService
 	adminService        service.AdminService
+	crewService         service.CrewService
+	boostService        service.BoostService
+
+	escrowCaptureService service.EscrowCaptureService
 }
 
-func NewJobHandler(jobService *service.JobService, chatService service.ChatService, notificationService service.NotificationService, minioRepo *repository.Repository, paymentService service.PaymentService, adminService service.AdminService) *JobHandler {
+func NewJobHandler(jobService *service.JobService, chatService service.ChatService, notificationService service.NotificationService, minioRepo *repository.Repository, jobPostingService service.JobPostingService, adminService service.AdminService, crewService service.CrewService, boostService service.BoostService, escrowCaptureService service.EscrowCaptureService) *JobHandler {
 	return &JobHandler{
 		jobService:          jobService,
 		chatService:         chatService,
 		notificationService: notificationService,
 		minioRepo:           minioRepo,
-		paymentService:      paymentService,
+		jobPostingService:   jobPostingService,
 		adminService:        adminService,
+		crewService:         crewService,
+		boostService:        boostService,
+
+		escrowCaptureService: escrowCaptureService,
 	}
 }
 
 // PostNewJob godoc
 // @Summary Create a new job with payment
-// @Description Creates a new job posting for moving services with required payment processing
+// @Description Creates a new job posting for moving services. The job is created in the pending_payment state together with a payment outbox record in one transaction: the job payment is authorized in escrow (captured when the job is completed) and the processing fee and boosts are charged. The job becomes visible to movers once both payments are confirmed; payments that require customer action (3DS) return client secrets. Temporary failures are retried in the background and failed postings are rolled back automatically. An optional promo code and the account credit balance reduce the processing fee
 // @Tags Jobs
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
 // @Param job body models.CreateJobWithPaymentRequest true "Job creation data with payment"
-// @Success 201 {object} map[string]interface{} "Job created successfully with payment processed"
+// @Success 201 {object} map[string]interface{} "Job created; posted if payment is confirmed, otherwise awaiting customer confirmation"
+// @Success 202 {object} map[string]interface{} "Job created, payment processing will be retried"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 402 {object} map[string]string "Payment required"
+// @Failure 404 {object} map[string]string "Promo code not found"
+// @Failure 409 {object} map[string]interface{} "Possible duplicate job, resend with confirm_duplicate=true"
+// @Failure 422 {object} map[string]string "Idempotency-Key reused with a different request"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /jobs/post-new-job [post]
 func (h *JobHandler) PostNewJob(c *gin.Context) {
@@ -64,11 +80,13 @@ func (h *JobHandler) PostNewJob(c *gin.Context) {
 		return
 	}
 
-	// Calculate total amount: job payment + $15 processing fee
-	processingFeeCents := int64(1500) // $15.00 in cents
-	totalAmountCents := int64(req.PaymentAmount*100) + processingFeeCents
+	// Optional paid boosts are charged together with the job posting
+	boostQuote, err := h.boostService.QuoteBoosts(c.Request.Context(), userID.(int64), req.Boosts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid boosts", "details": err.Error()})
+		return
+	}
 
-	// Create the job first
 	jobReq := &models.CreateJobRequest{
 		JobType:                       req.JobType,
 		NumberOfBedrooms:              req.NumberOfBedrooms,
@@ -92,6 +110,7 @@ func (h *JobHandler) PostNewJob(c *gin.Context) {
 		DeliveryBuildingType:          req.DeliveryBuildingType,
 		DeliveryWalkDistance:          req.DeliveryWalkDistance,
 		DistanceMiles:                 req.DistanceMiles,
+		Stops:                         req.Stops,
 		PickupDate:                    req.PickupDate,
 		PickupTimeFrom:                req.PickupTimeFrom,
 		PickupTimeTo:                  req.PickupTimeTo,
@@ -102,58 +121,138 @@ func (h *JobHandler) PostNewJob(c *gin.Context) {
 		PaymentAmount:                 req.PaymentAmount,
 		WeightLbs:                     req.WeightLbs,
 		VolumeCuFt:                    req.VolumeCuFt,
+		ConfirmDuplicate:              req.ConfirmDuplicate,
+	}
+
+	posting := &models.JobPosting{
+		PaymentMethodID: req.PaymentMethodID,
+		PromoCode:       req.PromoCode,
+		Boosts:          boostQuote,
 	}
 
-	job, err := h.jobService.CreateJob(userID.(int64), jobReq)
+	// The job is created together with its payment outbox record and becomes visible once payment is confirmed
+	result, err := h.jobPostingService.PostJob(c.Request.Context(), userID.(int64), jobReq, posting)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var duplicateErr *service.DuplicateJobError
+		switch {
+		case errors.As(err, &duplicateErr):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":        "Possible duplicate job",
+				"details":      duplicateErr.Error(),
+				"duplicate_of": duplicateErr.Candidates[0].ID,
+				"duplicates":   duplicateErr.Candidates,
+			})
+		case errors.Is(err, service.ErrDistanceUnavailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Route distance is unavailable", "details": err.Error()})
+		case errors.Is(err, service.ErrPromoCodeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Promo code cannot be applied", "details": err.Error()})
+		case errors.Is(err, service.ErrPromoCodeUnavailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Promo code cannot be applied", "details": err.Error()})
+		case errors.Is(err, service.ErrJobPostingFailed):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment failed", "details": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	// Create payment after job creation with job ID
-	paymentReq := &models.CreatePaymentRequest{
-		JobID:           &job.ID,
-		PaymentMethodID: req.PaymentMethodID,
-		AmountCents:     totalAmountCents,
-		Description:     fmt.Sprintf("Payment for %s job posting", req.JobType),
+	status := http.StatusCreated
+	message := "Job posted successfully with payment confirmed"
+	switch result.Posting.Status {
+	case models.JobPostingAwaitingConfirmation:
+		message = "Job created, it will be posted once the payment is confirmed"
+	case models.JobPostingPending:
+		// Payment could not be created right now and will be retried automatically
+		status = http.StatusAccepted
+		message = "Job created, payment processing will be retried"
+	}
+
+	c.JSON(status, gin.H{
+		"message": message,
+		"job":     result.Job,
+		"posting": result.Posting,
+		"boosts":  result.Boosts,
+		"payment": jobPostingPayment(result),
+	})
+}
+
+// GetJobPosting godoc
+// @Summary Get job posting status
+// @Description Returns the payment state of a job posted with payment. The job becomes visible to movers once the escrow authorization and the processing fee are confirmed; client secrets are returned for payments that require customer action (3DS)
+// @Tags Jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} map[string]interface{} "Job posting status"
+// @Failure 400 {object} map[string]string "Invalid job ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Job posting not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /jobs/{id}/posting [get]
+func (h *JobHandler) GetJobPosting(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
 
-	paymentResponse, err := h.paymentService.CreatePayment(c.Request.Context(), userID.(int64), paymentReq)
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		// If payment fails, delete the created job
-		deleteErr := h.jobService.DeleteJob(job.ID, userID.(int64))
-		if deleteErr != nil {
-			fmt.Printf("Failed to delete job after payment failure: %v\n", deleteErr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	result, err := h.jobPostingService.GetJobPosting(c.Request.Context(), userID.(int64), jobID)
+	if err != nil {
+		if errors.Is(err, service.ErrJobPostingNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job posting not found"})
+			return
 		}
-		
-		c.JSON(http.StatusPaymentRequired, gin.H{
-			"error":   "Payment processing failed",
-			"details": err.Error(),
-		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job posting", "details": err.Error()})
 		return
 	}
 
-	// Send notifications to potential interested users (async)
-	go func() {
-		// Get route information for notification
-		route := fmt.Sprintf("%s → %s", req.PickupAddress, req.DeliveryAddress)
-		estimatedPay := float64(req.PaymentAmount)
+	c.JSON(http.StatusOK, gin.H{
+		"job":     result.Job,
+		"posting": result.Posting,
+		"boosts":  result.Boosts,
+		"payment": jobPostingPayment(result),
+	})
+}
 
-		// Log that a new job was posted for potential notification
-		fmt.Printf("New job posted (ID: %d) - route: %s, pay: $%.2f\n", job.ID, route, estimatedPay)
-	}()
+// jobPostingPayment describes the posting payments: the processing fee with boosts and the escrow of the job payment
+func jobPostingPayment(result *models.JobPostingResult) gin.H {
+	processingFeeCents := result.Job.FeeBreakdown.JobProcessingFeeCents()
+	payoutCents := int64(math.Round(result.Job.PaymentAmount * 100))
+	var boostsCents int64
+	if result.Posting.Boosts != nil {
+		boostsCents = result.Posting.Boosts.TotalCents
+	}
+
+	payment := gin.H{
+		"status":         "not_required",
+		"total_amount":   float64(payoutCents+processingFeeCents+boostsCents) / 100,
+		"processing_fee": float64(processingFeeCents) / 100,
+		"fee_breakdown":  result.Job.FeeBreakdown,
+		"boosts_amount":  float64(boostsCents) / 100,
+	}
+	if result.Payment != nil {
+		payment["payment_intent_id"] = result.Payment.PaymentIntentID
+		payment["client_secret"] = result.Payment.ClientSecret
+		payment["status"] = result.Payment.Status
+		payment["requires_action"] = result.Payment.RequiresConfirmation
+	}
+	if result.Escrow != nil {
+		payment["escrow"] = gin.H{
+			"payment_intent_id": result.Escrow.PaymentIntentID,
+			"client_secret":     result.Escrow.ClientSecret,
+			"status":            result.Escrow.Status,
+			"requires_action":   result.Escrow.RequiresConfirmation,
+			"amount":            float64(payoutCents) / 100,
+		}
+	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Job created successfully with payment processed",
-		"job":     job,
-		"payment": gin.H{
-			"payment_intent_id": paymentResponse.PaymentIntentID,
-			"client_secret":     paymentResponse.ClientSecret,
-			"status":            paymentResponse.Status,
-			"total_amount":      float64(totalAmountCents) / 100,
-			"processing_fee":    15.00,
-		},
-	})
+	return payment
 }
 
 // ClaimJob godoc
@@ -206,7 +305,7 @@ func (h *JobHandler) ClaimJob(c *gin.Context) {
 
 // DeleteJob godoc
 // @Summary Delete a job
-// @Description Deletes a job posting (only by job owner)
+// @Description Deletes a job posting (only by job owner, only while no mover has claimed it)
 // @Tags Jobs
 // @Accept json
 // @Produce json
@@ -215,6 +314,7 @@ func (h *JobHandler) ClaimJob(c *gin.Context) {
 // @Success 200 {object} map[string]string "Job deleted successfully"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "Job not found or already claimed"
 // @Router /jobs/delete-job/{id} [delete]
 func (h *JobHandler) DeleteJob(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -231,6 +331,10 @@ func (h *JobHandler) DeleteJob(c *gin.Context) {
 	}
 
 	err = h.jobService.DeleteJob(jobID, userID.(int64))
+	if errors.Is(err, service.ErrJobNotDeletable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -247,7 +351,8 @@ func (h *JobHandler) DeleteJob(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Job ID"
-// @Success 200 {object} models.Job "Job details with contractor username, status and average rating"
+// @Param tz query string false "Viewer IANA time zone, e.g. America/Chicago (defaults to server time zone)"
+// @Success 200 {object} models.Job "Job details with contractor username, status, average rating and assigned crew"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 404 {object} map[string]string "Job not found"
 // @Router /jobs/{id} [get]
@@ -259,6 +364,12 @@ func (h *JobHandler) GetJobByID(c *gin.Context) {
 		return
 	}
 
+	viewerTZ, err := viewerTimeZone(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone", "details": err.Error()})
+		return
+	}
+
 	job, err := h.jobService.GetJobByID(jobID)
 	if err != nil {
 		// Log the actual error for debugging
@@ -266,10 +377,31 @@ func (h *JobHandler) GetJobByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found", "details": err.Error()})
 		return
 	}
+	job.LocalizeWindows(viewerTZ)
+
+	// Назначенная команда видна заказчику, исполнителю и самой команде
+	if userID, exists := c.Get("userID"); exists && job.ExecutorID != nil {
+		uid := userID.(int64)
+		crew, err := h.crewService.GetJobCrew(c.Request.Context(), jobID)
+		if err != nil {
+			fmt.Printf("Failed to get crew for job %d: %v\n", jobID, err)
+		} else if uid == job.ContractorID || uid == *job.ExecutorID || crewContains(crew, uid) {
+			job.Crew = crew
+		}
+	}
 
 	c.JSON(http.StatusOK, job)
 }
 
+func crewContains(crew *models.JobCrew, userID int64) bool {
+	for _, m := range crew.Members {
+		if m.MemberID == userID {
+			return true
+		}
+	}
+	return false
+}
+
 // GetMyJobs godoc
 // @Summary Get my jobs
 // @Description Retrieves jobs created by the authenticated user with pagination
@@ -279,6 +411,7 @@ func (h *JobHandler) GetJobByID(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param tz query string false "Viewer IANA time zone, e.g. America/Chicago (defaults to server time zone)"
 // @Success 200 {object} map[string]interface{} "User's jobs with pagination"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 401 {object} map[string]string "Unauthorized"
@@ -297,7 +430,13 @@ func (h *JobHandler) GetMyJobs(c *gin.Context) {
 		return
 	}
 
-	jobs, total, err := h.jobService.GetMyJobs(userID.(int64), pagination.Page, pagination.Limit)
+	viewerTZ, err := viewerTimeZone(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone", "details": err.Error()})
+		return
+	}
+
+	jobs, total, err := h.jobService.GetMyJobs(userID.(int64), pagination.Page, pagination.Limit, viewerTZ)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -334,6 +473,8 @@ func (h *JobHandler) GetMyJobs(c *gin.Context) {
 // @Param truck_size query string false "Truck size filter (space-separated for multiple)" example("Small Large")
 // @Param payout_min query number false "Minimum payout amount"
 // @Param payout_max query number false "Maximum payout amount"
+// @Param max_stops query int false "Maximum number of intermediate stops (0 for direct moves only)"
+// @Param tz query string false "Viewer IANA time zone, e.g. America/Chicago (defaults to server time zone)"
 // @Success 200 {object} map[string]interface{} "Available jobs with pagination and applied filters"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]string "Unauthorized"
@@ -355,7 +496,13 @@ func (h *JobHandler) GetAvailableJobs(c *gin.Context) {
 		return
 	}
 
-	jobs, total, err := h.jobService.GetAvailableJobs(userID.(int64), &filters)
+	viewerTZ, err := viewerTimeZone(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone", "details": err.Error()})
+		return
+	}
+
+	jobs, total, err := h.jobService.GetAvailableJobs(userID.(int64), &filters, viewerTZ)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get available jobs",
@@ -395,6 +542,7 @@ func (h *JobHandler) GetAvailableJobs(c *gin.Context) {
 			"truck_size":         filters.TruckSize,
 			"payout_min":         filters.PayoutMin,
 			"payout_max":         filters.PayoutMax,
+			"max_stops":          filters.MaxStops,
 		},
 	}
 
@@ -438,6 +586,7 @@ func (h *JobHandler) GetJobFilterOptions(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param tz query string false "Viewer IANA time zone, e.g. America/Chicago (defaults to server time zone)"
 // @Success 200 {object} map[string]interface{} "Claimed jobs with pagination and chat status"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 401 {object} map[string]string "Unauthorized"
@@ -456,7 +605,13 @@ func (h *JobHandler) GetClaimedJobs(c *gin.Context) {
 		return
 	}
 
-	jobs, total, err := h.jobService.GetClaimedJobs(userID.(int64), pagination.Page, pagination.Limit)
+	viewerTZ, err := viewerTimeZone(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone", "details": err.Error()})
+		return
+	}
+
+	jobs, total, err := h.jobService.GetClaimedJobs(userID.(int64), pagination.Page, pagination.Limit, viewerTZ)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -513,13 +668,13 @@ func (h *JobHandler) GetClaimedJobs(c *gin.Context) {
 
 // MarkJobCompleted godoc
 // @Summary Mark job as completed
-// @Description Marks a job as completed by the user who claimed it
+// @Description Marks a job as completed by the user who claimed it. The escrowed payment is not captured yet: the job owner confirms the completion or opens a dispute, and the completion is confirmed automatically when the confirmation window ends
 // @Tags Jobs
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Job ID"
-// @Success 200 {object} map[string]string "Job marked as completed successfully"
+// @Success 200 {object} map[string]interface{} "Job marked as completed successfully"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Router /jobs/mark-job-completed/{id} [post]
@@ -537,7 +692,7 @@ func (h *JobHandler) MarkJobCompleted(c *gin.Context) {
 		return
 	}
 
-	err = h.jobService.MarkJobCompleted(jobID, userID.(int64))
+	completion, err := h.jobService.MarkJobCompleted(jobID, userID.(int64))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -553,7 +708,134 @@ func (h *JobHandler) MarkJobCompleted(c *gin.Context) {
 		}
 	}()
 
-	c.JSON(http.StatusOK, gin.H{"message": "Job marked as completed successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Job marked as completed successfully", "completion": completion})
+}
+
+// GetJobCompletion godoc
+// @Summary Get job completion status
+// @Description Returns the confirmation and payment capture state of a completed job to its owner or executor
+// @Tags Jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.EscrowCapture
+// @Failure 400 {object} map[string]string "Invalid job ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Job completion not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /jobs/{id}/completion [get]
+func (h *JobHandler) GetJobCompletion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	completion, err := h.escrowCaptureService.GetEscrowCapture(c.Request.Context(), userID.(int64), false, jobID)
+	if err != nil {
+		c.JSON(EscrowCaptureErrorStatus(err), gin.H{"error": "Failed to get job completion", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, completion)
+}
+
+// ConfirmJobCompletion godoc
+// @Summary Confirm job completion
+// @Description The job owner confirms that the job was completed (or withdraws their dispute). The escrowed payment is captured and the mover's payout is recorded; a failed capture is retried automatically
+// @Tags Jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.EscrowCapture
+// @Failure 400 {object} map[string]string "Invalid job ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Job completion not found"
+// @Failure 409 {object} map[string]string "Job completion was already confirmed or resolved"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /jobs/{id}/completion/confirm [post]
+func (h *JobHandler) ConfirmJobCompletion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	completion, err := h.escrowCaptureService.ConfirmCompletion(c.Request.Context(), userID.(int64), jobID)
+	if err != nil {
+		c.JSON(EscrowCaptureErrorStatus(err), gin.H{"error": "Failed to confirm job completion", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, completion)
+}
+
+// DisputeJobCompletion godoc
+// @Summary Dispute job completion
+// @Description The job owner disputes the completion before it is confirmed automatically. The escrowed payment stays authorized until an admin resolves the dispute
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Param dispute body models.DisputeCompletionRequest true "Dispute reason"
+// @Success 200 {object} models.EscrowCapture
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Job completion not found"
+// @Failure 409 {object} map[string]string "Job completion was already confirmed or disputed"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /jobs/{id}/completion/dispute [post]
+func (h *JobHandler) DisputeJobCompletion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	var req models.DisputeCompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	completion, err := h.escrowCaptureService.DisputeCompletion(c.Request.Context(), userID.(int64), jobID, &req)
+	if err != nil {
+		c.JSON(EscrowCaptureErrorStatus(err), gin.H{"error": "Failed to dispute job completion", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, completion)
+}
+
+// EscrowCaptureErrorStatus maps job completion errors to HTTP status codes
+func EscrowCaptureErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrEscrowCaptureNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrEscrowCaptureConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // CancelJobs godoc
@@ -657,7 +939,7 @@ func (h *JobHandler) generateCSV(jobs []models.Job) ([]byte, error) {
 		"delivery_address", "delivery_floor", "delivery_building_type", "delivery_walk_distance",
 		"distance_miles", "job_status", "pickup_date", "pickup_time_from", "pickup_time_to",
 		"delivery_date", "delivery_time_from", "delivery_time_to", "cut_amount", "payment_amount",
-		"weight_lbs", "volume_cu_ft",
+		"weight_lbs", "volume_cu_ft", "stops",
 	}
 
 	if err := writer.Write(headers); err != nil {
@@ -695,6 +977,7 @@ func (h *JobHandler) generateCSV(jobs []models.Job) ([]byte, error) {
 			fmt.Sprintf("%.2f", job.PaymentAmount),
 			fmt.Sprintf("%.2f", job.WeightLbs),
 			fmt.Sprintf("%.2f", job.VolumeCuFt),
+			h.formatStops(job.Stops),
 		}
 
 		if err := writer.Write(record); err != nil {
@@ -710,6 +993,37 @@ func (h *JobHandler) generateCSV(jobs []models.Job) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// formatStops выводит остановки в одну ячейку: "1. storage: address (floor 2, 2025-01-06 12:00-13:00); 2. ..."
+func (h *JobHandler) formatStops(stops []models.JobStop) string {
+	parts := make([]string, 0, len(stops))
+	for _, stop := range stops {
+		var details []string
+		if stop.Floor != nil {
+			details = append(details, fmt.Sprintf("floor %d", *stop.Floor))
+		}
+		if stop.BuildingType != "" {
+			details = append(details, stop.BuildingType)
+		}
+		if stop.WalkDistance != "" {
+			details = append(details, "walk "+stop.WalkDistance)
+		}
+		if stop.Date != nil {
+			window := stop.Date.Format("2006-01-02")
+			if stop.TimeFrom != "" {
+				window += fmt.Sprintf(" %s-%s", stop.TimeFrom, stop.TimeTo)
+			}
+			details = append(details, window)
+		}
+
+		part := fmt.Sprintf("%d. %s: %s", stop.StopOrder, stop.StopType, stop.Address)
+		if len(details) > 0 {
+			part += " (" + strings.Join(details, ", ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
 func (h *JobHandler) formatIntPtr(ptr *int) string {
 	if ptr == nil {
 		return ""
@@ -786,6 +1100,7 @@ func (h *JobHandler) GetUserWorkStats(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param limit query int false "Items limit" default(10)
+// @Param tz query string false "Viewer IANA time zone, e.g. America/Chicago (defaults to server time zone)"
 // @Success 200 {object} map[string]interface{} "Pending jobs"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 401 {object} map[string]string "Unauthorized"
@@ -804,7 +1119,13 @@ func (h *JobHandler) GetPendingJobs(c *gin.Context) {
 		limit = 10
 	}
 
-	jobs, err := h.jobService.GetPendingJobs(userID.(int64), limit)
+	viewerTZ, err := viewerTimeZone(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone", "details": err.Error()})
+		return
+	}
+
+	jobs, err := h.jobService.GetPendingJobs(userID.(int64), limit, viewerTZ)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -817,13 +1138,14 @@ func (h *JobHandler) GetPendingJobs(c *gin.Context) {
 
 // GetTodayScheduleJobs godoc
 // @Summary Get today's schedule jobs
-// @Description Retrieves today's jobs for the authenticated user (as executor) sorted by pickup time
+// @Description Retrieves today's jobs for the authenticated user (as executor) sorted by pickup time. "Today" is resolved in the viewer's time zone
 // @Tags Jobs
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param tz query string false "Viewer IANA time zone, e.g. America/Chicago (defaults to server time zone)"
 // @Success 200 {object} map[string]interface{} "Today's schedule jobs with pagination"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 401 {object} map[string]string "Unauthorized"
@@ -842,7 +1164,13 @@ func (h *JobHandler) GetTodayScheduleJobs(c *gin.Context) {
 		return
 	}
 
-	jobs, total, err := h.jobService.GetTodayScheduleJobs(userID.(int64), pagination.Page, pagination.Limit)
+	viewerTZ, err := viewerTimeZone(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone", "details": err.Error()})
+		return
+	}
+
+	jobs, total, err := h.jobService.GetTodayScheduleJobs(userID.(int64), pagination.Page, pagination.Limit, viewerTZ)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -861,6 +1189,16 @@ func (h *JobHandler) GetTodayScheduleJobs(c *gin.Context) {
 	})
 }
 
+// viewerTimeZone reads the optional tz query parameter (IANA name).
+// Without it the server time zone is used.
+func viewerTimeZone(c *gin.Context) (*time.Location, error) {
+	tz := c.Query("tz")
+	if tz == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(tz)
+}
+
 // UploadJobFiles godoc
 // @Summary Upload files for a claimed job
 // @Description Uploads files for a job that the user has claimed and changes status to pending
@@ -1172,9 +1510,9 @@ func (h *JobHandler) uploadFilesWithType(c *gin.Context, fileType string) {
 	}
 	fmt.Printf("User is confirmed executor\n")
 
-	if job.JobStatus != "claimed" && job.JobStatus != "pending" {
-		fmt.Printf("ERROR: Job status is '%s', expected 'claimed' or 'pending'\n", job.JobStatus)
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Job must be in claimed or pending status to upload files. Current status: %s", job.JobStatus)})
+	if job.JobStatus != "claimed" && job.JobStatus != "pending" && job.JobStatus != "in_progress" {
+		fmt.Printf("ERROR: Job status is '%s', expected 'claimed', 'pending' or 'in_progress'\n", job.JobStatus)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Job must be in claimed, pending or in_progress status to upload files. Current status: %s", job.JobStatus)})
 		return
 	}
 	fmt.Printf("Job status check passed\n")
@@ -1382,3 +1720,101 @@ func (h *JobHandler) GetJobFilesByType(c *gin.Context) {
 		"count":     len(files),
 	})
 }
+
+// RecordJobMilestone godoc
+// @Summary Record job progress milestone
+// @Description Records an executor milestone (arrived_pickup, loading_finished, departed_pickup, arrived_delivery, unload_finished) with the current timestamp. Milestones must be recorded in order; the first one moves the job to in_progress.
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Param milestone path string true "Milestone" Enums(arrived_pickup, loading_finished, departed_pickup, arrived_delivery, unload_finished)
+// @Success 201 {object} models.JobMilestone "Recorded milestone"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Router /jobs/{id}/milestones/{milestone}/ [post]
+func (h *JobHandler) RecordJobMilestone(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	milestone, err := h.jobService.RecordJobMilestone(jobID, userID.(int64), c.Param("milestone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to record milestone", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, milestone)
+}
+
+// GetJobTimeline godoc
+// @Summary Get job timeline
+// @Description Returns recorded milestones of a job compared with its scheduled pickup and delivery windows
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.JobTimeline "Actual vs scheduled timeline"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Router /jobs/{id}/milestones/ [get]
+func (h *JobHandler) GetJobTimeline(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	timeline, err := h.jobService.GetJobTimeline(jobID, userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Failed to get job timeline", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
+
+// GetMoverPerformance godoc
+// @Summary Get mover on-time performance
+// @Description Returns on-time pickup/delivery rates and average durations for a mover, based on recorded milestones
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param userId path int true "Mover user ID"
+// @Success 200 {object} models.MoverPerformance "On-time performance"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /jobs/performance/{userId}/ [get]
+func (h *JobHandler) GetMoverPerformance(c *gin.Context) {
+	moverID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	performance, err := h.jobService.GetMoverPerformance(moverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get performance", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, performance)
+}
@@ -0,0 +1,66 @@
+package job_template
+
+import (
+	"errors"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateJobFromTemplate godoc
+// @Summary      Create a job from template
+// @Description  Posts a new job from a template; only the dates are required. The job payment and processing fee are charged as for a regular posting.
+// @Tags         Job Templates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                                  true  "Template ID"
+// @Param        job   body      models.CreateJobFromTemplateRequest  true  "Dates and payment method"
+// @Success      201   {object}  models.CreateJobFromTemplateResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Failure      409   {object}  map[string]interface{} "Possible duplicate job, resend with confirm_duplicate=true"
+// @Router       /job-templates/{id}/create-job/ [post]
+func CreateJobFromTemplate(templateService service.JobTemplateService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+			return
+		}
+
+		var req models.CreateJobFromTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		response, err := templateService.CreateJobFromTemplate(c.Request.Context(), userID, templateID, &req)
+		if err != nil {
+			var duplicateErr *service.DuplicateJobError
+			if errors.As(err, &duplicateErr) {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":        "Possible duplicate job",
+					"details":      duplicateErr.Error(),
+					"duplicate_of": duplicateErr.Candidates[0].ID,
+					"duplicates":   duplicateErr.Candidates,
+				})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create job from template", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, response)
+	}
+}
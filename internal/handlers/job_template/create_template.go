@@ -0,0 +1,46 @@
+package job_template
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTemplate godoc
+// @Summary      Create a job template
+// @Description  Saves addresses, services, truck size and typical payout so the same job can be posted again by dates only
+// @Tags         Job Templates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        template  body      models.JobTemplateRequest  true  "Template data"
+// @Success      201       {object}  models.JobTemplate
+// @Failure      400       {object}  map[string]string
+// @Failure      401       {object}  map[string]string
+// @Router       /job-templates/ [post]
+func CreateTemplate(templateService service.JobTemplateService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		var req models.JobTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		template, err := templateService.CreateTemplate(c.Request.Context(), userID, &req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create template", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, template)
+	}
+}
@@ -0,0 +1,44 @@
+package job_template
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteTemplate godoc
+// @Summary      Delete job template
+// @Description  Deletes a job template and stops its recurring posts. Already posted jobs are kept.
+// @Tags         Job Templates
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "Template ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /job-templates/{id}/ [delete]
+func DeleteTemplate(templateService service.JobTemplateService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+			return
+		}
+
+		if err := templateService.DeleteTemplate(c.Request.Context(), userID, templateID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to delete template", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+	}
+}
@@ -0,0 +1,72 @@
+package job_template
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTemplates godoc
+// @Summary      Get job templates
+// @Description  Returns job templates of the authenticated user
+// @Tags         Job Templates
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Router       /job-templates/ [get]
+func GetTemplates(templateService service.JobTemplateService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		templates, err := templateService.GetTemplates(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get templates", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"templates": templates})
+	}
+}
+
+// GetTemplate godoc
+// @Summary      Get job template
+// @Description  Returns a single job template of the authenticated user
+// @Tags         Job Templates
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "Template ID"
+// @Success      200  {object}  models.JobTemplate
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /job-templates/{id}/ [get]
+func GetTemplate(templateService service.JobTemplateService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+			return
+		}
+
+		template, err := templateService.GetTemplate(c.Request.Context(), userID, templateID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, template)
+	}
+}
@@ -0,0 +1,88 @@
+package job_template
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetRecurrence godoc
+// @Summary      Set template recurrence
+// @Description  Enables automatic posting on a schedule, e.g. every Monday. Jobs are posted lead_days before the pickup date and charged to the given or default payment method.
+// @Tags         Job Templates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int                                  true  "Template ID"
+// @Param        recurrence  body      models.SetTemplateRecurrenceRequest  true  "Recurrence rule"
+// @Success      200         {object}  models.JobTemplate
+// @Failure      400         {object}  map[string]string
+// @Failure      401         {object}  map[string]string
+// @Router       /job-templates/{id}/recurrence/ [put]
+func SetRecurrence(templateService service.JobTemplateService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+			return
+		}
+
+		var req models.SetTemplateRecurrenceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		template, err := templateService.SetRecurrence(c.Request.Context(), userID, templateID, &req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to set recurrence", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, template)
+	}
+}
+
+// ClearRecurrence godoc
+// @Summary      Stop template recurrence
+// @Description  Disables automatic posting for the template
+// @Tags         Job Templates
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "Template ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /job-templates/{id}/recurrence/ [delete]
+func ClearRecurrence(templateService service.JobTemplateService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+			return
+		}
+
+		if err := templateService.ClearRecurrence(c.Request.Context(), userID, templateID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to stop recurrence", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Recurrence stopped"})
+	}
+}
@@ -0,0 +1,54 @@
+package job_template
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SaveJobAsTemplate godoc
+// @Summary      Save a job as template
+// @Description  Creates a template from one of the authenticated user's posted jobs
+// @Tags         Job Templates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        jobId     path      int                              true  "Job ID"
+// @Param        template  body      models.SaveJobAsTemplateRequest  true  "Template name"
+// @Success      201       {object}  models.JobTemplate
+// @Failure      400       {object}  map[string]string
+// @Failure      401       {object}  map[string]string
+// @Router       /job-templates/from-job/{jobId}/ [post]
+func SaveJobAsTemplate(templateService service.JobTemplateService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		jobID, err := strconv.ParseInt(c.Param("jobId"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		var req models.SaveJobAsTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		template, err := templateService.SaveJobAsTemplate(c.Request.Context(), userID, jobID, req.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to save job as template", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, template)
+	}
+}
@@ -0,0 +1,117 @@
+// internal/handlers/payment/add_bank_account.go
+package payment
+
+import (
+	"errors"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateBankAccountSetupIntent godoc
+// @Summary      Start bank account setup
+// @Description  Creates a Stripe SetupIntent for linking a US bank account (ACH debit). The client secret is used with Stripe.js collectBankAccountForSetup
+// @Tags         Payment
+// @Security     BearerAuth
+// @Produce      json
+// @Success      201  {object}  models.BankAccountSetupResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/bank-accounts/setup-intent [post]
+func CreateBankAccountSetupIntent(paymentService service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		setup, err := paymentService.CreateBankAccountSetupIntent(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to start bank account setup",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusCreated, setup)
+	}
+}
+
+// AddBankAccount godoc
+// @Summary      Add a bank account
+// @Description  Saves the US bank account confirmed through the SetupIntent. Accounts verified with microdeposits stay in pending_verification until the customer confirms the amounts
+// @Tags         Payment
+// @Security     BearerAuth
+// @Param        bank_account body models.AddBankAccountRequest true "Confirmed SetupIntent"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}  models.AddCardResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/bank-accounts [post]
+func AddBankAccount(paymentService service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req models.AddBankAccountRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"details": err.Error(),
+			})
+			return
+		}
+		if !strings.HasPrefix(req.SetupIntentID, "seti_") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setup intent ID format. Must start with 'seti_'"})
+			return
+		}
+
+		bankAccount, err := paymentService.AddBankAccount(c.Request.Context(), userID, req.SetupIntentID)
+		if err != nil {
+			if errors.Is(err, service.ErrBankAccountSetupInvalid) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Bank account setup is not complete",
+					"details": err.Error(),
+				})
+				return
+			}
+
+			if strings.Contains(err.Error(), "already added") {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":   "Payment method already added",
+					"details": err.Error(),
+				})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to add bank account",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		message := "Bank account added successfully"
+		if !bankAccount.IsUsable() {
+			message = "Bank account added, it can be used for payments once verification is complete"
+		}
+
+		c.JSON(http.StatusCreated, models.AddCardResponse{
+			PaymentMethod: models.NewPaymentMethodResponse(bankAccount),
+			Message:       message,
+			Success:       true,
+		})
+	}
+}
@@ -79,17 +79,9 @@ func AddCard(paymentService service.PaymentService) gin.HandlerFunc {
 
 		// Формируем ответ
 		response := models.AddCardResponse{
-			PaymentMethod: models.PaymentMethodResponse{
-				ID:           paymentMethod.ID,
-				CardLast4:    paymentMethod.CardLast4,
-				CardBrand:    paymentMethod.CardBrand,
-				CardExpMonth: paymentMethod.CardExpMonth,
-				CardExpYear:  paymentMethod.CardExpYear,
-				IsDefault:    paymentMethod.IsDefault,
-				CreatedAt:    paymentMethod.CreatedAt,
-			},
-			Message: "Payment method added successfully",
-			Success: true,
+			PaymentMethod: models.NewPaymentMethodResponse(paymentMethod),
+			Message:       "Payment method added successfully",
+			Success:       true,
 		}
 
 		c.JSON(http.StatusCreated, response)
@@ -0,0 +1,54 @@
+// internal/handlers/payment/connect_onboarding.go
+package payment
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartConnectOnboarding godoc
+// @Summary      Start payout account onboarding
+// @Description  Creates a Stripe Connect account for the mover (if needed) and returns a Stripe-hosted onboarding link
+// @Tags         Payment
+// @Security     BearerAuth
+// @Param        onboarding body models.ConnectOnboardingRequest true "Redirect URLs"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.ConnectOnboardingResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/connect/onboarding [post]
+func StartConnectOnboarding(payoutService service.PayoutService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req models.ConnectOnboardingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		response, err := payoutService.StartOnboarding(c.Request.Context(), userID, &req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to start payout onboarding",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -2,6 +2,7 @@
 package payment
 
 import (
+	"errors"
 	"moveshare/internal/models"
 	"moveshare/internal/service"
 	"moveshare/internal/utils"
@@ -16,6 +17,7 @@ import (
 // @Description  Creates a payment intent for a job using user's saved card
 // @Tags         Payment
 // @Security     BearerAuth
+// @Param        Idempotency-Key header string false "Unique key to safely retry the request"
 // @Param        payment body models.CreatePaymentRequest true "Payment data"
 // @Accept       json
 // @Produce      json
@@ -23,6 +25,8 @@ import (
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      422  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /payment/create-intent [post]
 func CreatePayment(paymentService service.PaymentService) gin.HandlerFunc {
@@ -42,6 +46,9 @@ func CreatePayment(paymentService service.PaymentService) gin.HandlerFunc {
 			})
 			return
 		}
+		if key := c.GetHeader(models.IdempotencyKeyHeader); key != "" {
+			req.IdempotencyKey = "create-intent:" + key
+		}
 
 		// Создаем платеж
 		response, err := paymentService.CreatePayment(c.Request.Context(), userID, &req)
@@ -56,6 +63,14 @@ func CreatePayment(paymentService service.PaymentService) gin.HandlerFunc {
 				return
 			}
 
+			if errors.Is(err, service.ErrPaymentMethodNotVerified) {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":   "Payment method is not verified",
+					"details": err.Error(),
+				})
+				return
+			}
+
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   "Failed to create payment",
 				"details": err.Error(),
@@ -0,0 +1,51 @@
+// internal/handlers/payment/download_invoice.go
+package payment
+
+import (
+	"fmt"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadInvoice godoc
+// @Summary      Download invoice
+// @Description  Downloads the invoice PDF of the authenticated user by invoice ID
+// @Tags         Payment
+// @Security     BearerAuth
+// @Produce      application/pdf
+// @Param        id   path  int  true  "Invoice ID"
+// @Success      200  {file}  file
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /payment/invoices/{id} [get]
+func DownloadInvoice(invoiceService service.InvoiceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized", "details": err.Error()})
+			return
+		}
+
+		invoiceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+			return
+		}
+
+		invoice, stream, err := invoiceService.DownloadInvoice(c.Request.Context(), userID, invoiceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Failed to get invoice", "details": err.Error()})
+			return
+		}
+		defer stream.Close()
+
+		c.DataFromReader(http.StatusOK, invoice.FileSize, "application/pdf", stream, map[string]string{
+			"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, invoice.FileName()),
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// internal/handlers/payment/earnings_statements.go
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEarningsStatement godoc
+// @Summary      Get monthly earnings statement
+// @Description  Gets the authenticated mover's earnings for a calendar month (UTC): every completed job with gross payout, platform commission, tips and net. Use format=pdf or format=csv to download the statement
+// @Tags         Payment
+// @Security     BearerAuth
+// @Param        year    query  int     false  "Statement year (current year by default)"
+// @Param        month   query  int     false  "Statement month 1-12 (current month by default)"
+// @Param        format  query  string  false  "Response format: json, pdf or csv" default(json)
+// @Produce      json
+// @Produce      application/pdf
+// @Produce      text/csv
+// @Success      200  {object}  models.EarningsStatement
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/earnings/statements [get]
+func GetEarningsStatement(statementService service.StatementService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		now := time.Now().UTC()
+		year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(now.Year())))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year parameter"})
+			return
+		}
+		month, err := strconv.Atoi(c.DefaultQuery("month", strconv.Itoa(int(now.Month()))))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month parameter"})
+			return
+		}
+		format, ok := statementFormat(c)
+		if !ok {
+			return
+		}
+
+		statement, err := statementService.GetMonthlyStatement(c.Request.Context(), userID, year, month)
+		if err != nil {
+			c.JSON(statementErrorStatus(err), gin.H{"error": "Failed to get earnings statement", "details": err.Error()})
+			return
+		}
+
+		switch format {
+		case models.StatementFormatPDF:
+			writeStatementFile(c, statement.FileName(format), "application/pdf", func() ([]byte, error) {
+				return utils.GenerateEarningsStatementPDF(statement)
+			})
+		case models.StatementFormatCSV:
+			writeStatementFile(c, statement.FileName(format), "text/csv", func() ([]byte, error) {
+				return utils.GenerateEarningsStatementCSV(statement)
+			})
+		default:
+			c.JSON(http.StatusOK, statement)
+		}
+	}
+}
+
+// GetEarningsTaxSummary godoc
+// @Summary      Get annual earnings tax summary
+// @Description  Gets the authenticated mover's 1099-style annual summary: payer and payee company details with tax ID, monthly and yearly totals of gross payouts, commission, tips and net. Use format=pdf or format=csv to download the summary
+// @Tags         Payment
+// @Security     BearerAuth
+// @Param        year    query  int     false  "Tax year (previous year by default)"
+// @Param        format  query  string  false  "Response format: json, pdf or csv" default(json)
+// @Produce      json
+// @Produce      application/pdf
+// @Produce      text/csv
+// @Success      200  {object}  models.AnnualEarningsSummary
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/earnings/tax-summary [get]
+func GetEarningsTaxSummary(statementService service.StatementService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().UTC().Year()-1)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year parameter"})
+			return
+		}
+		format, ok := statementFormat(c)
+		if !ok {
+			return
+		}
+
+		summary, err := statementService.GetAnnualSummary(c.Request.Context(), userID, year)
+		if err != nil {
+			c.JSON(statementErrorStatus(err), gin.H{"error": "Failed to get earnings tax summary", "details": err.Error()})
+			return
+		}
+
+		switch format {
+		case models.StatementFormatPDF:
+			writeStatementFile(c, summary.FileName(format), "application/pdf", func() ([]byte, error) {
+				return utils.GenerateAnnualEarningsSummaryPDF(summary)
+			})
+		case models.StatementFormatCSV:
+			writeStatementFile(c, summary.FileName(format), "text/csv", func() ([]byte, error) {
+				return utils.GenerateAnnualEarningsSummaryCSV(summary)
+			})
+		default:
+			c.JSON(http.StatusOK, summary)
+		}
+	}
+}
+
+// statementFormat читает параметр format; при неизвестном формате отвечает 400
+func statementFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", models.StatementFormatJSON)
+	switch format {
+	case models.StatementFormatJSON, models.StatementFormatPDF, models.StatementFormatCSV:
+		return format, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid format parameter",
+			"details": "Format must be json, pdf or csv",
+		})
+		return "", false
+	}
+}
+
+func writeStatementFile(c *gin.Context, fileName, contentType string, render func() ([]byte, error)) {
+	data, err := render()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate file", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func statementErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidStatementPeriod) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
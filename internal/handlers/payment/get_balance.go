@@ -0,0 +1,41 @@
+// internal/handlers/payment/get_balance.go
+package payment
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLedgerBalance godoc
+// @Summary      Get ledger balance
+// @Description  Gets the ledger balance of the authenticated user: the amount the platform currently owes the user (earned payouts not yet transferred)
+// @Tags         Payment
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  models.LedgerAccountBalance
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/balance [get]
+func GetLedgerBalance(ledgerService service.LedgerService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		balance, err := ledgerService.GetUserBalance(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get balance",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, balance)
+	}
+}
@@ -0,0 +1,54 @@
+// internal/handlers/payment/get_connect_account.go
+package payment
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetConnectAccount godoc
+// @Summary      Get payout account status
+// @Description  Returns the mover's Stripe Connect account status and payout balance
+// @Tags         Payment
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/connect/account [get]
+func GetConnectAccount(payoutService service.PayoutService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		account, err := payoutService.GetConnectAccount(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get payout account",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		balance, err := payoutService.GetPayoutBalance(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get payout balance",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"connected": account != nil,
+			"account":   account,
+			"balance":   balance,
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// internal/handlers/payment/get_credits.go
+package payment
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCredits godoc
+// @Summary      Get account credits
+// @Description  Gets the authenticated user's credit balance with recent credit transactions. Credits are applied automatically to the processing fee when a job is posted
+// @Tags         Payment
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  models.CreditBalance
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/credits [get]
+func GetCredits(promoService service.PromoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		balance, err := promoService.GetCreditBalance(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get credits",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, balance)
+	}
+}
@@ -0,0 +1,72 @@
+// internal/handlers/payment/get_invoices.go
+package payment
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInvoices godoc
+// @Summary      Get invoices
+// @Description  Gets the paginated invoices of the authenticated user with line items, taxes and current payment status
+// @Tags         Payment
+// @Security     BearerAuth
+// @Param        limit query int false "Limit number of invoices returned" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/invoices [get]
+func GetInvoices(invoiceService service.InvoiceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid limit parameter",
+				"details": "Limit must be between 1 and 100",
+			})
+			return
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid offset parameter",
+				"details": "Offset must be 0 or greater",
+			})
+			return
+		}
+
+		invoices, total, err := invoiceService.GetUserInvoices(c.Request.Context(), userID, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get invoices",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"invoices": invoices,
+			"pagination": gin.H{
+				"limit":  limit,
+				"offset": offset,
+				"count":  len(invoices),
+				"total":  total,
+			},
+			"success": true,
+		})
+	}
+}
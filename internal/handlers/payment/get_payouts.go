@@ -0,0 +1,82 @@
+// internal/handlers/payment/get_payouts.go
+package payment
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPayoutHistory godoc
+// @Summary      Get payout history
+// @Description  Gets the paginated payout ledger of the authenticated mover: gross job payment, platform commission and net amount per completed job
+// @Tags         Payment
+// @Security     BearerAuth
+// @Param        limit query int false "Limit number of payouts returned" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/payouts [get]
+func GetPayoutHistory(payoutService service.PayoutService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid limit parameter",
+				"details": "Limit must be between 1 and 100",
+			})
+			return
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid offset parameter",
+				"details": "Offset must be 0 or greater",
+			})
+			return
+		}
+
+		payouts, total, err := payoutService.GetUserPayouts(c.Request.Context(), userID, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get payout history",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		balance, err := payoutService.GetPayoutBalance(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get payout balance",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"payouts": payouts,
+			"balance": balance,
+			"pagination": gin.H{
+				"limit":  limit,
+				"offset": offset,
+				"count":  len(payouts),
+				"total":  total,
+			},
+			"success": true,
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// internal/handlers/payment/refunds.go
+package payment
+
+import (
+	"errors"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefundPayment godoc
+// @Summary      Refund a payment
+// @Description  Issues a full or partial refund of the authenticated contractor's payment for a canceled job. Omit amount_cents to refund the remaining balance
+// @Tags         Payment
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id      path  int                         true  "Payment ID"
+// @Param        refund  body  models.CreateRefundRequest  true  "Refund data"
+// @Success      201  {object}  models.PaymentRefund
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/payments/{id}/refunds [post]
+func RefundPayment(paymentService service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || paymentID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+			return
+		}
+
+		var req models.CreateRefundRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		refund, err := paymentService.RefundPayment(c.Request.Context(), userID, false, paymentID, &req)
+		if err != nil {
+			c.JSON(RefundErrorStatus(err), gin.H{
+				"error":   "Failed to refund payment",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusCreated, refund)
+	}
+}
+
+// GetPaymentRefunds godoc
+// @Summary      Get payment refunds
+// @Description  Gets refunds of the authenticated user's payment with their current status
+// @Tags         Payment
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path  int  true  "Payment ID"
+// @Success      200  {array}   models.PaymentRefund
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/payments/{id}/refunds [get]
+func GetPaymentRefunds(paymentService service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || paymentID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+			return
+		}
+
+		refunds, err := paymentService.GetPaymentRefunds(c.Request.Context(), userID, false, paymentID)
+		if err != nil {
+			c.JSON(RefundErrorStatus(err), gin.H{
+				"error":   "Failed to get refunds",
+				"details": err.Error(),
+			})
+			return
+		}
+		if refunds == nil {
+			refunds = []models.PaymentRefund{}
+		}
+
+		c.JSON(http.StatusOK, refunds)
+	}
+}
+
+// RefundErrorStatus - HTTP статус для ошибки возврата
+func RefundErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPaymentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRefundNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrRefundInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrPayoutTransferInProgress):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,6 +1,7 @@
 package payment
 
 import (
+	"errors"
 	"moveshare/internal/service"
 	"moveshare/internal/utils"
 	"net/http"
@@ -55,6 +56,14 @@ func SetDefaultCard(paymentService service.PaymentService) gin.HandlerFunc {
 				return
 			}
 
+			if errors.Is(err, service.ErrPaymentMethodNotVerified) {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":   "Payment method is not verified",
+					"details": "Bank accounts can be set as default once verification is complete",
+				})
+				return
+			}
+
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   "Failed to set default payment method",
 				"details": err.Error(),
@@ -0,0 +1,61 @@
+// internal/handlers/payment/stripe_webhook.go
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"moveshare/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBodyBytes - ограничение размера тела webhook (рекомендация Stripe)
+const maxWebhookBodyBytes = 65536
+
+// StripeWebhook godoc
+// @Summary      Stripe webhook
+// @Description  Receives Stripe events. The Stripe-Signature header is verified, and every event ID is stored so redelivered events are processed only once
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Param        Stripe-Signature header string true "Stripe signature"
+// @Success      200  {object}  map[string]bool
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/webhook [post]
+func StripeWebhook(paymentService service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes)
+		payload, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to read request body",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		err = paymentService.HandleWebhook(c.Request.Context(), payload, c.GetHeader("Stripe-Signature"))
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidWebhookSignature) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Invalid signature",
+					"details": err.Error(),
+				})
+				return
+			}
+
+			// 500 - Stripe доставит событие повторно
+			fmt.Printf("Stripe webhook processing failed: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to process webhook",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"received": true})
+	}
+}
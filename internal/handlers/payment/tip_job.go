@@ -0,0 +1,82 @@
+// internal/handlers/payment/tip_job.go
+package payment
+
+import (
+	"errors"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TipJob godoc
+// @Summary      Tip the crew of a completed job
+// @Description  Charges a tip to the authenticated contractor's saved card (default card if payment_method_id is omitted). The whole tip is paid out to the job executor with no commission
+// @Tags         Payment
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path  int                      true  "Job ID"
+// @Param        tip  body  models.CreateTipRequest  true  "Tip data"
+// @Success      201  {object}  models.CreatePaymentResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /payment/jobs/{id}/tip [post]
+func TipJob(paymentService service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || jobID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		var req models.CreateTipRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"details": err.Error(),
+			})
+			return
+		}
+		if key := c.GetHeader(models.IdempotencyKeyHeader); key != "" {
+			req.IdempotencyKey = "tip:" + key
+		}
+
+		response, err := paymentService.TipJob(c.Request.Context(), userID, jobID, &req)
+		if err != nil {
+			c.JSON(TipErrorStatus(err), gin.H{
+				"error":   "Failed to tip",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// TipErrorStatus - HTTP статус для ошибки чаевых
+func TipErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTipNotAllowed):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTipAlreadyPaid):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -26,7 +26,7 @@ func NewReviewHandler(reviewService *service.ReviewService, notificationService
 
 // CreateReview godoc
 // @Summary Create a new review
-// @Description Creates a new review for a completed job
+// @Description Creates a new review for a completed job. The job owner can add tip_cents to tip the executor from a saved card. The review is saved first; if the tip cannot be charged the response carries tip_error and the tip can be retried via POST /payment/jobs/{id}/tip
 // @Tags Reviews
 // @Accept json
 // @Produce json
@@ -0,0 +1,67 @@
+package subscription
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CancelSubscription godoc
+// @Summary      Cancel subscription
+// @Description  Cancels the subscription at the end of the paid period; the plan stays in effect until then
+// @Tags         Subscriptions
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  models.Subscription
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /subscriptions/cancel [post]
+func CancelSubscription(subscriptionService service.SubscriptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		sub, err := subscriptionService.CancelSubscription(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(subscriptionErrorStatus(err), gin.H{"error": "Failed to cancel subscription", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, sub)
+	}
+}
+
+// ResumeSubscription godoc
+// @Summary      Resume subscription
+// @Description  Undoes a pending cancellation so the subscription renews at the end of the period
+// @Tags         Subscriptions
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  models.Subscription
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /subscriptions/resume [post]
+func ResumeSubscription(subscriptionService service.SubscriptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		sub, err := subscriptionService.ResumeSubscription(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(subscriptionErrorStatus(err), gin.H{"error": "Failed to resume subscription", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, sub)
+	}
+}
@@ -0,0 +1,50 @@
+package subscription
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangePlan godoc
+// @Summary      Change subscription plan
+// @Description  Upgrades take effect at once and the prorated difference for the rest of the period is charged immediately. Downgrades take effect at once and the unused time is credited to the next invoice. Switching to free cancels the subscription at the end of the period
+// @Tags         Subscriptions
+// @Security     BearerAuth
+// @Param        plan body models.ChangePlanRequest true "New plan"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.Subscription
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      402  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /subscriptions/plan [patch]
+func ChangePlan(subscriptionService service.SubscriptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req models.ChangePlanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		sub, err := subscriptionService.ChangePlan(c.Request.Context(), userID, &req)
+		if err != nil {
+			c.JSON(subscriptionErrorStatus(err), gin.H{"error": "Failed to change plan", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, sub)
+	}
+}
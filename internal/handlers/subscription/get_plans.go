@@ -0,0 +1,22 @@
+package subscription
+
+import (
+	"moveshare/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlans godoc
+// @Summary      Get subscription plans
+// @Description  Returns the free plan and the paid company plans (pro, fleet) with their monthly price and entitlements
+// @Tags         Subscriptions
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /subscriptions/plans [get]
+func GetPlans(subscriptionService service.SubscriptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"plans": subscriptionService.GetPlans(c.Request.Context())})
+	}
+}
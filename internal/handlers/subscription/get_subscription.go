@@ -0,0 +1,37 @@
+package subscription
+
+import (
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSubscription godoc
+// @Summary      Get current subscription
+// @Description  Returns the company subscription (null on the free plan) and the entitlements in effect right now. A past due subscription keeps its plan during the grace period
+// @Tags         Subscriptions
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  models.SubscriptionResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /subscriptions/current [get]
+func GetSubscription(subscriptionService service.SubscriptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		response, err := subscriptionService.GetSubscription(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get subscription", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,73 @@
+package subscription
+
+import (
+	"errors"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Subscribe godoc
+// @Summary      Subscribe to a plan
+// @Description  Starts a monthly subscription to the pro or fleet plan, charged to the given or default payment method. If the first payment needs 3D Secure, the subscription stays incomplete and the client secret is returned for confirmation
+// @Tags         Subscriptions
+// @Security     BearerAuth
+// @Param        Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param        subscription body models.SubscribeRequest true "Plan and payment method"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}  models.SubscribeResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      402  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      422  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /subscriptions [post]
+func Subscribe(subscriptionService service.SubscriptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var req models.SubscribeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+		req.IdempotencyKey = c.GetHeader(models.IdempotencyKeyHeader)
+
+		response, err := subscriptionService.Subscribe(c.Request.Context(), userID, &req)
+		if err != nil {
+			c.JSON(subscriptionErrorStatus(err), gin.H{"error": "Failed to subscribe", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func subscriptionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPlanNotFound), errors.Is(err, service.ErrNoSubscription),
+		strings.Contains(err.Error(), "payment method not found"),
+		strings.Contains(err.Error(), "no default payment method found"):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrPlanUnavailable):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAlreadySubscribed), errors.Is(err, service.ErrPlanUnchanged),
+		errors.Is(err, service.ErrPaymentMethodNotVerified):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrSubscriptionPaymentFailed):
+		return http.StatusPaymentRequired
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"math"
 	"moveshare/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,4 +29,42 @@ func GetCommissionRate(adminService service.AdminService) gin.HandlerFunc {
 			"commission_rate": settings.CommissionRate,
 		})
 	}
-}
\ No newline at end of file
+}
+// GetFeeQuote handles previewing the job posting fee
+// @Summary Preview job posting fee
+// @Description Calculates the posting fee and commission for a job using the current fee schedule. Subscription plan discounts are applied when the job is posted
+// @Tags System
+// @Produce json
+// @Param payment_amount query number true "Job payout in dollars"
+// @Param distance_miles query number false "Route distance in miles"
+// @Param pickup_state query string false "Pickup state"
+// @Success 200 {object} models.FeeBreakdown
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /fee-quote [get]
+func GetFeeQuote(feeService service.FeeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		paymentAmount, err := strconv.ParseFloat(c.Query("payment_amount"), 64)
+		if err != nil || paymentAmount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment_amount"})
+			return
+		}
+
+		var distanceMiles float64
+		if raw := c.Query("distance_miles"); raw != "" {
+			distanceMiles, err = strconv.ParseFloat(raw, 64)
+			if err != nil || distanceMiles < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid distance_miles"})
+				return
+			}
+		}
+
+		breakdown, err := feeService.QuoteJobFee(c.Request.Context(), 0, int64(math.Round(paymentAmount*100)), distanceMiles, c.Query("pickup_state"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate fee", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, breakdown)
+	}
+}
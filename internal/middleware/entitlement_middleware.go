@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoadEntitlements кладет в контекст возможности плана подписки пользователя
+// (см. utils.GetEntitlementsFromContext). Подключается после AuthMiddleware
+func LoadEntitlements(subscriptionService service.SubscriptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := loadEntitlements(c, subscriptionService); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load plan entitlements", "details": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePlan пропускает только пользователей с планом не ниже plan
+func RequirePlan(subscriptionService service.SubscriptionService, plan string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entitlements, err := loadEntitlements(c, subscriptionService)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load plan entitlements", "details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		if !entitlements.AtLeast(plan) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":         "Upgrade your subscription plan to use this feature",
+				"details":       service.ErrPlanRequired.Error(),
+				"current_plan":  entitlements.Plan,
+				"required_plan": plan,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func loadEntitlements(c *gin.Context, subscriptionService service.SubscriptionService) (*models.Entitlements, error) {
+	if entitlements, err := utils.GetEntitlementsFromContext(c); err == nil {
+		return entitlements, nil
+	}
+
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	entitlements, err := subscriptionService.GetEntitlements(c.Request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Set(utils.EntitlementsContextKey, entitlements)
+	return entitlements, nil
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"moveshare/internal/models"
+	"moveshare/internal/service"
+	"moveshare/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyMiddleware обрабатывает заголовок Idempotency-Key: первый ответ сохраняется,
+// повтор с тем же ключом и телом получает сохраненный ответ, повтор с другим телом отклоняется.
+// Используется после AuthMiddleware; запросы без заголовка проходят как обычно
+func IdempotencyMiddleware(idempotencyService service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(models.IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body", "details": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, replay, err := idempotencyService.Begin(c.Request.Context(), userID, key, c.Request.Method, c.FullPath(), body)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyInvalid):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Idempotency-Key", "details": err.Error()})
+			case errors.Is(err, service.ErrIdempotencyKeyMismatch):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key reused with a different request", "details": err.Error()})
+			case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+				c.JSON(http.StatusConflict, gin.H{"error": "Request with this Idempotency-Key is in progress", "details": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key", "details": err.Error()})
+			}
+			c.Abort()
+			return
+		}
+
+		if replay {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.ResponseStatus, record.ResponseContentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// Ошибку сервера не запоминаем: клиент должен иметь возможность повторить запрос
+		if writer.Status() >= http.StatusInternalServerError {
+			if err := idempotencyService.Release(c.Request.Context(), record); err != nil {
+				fmt.Printf("Failed to release idempotency key %s: %v\n", key, err)
+			}
+			return
+		}
+
+		contentType := writer.Header().Get("Content-Type")
+		if err := idempotencyService.Complete(c.Request.Context(), record, writer.Status(), contentType, writer.body.Bytes()); err != nil {
+			fmt.Printf("Failed to save response for idempotency key %s: %v\n", key, err)
+		}
+	}
+}
+
+// recordingResponseWriter копирует тело ответа, чтобы его можно было сохранить
+type recordingResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -97,4 +97,16 @@ type SystemSettings struct {
 	NewUserApproval   string  `json:"new_user_approval" db:"new_user_approval"`
 	MinimumPayout     int     `json:"minimum_payout" db:"minimum_payout"`
 	JobExpirationDays int     `json:"job_expiration_days" db:"job_expiration_days"`
+
+	// Цены платных продвижений работ (в центах) и срок их действия
+	UrgentBoostPriceCents     int `json:"urgent_boost_price_cents" db:"urgent_boost_price_cents"`
+	PinBoostPriceCents        int `json:"pin_boost_price_cents" db:"pin_boost_price_cents"`
+	NearbyPushBoostPriceCents int `json:"nearby_push_boost_price_cents" db:"nearby_push_boost_price_cents"`
+	BoostDurationHours        int `json:"boost_duration_hours" db:"boost_duration_hours"`
+
+	// Расписание сборов за публикацию работы (nil - фиксированный сбор $15)
+	FeeSchedule *FeeSchedule `json:"fee_schedule,omitempty" db:"fee_schedule"`
+
+	// Ставка налога (%), включенного в сборы платформы в счетах
+	InvoiceTaxRate float64 `json:"invoice_tax_rate" db:"invoice_tax_rate"`
 }
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Типы платных продвижений работы
+const (
+	BoostTypeUrgent     = "urgent"      // бейдж "срочно" и приоритет в выдаче
+	BoostTypePinToTop   = "pin_to_top"  // закрепление вверху списка доступных работ
+	BoostTypeNearbyPush = "nearby_push" // уведомление перевозчикам в штате погрузки
+)
+
+// Статусы продвижения: платное ждет успешной оплаты в pending
+const (
+	BoostStatusPending = "pending"
+	BoostStatusActive  = "active"
+)
+
+func IsValidBoostType(boostType string) bool {
+	switch boostType {
+	case BoostTypeUrgent, BoostTypePinToTop, BoostTypeNearbyPush:
+		return true
+	}
+	return false
+}
+
+type JobBoost struct {
+	ID                    int64     `json:"id"`
+	JobID                 int64     `json:"job_id"`
+	BoostType             string    `json:"boost_type"`
+	PriceCents            int       `json:"price_cents"`
+	StripePaymentIntentID *string   `json:"stripe_payment_intent_id,omitempty"`
+	PurchasedBy           int64     `json:"purchased_by"`
+	IncludedInPlan        bool      `json:"included_in_plan"` // бесплатное продвижение из плана подписки
+	Status                string    `json:"status"`
+	StartsAt              time.Time `json:"starts_at"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// BoostOption - доступное продвижение с текущей ценой из настроек
+type BoostOption struct {
+	BoostType      string `json:"boost_type"`
+	PriceCents     int    `json:"price_cents"`
+	DurationHours  int    `json:"duration_hours"`
+	IncludedInPlan bool   `json:"included_in_plan,omitempty"` // не оплачивается, входит в план подписки
+}
+
+// BoostQuote - итоговая стоимость выбранных продвижений (без входящих в план)
+type BoostQuote struct {
+	Options    []BoostOption `json:"options"`
+	TotalCents int64         `json:"total_cents"`
+}
+
+type PurchaseBoostsRequest struct {
+	BoostTypes      []string `json:"boost_types" binding:"required,min=1"`
+	PaymentMethodID *int64   `json:"payment_method_id,omitempty"` // Optional, will use default if not provided
+}
+
+type PurchaseBoostsResponse struct {
+	Boosts          []JobBoost `json:"boosts"`
+	PaymentIntentID string     `json:"payment_intent_id"`
+	ClientSecret    string     `json:"client_secret"`
+	Status          string     `json:"status"`
+	TotalAmount     float64    `json:"total_amount"`
+}
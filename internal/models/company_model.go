@@ -16,6 +16,7 @@ type Company struct {
 	City               string    `json:"city"`
 	ZipCode            string    `json:"zip_code"`
 	DotNumber          string    `json:"dot_number"`
+	TaxID              string    `json:"tax_id"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 }
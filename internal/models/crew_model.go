@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+const (
+	CrewRoleDriver = "driver"
+	CrewRoleHelper = "helper"
+)
+
+// Статусы участника команды: приглашенный пользователь должен принять приглашение,
+// только после этого его можно назначать на работы
+const (
+	TeamMemberPending = "pending"
+	TeamMemberActive  = "active"
+)
+
+type TeamMember struct {
+	ID         int64      `json:"id"`
+	OwnerID    int64      `json:"owner_id"`
+	MemberID   int64      `json:"member_id"`
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`   // driver or helper
+	Status     string     `json:"status"` // pending or active
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TeamInvite - приглашение в команду компании, ожидающее ответа пользователя
+type TeamInvite struct {
+	OwnerID       int64     `json:"owner_id"`
+	OwnerUsername string    `json:"owner_username"`
+	CompanyName   string    `json:"company_name,omitempty"`
+	Role          string    `json:"role"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type CrewAssignment struct {
+	MemberID   int64     `json:"member_id"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	AssignedBy int64     `json:"assigned_by"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// JobCrew - команда и грузовик, назначенные на работу
+type JobCrew struct {
+	JobID   int64            `json:"job_id"`
+	TruckID *int64           `json:"truck_id"`
+	Truck   *Truck           `json:"truck,omitempty"`
+	Members []CrewAssignment `json:"members"`
+}
+
+// AssignedJob - работа в списке "мои назначения" водителя/помощника
+type AssignedJob struct {
+	Job
+	CrewRole   string    `json:"crew_role"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// Request DTOs
+type AddTeamMemberRequest struct {
+	Identifier string `json:"identifier" binding:"required" example:"driver@example.com"` // email or username
+	Role       string `json:"role" binding:"required,oneof=driver helper" example:"driver"`
+}
+
+type CrewMemberInput struct {
+	UserID int64  `json:"user_id" binding:"required" example:"42"`
+	Role   string `json:"role" binding:"required,oneof=driver helper" example:"driver"`
+}
+
+type AssignCrewRequest struct {
+	TruckID *int64            `json:"truck_id" example:"7"`
+	Members []CrewMemberInput `json:"members" binding:"required,min=1,dive"`
+}
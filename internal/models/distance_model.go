@@ -0,0 +1,27 @@
+package models
+
+const (
+	DistanceProviderGoogle  = "google"
+	DistanceProviderOffline = "offline"
+	DistanceProviderClient  = "client" // расстояние передано в запросе
+	DistanceProviderNone    = "none"
+)
+
+// RouteEndpoint - точка маршрута для расчета расстояния
+type RouteEndpoint struct {
+	Address string
+	City    string
+	State   string
+}
+
+type DistanceEstimate struct {
+	Meters          int    `json:"meters"`
+	DurationSeconds int    `json:"duration_seconds"`
+	Provider        string `json:"provider"`
+	Cached          bool   `json:"cached"`
+}
+
+func (e *DistanceEstimate) Miles() float64 {
+	// 1 meter = 0.000621371 miles
+	return float64(e.Meters) * 0.000621371
+}
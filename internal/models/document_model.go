@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+const JobFileTypeBillOfLading = "bill_of_lading"
+
+// BillOfLadingData - данные для генерации bill of lading / order for service
+type BillOfLadingData struct {
+	Number   string
+	IssuedAt time.Time
+	Job      *Job
+	Shipper  *Company // заказчик (contractor)
+	Carrier  *Company // перевозчик (executor)
+	Crew     *JobCrew
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Статусы списания escrow после выполнения работы: подрядчик подтверждает выполнение
+// или открывает спор, после подтверждения фоновый обработчик списывает оплату
+const (
+	EscrowCaptureAwaitingConfirmation = "awaiting_confirmation" // исполнитель отметил выполнение, ждем подрядчика
+	EscrowCaptureDisputed             = "disputed"              // подрядчик оспорил выполнение, решает администратор
+	EscrowCapturePending              = "capture_pending"       // выполнение подтверждено, списание ждет (повторной) попытки
+	EscrowCaptureCaptured             = "captured"              // оплата списана, выплата исполнителю начислена
+	EscrowCaptureReleased             = "released"              // спор решен в пользу подрядчика, блокировка снята
+	EscrowCaptureFailed               = "capture_failed"        // списание не удалось и не будет повторяться, решает администратор
+)
+
+// EscrowCapture - списание оплаты выполненной работы
+type EscrowCapture struct {
+	JobID         int64      `json:"job_id"`
+	ContractorID  int64      `json:"contractor_id"`
+	ExecutorID    int64      `json:"executor_id"`
+	Status        string     `json:"status"`
+	ConfirmBy     time.Time  `json:"confirm_by"` // без ответа подрядчика выполнение подтверждается в этот момент
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"`
+	AutoConfirmed bool       `json:"auto_confirmed"`
+	DisputedAt    *time.Time `json:"disputed_at,omitempty"`
+	DisputeReason string     `json:"dispute_reason,omitempty"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	CapturedAt    *time.Time `json:"captured_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// DisputeCompletionRequest - подрядчик оспаривает выполнение работы
+type DisputeCompletionRequest struct {
+	Reason string `json:"reason" binding:"required,max=2000"`
+}
+
+// ResolveCompletionDisputeRequest - решение администратора по спору или неудавшемуся списанию:
+// capture - списать оплату исполнителю, release - снять блокировку с карты подрядчика
+type ResolveCompletionDisputeRequest struct {
+	Resolution string `json:"resolution" binding:"required,oneof=capture release"`
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Типы расчета сбора за публикацию работы
+const (
+	FeeTypeFlat           = "flat"            // фиксированная сумма
+	FeeTypePercentage     = "percentage"      // процент от оплаты работы
+	FeeTypeTieredPayout   = "tiered_payout"   // ступени по сумме оплаты работы (в долларах)
+	FeeTypeTieredDistance = "tiered_distance" // ступени по расстоянию (в милях)
+)
+
+// DefaultProcessingFeeCents - сбор, если расписание не настроено ($15.00)
+const DefaultProcessingFeeCents = int64(1500)
+
+// FeeTier - ступень тарифа. UpTo - верхняя граница включительно (доллары или мили),
+// 0 у последней ступени означает "без ограничения"
+type FeeTier struct {
+	UpTo      float64 `json:"up_to"`
+	FlatCents int64   `json:"flat_cents"`
+	Percent   float64 `json:"percent"`
+}
+
+// FeeRule - правило расчета сбора
+type FeeRule struct {
+	Type      string    `json:"type" example:"flat"`
+	FlatCents int64     `json:"flat_cents,omitempty" example:"1500"`
+	Percent   float64   `json:"percent,omitempty"`
+	MinCents  int64     `json:"min_cents,omitempty"`
+	MaxCents  int64     `json:"max_cents,omitempty"`
+	Tiers     []FeeTier `json:"tiers,omitempty"`
+}
+
+// FeePromotion - промо-период со скидкой на сбор (для всех штатов или только для перечисленных)
+type FeePromotion struct {
+	Name            string    `json:"name" example:"Spring launch"`
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`
+	States          []string  `json:"states,omitempty"`
+	DiscountPercent float64   `json:"discount_percent,omitempty"`
+	FixedFeeCents   *int64    `json:"fixed_fee_cents,omitempty"`
+}
+
+// FeeSchedule - расписание сборов в системных настройках.
+// StateOverrides применяются по штату погрузки (ключ - код штата)
+type FeeSchedule struct {
+	Default        FeeRule            `json:"default"`
+	StateOverrides map[string]FeeRule `json:"state_overrides,omitempty"`
+	Promotions     []FeePromotion     `json:"promotions,omitempty"`
+}
+
+// DefaultFeeSchedule - прежний фиксированный сбор $15
+func DefaultFeeSchedule() *FeeSchedule {
+	return &FeeSchedule{
+		Default: FeeRule{Type: FeeTypeFlat, FlatCents: DefaultProcessingFeeCents},
+	}
+}
+
+func (r *FeeRule) Validate() error {
+	if r.MinCents < 0 || r.MaxCents < 0 || (r.MaxCents > 0 && r.MinCents > r.MaxCents) {
+		return fmt.Errorf("invalid min/max fee")
+	}
+
+	switch r.Type {
+	case FeeTypeFlat:
+		if r.FlatCents < 0 {
+			return fmt.Errorf("flat fee must be positive")
+		}
+	case FeeTypePercentage:
+		if r.Percent < 0 || r.Percent > 100 {
+			return fmt.Errorf("fee percent must be between 0 and 100")
+		}
+	case FeeTypeTieredPayout, FeeTypeTieredDistance:
+		if len(r.Tiers) == 0 {
+			return fmt.Errorf("tiered fee requires at least one tier")
+		}
+		for i, tier := range r.Tiers {
+			if tier.FlatCents < 0 || tier.Percent < 0 || tier.Percent > 100 {
+				return fmt.Errorf("tier %d has invalid fee", i+1)
+			}
+			if tier.UpTo == 0 && i != len(r.Tiers)-1 {
+				return fmt.Errorf("only the last tier can be unbounded")
+			}
+			if i > 0 && tier.UpTo != 0 && tier.UpTo <= r.Tiers[i-1].UpTo {
+				return fmt.Errorf("tiers must be sorted by up_to")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown fee type %q", r.Type)
+	}
+
+	return nil
+}
+
+func (s *FeeSchedule) Validate() error {
+	if err := s.Default.Validate(); err != nil {
+		return fmt.Errorf("default fee: %w", err)
+	}
+
+	for state, rule := range s.StateOverrides {
+		if strings.TrimSpace(state) == "" {
+			return fmt.Errorf("state override requires a state")
+		}
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("fee override for %s: %w", state, err)
+		}
+	}
+
+	for _, promo := range s.Promotions {
+		if strings.TrimSpace(promo.Name) == "" {
+			return fmt.Errorf("promotion requires a name")
+		}
+		if !promo.EndsAt.After(promo.StartsAt) {
+			return fmt.Errorf("promotion %q must end after it starts", promo.Name)
+		}
+		if promo.DiscountPercent < 0 || promo.DiscountPercent > 100 {
+			return fmt.Errorf("promotion %q discount must be between 0 and 100", promo.Name)
+		}
+		if promo.FixedFeeCents != nil && *promo.FixedFeeCents < 0 {
+			return fmt.Errorf("promotion %q fixed fee must be positive", promo.Name)
+		}
+	}
+
+	return nil
+}
+
+// FeeBreakdown - расчет сбора и комиссии для работы. Сохраняется в работе и в платежах
+type FeeBreakdown struct {
+	PayoutCents        int64    `json:"payout_cents"`
+	DistanceMiles      float64  `json:"distance_miles"`
+	FeeType            string   `json:"fee_type"`
+	AppliedRule        string   `json:"applied_rule"` // default или state:<код штата>
+	Tier               *FeeTier `json:"tier,omitempty"`
+	BaseFeeCents       int64    `json:"base_fee_cents"`
+	Promotion          string   `json:"promotion,omitempty"`
+	Plan               string   `json:"plan,omitempty"`                // план подписки заказчика со скидкой на сбор
+	PlanDiscountCents  int64    `json:"plan_discount_cents,omitempty"` // входит в DiscountCents
+	DiscountCents      int64    `json:"discount_cents"`
+	ProcessingFeeCents int64    `json:"processing_fee_cents"`
+	CommissionRate     float64  `json:"commission_rate"`
+	CommissionCents    int64    `json:"commission_cents"` // удерживается из выплаты исполнителю
+	MoverNetCents      int64    `json:"mover_net_cents"`
+	TotalChargeCents   int64    `json:"total_charge_cents"` // оплата работы + сбор
+
+	// Промокод и кредиты, примененные при публикации (уже вычтены из ProcessingFeeCents)
+	PromoCode              string `json:"promo_code,omitempty"`
+	PromoCodeDiscountCents int64  `json:"promo_code_discount_cents,omitempty"`
+	CreditsAppliedCents    int64  `json:"credits_applied_cents,omitempty"`
+}
+
+// JobProcessingFeeCents - сбор за публикацию; для работ без расчета - прежние $15
+func (b *FeeBreakdown) JobProcessingFeeCents() int64 {
+	if b == nil {
+		return DefaultProcessingFeeCents
+	}
+	return b.ProcessingFeeCents
+}
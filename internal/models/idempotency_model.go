@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// IdempotencyKeyHeader - заголовок, которым клиент помечает повторы одного и того же запроса
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyKey - сохраненный результат запроса с заголовком Idempotency-Key
+type IdempotencyKey struct {
+	ID                  int64      `json:"id"`
+	UserID              int64      `json:"user_id"`
+	Key                 string     `json:"idempotency_key"`
+	RequestMethod       string     `json:"request_method"`
+	RequestPath         string     `json:"request_path"`
+	RequestHash         string     `json:"request_hash"`
+	Status              string     `json:"status"`
+	ResponseStatus      int        `json:"response_status,omitempty"`
+	ResponseContentType string     `json:"response_content_type,omitempty"`
+	ResponseBody        []byte     `json:"-"`
+	CreatedAt           time.Time  `json:"created_at"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt           time.Time  `json:"expires_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Статусы счета по состоянию платежа
+const (
+	InvoiceStatusPaid              = "paid"
+	InvoiceStatusPartiallyRefunded = "partially_refunded"
+	InvoiceStatusRefunded          = "refunded"
+)
+
+// InvoiceLineItem - строка счета. Сумма указана без налога
+type InvoiceLineItem struct {
+	Description string `json:"description"`
+	AmountCents int64  `json:"amount_cents"`
+	Taxable     bool   `json:"taxable"`
+}
+
+// Invoice - счет по успешному платежу
+type Invoice struct {
+	ID            int64             `json:"id"`
+	InvoiceNumber string            `json:"invoice_number"`
+	PaymentID     int64             `json:"payment_id"`
+	UserID        int64             `json:"user_id"`
+	JobID         *int64            `json:"job_id,omitempty"`
+	LineItems     []InvoiceLineItem `json:"line_items"`
+	SubtotalCents int64             `json:"subtotal_cents"`
+	TaxRate       float64           `json:"tax_rate"`
+	TaxCents      int64             `json:"tax_cents"`
+	TotalCents    int64             `json:"total_cents"`
+	Currency      string            `json:"currency"`
+	Status        string            `json:"status"`
+	PaymentStatus string            `json:"payment_status"`
+	ObjectName    *string           `json:"-"`
+	FileSize      int64             `json:"file_size"`
+	EmailedTo     *string           `json:"emailed_to,omitempty"`
+	EmailedAt     *time.Time        `json:"emailed_at,omitempty"`
+	IssuedAt      time.Time         `json:"issued_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// FileName - имя PDF файла счета при скачивании
+func (i *Invoice) FileName() string {
+	return i.InvoiceNumber + ".pdf"
+}
+
+// InvoiceStatus определяет статус счета по сумме возврата платежа
+func InvoiceStatus(totalCents, amountRefundedCents int64) string {
+	switch {
+	case amountRefundedCents <= 0:
+		return InvoiceStatusPaid
+	case amountRefundedCents < totalCents:
+		return InvoiceStatusPartiallyRefunded
+	default:
+		return InvoiceStatusRefunded
+	}
+}
+
+// InvoiceData - данные для генерации PDF счета
+type InvoiceData struct {
+	Invoice *Invoice
+	Payment *Payment
+	BillTo  *Company
+	Email   string // email компании или пользователя, на который отправляется счет
+}
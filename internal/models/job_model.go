@@ -43,8 +43,9 @@ type Job struct {
 	DeliveryWalkDistance string `json:"delivery_walk_distance" db:"delivery_walk_distance"`
 
 	// Job info
-	DistanceMiles float64 `json:"distance_miles" db:"distance_miles"`
-	JobStatus     string  `json:"job_status" db:"job_status"`
+	DistanceMiles    float64 `json:"distance_miles" db:"distance_miles"`
+	DistanceProvider string  `json:"distance_provider" db:"distance_provider"` // google, offline, client or none
+	JobStatus        string  `json:"job_status" db:"job_status"`
 
 	// Schedule
 	PickupDate       time.Time `json:"pickup_date" db:"pickup_date"`
@@ -54,6 +55,14 @@ type Job struct {
 	DeliveryTimeFrom time.Time `json:"delivery_time_from" db:"delivery_time_from"`
 	DeliveryTimeTo   time.Time `json:"delivery_time_to" db:"delivery_time_to"`
 
+	// Zoned schedule: IANA zones of the locations and windows as absolute timestamps
+	PickupTimeZone      string     `json:"pickup_timezone" db:"pickup_timezone"`
+	DeliveryTimeZone    string     `json:"delivery_timezone" db:"delivery_timezone"`
+	PickupWindowStart   *time.Time `json:"pickup_window_start,omitempty" db:"pickup_window_start"`
+	PickupWindowEnd     *time.Time `json:"pickup_window_end,omitempty" db:"pickup_window_end"`
+	DeliveryWindowStart *time.Time `json:"delivery_window_start,omitempty" db:"delivery_window_start"`
+	DeliveryWindowEnd   *time.Time `json:"delivery_window_end,omitempty" db:"delivery_window_end"`
+
 	// Payment
 	CutAmount     float64 `json:"cut_amount" db:"cut_amount"`
 	PaymentAmount float64 `json:"payment_amount" db:"payment_amount"`
@@ -75,6 +84,15 @@ type Job struct {
 	ExecutorName     *string  `json:"executor_name,omitempty"`
 	ExecutorRating   *float64 `json:"executor_rating,omitempty"`
 
+	// Assigned crew and truck (only for detailed job view)
+	Crew *JobCrew `json:"crew,omitempty"`
+
+	// Intermediate stops between pickup and delivery, in route order
+	Stops []JobStop `json:"stops,omitempty"`
+
+	// Posting fee and platform commission applied when the job was posted
+	FeeBreakdown *FeeBreakdown `json:"fee_breakdown,omitempty" db:"fee_breakdown"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
@@ -130,6 +148,9 @@ type CreateJobRequest struct {
 
 	DistanceMiles float64 `json:"distance_miles"`
 
+	// Промежуточные остановки по порядку маршрута
+	Stops []JobStopRequest `json:"stops,omitempty" binding:"omitempty,max=10,dive"`
+
 	PickupDate       string `json:"pickup_date" binding:"required"`
 	PickupTimeFrom   string `json:"pickup_time_from" binding:"required"`
 	PickupTimeTo     string `json:"pickup_time_to" binding:"required"`
@@ -141,6 +162,9 @@ type CreateJobRequest struct {
 	PaymentAmount float64 `json:"payment_amount" binding:"required"`
 	WeightLbs     float64 `json:"weight_lbs"`
 	VolumeCuFt    float64 `json:"volume_cu_ft"`
+
+	// ConfirmDuplicate подтверждает публикацию, даже если найдена похожая работа
+	ConfirmDuplicate bool `json:"confirm_duplicate"`
 }
 
 // CreateJobWithPaymentRequest combines job creation with payment processing
@@ -174,6 +198,9 @@ type CreateJobWithPaymentRequest struct {
 
 	DistanceMiles float64 `json:"distance_miles"`
 
+	// Intermediate stops (storage unit, extra pickup or drop-off) in route order
+	Stops []JobStopRequest `json:"stops,omitempty" binding:"omitempty,max=10,dive"`
+
 	PickupDate       string `json:"pickup_date" binding:"required"`
 	PickupTimeFrom   string `json:"pickup_time_from" binding:"required"`
 	PickupTimeTo     string `json:"pickup_time_to" binding:"required"`
@@ -188,6 +215,15 @@ type CreateJobWithPaymentRequest struct {
 
 	// Payment information
 	PaymentMethodID *int64 `json:"payment_method_id,omitempty"` // Optional, will use default if not provided
+
+	// Set to true to post even if a near-identical job was posted recently
+	ConfirmDuplicate bool `json:"confirm_duplicate"`
+
+	// Optional paid upgrades: urgent, pin_to_top, nearby_push
+	Boosts []string `json:"boosts,omitempty"`
+
+	// Optional promo code for the processing fee; account credits are applied automatically
+	PromoCode string `json:"promo_code,omitempty" example:"SPRING25"`
 }
 
 type PaginationQuery struct {
@@ -213,6 +249,7 @@ type JobFilters struct {
 	TruckSize        *string  `form:"truck_size"`         // размер грузовика: "Small", "Medium", "Large"
 	PayoutMin        *float64 `form:"payout_min"`         // минимальная оплата
 	PayoutMax        *float64 `form:"payout_max"`         // максимальная оплата
+	MaxStops         *int     `form:"max_stops"`          // максимум промежуточных остановок (0 - только прямые)
 }
 
 // Validate валидирует параметры фильтрации
@@ -235,6 +272,10 @@ func (f *JobFilters) Validate() error {
 		return fmt.Errorf("max_distance must be greater than 0")
 	}
 
+	if f.MaxStops != nil && *f.MaxStops < 0 {
+		return fmt.Errorf("max_stops must not be negative")
+	}
+
 	// Валидация размера грузовика
 	if f.TruckSize != nil {
 		validSizes := map[string]bool{"Small": true, "Medium": true, "Large": true}
@@ -305,6 +346,18 @@ type AvailableJobDTO struct {
 	VolumeCuFt       float64   `json:"volume_cu_ft"`
 	PaymentAmount    float64   `json:"payment_amount"`
 	CutAmount        float64   `json:"cut_amount"`
+	IsUrgent         bool      `json:"is_urgent"`
+	IsPinned         bool      `json:"is_pinned"`
+	StopCount        int       `json:"stop_count"`
+	Stops            []JobStop `json:"stops,omitempty"`
+
+	// Zoned schedule, same as in Job
+	PickupTimeZone      string     `json:"pickup_timezone"`
+	DeliveryTimeZone    string     `json:"delivery_timezone"`
+	PickupWindowStart   *time.Time `json:"pickup_window_start,omitempty"`
+	PickupWindowEnd     *time.Time `json:"pickup_window_end,omitempty"`
+	DeliveryWindowStart *time.Time `json:"delivery_window_start,omitempty"`
+	DeliveryWindowEnd   *time.Time `json:"delivery_window_end,omitempty"`
 }
 
 // ExportJobsRequest представляет запрос на экспорт работ
@@ -327,6 +380,132 @@ type JobsStats struct {
 // UserWorkStats представляет статистику работ пользователя (на которые он откликался)
 type UserWorkStats struct {
 	CompletedJobs int     `json:"completed_jobs"`
-	Earnings      float64 `json:"earnings"`
+	Earnings      float64 `json:"earnings"` // включая чаевые
+	Tips          float64 `json:"tips"`
 	UpcomingJobs  int     `json:"upcoming_jobs"`
 }
+
+// Job milestones - фактические отметки исполнителя во время переезда
+const (
+	MilestoneArrivedPickup   = "arrived_pickup"
+	MilestoneLoadingFinished = "loading_finished"
+	MilestoneDepartedPickup  = "departed_pickup"
+	MilestoneArrivedDelivery = "arrived_delivery"
+	MilestoneUnloadFinished  = "unload_finished"
+)
+
+// JobMilestoneOrder - порядок, в котором должны фиксироваться отметки
+var JobMilestoneOrder = []string{
+	MilestoneArrivedPickup,
+	MilestoneLoadingFinished,
+	MilestoneDepartedPickup,
+	MilestoneArrivedDelivery,
+	MilestoneUnloadFinished,
+}
+
+func IsValidMilestone(milestone string) bool {
+	for _, m := range JobMilestoneOrder {
+		if m == milestone {
+			return true
+		}
+	}
+	return false
+}
+
+type JobMilestone struct {
+	ID         int64     `json:"id" db:"id"`
+	JobID      int64     `json:"job_id" db:"job_id"`
+	Milestone  string    `json:"milestone" db:"milestone"`
+	RecordedBy int64     `json:"recorded_by" db:"recorded_by"`
+	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// JobTimeline - фактическое выполнение работы в сравнении с расписанием
+type JobTimeline struct {
+	JobID      int64          `json:"job_id"`
+	JobStatus  string         `json:"job_status"`
+	Milestones []JobMilestone `json:"milestones"`
+
+	ScheduledPickupFrom   time.Time `json:"scheduled_pickup_from"`
+	ScheduledPickupTo     time.Time `json:"scheduled_pickup_to"`
+	ScheduledDeliveryFrom time.Time `json:"scheduled_delivery_from"`
+	ScheduledDeliveryTo   time.Time `json:"scheduled_delivery_to"`
+
+	// Опоздание относительно конца окна (0 если вовремя), nil пока отметки нет
+	PickupDelayMinutes   *float64 `json:"pickup_delay_minutes"`
+	DeliveryDelayMinutes *float64 `json:"delivery_delay_minutes"`
+	OnTimePickup         *bool    `json:"on_time_pickup"`
+	OnTimeDelivery       *bool    `json:"on_time_delivery"`
+
+	LoadingMinutes *float64 `json:"loading_minutes"`
+	TransitMinutes *float64 `json:"transit_minutes"`
+	UnloadMinutes  *float64 `json:"unload_minutes"`
+
+	// Оценка по расписанию (начало окна погрузки -> конец окна доставки) против факта
+	EstimatedTotalMinutes float64  `json:"estimated_total_minutes"`
+	ActualTotalMinutes    *float64 `json:"actual_total_minutes"`
+}
+
+// MoverPerformance - показатели пунктуальности исполнителя
+type MoverPerformance struct {
+	UserID                  int64   `json:"user_id"`
+	TrackedJobs             int     `json:"tracked_jobs"`
+	PickupsRecorded         int     `json:"pickups_recorded"`
+	OnTimePickups           int     `json:"on_time_pickups"`
+	OnTimePickupRate        float64 `json:"on_time_pickup_rate"`
+	DeliveriesRecorded      int     `json:"deliveries_recorded"`
+	OnTimeDeliveries        int     `json:"on_time_deliveries"`
+	OnTimeDeliveryRate      float64 `json:"on_time_delivery_rate"`
+	AvgPickupDelayMinutes   float64 `json:"avg_pickup_delay_minutes"`
+	AvgDeliveryDelayMinutes float64 `json:"avg_delivery_delay_minutes"`
+	AvgLoadingMinutes       float64 `json:"avg_loading_minutes"`
+	AvgTransitMinutes       float64 `json:"avg_transit_minutes"`
+	AvgUnloadMinutes        float64 `json:"avg_unload_minutes"`
+}
+
+// LocalizeWindows переводит окна в часовые пояса мест погрузки и доставки.
+// Если зона места неизвестна, окно показывается в зоне пользователя viewerTZ (nil - без изменений)
+func (j *Job) LocalizeWindows(viewerTZ *time.Location) {
+	j.PickupWindowStart = localizeWindow(j.PickupWindowStart, j.PickupTimeZone, viewerTZ)
+	j.PickupWindowEnd = localizeWindow(j.PickupWindowEnd, j.PickupTimeZone, viewerTZ)
+	j.DeliveryWindowStart = localizeWindow(j.DeliveryWindowStart, j.DeliveryTimeZone, viewerTZ)
+	j.DeliveryWindowEnd = localizeWindow(j.DeliveryWindowEnd, j.DeliveryTimeZone, viewerTZ)
+}
+
+// LocalizeWindows переводит окна так же, как Job.LocalizeWindows
+func (j *AvailableJobDTO) LocalizeWindows(viewerTZ *time.Location) {
+	j.PickupWindowStart = localizeWindow(j.PickupWindowStart, j.PickupTimeZone, viewerTZ)
+	j.PickupWindowEnd = localizeWindow(j.PickupWindowEnd, j.PickupTimeZone, viewerTZ)
+	j.DeliveryWindowStart = localizeWindow(j.DeliveryWindowStart, j.DeliveryTimeZone, viewerTZ)
+	j.DeliveryWindowEnd = localizeWindow(j.DeliveryWindowEnd, j.DeliveryTimeZone, viewerTZ)
+}
+
+func localizeWindow(t *time.Time, zone string, fallback *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	loc := fallback
+	if zone != "" {
+		if zoneLoc, err := time.LoadLocation(zone); err == nil {
+			loc = zoneLoc
+		}
+	}
+	if loc == nil {
+		return t
+	}
+	local := t.In(loc)
+	return &local
+}
+
+// DuplicateJobCandidate - недавно опубликованная работа, похожая на новую
+type DuplicateJobCandidate struct {
+	ID              int64     `json:"id"`
+	JobStatus       string    `json:"job_status"`
+	PickupAddress   string    `json:"pickup_address"`
+	DeliveryAddress string    `json:"delivery_address"`
+	PickupDate      time.Time `json:"pickup_date"`
+	DeliveryDate    time.Time `json:"delivery_date"`
+	PaymentAmount   float64   `json:"payment_amount"`
+	CreatedAt       time.Time `json:"created_at"`
+	URL             string    `json:"url"`
+}
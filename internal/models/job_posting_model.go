@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// JobStatusPendingPayment - работа создана, но не видна исполнителям, пока не подтверждена оплата
+const JobStatusPendingPayment = "pending_payment"
+
+// Статусы публикации работы (outbox): по записи фоновый обработчик создает платежи,
+// публикует работу после их подтверждения или откатывает публикацию
+const (
+	JobPostingPending              = "pending"               // платежи еще не созданы
+	JobPostingAwaitingConfirmation = "awaiting_confirmation" // платежи созданы, ждем авторизации и подтверждения (3DS)
+	JobPostingCanceling            = "canceling"             // публикация не прошла, отменяем платежи
+	JobPostingCompleted            = "completed"             // работа опубликована
+	JobPostingFailed               = "failed"                // работа удалена, платежи отменены
+)
+
+// JobPosting - запись outbox: создается в одной транзакции с работой и доводит ее оплату до конца
+type JobPosting struct {
+	ID              int64       `json:"id"`
+	JobID           *int64      `json:"job_id,omitempty"` // nil - работа удалена
+	UserID          int64       `json:"user_id"`
+	PaymentMethodID *int64      `json:"payment_method_id,omitempty"`
+	PromoCode       string      `json:"promo_code,omitempty"`
+	Boosts          *BoostQuote `json:"boosts,omitempty"`
+	TemplateName    string      `json:"template_name,omitempty"` // работа публикуется по шаблону
+	Status          string      `json:"status"`
+	Attempts        int         `json:"attempts"`
+	NextAttemptAt   time.Time   `json:"next_attempt_at"`
+	LastError       string      `json:"last_error,omitempty"`
+
+	EscrowPaymentIntentID string `json:"escrow_payment_intent_id,omitempty"`
+	EscrowClientSecret    string `json:"-"`
+	FeePaymentIntentID    string `json:"fee_payment_intent_id,omitempty"`
+	FeeClientSecret       string `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsFinal - обработка публикации закончена
+func (p *JobPosting) IsFinal() bool {
+	return p.Status == JobPostingCompleted || p.Status == JobPostingFailed
+}
+
+// JobPostingResult - состояние публикации работы с оплатой. Escrow и Payment с ClientSecret
+// передаются, пока клиенту нужно подтвердить платеж (3DS); Payment - nil, если сбор покрыт скидкой
+type JobPostingResult struct {
+	Job     *Job                   `json:"job"`
+	Posting *JobPosting            `json:"posting"`
+	Escrow  *CreatePaymentResponse `json:"escrow,omitempty"`
+	Payment *CreatePaymentResponse `json:"payment,omitempty"`
+	Boosts  []JobBoost             `json:"boosts,omitempty"`
+}
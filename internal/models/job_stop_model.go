@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Типы промежуточных остановок
+const (
+	StopTypePickup  = "pickup"  // дополнительная погрузка
+	StopTypeDropoff = "dropoff" // дополнительная выгрузка
+	StopTypeStorage = "storage" // склад / storage unit
+)
+
+// JobStop - промежуточная остановка между основной погрузкой и доставкой.
+// StopOrder начинается с 1 и задает порядок маршрута.
+type JobStop struct {
+	ID           int64      `json:"id"`
+	JobID        int64      `json:"job_id"`
+	StopOrder    int        `json:"stop_order"`
+	StopType     string     `json:"stop_type"`
+	Address      string     `json:"address"`
+	City         string     `json:"city"`
+	State        string     `json:"state"`
+	Floor        *int       `json:"floor"`
+	BuildingType string     `json:"building_type"`
+	WalkDistance string     `json:"walk_distance"`
+	Date         *time.Time `json:"date,omitempty"`
+	TimeFrom     string     `json:"time_from,omitempty"` // "15:04"
+	TimeTo       string     `json:"time_to,omitempty"`
+	TimeZone     string     `json:"timezone"`
+	WindowStart  *time.Time `json:"window_start,omitempty"`
+	WindowEnd    *time.Time `json:"window_end,omitempty"`
+}
+
+type JobStopRequest struct {
+	StopType     string `json:"stop_type" binding:"required,oneof=pickup dropoff storage"`
+	Address      string `json:"address" binding:"required"`
+	City         string `json:"city" binding:"required"`
+	State        string `json:"state" binding:"required"`
+	Floor        *int   `json:"floor"`
+	BuildingType string `json:"building_type"`
+	WalkDistance string `json:"walk_distance"`
+	Date         string `json:"date,omitempty" example:"2025-01-06"`
+	TimeFrom     string `json:"time_from,omitempty" example:"12:00"`
+	TimeTo       string `json:"time_to,omitempty" example:"13:00"`
+}
+
+// LocalizeWindow переводит окно остановки в ее часовой пояс
+func (s *JobStop) LocalizeWindow() {
+	if s.TimeZone == "" {
+		return
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return
+	}
+	if s.WindowStart != nil {
+		start := s.WindowStart.In(loc)
+		s.WindowStart = &start
+	}
+	if s.WindowEnd != nil {
+		end := s.WindowEnd.In(loc)
+		s.WindowEnd = &end
+	}
+}
@@ -0,0 +1,131 @@
+package models
+
+import "time"
+
+// JobTemplate - сохраненная работа для повторяющихся переездов (без дат)
+type JobTemplate struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+
+	JobType                       string  `json:"job_type"`
+	NumberOfBedrooms              string  `json:"number_of_bedrooms"`
+	PackingBoxes                  bool    `json:"packing_boxes"`
+	BulkyItems                    bool    `json:"bulky_items"`
+	InventoryList                 bool    `json:"inventory_list"`
+	Hoisting                      bool    `json:"hoisting"`
+	AdditionalServicesDescription *string `json:"additional_services_description"`
+	EstimatedCrewAssistants       string  `json:"estimated_crew_assistants"`
+	TruckSize                     string  `json:"truck_size"`
+
+	PickupAddress      string `json:"pickup_address"`
+	PickupCity         string `json:"pickup_city"`
+	PickupState        string `json:"pickup_state"`
+	PickupFloor        *int   `json:"pickup_floor"`
+	PickupBuildingType string `json:"pickup_building_type"`
+	PickupWalkDistance string `json:"pickup_walk_distance"`
+
+	DeliveryAddress      string `json:"delivery_address"`
+	DeliveryCity         string `json:"delivery_city"`
+	DeliveryState        string `json:"delivery_state"`
+	DeliveryFloor        *int   `json:"delivery_floor"`
+	DeliveryBuildingType string `json:"delivery_building_type"`
+	DeliveryWalkDistance string `json:"delivery_walk_distance"`
+
+	// Время окон в формате "15:04", доставка через DeliveryOffsetDays дней после погрузки
+	PickupTimeFrom     string `json:"pickup_time_from"`
+	PickupTimeTo       string `json:"pickup_time_to"`
+	DeliveryTimeFrom   string `json:"delivery_time_from"`
+	DeliveryTimeTo     string `json:"delivery_time_to"`
+	DeliveryOffsetDays int    `json:"delivery_offset_days"`
+
+	CutAmount     float64 `json:"cut_amount"`
+	PaymentAmount float64 `json:"payment_amount"`
+	WeightLbs     float64 `json:"weight_lbs"`
+	VolumeCuFt    float64 `json:"volume_cu_ft"`
+
+	Recurrence      *TemplateRecurrence `json:"recurrence,omitempty"`
+	LastPostedJobID *int64              `json:"last_posted_job_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TemplateRecurrence - правило автопубликации, например "каждый понедельник"
+type TemplateRecurrence struct {
+	Weekdays        []string   `json:"weekdays"`       // monday ... sunday
+	IntervalWeeks   int        `json:"interval_weeks"` // 1 - каждую неделю, 2 - через неделю
+	LeadDays        int        `json:"lead_days"`      // за сколько дней до погрузки публиковать
+	StartsOn        time.Time  `json:"starts_on"`
+	EndsOn          *time.Time `json:"ends_on,omitempty"`
+	NextPickupDate  *time.Time `json:"next_pickup_date,omitempty"`
+	PaymentMethodID *int64     `json:"payment_method_id,omitempty"`
+}
+
+type JobTemplateRequest struct {
+	Name string `json:"name" binding:"required"`
+
+	JobType                       string  `json:"job_type" binding:"required"`
+	NumberOfBedrooms              string  `json:"number_of_bedrooms"`
+	PackingBoxes                  bool    `json:"packing_boxes"`
+	BulkyItems                    bool    `json:"bulky_items"`
+	InventoryList                 bool    `json:"inventory_list"`
+	Hoisting                      bool    `json:"hoisting"`
+	AdditionalServicesDescription *string `json:"additional_services_description"`
+	EstimatedCrewAssistants       string  `json:"estimated_crew_assistants"`
+	TruckSize                     string  `json:"truck_size" binding:"required"`
+
+	PickupAddress      string `json:"pickup_address" binding:"required"`
+	PickupCity         string `json:"pickup_city" binding:"required"`
+	PickupState        string `json:"pickup_state" binding:"required"`
+	PickupFloor        *int   `json:"pickup_floor"`
+	PickupBuildingType string `json:"pickup_building_type"`
+	PickupWalkDistance string `json:"pickup_walk_distance"`
+
+	DeliveryAddress      string `json:"delivery_address" binding:"required"`
+	DeliveryCity         string `json:"delivery_city" binding:"required"`
+	DeliveryState        string `json:"delivery_state" binding:"required"`
+	DeliveryFloor        *int   `json:"delivery_floor"`
+	DeliveryBuildingType string `json:"delivery_building_type"`
+	DeliveryWalkDistance string `json:"delivery_walk_distance"`
+
+	PickupTimeFrom     string `json:"pickup_time_from" binding:"required" example:"08:00"`
+	PickupTimeTo       string `json:"pickup_time_to" binding:"required" example:"10:00"`
+	DeliveryTimeFrom   string `json:"delivery_time_from" binding:"required" example:"14:00"`
+	DeliveryTimeTo     string `json:"delivery_time_to" binding:"required" example:"18:00"`
+	DeliveryOffsetDays int    `json:"delivery_offset_days" binding:"min=0"`
+
+	CutAmount     float64 `json:"cut_amount"`
+	PaymentAmount float64 `json:"payment_amount" binding:"required"`
+	WeightLbs     float64 `json:"weight_lbs"`
+	VolumeCuFt    float64 `json:"volume_cu_ft"`
+}
+
+type SaveJobAsTemplateRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateJobFromTemplateRequest - для публикации по шаблону нужны только даты
+type CreateJobFromTemplateRequest struct {
+	PickupDate       string `json:"pickup_date" binding:"required" example:"2025-01-06"`
+	DeliveryDate     string `json:"delivery_date,omitempty" example:"2025-01-06"` // по умолчанию pickup_date + delivery_offset_days
+	PaymentMethodID  *int64 `json:"payment_method_id,omitempty"`
+	ConfirmDuplicate bool   `json:"confirm_duplicate"`
+}
+
+type SetTemplateRecurrenceRequest struct {
+	Weekdays        []string `json:"weekdays" binding:"required,min=1" example:"monday"`
+	IntervalWeeks   int      `json:"interval_weeks" binding:"min=0"`
+	LeadDays        int      `json:"lead_days" binding:"min=0"`
+	StartsOn        string   `json:"starts_on,omitempty" example:"2025-01-06"`
+	EndsOn          string   `json:"ends_on,omitempty" example:"2025-06-30"`
+	PaymentMethodID *int64   `json:"payment_method_id,omitempty"`
+}
+
+// CreateJobFromTemplateResponse - созданная работа, платеж за публикацию
+// и авторизация оплаты работы (escrow)
+type CreateJobFromTemplateResponse struct {
+	Job     *Job                   `json:"job"`
+	Payment *CreatePaymentResponse `json:"payment"`
+	Escrow  *CreatePaymentResponse `json:"escrow"`
+}
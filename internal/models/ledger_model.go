@@ -0,0 +1,172 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Типы счетов журнала
+const (
+	LedgerAccountAsset     = "asset"
+	LedgerAccountLiability = "liability"
+	LedgerAccountRevenue   = "revenue"
+	LedgerAccountExpense   = "expense"
+)
+
+// Счета платформы
+const (
+	LedgerStripeClearing  = "stripe_clearing"  // деньги на балансе Stripe
+	LedgerEscrow          = "escrow"           // списанная оплата работ до начисления исполнителю
+	LedgerPlatformRevenue = "platform_revenue" // сборы, продвижения и комиссии
+	LedgerRefunds         = "refunds"          // возвраты сборов платформы
+	LedgerPromoExpense    = "promo_expense"    // скидки по промокодам и начисленные кредиты
+	LedgerCustomerCredits = "customer_credits" // неиспользованные кредиты пользователей
+)
+
+// Источники транзакций журнала
+const (
+	LedgerRefPaymentCharge  = "payment_charge"
+	LedgerRefPaymentCapture = "payment_capture"
+	LedgerRefTipCharge      = "tip_charge"
+	LedgerRefRefund         = "refund"
+	LedgerRefPayout         = "payout"
+	LedgerRefPayoutRefund   = "payout_refund"
+	LedgerRefTransfer       = "transfer"
+
+	LedgerRefFeeRedemption         = "fee_redemption"
+	LedgerRefFeeRedemptionReversal = "fee_redemption_reversal"
+	LedgerRefCreditAdjustment      = "credit_adjustment"
+)
+
+var systemLedgerAccounts = map[string]LedgerAccount{
+	LedgerStripeClearing:  {Code: LedgerStripeClearing, Name: "Stripe clearing", Type: LedgerAccountAsset},
+	LedgerEscrow:          {Code: LedgerEscrow, Name: "Job payments held in escrow", Type: LedgerAccountLiability},
+	LedgerPlatformRevenue: {Code: LedgerPlatformRevenue, Name: "Platform revenue (fees and commissions)", Type: LedgerAccountRevenue},
+	LedgerRefunds:         {Code: LedgerRefunds, Name: "Refunds of platform charges", Type: LedgerAccountExpense},
+	LedgerPromoExpense:    {Code: LedgerPromoExpense, Name: "Promo code discounts and granted credits", Type: LedgerAccountExpense},
+	LedgerCustomerCredits: {Code: LedgerCustomerCredits, Name: "Unused customer credits", Type: LedgerAccountLiability},
+}
+
+// LedgerUserAccount - код счета пользователя (что платформа должна пользователю)
+func LedgerUserAccount(userID int64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// LedgerAccountForCode возвращает описание счета платформы или счета пользователя по коду
+func LedgerAccountForCode(code string) (*LedgerAccount, error) {
+	if account, ok := systemLedgerAccounts[code]; ok {
+		return &account, nil
+	}
+
+	if raw, ok := strings.CutPrefix(code, "user:"); ok {
+		userID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || userID <= 0 {
+			return nil, fmt.Errorf("invalid user ledger account: %s", code)
+		}
+		return &LedgerAccount{
+			Code:   code,
+			Name:   fmt.Sprintf("User %d balance", userID),
+			Type:   LedgerAccountLiability,
+			UserID: &userID,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown ledger account: %s", code)
+}
+
+type LedgerAccount struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LedgerEntry - проводка: ровно одна из сумм дебета/кредита больше нуля
+type LedgerEntry struct {
+	ID            int64     `json:"id"`
+	TransactionID int64     `json:"transaction_id"`
+	AccountCode   string    `json:"account_code"`
+	DebitCents    int64     `json:"debit_cents"`
+	CreditCents   int64     `json:"credit_cents"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LedgerTransaction struct {
+	ID            int64         `json:"id"`
+	ReferenceType string        `json:"reference_type"`
+	ReferenceID   string        `json:"reference_id"`
+	Description   string        `json:"description"`
+	Entries       []LedgerEntry `json:"entries"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// Debit и Credit добавляют проводку в транзакцию
+func (t *LedgerTransaction) Debit(accountCode string, cents int64) *LedgerTransaction {
+	t.Entries = append(t.Entries, LedgerEntry{AccountCode: accountCode, DebitCents: cents})
+	return t
+}
+
+func (t *LedgerTransaction) Credit(accountCode string, cents int64) *LedgerTransaction {
+	t.Entries = append(t.Entries, LedgerEntry{AccountCode: accountCode, CreditCents: cents})
+	return t
+}
+
+// Validate проверяет, что проводки корректны и транзакция сбалансирована
+func (t *LedgerTransaction) Validate() error {
+	if t.ReferenceType == "" || t.ReferenceID == "" {
+		return fmt.Errorf("ledger transaction reference is required")
+	}
+	if len(t.Entries) < 2 {
+		return fmt.Errorf("ledger transaction needs at least two entries")
+	}
+
+	var debit, credit int64
+	for _, e := range t.Entries {
+		if e.DebitCents < 0 || e.CreditCents < 0 || (e.DebitCents == 0) == (e.CreditCents == 0) {
+			return fmt.Errorf("invalid ledger entry for account %s", e.AccountCode)
+		}
+		debit += e.DebitCents
+		credit += e.CreditCents
+	}
+	if debit != credit {
+		return fmt.Errorf("unbalanced ledger transaction %s/%s: debit %d, credit %d", t.ReferenceType, t.ReferenceID, debit, credit)
+	}
+
+	return nil
+}
+
+// LedgerAccountBalance - обороты и остаток счета. Остаток считается
+// со стороны нормального сальдо: дебет для активов и расходов, кредит для обязательств и доходов
+type LedgerAccountBalance struct {
+	AccountCode  string `json:"account_code"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	UserID       *int64 `json:"user_id,omitempty"`
+	DebitCents   int64  `json:"debit_cents"`
+	CreditCents  int64  `json:"credit_cents"`
+	BalanceCents int64  `json:"balance_cents"`
+}
+
+func (b *LedgerAccountBalance) CalculateBalance() {
+	switch b.Type {
+	case LedgerAccountAsset, LedgerAccountExpense:
+		b.BalanceCents = b.DebitCents - b.CreditCents
+	default:
+		b.BalanceCents = b.CreditCents - b.DebitCents
+	}
+}
+
+// TrialBalance - оборотно-сальдовая ведомость по всем счетам журнала
+type TrialBalance struct {
+	Accounts               []LedgerAccountBalance `json:"accounts"`
+	TotalDebitCents        int64                  `json:"total_debit_cents"`
+	TotalCreditCents       int64                  `json:"total_credit_cents"`
+	UnbalancedTransactions []int64                `json:"unbalanced_transactions"`
+	Balanced               bool                   `json:"balanced"`
+	GeneratedAt            time.Time              `json:"generated_at"`
+}
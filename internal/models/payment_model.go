@@ -3,22 +3,52 @@ package models
 
 import "time"
 
-// UserPaymentMethod представляет сохраненную карту пользователя
+// UserPaymentMethod представляет сохраненную карту или банковский счет пользователя
 type UserPaymentMethod struct {
 	ID                    int64     `json:"id"`
 	UserID                int64     `json:"user_id"`
 	StripePaymentMethodID string    `json:"stripe_payment_method_id"`
 	StripeCustomerID      string    `json:"stripe_customer_id,omitempty"`
+	Type                  string    `json:"type"`
+	Status                string    `json:"status"`
 	CardLast4             string    `json:"card_last4"`
 	CardBrand             string    `json:"card_brand"`
 	CardExpMonth          int       `json:"card_exp_month"`
 	CardExpYear           int       `json:"card_exp_year"`
+	BankName              string    `json:"bank_name,omitempty"`
+	BankLast4             string    `json:"bank_last4,omitempty"`
+	BankAccountType       string    `json:"bank_account_type,omitempty"`
+	StripeSetupIntentID   string    `json:"-"`
+	StripeMandateID       string    `json:"-"`
 	IsDefault             bool      `json:"is_default"`
 	IsActive              bool      `json:"is_active"`
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
 }
 
+// Типы сохраненных способов оплаты (совпадают с типами PaymentMethod Stripe)
+const (
+	PaymentMethodTypeCard          = "card"
+	PaymentMethodTypeUSBankAccount = "us_bank_account" // ACH debit
+)
+
+// Статусы сохраненного способа оплаты
+const (
+	PaymentMethodStatusActive              = "active"
+	PaymentMethodStatusPendingVerification = "pending_verification" // банковский счет ждет подтверждения микродепозитами
+	PaymentMethodStatusVerificationFailed  = "verification_failed"
+)
+
+// IsBankAccount - способ оплаты является банковским счетом (ACH debit)
+func (pm *UserPaymentMethod) IsBankAccount() bool {
+	return pm.Type == PaymentMethodTypeUSBankAccount
+}
+
+// IsUsable - способом оплаты можно платить и сделать его основным
+func (pm *UserPaymentMethod) IsUsable() bool {
+	return pm.IsActive && (pm.Status == "" || pm.Status == PaymentMethodStatusActive)
+}
+
 // Payment представляет платеж
 type Payment struct {
 	ID                    int64     `json:"id"`
@@ -34,6 +64,84 @@ type Payment struct {
 	FailureReason         string    `json:"failure_reason,omitempty"`
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
+
+	// Escrow: авторизация и списание отслеживаются отдельно от статуса Stripe
+	CaptureMethod             string     `json:"capture_method"`
+	AuthorizationStatus       string     `json:"authorization_status"`
+	CaptureStatus             string     `json:"capture_status"`
+	AuthorizedAt              *time.Time `json:"authorized_at,omitempty"`
+	AuthorizationExpiresAt    *time.Time `json:"authorization_expires_at,omitempty"`
+	CapturedAt                *time.Time `json:"captured_at,omitempty"`
+	AmountCapturedCents       int64      `json:"amount_captured_cents"`
+	ReleasedAt                *time.Time `json:"released_at,omitempty"`
+	RefundedAt                *time.Time `json:"refunded_at,omitempty"`
+	ReauthorizedFromPaymentID *int64     `json:"reauthorized_from_payment_id,omitempty"`
+	AmountRefundedCents       int64      `json:"amount_refunded_cents"`
+
+	// Расчет сбора и комиссии работы, к которой относится платеж
+	FeeBreakdown *FeeBreakdown `json:"fee_breakdown,omitempty"`
+
+	// Чаевые: платеж целиком начисляется исполнителю работы
+	PaymentType    string `json:"payment_type"`
+	TipRecipientID *int64 `json:"tip_recipient_id,omitempty"`
+
+	// Возвраты по платежу (заполняется в истории платежей)
+	Refunds []PaymentRefund `json:"refunds,omitempty"`
+}
+
+// RefundableCents - сколько списано по платежу (escrow - только после списания)
+func (p *Payment) RefundableCents() int64 {
+	if p.CaptureMethod == PaymentCaptureManual {
+		return p.AmountCapturedCents
+	}
+	return p.AmountCents
+}
+
+// IsTip - платеж является чаевыми исполнителю
+func (p *Payment) IsTip() bool {
+	return p.PaymentType == PaymentTypeTip
+}
+
+// Типы платежей
+const (
+	PaymentTypeCharge = "charge" // сборы, продвижения и оплата работ
+	PaymentTypeTip    = "tip"    // чаевые исполнителю после выполнения работы
+)
+
+// Платежные шлюзы (PAYMENT_GATEWAY)
+const (
+	PaymentGatewayStripe = "stripe"
+	PaymentGatewayFake   = "fake" // в памяти, для локального запуска и тестов
+)
+
+const (
+	PaymentCaptureAutomatic = "automatic"
+	PaymentCaptureManual    = "manual" // escrow: списание после выполнения работы
+)
+
+// Состояния авторизации escrow-платежа
+const (
+	AuthorizationStatusNone       = "none"
+	AuthorizationStatusPending    = "pending" // требуется действие клиента (3DS)
+	AuthorizationStatusAuthorized = "authorized"
+	AuthorizationStatusReleased   = "released"
+	AuthorizationStatusExpired    = "expired"
+	AuthorizationStatusReplaced   = "replaced" // заменена повторной авторизацией
+	AuthorizationStatusFailed     = "failed"
+)
+
+// Состояния списания escrow-платежа
+const (
+	CaptureStatusNone       = "none"
+	CaptureStatusUncaptured = "uncaptured"
+	CaptureStatusCaptured   = "captured"
+	CaptureStatusRefunded   = "refunded"
+	CaptureStatusCanceled   = "canceled"
+)
+
+// IsEscrow - платеж авторизован с ручным списанием
+func (p *Payment) IsEscrow() bool {
+	return p.CaptureMethod == PaymentCaptureManual
 }
 
 // User расширение для Stripe Customer ID
@@ -44,13 +152,36 @@ type UserWithStripe struct {
 
 // DTO для API ответов
 type PaymentMethodResponse struct {
-	ID           int64     `json:"id"`
-	CardLast4    string    `json:"card_last4"`
-	CardBrand    string    `json:"card_brand"`
-	CardExpMonth int       `json:"card_exp_month"`
-	CardExpYear  int       `json:"card_exp_year"`
-	IsDefault    bool      `json:"is_default"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID              int64     `json:"id"`
+	Type            string    `json:"type"`
+	Status          string    `json:"status"`
+	CardLast4       string    `json:"card_last4,omitempty"`
+	CardBrand       string    `json:"card_brand,omitempty"`
+	CardExpMonth    int       `json:"card_exp_month,omitempty"`
+	CardExpYear     int       `json:"card_exp_year,omitempty"`
+	BankName        string    `json:"bank_name,omitempty"`
+	BankLast4       string    `json:"bank_last4,omitempty"`
+	BankAccountType string    `json:"bank_account_type,omitempty"`
+	IsDefault       bool      `json:"is_default"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// NewPaymentMethodResponse формирует ответ API по сохраненному способу оплаты
+func NewPaymentMethodResponse(pm *UserPaymentMethod) PaymentMethodResponse {
+	return PaymentMethodResponse{
+		ID:              pm.ID,
+		Type:            pm.Type,
+		Status:          pm.Status,
+		CardLast4:       pm.CardLast4,
+		CardBrand:       pm.CardBrand,
+		CardExpMonth:    pm.CardExpMonth,
+		CardExpYear:     pm.CardExpYear,
+		BankName:        pm.BankName,
+		BankLast4:       pm.BankLast4,
+		BankAccountType: pm.BankAccountType,
+		IsDefault:       pm.IsDefault,
+		CreatedAt:       pm.CreatedAt,
+	}
 }
 
 type AddCardRequest struct {
@@ -63,11 +194,48 @@ type AddCardResponse struct {
 	Success       bool                  `json:"success"`
 }
 
+// BankAccountSetupResponse - SetupIntent для привязки банковского счета через Stripe.js
+// (collectBankAccountForSetup и confirmUsBankAccountSetup)
+type BankAccountSetupResponse struct {
+	SetupIntentID string `json:"setup_intent_id"`
+	ClientSecret  string `json:"client_secret"`
+	Status        string `json:"status"`
+}
+
+// AddBankAccountRequest - SetupIntent, подтвержденный клиентом
+type AddBankAccountRequest struct {
+	SetupIntentID string `json:"setup_intent_id" binding:"required" example:"seti_1234567890"`
+}
+
 type CreatePaymentRequest struct {
 	JobID           *int64 `json:"job_id,omitempty" example:"123"`                 // Опционально для отдельных платежей
 	PaymentMethodID *int64 `json:"payment_method_id,omitempty" example:"456"`      // Опционально, если не указано - берем default
 	AmountCents     int64  `json:"amount_cents" binding:"required" example:"2999"` // $29.99
 	Description     string `json:"description,omitempty" example:"Payment for job posting"`
+
+	FeeBreakdown *FeeBreakdown `json:"-"` // заполняется при публикации работы
+	// Заполняются для чаевых исполнителю
+	PaymentType    string `json:"-"`
+	TipRecipientID *int64 `json:"-"`
+	// Ключ идемпотентности клиента (заголовок Idempotency-Key) с префиксом операции
+	IdempotencyKey string `json:"-"`
+}
+
+// CreateTipRequest - чаевые исполнителю выполненной работы
+type CreateTipRequest struct {
+	AmountCents     int64  `json:"amount_cents" binding:"required,min=100,max=100000" example:"2000"` // $1 - $1000
+	PaymentMethodID *int64 `json:"payment_method_id,omitempty" example:"456"`                         // Опционально, если не указано - берем default
+
+	// Ключ идемпотентности клиента (заголовок Idempotency-Key) с префиксом операции
+	IdempotencyKey string `json:"-"`
+}
+
+// JobParticipants - стороны работы, по которой проводится платеж
+type JobParticipants struct {
+	JobID        int64
+	ContractorID int64
+	ExecutorID   *int64
+	JobStatus    string
 }
 
 type CreatePaymentResponse struct {
@@ -88,3 +256,81 @@ type ConfirmPaymentResponse struct {
 	Success   bool   `json:"success"`
 	Message   string `json:"message"`
 }
+
+// Статусы обработки webhook-событий Stripe
+const (
+	StripeEventStatusReceived   = "received"
+	StripeEventStatusProcessing = "processing"
+	StripeEventStatusProcessed  = "processed"
+	StripeEventStatusIgnored    = "ignored" // тип события не обрабатывается
+	StripeEventStatusFailed     = "failed"
+)
+
+// StripeEvent - сохраненное webhook-событие Stripe
+type StripeEvent struct {
+	ID            int64      `json:"id"`
+	StripeEventID string     `json:"stripe_event_id"`
+	EventType     string     `json:"event_type"`
+	Payload       []byte     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     *string    `json:"last_error,omitempty"`
+	ReceivedAt    time.Time  `json:"received_at"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+}
+
+// Причины возврата
+const (
+	RefundReasonRequestedByCustomer = "requested_by_customer"
+	RefundReasonDuplicate           = "duplicate"
+	RefundReasonFraudulent          = "fraudulent"
+	RefundReasonJobCanceled         = "job_canceled"
+	RefundReasonDispute             = "dispute"
+	RefundReasonExternal            = "external" // сделан в Stripe Dashboard
+)
+
+// Статусы возврата (совпадают со статусами Stripe)
+const (
+	RefundStatusPending        = "pending"
+	RefundStatusRequiresAction = "requires_action"
+	RefundStatusSucceeded      = "succeeded"
+	RefundStatusFailed         = "failed"
+	RefundStatusCanceled       = "canceled"
+)
+
+// PaymentRefund - полный или частичный возврат по платежу
+type PaymentRefund struct {
+	ID             int64     `json:"id"`
+	PaymentID      int64     `json:"payment_id"`
+	StripeRefundID *string   `json:"stripe_refund_id,omitempty"`
+	AmountCents    int64     `json:"amount_cents"`
+	Currency       string    `json:"currency"`
+	Reason         string    `json:"reason"`
+	Note           string    `json:"note,omitempty"`
+	Status         string    `json:"status"`
+	FailureReason  string    `json:"failure_reason,omitempty"`
+	RequestedBy    *int64    `json:"requested_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type CreateRefundRequest struct {
+	AmountCents int64  `json:"amount_cents,omitempty" binding:"min=0" example:"1500"` // 0 - вернуть весь остаток
+	Reason      string `json:"reason" binding:"required,oneof=requested_by_customer duplicate fraudulent job_canceled dispute" example:"job_canceled"`
+	Note        string `json:"note,omitempty" example:"Job canceled by contractor"`
+}
+
+// PaymentDispute - спор (chargeback) по платежу
+type PaymentDispute struct {
+	ID                    int64      `json:"id"`
+	PaymentID             *int64     `json:"payment_id,omitempty"`
+	StripeDisputeID       string     `json:"stripe_dispute_id"`
+	StripePaymentIntentID string     `json:"stripe_payment_intent_id,omitempty"`
+	AmountCents           int64      `json:"amount_cents"`
+	Currency              string     `json:"currency"`
+	Reason                string     `json:"reason"`
+	Status                string     `json:"status"`
+	EvidenceDueBy         *time.Time `json:"evidence_due_by,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
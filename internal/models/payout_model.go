@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Статусы записей журнала выплат
+const (
+	PayoutStatusPending     = "pending"    // ждет минимальной суммы или подключения Stripe Connect
+	PayoutStatusProcessing  = "processing" // перевод выполняется
+	PayoutStatusTransferred = "transferred"
+	PayoutStatusCanceled    = "canceled" // оплата работы полностью возвращена до перевода
+)
+
+// ConnectAccount - Stripe Connect аккаунт исполнителя
+type ConnectAccount struct {
+	UserID           int64     `json:"user_id"`
+	StripeAccountID  string    `json:"stripe_account_id"`
+	DetailsSubmitted bool      `json:"details_submitted"`
+	PayoutsEnabled   bool      `json:"payouts_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Payout - запись журнала выплат исполнителю за выполненную работу
+type Payout struct {
+	ID               int64      `json:"id"`
+	UserID           int64      `json:"user_id"`
+	JobID            *int64     `json:"job_id,omitempty"`
+	PaymentID        *int64     `json:"payment_id,omitempty"`
+	GrossAmountCents int64      `json:"gross_amount_cents"`
+	CommissionRate   float64    `json:"commission_rate"`
+	CommissionCents  int64      `json:"commission_cents"`
+	NetAmountCents   int64      `json:"net_amount_cents"`
+	Currency         string     `json:"currency"`
+	Status           string     `json:"status"`
+	StripeTransferID *string    `json:"stripe_transfer_id,omitempty"`
+	FailureReason    *string    `json:"failure_reason,omitempty"`
+	TransferredAt    *time.Time `json:"transferred_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// PayoutBalance - сводка по выплатам исполнителя
+type PayoutBalance struct {
+	PendingCents       int64 `json:"pending_cents"`
+	TransferredCents   int64 `json:"transferred_cents"`
+	MinimumPayoutCents int64 `json:"minimum_payout_cents"`
+}
+
+type ConnectOnboardingRequest struct {
+	RefreshURL string `json:"refresh_url" binding:"required,url" example:"https://app.moveshare.com/settings/payouts?refresh=1"`
+	ReturnURL  string `json:"return_url" binding:"required,url" example:"https://app.moveshare.com/settings/payouts"`
+}
+
+type ConnectOnboardingResponse struct {
+	StripeAccountID string    `json:"stripe_account_id"`
+	OnboardingURL   string    `json:"onboarding_url"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Типы скидки промокода
+const (
+	PromoDiscountPercentage = "percentage" // процент от сбора
+	PromoDiscountFixed      = "fixed"      // фиксированная сумма
+)
+
+// Статусы скидки на сбор
+const (
+	FeeRedemptionApplied  = "applied"
+	FeeRedemptionReversed = "reversed" // публикация работы не прошла
+)
+
+// Причины движения кредитов
+const (
+	CreditReasonAdminGrant     = "admin_grant"
+	CreditReasonAdminDeduction = "admin_deduction"
+	CreditReasonJobFee         = "job_fee"
+	CreditReasonJobFeeReversal = "job_fee_reversal"
+)
+
+// MinimumChargeCents - минимальная сумма платежа Stripe ($0.50).
+// Меньший остаток сбора после скидок не списывается
+const MinimumChargeCents = int64(50)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{2,49}$`)
+
+// NormalizePromoCode - коды не зависят от регистра и хранятся в верхнем
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// PromoCode - промокод на сбор за публикацию работы
+type PromoCode struct {
+	ID             int64      `json:"id"`
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	DiscountType   string     `json:"discount_type"`
+	PercentOff     float64    `json:"percent_off,omitempty"`
+	AmountOffCents int64      `json:"amount_off_cents,omitempty"`
+	MaxRedemptions *int       `json:"max_redemptions,omitempty"` // на все аккаунты
+	PerUserLimit   *int       `json:"per_user_limit,omitempty"`
+	FirstJobOnly   bool       `json:"first_job_only"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	IsActive       bool       `json:"is_active"`
+	CreatedBy      *int64     `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Статистика использования
+	TimesRedeemed      int   `json:"times_redeemed"`
+	TotalDiscountCents int64 `json:"total_discount_cents"`
+}
+
+func (p *PromoCode) Validate() error {
+	if !promoCodePattern.MatchString(p.Code) {
+		return fmt.Errorf("code must be 3-50 letters, digits, '-' or '_'")
+	}
+
+	switch p.DiscountType {
+	case PromoDiscountPercentage:
+		if p.PercentOff <= 0 || p.PercentOff > 100 {
+			return fmt.Errorf("percent_off must be between 0 and 100")
+		}
+	case PromoDiscountFixed:
+		if p.AmountOffCents <= 0 {
+			return fmt.Errorf("amount_off_cents must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown discount type %q", p.DiscountType)
+	}
+
+	if p.MaxRedemptions != nil && *p.MaxRedemptions <= 0 {
+		return fmt.Errorf("max_redemptions must be positive")
+	}
+	if p.PerUserLimit != nil && *p.PerUserLimit <= 0 {
+		return fmt.Errorf("per_user_limit must be positive")
+	}
+	if p.StartsAt != nil && p.ExpiresAt != nil && !p.ExpiresAt.After(*p.StartsAt) {
+		return fmt.Errorf("promo code must expire after it starts")
+	}
+
+	return nil
+}
+
+// DiscountCents - скидка на сбор feeCents, не больше самого сбора
+func (p *PromoCode) DiscountCents(feeCents int64) int64 {
+	var discount int64
+	switch p.DiscountType {
+	case PromoDiscountPercentage:
+		discount = int64(math.Round(float64(feeCents) * p.PercentOff / 100))
+	case PromoDiscountFixed:
+		discount = p.AmountOffCents
+	}
+	return min(discount, feeCents)
+}
+
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" binding:"required" example:"SPRING25"`
+	Description    string     `json:"description" example:"Spring campaign"`
+	DiscountType   string     `json:"discount_type" binding:"required,oneof=percentage fixed" example:"percentage"`
+	PercentOff     float64    `json:"percent_off,omitempty" example:"25"`
+	AmountOffCents int64      `json:"amount_off_cents,omitempty" example:"500"`
+	MaxRedemptions *int       `json:"max_redemptions,omitempty" example:"1000"`
+	PerUserLimit   *int       `json:"per_user_limit,omitempty" example:"1"`
+	FirstJobOnly   bool       `json:"first_job_only"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdatePromoCodeRequest - изменяются только переданные поля
+type UpdatePromoCodeRequest struct {
+	Description    *string    `json:"description,omitempty"`
+	MaxRedemptions *int       `json:"max_redemptions,omitempty"`
+	PerUserLimit   *int       `json:"per_user_limit,omitempty"`
+	FirstJobOnly   *bool      `json:"first_job_only,omitempty"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	IsActive       *bool      `json:"is_active,omitempty"`
+}
+
+// FeeRedemption - скидка на сбор за публикацию работы по промокоду и/или кредитами
+type FeeRedemption struct {
+	ID                  int64      `json:"id"`
+	PromoCodeID         *int64     `json:"promo_code_id,omitempty"`
+	PromoCode           string     `json:"promo_code,omitempty"`
+	UserID              int64      `json:"user_id"`
+	JobID               *int64     `json:"job_id,omitempty"`
+	FeeBeforeCents      int64      `json:"fee_before_cents"`
+	DiscountCents       int64      `json:"discount_cents"`
+	CreditsAppliedCents int64      `json:"credits_applied_cents"`
+	FeeAfterCents       int64      `json:"fee_after_cents"`
+	Status              string     `json:"status"`
+	CreatedAt           time.Time  `json:"created_at"`
+	ReversedAt          *time.Time `json:"reversed_at,omitempty"`
+}
+
+// CreditTransaction - начисление (> 0) или списание (< 0) кредитов
+type CreditTransaction struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	AmountCents int64     `json:"amount_cents"`
+	Reason      string    `json:"reason"`
+	JobID       *int64    `json:"job_id,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	CreatedBy   *int64    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreditBalance - баланс кредитов пользователя с последними операциями
+type CreditBalance struct {
+	UserID       int64               `json:"user_id"`
+	BalanceCents int64               `json:"balance_cents"`
+	Transactions []CreditTransaction `json:"transactions"`
+}
+
+// AdjustCreditsRequest - начисление (> 0) или списание (< 0) кредитов администратором
+type AdjustCreditsRequest struct {
+	AmountCents int64  `json:"amount_cents" binding:"required" example:"2500"`
+	Note        string `json:"note" binding:"required" example:"Compensation for delayed payout"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Статусы расхождений сверки со Stripe
+const (
+	ReconciliationIssueOpen      = "open"
+	ReconciliationIssueAutoFixed = "auto_fixed"
+	ReconciliationIssueResolved  = "resolved"
+)
+
+// Типы расхождений
+const (
+	ReconciliationStatusUpdated  = "status_updated"  // статус платежа обновлен по Stripe
+	ReconciliationRefundUpdated  = "refund_updated"  // сумма возврата обновлена по Stripe
+	ReconciliationMissingPayment = "missing_payment" // PaymentIntent есть в Stripe, но нет в payments
+	ReconciliationMissingIntent  = "missing_intent"  // платеж есть в payments, но не найден в Stripe
+	ReconciliationAmountMismatch = "amount_mismatch"
+	ReconciliationStatusConflict = "status_conflict" // платеж учтен как успешный, а в Stripe - нет
+	ReconciliationFixFailed      = "fix_failed"
+)
+
+type ReconciliationRun struct {
+	ID              int64      `json:"id"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	IntentsChecked  int        `json:"intents_checked"`
+	PaymentsChecked int        `json:"payments_checked"`
+	AutoFixed       int        `json:"auto_fixed"`
+	Flagged         int        `json:"flagged"`
+	Error           *string    `json:"error,omitempty"`
+}
+
+type ReconciliationIssue struct {
+	ID                    int64      `json:"id"`
+	RunID                 *int64     `json:"run_id,omitempty"`
+	StripePaymentIntentID string     `json:"stripe_payment_intent_id"`
+	PaymentID             *int64     `json:"payment_id,omitempty"`
+	IssueType             string     `json:"issue_type"`
+	Status                string     `json:"status"`
+	LocalStatus           string     `json:"local_status,omitempty"`
+	StripeStatus          string     `json:"stripe_status,omitempty"`
+	LocalAmountCents      int64      `json:"local_amount_cents"`
+	StripeAmountCents     int64      `json:"stripe_amount_cents"`
+	Details               string     `json:"details"`
+	DetectedAt            time.Time  `json:"detected_at"`
+	LastSeenAt            time.Time  `json:"last_seen_at"`
+	ResolvedAt            *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy            *int64     `json:"resolved_by,omitempty"`
+	ResolutionNote        *string    `json:"resolution_note,omitempty"`
+}
+
+// ReconciliationReport - отчет для администраторов: последний запуск, открытые расхождения и недавние автоисправления
+type ReconciliationReport struct {
+	LatestRun       *ReconciliationRun    `json:"latest_run,omitempty"`
+	OpenIssues      []ReconciliationIssue `json:"open_issues"`
+	OpenIssuesTotal int                   `json:"open_issues_total"`
+	RecentFixes     []ReconciliationIssue `json:"recent_fixes"`
+}
+
+type ResolveReconciliationIssueRequest struct {
+	Note string `json:"note" binding:"required"`
+}
@@ -11,12 +11,19 @@ type Review struct {
 	Comment    string    `json:"comment" db:"comment"`
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+
+	Tip      *CreatePaymentResponse `json:"tip,omitempty" db:"-"`       // чаевые, оставленные вместе с отзывом
+	TipError string                 `json:"tip_error,omitempty" db:"-"` // отзыв сохранен, но чаевые не списались
 }
 
 type CreateReviewRequest struct {
 	JobID   int64  `json:"job_id" binding:"required"`
 	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
 	Comment string `json:"comment"`
+
+	// Чаевые исполнителю (только для подрядчика), списываются с сохраненной карты
+	TipCents        int64  `json:"tip_cents,omitempty" binding:"omitempty,min=100,max=100000"`
+	PaymentMethodID *int64 `json:"payment_method_id,omitempty"`
 }
 
 type ReviewResponse struct {
@@ -26,6 +33,7 @@ type ReviewResponse struct {
 	RevieweeName string    `json:"reviewee_name"`
 	Rating       int       `json:"rating"`
 	Comment      string    `json:"comment"`
+	TipCents     int64     `json:"tip_cents,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
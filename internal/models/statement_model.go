@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Форматы выгрузки выписок о заработке
+const (
+	StatementFormatJSON = "json"
+	StatementFormatPDF  = "pdf"
+	StatementFormatCSV  = "csv"
+)
+
+// EarningsLine - строка выписки: выплаты исполнителю по одной работе за период.
+// Gross - оплата работы до комиссии, Tips - чаевые без комиссии, Net - к выплате
+type EarningsLine struct {
+	JobID           *int64    `json:"job_id,omitempty"`
+	EarnedAt        time.Time `json:"earned_at"`
+	PickupCity      string    `json:"pickup_city"`
+	PickupState     string    `json:"pickup_state"`
+	DeliveryCity    string    `json:"delivery_city"`
+	DeliveryState   string    `json:"delivery_state"`
+	GrossCents      int64     `json:"gross_cents"`
+	CommissionCents int64     `json:"commission_cents"`
+	TipsCents       int64     `json:"tips_cents"`
+	NetCents        int64     `json:"net_cents"`
+	Currency        string    `json:"currency"`
+}
+
+// Route - маршрут работы для выписки, например "Dallas, TX -> Austin, TX"
+func (l *EarningsLine) Route() string {
+	from := joinCityState(l.PickupCity, l.PickupState)
+	to := joinCityState(l.DeliveryCity, l.DeliveryState)
+	if from == "" && to == "" {
+		return ""
+	}
+	return from + " -> " + to
+}
+
+func joinCityState(city, state string) string {
+	switch {
+	case city == "":
+		return state
+	case state == "":
+		return city
+	default:
+		return city + ", " + state
+	}
+}
+
+// EarningsTotals - итоги выписки
+type EarningsTotals struct {
+	Jobs            int   `json:"jobs"`
+	GrossCents      int64 `json:"gross_cents"`
+	CommissionCents int64 `json:"commission_cents"`
+	TipsCents       int64 `json:"tips_cents"`
+	NetCents        int64 `json:"net_cents"`
+}
+
+// Add добавляет строку выписки к итогам
+func (t *EarningsTotals) Add(line EarningsLine) {
+	if line.JobID != nil {
+		t.Jobs++
+	}
+	t.GrossCents += line.GrossCents
+	t.CommissionCents += line.CommissionCents
+	t.TipsCents += line.TipsCents
+	t.NetCents += line.NetCents
+}
+
+// EarningsStatement - месячная выписка о заработке исполнителя
+type EarningsStatement struct {
+	UserID      int64          `json:"user_id"`
+	Year        int            `json:"year"`
+	Month       int            `json:"month"`
+	PeriodStart time.Time      `json:"period_start"`
+	PeriodEnd   time.Time      `json:"period_end"`
+	Payee       *Company       `json:"payee"`
+	Lines       []EarningsLine `json:"lines"`
+	Totals      EarningsTotals `json:"totals"`
+	Currency    string         `json:"currency"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+// FileName - имя файла выписки при скачивании
+func (s *EarningsStatement) FileName(format string) string {
+	return fmt.Sprintf("earnings-statement-%04d-%02d.%s", s.Year, s.Month, format)
+}
+
+// MonthlyEarnings - итоги одного месяца в годовой сводке
+type MonthlyEarnings struct {
+	Month int `json:"month"`
+	EarningsTotals
+}
+
+// AnnualEarningsSummary - годовая сводка выплат исполнителю в духе формы 1099
+type AnnualEarningsSummary struct {
+	UserID      int64             `json:"user_id"`
+	Year        int               `json:"year"`
+	Payee       *Company          `json:"payee"`
+	Months      []MonthlyEarnings `json:"months"`
+	Totals      EarningsTotals    `json:"totals"`
+	Currency    string            `json:"currency"`
+	GeneratedAt time.Time         `json:"generated_at"`
+}
+
+// FileName - имя файла сводки при скачивании
+func (s *AnnualEarningsSummary) FileName(format string) string {
+	return fmt.Sprintf("earnings-tax-summary-%04d.%s", s.Year, format)
+}
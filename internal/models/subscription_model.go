@@ -0,0 +1,187 @@
+package models
+
+import "time"
+
+// Планы подписки компании
+const (
+	PlanFree  = "free"
+	PlanPro   = "pro"
+	PlanFleet = "fleet"
+)
+
+// UnlimitedTeamSeats - план без ограничения на размер команды
+const UnlimitedTeamSeats = -1
+
+// SubscriptionGracePeriod - сколько после неудачного списания план продолжает действовать,
+// пока Stripe повторяет попытки (dunning)
+const SubscriptionGracePeriod = 7 * 24 * time.Hour
+
+// Статусы подписки повторяют статусы Stripe Subscription
+const (
+	SubscriptionStatusIncomplete        = "incomplete"
+	SubscriptionStatusIncompleteExpired = "incomplete_expired"
+	SubscriptionStatusTrialing          = "trialing"
+	SubscriptionStatusActive            = "active"
+	SubscriptionStatusPastDue           = "past_due"
+	SubscriptionStatusUnpaid            = "unpaid"
+	SubscriptionStatusPaused            = "paused"
+	SubscriptionStatusCanceled          = "canceled"
+)
+
+// PlanEntitlements - возможности и лимиты, которые дает план
+type PlanEntitlements struct {
+	PostingFeeDiscountPercent float64  `json:"posting_fee_discount_percent"` // скидка на сбор за публикацию работы
+	CommissionRate            *float64 `json:"commission_rate,omitempty"`    // комиссия с выплат исполнителю; nil - из системных настроек
+	TeamSeats                 int      `json:"team_seats"`                   // UnlimitedTeamSeats - без ограничения
+	IncludedBoosts            int      `json:"included_boosts"`              // бесплатные продвижения работ за расчетный период
+}
+
+// HasTeamSeat - можно ли добавить в команду еще одного участника
+func (e *PlanEntitlements) HasTeamSeat(used int) bool {
+	return e.TeamSeats == UnlimitedTeamSeats || used < e.TeamSeats
+}
+
+// Plan - план подписки. Цена в Stripe задается через STRIPE_PRICE_PRO / STRIPE_PRICE_FLEET
+type Plan struct {
+	Code              string           `json:"code" example:"pro"`
+	Name              string           `json:"name" example:"Pro"`
+	MonthlyPriceCents int64            `json:"monthly_price_cents" example:"4900"`
+	Rank              int              `json:"-"`
+	Entitlements      PlanEntitlements `json:"entitlements"`
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+var subscriptionPlans = []Plan{
+	{
+		Code: PlanFree,
+		Name: "Free",
+		Rank: 0,
+		Entitlements: PlanEntitlements{
+			TeamSeats: 3,
+		},
+	},
+	{
+		Code:              PlanPro,
+		Name:              "Pro",
+		MonthlyPriceCents: 4900,
+		Rank:              1,
+		Entitlements: PlanEntitlements{
+			PostingFeeDiscountPercent: 25,
+			CommissionRate:            floatPtr(6),
+			TeamSeats:                 10,
+			IncludedBoosts:            3,
+		},
+	},
+	{
+		Code:              PlanFleet,
+		Name:              "Fleet",
+		MonthlyPriceCents: 19900,
+		Rank:              2,
+		Entitlements: PlanEntitlements{
+			PostingFeeDiscountPercent: 50,
+			CommissionRate:            floatPtr(4.5),
+			TeamSeats:                 UnlimitedTeamSeats,
+			IncludedBoosts:            15,
+		},
+	},
+}
+
+// SubscriptionPlans - все планы в порядке возрастания
+func SubscriptionPlans() []Plan {
+	plans := make([]Plan, len(subscriptionPlans))
+	copy(plans, subscriptionPlans)
+	return plans
+}
+
+// GetPlan возвращает план по коду или nil
+func GetPlan(code string) *Plan {
+	for i := range subscriptionPlans {
+		if subscriptionPlans[i].Code == code {
+			plan := subscriptionPlans[i]
+			return &plan
+		}
+	}
+	return nil
+}
+
+// Subscription - подписка компании (пользователя) на платный план
+type Subscription struct {
+	ID                   int64      `json:"id"`
+	UserID               int64      `json:"user_id"`
+	Plan                 string     `json:"plan"`
+	Status               string     `json:"status"`
+	StripeSubscriptionID string     `json:"stripe_subscription_id"`
+	StripeCustomerID     string     `json:"-"`
+	StripePriceID        string     `json:"-"`
+	CurrentPeriodStart   *time.Time `json:"current_period_start,omitempty"`
+	CurrentPeriodEnd     *time.Time `json:"current_period_end,omitempty"`
+	CancelAtPeriodEnd    bool       `json:"cancel_at_period_end"`
+	PastDueSince         *time.Time `json:"past_due_since,omitempty"`
+	CanceledAt           *time.Time `json:"canceled_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// IsCurrent - подписка еще может дать план (не отменена и не брошена до первой оплаты)
+func (s *Subscription) IsCurrent() bool {
+	return s.Status != SubscriptionStatusCanceled && s.Status != SubscriptionStatusIncompleteExpired
+}
+
+// EffectivePlan - план, который действует сейчас. Просроченная подписка действует
+// в течение SubscriptionGracePeriod, затем до оплаты действует бесплатный план
+func (s *Subscription) EffectivePlan(now time.Time) string {
+	switch s.Status {
+	case SubscriptionStatusActive, SubscriptionStatusTrialing:
+		return s.Plan
+	case SubscriptionStatusPastDue:
+		if s.PastDueSince != nil && now.Before(s.PastDueSince.Add(SubscriptionGracePeriod)) {
+			return s.Plan
+		}
+	}
+	return PlanFree
+}
+
+// Entitlements - возможности, действующие для пользователя сейчас
+type Entitlements struct {
+	Plan string `json:"plan" example:"pro"`
+	PlanEntitlements
+	// Расчетный период, за который считаются бесплатные продвижения
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// AtLeast - план пользователя не ниже plan
+func (e *Entitlements) AtLeast(plan string) bool {
+	current, required := GetPlan(e.Plan), GetPlan(plan)
+	if current == nil || required == nil {
+		return false
+	}
+	return current.Rank >= required.Rank
+}
+
+type SubscriptionResponse struct {
+	Subscription *Subscription `json:"subscription"` // nil - бесплатный план без подписки
+	Entitlements *Entitlements `json:"entitlements"`
+}
+
+type SubscribeRequest struct {
+	Plan            string `json:"plan" binding:"required" example:"pro"`
+	PaymentMethodID *int64 `json:"payment_method_id,omitempty"` // Optional, will use default if not provided
+
+	// Заголовок Idempotency-Key запроса; повтор не создает вторую подписку в Stripe
+	IdempotencyKey string `json:"-"`
+}
+
+// SubscribeResponse - ClientSecret передается, если первое списание требует подтверждения клиентом (3DS)
+type SubscribeResponse struct {
+	Subscription         *Subscription `json:"subscription"`
+	ClientSecret         string        `json:"client_secret,omitempty"`
+	RequiresConfirmation bool          `json:"requires_confirmation"`
+}
+
+type ChangePlanRequest struct {
+	Plan string `json:"plan" binding:"required" example:"fleet"`
+}
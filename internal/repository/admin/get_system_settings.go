@@ -14,6 +14,12 @@ func (r *repository) GetSystemSettings(ctx context.Context) (*models.SystemSetti
 			new_user_approval VARCHAR(20) NOT NULL DEFAULT 'manual' CHECK (new_user_approval IN ('manual', 'auto')),
 			minimum_payout INTEGER NOT NULL DEFAULT 500,
 			job_expiration_days INTEGER NOT NULL DEFAULT 14,
+			urgent_boost_price_cents INTEGER NOT NULL DEFAULT 999,
+			pin_boost_price_cents INTEGER NOT NULL DEFAULT 1999,
+			nearby_push_boost_price_cents INTEGER NOT NULL DEFAULT 1499,
+			boost_duration_hours INTEGER NOT NULL DEFAULT 24,
+			fee_schedule JSONB,
+			invoice_tax_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 		);
@@ -25,7 +31,9 @@ func (r *repository) GetSystemSettings(ctx context.Context) (*models.SystemSetti
 	}
 
 	query := `
-		SELECT id, commission_rate, new_user_approval, minimum_payout, job_expiration_days
+		SELECT id, commission_rate, new_user_approval, minimum_payout, job_expiration_days,
+			   urgent_boost_price_cents, pin_boost_price_cents, nearby_push_boost_price_cents, boost_duration_hours,
+			   fee_schedule, invoice_tax_rate
 		FROM system_settings 
 		WHERE id = 1
 	`
@@ -37,6 +45,12 @@ func (r *repository) GetSystemSettings(ctx context.Context) (*models.SystemSetti
 		&settings.NewUserApproval,
 		&settings.MinimumPayout,
 		&settings.JobExpirationDays,
+		&settings.UrgentBoostPriceCents,
+		&settings.PinBoostPriceCents,
+		&settings.NearbyPushBoostPriceCents,
+		&settings.BoostDurationHours,
+		&settings.FeeSchedule,
+		&settings.InvoiceTaxRate,
 	)
 
 	if err != nil {
@@ -47,6 +61,11 @@ func (r *repository) GetSystemSettings(ctx context.Context) (*models.SystemSetti
 			NewUserApproval:   "manual",
 			MinimumPayout:     500,
 			JobExpirationDays: 14,
+
+			UrgentBoostPriceCents:     999,
+			PinBoostPriceCents:        1999,
+			NearbyPushBoostPriceCents: 1499,
+			BoostDurationHours:        24,
 		}, nil
 	}
 
@@ -57,13 +76,21 @@ func (r *repository) UpdateSystemSettings(ctx context.Context, settings *models.
 	// Always update the first (and should be only) record
 	// Use UPSERT to either insert or update
 	query := `
-		INSERT INTO system_settings (id, commission_rate, new_user_approval, minimum_payout, job_expiration_days)
-		VALUES (1, $1, $2, $3, $4)
+		INSERT INTO system_settings (id, commission_rate, new_user_approval, minimum_payout, job_expiration_days,
+			urgent_boost_price_cents, pin_boost_price_cents, nearby_push_boost_price_cents, boost_duration_hours,
+			fee_schedule, invoice_tax_rate)
+		VALUES (1, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (id) DO UPDATE SET
 			commission_rate = EXCLUDED.commission_rate,
 			new_user_approval = EXCLUDED.new_user_approval,
 			minimum_payout = EXCLUDED.minimum_payout,
 			job_expiration_days = EXCLUDED.job_expiration_days,
+			urgent_boost_price_cents = EXCLUDED.urgent_boost_price_cents,
+			pin_boost_price_cents = EXCLUDED.pin_boost_price_cents,
+			nearby_push_boost_price_cents = EXCLUDED.nearby_push_boost_price_cents,
+			boost_duration_hours = EXCLUDED.boost_duration_hours,
+			fee_schedule = EXCLUDED.fee_schedule,
+			invoice_tax_rate = EXCLUDED.invoice_tax_rate,
 			updated_at = NOW()
 		RETURNING id
 	`
@@ -73,6 +100,12 @@ func (r *repository) UpdateSystemSettings(ctx context.Context, settings *models.
 		settings.NewUserApproval,
 		settings.MinimumPayout,
 		settings.JobExpirationDays,
+		settings.UrgentBoostPriceCents,
+		settings.PinBoostPriceCents,
+		settings.NearbyPushBoostPriceCents,
+		settings.BoostDurationHours,
+		settings.FeeSchedule,
+		settings.InvoiceTaxRate,
 	).Scan(&settings.ID)
 
 	return err
@@ -19,6 +19,7 @@ type AdminRepository interface {
 	// GetAllJobs(ctx context.Context, limit, offset int) ([]models.Job, error)
 	ChangeUserStatus(ctx context.Context, userID int, newStatus string) error
 	GetUserRole(ctx context.Context, userID int64) (string, error)
+	GetAdminUserIDs(ctx context.Context) ([]int64, error)
 	ChangeVerificationFileStatus(ctx context.Context, fileID int, newStatus string) error
 	GetUserFullInfo(ctx context.Context, userID int64) (*models.UserFullInfo, error)
 	GetTopCompanies(ctx context.Context, days int, limit int) ([]models.TopCompany, error)
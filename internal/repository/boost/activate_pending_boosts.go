@@ -0,0 +1,34 @@
+package boost
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+// ActivatePendingBoosts включает продвижения, оплаченные PaymentIntent. Срок действия
+// отсчитывается от момента активации. Возвращает только что включенные продвижения
+func (r *repository) ActivatePendingBoosts(ctx context.Context, paymentIntentID string) ([]models.JobBoost, error) {
+	query := `
+		UPDATE job_boosts
+		SET status = 'active', starts_at = NOW(), expires_at = NOW() + (expires_at - starts_at)
+		WHERE stripe_payment_intent_id = $1 AND status = 'pending'
+		RETURNING id, job_id, boost_type, price_cents, stripe_payment_intent_id, purchased_by, included_in_plan, status, starts_at, expires_at, created_at`
+
+	rows, err := r.db.Query(ctx, query, paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	boosts := []models.JobBoost{}
+	for rows.Next() {
+		var b models.JobBoost
+		if err := rows.Scan(&b.ID, &b.JobID, &b.BoostType, &b.PriceCents, &b.StripePaymentIntentID,
+			&b.PurchasedBy, &b.IncludedInPlan, &b.Status, &b.StartsAt, &b.ExpiresAt, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		boosts = append(boosts, b)
+	}
+
+	return boosts, rows.Err()
+}
@@ -0,0 +1,18 @@
+package boost
+
+import (
+	"context"
+	"time"
+)
+
+// CountIncludedBoosts - сколько бесплатных продвижений из плана пользователь использовал с начала периода
+func (r *repository) CountIncludedBoosts(ctx context.Context, userID int64, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM job_boosts
+		WHERE purchased_by = $1 AND included_in_plan AND created_at >= $2`
+
+	var count int
+	err := r.db.QueryRow(ctx, query, userID, since).Scan(&count)
+	return count, err
+}
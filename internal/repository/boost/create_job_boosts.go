@@ -0,0 +1,36 @@
+package boost
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) CreateJobBoosts(ctx context.Context, boosts []models.JobBoost) ([]models.JobBoost, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO job_boosts (job_id, boost_type, price_cents, stripe_payment_intent_id, purchased_by, included_in_plan, status, starts_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at`
+
+	created := make([]models.JobBoost, 0, len(boosts))
+	for _, b := range boosts {
+		err := tx.QueryRow(ctx, query,
+			b.JobID, b.BoostType, b.PriceCents, b.StripePaymentIntentID, b.PurchasedBy, b.IncludedInPlan, b.Status, b.StartsAt, b.ExpiresAt,
+		).Scan(&b.ID, &b.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, b)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
@@ -0,0 +1,32 @@
+package boost
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) GetActiveJobBoosts(ctx context.Context, jobID int64) ([]models.JobBoost, error) {
+	query := `
+		SELECT id, job_id, boost_type, price_cents, stripe_payment_intent_id, purchased_by, included_in_plan, status, starts_at, expires_at, created_at
+		FROM job_boosts
+		WHERE job_id = $1 AND status = 'active' AND expires_at > NOW()
+		ORDER BY created_at ASC`
+
+	rows, err := r.db.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	boosts := []models.JobBoost{}
+	for rows.Next() {
+		var b models.JobBoost
+		if err := rows.Scan(&b.ID, &b.JobID, &b.BoostType, &b.PriceCents, &b.StripePaymentIntentID,
+			&b.PurchasedBy, &b.IncludedInPlan, &b.Status, &b.StartsAt, &b.ExpiresAt, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		boosts = append(boosts, b)
+	}
+
+	return boosts, rows.Err()
+}
@@ -0,0 +1,35 @@
+package boost
+
+import (
+	"context"
+)
+
+// GetNearbyMovers возвращает пользователей, чья компания находится в штате погрузки.
+// В companies штат хранится как код или полное название, поэтому сравниваем с обоими.
+func (r *repository) GetNearbyMovers(ctx context.Context, state, stateName string, excludeUserID int64, limit int) ([]int64, error) {
+	query := `
+		SELECT c.user_id
+		FROM companies c
+		JOIN users u ON u.id = c.user_id
+		WHERE (UPPER(TRIM(c.state)) = UPPER($1) OR LOWER(TRIM(c.state)) = LOWER($2))
+		  AND c.user_id <> $3
+		ORDER BY c.updated_at DESC
+		LIMIT $4`
+
+	rows, err := r.db.Query(ctx, query, state, stateName, excludeUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	return userIDs, rows.Err()
+}
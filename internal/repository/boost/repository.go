@@ -0,0 +1,25 @@
+package boost
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BoostRepository interface {
+	CreateJobBoosts(ctx context.Context, boosts []models.JobBoost) ([]models.JobBoost, error)
+	GetActiveJobBoosts(ctx context.Context, jobID int64) ([]models.JobBoost, error)
+	ActivatePendingBoosts(ctx context.Context, paymentIntentID string) ([]models.JobBoost, error)
+	CountIncludedBoosts(ctx context.Context, userID int64, since time.Time) (int, error)
+	GetNearbyMovers(ctx context.Context, state, stateName string, excludeUserID int64, limit int) ([]int64, error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewBoostRepository(db *pgxpool.Pool) BoostRepository {
+	return &repository{db: db}
+}
@@ -22,6 +22,7 @@ func (r *repository) GetCompany(ctx context.Context, userID int64) (*models.Comp
 			COALESCE(c.city, '') AS city, 
 			COALESCE(c.zip_code, '') AS zip_code, 
 			COALESCE(c.dot_number, '') AS dot_number,
+			COALESCE(c.tax_id, '') AS tax_id,
 			COALESCE(c.created_at, now()) AS created_at, 
 			COALESCE(c.updated_at, now()) AS updated_at
 		FROM users u
@@ -43,6 +44,7 @@ func (r *repository) GetCompany(ctx context.Context, userID int64) (*models.Comp
 		&company.City,
 		&company.ZipCode,
 		&company.DotNumber,
+		&company.TaxID,
 		&company.CreatedAt,
 		&company.UpdatedAt,
 	)
@@ -9,10 +9,10 @@ func (r *repository) UpdateCompany(ctx context.Context, userID int64, c *models.
 	query := `
 		INSERT INTO companies (
 			user_id, company_name, address, state, mc_license_number, 
-			company_description, contact_person, phone_number, city, zip_code, dot_number, created_at, updated_at
+			company_description, contact_person, phone_number, city, zip_code, dot_number, tax_id, created_at, updated_at
 		) VALUES (
 			$1, $2, $3, $4, $5,
-			$6, $7, $8, $9, $10, $11, now(), now()
+			$6, $7, $8, $9, $10, $11, $12, now(), now()
 		)
 		ON CONFLICT (user_id) DO UPDATE SET
 			company_name = EXCLUDED.company_name,
@@ -25,6 +25,7 @@ func (r *repository) UpdateCompany(ctx context.Context, userID int64, c *models.
 			city = EXCLUDED.city,
 			zip_code = EXCLUDED.zip_code,
 			dot_number = EXCLUDED.dot_number,
+			tax_id = EXCLUDED.tax_id,
 			updated_at = now()
 	`
 
@@ -40,6 +41,7 @@ func (r *repository) UpdateCompany(ctx context.Context, userID int64, c *models.
 		c.City,
 		c.ZipCode,
 		c.DotNumber,
+		c.TaxID,
 	)
 
 	return err
@@ -0,0 +1,16 @@
+package crew
+
+import "context"
+
+// AcceptTeamInvite переводит приглашение в активное участие в команде.
+// Если непринятого приглашения нет, возвращает pgx.ErrNoRows
+func (r *repository) AcceptTeamInvite(ctx context.Context, ownerID, memberID int64) error {
+	query := `
+		UPDATE company_team_members
+		SET status = 'active', accepted_at = NOW()
+		WHERE owner_id = $1 AND member_id = $2 AND status = 'pending'
+		RETURNING id`
+
+	var id int64
+	return r.db.QueryRow(ctx, query, ownerID, memberID).Scan(&id)
+}
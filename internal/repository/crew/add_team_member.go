@@ -0,0 +1,28 @@
+package crew
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+// AddTeamMember приглашает пользователя в команду. Для существующего участника или приглашения
+// меняется только роль, статус остается прежним
+func (r *repository) AddTeamMember(ctx context.Context, ownerID, memberID int64, role string) (*models.TeamMember, error) {
+	query := `
+		INSERT INTO company_team_members (owner_id, member_id, role, status)
+		VALUES ($1, $2, $3, 'pending')
+		ON CONFLICT (owner_id, member_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING id, status, accepted_at, created_at`
+
+	member := &models.TeamMember{
+		OwnerID:  ownerID,
+		MemberID: memberID,
+		Role:     role,
+	}
+	err := r.db.QueryRow(ctx, query, ownerID, memberID, role).Scan(&member.ID, &member.Status, &member.AcceptedAt, &member.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
@@ -0,0 +1,38 @@
+package crew
+
+import (
+	"context"
+	"fmt"
+	"moveshare/internal/models"
+)
+
+// AssignJobCrew заменяет текущую команду и грузовик работы одной транзакцией
+func (r *repository) AssignJobCrew(ctx context.Context, jobID, assignedBy int64, truckID *int64, members []models.CrewMemberInput) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE jobs SET assigned_truck_id = $1, updated_at = NOW() WHERE id = $2`, truckID, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to assign truck: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM job_crew_assignments WHERE job_id = $1`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to clear crew: %w", err)
+	}
+
+	for _, m := range members {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO job_crew_assignments (job_id, member_id, role, assigned_by)
+			VALUES ($1, $2, $3, $4)`,
+			jobID, m.UserID, m.Role, assignedBy)
+		if err != nil {
+			return fmt.Errorf("failed to assign crew member %d: %w", m.UserID, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,22 @@
+package crew
+
+import (
+	"context"
+	"fmt"
+)
+
+// DeclineTeamInvite удаляет приглашение, которое пользователь еще не принял
+func (r *repository) DeclineTeamInvite(ctx context.Context, ownerID, memberID int64) error {
+	query := `DELETE FROM company_team_members WHERE owner_id = $1 AND member_id = $2 AND status = 'pending'`
+
+	result, err := r.db.Exec(ctx, query, ownerID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("team invite not found")
+	}
+
+	return nil
+}
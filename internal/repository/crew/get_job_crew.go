@@ -0,0 +1,41 @@
+package crew
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) GetJobCrew(ctx context.Context, jobID int64) (*models.JobCrew, error) {
+	crew := &models.JobCrew{
+		JobID:   jobID,
+		Members: []models.CrewAssignment{},
+	}
+
+	err := r.db.QueryRow(ctx, `SELECT assigned_truck_id FROM jobs WHERE id = $1`, jobID).Scan(&crew.TruckID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT a.member_id, u.username, a.role, a.assigned_by, a.assigned_at
+		FROM job_crew_assignments a
+		JOIN users u ON u.id = a.member_id
+		WHERE a.job_id = $1
+		ORDER BY a.role ASC, a.assigned_at ASC`
+
+	rows, err := r.db.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.CrewAssignment
+		if err := rows.Scan(&a.MemberID, &a.Username, &a.Role, &a.AssignedBy, &a.AssignedAt); err != nil {
+			return nil, err
+		}
+		crew.Members = append(crew.Members, a)
+	}
+
+	return crew, rows.Err()
+}
@@ -0,0 +1,58 @@
+package crew
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) GetMemberAssignments(ctx context.Context, memberID int64, offset, limit int) ([]models.AssignedJob, error) {
+	query := `
+		SELECT j.id, j.contractor_id, j.executor_id, j.job_type, j.number_of_bedrooms, j.packing_boxes, j.bulky_items,
+			   j.inventory_list, j.hoisting, j.additional_services_description, j.estimated_crew_assistants,
+			   j.truck_size, j.pickup_address, j.pickup_city, j.pickup_state, j.pickup_floor, j.pickup_building_type,
+			   j.pickup_walk_distance, j.delivery_address, j.delivery_city, j.delivery_state, j.delivery_floor,
+			   j.delivery_building_type, j.delivery_walk_distance, j.distance_miles, j.job_status,
+			   j.pickup_date, j.pickup_time_from, j.pickup_time_to, j.delivery_date, j.delivery_time_from,
+			   j.delivery_time_to, j.cut_amount, j.payment_amount, j.weight_lbs, j.volume_cu_ft,
+			   j.created_at, j.updated_at, a.role, a.assigned_at
+		FROM job_crew_assignments a
+		JOIN jobs j ON j.id = a.job_id
+		WHERE a.member_id = $1
+		ORDER BY j.pickup_date ASC, j.pickup_time_from ASC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, memberID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []models.AssignedJob{}
+	for rows.Next() {
+		var a models.AssignedJob
+		err := rows.Scan(
+			&a.ID, &a.ContractorID, &a.ExecutorID, &a.JobType, &a.NumberOfBedrooms, &a.PackingBoxes,
+			&a.BulkyItems, &a.InventoryList, &a.Hoisting, &a.AdditionalServicesDescription,
+			&a.EstimatedCrewAssistants, &a.TruckSize, &a.PickupAddress, &a.PickupCity, &a.PickupState,
+			&a.PickupFloor, &a.PickupBuildingType, &a.PickupWalkDistance, &a.DeliveryAddress,
+			&a.DeliveryCity, &a.DeliveryState, &a.DeliveryFloor, &a.DeliveryBuildingType,
+			&a.DeliveryWalkDistance, &a.DistanceMiles, &a.JobStatus, &a.PickupDate, &a.PickupTimeFrom,
+			&a.PickupTimeTo, &a.DeliveryDate, &a.DeliveryTimeFrom, &a.DeliveryTimeTo, &a.CutAmount,
+			&a.PaymentAmount, &a.WeightLbs, &a.VolumeCuFt, &a.CreatedAt, &a.UpdatedAt,
+			&a.CrewRole, &a.AssignedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+func (r *repository) GetCountMemberAssignments(ctx context.Context, memberID int64) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM job_crew_assignments WHERE member_id = $1`
+	err := r.db.QueryRow(ctx, query, memberID).Scan(&count)
+	return count, err
+}
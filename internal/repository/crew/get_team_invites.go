@@ -0,0 +1,34 @@
+package crew
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+// GetTeamInvites возвращает приглашения в команды, которые пользователь еще не принял
+func (r *repository) GetTeamInvites(ctx context.Context, memberID int64) ([]models.TeamInvite, error) {
+	query := `
+		SELECT tm.owner_id, u.username, COALESCE(c.company_name, ''), tm.role, tm.created_at
+		FROM company_team_members tm
+		JOIN users u ON u.id = tm.owner_id
+		LEFT JOIN companies c ON c.user_id = tm.owner_id
+		WHERE tm.member_id = $1 AND tm.status = 'pending'
+		ORDER BY tm.created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []models.TeamInvite{}
+	for rows.Next() {
+		var i models.TeamInvite
+		if err := rows.Scan(&i.OwnerID, &i.OwnerUsername, &i.CompanyName, &i.Role, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		invites = append(invites, i)
+	}
+
+	return invites, rows.Err()
+}
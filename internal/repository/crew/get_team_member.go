@@ -0,0 +1,24 @@
+package crew
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) GetTeamMember(ctx context.Context, ownerID, memberID int64) (*models.TeamMember, error) {
+	query := `
+		SELECT tm.id, tm.owner_id, tm.member_id, u.username, u.email, tm.role, tm.status, tm.accepted_at, tm.created_at
+		FROM company_team_members tm
+		JOIN users u ON u.id = tm.member_id
+		WHERE tm.owner_id = $1 AND tm.member_id = $2`
+
+	var m models.TeamMember
+	err := r.db.QueryRow(ctx, query, ownerID, memberID).Scan(
+		&m.ID, &m.OwnerID, &m.MemberID, &m.Username, &m.Email, &m.Role, &m.Status, &m.AcceptedAt, &m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
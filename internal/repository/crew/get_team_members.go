@@ -0,0 +1,32 @@
+package crew
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) GetTeamMembers(ctx context.Context, ownerID int64) ([]models.TeamMember, error) {
+	query := `
+		SELECT tm.id, tm.owner_id, tm.member_id, u.username, u.email, tm.role, tm.status, tm.accepted_at, tm.created_at
+		FROM company_team_members tm
+		JOIN users u ON u.id = tm.member_id
+		WHERE tm.owner_id = $1
+		ORDER BY tm.created_at ASC`
+
+	rows, err := r.db.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.TeamMember{}
+	for rows.Next() {
+		var m models.TeamMember
+		if err := rows.Scan(&m.ID, &m.OwnerID, &m.MemberID, &m.Username, &m.Email, &m.Role, &m.Status, &m.AcceptedAt, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
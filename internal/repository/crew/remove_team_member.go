@@ -0,0 +1,37 @@
+package crew
+
+import (
+	"context"
+	"fmt"
+)
+
+// RemoveTeamMember удаляет участника из команды и снимает его с еще не выполненных работ компании
+// одной транзакцией, чтобы бывший участник не остался в команде назначенной работы
+func (r *repository) RemoveTeamMember(ctx context.Context, ownerID, memberID int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `DELETE FROM company_team_members WHERE owner_id = $1 AND member_id = $2`, ownerID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("team member not found")
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM job_crew_assignments a
+		USING jobs j
+		WHERE a.job_id = j.id AND a.member_id = $2
+		  AND j.executor_id = $1 AND j.job_status IN ('claimed', 'in_progress')`,
+		ownerID, memberID)
+	if err != nil {
+		return fmt.Errorf("failed to unassign member from jobs: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,30 @@
+package crew
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CrewRepository interface {
+	AddTeamMember(ctx context.Context, ownerID, memberID int64, role string) (*models.TeamMember, error)
+	GetTeamMembers(ctx context.Context, ownerID int64) ([]models.TeamMember, error)
+	GetTeamMember(ctx context.Context, ownerID, memberID int64) (*models.TeamMember, error)
+	RemoveTeamMember(ctx context.Context, ownerID, memberID int64) error
+	GetTeamInvites(ctx context.Context, memberID int64) ([]models.TeamInvite, error)
+	AcceptTeamInvite(ctx context.Context, ownerID, memberID int64) error
+	DeclineTeamInvite(ctx context.Context, ownerID, memberID int64) error
+	AssignJobCrew(ctx context.Context, jobID, assignedBy int64, truckID *int64, members []models.CrewMemberInput) error
+	GetJobCrew(ctx context.Context, jobID int64) (*models.JobCrew, error)
+	GetMemberAssignments(ctx context.Context, memberID int64, offset, limit int) ([]models.AssignedJob, error)
+	GetCountMemberAssignments(ctx context.Context, memberID int64) (int, error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewCrewRepository(db *pgxpool.Pool) CrewRepository {
+	return &repository{db: db}
+}
@@ -0,0 +1,45 @@
+package distance
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetCachedDistance возвращает nil, nil если пары адресов нет в кэше
+func (r *repository) GetCachedDistance(ctx context.Context, originKey, destinationKey string) (*models.DistanceEstimate, error) {
+	query := `
+		SELECT distance_meters, duration_seconds, provider
+		FROM distance_cache
+		WHERE origin_key = $1 AND destination_key = $2`
+
+	estimate := &models.DistanceEstimate{Cached: true}
+	err := r.db.QueryRow(ctx, query, originKey, destinationKey).Scan(
+		&estimate.Meters,
+		&estimate.DurationSeconds,
+		&estimate.Provider,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return estimate, nil
+}
+
+func (r *repository) SaveCachedDistance(ctx context.Context, originKey, destinationKey string, estimate *models.DistanceEstimate) error {
+	query := `
+		INSERT INTO distance_cache (origin_key, destination_key, distance_meters, duration_seconds, provider)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (origin_key, destination_key) DO UPDATE SET
+			distance_meters = EXCLUDED.distance_meters,
+			duration_seconds = EXCLUDED.duration_seconds,
+			provider = EXCLUDED.provider,
+			created_at = NOW()`
+
+	_, err := r.db.Exec(ctx, query, originKey, destinationKey, estimate.Meters, estimate.DurationSeconds, estimate.Provider)
+	return err
+}
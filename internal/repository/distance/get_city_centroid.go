@@ -0,0 +1,29 @@
+package distance
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetCityCentroid ищет координаты города; state может быть названием штата
+func (r *repository) GetCityCentroid(ctx context.Context, city, state string) (float64, float64, bool, error) {
+	query := `
+		SELECT c.latitude, c.longitude
+		FROM cities c
+		JOIN states s ON s.id = c.state_id
+		WHERE LOWER(c.name) = LOWER($1) AND LOWER(s.name) = LOWER($2)
+		  AND c.latitude IS NOT NULL AND c.longitude IS NOT NULL
+		LIMIT 1`
+
+	var lat, lng float64
+	err := r.db.QueryRow(ctx, query, city, state).Scan(&lat, &lng)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, 0, false, nil
+		}
+		return 0, 0, false, err
+	}
+
+	return lat, lng, true, nil
+}
@@ -0,0 +1,22 @@
+package distance
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DistanceRepository interface {
+	GetCachedDistance(ctx context.Context, originKey, destinationKey string) (*models.DistanceEstimate, error)
+	SaveCachedDistance(ctx context.Context, originKey, destinationKey string, estimate *models.DistanceEstimate) error
+	GetCityCentroid(ctx context.Context, city, state string) (lat, lng float64, found bool, err error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewDistanceRepository(db *pgxpool.Pool) DistanceRepository {
+	return &repository{db: db}
+}
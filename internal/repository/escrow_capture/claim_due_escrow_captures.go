@@ -0,0 +1,46 @@
+package escrow_capture
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+)
+
+// ClaimDueEscrowCaptures забирает списания, которые пора выполнить: подтвержденные и те,
+// которые подрядчик не подтвердил и не оспорил до confirm_by (они подтверждаются автоматически).
+// next_attempt_at сдвигается на lease: пока он не истек, запись не достанется другому обработчику
+func (r *repository) ClaimDueEscrowCaptures(ctx context.Context, lease time.Duration, limit int) ([]models.EscrowCapture, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE escrow_captures
+		SET status = 'capture_pending',
+			confirmed_at = COALESCE(confirmed_at, NOW()),
+			auto_confirmed = auto_confirmed OR status = 'awaiting_confirmation',
+			next_attempt_at = NOW() + make_interval(secs => $1),
+			updated_at = NOW()
+		WHERE job_id IN (
+			SELECT job_id FROM escrow_captures
+			WHERE (status = 'capture_pending' AND next_attempt_at <= NOW())
+				OR (status = 'awaiting_confirmation' AND confirm_by <= NOW())
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+captureColumns,
+		lease.Seconds(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var captures []models.EscrowCapture
+	for rows.Next() {
+		capture, err := scanEscrowCapture(rows)
+		if err != nil {
+			return nil, err
+		}
+		captures = append(captures, *capture)
+	}
+
+	return captures, rows.Err()
+}
@@ -0,0 +1,36 @@
+package escrow_capture
+
+import (
+	"context"
+	"moveshare/internal/models"
+	jobrepository "moveshare/internal/repository"
+	"time"
+)
+
+// CompleteJob отмечает работу выполненной и создает списание, ожидающее подтверждения подрядчика,
+// одной транзакцией: выполненная работа не может остаться без списания
+func (r *repository) CompleteJob(ctx context.Context, jobID, executorID int64, confirmBy time.Time) (*models.EscrowCapture, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := jobrepository.MarkJobCompletedTx(ctx, tx, jobID, executorID); err != nil {
+		return nil, err
+	}
+
+	capture, err := scanEscrowCapture(tx.QueryRow(ctx, `
+		INSERT INTO escrow_captures (job_id, contractor_id, executor_id, status, confirm_by, next_attempt_at)
+		SELECT id, contractor_id, executor_id, $2, $3, $3
+		FROM jobs
+		WHERE id = $1
+		RETURNING `+captureColumns,
+		jobID, models.EscrowCaptureAwaitingConfirmation, confirmBy,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return capture, tx.Commit(ctx)
+}
@@ -0,0 +1,29 @@
+package escrow_capture
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ConfirmEscrowCapture подтверждает выполнение, если списание все еще в статусе fromStatus,
+// и занимает списание на lease для немедленной попытки. Повтор неудавшегося списания начинает
+// счет попыток заново. Возвращает nil, если статус уже изменился
+func (r *repository) ConfirmEscrowCapture(ctx context.Context, jobID int64, fromStatus string, lease time.Duration) (*models.EscrowCapture, error) {
+	capture, err := scanEscrowCapture(r.db.QueryRow(ctx, `
+		UPDATE escrow_captures
+		SET status = 'capture_pending', confirmed_at = COALESCE(confirmed_at, NOW()),
+			attempts = CASE WHEN status = 'capture_failed' THEN 0 ELSE attempts END,
+			next_attempt_at = NOW() + make_interval(secs => $3), updated_at = NOW()
+		WHERE job_id = $1 AND status = $2
+		RETURNING `+captureColumns,
+		jobID, fromStatus, lease.Seconds(),
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return capture, err
+}
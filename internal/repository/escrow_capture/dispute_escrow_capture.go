@@ -0,0 +1,25 @@
+package escrow_capture
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DisputeEscrowCapture останавливает списание до решения администратора.
+// Оспорить можно только еще не подтвержденное выполнение; иначе возвращает nil
+func (r *repository) DisputeEscrowCapture(ctx context.Context, jobID int64, reason string) (*models.EscrowCapture, error) {
+	capture, err := scanEscrowCapture(r.db.QueryRow(ctx, `
+		UPDATE escrow_captures
+		SET status = 'disputed', disputed_at = NOW(), dispute_reason = $2, updated_at = NOW()
+		WHERE job_id = $1 AND status = 'awaiting_confirmation'
+		RETURNING `+captureColumns,
+		jobID, reason,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return capture, err
+}
@@ -0,0 +1,23 @@
+package escrow_capture
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetEscrowCapture возвращает списание оплаты работы или nil
+func (r *repository) GetEscrowCapture(ctx context.Context, jobID int64) (*models.EscrowCapture, error) {
+	capture, err := scanEscrowCapture(r.db.QueryRow(ctx, `
+		SELECT `+captureColumns+`
+		FROM escrow_captures
+		WHERE job_id = $1`,
+		jobID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return capture, err
+}
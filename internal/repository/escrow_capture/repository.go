@@ -0,0 +1,45 @@
+package escrow_capture
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EscrowCaptureRepository interface {
+	CompleteJob(ctx context.Context, jobID, executorID int64, confirmBy time.Time) (*models.EscrowCapture, error)
+	GetEscrowCapture(ctx context.Context, jobID int64) (*models.EscrowCapture, error)
+	ConfirmEscrowCapture(ctx context.Context, jobID int64, fromStatus string, lease time.Duration) (*models.EscrowCapture, error)
+	DisputeEscrowCapture(ctx context.Context, jobID int64, reason string) (*models.EscrowCapture, error)
+	ClaimDueEscrowCaptures(ctx context.Context, lease time.Duration, limit int) ([]models.EscrowCapture, error)
+	UpdateEscrowCapture(ctx context.Context, capture *models.EscrowCapture) error
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewEscrowCaptureRepository(db *pgxpool.Pool) EscrowCaptureRepository {
+	return &repository{db: db}
+}
+
+const captureColumns = `
+	job_id, contractor_id, executor_id, status, confirm_by, confirmed_at, auto_confirmed,
+	disputed_at, COALESCE(dispute_reason, ''), attempts, next_attempt_at, COALESCE(last_error, ''),
+	captured_at, created_at, updated_at`
+
+func scanEscrowCapture(row pgx.Row) (*models.EscrowCapture, error) {
+	var c models.EscrowCapture
+	err := row.Scan(
+		&c.JobID, &c.ContractorID, &c.ExecutorID, &c.Status, &c.ConfirmBy, &c.ConfirmedAt, &c.AutoConfirmed,
+		&c.DisputedAt, &c.DisputeReason, &c.Attempts, &c.NextAttemptAt, &c.LastError,
+		&c.CapturedAt, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,19 @@
+package escrow_capture
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+// UpdateEscrowCapture сохраняет ход списания
+func (r *repository) UpdateEscrowCapture(ctx context.Context, capture *models.EscrowCapture) error {
+	return r.db.QueryRow(ctx, `
+		UPDATE escrow_captures
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = NULLIF($5, ''),
+			captured_at = $6, updated_at = NOW()
+		WHERE job_id = $1
+		RETURNING updated_at`,
+		capture.JobID, capture.Status, capture.Attempts, capture.NextAttemptAt, capture.LastError,
+		capture.CapturedAt,
+	).Scan(&capture.UpdatedAt)
+}
@@ -0,0 +1,88 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Reserve занимает ключ за запросом. Истекший ключ и ключ, зависший в обработке дольше staleBefore,
+// освобождаются. Возвращает false, если ключ уже занят
+func (r *repository) Reserve(ctx context.Context, record *models.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	_, err := r.db.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+		  AND (expires_at < NOW() OR (status = $3 AND created_at < $4))`,
+		record.UserID, record.Key, models.IdempotencyStatusProcessing, staleBefore,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to release expired idempotency key: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_method, request_path, request_hash, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING
+		RETURNING id, created_at`,
+		record.UserID, record.Key, record.RequestMethod, record.RequestPath, record.RequestHash,
+		models.IdempotencyStatusProcessing, record.ExpiresAt,
+	).Scan(&record.ID, &record.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	record.Status = models.IdempotencyStatusProcessing
+	return true, nil
+}
+
+func (r *repository) GetKey(ctx context.Context, userID int64, key string) (*models.IdempotencyKey, error) {
+	record, err := scanKey(r.db.QueryRow(ctx, `
+		SELECT `+keyColumns+`
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2`,
+		userID, key,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return record, nil
+}
+
+func (r *repository) Complete(ctx context.Context, id int64, responseStatus int, contentType string, body []byte) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status = $2, response_status = $3, response_content_type = $4, response_body = $5, completed_at = NOW()
+		WHERE id = $1`,
+		id, models.IdempotencyStatusCompleted, responseStatus, contentType, body,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,44 @@
+package idempotency
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *models.IdempotencyKey, staleBefore time.Time) (bool, error)
+	GetKey(ctx context.Context, userID int64, key string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, id int64, responseStatus int, contentType string, body []byte) error
+	Delete(ctx context.Context, id int64) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) IdempotencyRepository {
+	return &repository{db: db}
+}
+
+const keyColumns = `
+	id, user_id, idempotency_key, request_method, request_path, request_hash, status,
+	COALESCE(response_status, 0), COALESCE(response_content_type, ''), response_body,
+	created_at, completed_at, expires_at`
+
+func scanKey(row pgx.Row) (*models.IdempotencyKey, error) {
+	var k models.IdempotencyKey
+	err := row.Scan(
+		&k.ID, &k.UserID, &k.Key, &k.RequestMethod, &k.RequestPath, &k.RequestHash, &k.Status,
+		&k.ResponseStatus, &k.ResponseContentType, &k.ResponseBody,
+		&k.CreatedAt, &k.CompletedAt, &k.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
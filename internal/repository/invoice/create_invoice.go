@@ -0,0 +1,63 @@
+package invoice
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateInvoice сохраняет счет со следующим номером из invoice_number_seq.
+// Возвращает false, если счет по этому платежу уже выставлен
+func (r *repository) CreateInvoice(ctx context.Context, invoice *models.Invoice) (bool, error) {
+	query := `
+		INSERT INTO invoices (
+			invoice_number, payment_id, user_id, job_id, line_items,
+			subtotal_cents, tax_rate, tax_cents, total_cents, currency
+		) VALUES (
+			'INV-' || LPAD(nextval('invoice_number_seq')::text, 6, '0'),
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+		ON CONFLICT (payment_id) DO NOTHING
+		RETURNING id, invoice_number, issued_at, created_at, updated_at`
+
+	err := r.db.QueryRow(ctx, query,
+		invoice.PaymentID,
+		invoice.UserID,
+		invoice.JobID,
+		invoice.LineItems,
+		invoice.SubtotalCents,
+		invoice.TaxRate,
+		invoice.TaxCents,
+		invoice.TotalCents,
+		invoice.Currency,
+	).Scan(&invoice.ID, &invoice.InvoiceNumber, &invoice.IssuedAt, &invoice.CreatedAt, &invoice.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *repository) SetInvoiceFile(ctx context.Context, invoiceID int64, objectName string, fileSize int64) error {
+	query := `
+		UPDATE invoices
+		SET object_name = $1, file_size = $2, updated_at = NOW()
+		WHERE id = $3`
+
+	_, err := r.db.Exec(ctx, query, objectName, fileSize, invoiceID)
+	return err
+}
+
+func (r *repository) MarkInvoiceEmailed(ctx context.Context, invoiceID int64, emailedTo string) error {
+	query := `
+		UPDATE invoices
+		SET emailed_to = $1, emailed_at = NOW(), updated_at = NOW()
+		WHERE id = $2`
+
+	_, err := r.db.Exec(ctx, query, emailedTo, invoiceID)
+	return err
+}
@@ -0,0 +1,74 @@
+package invoice
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) GetInvoiceByID(ctx context.Context, invoiceID int64) (*models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + `
+		FROM invoices i
+		JOIN payments p ON p.id = i.payment_id
+		WHERE i.id = $1`
+
+	invoice, err := scanInvoice(r.db.QueryRow(ctx, query, invoiceID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+func (r *repository) GetInvoiceByPaymentID(ctx context.Context, paymentID int64) (*models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + `
+		FROM invoices i
+		JOIN payments p ON p.id = i.payment_id
+		WHERE i.payment_id = $1`
+
+	invoice, err := scanInvoice(r.db.QueryRow(ctx, query, paymentID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+func (r *repository) GetUserInvoices(ctx context.Context, userID int64, limit, offset int) ([]models.Invoice, int, error) {
+	var total int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM invoices WHERE user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + invoiceColumns + `
+		FROM invoices i
+		JOIN payments p ON p.id = i.payment_id
+		WHERE i.user_id = $1
+		ORDER BY i.issued_at DESC, i.id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	invoices := make([]models.Invoice, 0)
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+
+	return invoices, total, rows.Err()
+}
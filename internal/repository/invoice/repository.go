@@ -0,0 +1,51 @@
+package invoice
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InvoiceRepository interface {
+	CreateInvoice(ctx context.Context, invoice *models.Invoice) (bool, error)
+	SetInvoiceFile(ctx context.Context, invoiceID int64, objectName string, fileSize int64) error
+	MarkInvoiceEmailed(ctx context.Context, invoiceID int64, emailedTo string) error
+
+	GetInvoiceByID(ctx context.Context, invoiceID int64) (*models.Invoice, error)
+	GetInvoiceByPaymentID(ctx context.Context, paymentID int64) (*models.Invoice, error)
+	GetUserInvoices(ctx context.Context, userID int64, limit, offset int) ([]models.Invoice, int, error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewInvoiceRepository(db *pgxpool.Pool) InvoiceRepository {
+	return &repository{db: db}
+}
+
+// Статус платежа и сумма возврата берутся из payments, чтобы счет отражал текущее состояние
+const invoiceColumns = `
+	i.id, i.invoice_number, i.payment_id, i.user_id, i.job_id, i.line_items,
+	i.subtotal_cents, i.tax_rate, i.tax_cents, i.total_cents, i.currency,
+	i.object_name, i.file_size, i.emailed_to, i.emailed_at, i.issued_at,
+	i.created_at, i.updated_at, p.status, p.amount_refunded_cents`
+
+func scanInvoice(row pgx.Row) (*models.Invoice, error) {
+	var inv models.Invoice
+	var amountRefundedCents int64
+	err := row.Scan(
+		&inv.ID, &inv.InvoiceNumber, &inv.PaymentID, &inv.UserID, &inv.JobID, &inv.LineItems,
+		&inv.SubtotalCents, &inv.TaxRate, &inv.TaxCents, &inv.TotalCents, &inv.Currency,
+		&inv.ObjectName, &inv.FileSize, &inv.EmailedTo, &inv.EmailedAt, &inv.IssuedAt,
+		&inv.CreatedAt, &inv.UpdatedAt, &inv.PaymentStatus, &amountRefundedCents,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inv.Status = models.InvoiceStatus(inv.TotalCents, amountRefundedCents)
+	return &inv, nil
+}
@@ -0,0 +1,40 @@
+package job_posting
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+)
+
+// ClaimDueJobPostings забирает незавершенные публикации, срок обработки которых наступил.
+// next_attempt_at сдвигается на lease: пока он не истек, запись не достанется другому обработчику
+func (r *repository) ClaimDueJobPostings(ctx context.Context, lease time.Duration, limit int) ([]models.JobPosting, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE job_posting_outbox
+		SET next_attempt_at = NOW() + make_interval(secs => $1), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM job_posting_outbox
+			WHERE status IN ('pending', 'awaiting_confirmation', 'canceling') AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+postingColumns,
+		lease.Seconds(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var postings []models.JobPosting
+	for rows.Next() {
+		posting, err := scanJobPosting(rows)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, *posting)
+	}
+
+	return postings, rows.Err()
+}
@@ -0,0 +1,44 @@
+package job_posting
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+// CompleteJobPosting публикует оплаченную работу и закрывает запись outbox.
+// Возвращает false, если работа уже не ждет оплаты (удалена или отменена)
+func (r *repository) CompleteJobPosting(ctx context.Context, posting *models.JobPosting) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE jobs
+		SET job_status = 'active', updated_at = NOW()
+		WHERE id = $1 AND job_status = 'pending_payment'`,
+		posting.JobID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	posting.Status = models.JobPostingCompleted
+	posting.LastError = ""
+	err = tx.QueryRow(ctx, `
+		UPDATE job_posting_outbox
+		SET status = $2, last_error = NULL, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		posting.ID, posting.Status,
+	).Scan(&posting.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
@@ -0,0 +1,36 @@
+package job_posting
+
+import (
+	"context"
+	"moveshare/internal/models"
+	jobrepository "moveshare/internal/repository"
+)
+
+// CreateJobPosting сохраняет работу и запись outbox одной транзакцией:
+// работа не может остаться без обработчика оплаты и наоборот
+func (r *repository) CreateJobPosting(ctx context.Context, job *models.Job, posting *models.JobPosting) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := jobrepository.InsertJob(ctx, tx, job); err != nil {
+		return err
+	}
+
+	posting.JobID = &job.ID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO job_posting_outbox (
+			job_id, user_id, payment_method_id, promo_code, boosts, template_name, status, next_attempt_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8)
+		RETURNING id, created_at, updated_at`,
+		posting.JobID, posting.UserID, posting.PaymentMethodID, posting.PromoCode, posting.Boosts,
+		posting.TemplateName, posting.Status, posting.NextAttemptAt,
+	).Scan(&posting.ID, &posting.CreatedAt, &posting.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
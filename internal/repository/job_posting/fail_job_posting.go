@@ -0,0 +1,37 @@
+package job_posting
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+// FailJobPosting удаляет неоплаченную работу и закрывает запись outbox.
+// Платежи к этому моменту уже отменены
+func (r *repository) FailJobPosting(ctx context.Context, posting *models.JobPosting) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if posting.JobID != nil {
+		_, err := tx.Exec(ctx, `DELETE FROM jobs WHERE id = $1 AND job_status = 'pending_payment'`, *posting.JobID)
+		if err != nil {
+			return err
+		}
+	}
+
+	posting.Status = models.JobPostingFailed
+	err = tx.QueryRow(ctx, `
+		UPDATE job_posting_outbox
+		SET status = $2, last_error = NULLIF($3, ''), updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		posting.ID, posting.Status, posting.LastError,
+	).Scan(&posting.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,25 @@
+package job_posting
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetJobPostingByJobID возвращает публикацию работы пользователя или nil
+func (r *repository) GetJobPostingByJobID(ctx context.Context, userID, jobID int64) (*models.JobPosting, error) {
+	posting, err := scanJobPosting(r.db.QueryRow(ctx, `
+		SELECT `+postingColumns+`
+		FROM job_posting_outbox
+		WHERE job_id = $1 AND user_id = $2
+		ORDER BY created_at DESC
+		LIMIT 1`,
+		jobID, userID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return posting, err
+}
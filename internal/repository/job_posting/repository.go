@@ -0,0 +1,49 @@
+package job_posting
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type JobPostingRepository interface {
+	CreateJobPosting(ctx context.Context, job *models.Job, posting *models.JobPosting) error
+	GetJobPostingByJobID(ctx context.Context, userID, jobID int64) (*models.JobPosting, error)
+	ClaimDueJobPostings(ctx context.Context, lease time.Duration, limit int) ([]models.JobPosting, error)
+	UpdateJobPosting(ctx context.Context, posting *models.JobPosting) error
+	CompleteJobPosting(ctx context.Context, posting *models.JobPosting) (bool, error)
+	FailJobPosting(ctx context.Context, posting *models.JobPosting) error
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewJobPostingRepository(db *pgxpool.Pool) JobPostingRepository {
+	return &repository{db: db}
+}
+
+const postingColumns = `
+	id, job_id, user_id, payment_method_id, COALESCE(promo_code, ''), boosts, COALESCE(template_name, ''),
+	status, attempts, next_attempt_at, COALESCE(last_error, ''),
+	COALESCE(escrow_payment_intent_id, ''), COALESCE(escrow_client_secret, ''),
+	COALESCE(fee_payment_intent_id, ''), COALESCE(fee_client_secret, ''),
+	created_at, updated_at`
+
+func scanJobPosting(row pgx.Row) (*models.JobPosting, error) {
+	var p models.JobPosting
+	err := row.Scan(
+		&p.ID, &p.JobID, &p.UserID, &p.PaymentMethodID, &p.PromoCode, &p.Boosts, &p.TemplateName,
+		&p.Status, &p.Attempts, &p.NextAttemptAt, &p.LastError,
+		&p.EscrowPaymentIntentID, &p.EscrowClientSecret,
+		&p.FeePaymentIntentID, &p.FeeClientSecret,
+		&p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
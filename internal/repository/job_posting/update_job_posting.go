@@ -0,0 +1,22 @@
+package job_posting
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+// UpdateJobPosting сохраняет ход обработки публикации
+func (r *repository) UpdateJobPosting(ctx context.Context, posting *models.JobPosting) error {
+	return r.db.QueryRow(ctx, `
+		UPDATE job_posting_outbox
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = NULLIF($5, ''),
+			escrow_payment_intent_id = NULLIF($6, ''), escrow_client_secret = NULLIF($7, ''),
+			fee_payment_intent_id = NULLIF($8, ''), fee_client_secret = NULLIF($9, ''),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		posting.ID, posting.Status, posting.Attempts, posting.NextAttemptAt, posting.LastError,
+		posting.EscrowPaymentIntentID, posting.EscrowClientSecret,
+		posting.FeePaymentIntentID, posting.FeeClientSecret,
+	).Scan(&posting.UpdatedAt)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -35,6 +36,21 @@ func scanJob(scanner interface {
 }
 
 func (r *JobRepository) CreateJob(ctx context.Context, job *models.Job) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := InsertJob(ctx, tx, job); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// InsertJob сохраняет работу вместе с промежуточными остановками в транзакции tx
+func InsertJob(ctx context.Context, tx pgx.Tx, job *models.Job) error {
 	query := `
 		INSERT INTO jobs (
 			contractor_id, executor_id, job_type, number_of_bedrooms, packing_boxes, bulky_items, 
@@ -43,13 +59,16 @@ func (r *JobRepository) CreateJob(ctx context.Context, job *models.Job) error {
 			delivery_address, delivery_city, delivery_state, delivery_floor, delivery_building_type, delivery_walk_distance,
 			distance_miles, job_status, pickup_date, pickup_time_from, pickup_time_to,
 			delivery_date, delivery_time_from, delivery_time_to, cut_amount, payment_amount,
-			weight_lbs, volume_cu_ft
+			weight_lbs, volume_cu_ft, distance_provider,
+			pickup_timezone, delivery_timezone, pickup_window_start, pickup_window_end,
+			delivery_window_start, delivery_window_end, fee_breakdown
 		) VALUES (
 			$1, NULL, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
-			$21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34
+			$21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35,
+			$36, $37, $38, $39, $40, $41, $42
 		) RETURNING id, created_at, updated_at`
 
-	return r.db.QueryRow(
+	err := tx.QueryRow(
 		ctx,
 		query,
 		job.ContractorID, job.JobType, job.NumberOfBedrooms, job.PackingBoxes, job.BulkyItems,
@@ -58,8 +77,71 @@ func (r *JobRepository) CreateJob(ctx context.Context, job *models.Job) error {
 		job.DeliveryAddress, job.DeliveryCity, job.DeliveryState, job.DeliveryFloor, job.DeliveryBuildingType, job.DeliveryWalkDistance,
 		job.DistanceMiles, job.JobStatus, job.PickupDate, job.PickupTimeFrom, job.PickupTimeTo,
 		job.DeliveryDate, job.DeliveryTimeFrom, job.DeliveryTimeTo, job.CutAmount, job.PaymentAmount,
-		job.WeightLbs, job.VolumeCuFt,
+		job.WeightLbs, job.VolumeCuFt, job.DistanceProvider,
+		job.PickupTimeZone, job.DeliveryTimeZone, job.PickupWindowStart, job.PickupWindowEnd,
+		job.DeliveryWindowStart, job.DeliveryWindowEnd, job.FeeBreakdown,
 	).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	stopQuery := `
+		INSERT INTO job_stops (
+			job_id, stop_order, stop_type, address, city, state, floor, building_type, walk_distance,
+			stop_date, time_from, time_to, timezone, window_start, window_end
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, '')::time, NULLIF($12, '')::time, $13, $14, $15)
+		RETURNING id`
+
+	for i := range job.Stops {
+		stop := &job.Stops[i]
+		stop.JobID = job.ID
+		err = tx.QueryRow(ctx, stopQuery,
+			job.ID, stop.StopOrder, stop.StopType, stop.Address, stop.City, stop.State, stop.Floor,
+			stop.BuildingType, stop.WalkDistance, stop.Date, stop.TimeFrom, stop.TimeTo, stop.TimeZone,
+			stop.WindowStart, stop.WindowEnd,
+		).Scan(&stop.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert job stop %d: %w", stop.StopOrder, err)
+		}
+	}
+
+	return nil
+}
+
+// GetJobStops загружает промежуточные остановки для списка работ одним запросом
+func (r *JobRepository) GetJobStops(ctx context.Context, jobIDs []int64) (map[int64][]models.JobStop, error) {
+	stops := make(map[int64][]models.JobStop)
+	if len(jobIDs) == 0 {
+		return stops, nil
+	}
+
+	query := `
+		SELECT id, job_id, stop_order, stop_type, address, city, state, floor,
+			   COALESCE(building_type, ''), COALESCE(walk_distance, ''), stop_date,
+			   COALESCE(to_char(time_from, 'HH24:MI'), ''), COALESCE(to_char(time_to, 'HH24:MI'), ''),
+			   COALESCE(timezone, ''), window_start, window_end
+		FROM job_stops
+		WHERE job_id = ANY($1)
+		ORDER BY job_id, stop_order`
+
+	rows, err := r.db.Query(ctx, query, jobIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job stops: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.JobStop
+		err := rows.Scan(&s.ID, &s.JobID, &s.StopOrder, &s.StopType, &s.Address, &s.City, &s.State, &s.Floor,
+			&s.BuildingType, &s.WalkDistance, &s.Date, &s.TimeFrom, &s.TimeTo, &s.TimeZone, &s.WindowStart, &s.WindowEnd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job stop: %w", err)
+		}
+		s.LocalizeWindow()
+		stops[s.JobID] = append(stops[s.JobID], s)
+	}
+
+	return stops, rows.Err()
 }
 
 func (r *JobRepository) GetJobByID(ctx context.Context, jobID int64) (*models.Job, error) {
@@ -70,7 +152,9 @@ func (r *JobRepository) GetJobByID(ctx context.Context, jobID int64) (*models.Jo
 			   j.delivery_address, j.delivery_city, j.delivery_state, j.delivery_floor, j.delivery_building_type, j.delivery_walk_distance,
 			   j.distance_miles, j.job_status, j.pickup_date, j.pickup_time_from, j.pickup_time_to,
 			   j.delivery_date, j.delivery_time_from, j.delivery_time_to, j.cut_amount, j.payment_amount,
-			   j.weight_lbs, j.volume_cu_ft, j.created_at, j.updated_at,
+			   j.weight_lbs, j.volume_cu_ft, j.created_at, j.updated_at, COALESCE(j.distance_provider, ''),
+			   COALESCE(j.pickup_timezone, ''), COALESCE(j.delivery_timezone, ''), j.pickup_window_start, j.pickup_window_end,
+			   j.delivery_window_start, j.delivery_window_end, j.fee_breakdown,
 			   u.username, u.status, 
 			   COALESCE(AVG(r.rating), 0) as avg_rating
 		FROM jobs j
@@ -83,7 +167,9 @@ func (r *JobRepository) GetJobByID(ctx context.Context, jobID int64) (*models.Jo
 				 j.delivery_address, j.delivery_city, j.delivery_state, j.delivery_floor, j.delivery_building_type, j.delivery_walk_distance,
 				 j.distance_miles, j.job_status, j.pickup_date, j.pickup_time_from, j.pickup_time_to,
 				 j.delivery_date, j.delivery_time_from, j.delivery_time_to, j.cut_amount, j.payment_amount,
-				 j.weight_lbs, j.volume_cu_ft, j.created_at, j.updated_at, u.username, u.status`
+				 j.weight_lbs, j.volume_cu_ft, j.created_at, j.updated_at, j.distance_provider,
+				 j.pickup_timezone, j.delivery_timezone, j.pickup_window_start, j.pickup_window_end,
+				 j.delivery_window_start, j.delivery_window_end, j.fee_breakdown, u.username, u.status`
 
 	var job models.Job
 	var username, status string
@@ -97,7 +183,9 @@ func (r *JobRepository) GetJobByID(ctx context.Context, jobID int64) (*models.Jo
 		&job.DeliveryBuildingType, &job.DeliveryWalkDistance, &job.DistanceMiles, &job.JobStatus,
 		&job.PickupDate, &job.PickupTimeFrom, &job.PickupTimeTo, &job.DeliveryDate,
 		&job.DeliveryTimeFrom, &job.DeliveryTimeTo, &job.CutAmount, &job.PaymentAmount,
-		&job.WeightLbs, &job.VolumeCuFt, &job.CreatedAt, &job.UpdatedAt,
+		&job.WeightLbs, &job.VolumeCuFt, &job.CreatedAt, &job.UpdatedAt, &job.DistanceProvider,
+		&job.PickupTimeZone, &job.DeliveryTimeZone, &job.PickupWindowStart, &job.PickupWindowEnd,
+		&job.DeliveryWindowStart, &job.DeliveryWindowEnd, &job.FeeBreakdown,
 		&username, &status, &avgRating,
 	)
 
@@ -109,10 +197,23 @@ func (r *JobRepository) GetJobByID(ctx context.Context, jobID int64) (*models.Jo
 	job.ContractorUsername = &username
 	job.ContractorStatus = &status
 	job.ContractorRating = &avgRating
+	job.LocalizeWindows(nil)
+
+	stops, err := r.GetJobStops(ctx, []int64{job.ID})
+	if err != nil {
+		return nil, err
+	}
+	job.Stops = stops[job.ID]
 
 	return &job, nil
 }
 
+// UpdateJobFeeBreakdown сохраняет пересчитанный сбор работы (после промокода и кредитов)
+func (r *JobRepository) UpdateJobFeeBreakdown(ctx context.Context, jobID int64, breakdown *models.FeeBreakdown) error {
+	_, err := r.db.Exec(ctx, `UPDATE jobs SET fee_breakdown = $2, updated_at = NOW() WHERE id = $1`, jobID, breakdown)
+	return err
+}
+
 func (r *JobRepository) DeleteJob(ctx context.Context, jobID, userID int64) error {
 	query := `DELETE FROM jobs WHERE id = $1 AND contractor_id = $2`
 	result, err := r.db.Exec(ctx, query, jobID, userID)
@@ -127,6 +228,23 @@ func (r *JobRepository) DeleteJob(ctx context.Context, jobID, userID int64) erro
 	return nil
 }
 
+// WithdrawJob снимает с биржи работу, которую еще никто не взял, и возвращает ее прежний статус.
+// Если работа не найдена, чужая или уже взята исполнителем - pgx.ErrNoRows
+func (r *JobRepository) WithdrawJob(ctx context.Context, jobID, contractorID int64) (string, error) {
+	var previousStatus string
+	err := r.db.QueryRow(ctx, `
+		UPDATE jobs j
+		SET job_status = 'canceled', updated_at = CURRENT_TIMESTAMP
+		FROM (SELECT id, job_status FROM jobs WHERE id = $1 FOR UPDATE) prev
+		WHERE j.id = prev.id
+		  AND j.contractor_id = $2
+		  AND j.executor_id IS NULL
+		  AND j.job_status IN ('pending_payment', 'active')
+		RETURNING prev.job_status`,
+		jobID, contractorID).Scan(&previousStatus)
+	return previousStatus, err
+}
+
 func (r *JobRepository) ClaimJob(ctx context.Context, jobID, userID int64) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -137,7 +255,7 @@ func (r *JobRepository) ClaimJob(ctx context.Context, jobID, userID int64) error
 	var contractorID int64
 	var status string
 	var executorID *int64
-	err = tx.QueryRow(ctx, "SELECT contractor_id, job_status, executor_id FROM jobs WHERE id = $1", jobID).Scan(&contractorID, &status, &executorID)
+	err = tx.QueryRow(ctx, "SELECT contractor_id, job_status, executor_id FROM jobs WHERE id = $1 FOR UPDATE", jobID).Scan(&contractorID, &status, &executorID)
 	if err != nil {
 		return err
 	}
@@ -175,7 +293,9 @@ func (r *JobRepository) GetMyJobs(ctx context.Context, userID int64, offset, lim
 			   j.distance_miles, j.job_status, j.pickup_date, j.pickup_time_from, j.pickup_time_to,
 			   j.delivery_date, j.delivery_time_from, j.delivery_time_to, j.cut_amount, j.payment_amount,
 			   j.weight_lbs, j.volume_cu_ft, j.created_at, j.updated_at,
-			   COALESCE(c.company_name, u.username) AS executor_name
+			   COALESCE(j.pickup_timezone, ''), COALESCE(j.delivery_timezone, ''),
+			   j.pickup_window_start, j.pickup_window_end, j.delivery_window_start, j.delivery_window_end,
+			   COALESCE(c.company_name, u.username) AS executor_name, j.fee_breakdown
 		FROM jobs j
 		LEFT JOIN users u ON j.executor_id = u.id
 		LEFT JOIN companies c ON u.id = c.user_id
@@ -201,7 +321,10 @@ func (r *JobRepository) GetMyJobs(ctx context.Context, userID int64, offset, lim
 			&job.DeliveryBuildingType, &job.DeliveryWalkDistance, &job.DistanceMiles, &job.JobStatus,
 			&job.PickupDate, &job.PickupTimeFrom, &job.PickupTimeTo, &job.DeliveryDate,
 			&job.DeliveryTimeFrom, &job.DeliveryTimeTo, &job.CutAmount, &job.PaymentAmount,
-			&job.WeightLbs, &job.VolumeCuFt, &job.CreatedAt, &job.UpdatedAt, &job.ExecutorName,
+			&job.WeightLbs, &job.VolumeCuFt, &job.CreatedAt, &job.UpdatedAt,
+			&job.PickupTimeZone, &job.DeliveryTimeZone,
+			&job.PickupWindowStart, &job.PickupWindowEnd, &job.DeliveryWindowStart, &job.DeliveryWindowEnd,
+			&job.ExecutorName, &job.FeeBreakdown,
 		)
 		if err != nil {
 			return nil, err
@@ -252,7 +375,9 @@ func (r *JobRepository) GetPendingJobs(ctx context.Context, userID int64, limit
 			   delivery_address, delivery_floor, delivery_building_type, delivery_walk_distance,
 			   distance_miles, job_status, pickup_date, pickup_time_from, pickup_time_to,
 			   delivery_date, delivery_time_from, delivery_time_to, cut_amount, payment_amount,
-			   weight_lbs, volume_cu_ft, created_at, updated_at
+			   weight_lbs, volume_cu_ft, created_at, updated_at,
+			   COALESCE(pickup_timezone, ''), COALESCE(delivery_timezone, ''),
+			   pickup_window_start, pickup_window_end, delivery_window_start, delivery_window_end
 		FROM jobs
 		WHERE executor_id = $1 AND job_status != 'completed'
 		ORDER BY pickup_date ASC, pickup_time_from ASC
@@ -276,6 +401,8 @@ func (r *JobRepository) GetPendingJobs(ctx context.Context, userID int64, limit
 			&job.PickupDate, &job.PickupTimeFrom, &job.PickupTimeTo, &job.DeliveryDate,
 			&job.DeliveryTimeFrom, &job.DeliveryTimeTo, &job.CutAmount, &job.PaymentAmount,
 			&job.WeightLbs, &job.VolumeCuFt, &job.CreatedAt, &job.UpdatedAt,
+			&job.PickupTimeZone, &job.DeliveryTimeZone,
+			&job.PickupWindowStart, &job.PickupWindowEnd, &job.DeliveryWindowStart, &job.DeliveryWindowEnd,
 		)
 		if err != nil {
 			return nil, err
@@ -301,7 +428,9 @@ func (r *JobRepository) GetClaimedJobs(ctx context.Context, userID int64, offset
 			   delivery_address, delivery_floor, delivery_building_type, delivery_walk_distance,
 			   distance_miles, job_status, pickup_date, pickup_time_from, pickup_time_to,
 			   delivery_date, delivery_time_from, delivery_time_to, cut_amount, payment_amount,
-			   weight_lbs, volume_cu_ft, created_at, updated_at
+			   weight_lbs, volume_cu_ft, created_at, updated_at,
+			   COALESCE(pickup_timezone, ''), COALESCE(delivery_timezone, ''),
+			   pickup_window_start, pickup_window_end, delivery_window_start, delivery_window_end
 		FROM jobs
 		WHERE executor_id = $1
 		ORDER BY updated_at DESC
@@ -325,6 +454,8 @@ func (r *JobRepository) GetClaimedJobs(ctx context.Context, userID int64, offset
 			&job.PickupDate, &job.PickupTimeFrom, &job.PickupTimeTo, &job.DeliveryDate,
 			&job.DeliveryTimeFrom, &job.DeliveryTimeTo, &job.CutAmount, &job.PaymentAmount,
 			&job.WeightLbs, &job.VolumeCuFt, &job.CreatedAt, &job.UpdatedAt,
+			&job.PickupTimeZone, &job.DeliveryTimeZone,
+			&job.PickupWindowStart, &job.PickupWindowEnd, &job.DeliveryWindowStart, &job.DeliveryWindowEnd,
 		)
 		if err != nil {
 			return nil, err
@@ -355,7 +486,12 @@ func (r *JobRepository) GetAvailableJobs(ctx context.Context, userID int64, filt
 	baseQuery := `
 		SELECT id, job_type, distance_miles, pickup_address, pickup_city, pickup_state, delivery_address, delivery_city, delivery_state,
 			   pickup_date, delivery_date, truck_size, weight_lbs, volume_cu_ft, payment_amount,
-			   contractor_id, number_of_bedrooms, cut_amount
+			   contractor_id, number_of_bedrooms, cut_amount,
+			   COALESCE(pickup_timezone, ''), COALESCE(delivery_timezone, ''),
+			   pickup_window_start, pickup_window_end, delivery_window_start, delivery_window_end,
+			   EXISTS(SELECT 1 FROM job_boosts b WHERE b.job_id = jobs.id AND b.boost_type = 'urgent' AND b.status = 'active' AND b.expires_at > NOW()) AS is_urgent,
+			   EXISTS(SELECT 1 FROM job_boosts b WHERE b.job_id = jobs.id AND b.boost_type = 'pin_to_top' AND b.status = 'active' AND b.expires_at > NOW()) AS is_pinned,
+			   (SELECT COUNT(*) FROM job_stops s WHERE s.job_id = jobs.id) AS stop_count
 		FROM jobs 
 		WHERE contractor_id != $1 AND job_status = 'active' AND executor_id IS NULL
 	`
@@ -398,13 +534,16 @@ func (r *JobRepository) GetAvailableJobs(ctx context.Context, userID int64, filt
 		}
 		fmt.Printf("=== END FILTER DEBUG ===\n")
 		
-		conditions = append(conditions, fmt.Sprintf("pickup_city || ', ' || pickup_state = $%d", paramIndex))
+		// Погрузка может быть и на промежуточной остановке
+		conditions = append(conditions, fmt.Sprintf(`(pickup_city || ', ' || pickup_state = $%[1]d OR EXISTS (
+			SELECT 1 FROM job_stops s WHERE s.job_id = jobs.id AND s.stop_type IN ('pickup', 'storage') AND s.city || ', ' || s.state = $%[1]d))`, paramIndex))
 		params = append(params, *filters.Origin)
 		paramIndex++
 	}
 
 	if filters.Destination != nil && *filters.Destination != "" {
-		conditions = append(conditions, fmt.Sprintf("delivery_city || ', ' || delivery_state = $%d", paramIndex))
+		conditions = append(conditions, fmt.Sprintf(`(delivery_city || ', ' || delivery_state = $%[1]d OR EXISTS (
+			SELECT 1 FROM job_stops s WHERE s.job_id = jobs.id AND s.stop_type IN ('dropoff', 'storage') AND s.city || ', ' || s.state = $%[1]d))`, paramIndex))
 		params = append(params, *filters.Destination)
 		paramIndex++
 	}
@@ -452,6 +591,12 @@ func (r *JobRepository) GetAvailableJobs(ctx context.Context, userID int64, filt
 		paramIndex++
 	}
 
+	if filters.MaxStops != nil {
+		conditions = append(conditions, fmt.Sprintf("(SELECT COUNT(*) FROM job_stops s WHERE s.job_id = jobs.id) <= $%d", paramIndex))
+		params = append(params, *filters.MaxStops)
+		paramIndex++
+	}
+
 	// Добавляем условия к запросам
 	if len(conditions) > 0 {
 		conditionStr := " AND " + strings.Join(conditions, " AND ")
@@ -493,8 +638,8 @@ func (r *JobRepository) GetAvailableJobs(ctx context.Context, userID int64, filt
 		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
 	}
 
-	// Добавляем сортировку и пагинацию
-	baseQuery += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1)
+	// Добавляем сортировку и пагинацию: закрепленные, затем срочные, затем новые
+	baseQuery += fmt.Sprintf(" ORDER BY is_pinned DESC, is_urgent DESC, created_at DESC LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1)
 	params = append(params, filters.Limit, offset)
 
 	// Выполняем запрос
@@ -512,6 +657,9 @@ func (r *JobRepository) GetAvailableJobs(ctx context.Context, userID int64, filt
 			&job.DeliveryAddress, &job.DeliveryCity, &job.DeliveryState, &job.PickupDate, &job.DeliveryDate, &job.TruckSize,
 			&job.WeightLbs, &job.VolumeCuFt, &job.PaymentAmount,
 			&job.ContractorID, &job.NumberOfBedrooms, &job.CutAmount,
+			&job.PickupTimeZone, &job.DeliveryTimeZone,
+			&job.PickupWindowStart, &job.PickupWindowEnd, &job.DeliveryWindowStart, &job.DeliveryWindowEnd,
+			&job.IsUrgent, &job.IsPinned, &job.StopCount,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan job: %w", err)
@@ -523,173 +671,21 @@ func (r *JobRepository) GetAvailableJobs(ctx context.Context, userID int64, filt
 		return nil, 0, fmt.Errorf("rows error: %w", err)
 	}
 
-	return jobs, total, nil
-}
-
-// Также нужно обновить GetCountAvailableJobs для работы с фильтрами
-func (r *JobRepository) GetCountAvailableJobsWithFilters(ctx context.Context, userID int64, filters *models.JobFilters) (int, error) {
-	countQuery := `
-		SELECT COUNT(*) 
-		FROM jobs 
-		WHERE contractor_id != $1 AND job_status = 'active' AND executor_id IS NULL
-	`
-
-	var conditions []string
-	var params []interface{}
-	params = append(params, userID)
-	paramIndex := 2
-
-	// Добавляем те же фильтры, что и в основном запросе
-	if filters.NumberOfBedrooms != nil && *filters.NumberOfBedrooms != "" {
-		conditions = append(conditions, fmt.Sprintf("number_of_bedrooms = $%d", paramIndex))
-		params = append(params, *filters.NumberOfBedrooms)
-		paramIndex++
-	}
-
-	if filters.Origin != nil && *filters.Origin != "" {
-		conditions = append(conditions, fmt.Sprintf("pickup_address ILIKE $%d", paramIndex))
-		params = append(params, "%"+*filters.Origin+"%")
-		paramIndex++
-	}
-
-	if filters.Destination != nil && *filters.Destination != "" {
-		conditions = append(conditions, fmt.Sprintf("delivery_address ILIKE $%d", paramIndex))
-		params = append(params, "%"+*filters.Destination+"%")
-		paramIndex++
-	}
-
-	if filters.MaxDistance != nil {
-		conditions = append(conditions, fmt.Sprintf("distance_miles <= $%d", paramIndex))
-		params = append(params, *filters.MaxDistance)
-		paramIndex++
-	}
-
-	if filters.DateStart != nil && *filters.DateStart != "" {
-		conditions = append(conditions, fmt.Sprintf("pickup_date >= $%d", paramIndex))
-		params = append(params, *filters.DateStart)
-		paramIndex++
-	}
-
-	if filters.DateEnd != nil && *filters.DateEnd != "" {
-		conditions = append(conditions, fmt.Sprintf("pickup_date <= $%d", paramIndex))
-		params = append(params, *filters.DateEnd)
-		paramIndex++
-	}
-
-	if filters.TruckSize != nil && *filters.TruckSize != "" {
-		sizes := strings.Fields(*filters.TruckSize)
-		if len(sizes) == 1 {
-			conditions = append(conditions, fmt.Sprintf("truck_size = $%d", paramIndex))
-			params = append(params, sizes[0])
-			paramIndex++
-		} else if len(sizes) > 1 {
-			conditions = append(conditions, fmt.Sprintf("truck_size = ANY($%d)", paramIndex))
-			params = append(params, sizes)
-			paramIndex++
+	jobIDs := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		if job.StopCount > 0 {
+			jobIDs = append(jobIDs, job.ID)
 		}
 	}
-
-	if filters.PayoutMin != nil {
-		conditions = append(conditions, fmt.Sprintf("payment_amount >= $%d", paramIndex))
-		params = append(params, *filters.PayoutMin)
-		paramIndex++
-	}
-
-	if filters.PayoutMax != nil {
-		conditions = append(conditions, fmt.Sprintf("payment_amount <= $%d", paramIndex))
-		params = append(params, *filters.PayoutMax)
-		paramIndex++
-	}
-
-	if len(conditions) > 0 {
-		countQuery += " AND " + strings.Join(conditions, " AND ")
-	}
-
-	var count int
-	err := r.db.QueryRow(ctx, countQuery, params...).Scan(&count)
-	return count, err
-}
-
-func (r *JobRepository) GetCountAvailableJobs(ctx context.Context, userID int64, filters *models.JobFilters) (int, error) {
-	countQuery := `
-		SELECT COUNT(*) 
-		FROM jobs 
-		WHERE contractor_id != $1 AND job_status = 'active' AND executor_id IS NULL
-	`
-
-	var conditions []string
-	var params []interface{}
-	params = append(params, userID)
-	paramIndex := 2
-
-	if filters.NumberOfBedrooms != nil && *filters.NumberOfBedrooms != "" {
-		conditions = append(conditions, fmt.Sprintf("number_of_bedrooms = $%d", paramIndex))
-		params = append(params, *filters.NumberOfBedrooms)
-		paramIndex++
-	}
-
-	if filters.Origin != nil && *filters.Origin != "" {
-		conditions = append(conditions, fmt.Sprintf("pickup_address ILIKE $%d", paramIndex))
-		params = append(params, "%"+*filters.Origin+"%")
-		paramIndex++
-	}
-
-	if filters.Destination != nil && *filters.Destination != "" {
-		conditions = append(conditions, fmt.Sprintf("delivery_address ILIKE $%d", paramIndex))
-		params = append(params, "%"+*filters.Destination+"%")
-		paramIndex++
-	}
-
-	if filters.MaxDistance != nil {
-		conditions = append(conditions, fmt.Sprintf("distance_miles <= $%d", paramIndex))
-		params = append(params, *filters.MaxDistance)
-		paramIndex++
-	}
-
-	if filters.DateStart != nil && *filters.DateStart != "" {
-		conditions = append(conditions, fmt.Sprintf("pickup_date >= $%d", paramIndex))
-		params = append(params, *filters.DateStart)
-		paramIndex++
-	}
-
-	if filters.DateEnd != nil && *filters.DateEnd != "" {
-		conditions = append(conditions, fmt.Sprintf("pickup_date <= $%d", paramIndex))
-		params = append(params, *filters.DateEnd)
-		paramIndex++
-	}
-
-	if filters.TruckSize != nil && *filters.TruckSize != "" {
-		sizes := strings.Fields(*filters.TruckSize)
-		if len(sizes) == 1 {
-			conditions = append(conditions, fmt.Sprintf("truck_size = $%d", paramIndex))
-			params = append(params, sizes[0])
-			paramIndex++
-		} else if len(sizes) > 1 {
-			conditions = append(conditions, fmt.Sprintf("truck_size = ANY($%d)", paramIndex))
-			params = append(params, sizes)
-			paramIndex++
-		}
-	}
-
-	if filters.PayoutMin != nil {
-		conditions = append(conditions, fmt.Sprintf("payment_amount >= $%d", paramIndex))
-		params = append(params, *filters.PayoutMin)
-		paramIndex++
-	}
-
-	if filters.PayoutMax != nil {
-		conditions = append(conditions, fmt.Sprintf("payment_amount <= $%d", paramIndex))
-		params = append(params, *filters.PayoutMax)
-		paramIndex++
+	stops, err := r.GetJobStops(ctx, jobIDs)
+	if err != nil {
+		return nil, 0, err
 	}
-
-	if len(conditions) > 0 {
-		countQuery += " AND " + strings.Join(conditions, " AND ")
+	for i := range jobs {
+		jobs[i].Stops = stops[jobs[i].ID]
 	}
 
-	var count int
-	err := r.db.QueryRow(ctx, countQuery, params...).Scan(&count)
-	return count, err
+	return jobs, total, nil
 }
 
 // internal/repository/job_repository.go - добавить метод GetFilterOptions
@@ -846,16 +842,11 @@ func (r *JobRepository) GetFilterOptions(ctx context.Context, userID int64) (*mo
 	return options, nil
 }
 
-func (r *JobRepository) MarkJobCompleted(ctx context.Context, jobID, userID int64) error {
-	tx, err := r.db.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
+// MarkJobCompletedTx отмечает работу исполнителя userID выполненной в транзакции tx
+func MarkJobCompletedTx(ctx context.Context, tx pgx.Tx, jobID, userID int64) error {
 	var currentStatus string
 	var executorID *int64
-	err = tx.QueryRow(ctx, "SELECT job_status, executor_id FROM jobs WHERE id = $1", jobID).Scan(&currentStatus, &executorID)
+	err := tx.QueryRow(ctx, "SELECT job_status, executor_id FROM jobs WHERE id = $1 FOR UPDATE", jobID).Scan(&currentStatus, &executorID)
 	if err != nil {
 		return err
 	}
@@ -877,11 +868,7 @@ func (r *JobRepository) MarkJobCompleted(ctx context.Context, jobID, userID int6
 		SET job_status = 'completed', updated_at = CURRENT_TIMESTAMP 
 		WHERE id = $1`,
 		jobID)
-	if err != nil {
-		return err
-	}
-
-	return tx.Commit(ctx)
+	return err
 }
 
 func (r *JobRepository) CancelJobs(ctx context.Context, jobIDs []int64, userID int64) (int, error) {
@@ -969,6 +956,11 @@ func (r *JobRepository) GetJobsByIDs(ctx context.Context, userID int64, jobIDs [
 		WHERE contractor_id = $1 AND id = ANY($2)
 		ORDER BY created_at DESC`
 
+	stops, err := r.GetJobStops(ctx, jobIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := r.db.Query(ctx, query, userID, jobIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query jobs by IDs: %w", err)
@@ -991,6 +983,7 @@ func (r *JobRepository) GetJobsByIDs(ctx context.Context, userID int64, jobIDs [
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan job: %w", err)
 		}
+		job.Stops = stops[job.ID]
 		jobs = append(jobs, job)
 	}
 
@@ -1074,6 +1067,18 @@ func (r *JobRepository) GetUserWorkStats(ctx context.Context, userID int64) (mod
 		return stats, fmt.Errorf("failed to get earnings: %w", err)
 	}
 
+	// Чаевые начисляются исполнителю целиком, за вычетом возвратов
+	tipsQuery := `
+		SELECT COALESCE(SUM(amount_cents - amount_refunded_cents), 0) / 100.0
+		FROM payments
+		WHERE tip_recipient_id = $1 AND payment_type = 'tip' AND status = 'succeeded'`
+
+	err = r.db.QueryRow(ctx, tipsQuery, userID).Scan(&stats.Tips)
+	if err != nil {
+		return stats, fmt.Errorf("failed to get tips: %w", err)
+	}
+	stats.Earnings += stats.Tips
+
 	// Получаем количество предстоящих работ (claimed, in_progress)
 	upcomingJobsQuery := `
 		SELECT COUNT(*)
@@ -1088,7 +1093,9 @@ func (r *JobRepository) GetUserWorkStats(ctx context.Context, userID int64) (mod
 	return stats, nil
 }
 
-func (r *JobRepository) GetTodayScheduleJobs(ctx context.Context, userID int64, offset, limit int) ([]models.Job, error) {
+// GetTodayScheduleJobs возвращает работы, окно погрузки которых начинается в [dayStart, dayEnd) -
+// границы "сегодня" в часовом поясе пользователя. Старые работы без окон сравниваются по дате.
+func (r *JobRepository) GetTodayScheduleJobs(ctx context.Context, userID int64, dayStart, dayEnd time.Time, offset, limit int) ([]models.Job, error) {
 	query := `
 		SELECT id, contractor_id, executor_id, job_type, number_of_bedrooms, packing_boxes, bulky_items,
 			   inventory_list, hoisting, additional_services_description, estimated_crew_assistants,
@@ -1096,14 +1103,17 @@ func (r *JobRepository) GetTodayScheduleJobs(ctx context.Context, userID int64,
 			   delivery_address, delivery_floor, delivery_building_type, delivery_walk_distance,
 			   distance_miles, job_status, pickup_date, pickup_time_from, pickup_time_to,
 			   delivery_date, delivery_time_from, delivery_time_to, cut_amount, payment_amount,
-			   weight_lbs, volume_cu_ft, created_at, updated_at
+			   weight_lbs, volume_cu_ft, created_at, updated_at,
+			   COALESCE(pickup_timezone, ''), COALESCE(delivery_timezone, ''),
+			   pickup_window_start, pickup_window_end, delivery_window_start, delivery_window_end
 		FROM jobs
 		WHERE executor_id = $1 
-		  AND DATE(pickup_date) = CURRENT_DATE
-		ORDER BY pickup_time_from ASC
-		LIMIT $2 OFFSET $3`
+		  AND ((pickup_window_start >= $2 AND pickup_window_start < $3)
+		       OR (pickup_window_start IS NULL AND DATE(pickup_date) = $4::date))
+		ORDER BY COALESCE(pickup_window_start, pickup_date::date + pickup_time_from::time) ASC
+		LIMIT $5 OFFSET $6`
 
-	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	rows, err := r.db.Query(ctx, query, userID, dayStart, dayEnd, dayStart.Format("2006-01-02"), limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query today's schedule jobs: %w", err)
 	}
@@ -1121,6 +1131,8 @@ func (r *JobRepository) GetTodayScheduleJobs(ctx context.Context, userID int64,
 			&job.PickupDate, &job.PickupTimeFrom, &job.PickupTimeTo, &job.DeliveryDate,
 			&job.DeliveryTimeFrom, &job.DeliveryTimeTo, &job.CutAmount, &job.PaymentAmount,
 			&job.WeightLbs, &job.VolumeCuFt, &job.CreatedAt, &job.UpdatedAt,
+			&job.PickupTimeZone, &job.DeliveryTimeZone,
+			&job.PickupWindowStart, &job.PickupWindowEnd, &job.DeliveryWindowStart, &job.DeliveryWindowEnd,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan job: %w", err)
@@ -1135,15 +1147,16 @@ func (r *JobRepository) GetTodayScheduleJobs(ctx context.Context, userID int64,
 	return jobs, nil
 }
 
-func (r *JobRepository) GetCountTodayScheduleJobs(ctx context.Context, userID int64) (int, error) {
+func (r *JobRepository) GetCountTodayScheduleJobs(ctx context.Context, userID int64, dayStart, dayEnd time.Time) (int, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM jobs
 		WHERE executor_id = $1 
-		  AND DATE(pickup_date) = CURRENT_DATE`
+		  AND ((pickup_window_start >= $2 AND pickup_window_start < $3)
+		       OR (pickup_window_start IS NULL AND DATE(pickup_date) = $4::date))`
 
 	var count int
-	err := r.db.QueryRow(ctx, query, userID).Scan(&count)
+	err := r.db.QueryRow(ctx, query, userID, dayStart, dayEnd, dayStart.Format("2006-01-02")).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count today's schedule jobs: %w", err)
 	}
@@ -1231,3 +1244,222 @@ func (r *JobRepository) GetJobFilesByType(ctx context.Context, jobID int64, file
 
 	return files, rows.Err()
 }
+
+// RecordJobMilestone фиксирует отметку исполнителя и переводит работу в in_progress
+func (r *JobRepository) RecordJobMilestone(ctx context.Context, jobID, userID int64, milestone string) (*models.JobMilestone, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var currentStatus string
+	var executorID *int64
+	err = tx.QueryRow(ctx, "SELECT job_status, executor_id FROM jobs WHERE id = $1 FOR UPDATE", jobID).Scan(&currentStatus, &executorID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+
+	// Отметки может ставить исполнитель или назначенный на работу член команды
+	if executorID == nil {
+		return nil, fmt.Errorf("job has not been claimed")
+	}
+	if *executorID != userID {
+		var isCrew bool
+		err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM job_crew_assignments WHERE job_id = $1 AND member_id = $2)", jobID, userID).Scan(&isCrew)
+		if err != nil {
+			return nil, err
+		}
+		if !isCrew {
+			return nil, fmt.Errorf("you are not assigned to this job")
+		}
+	}
+
+	if currentStatus != "claimed" && currentStatus != "pending" && currentStatus != "in_progress" {
+		return nil, fmt.Errorf("cannot record progress for a job with status %s", currentStatus)
+	}
+
+	recorded := make(map[string]bool)
+	rows, err := tx.Query(ctx, "SELECT milestone FROM job_milestones WHERE job_id = $1", jobID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		recorded[m] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if recorded[milestone] {
+		return nil, fmt.Errorf("milestone %s has already been recorded", milestone)
+	}
+	for _, m := range models.JobMilestoneOrder {
+		if m == milestone {
+			break
+		}
+		if !recorded[m] {
+			return nil, fmt.Errorf("milestone %s must be recorded before %s", m, milestone)
+		}
+	}
+
+	result := &models.JobMilestone{
+		JobID:      jobID,
+		Milestone:  milestone,
+		RecordedBy: userID,
+	}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO job_milestones (job_id, milestone, recorded_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, recorded_at`,
+		jobID, milestone, userID).Scan(&result.ID, &result.RecordedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if currentStatus != "in_progress" {
+		_, err = tx.Exec(ctx, "UPDATE jobs SET job_status = 'in_progress', updated_at = CURRENT_TIMESTAMP WHERE id = $1", jobID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *JobRepository) GetJobMilestones(ctx context.Context, jobID int64) ([]models.JobMilestone, error) {
+	query := `
+		SELECT id, job_id, milestone, recorded_by, recorded_at
+		FROM job_milestones
+		WHERE job_id = $1
+		ORDER BY recorded_at ASC`
+
+	rows, err := r.db.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	milestones := []models.JobMilestone{}
+	for rows.Next() {
+		var m models.JobMilestone
+		if err := rows.Scan(&m.ID, &m.JobID, &m.Milestone, &m.RecordedBy, &m.RecordedAt); err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, m)
+	}
+
+	return milestones, rows.Err()
+}
+
+func (r *JobRepository) IsJobCrewMember(ctx context.Context, jobID, userID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM job_crew_assignments WHERE job_id = $1 AND member_id = $2)`
+	err := r.db.QueryRow(ctx, query, jobID, userID).Scan(&exists)
+	return exists, err
+}
+
+// GetMoverPerformance считает пунктуальность исполнителя по отметкам job_milestones
+func (r *JobRepository) GetMoverPerformance(ctx context.Context, userID int64) (*models.MoverPerformance, error) {
+	query := `
+		WITH timeline AS (
+			SELECT j.id,
+				   COALESCE(j.pickup_window_end, j.pickup_date::date + j.pickup_time_to::time) AS pickup_deadline,
+				   COALESCE(j.delivery_window_end, j.delivery_date::date + j.delivery_time_to::time) AS delivery_deadline,
+				   MAX(m.recorded_at) FILTER (WHERE m.milestone = 'arrived_pickup') AS arrived_pickup,
+				   MAX(m.recorded_at) FILTER (WHERE m.milestone = 'loading_finished') AS loading_finished,
+				   MAX(m.recorded_at) FILTER (WHERE m.milestone = 'departed_pickup') AS departed_pickup,
+				   MAX(m.recorded_at) FILTER (WHERE m.milestone = 'arrived_delivery') AS arrived_delivery,
+				   MAX(m.recorded_at) FILTER (WHERE m.milestone = 'unload_finished') AS unload_finished
+			FROM jobs j
+			JOIN job_milestones m ON m.job_id = j.id
+			WHERE j.executor_id = $1
+			GROUP BY j.id
+		)
+		SELECT COUNT(*),
+			   COUNT(arrived_pickup),
+			   COUNT(*) FILTER (WHERE arrived_pickup <= pickup_deadline),
+			   COUNT(arrived_delivery),
+			   COUNT(*) FILTER (WHERE arrived_delivery <= delivery_deadline),
+			   COALESCE(AVG(GREATEST(EXTRACT(EPOCH FROM arrived_pickup - pickup_deadline) / 60, 0)), 0),
+			   COALESCE(AVG(GREATEST(EXTRACT(EPOCH FROM arrived_delivery - delivery_deadline) / 60, 0)), 0),
+			   COALESCE(AVG(EXTRACT(EPOCH FROM loading_finished - arrived_pickup) / 60), 0),
+			   COALESCE(AVG(EXTRACT(EPOCH FROM arrived_delivery - departed_pickup) / 60), 0),
+			   COALESCE(AVG(EXTRACT(EPOCH FROM unload_finished - arrived_delivery) / 60), 0)
+		FROM timeline`
+
+	perf := &models.MoverPerformance{UserID: userID}
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&perf.TrackedJobs,
+		&perf.PickupsRecorded,
+		&perf.OnTimePickups,
+		&perf.DeliveriesRecorded,
+		&perf.OnTimeDeliveries,
+		&perf.AvgPickupDelayMinutes,
+		&perf.AvgDeliveryDelayMinutes,
+		&perf.AvgLoadingMinutes,
+		&perf.AvgTransitMinutes,
+		&perf.AvgUnloadMinutes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if perf.PickupsRecorded > 0 {
+		perf.OnTimePickupRate = float64(perf.OnTimePickups) / float64(perf.PickupsRecorded) * 100
+	}
+	if perf.DeliveriesRecorded > 0 {
+		perf.OnTimeDeliveryRate = float64(perf.OnTimeDeliveries) / float64(perf.DeliveriesRecorded) * 100
+	}
+
+	return perf, nil
+}
+
+// FindDuplicateJobs ищет работы того же заказчика с теми же адресами, датами и близкой оплатой,
+// опубликованные за последние withinHours часов
+func (r *JobRepository) FindDuplicateJobs(ctx context.Context, contractorID int64, pickupAddress, deliveryAddress string, pickupDate, deliveryDate time.Time, minPayment, maxPayment float64, withinHours int) ([]models.DuplicateJobCandidate, error) {
+	query := `
+		SELECT id, job_status, pickup_address, delivery_address, pickup_date, delivery_date,
+			   COALESCE(payment_amount, 0)::float8, created_at
+		FROM jobs
+		WHERE contractor_id = $1
+		  AND TRIM(regexp_replace(LOWER(pickup_address), '\s+', ' ', 'g')) = TRIM(regexp_replace(LOWER($2), '\s+', ' ', 'g'))
+		  AND TRIM(regexp_replace(LOWER(delivery_address), '\s+', ' ', 'g')) = TRIM(regexp_replace(LOWER($3), '\s+', ' ', 'g'))
+		  AND pickup_date = $4::date
+		  AND delivery_date = $5::date
+		  AND payment_amount BETWEEN $6 AND $7
+		  AND created_at >= NOW() - make_interval(hours => $8)
+		  AND job_status <> 'canceled'
+		ORDER BY created_at DESC
+		LIMIT 5`
+
+	rows, err := r.db.Query(ctx, query, contractorID, pickupAddress, deliveryAddress,
+		pickupDate.Format("2006-01-02"), deliveryDate.Format("2006-01-02"), minPayment, maxPayment, withinHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []models.DuplicateJobCandidate
+	for rows.Next() {
+		var c models.DuplicateJobCandidate
+		if err := rows.Scan(&c.ID, &c.JobStatus, &c.PickupAddress, &c.DeliveryAddress,
+			&c.PickupDate, &c.DeliveryDate, &c.PaymentAmount, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate job: %w", err)
+		}
+		c.URL = fmt.Sprintf("/jobs/%d/details/", c.ID)
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
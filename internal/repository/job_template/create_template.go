@@ -0,0 +1,31 @@
+package job_template
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) CreateTemplate(ctx context.Context, t *models.JobTemplate) error {
+	query := `
+		INSERT INTO job_templates (
+			user_id, name, job_type, number_of_bedrooms, packing_boxes, bulky_items, inventory_list, hoisting,
+			additional_services_description, estimated_crew_assistants, truck_size,
+			pickup_address, pickup_city, pickup_state, pickup_floor, pickup_building_type, pickup_walk_distance,
+			delivery_address, delivery_city, delivery_state, delivery_floor, delivery_building_type, delivery_walk_distance,
+			pickup_time_from, pickup_time_to, delivery_time_from, delivery_time_to, delivery_offset_days,
+			cut_amount, payment_amount, weight_lbs, volume_cu_ft
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+			$18, $19, $20, $21, $22, $23, $24::time, $25::time, $26::time, $27::time, $28, $29, $30, $31, $32
+		)
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(ctx, query,
+		t.UserID, t.Name, t.JobType, t.NumberOfBedrooms, t.PackingBoxes, t.BulkyItems, t.InventoryList, t.Hoisting,
+		t.AdditionalServicesDescription, t.EstimatedCrewAssistants, t.TruckSize,
+		t.PickupAddress, t.PickupCity, t.PickupState, t.PickupFloor, t.PickupBuildingType, t.PickupWalkDistance,
+		t.DeliveryAddress, t.DeliveryCity, t.DeliveryState, t.DeliveryFloor, t.DeliveryBuildingType, t.DeliveryWalkDistance,
+		t.PickupTimeFrom, t.PickupTimeTo, t.DeliveryTimeFrom, t.DeliveryTimeTo, t.DeliveryOffsetDays,
+		t.CutAmount, t.PaymentAmount, t.WeightLbs, t.VolumeCuFt,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
@@ -0,0 +1,19 @@
+package job_template
+
+import (
+	"context"
+	"fmt"
+)
+
+func (r *repository) DeleteTemplate(ctx context.Context, userID, templateID int64) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM job_templates WHERE id = $1 AND user_id = $2`, templateID, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("template not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package job_template
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) GetTemplates(ctx context.Context, userID int64) ([]models.JobTemplate, error) {
+	query := `SELECT ` + templateColumns + ` FROM job_templates WHERE user_id = $1 ORDER BY name ASC, id ASC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.JobTemplate{}
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *repository) GetTemplateByID(ctx context.Context, userID, templateID int64) (*models.JobTemplate, error) {
+	query := `SELECT ` + templateColumns + ` FROM job_templates WHERE id = $1 AND user_id = $2`
+	return scanTemplate(r.db.QueryRow(ctx, query, templateID, userID))
+}
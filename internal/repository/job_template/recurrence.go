@@ -0,0 +1,97 @@
+package job_template
+
+import (
+	"context"
+	"fmt"
+	"moveshare/internal/models"
+	"time"
+)
+
+// SetRecurrence сохраняет правило повторения, nil отключает автопубликацию
+func (r *repository) SetRecurrence(ctx context.Context, userID, templateID int64, rec *models.TemplateRecurrence) error {
+	var query string
+	var args []interface{}
+
+	if rec == nil {
+		query = `
+			UPDATE job_templates
+			SET recurrence_active = FALSE, recurrence_next_pickup = NULL, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2`
+		args = []interface{}{templateID, userID}
+	} else {
+		query = `
+			UPDATE job_templates
+			SET recurrence_active = TRUE,
+				recurrence_weekdays = $3,
+				recurrence_interval_weeks = $4,
+				recurrence_lead_days = $5,
+				recurrence_starts_on = $6,
+				recurrence_ends_on = $7,
+				recurrence_next_pickup = $8,
+				recurrence_payment_method_id = $9,
+				updated_at = NOW()
+			WHERE id = $1 AND user_id = $2`
+		args = []interface{}{templateID, userID, rec.Weekdays, rec.IntervalWeeks, rec.LeadDays,
+			rec.StartsOn, rec.EndsOn, rec.NextPickupDate, rec.PaymentMethodID}
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("template not found")
+	}
+
+	return nil
+}
+
+// GetDueRecurringTemplates - шаблоны, чью следующую работу пора публиковать
+func (r *repository) GetDueRecurringTemplates(ctx context.Context, today time.Time) ([]models.JobTemplate, error) {
+	query := `SELECT ` + templateColumns + `
+		FROM job_templates
+		WHERE recurrence_active
+		  AND recurrence_next_pickup IS NOT NULL
+		  AND recurrence_next_pickup - recurrence_lead_days <= $1::date
+		ORDER BY recurrence_next_pickup ASC`
+
+	rows, err := r.db.Query(ctx, query, today.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []models.JobTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+
+	return templates, rows.Err()
+}
+
+// AdvanceRecurrence переносит следующую дату, только если она не изменилась с момента чтения.
+// Возвращает false, если шаблон уже обработал другой экземпляр сервиса.
+func (r *repository) AdvanceRecurrence(ctx context.Context, templateID int64, current time.Time, next *time.Time) (bool, error) {
+	query := `
+		UPDATE job_templates
+		SET recurrence_next_pickup = $3,
+			recurrence_active = $3::date IS NOT NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND recurrence_active AND recurrence_next_pickup = $2::date`
+
+	result, err := r.db.Exec(ctx, query, templateID, current.Format("2006-01-02"), next)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *repository) SetLastPostedJob(ctx context.Context, templateID, jobID int64) error {
+	_, err := r.db.Exec(ctx, `UPDATE job_templates SET last_posted_job_id = $2, updated_at = NOW() WHERE id = $1`, templateID, jobID)
+	return err
+}
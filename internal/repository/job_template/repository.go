@@ -0,0 +1,72 @@
+package job_template
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type JobTemplateRepository interface {
+	CreateTemplate(ctx context.Context, template *models.JobTemplate) error
+	GetTemplates(ctx context.Context, userID int64) ([]models.JobTemplate, error)
+	GetTemplateByID(ctx context.Context, userID, templateID int64) (*models.JobTemplate, error)
+	DeleteTemplate(ctx context.Context, userID, templateID int64) error
+	SetRecurrence(ctx context.Context, userID, templateID int64, recurrence *models.TemplateRecurrence) error
+	GetDueRecurringTemplates(ctx context.Context, today time.Time) ([]models.JobTemplate, error)
+	AdvanceRecurrence(ctx context.Context, templateID int64, current time.Time, next *time.Time) (bool, error)
+	SetLastPostedJob(ctx context.Context, templateID, jobID int64) error
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewJobTemplateRepository(db *pgxpool.Pool) JobTemplateRepository {
+	return &repository{db: db}
+}
+
+const templateColumns = `
+	id, user_id, name, job_type, COALESCE(number_of_bedrooms, ''), packing_boxes, bulky_items, inventory_list, hoisting,
+	additional_services_description, COALESCE(estimated_crew_assistants, ''), truck_size,
+	pickup_address, pickup_city, pickup_state, pickup_floor, COALESCE(pickup_building_type, ''), COALESCE(pickup_walk_distance, ''),
+	delivery_address, delivery_city, delivery_state, delivery_floor, COALESCE(delivery_building_type, ''), COALESCE(delivery_walk_distance, ''),
+	to_char(pickup_time_from, 'HH24:MI'), to_char(pickup_time_to, 'HH24:MI'),
+	to_char(delivery_time_from, 'HH24:MI'), to_char(delivery_time_to, 'HH24:MI'), delivery_offset_days,
+	COALESCE(cut_amount, 0)::float8, payment_amount::float8, COALESCE(weight_lbs, 0)::float8, COALESCE(volume_cu_ft, 0)::float8,
+	recurrence_active, COALESCE(recurrence_weekdays, '{}'), recurrence_interval_weeks, recurrence_lead_days,
+	recurrence_starts_on, recurrence_ends_on, recurrence_next_pickup, recurrence_payment_method_id,
+	last_posted_job_id, created_at, updated_at`
+
+func scanTemplate(row pgx.Row) (*models.JobTemplate, error) {
+	var t models.JobTemplate
+	var active bool
+	var recurrence models.TemplateRecurrence
+	var startsOn *time.Time
+
+	err := row.Scan(
+		&t.ID, &t.UserID, &t.Name, &t.JobType, &t.NumberOfBedrooms, &t.PackingBoxes, &t.BulkyItems, &t.InventoryList, &t.Hoisting,
+		&t.AdditionalServicesDescription, &t.EstimatedCrewAssistants, &t.TruckSize,
+		&t.PickupAddress, &t.PickupCity, &t.PickupState, &t.PickupFloor, &t.PickupBuildingType, &t.PickupWalkDistance,
+		&t.DeliveryAddress, &t.DeliveryCity, &t.DeliveryState, &t.DeliveryFloor, &t.DeliveryBuildingType, &t.DeliveryWalkDistance,
+		&t.PickupTimeFrom, &t.PickupTimeTo, &t.DeliveryTimeFrom, &t.DeliveryTimeTo, &t.DeliveryOffsetDays,
+		&t.CutAmount, &t.PaymentAmount, &t.WeightLbs, &t.VolumeCuFt,
+		&active, &recurrence.Weekdays, &recurrence.IntervalWeeks, &recurrence.LeadDays,
+		&startsOn, &recurrence.EndsOn, &recurrence.NextPickupDate, &recurrence.PaymentMethodID,
+		&t.LastPostedJobID, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if active {
+		if startsOn != nil {
+			recurrence.StartsOn = *startsOn
+		}
+		t.Recurrence = &recurrence
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,123 @@
+package ledger
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const balanceQuery = `
+	SELECT a.code, a.name, a.account_type, a.user_id,
+		   COALESCE(SUM(e.debit_cents), 0), COALESCE(SUM(e.credit_cents), 0)
+	FROM ledger_accounts a
+	LEFT JOIN ledger_entries e ON e.account_id = a.id`
+
+func scanBalance(row pgx.Row) (*models.LedgerAccountBalance, error) {
+	var b models.LedgerAccountBalance
+	if err := row.Scan(&b.AccountCode, &b.Name, &b.Type, &b.UserID, &b.DebitCents, &b.CreditCents); err != nil {
+		return nil, err
+	}
+	b.CalculateBalance()
+	return &b, nil
+}
+
+// GetAccountBalance возвращает обороты и остаток счета или nil, если по счету еще не было проводок
+func (r *repository) GetAccountBalance(ctx context.Context, accountCode string) (*models.LedgerAccountBalance, error) {
+	query := balanceQuery + `
+		WHERE a.code = $1
+		GROUP BY a.id`
+
+	balance, err := scanBalance(r.db.QueryRow(ctx, query, accountCode))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+func (r *repository) GetAccountEntries(ctx context.Context, accountCode string, limit, offset int) ([]models.LedgerEntry, int, error) {
+	var total int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE a.code = $1`, accountCode).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT e.id, e.transaction_id, a.code, e.debit_cents, e.credit_cents, e.created_at
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE a.code = $1
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $2 OFFSET $3`, accountCode, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.LedgerEntry, 0)
+	for rows.Next() {
+		var e models.LedgerEntry
+		if err := rows.Scan(&e.ID, &e.TransactionID, &e.AccountCode, &e.DebitCents, &e.CreditCents, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, total, rows.Err()
+}
+
+func (r *repository) GetTrialBalance(ctx context.Context) ([]models.LedgerAccountBalance, error) {
+	query := balanceQuery + `
+		GROUP BY a.id
+		ORDER BY a.user_id NULLS FIRST, a.code`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make([]models.LedgerAccountBalance, 0)
+	for rows.Next() {
+		b, err := scanBalance(rows)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, *b)
+	}
+
+	return balances, rows.Err()
+}
+
+// GetUnbalancedTransactions - контроль целостности: транзакции, у которых дебет не равен кредиту
+func (r *repository) GetUnbalancedTransactions(ctx context.Context) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT transaction_id
+		FROM ledger_entries
+		GROUP BY transaction_id
+		HAVING SUM(debit_cents) <> SUM(credit_cents)
+		ORDER BY transaction_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
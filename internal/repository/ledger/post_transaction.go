@@ -0,0 +1,80 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PostTransaction сохраняет транзакцию и ее проводки атомарно, создавая недостающие счета.
+// Возвращает false, если транзакция с таким источником уже проведена
+func (r *repository) PostTransaction(ctx context.Context, txn *models.LedgerTransaction) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO ledger_transactions (reference_type, reference_id, description)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (reference_type, reference_id) DO NOTHING
+		RETURNING id, created_at`,
+		txn.ReferenceType, txn.ReferenceID, txn.Description,
+	).Scan(&txn.ID, &txn.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for i := range txn.Entries {
+		entry := &txn.Entries[i]
+
+		accountID, err := ensureAccount(ctx, tx, entry.AccountCode)
+		if err != nil {
+			return false, err
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO ledger_entries (transaction_id, account_id, debit_cents, credit_cents)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at`,
+			txn.ID, accountID, entry.DebitCents, entry.CreditCents,
+		).Scan(&entry.ID, &entry.CreatedAt)
+		if err != nil {
+			return false, err
+		}
+		entry.TransactionID = txn.ID
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func ensureAccount(ctx context.Context, tx pgx.Tx, code string) (int64, error) {
+	account, err := models.LedgerAccountForCode(code)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO ledger_accounts (code, name, account_type, user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code) DO NOTHING`,
+		account.Code, account.Name, account.Type, account.UserID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create ledger account %s: %w", code, err)
+	}
+
+	var id int64
+	err = tx.QueryRow(ctx, `SELECT id FROM ledger_accounts WHERE code = $1`, code).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,25 @@
+package ledger
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LedgerRepository interface {
+	PostTransaction(ctx context.Context, txn *models.LedgerTransaction) (bool, error)
+
+	GetAccountBalance(ctx context.Context, accountCode string) (*models.LedgerAccountBalance, error)
+	GetAccountEntries(ctx context.Context, accountCode string, limit, offset int) ([]models.LedgerEntry, int, error)
+	GetTrialBalance(ctx context.Context) ([]models.LedgerAccountBalance, error)
+	GetUnbalancedTransactions(ctx context.Context) ([]int64, error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewLedgerRepository(db *pgxpool.Pool) LedgerRepository {
+	return &repository{db: db}
+}
@@ -4,7 +4,9 @@ package payment
 import (
 	"context"
 	"moveshare/internal/models"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -21,11 +23,47 @@ type PaymentRepository interface {
 	DeletePaymentMethod(ctx context.Context, userID, paymentMethodID int64) error
 	SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID int64) error
 	GetDefaultPaymentMethod(ctx context.Context, userID int64) (*models.UserPaymentMethod, error)
+	DeactivatePaymentMethodByStripeID(ctx context.Context, stripePaymentMethodID string) error
+	UpdatePaymentMethodCard(ctx context.Context, stripePaymentMethodID, brand, last4 string, expMonth, expYear int) error
+	UpdateBankAccountVerification(ctx context.Context, setupIntentID, status, mandateID string) (*models.UserPaymentMethod, error)
 
 	SavePayment(ctx context.Context, payment *models.Payment) error
 	GetPaymentByStripeIntentID(ctx context.Context, stripePaymentIntentID string) (*models.Payment, error)
+	GetPaymentByID(ctx context.Context, paymentID int64) (*models.Payment, error)
 	UpdatePaymentStatus(ctx context.Context, paymentID int64, status, failureReason string) error
 	GetUserPayments(ctx context.Context, userID int64, limit, offset int) ([]models.Payment, error)
+	GetPaymentsCreatedBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error)
+
+	// Escrow
+	GetJobEscrowPayment(ctx context.Context, jobID int64) (*models.Payment, error)
+	UpdateAuthorizationState(ctx context.Context, paymentID int64, authorizationStatus, captureStatus string, authorizedAt, expiresAt *time.Time) error
+	MarkPaymentCaptured(ctx context.Context, paymentID, amountCapturedCents int64, status string) error
+	MarkPaymentReleased(ctx context.Context, paymentID int64, status, authorizationStatus string) error
+	GetExpiringAuthorizations(ctx context.Context, before time.Time, limit int) ([]models.Payment, error)
+
+	// Refunds & disputes
+	UpdatePaymentRefund(ctx context.Context, paymentID, amountRefundedCents int64, fullyRefunded bool) error
+	ApplySucceededRefunds(ctx context.Context, paymentID, refundableCents int64) (previousCents, refundedCents int64, err error)
+	SaveDispute(ctx context.Context, dispute *models.PaymentDispute) error
+	ReserveRefund(ctx context.Context, refund *models.PaymentRefund, refundableCents int64) (reserved bool, remainingCents int64, err error)
+	UpdateRefund(ctx context.Context, refund *models.PaymentRefund) error
+	UpsertStripeRefund(ctx context.Context, refund *models.PaymentRefund) error
+	GetRefundByID(ctx context.Context, refundID int64) (*models.PaymentRefund, error)
+	GetRefundByStripeID(ctx context.Context, stripeRefundID string) (*models.PaymentRefund, error)
+	GetPaymentRefunds(ctx context.Context, paymentIDs []int64) ([]models.PaymentRefund, error)
+	GetJobStatus(ctx context.Context, jobID int64) (string, error)
+
+	// Tips
+	GetJobTip(ctx context.Context, jobID int64) (*models.Payment, error)
+	GetUnconfirmedJobTips(ctx context.Context, jobID int64) ([]models.Payment, error)
+	GetJobParticipants(ctx context.Context, jobID int64) (*models.JobParticipants, error)
+
+	// Stripe webhook events
+	SaveStripeEvent(ctx context.Context, event *models.StripeEvent) (bool, error)
+	ClaimStripeEvent(ctx context.Context, stripeEventID string) (bool, error)
+	FinishStripeEvent(ctx context.Context, stripeEventID, status, lastError string) error
+	GetStripeEvent(ctx context.Context, stripeEventID string) (*models.StripeEvent, error)
+	GetStripeEventsByStatus(ctx context.Context, status string, limit int) ([]models.StripeEvent, error)
 }
 
 type repository struct {
@@ -61,6 +99,28 @@ func (r *repository) UpdateUserStripeCustomerID(ctx context.Context, userID int6
 }
 
 // internal/repository/payment/payment_methods.go
+const paymentMethodColumns = `
+	id, user_id, stripe_payment_method_id, stripe_customer_id, type, status,
+	COALESCE(card_last4, ''), COALESCE(card_brand, ''), COALESCE(card_exp_month, 0), COALESCE(card_exp_year, 0),
+	COALESCE(bank_name, ''), COALESCE(bank_last4, ''), COALESCE(bank_account_type, ''),
+	COALESCE(stripe_setup_intent_id, ''), COALESCE(stripe_mandate_id, ''),
+	is_default, is_active, created_at, updated_at`
+
+func scanPaymentMethod(row pgx.Row) (*models.UserPaymentMethod, error) {
+	var pm models.UserPaymentMethod
+	err := row.Scan(
+		&pm.ID, &pm.UserID, &pm.StripePaymentMethodID, &pm.StripeCustomerID, &pm.Type, &pm.Status,
+		&pm.CardLast4, &pm.CardBrand, &pm.CardExpMonth, &pm.CardExpYear,
+		&pm.BankName, &pm.BankLast4, &pm.BankAccountType,
+		&pm.StripeSetupIntentID, &pm.StripeMandateID,
+		&pm.IsDefault, &pm.IsActive, &pm.CreatedAt, &pm.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pm, nil
+}
+
 func (r *repository) SavePaymentMethod(ctx context.Context, paymentMethod *models.UserPaymentMethod) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -81,12 +141,13 @@ func (r *repository) SavePaymentMethod(ctx context.Context, paymentMethod *model
 		}
 	}
 
-	// Если у пользователя нет других карт, делаем эту default
-	if !paymentMethod.IsDefault {
+	// Если у пользователя нет других карт, делаем эту default.
+	// Неподтвержденный банковский счет станет default только после подтверждения
+	if !paymentMethod.IsDefault && paymentMethod.IsUsable() {
 		countQuery := `
 			SELECT COUNT(*) 
 			FROM user_payment_methods 
-			WHERE user_id = $1 AND is_active = true
+			WHERE user_id = $1 AND is_active = true AND status = 'active'
 		`
 		var count int
 		err = tx.QueryRow(ctx, countQuery, paymentMethod.UserID).Scan(&count)
@@ -102,10 +163,16 @@ func (r *repository) SavePaymentMethod(ctx context.Context, paymentMethod *model
 	// Вставляем новую карту
 	insertQuery := `
 		INSERT INTO user_payment_methods (
-			user_id, stripe_payment_method_id, stripe_customer_id, 
+			user_id, stripe_payment_method_id, stripe_customer_id, type, status,
 			card_last4, card_brand, card_exp_month, card_exp_year, 
+			bank_name, bank_last4, bank_account_type, stripe_setup_intent_id, stripe_mandate_id,
 			is_default, is_active
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		) VALUES (
+			$1, $2, $3, $4, $5,
+			NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, 0), NULLIF($9, 0),
+			NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), NULLIF($14, ''),
+			$15, $16
+		)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -113,10 +180,17 @@ func (r *repository) SavePaymentMethod(ctx context.Context, paymentMethod *model
 		paymentMethod.UserID,
 		paymentMethod.StripePaymentMethodID,
 		paymentMethod.StripeCustomerID,
+		paymentMethod.Type,
+		paymentMethod.Status,
 		paymentMethod.CardLast4,
 		paymentMethod.CardBrand,
 		paymentMethod.CardExpMonth,
 		paymentMethod.CardExpYear,
+		paymentMethod.BankName,
+		paymentMethod.BankLast4,
+		paymentMethod.BankAccountType,
+		paymentMethod.StripeSetupIntentID,
+		paymentMethod.StripeMandateID,
 		paymentMethod.IsDefault,
 		paymentMethod.IsActive,
 	).Scan(&paymentMethod.ID, &paymentMethod.CreatedAt, &paymentMethod.UpdatedAt)
@@ -129,10 +203,7 @@ func (r *repository) SavePaymentMethod(ctx context.Context, paymentMethod *model
 }
 
 func (r *repository) GetUserPaymentMethods(ctx context.Context, userID int64) ([]models.UserPaymentMethod, error) {
-	query := `
-		SELECT id, user_id, stripe_payment_method_id, stripe_customer_id,
-		       card_last4, card_brand, card_exp_month, card_exp_year,
-		       is_default, is_active, created_at, updated_at
+	query := `SELECT ` + paymentMethodColumns + `
 		FROM user_payment_methods
 		WHERE user_id = $1 AND is_active = true
 		ORDER BY is_default DESC, created_at DESC
@@ -146,63 +217,32 @@ func (r *repository) GetUserPaymentMethods(ctx context.Context, userID int64) ([
 
 	var paymentMethods []models.UserPaymentMethod
 	for rows.Next() {
-		var pm models.UserPaymentMethod
-		err := rows.Scan(
-			&pm.ID, &pm.UserID, &pm.StripePaymentMethodID, &pm.StripeCustomerID,
-			&pm.CardLast4, &pm.CardBrand, &pm.CardExpMonth, &pm.CardExpYear,
-			&pm.IsDefault, &pm.IsActive, &pm.CreatedAt, &pm.UpdatedAt,
-		)
+		pm, err := scanPaymentMethod(rows)
 		if err != nil {
 			return nil, err
 		}
-		paymentMethods = append(paymentMethods, pm)
+		paymentMethods = append(paymentMethods, *pm)
 	}
 
 	return paymentMethods, rows.Err()
 }
 
 func (r *repository) GetPaymentMethodByID(ctx context.Context, userID, paymentMethodID int64) (*models.UserPaymentMethod, error) {
-	query := `
-		SELECT id, user_id, stripe_payment_method_id, stripe_customer_id,
-		       card_last4, card_brand, card_exp_month, card_exp_year,
-		       is_default, is_active, created_at, updated_at
+	query := `SELECT ` + paymentMethodColumns + `
 		FROM user_payment_methods
 		WHERE id = $1 AND user_id = $2 AND is_active = true
 	`
 
-	var pm models.UserPaymentMethod
-	err := r.db.QueryRow(ctx, query, paymentMethodID, userID).Scan(
-		&pm.ID, &pm.UserID, &pm.StripePaymentMethodID, &pm.StripeCustomerID,
-		&pm.CardLast4, &pm.CardBrand, &pm.CardExpMonth, &pm.CardExpYear,
-		&pm.IsDefault, &pm.IsActive, &pm.CreatedAt, &pm.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return &pm, nil
+	return scanPaymentMethod(r.db.QueryRow(ctx, query, paymentMethodID, userID))
 }
 
 func (r *repository) GetPaymentMethodByStripeID(ctx context.Context, userID int64, stripePaymentMethodID string) (*models.UserPaymentMethod, error) {
-	query := `
-		SELECT id, user_id, stripe_payment_method_id, stripe_customer_id,
-		       card_last4, card_brand, card_exp_month, card_exp_year,
-		       is_default, is_active, created_at, updated_at
+	query := `SELECT ` + paymentMethodColumns + `
 		FROM user_payment_methods
 		WHERE stripe_payment_method_id = $1 AND user_id = $2 AND is_active = true
 	`
 
-	var pm models.UserPaymentMethod
-	err := r.db.QueryRow(ctx, query, stripePaymentMethodID, userID).Scan(
-		&pm.ID, &pm.UserID, &pm.StripePaymentMethodID, &pm.StripeCustomerID,
-		&pm.CardLast4, &pm.CardBrand, &pm.CardExpMonth, &pm.CardExpYear,
-		&pm.IsDefault, &pm.IsActive, &pm.CreatedAt, &pm.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return &pm, nil
+	return scanPaymentMethod(r.db.QueryRow(ctx, query, stripePaymentMethodID, userID))
 }
 
 func (r *repository) DeletePaymentMethod(ctx context.Context, userID, paymentMethodID int64) error {
@@ -248,7 +288,7 @@ func (r *repository) DeletePaymentMethod(ctx context.Context, userID, paymentMet
 			WHERE user_id = $1 AND is_active = true
 			AND id = (
 				SELECT id FROM user_payment_methods 
-				WHERE user_id = $1 AND is_active = true 
+				WHERE user_id = $1 AND is_active = true AND status = 'active'
 				ORDER BY created_at ASC 
 				LIMIT 1
 			)
@@ -284,7 +324,7 @@ func (r *repository) SetDefaultPaymentMethod(ctx context.Context, userID, paymen
 	setDefaultQuery := `
 		UPDATE user_payment_methods 
 		SET is_default = true, updated_at = NOW()
-		WHERE id = $1 AND user_id = $2 AND is_active = true
+		WHERE id = $1 AND user_id = $2 AND is_active = true AND status = 'active'
 	`
 	result, err := tx.Exec(ctx, setDefaultQuery, paymentMethodID, userID)
 	if err != nil {
@@ -300,35 +340,141 @@ func (r *repository) SetDefaultPaymentMethod(ctx context.Context, userID, paymen
 }
 
 func (r *repository) GetDefaultPaymentMethod(ctx context.Context, userID int64) (*models.UserPaymentMethod, error) {
-	query := `
-		SELECT id, user_id, stripe_payment_method_id, stripe_customer_id,
-		       card_last4, card_brand, card_exp_month, card_exp_year,
-		       is_default, is_active, created_at, updated_at
+	query := `SELECT ` + paymentMethodColumns + `
 		FROM user_payment_methods
 		WHERE user_id = $1 AND is_default = true AND is_active = true
 		LIMIT 1
 	`
 
-	var pm models.UserPaymentMethod
-	err := r.db.QueryRow(ctx, query, userID).Scan(
-		&pm.ID, &pm.UserID, &pm.StripePaymentMethodID, &pm.StripeCustomerID,
-		&pm.CardLast4, &pm.CardBrand, &pm.CardExpMonth, &pm.CardExpYear,
-		&pm.IsDefault, &pm.IsActive, &pm.CreatedAt, &pm.UpdatedAt,
-	)
+	return scanPaymentMethod(r.db.QueryRow(ctx, query, userID))
+}
+
+// DeactivatePaymentMethodByStripeID - карта отвязана от customer на стороне Stripe
+func (r *repository) DeactivatePaymentMethodByStripeID(ctx context.Context, stripePaymentMethodID string) error {
+	query := `
+		UPDATE user_payment_methods 
+		SET is_active = false, is_default = false, updated_at = NOW()
+		WHERE stripe_payment_method_id = $1 AND is_active = true
+	`
+
+	_, err := r.db.Exec(ctx, query, stripePaymentMethodID)
+	return err
+}
+
+// UpdatePaymentMethodCard обновляет данные карты (перевыпуск, новый срок действия)
+func (r *repository) UpdatePaymentMethodCard(ctx context.Context, stripePaymentMethodID, brand, last4 string, expMonth, expYear int) error {
+	query := `
+		UPDATE user_payment_methods 
+		SET card_brand = $1, card_last4 = $2, card_exp_month = $3, card_exp_year = $4, updated_at = NOW()
+		WHERE stripe_payment_method_id = $5
+	`
+
+	_, err := r.db.Exec(ctx, query, brand, last4, expMonth, expYear, stripePaymentMethodID)
+	return err
+}
+
+// UpdateBankAccountVerification сохраняет результат подтверждения банковского счета по его SetupIntent.
+// Подтвержденный счет становится default, если у пользователя нет другого способа оплаты.
+// Возвращает nil, если счет с таким SetupIntent не найден
+func (r *repository) UpdateBankAccountVerification(ctx context.Context, setupIntentID, status, mandateID string) (*models.UserPaymentMethod, error) {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	return &pm, nil
+	query := `
+		UPDATE user_payment_methods
+		SET status = $1,
+		    stripe_mandate_id = COALESCE(NULLIF($2, ''), stripe_mandate_id),
+		    updated_at = NOW()
+		WHERE stripe_setup_intent_id = $3 AND is_active = true
+		RETURNING ` + paymentMethodColumns
+
+	pm, err := scanPaymentMethod(tx.QueryRow(ctx, query, status, mandateID, setupIntentID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if pm.IsUsable() && !pm.IsDefault {
+		defaultQuery := `
+			UPDATE user_payment_methods
+			SET is_default = true, updated_at = NOW()
+			WHERE id = $1 AND NOT EXISTS (
+				SELECT 1 FROM user_payment_methods
+				WHERE user_id = $2 AND is_default = true AND is_active = true
+			)`
+		result, err := tx.Exec(ctx, defaultQuery, pm.ID, pm.UserID)
+		if err != nil {
+			return nil, err
+		}
+		pm.IsDefault = result.RowsAffected() > 0
+	}
+
+	return pm, tx.Commit(ctx)
 }
 
 // internal/repository/payment/payments.go
+const paymentColumns = `
+	id, user_id, job_id, stripe_payment_intent_id, stripe_payment_method_id,
+	stripe_customer_id, amount_cents, currency, status, description,
+	failure_reason, created_at, updated_at,
+	capture_method, authorization_status, capture_status, authorized_at,
+	authorization_expires_at, captured_at, amount_captured_cents, released_at,
+	refunded_at, reauthorized_from_payment_id, amount_refunded_cents, fee_breakdown,
+	payment_type, tip_recipient_id`
+
+func scanPayment(row pgx.Row) (*models.Payment, error) {
+	var payment models.Payment
+	var failureReason *string // ✅ ИЗМЕНЕНИЕ: Используем *string вместо string
+	err := row.Scan(
+		&payment.ID, &payment.UserID, &payment.JobID, &payment.StripePaymentIntentID,
+		&payment.StripePaymentMethodID, &payment.StripeCustomerID,
+		&payment.AmountCents, &payment.Currency, &payment.Status,
+		&payment.Description, &failureReason, // ✅ ИЗМЕНЕНИЕ: Сканируем в указатель
+		&payment.CreatedAt, &payment.UpdatedAt,
+		&payment.CaptureMethod, &payment.AuthorizationStatus, &payment.CaptureStatus,
+		&payment.AuthorizedAt, &payment.AuthorizationExpiresAt, &payment.CapturedAt,
+		&payment.AmountCapturedCents, &payment.ReleasedAt, &payment.RefundedAt,
+		&payment.ReauthorizedFromPaymentID, &payment.AmountRefundedCents, &payment.FeeBreakdown,
+		&payment.PaymentType, &payment.TipRecipientID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if failureReason != nil {
+		payment.FailureReason = *failureReason
+	}
+
+	return &payment, nil
+}
+
 func (r *repository) SavePayment(ctx context.Context, payment *models.Payment) error {
+	if payment.CaptureMethod == "" {
+		payment.CaptureMethod = models.PaymentCaptureAutomatic
+	}
+	if payment.AuthorizationStatus == "" {
+		payment.AuthorizationStatus = models.AuthorizationStatusNone
+	}
+	if payment.CaptureStatus == "" {
+		payment.CaptureStatus = models.CaptureStatusNone
+	}
+	if payment.PaymentType == "" {
+		payment.PaymentType = models.PaymentTypeCharge
+	}
+
 	query := `
 		INSERT INTO payments (
 			user_id, job_id, stripe_payment_intent_id, stripe_payment_method_id,
-			stripe_customer_id, amount_cents, currency, status, description
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			stripe_customer_id, amount_cents, currency, status, description,
+			capture_method, authorization_status, capture_status,
+			authorized_at, authorization_expires_at, reauthorized_from_payment_id, fee_breakdown,
+			payment_type, tip_recipient_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -342,43 +488,45 @@ func (r *repository) SavePayment(ctx context.Context, payment *models.Payment) e
 		payment.Currency,
 		payment.Status,
 		payment.Description,
+		payment.CaptureMethod,
+		payment.AuthorizationStatus,
+		payment.CaptureStatus,
+		payment.AuthorizedAt,
+		payment.AuthorizationExpiresAt,
+		payment.ReauthorizedFromPaymentID,
+		payment.FeeBreakdown,
+		payment.PaymentType,
+		payment.TipRecipientID,
 	).Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
 
 	return err
 }
 
 func (r *repository) GetPaymentByStripeIntentID(ctx context.Context, stripePaymentIntentID string) (*models.Payment, error) {
-	query := `
-		SELECT id, user_id, job_id, stripe_payment_intent_id, stripe_payment_method_id,
-		       stripe_customer_id, amount_cents, currency, status, description,
-		       failure_reason, created_at, updated_at
+	query := `SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE stripe_payment_intent_id = $1
 	`
 
-	var payment models.Payment
-	var jobID *int64
-	var failureReason *string // ✅ ИЗМЕНЕНИЕ: Используем *string вместо string
-	err := r.db.QueryRow(ctx, query, stripePaymentIntentID).Scan(
-		&payment.ID, &payment.UserID, &jobID, &payment.StripePaymentIntentID,
-		&payment.StripePaymentMethodID, &payment.StripeCustomerID,
-		&payment.AmountCents, &payment.Currency, &payment.Status,
-		&payment.Description, &failureReason, // ✅ ИЗМЕНЕНИЕ: Сканируем в указатель
-		&payment.CreatedAt, &payment.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
+	return scanPayment(r.db.QueryRow(ctx, query, stripePaymentIntentID))
+}
 
-	if jobID != nil {
-		payment.JobID = jobID
-	}
+// GetPaymentByID возвращает платеж или nil, если его нет
+func (r *repository) GetPaymentByID(ctx context.Context, paymentID int64) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE id = $1
+	`
 
-	if failureReason != nil {
-		payment.FailureReason = *failureReason
+	payment, err := scanPayment(r.db.QueryRow(ctx, query, paymentID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
 	}
 
-	return &payment, nil
+	return payment, nil
 }
 
 func (r *repository) UpdatePaymentStatus(ctx context.Context, paymentID int64, status, failureReason string) error {
@@ -393,10 +541,7 @@ func (r *repository) UpdatePaymentStatus(ctx context.Context, paymentID int64, s
 }
 
 func (r *repository) GetUserPayments(ctx context.Context, userID int64, limit, offset int) ([]models.Payment, error) {
-	query := `
-		SELECT id, user_id, job_id, stripe_payment_intent_id, stripe_payment_method_id,
-		       stripe_customer_id, amount_cents, currency, status, description,
-		       failure_reason, created_at, updated_at
+	query := `SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -411,31 +556,590 @@ func (r *repository) GetUserPayments(ctx context.Context, userID int64, limit, o
 
 	var payments []models.Payment
 	for rows.Next() {
-		var payment models.Payment
-		var jobID *int64
-		var failureReason *string // ✅ ИЗМЕНЕНИЕ: Используем *string
-		err := rows.Scan(
-			&payment.ID, &payment.UserID, &jobID, &payment.StripePaymentIntentID,
-			&payment.StripePaymentMethodID, &payment.StripeCustomerID,
-			&payment.AmountCents, &payment.Currency, &payment.Status,
-			&payment.Description, &failureReason, // ✅ ИЗМЕНЕНИЕ: Сканируем в указатель
-			&payment.CreatedAt, &payment.UpdatedAt,
-		)
+		payment, err := scanPayment(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		if jobID != nil {
-			payment.JobID = jobID
+		payments = append(payments, *payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// GetPaymentsCreatedBetween - платежи за интервал для сверки со Stripe
+func (r *repository) GetPaymentsCreatedBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []models.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
 		}
 
-		// ✅ ИЗМЕНЕНИЕ: Проверяем и присваиваем failure_reason
-		if failureReason != nil {
-			payment.FailureReason = *failureReason
+		payments = append(payments, *payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// internal/repository/payment/escrow.go
+
+// GetJobEscrowPayment возвращает актуальный escrow-платеж работы
+// (последний, который не был заменен повторной авторизацией) или nil
+func (r *repository) GetJobEscrowPayment(ctx context.Context, jobID int64) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE job_id = $1
+		  AND capture_method = 'manual'
+		  AND authorization_status <> 'replaced'
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	payment, err := scanPayment(r.db.QueryRow(ctx, query, jobID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
 		}
+		return nil, err
+	}
+
+	return payment, nil
+}
 
-		payments = append(payments, payment)
+func (r *repository) UpdateAuthorizationState(ctx context.Context, paymentID int64, authorizationStatus, captureStatus string, authorizedAt, expiresAt *time.Time) error {
+	query := `
+		UPDATE payments
+		SET authorization_status = $1,
+		    capture_status = $2,
+		    authorized_at = COALESCE($3, authorized_at),
+		    authorization_expires_at = COALESCE($4, authorization_expires_at),
+		    updated_at = NOW()
+		WHERE id = $5
+	`
+
+	_, err := r.db.Exec(ctx, query, authorizationStatus, captureStatus, authorizedAt, expiresAt, paymentID)
+	return err
+}
+
+func (r *repository) MarkPaymentCaptured(ctx context.Context, paymentID, amountCapturedCents int64, status string) error {
+	query := `
+		UPDATE payments
+		SET status = $1,
+		    capture_status = 'captured',
+		    amount_captured_cents = $2,
+		    captured_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $3
+	`
+
+	_, err := r.db.Exec(ctx, query, status, amountCapturedCents, paymentID)
+	return err
+}
+
+func (r *repository) MarkPaymentReleased(ctx context.Context, paymentID int64, status, authorizationStatus string) error {
+	query := `
+		UPDATE payments
+		SET status = $1,
+		    authorization_status = $2,
+		    capture_status = 'canceled',
+		    released_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $3
+	`
+
+	_, err := r.db.Exec(ctx, query, status, authorizationStatus, paymentID)
+	return err
+}
+
+// GetExpiringAuthorizations - авторизованные, но не списанные платежи,
+// срок авторизации которых истекает раньше before. Платежи удаленных работ
+// (job_id обнулен) не продлеваются
+func (r *repository) GetExpiringAuthorizations(ctx context.Context, before time.Time, limit int) ([]models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE capture_method = 'manual'
+		  AND authorization_status = 'authorized'
+		  AND capture_status = 'uncaptured'
+		  AND authorization_expires_at < $1
+		  AND job_id IS NOT NULL
+		ORDER BY authorization_expires_at
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []models.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
 	}
 
 	return payments, rows.Err()
 }
+
+const updatePaymentRefundQuery = `
+	UPDATE payments
+	SET amount_refunded_cents = $1,
+	    capture_status = CASE WHEN $2 AND capture_method = 'manual' THEN 'refunded' ELSE capture_status END,
+	    refunded_at = CASE WHEN $2 THEN COALESCE(refunded_at, NOW()) ELSE refunded_at END,
+	    updated_at = NOW()
+	WHERE id = $3
+`
+
+func (r *repository) UpdatePaymentRefund(ctx context.Context, paymentID, amountRefundedCents int64, fullyRefunded bool) error {
+	_, err := r.db.Exec(ctx, updatePaymentRefundQuery, amountRefundedCents, fullyRefunded, paymentID)
+	return err
+}
+
+// ApplySucceededRefunds поднимает сумму возврата платежа до суммы его успешных возвратов
+// и отмечает платеж возвращенным, когда она достигла refundableCents.
+// Возвращает сумму возврата до и после обновления
+func (r *repository) ApplySucceededRefunds(ctx context.Context, paymentID, refundableCents int64) (int64, int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous int64
+	err = tx.QueryRow(ctx, `SELECT amount_refunded_cents FROM payments WHERE id = $1 FOR UPDATE`, paymentID).Scan(&previous)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var succeeded int64
+	query := `
+		SELECT COALESCE(SUM(amount_cents), 0)
+		FROM payment_refunds
+		WHERE payment_id = $1 AND status = 'succeeded'
+	`
+	if err := tx.QueryRow(ctx, query, paymentID).Scan(&succeeded); err != nil {
+		return 0, 0, err
+	}
+
+	// Webhook мог уже записать сумму из Stripe, включая возвраты, которых у нас нет
+	if succeeded <= previous {
+		return previous, previous, nil
+	}
+
+	if _, err := tx.Exec(ctx, updatePaymentRefundQuery, succeeded, succeeded >= refundableCents, paymentID); err != nil {
+		return 0, 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	return previous, succeeded, nil
+}
+
+// internal/repository/payment/stripe_events.go
+
+// SaveStripeEvent сохраняет событие, если оно еще не было получено.
+// Возвращает false для повторной доставки
+func (r *repository) SaveStripeEvent(ctx context.Context, event *models.StripeEvent) (bool, error) {
+	query := `
+		INSERT INTO stripe_events (stripe_event_id, event_type, payload)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (stripe_event_id) DO NOTHING
+		RETURNING id, status, received_at
+	`
+
+	err := r.db.QueryRow(ctx, query, event.StripeEventID, event.EventType, event.Payload).Scan(
+		&event.ID, &event.Status, &event.ReceivedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClaimStripeEvent помечает событие как обрабатываемое. Возвращает false, если событие
+// уже обработано или его прямо сейчас обрабатывает другой запрос
+// (зависшие в processing дольше 10 минут считаются упавшими)
+func (r *repository) ClaimStripeEvent(ctx context.Context, stripeEventID string) (bool, error) {
+	query := `
+		UPDATE stripe_events
+		SET status = 'processing', attempts = attempts + 1, updated_at = NOW()
+		WHERE stripe_event_id = $1
+		  AND (status IN ('received', 'failed')
+		       OR (status = 'processing' AND updated_at < NOW() - INTERVAL '10 minutes'))
+	`
+
+	result, err := r.db.Exec(ctx, query, stripeEventID)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *repository) FinishStripeEvent(ctx context.Context, stripeEventID, status, lastError string) error {
+	query := `
+		UPDATE stripe_events
+		SET status = $1,
+		    last_error = NULLIF($2, ''),
+		    processed_at = CASE WHEN $1 IN ('processed', 'ignored') THEN NOW() ELSE processed_at END,
+		    updated_at = NOW()
+		WHERE stripe_event_id = $3
+	`
+
+	_, err := r.db.Exec(ctx, query, status, lastError, stripeEventID)
+	return err
+}
+
+const stripeEventColumns = `
+	id, stripe_event_id, event_type, payload, status, attempts, last_error, received_at, processed_at`
+
+func scanStripeEvent(row pgx.Row) (*models.StripeEvent, error) {
+	var event models.StripeEvent
+	err := row.Scan(
+		&event.ID, &event.StripeEventID, &event.EventType, &event.Payload, &event.Status,
+		&event.Attempts, &event.LastError, &event.ReceivedAt, &event.ProcessedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *repository) GetStripeEvent(ctx context.Context, stripeEventID string) (*models.StripeEvent, error) {
+	query := `SELECT ` + stripeEventColumns + ` FROM stripe_events WHERE stripe_event_id = $1`
+
+	event, err := scanStripeEvent(r.db.QueryRow(ctx, query, stripeEventID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (r *repository) GetStripeEventsByStatus(ctx context.Context, status string, limit int) ([]models.StripeEvent, error) {
+	query := `SELECT ` + stripeEventColumns + `
+		FROM stripe_events
+		WHERE status = $1
+		ORDER BY received_at
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.StripeEvent
+	for rows.Next() {
+		event, err := scanStripeEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+
+	return events, rows.Err()
+}
+
+// internal/repository/payment/disputes.go
+func (r *repository) SaveDispute(ctx context.Context, dispute *models.PaymentDispute) error {
+	query := `
+		INSERT INTO payment_disputes (
+			payment_id, stripe_dispute_id, stripe_payment_intent_id, amount_cents,
+			currency, reason, status, evidence_due_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (stripe_dispute_id) DO UPDATE SET
+			amount_cents = EXCLUDED.amount_cents,
+			reason = EXCLUDED.reason,
+			status = EXCLUDED.status,
+			evidence_due_by = EXCLUDED.evidence_due_by,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.QueryRow(ctx, query,
+		dispute.PaymentID,
+		dispute.StripeDisputeID,
+		dispute.StripePaymentIntentID,
+		dispute.AmountCents,
+		dispute.Currency,
+		dispute.Reason,
+		dispute.Status,
+		dispute.EvidenceDueBy,
+	).Scan(&dispute.ID, &dispute.CreatedAt, &dispute.UpdatedAt)
+}
+
+// internal/repository/payment/refunds.go
+
+const refundColumns = `
+	id, payment_id, stripe_refund_id, amount_cents, currency, reason, COALESCE(note, ''),
+	status, COALESCE(failure_reason, ''), requested_by, created_at, updated_at`
+
+func scanRefund(row pgx.Row) (*models.PaymentRefund, error) {
+	var refund models.PaymentRefund
+	err := row.Scan(
+		&refund.ID, &refund.PaymentID, &refund.StripeRefundID, &refund.AmountCents, &refund.Currency,
+		&refund.Reason, &refund.Note, &refund.Status, &refund.FailureReason, &refund.RequestedBy,
+		&refund.CreatedAt, &refund.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// ReserveRefund создает возврат до обращения к Stripe, чтобы сумма была зарезервирована. Платеж блокируется
+// на время проверки остатка, поэтому параллельные возвраты не превысят refundableCents. Остаток -
+// refundableCents за вычетом выполненных и выполняющихся возвратов (или суммы возврата платежа, если она больше).
+// Нулевая сумма резервирует весь остаток. Если сумма не помещается в остаток, возврат не создается
+func (r *repository) ReserveRefund(ctx context.Context, refund *models.PaymentRefund, refundableCents int64) (bool, int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var refunded int64
+	err = tx.QueryRow(ctx, `SELECT amount_refunded_cents FROM payments WHERE id = $1 FOR UPDATE`, refund.PaymentID).Scan(&refunded)
+	if err != nil {
+		return false, 0, err
+	}
+
+	var reserved int64
+	query := `
+		SELECT COALESCE(SUM(amount_cents), 0)
+		FROM payment_refunds
+		WHERE payment_id = $1 AND status IN ('pending', 'requires_action', 'succeeded')
+	`
+	if err := tx.QueryRow(ctx, query, refund.PaymentID).Scan(&reserved); err != nil {
+		return false, 0, err
+	}
+
+	remaining := refundableCents - max(reserved, refunded)
+	if remaining <= 0 || refund.AmountCents > remaining {
+		return false, remaining, nil
+	}
+	if refund.AmountCents == 0 {
+		refund.AmountCents = remaining
+	}
+
+	query = `
+		INSERT INTO payment_refunds (
+			payment_id, stripe_refund_id, amount_cents, currency, reason, note, status, failure_reason, requested_by
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, NULLIF($8, ''), $9)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query,
+		refund.PaymentID,
+		refund.StripeRefundID,
+		refund.AmountCents,
+		refund.Currency,
+		refund.Reason,
+		refund.Note,
+		refund.Status,
+		refund.FailureReason,
+		refund.RequestedBy,
+	).Scan(&refund.ID, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		return false, 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, 0, err
+	}
+
+	return true, remaining - refund.AmountCents, nil
+}
+
+func (r *repository) UpdateRefund(ctx context.Context, refund *models.PaymentRefund) error {
+	query := `
+		UPDATE payment_refunds
+		SET stripe_refund_id = COALESCE($1, stripe_refund_id),
+		    status = $2,
+		    failure_reason = NULLIF($3, ''),
+		    updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at
+	`
+
+	return r.db.QueryRow(ctx, query, refund.StripeRefundID, refund.Status, refund.FailureReason, refund.ID).Scan(&refund.UpdatedAt)
+}
+
+// UpsertStripeRefund сохраняет возврат, созданный вне платформы, или обновляет его статус
+func (r *repository) UpsertStripeRefund(ctx context.Context, refund *models.PaymentRefund) error {
+	query := `
+		INSERT INTO payment_refunds (
+			payment_id, stripe_refund_id, amount_cents, currency, reason, status, failure_reason
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		ON CONFLICT (stripe_refund_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			failure_reason = EXCLUDED.failure_reason,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.QueryRow(ctx, query,
+		refund.PaymentID,
+		refund.StripeRefundID,
+		refund.AmountCents,
+		refund.Currency,
+		refund.Reason,
+		refund.Status,
+		refund.FailureReason,
+	).Scan(&refund.ID, &refund.CreatedAt, &refund.UpdatedAt)
+}
+
+// GetRefundByID возвращает возврат или nil, если его нет
+func (r *repository) GetRefundByID(ctx context.Context, refundID int64) (*models.PaymentRefund, error) {
+	query := `SELECT ` + refundColumns + `
+		FROM payment_refunds
+		WHERE id = $1
+	`
+
+	refund, err := scanRefund(r.db.QueryRow(ctx, query, refundID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return refund, err
+}
+
+// GetRefundByStripeID возвращает возврат или nil, если его нет
+func (r *repository) GetRefundByStripeID(ctx context.Context, stripeRefundID string) (*models.PaymentRefund, error) {
+	query := `SELECT ` + refundColumns + `
+		FROM payment_refunds
+		WHERE stripe_refund_id = $1
+	`
+
+	refund, err := scanRefund(r.db.QueryRow(ctx, query, stripeRefundID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return refund, err
+}
+
+func (r *repository) GetPaymentRefunds(ctx context.Context, paymentIDs []int64) ([]models.PaymentRefund, error) {
+	query := `SELECT ` + refundColumns + `
+		FROM payment_refunds
+		WHERE payment_id = ANY($1)
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, paymentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []models.PaymentRefund
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, *refund)
+	}
+
+	return refunds, rows.Err()
+}
+
+// GetJobStatus - статус работы, к которой относится платеж
+func (r *repository) GetJobStatus(ctx context.Context, jobID int64) (string, error) {
+	var status string
+	err := r.db.QueryRow(ctx, `SELECT job_status FROM jobs WHERE id = $1`, jobID).Scan(&status)
+	return status, err
+}
+
+// internal/repository/payment/tips.go
+
+// GetJobTip возвращает чаевые по работе, которые прошли или еще проводятся, или nil.
+// Неподтвержденные клиентом чаевые не считаются оплаченными
+func (r *repository) GetJobTip(ctx context.Context, jobID int64) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE job_id = $1
+		  AND payment_type = 'tip'
+		  AND status IN ('succeeded', 'processing')
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	payment, err := scanPayment(r.db.QueryRow(ctx, query, jobID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// GetUnconfirmedJobTips - чаевые по работе, которые клиент еще не подтвердил или не смог оплатить
+func (r *repository) GetUnconfirmedJobTips(ctx context.Context, jobID int64) ([]models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE job_id = $1
+		  AND payment_type = 'tip'
+		  AND status IN ('requires_payment_method', 'requires_confirmation', 'requires_action')
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []models.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// GetJobParticipants - подрядчик, исполнитель и статус работы или nil, если работы нет
+func (r *repository) GetJobParticipants(ctx context.Context, jobID int64) (*models.JobParticipants, error) {
+	query := `SELECT id, contractor_id, executor_id, job_status FROM jobs WHERE id = $1`
+
+	var participants models.JobParticipants
+	err := r.db.QueryRow(ctx, query, jobID).Scan(
+		&participants.JobID, &participants.ContractorID, &participants.ExecutorID, &participants.JobStatus,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &participants, nil
+}
@@ -0,0 +1,54 @@
+package payout
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetPaymentPayout возвращает выплату по платежу или nil, если ее нет
+func (r *repository) GetPaymentPayout(ctx context.Context, paymentID int64) (*models.Payout, error) {
+	query := `SELECT ` + payoutColumns + `
+		FROM payouts
+		WHERE payment_id = $1`
+
+	p, err := scanPayout(r.db.QueryRow(ctx, query, paymentID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// UpdatePayoutAmounts сохраняет новые суммы и статус еще не переведенной выплаты, если ее сумма
+// все еще равна expectedGrossCents. Возвращает false, если выплату успели забрать на перевод или изменить
+func (r *repository) UpdatePayoutAmounts(ctx context.Context, payout *models.Payout, expectedGrossCents int64) (bool, error) {
+	query := `
+		UPDATE payouts
+		SET gross_amount_cents = $1,
+		    commission_cents = $2,
+		    net_amount_cents = $3,
+		    status = $4,
+		    updated_at = NOW()
+		WHERE id = $5 AND gross_amount_cents = $6 AND status IN ('pending', 'canceled')
+		RETURNING updated_at`
+
+	err := r.db.QueryRow(ctx, query,
+		payout.GrossAmountCents,
+		payout.CommissionCents,
+		payout.NetAmountCents,
+		payout.Status,
+		payout.ID,
+		expectedGrossCents,
+	).Scan(&payout.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
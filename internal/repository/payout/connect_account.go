@@ -0,0 +1,52 @@
+package payout
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) GetConnectAccount(ctx context.Context, userID int64) (*models.ConnectAccount, error) {
+	query := `
+		SELECT user_id, stripe_account_id, details_submitted, payouts_enabled, created_at, updated_at
+		FROM connect_accounts
+		WHERE user_id = $1`
+
+	var account models.ConnectAccount
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&account.UserID,
+		&account.StripeAccountID,
+		&account.DetailsSubmitted,
+		&account.PayoutsEnabled,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func (r *repository) SaveConnectAccount(ctx context.Context, account *models.ConnectAccount) error {
+	query := `
+		INSERT INTO connect_accounts (user_id, stripe_account_id, details_submitted, payouts_enabled)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			stripe_account_id = EXCLUDED.stripe_account_id,
+			details_submitted = EXCLUDED.details_submitted,
+			payouts_enabled = EXCLUDED.payouts_enabled,
+			updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(ctx, query,
+		account.UserID,
+		account.StripeAccountID,
+		account.DetailsSubmitted,
+		account.PayoutsEnabled,
+	).Scan(&account.CreatedAt, &account.UpdatedAt)
+}
@@ -0,0 +1,40 @@
+package payout
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreatePayout добавляет запись в журнал выплат.
+// Возвращает false, если выплата по этому платежу уже записана
+func (r *repository) CreatePayout(ctx context.Context, payout *models.Payout) (bool, error) {
+	query := `
+		INSERT INTO payouts (
+			user_id, job_id, payment_id, gross_amount_cents, commission_rate,
+			commission_cents, net_amount_cents, currency, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (payment_id) DO NOTHING
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRow(ctx, query,
+		payout.UserID,
+		payout.JobID,
+		payout.PaymentID,
+		payout.GrossAmountCents,
+		payout.CommissionRate,
+		payout.CommissionCents,
+		payout.NetAmountCents,
+		payout.Currency,
+		payout.Status,
+	).Scan(&payout.ID, &payout.CreatedAt, &payout.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
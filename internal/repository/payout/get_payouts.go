@@ -0,0 +1,76 @@
+package payout
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+)
+
+func (r *repository) GetUserPayouts(ctx context.Context, userID int64, limit, offset int) ([]models.Payout, int, error) {
+	var total int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM payouts WHERE user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + payoutColumns + `
+		FROM payouts
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	payouts := make([]models.Payout, 0)
+	for rows.Next() {
+		p, err := scanPayout(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		payouts = append(payouts, p)
+	}
+
+	return payouts, total, rows.Err()
+}
+
+func (r *repository) GetPayoutBalance(ctx context.Context, userID int64) (int64, int64, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(net_amount_cents) FILTER (WHERE status IN ('pending', 'processing')), 0),
+			COALESCE(SUM(net_amount_cents) FILTER (WHERE status = 'transferred'), 0)
+		FROM payouts
+		WHERE user_id = $1`
+
+	var pending, transferred int64
+	err := r.db.QueryRow(ctx, query, userID).Scan(&pending, &transferred)
+	return pending, transferred, err
+}
+
+// GetUsersWithPendingPayouts - исполнители с ожидающими выплатами или выплатами,
+// зависшими в processing дольше staleAfter
+func (r *repository) GetUsersWithPendingPayouts(ctx context.Context, staleAfter time.Duration) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT DISTINCT user_id FROM payouts
+		WHERE status = 'pending'
+		   OR (status = 'processing' AND updated_at < NOW() - make_interval(secs => $1))`,
+		staleAfter.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
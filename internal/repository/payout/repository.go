@@ -0,0 +1,66 @@
+package payout
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PayoutRepository interface {
+	GetConnectAccount(ctx context.Context, userID int64) (*models.ConnectAccount, error)
+	SaveConnectAccount(ctx context.Context, account *models.ConnectAccount) error
+
+	CreatePayout(ctx context.Context, payout *models.Payout) (bool, error)
+	GetPaymentPayout(ctx context.Context, paymentID int64) (*models.Payout, error)
+	UpdatePayoutAmounts(ctx context.Context, payout *models.Payout, expectedGrossCents int64) (bool, error)
+	GetUserPayouts(ctx context.Context, userID int64, limit, offset int) ([]models.Payout, int, error)
+	GetPayoutBalance(ctx context.Context, userID int64) (pendingCents, transferredCents int64, err error)
+	GetUsersWithPendingPayouts(ctx context.Context, staleAfter time.Duration) ([]int64, error)
+	GetEarningsLines(ctx context.Context, userID int64, from, to time.Time) ([]models.EarningsLine, error)
+
+	ClaimPendingPayouts(ctx context.Context, userID int64) ([]models.Payout, error)
+	ReclaimStalePayouts(ctx context.Context, userID int64, staleAfter time.Duration) ([]models.Payout, error)
+	MarkPayoutsTransferred(ctx context.Context, payoutIDs []int64, stripeTransferID string) error
+	ReleaseClaimedPayouts(ctx context.Context, payoutIDs []int64, failureReason string) error
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPayoutRepository(db *pgxpool.Pool) PayoutRepository {
+	return &repository{db: db}
+}
+
+const payoutColumns = `
+	id, user_id, job_id, payment_id, gross_amount_cents, commission_rate,
+	commission_cents, net_amount_cents, currency, status, stripe_transfer_id,
+	failure_reason, transferred_at, created_at, updated_at`
+
+func scanPayout(row pgx.Row) (models.Payout, error) {
+	var p models.Payout
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.JobID, &p.PaymentID, &p.GrossAmountCents, &p.CommissionRate,
+		&p.CommissionCents, &p.NetAmountCents, &p.Currency, &p.Status, &p.StripeTransferID,
+		&p.FailureReason, &p.TransferredAt, &p.CreatedAt, &p.UpdatedAt,
+	)
+	return p, err
+}
+
+func scanPayouts(rows pgx.Rows) ([]models.Payout, error) {
+	defer rows.Close()
+
+	var payouts []models.Payout
+	for rows.Next() {
+		p, err := scanPayout(rows)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, p)
+	}
+
+	return payouts, rows.Err()
+}
@@ -0,0 +1,51 @@
+package payout
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+)
+
+// GetEarningsLines возвращает выплаты исполнителя за период, сгруппированные по работам.
+// Чаевые отделяются от оплаты работы по типу платежа
+func (r *repository) GetEarningsLines(ctx context.Context, userID int64, from, to time.Time) ([]models.EarningsLine, error) {
+	query := `
+		SELECT
+			p.job_id,
+			MIN(p.created_at) AS earned_at,
+			COALESCE(MAX(j.pickup_city), ''),
+			COALESCE(MAX(j.pickup_state), ''),
+			COALESCE(MAX(j.delivery_city), ''),
+			COALESCE(MAX(j.delivery_state), ''),
+			COALESCE(SUM(p.gross_amount_cents) FILTER (WHERE pay.payment_type IS DISTINCT FROM 'tip'), 0),
+			COALESCE(SUM(p.commission_cents), 0),
+			COALESCE(SUM(p.gross_amount_cents) FILTER (WHERE pay.payment_type = 'tip'), 0),
+			COALESCE(SUM(p.net_amount_cents), 0),
+			MAX(p.currency)
+		FROM payouts p
+		LEFT JOIN payments pay ON pay.id = p.payment_id
+		LEFT JOIN jobs j ON j.id = p.job_id
+		WHERE p.user_id = $1 AND p.created_at >= $2 AND p.created_at < $3 AND p.status <> 'canceled'
+		GROUP BY p.job_id
+		ORDER BY earned_at, p.job_id`
+
+	rows, err := r.db.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]models.EarningsLine, 0)
+	for rows.Next() {
+		var l models.EarningsLine
+		if err := rows.Scan(
+			&l.JobID, &l.EarnedAt, &l.PickupCity, &l.PickupState, &l.DeliveryCity, &l.DeliveryState,
+			&l.GrossCents, &l.CommissionCents, &l.TipsCents, &l.NetCents, &l.Currency,
+		); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
@@ -0,0 +1,69 @@
+package payout
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"time"
+)
+
+// ClaimPendingPayouts переводит все ожидающие выплаты пользователя в processing,
+// чтобы параллельный запуск не перевел их второй раз
+func (r *repository) ClaimPendingPayouts(ctx context.Context, userID int64) ([]models.Payout, error) {
+	query := `
+		UPDATE payouts
+		SET status = 'processing', updated_at = NOW()
+		WHERE user_id = $1 AND status = 'pending'
+		RETURNING ` + payoutColumns
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPayouts(rows)
+}
+
+// ReclaimStalePayouts забирает выплаты, которые остались в processing дольше staleAfter
+// (обработчик упал или не дождался ответа Stripe), и продлевает их захват
+func (r *repository) ReclaimStalePayouts(ctx context.Context, userID int64, staleAfter time.Duration) ([]models.Payout, error) {
+	query := `
+		UPDATE payouts
+		SET updated_at = NOW()
+		WHERE user_id = $1 AND status = 'processing'
+		  AND updated_at < NOW() - make_interval(secs => $2)
+		RETURNING ` + payoutColumns
+
+	rows, err := r.db.Query(ctx, query, userID, staleAfter.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPayouts(rows)
+}
+
+func (r *repository) MarkPayoutsTransferred(ctx context.Context, payoutIDs []int64, stripeTransferID string) error {
+	query := `
+		UPDATE payouts
+		SET status = 'transferred',
+		    stripe_transfer_id = $1,
+		    failure_reason = NULL,
+		    transferred_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = ANY($2)`
+
+	_, err := r.db.Exec(ctx, query, stripeTransferID, payoutIDs)
+	return err
+}
+
+// ReleaseClaimedPayouts возвращает выплаты в pending (ниже минимума или ошибка перевода)
+func (r *repository) ReleaseClaimedPayouts(ctx context.Context, payoutIDs []int64, failureReason string) error {
+	query := `
+		UPDATE payouts
+		SET status = 'pending',
+		    failure_reason = NULLIF($1, ''),
+		    updated_at = NOW()
+		WHERE id = ANY($2) AND status = 'processing'`
+
+	_, err := r.db.Exec(ctx, query, failureReason, payoutIDs)
+	return err
+}
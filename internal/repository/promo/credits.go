@@ -0,0 +1,62 @@
+package promo
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+func (r *repository) GetCreditBalance(ctx context.Context, userID int64) (int64, error) {
+	var balance int64
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE((SELECT balance_cents FROM user_credits WHERE user_id = $1), 0)`,
+		userID,
+	).Scan(&balance)
+	return balance, err
+}
+
+func (r *repository) GetCreditTransactions(ctx context.Context, userID int64, limit int) ([]models.CreditTransaction, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, amount_cents, reason, job_id, COALESCE(note, ''), created_by, created_at
+		FROM user_credit_transactions
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.CreditTransaction{}
+	for rows.Next() {
+		var txn models.CreditTransaction
+		err := rows.Scan(&txn.ID, &txn.UserID, &txn.AmountCents, &txn.Reason, &txn.JobID, &txn.Note, &txn.CreatedBy, &txn.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, txn)
+	}
+
+	return transactions, rows.Err()
+}
+
+// AdjustCredits начисляет или списывает кредиты. Возвращает false, если списание больше баланса
+func (r *repository) AdjustCredits(ctx context.Context, txn *models.CreditTransaction) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	applied, err := applyCredits(ctx, tx, txn)
+	if err != nil || !applied {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,124 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const promoColumns = `
+	p.id, p.code, p.description, p.discount_type, p.percent_off, p.amount_off_cents,
+	p.max_redemptions, p.per_user_limit, p.first_job_only, p.starts_at, p.expires_at,
+	p.is_active, p.created_by, p.created_at, p.updated_at,
+	(SELECT COUNT(*) FROM fee_redemptions r WHERE r.promo_code_id = p.id AND r.status = 'applied'),
+	(SELECT COALESCE(SUM(r.discount_cents), 0) FROM fee_redemptions r WHERE r.promo_code_id = p.id AND r.status = 'applied')`
+
+func scanPromoCode(row pgx.Row) (*models.PromoCode, error) {
+	var p models.PromoCode
+	err := row.Scan(
+		&p.ID, &p.Code, &p.Description, &p.DiscountType, &p.PercentOff, &p.AmountOffCents,
+		&p.MaxRedemptions, &p.PerUserLimit, &p.FirstJobOnly, &p.StartsAt, &p.ExpiresAt,
+		&p.IsActive, &p.CreatedBy, &p.CreatedAt, &p.UpdatedAt,
+		&p.TimesRedeemed, &p.TotalDiscountCents,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *repository) CreatePromoCode(ctx context.Context, promo *models.PromoCode) error {
+	return r.db.QueryRow(ctx, `
+		INSERT INTO promo_codes (
+			code, description, discount_type, percent_off, amount_off_cents,
+			max_redemptions, per_user_limit, first_job_only, starts_at, expires_at,
+			is_active, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at, updated_at`,
+		promo.Code, promo.Description, promo.DiscountType, promo.PercentOff, promo.AmountOffCents,
+		promo.MaxRedemptions, promo.PerUserLimit, promo.FirstJobOnly, promo.StartsAt, promo.ExpiresAt,
+		promo.IsActive, promo.CreatedBy,
+	).Scan(&promo.ID, &promo.CreatedAt, &promo.UpdatedAt)
+}
+
+// UpdatePromoCode сохраняет изменяемые поля; код и размер скидки после создания не меняются
+func (r *repository) UpdatePromoCode(ctx context.Context, promo *models.PromoCode) error {
+	return r.db.QueryRow(ctx, `
+		UPDATE promo_codes
+		SET description = $2, max_redemptions = $3, per_user_limit = $4, first_job_only = $5,
+			starts_at = $6, expires_at = $7, is_active = $8, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		promo.ID, promo.Description, promo.MaxRedemptions, promo.PerUserLimit, promo.FirstJobOnly,
+		promo.StartsAt, promo.ExpiresAt, promo.IsActive,
+	).Scan(&promo.UpdatedAt)
+}
+
+// GetPromoCodeByID возвращает промокод или nil, если его нет
+func (r *repository) GetPromoCodeByID(ctx context.Context, id int64) (*models.PromoCode, error) {
+	promo, err := scanPromoCode(r.db.QueryRow(ctx, `SELECT `+promoColumns+` FROM promo_codes p WHERE p.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return promo, err
+}
+
+// GetPromoCodeByCode возвращает промокод по нормализованному коду или nil
+func (r *repository) GetPromoCodeByCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	promo, err := scanPromoCode(r.db.QueryRow(ctx, `SELECT `+promoColumns+` FROM promo_codes p WHERE p.code = $1`, code))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return promo, err
+}
+
+func (r *repository) ListPromoCodes(ctx context.Context, limit, offset int) ([]models.PromoCode, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM promo_codes`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+promoColumns+`
+		FROM promo_codes p
+		ORDER BY p.created_at DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	promos := []models.PromoCode{}
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		promos = append(promos, *promo)
+	}
+
+	return promos, total, rows.Err()
+}
+
+// CountRedemptions - сколько раз промокод применен всего и пользователем userID
+func (r *repository) CountRedemptions(ctx context.Context, promoCodeID, userID int64) (int, int, error) {
+	var total, byUser int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+		FROM fee_redemptions
+		WHERE promo_code_id = $1 AND status = 'applied'`,
+		promoCodeID, userID,
+	).Scan(&total, &byUser)
+	return total, byUser, err
+}
+
+// CountUserJobs - сколько работ опубликовал пользователь, не считая excludeJobID
+func (r *repository) CountUserJobs(ctx context.Context, userID, excludeJobID int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM jobs WHERE contractor_id = $1 AND id <> $2`, userID, excludeJobID).Scan(&count)
+	return count, err
+}
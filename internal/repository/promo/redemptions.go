@@ -0,0 +1,184 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateRedemption записывает скидку на сбор работы и списывает примененные кредиты.
+// Лимиты промокода перепроверяются под блокировкой; возвращает false, если лимит исчерпан
+// или кредитов уже не хватает
+func (r *repository) CreateRedemption(ctx context.Context, redemption *models.FeeRedemption, promo *models.PromoCode) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if promo != nil {
+		if _, err := tx.Exec(ctx, `SELECT id FROM promo_codes WHERE id = $1 FOR UPDATE`, promo.ID); err != nil {
+			return false, err
+		}
+
+		var total, byUser int
+		err := tx.QueryRow(ctx, `
+			SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+			FROM fee_redemptions
+			WHERE promo_code_id = $1 AND status = 'applied'`,
+			promo.ID, redemption.UserID,
+		).Scan(&total, &byUser)
+		if err != nil {
+			return false, err
+		}
+		if (promo.MaxRedemptions != nil && total >= *promo.MaxRedemptions) ||
+			(promo.PerUserLimit != nil && byUser >= *promo.PerUserLimit) {
+			return false, nil
+		}
+	}
+
+	if redemption.CreditsAppliedCents > 0 {
+		applied, err := applyCredits(ctx, tx, &models.CreditTransaction{
+			UserID:      redemption.UserID,
+			AmountCents: -redemption.CreditsAppliedCents,
+			Reason:      models.CreditReasonJobFee,
+			JobID:       redemption.JobID,
+		})
+		if err != nil || !applied {
+			return false, err
+		}
+	}
+
+	redemption.Status = models.FeeRedemptionApplied
+	err = tx.QueryRow(ctx, `
+		INSERT INTO fee_redemptions (
+			promo_code_id, user_id, job_id, fee_before_cents, discount_cents,
+			credits_applied_cents, fee_after_cents, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`,
+		redemption.PromoCodeID, redemption.UserID, redemption.JobID, redemption.FeeBeforeCents,
+		redemption.DiscountCents, redemption.CreditsAppliedCents, redemption.FeeAfterCents, redemption.Status,
+	).Scan(&redemption.ID, &redemption.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReverseJobRedemption отменяет скидку работы и возвращает списанные кредиты.
+// Возвращает nil, если по работе не было скидки
+func (r *repository) ReverseJobRedemption(ctx context.Context, jobID int64) (*models.FeeRedemption, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var redemption models.FeeRedemption
+	err = tx.QueryRow(ctx, `
+		UPDATE fee_redemptions
+		SET status = 'reversed', reversed_at = NOW()
+		WHERE job_id = $1 AND status = 'applied'
+		RETURNING id, promo_code_id, user_id, job_id, fee_before_cents, discount_cents,
+			credits_applied_cents, fee_after_cents, status, created_at, reversed_at`,
+		jobID,
+	).Scan(
+		&redemption.ID, &redemption.PromoCodeID, &redemption.UserID, &redemption.JobID,
+		&redemption.FeeBeforeCents, &redemption.DiscountCents, &redemption.CreditsAppliedCents,
+		&redemption.FeeAfterCents, &redemption.Status, &redemption.CreatedAt, &redemption.ReversedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if redemption.CreditsAppliedCents > 0 {
+		_, err := applyCredits(ctx, tx, &models.CreditTransaction{
+			UserID:      redemption.UserID,
+			AmountCents: redemption.CreditsAppliedCents,
+			Reason:      models.CreditReasonJobFeeReversal,
+			JobID:       redemption.JobID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &redemption, nil
+}
+
+// GetJobRedemption возвращает действующую скидку на сбор работы или nil
+func (r *repository) GetJobRedemption(ctx context.Context, jobID int64) (*models.FeeRedemption, error) {
+	var redemption models.FeeRedemption
+	err := r.db.QueryRow(ctx, `
+		SELECT r.id, r.promo_code_id, COALESCE(p.code, ''), r.user_id, r.job_id, r.fee_before_cents, r.discount_cents,
+			r.credits_applied_cents, r.fee_after_cents, r.status, r.created_at, r.reversed_at
+		FROM fee_redemptions r
+		LEFT JOIN promo_codes p ON p.id = r.promo_code_id
+		WHERE r.job_id = $1 AND r.status = 'applied'`,
+		jobID,
+	).Scan(
+		&redemption.ID, &redemption.PromoCodeID, &redemption.PromoCode, &redemption.UserID, &redemption.JobID,
+		&redemption.FeeBeforeCents, &redemption.DiscountCents, &redemption.CreditsAppliedCents,
+		&redemption.FeeAfterCents, &redemption.Status, &redemption.CreatedAt, &redemption.ReversedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *repository) GetPromoRedemptions(ctx context.Context, promoCodeID int64, limit, offset int) ([]models.FeeRedemption, int, error) {
+	var total int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM fee_redemptions WHERE promo_code_id = $1`, promoCodeID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT r.id, r.promo_code_id, p.code, r.user_id, r.job_id, r.fee_before_cents, r.discount_cents,
+			r.credits_applied_cents, r.fee_after_cents, r.status, r.created_at, r.reversed_at
+		FROM fee_redemptions r
+		JOIN promo_codes p ON p.id = r.promo_code_id
+		WHERE r.promo_code_id = $1
+		ORDER BY r.created_at DESC
+		LIMIT $2 OFFSET $3`,
+		promoCodeID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	redemptions := []models.FeeRedemption{}
+	for rows.Next() {
+		var redemption models.FeeRedemption
+		err := rows.Scan(
+			&redemption.ID, &redemption.PromoCodeID, &redemption.PromoCode, &redemption.UserID, &redemption.JobID,
+			&redemption.FeeBeforeCents, &redemption.DiscountCents, &redemption.CreditsAppliedCents,
+			&redemption.FeeAfterCents, &redemption.Status, &redemption.CreatedAt, &redemption.ReversedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+
+	return redemptions, total, rows.Err()
+}
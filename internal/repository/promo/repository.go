@@ -0,0 +1,81 @@
+package promo
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PromoRepository interface {
+	// Promo codes
+	CreatePromoCode(ctx context.Context, promo *models.PromoCode) error
+	UpdatePromoCode(ctx context.Context, promo *models.PromoCode) error
+	GetPromoCodeByID(ctx context.Context, id int64) (*models.PromoCode, error)
+	GetPromoCodeByCode(ctx context.Context, code string) (*models.PromoCode, error)
+	ListPromoCodes(ctx context.Context, limit, offset int) ([]models.PromoCode, int, error)
+	CountRedemptions(ctx context.Context, promoCodeID, userID int64) (total, byUser int, err error)
+	CountUserJobs(ctx context.Context, userID, excludeJobID int64) (int, error)
+
+	// Fee redemptions
+	CreateRedemption(ctx context.Context, redemption *models.FeeRedemption, promo *models.PromoCode) (bool, error)
+	ReverseJobRedemption(ctx context.Context, jobID int64) (*models.FeeRedemption, error)
+	GetJobRedemption(ctx context.Context, jobID int64) (*models.FeeRedemption, error)
+	GetPromoRedemptions(ctx context.Context, promoCodeID int64, limit, offset int) ([]models.FeeRedemption, int, error)
+
+	// Credits
+	GetCreditBalance(ctx context.Context, userID int64) (int64, error)
+	GetCreditTransactions(ctx context.Context, userID int64, limit int) ([]models.CreditTransaction, error)
+	AdjustCredits(ctx context.Context, txn *models.CreditTransaction) (bool, error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPromoRepository(db *pgxpool.Pool) PromoRepository {
+	return &repository{db: db}
+}
+
+// applyCredits меняет баланс кредитов и записывает операцию в историю внутри транзакции tx.
+// Возвращает false, если списание больше баланса
+func applyCredits(ctx context.Context, tx pgx.Tx, txn *models.CreditTransaction) (bool, error) {
+	if txn.AmountCents > 0 {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO user_credits (user_id, balance_cents, updated_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (user_id) DO UPDATE
+			SET balance_cents = user_credits.balance_cents + EXCLUDED.balance_cents, updated_at = NOW()`,
+			txn.UserID, txn.AmountCents,
+		)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		tag, err := tx.Exec(ctx, `
+			UPDATE user_credits
+			SET balance_cents = balance_cents + $2, updated_at = NOW()
+			WHERE user_id = $1 AND balance_cents + $2 >= 0`,
+			txn.UserID, txn.AmountCents,
+		)
+		if err != nil {
+			return false, err
+		}
+		if tag.RowsAffected() == 0 {
+			return false, nil
+		}
+	}
+
+	err := tx.QueryRow(ctx, `
+		INSERT INTO user_credit_transactions (user_id, amount_cents, reason, job_id, note, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING id, created_at`,
+		txn.UserID, txn.AmountCents, txn.Reason, txn.JobID, txn.Note, txn.CreatedBy,
+	).Scan(&txn.ID, &txn.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
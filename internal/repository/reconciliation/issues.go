@@ -0,0 +1,95 @@
+package reconciliation
+
+import (
+	"context"
+	"moveshare/internal/models"
+)
+
+// SaveIssue сохраняет расхождение. Уже открытое расхождение того же типа по PaymentIntent
+// обновляется, а не дублируется. Возвращает true, если расхождение новое
+func (r *repository) SaveIssue(ctx context.Context, issue *models.ReconciliationIssue) (bool, error) {
+	if issue.Status == "" {
+		issue.Status = models.ReconciliationIssueOpen
+	}
+
+	query := `
+		INSERT INTO reconciliation_issues (
+			run_id, stripe_payment_intent_id, payment_id, issue_type, status,
+			local_status, stripe_status, local_amount_cents, stripe_amount_cents, details
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (stripe_payment_intent_id, issue_type) WHERE status = 'open'
+		DO UPDATE SET
+			run_id = EXCLUDED.run_id,
+			local_status = EXCLUDED.local_status,
+			stripe_status = EXCLUDED.stripe_status,
+			local_amount_cents = EXCLUDED.local_amount_cents,
+			stripe_amount_cents = EXCLUDED.stripe_amount_cents,
+			details = EXCLUDED.details,
+			last_seen_at = NOW()
+		RETURNING id, detected_at, last_seen_at, (xmax = 0) AS inserted`
+
+	var inserted bool
+	err := r.db.QueryRow(ctx, query,
+		issue.RunID,
+		issue.StripePaymentIntentID,
+		issue.PaymentID,
+		issue.IssueType,
+		issue.Status,
+		issue.LocalStatus,
+		issue.StripeStatus,
+		issue.LocalAmountCents,
+		issue.StripeAmountCents,
+		issue.Details,
+	).Scan(&issue.ID, &issue.DetectedAt, &issue.LastSeenAt, &inserted)
+	if err != nil {
+		return false, err
+	}
+
+	return inserted, nil
+}
+
+func (r *repository) GetIssuesByStatus(ctx context.Context, status string, limit, offset int) ([]models.ReconciliationIssue, int, error) {
+	var total int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM reconciliation_issues WHERE status = $1`, status).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + issueColumns + `
+		FROM reconciliation_issues
+		WHERE status = $1
+		ORDER BY last_seen_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	issues := make([]models.ReconciliationIssue, 0)
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, total, rows.Err()
+}
+
+// ResolveIssue закрывает открытое расхождение. Возвращает false, если оно не найдено или уже закрыто
+func (r *repository) ResolveIssue(ctx context.Context, issueID, adminID int64, note string) (bool, error) {
+	query := `
+		UPDATE reconciliation_issues
+		SET status = 'resolved', resolved_at = NOW(), resolved_by = $1, resolution_note = $2
+		WHERE id = $3 AND status = 'open'`
+
+	tag, err := r.db.Exec(ctx, query, adminID, note, issueID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
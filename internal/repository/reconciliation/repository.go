@@ -0,0 +1,42 @@
+package reconciliation
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReconciliationRepository interface {
+	CreateRun(ctx context.Context) (*models.ReconciliationRun, error)
+	FinishRun(ctx context.Context, run *models.ReconciliationRun) error
+	GetLatestRun(ctx context.Context) (*models.ReconciliationRun, error)
+
+	SaveIssue(ctx context.Context, issue *models.ReconciliationIssue) (bool, error)
+	GetIssuesByStatus(ctx context.Context, status string, limit, offset int) ([]models.ReconciliationIssue, int, error)
+	ResolveIssue(ctx context.Context, issueID, adminID int64, note string) (bool, error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewReconciliationRepository(db *pgxpool.Pool) ReconciliationRepository {
+	return &repository{db: db}
+}
+
+const issueColumns = `
+	id, run_id, stripe_payment_intent_id, payment_id, issue_type, status,
+	COALESCE(local_status, ''), COALESCE(stripe_status, ''), local_amount_cents, stripe_amount_cents,
+	COALESCE(details, ''), detected_at, last_seen_at, resolved_at, resolved_by, resolution_note`
+
+func scanIssue(row pgx.Row) (models.ReconciliationIssue, error) {
+	var i models.ReconciliationIssue
+	err := row.Scan(
+		&i.ID, &i.RunID, &i.StripePaymentIntentID, &i.PaymentID, &i.IssueType, &i.Status,
+		&i.LocalStatus, &i.StripeStatus, &i.LocalAmountCents, &i.StripeAmountCents,
+		&i.Details, &i.DetectedAt, &i.LastSeenAt, &i.ResolvedAt, &i.ResolvedBy, &i.ResolutionNote,
+	)
+	return i, err
+}
@@ -0,0 +1,58 @@
+package reconciliation
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) CreateRun(ctx context.Context) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO reconciliation_runs DEFAULT VALUES
+		RETURNING id, started_at`).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+func (r *repository) FinishRun(ctx context.Context, run *models.ReconciliationRun) error {
+	query := `
+		UPDATE reconciliation_runs
+		SET finished_at = NOW(),
+		    intents_checked = $1,
+		    payments_checked = $2,
+		    auto_fixed = $3,
+		    flagged = $4,
+		    error = $5
+		WHERE id = $6
+		RETURNING finished_at`
+
+	return r.db.QueryRow(ctx, query,
+		run.IntentsChecked, run.PaymentsChecked, run.AutoFixed, run.Flagged, run.Error, run.ID,
+	).Scan(&run.FinishedAt)
+}
+
+// GetLatestRun возвращает последний запуск сверки или nil
+func (r *repository) GetLatestRun(ctx context.Context) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := r.db.QueryRow(ctx, `
+		SELECT id, started_at, finished_at, intents_checked, payments_checked, auto_fixed, flagged, error
+		FROM reconciliation_runs
+		ORDER BY id DESC
+		LIMIT 1`).Scan(
+		&run.ID, &run.StartedAt, &run.FinishedAt, &run.IntentsChecked, &run.PaymentsChecked,
+		&run.AutoFixed, &run.Flagged, &run.Error,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
@@ -40,10 +40,15 @@ func (r *ReviewRepository) GetReviewsByUser(ctx context.Context, userID int64, o
 		SELECT 
 			r.id, r.job_id, r.rating, r.comment, r.created_at,
 			reviewer.username as reviewer_name,
-			reviewee.username as reviewee_name
+			reviewee.username as reviewee_name,
+			COALESCE(tip.amount_cents, 0) as tip_cents
 		FROM reviews r
 		JOIN users reviewer ON r.reviewer_id = reviewer.id
 		JOIN users reviewee ON r.reviewee_id = reviewee.id
+		LEFT JOIN payments tip ON tip.job_id = r.job_id
+			AND tip.user_id = r.reviewer_id
+			AND tip.payment_type = 'tip'
+			AND tip.status = 'succeeded'
 		WHERE r.reviewee_id = $1
 		ORDER BY r.created_at DESC
 		OFFSET $2 LIMIT $3
@@ -66,6 +71,7 @@ func (r *ReviewRepository) GetReviewsByUser(ctx context.Context, userID int64, o
 			&review.CreatedAt,
 			&review.ReviewerName,
 			&review.RevieweeName,
+			&review.TipCents,
 		)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,23 @@
+package subscription
+
+import (
+	"context"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SubscriptionRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.Subscription) error
+	UpdateSubscription(ctx context.Context, sub *models.Subscription) error
+	GetCurrentSubscription(ctx context.Context, userID int64) (*models.Subscription, error)
+	GetSubscriptionByStripeID(ctx context.Context, stripeSubscriptionID string) (*models.Subscription, error)
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewSubscriptionRepository(db *pgxpool.Pool) SubscriptionRepository {
+	return &repository{db: db}
+}
@@ -0,0 +1,80 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const subscriptionColumns = `
+	id, user_id, plan, status, stripe_subscription_id, stripe_customer_id, stripe_price_id,
+	current_period_start, current_period_end, cancel_at_period_end, past_due_since, canceled_at,
+	created_at, updated_at`
+
+func scanSubscription(row pgx.Row) (*models.Subscription, error) {
+	var s models.Subscription
+	err := row.Scan(
+		&s.ID, &s.UserID, &s.Plan, &s.Status, &s.StripeSubscriptionID, &s.StripeCustomerID, &s.StripePriceID,
+		&s.CurrentPeriodStart, &s.CurrentPeriodEnd, &s.CancelAtPeriodEnd, &s.PastDueSince, &s.CanceledAt,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *repository) CreateSubscription(ctx context.Context, sub *models.Subscription) error {
+	return r.db.QueryRow(ctx, `
+		INSERT INTO subscriptions (
+			user_id, plan, status, stripe_subscription_id, stripe_customer_id, stripe_price_id,
+			current_period_start, current_period_end, cancel_at_period_end, past_due_since, canceled_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at`,
+		sub.UserID, sub.Plan, sub.Status, sub.StripeSubscriptionID, sub.StripeCustomerID, sub.StripePriceID,
+		sub.CurrentPeriodStart, sub.CurrentPeriodEnd, sub.CancelAtPeriodEnd, sub.PastDueSince, sub.CanceledAt,
+	).Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+}
+
+// UpdateSubscription сохраняет состояние подписки, полученное из Stripe
+func (r *repository) UpdateSubscription(ctx context.Context, sub *models.Subscription) error {
+	return r.db.QueryRow(ctx, `
+		UPDATE subscriptions
+		SET plan = $2, status = $3, stripe_price_id = $4, current_period_start = $5, current_period_end = $6,
+			cancel_at_period_end = $7, past_due_since = $8, canceled_at = $9, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		sub.ID, sub.Plan, sub.Status, sub.StripePriceID, sub.CurrentPeriodStart, sub.CurrentPeriodEnd,
+		sub.CancelAtPeriodEnd, sub.PastDueSince, sub.CanceledAt,
+	).Scan(&sub.UpdatedAt)
+}
+
+// GetCurrentSubscription возвращает действующую (не отмененную) подписку пользователя или nil
+func (r *repository) GetCurrentSubscription(ctx context.Context, userID int64) (*models.Subscription, error) {
+	sub, err := scanSubscription(r.db.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE user_id = $1 AND status NOT IN ('canceled', 'incomplete_expired')`,
+		userID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return sub, err
+}
+
+// GetSubscriptionByStripeID возвращает подписку по ID Stripe Subscription или nil
+func (r *repository) GetSubscriptionByStripeID(ctx context.Context, stripeSubscriptionID string) (*models.Subscription, error) {
+	sub, err := scanSubscription(r.db.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE stripe_subscription_id = $1`,
+		stripeSubscriptionID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return sub, err
+}
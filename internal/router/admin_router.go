@@ -9,7 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AdminRouter(r gin.IRouter, jwtAuth service.JWTAuth, adminService service.AdminService) {
+func AdminRouter(r gin.IRouter, jwtAuth service.JWTAuth, adminService service.AdminService, ledgerService service.LedgerService, reconciliationService service.ReconciliationService, paymentService service.PaymentService, promoService service.PromoService, escrowCaptureService service.EscrowCaptureService) {
 	adminGroup := r.Group("/admin")
 	adminGroup.Use(middleware.AdminMiddleware(jwtAuth))
 	{
@@ -22,8 +22,22 @@ func AdminRouter(r gin.IRouter, jwtAuth service.JWTAuth, adminService service.Ad
 		adminGroup.GET("/conversations/count", admin.GetChatConversationCount(adminService))
 		adminGroup.GET("/jobs/active/count", admin.GetActiveJobsCount(adminService))
 		adminGroup.GET("/jobs", admin.GetJobsList(adminService))
+		adminGroup.POST("/jobs/:id/completion/resolve", admin.ResolveJobCompletionDispute(escrowCaptureService))
 		adminGroup.GET("/analytics", admin.GetPlatformAnalytics(adminService))
 		adminGroup.GET("/settings", admin.GetSystemSettings(adminService))
 		adminGroup.PUT("/settings", admin.UpdateSystemSettings(adminService))
+		adminGroup.GET("/ledger/trial-balance", admin.GetTrialBalance(ledgerService))
+		adminGroup.GET("/ledger/accounts/:code", admin.GetLedgerAccount(ledgerService))
+		adminGroup.GET("/reconciliation", admin.GetReconciliationReport(reconciliationService))
+		adminGroup.POST("/reconciliation/run", admin.RunReconciliation(reconciliationService))
+		adminGroup.PATCH("/reconciliation/issues/:id/resolve", admin.ResolveReconciliationIssue(reconciliationService))
+		adminGroup.POST("/payments/:id/refunds", admin.RefundPayment(paymentService))
+		adminGroup.GET("/payments/:id/refunds", admin.GetPaymentRefunds(paymentService))
+		adminGroup.GET("/promo-codes", admin.ListPromoCodes(promoService))
+		adminGroup.POST("/promo-codes", admin.CreatePromoCode(promoService))
+		adminGroup.PATCH("/promo-codes/:id", admin.UpdatePromoCode(promoService))
+		adminGroup.GET("/promo-codes/:id/redemptions", admin.GetPromoCodeRedemptions(promoService))
+		adminGroup.GET("/user/:userID/credits", admin.GetUserCredits(promoService))
+		adminGroup.POST("/user/:userID/credits", admin.AdjustUserCredits(promoService))
 	}
 }
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"moveshare/internal/handlers/boost"
+	"moveshare/internal/middleware"
+	"moveshare/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func BoostRouter(r gin.IRouter, boostService service.BoostService, subscriptionService service.SubscriptionService, jwtAuth service.JWTAuth) {
+	boostGroup := r.Group("/jobs")
+	boostGroup.Use(middleware.AuthMiddleware(jwtAuth))
+	{
+		boostGroup.GET("/boost-options/", middleware.LoadEntitlements(subscriptionService), boost.GetBoostOptions(boostService))
+		boostGroup.POST("/:id/boosts/", boost.PurchaseJobBoosts(boostService))
+		boostGroup.GET("/:id/boosts/", boost.GetJobBoosts(boostService))
+	}
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"moveshare/internal/handlers/crew"
+	"moveshare/internal/middleware"
+	"moveshare/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CrewRouter(r gin.IRouter, crewService service.CrewService, jwtAuth service.JWTAuth) {
+	crewGroup := r.Group("/crew")
+	crewGroup.Use(middleware.AuthMiddleware(jwtAuth))
+	{
+		crewGroup.GET("/team/", crew.GetTeamMembers(crewService))
+		crewGroup.POST("/team/", crew.AddTeamMember(crewService))
+		crewGroup.DELETE("/team/:memberId/", crew.RemoveTeamMember(crewService))
+		crewGroup.GET("/invites/", crew.GetTeamInvites(crewService))
+		crewGroup.POST("/invites/:ownerId/accept/", crew.AcceptTeamInvite(crewService))
+		crewGroup.DELETE("/invites/:ownerId/", crew.DeclineTeamInvite(crewService))
+		crewGroup.PUT("/jobs/:id/", crew.AssignJobCrew(crewService))
+		crewGroup.GET("/my-assignments/", crew.GetMyAssignments(crewService))
+	}
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"moveshare/internal/handlers/document"
+	"moveshare/internal/middleware"
+	"moveshare/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DocumentRouter(r gin.IRouter, documentService service.DocumentService, jwtAuth service.JWTAuth) {
+	documentGroup := r.Group("/jobs")
+	documentGroup.Use(middleware.AuthMiddleware(jwtAuth))
+	{
+		documentGroup.POST("/:id/bill-of-lading/", document.GenerateBillOfLading(documentService))
+		documentGroup.GET("/:id/bill-of-lading/", document.DownloadBillOfLading(documentService))
+	}
+}
@@ -8,11 +8,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupJobRoutes(r gin.IRouter, jobHandler *handlers.JobHandler, jwtAuth service.JWTAuth) {
+func SetupJobRoutes(r gin.IRouter, jobHandler *handlers.JobHandler, jwtAuth service.JWTAuth, idempotencyService service.IdempotencyService) {
 	protected := r.Group("/jobs")
 	protected.Use(middleware.AuthMiddleware(jwtAuth))
 	{
-		protected.POST("/post-new-job/", jobHandler.PostNewJob)
+		protected.POST("/post-new-job/", middleware.IdempotencyMiddleware(idempotencyService), jobHandler.PostNewJob)
 		protected.POST("/claim-job/:id/", jobHandler.ClaimJob)
 		protected.GET("/available-jobs/", jobHandler.GetAvailableJobs)    // уже обновлен
 		protected.GET("/filter-options/", jobHandler.GetJobFilterOptions) // новый эндпоинт
@@ -20,11 +20,15 @@ func SetupJobRoutes(r gin.IRouter, jobHandler *handlers.JobHandler, jwtAuth serv
 		protected.DELETE("/delete-job/:id/", jobHandler.DeleteJob)
 		protected.GET("/my-jobs/", jobHandler.GetMyJobs)
 		protected.GET("/:id/details/", jobHandler.GetJobByID)
+		protected.GET("/:id/posting/", jobHandler.GetJobPosting)
 		protected.GET("/claimed-jobs/", jobHandler.GetClaimedJobs)
 		protected.GET("/pending-jobs/", jobHandler.GetPendingJobs)
 		protected.GET("/today-schedule/", jobHandler.GetTodayScheduleJobs)
 		protected.GET("/user-work-stats/", jobHandler.GetUserWorkStats)
 		protected.POST("/mark-job-completed/:id/", jobHandler.MarkJobCompleted)
+		protected.GET("/:id/completion/", jobHandler.GetJobCompletion)
+		protected.POST("/:id/completion/confirm/", jobHandler.ConfirmJobCompletion)
+		protected.POST("/:id/completion/dispute/", jobHandler.DisputeJobCompletion)
 		protected.POST("/cancel-jobs/", jobHandler.CancelJobs)
 		protected.POST("/export-jobs/", jobHandler.ExportJobs)
 		protected.POST("/upload-files/:id/", jobHandler.UploadJobFiles)
@@ -32,5 +36,8 @@ func SetupJobRoutes(r gin.IRouter, jobHandler *handlers.JobHandler, jwtAuth serv
 		protected.POST("/upload-work-photos/:id/", jobHandler.UploadWorkPhotos)
 		protected.GET("/:id/files/", jobHandler.GetJobFiles)
 		protected.GET("/:id/files/by-type/", jobHandler.GetJobFilesByType)
+		protected.POST("/:id/milestones/:milestone/", jobHandler.RecordJobMilestone)
+		protected.GET("/:id/milestones/", jobHandler.GetJobTimeline)
+		protected.GET("/performance/:userId/", jobHandler.GetMoverPerformance)
 	}
 }
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"moveshare/internal/handlers/job_template"
+	"moveshare/internal/middleware"
+	"moveshare/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func JobTemplateRouter(r gin.IRouter, templateService service.JobTemplateService, jwtAuth service.JWTAuth) {
+	templateGroup := r.Group("/job-templates")
+	templateGroup.Use(middleware.AuthMiddleware(jwtAuth))
+	{
+		templateGroup.GET("/", job_template.GetTemplates(templateService))
+		templateGroup.POST("/", job_template.CreateTemplate(templateService))
+		templateGroup.POST("/from-job/:jobId/", job_template.SaveJobAsTemplate(templateService))
+		templateGroup.GET("/:id/", job_template.GetTemplate(templateService))
+		templateGroup.DELETE("/:id/", job_template.DeleteTemplate(templateService))
+		templateGroup.POST("/:id/create-job/", job_template.CreateJobFromTemplate(templateService))
+		templateGroup.PUT("/:id/recurrence/", job_template.SetRecurrence(templateService))
+		templateGroup.DELETE("/:id/recurrence/", job_template.ClearRecurrence(templateService))
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func PaymentRouter(r gin.IRouter, paymentService service.PaymentService, jwtAuth service.JWTAuth) {
+func PaymentRouter(r gin.IRouter, paymentService service.PaymentService, payoutService service.PayoutService, invoiceService service.InvoiceService, ledgerService service.LedgerService, promoService service.PromoService, statementService service.StatementService, idempotencyService service.IdempotencyService, jwtAuth service.JWTAuth) {
 	paymentGroup := r.Group("/payment")
 	paymentGroup.Use(middleware.AuthMiddleware(jwtAuth))
 	{
@@ -19,11 +19,39 @@ func PaymentRouter(r gin.IRouter, paymentService service.PaymentService, jwtAuth
 		paymentGroup.DELETE("/cards/:cardId", payment.DeleteCard(paymentService))
 		paymentGroup.PATCH("/cards/:cardId/default", payment.SetDefaultCard(paymentService))
 
-		paymentGroup.POST("/create-intent", payment.CreatePayment(paymentService))
+		// Payment Methods (US bank accounts, ACH debit)
+		paymentGroup.POST("/bank-accounts/setup-intent", payment.CreateBankAccountSetupIntent(paymentService))
+		paymentGroup.POST("/bank-accounts", payment.AddBankAccount(paymentService))
+
+		paymentGroup.POST("/create-intent", middleware.IdempotencyMiddleware(idempotencyService), payment.CreatePayment(paymentService))
 		paymentGroup.POST("/confirm-payment", payment.ConfirmPayment(paymentService))
 		paymentGroup.GET("/history", payment.GetPaymentHistory(paymentService))
+
+		// Refunds
+		paymentGroup.POST("/payments/:id/refunds", middleware.IdempotencyMiddleware(idempotencyService), payment.RefundPayment(paymentService))
+		paymentGroup.GET("/payments/:id/refunds", payment.GetPaymentRefunds(paymentService))
+
+		// Tips
+		paymentGroup.POST("/jobs/:id/tip", middleware.IdempotencyMiddleware(idempotencyService), payment.TipJob(paymentService))
+
+		// Invoices
+		paymentGroup.GET("/invoices", payment.GetInvoices(invoiceService))
+		paymentGroup.GET("/invoices/:id", payment.DownloadInvoice(invoiceService))
+
+		// Mover payouts (Stripe Connect)
+		paymentGroup.POST("/connect/onboarding", payment.StartConnectOnboarding(payoutService))
+		paymentGroup.GET("/connect/account", payment.GetConnectAccount(payoutService))
+		paymentGroup.GET("/payouts", payment.GetPayoutHistory(payoutService))
+		paymentGroup.GET("/balance", payment.GetLedgerBalance(ledgerService))
+
+		// Earnings statements (PDF/CSV)
+		paymentGroup.GET("/earnings/statements", payment.GetEarningsStatement(statementService))
+		paymentGroup.GET("/earnings/tax-summary", payment.GetEarningsTaxSummary(statementService))
+
+		// Account credits for platform fees
+		paymentGroup.GET("/credits", payment.GetCredits(promoService))
 	}
 
-	// Webhook endpoint (без аутентификации)
-	// r.POST("/payment/webhook", payment.StripeWebhook(paymentService))
+	// Webhook endpoint (без аутентификации, проверяется подпись Stripe)
+	r.POST("/payment/webhook", payment.StripeWebhook(paymentService))
 }
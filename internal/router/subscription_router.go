@@ -0,0 +1,22 @@
+package router
+
+import (
+	"moveshare/internal/handlers/subscription"
+	"moveshare/internal/middleware"
+	"moveshare/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SubscriptionRouter(r gin.IRouter, subscriptionService service.SubscriptionService, idempotencyService service.IdempotencyService, jwtAuth service.JWTAuth) {
+	subscriptionGroup := r.Group("/subscriptions")
+	subscriptionGroup.Use(middleware.AuthMiddleware(jwtAuth))
+	{
+		subscriptionGroup.GET("/plans", subscription.GetPlans(subscriptionService))
+		subscriptionGroup.GET("/current", subscription.GetSubscription(subscriptionService))
+		subscriptionGroup.POST("", middleware.IdempotencyMiddleware(idempotencyService), subscription.Subscribe(subscriptionService))
+		subscriptionGroup.PATCH("/plan", subscription.ChangePlan(subscriptionService))
+		subscriptionGroup.POST("/cancel", subscription.CancelSubscription(subscriptionService))
+		subscriptionGroup.POST("/resume", subscription.ResumeSubscription(subscriptionService))
+	}
+}
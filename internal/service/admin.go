@@ -18,6 +18,7 @@ type AdminService interface {
 	// GetActiveJobs(ctx context.Context, limit, offset int) ([]models.Job, error)
 	ChangeUserStatus(ctx context.Context, userID int, newStatus string) error
 	GetUserRole(ctx context.Context, userID int64) (string, error)
+	GetAdminUserIDs(ctx context.Context) ([]int64, error)
 	ChangeVerificationFileStatus(ctx context.Context, fileID int, newStatus string) error
 	GetUserFullInfo(ctx context.Context, userID int64) (*models.UserFullInfo, error)
 	GetPlatformAnalytics(ctx context.Context, days int) (*models.PlatformAnalytics, error)
@@ -80,6 +81,10 @@ func (s *adminService) GetUserRole(ctx context.Context, userID int64) (string, e
 	return s.adminRepo.GetUserRole(ctx, userID)
 }
 
+func (s *adminService) GetAdminUserIDs(ctx context.Context) ([]int64, error) {
+	return s.adminRepo.GetAdminUserIDs(ctx)
+}
+
 func (s *adminService) ChangeVerificationFileStatus(ctx context.Context, fileID int, newStatus string) error {
 	return s.adminRepo.ChangeVerificationFileStatus(ctx, fileID, newStatus)
 }
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository"
+	"moveshare/internal/repository/boost"
+	"moveshare/internal/utils"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+// nearbyPushLimit - максимум перевозчиков, получающих уведомление о продвигаемой работе
+const nearbyPushLimit = 200
+
+type BoostService interface {
+	GetBoostOptions(ctx context.Context) ([]models.BoostOption, error)
+	// QuoteBoosts - userID покупателя: продвижения, оставшиеся в его плане подписки, не оплачиваются
+	QuoteBoosts(ctx context.Context, userID int64, boostTypes []string) (*models.BoostQuote, error)
+	// ActivateBoosts включает продвижения, оплата которых уже подтверждена (или не требуется)
+	ActivateBoosts(ctx context.Context, userID, jobID int64, quote *models.BoostQuote, paymentIntentID string) ([]models.JobBoost, error)
+	// PurchaseBoosts - покупка для опубликованной работы: платные продвижения ждут успешной оплаты
+	PurchaseBoosts(ctx context.Context, userID, jobID int64, req *models.PurchaseBoostsRequest) (*models.PurchaseBoostsResponse, error)
+	// HandlePaymentSucceeded включает продвижения, ожидавшие этого платежа (PaymentService.OnPaymentSucceeded)
+	HandlePaymentSucceeded(ctx context.Context, payment *models.Payment) error
+	GetJobBoosts(ctx context.Context, jobID int64) ([]models.JobBoost, error)
+}
+
+type boostService struct {
+	repo                boost.BoostRepository
+	jobRepo             *repository.JobRepository
+	adminService        AdminService
+	paymentService      PaymentService
+	notificationService NotificationService
+	subscriptionService SubscriptionService
+}
+
+func NewBoostService(repo boost.BoostRepository, jobRepo *repository.JobRepository, adminService AdminService, paymentService PaymentService, notificationService NotificationService, subscriptionService SubscriptionService) BoostService {
+	return &boostService{
+		repo:                repo,
+		jobRepo:             jobRepo,
+		adminService:        adminService,
+		paymentService:      paymentService,
+		notificationService: notificationService,
+		subscriptionService: subscriptionService,
+	}
+}
+
+func (s *boostService) GetBoostOptions(ctx context.Context) ([]models.BoostOption, error) {
+	settings, err := s.adminService.GetSystemSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system settings: %w", err)
+	}
+
+	return []models.BoostOption{
+		{BoostType: models.BoostTypeUrgent, PriceCents: settings.UrgentBoostPriceCents, DurationHours: settings.BoostDurationHours},
+		{BoostType: models.BoostTypePinToTop, PriceCents: settings.PinBoostPriceCents, DurationHours: settings.BoostDurationHours},
+		{BoostType: models.BoostTypeNearbyPush, PriceCents: settings.NearbyPushBoostPriceCents, DurationHours: settings.BoostDurationHours},
+	}, nil
+}
+
+func (s *boostService) QuoteBoosts(ctx context.Context, userID int64, boostTypes []string) (*models.BoostQuote, error) {
+	options, err := s.GetBoostOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.BoostQuote{Options: []models.BoostOption{}}
+	seen := make(map[string]bool)
+	for _, boostType := range boostTypes {
+		if !models.IsValidBoostType(boostType) {
+			return nil, fmt.Errorf("invalid boost type: %s", boostType)
+		}
+		if seen[boostType] {
+			continue
+		}
+		seen[boostType] = true
+
+		for _, option := range options {
+			if option.BoostType == boostType {
+				quote.Options = append(quote.Options, option)
+			}
+		}
+	}
+
+	remaining, err := s.remainingIncludedBoosts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range quote.Options {
+		if remaining > 0 {
+			quote.Options[i].IncludedInPlan = true
+			remaining--
+			continue
+		}
+		quote.TotalCents += int64(quote.Options[i].PriceCents)
+	}
+
+	return quote, nil
+}
+
+// remainingIncludedBoosts - сколько бесплатных продвижений плана подписки осталось в текущем периоде
+func (s *boostService) remainingIncludedBoosts(ctx context.Context, userID int64) (int, error) {
+	if userID == 0 || s.subscriptionService == nil {
+		return 0, nil
+	}
+
+	entitlements, err := s.subscriptionService.GetEntitlements(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get plan entitlements: %w", err)
+	}
+	if entitlements.IncludedBoosts == 0 {
+		return 0, nil
+	}
+
+	used, err := s.repo.CountIncludedBoosts(ctx, userID, entitlements.PeriodStart)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count included boosts: %w", err)
+	}
+	if used >= entitlements.IncludedBoosts {
+		return 0, nil
+	}
+	return entitlements.IncludedBoosts - used, nil
+}
+
+func (s *boostService) ActivateBoosts(ctx context.Context, userID, jobID int64, quote *models.BoostQuote, paymentIntentID string) ([]models.JobBoost, error) {
+	return s.createBoosts(ctx, userID, jobID, quote, paymentIntentID, false)
+}
+
+// createBoosts сохраняет продвижения по расчету. При awaitingPayment платные продвижения остаются
+// pending до успешной оплаты paymentIntentID, входящие в план действуют сразу. Возвращает действующие
+func (s *boostService) createBoosts(ctx context.Context, userID, jobID int64, quote *models.BoostQuote, paymentIntentID string, awaitingPayment bool) ([]models.JobBoost, error) {
+	if quote == nil || len(quote.Options) == 0 {
+		return []models.JobBoost{}, nil
+	}
+
+	now := time.Now()
+	boosts := make([]models.JobBoost, 0, len(quote.Options))
+	for _, option := range quote.Options {
+		b := models.JobBoost{
+			JobID:          jobID,
+			BoostType:      option.BoostType,
+			PriceCents:     option.PriceCents,
+			PurchasedBy:    userID,
+			IncludedInPlan: option.IncludedInPlan,
+			Status:         models.BoostStatusActive,
+			StartsAt:       now,
+			ExpiresAt:      now.Add(time.Duration(option.DurationHours) * time.Hour),
+		}
+		if option.IncludedInPlan {
+			b.PriceCents = 0
+		} else {
+			intentID := paymentIntentID
+			b.StripePaymentIntentID = &intentID
+			if awaitingPayment {
+				b.Status = models.BoostStatusPending
+			}
+		}
+		boosts = append(boosts, b)
+	}
+
+	created, err := s.repo.CreateJobBoosts(ctx, boosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save job boosts: %w", err)
+	}
+
+	active := make([]models.JobBoost, 0, len(created))
+	for _, b := range created {
+		if b.Status == models.BoostStatusActive {
+			active = append(active, b)
+		}
+	}
+	s.startBoosts(active)
+
+	return active, nil
+}
+
+func (s *boostService) HandlePaymentSucceeded(ctx context.Context, payment *models.Payment) error {
+	boosts, err := s.repo.ActivatePendingBoosts(ctx, payment.StripePaymentIntentID)
+	if err != nil {
+		return fmt.Errorf("failed to activate paid boosts: %w", err)
+	}
+	if len(boosts) > 0 {
+		fmt.Printf("Activated %d paid boost(s) for payment %d\n", len(boosts), payment.ID)
+	}
+
+	s.startBoosts(boosts)
+	return nil
+}
+
+// startBoosts запускает то, что продвижение делает в момент включения (рассылку перевозчикам)
+func (s *boostService) startBoosts(boosts []models.JobBoost) {
+	for _, b := range boosts {
+		if b.BoostType == models.BoostTypeNearbyPush {
+			go s.pushToNearbyMovers(context.Background(), b.PurchasedBy, b.JobID)
+		}
+	}
+}
+
+func (s *boostService) PurchaseBoosts(ctx context.Context, userID, jobID int64, req *models.PurchaseBoostsRequest) (*models.PurchaseBoostsResponse, error) {
+	job, err := s.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+	if job.ContractorID != userID {
+		return nil, fmt.Errorf("you can only boost your own jobs")
+	}
+	if job.JobStatus != "active" || job.ExecutorID != nil {
+		return nil, fmt.Errorf("only active unclaimed jobs can be boosted, current status: %s", job.JobStatus)
+	}
+
+	quote, err := s.QuoteBoosts(ctx, userID, req.BoostTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.repo.GetActiveJobBoosts(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active boosts: %w", err)
+	}
+	for _, b := range active {
+		for _, option := range quote.Options {
+			if b.BoostType == option.BoostType {
+				return nil, fmt.Errorf("boost %s is already active until %s", b.BoostType, b.ExpiresAt.Format(time.RFC3339))
+			}
+		}
+	}
+
+	// Продвижения, целиком покрытые планом подписки, не оплачиваются
+	paymentResponse := &models.CreatePaymentResponse{Status: "not_required", Success: true}
+	for _, option := range quote.Options {
+		if !option.IncludedInPlan && option.PriceCents <= 0 {
+			return nil, fmt.Errorf("boost prices are not configured")
+		}
+	}
+
+	if quote.TotalCents > 0 {
+		paymentResponse, err = s.paymentService.CreatePayment(ctx, userID, &models.CreatePaymentRequest{
+			JobID:           &jobID,
+			PaymentMethodID: req.PaymentMethodID,
+			AmountCents:     quote.TotalCents,
+			Description:     fmt.Sprintf("Listing boost for job #%d", jobID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("payment processing failed: %w", err)
+		}
+	}
+
+	// PaymentIntent создается без подтверждения: платные продвижения включатся в HandlePaymentSucceeded.
+	// До этого в ответе только client secret для подтверждения оплаты
+	awaitingPayment := quote.TotalCents > 0 && paymentResponse.Status != string(stripe.PaymentIntentStatusSucceeded)
+	boosts, err := s.createBoosts(ctx, userID, jobID, quote, paymentResponse.PaymentIntentID, awaitingPayment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PurchaseBoostsResponse{
+		Boosts:          boosts,
+		PaymentIntentID: paymentResponse.PaymentIntentID,
+		ClientSecret:    paymentResponse.ClientSecret,
+		Status:          paymentResponse.Status,
+		TotalAmount:     float64(quote.TotalCents) / 100,
+	}, nil
+}
+
+func (s *boostService) GetJobBoosts(ctx context.Context, jobID int64) ([]models.JobBoost, error) {
+	return s.repo.GetActiveJobBoosts(ctx, jobID)
+}
+
+// pushToNearbyMovers рассылает уведомление перевозчикам в штате погрузки
+func (s *boostService) pushToNearbyMovers(ctx context.Context, contractorID, jobID int64) {
+	job, err := s.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		fmt.Printf("Failed to load job %d for nearby push: %v\n", jobID, err)
+		return
+	}
+
+	movers, err := s.repo.GetNearbyMovers(ctx, job.PickupState, utils.StateFullName(job.PickupState), contractorID, nearbyPushLimit)
+	if err != nil {
+		fmt.Printf("Failed to get nearby movers for job %d: %v\n", jobID, err)
+		return
+	}
+
+	for _, moverID := range movers {
+		req := &models.NotificationRequest{
+			UserID:        moverID,
+			Type:          models.NotificationTypeNewJob,
+			Title:         "New Job Near You",
+			Message:       fmt.Sprintf("%s job from %s, %s to %s, %s on %s pays $%.2f.", job.JobType, job.PickupCity, job.PickupState, job.DeliveryCity, job.DeliveryState, job.PickupDate.Format("Jan 2, 2006"), job.PaymentAmount),
+			JobID:         &jobID,
+			RelatedUserID: &contractorID,
+			Priority:      models.NotificationPriorityHigh,
+			Actions: []models.NotificationAction{
+				{Label: "View Job", Action: "view_job", URL: fmt.Sprintf("/jobs/%d", jobID), Primary: true},
+				{Label: "Mark as Read", Action: "mark_read"},
+			},
+			Metadata: map[string]interface{}{
+				"boost_type": models.BoostTypeNearbyPush,
+			},
+		}
+
+		if _, err := s.notificationService.CreateNotification(ctx, req); err != nil {
+			fmt.Printf("Failed to push job %d to mover %d: %v\n", jobID, moverID, err)
+		}
+	}
+
+	fmt.Printf("Job %d pushed to %d nearby movers\n", jobID, len(movers))
+}
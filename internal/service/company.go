@@ -53,6 +53,7 @@ func (s *companyService) UpdateCompany(ctx context.Context, userID int64, req dt
 		City:               req.City,
 		ZipCode:            req.ZipCode,
 		DotNumber:          req.DotNumber,
+		TaxID:              req.TaxID,
 	}
 
 	return s.companyRepo.UpdateCompany(ctx, userID, company)
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository"
+	"moveshare/internal/repository/crew"
+	"moveshare/internal/repository/truck"
+	"moveshare/internal/repository/user"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CrewService interface {
+	AddTeamMember(ctx context.Context, ownerID int64, req *models.AddTeamMemberRequest) (*models.TeamMember, error)
+	GetTeamMembers(ctx context.Context, ownerID int64) ([]models.TeamMember, error)
+	RemoveTeamMember(ctx context.Context, ownerID, memberID int64) error
+	GetTeamInvites(ctx context.Context, memberID int64) ([]models.TeamInvite, error)
+	AcceptTeamInvite(ctx context.Context, memberID, ownerID int64) (*models.TeamMember, error)
+	DeclineTeamInvite(ctx context.Context, memberID, ownerID int64) error
+	AssignJobCrew(ctx context.Context, userID, jobID int64, req *models.AssignCrewRequest) (*models.JobCrew, error)
+	GetJobCrew(ctx context.Context, jobID int64) (*models.JobCrew, error)
+	GetMyAssignments(ctx context.Context, memberID int64, page, limit int) ([]models.AssignedJob, int, error)
+}
+
+// ErrTeamInviteNotFound - у пользователя нет такого непринятого приглашения
+var ErrTeamInviteNotFound = errors.New("team invite not found")
+
+type crewService struct {
+	repo                crew.CrewRepository
+	jobRepo             *repository.JobRepository
+	truckRepo           truck.TruckRepository
+	userRepo            user.UserRepository
+	notificationService NotificationService
+	subscriptionService SubscriptionService
+}
+
+func NewCrewService(repo crew.CrewRepository, jobRepo *repository.JobRepository, truckRepo truck.TruckRepository, userRepo user.UserRepository, notificationService NotificationService, subscriptionService SubscriptionService) CrewService {
+	return &crewService{
+		repo:                repo,
+		jobRepo:             jobRepo,
+		truckRepo:           truckRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		subscriptionService: subscriptionService,
+	}
+}
+
+// AddTeamMember приглашает пользователя в команду. Назначать его на работы можно
+// только после того, как он примет приглашение
+func (s *crewService) AddTeamMember(ctx context.Context, ownerID int64, req *models.AddTeamMemberRequest) (*models.TeamMember, error) {
+	memberUser, err := s.userRepo.FindUserByEmailOrUsername(ctx, req.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if memberUser.ID == ownerID {
+		return nil, fmt.Errorf("you cannot add yourself to your team")
+	}
+
+	if err := s.checkTeamSeat(ctx, ownerID, memberUser.ID); err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.AddTeamMember(ctx, ownerID, memberUser.ID, req.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add team member: %w", err)
+	}
+	member.Username = memberUser.Username
+	member.Email = memberUser.Email
+
+	if member.Status == models.TeamMemberPending && s.notificationService != nil {
+		s.notifyTeamInvite(ctx, ownerID, member)
+	}
+
+	return member, nil
+}
+
+func (s *crewService) notifyTeamInvite(ctx context.Context, ownerID int64, member *models.TeamMember) {
+	req := &models.NotificationRequest{
+		UserID:        member.MemberID,
+		Type:          models.NotificationTypeSystem,
+		Title:         "Team Invitation",
+		Message:       fmt.Sprintf("You have been invited to join a moving company team as %s.", member.Role),
+		RelatedUserID: &ownerID,
+		Priority:      models.NotificationPriorityNormal,
+		Actions: []models.NotificationAction{
+			{Label: "View Invites", Action: "view_team_invites", URL: "/crew/invites", Primary: true},
+			{Label: "Mark as Read", Action: "mark_read"},
+		},
+		Metadata: map[string]interface{}{
+			"crew_role": member.Role,
+			"owner_id":  ownerID,
+		},
+	}
+
+	if _, err := s.notificationService.CreateNotification(ctx, req); err != nil {
+		fmt.Printf("Failed to notify user %d about team invite from %d: %v\n", member.MemberID, ownerID, err)
+	}
+}
+
+// checkTeamSeat проверяет лимит команды по плану подписки. Смена роли участника места не занимает,
+// а участники сверх лимита после понижения плана остаются в команде
+func (s *crewService) checkTeamSeat(ctx context.Context, ownerID, memberID int64) error {
+	if s.subscriptionService == nil {
+		return nil
+	}
+
+	entitlements, err := s.subscriptionService.GetEntitlements(ctx, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to get plan entitlements: %w", err)
+	}
+
+	members, err := s.repo.GetTeamMembers(ctx, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to get team members: %w", err)
+	}
+	for _, m := range members {
+		if m.MemberID == memberID {
+			return nil
+		}
+	}
+
+	if !entitlements.HasTeamSeat(len(members)) {
+		return fmt.Errorf("%w: the %s plan includes %d seats", ErrTeamSeatLimit, entitlements.Plan, entitlements.TeamSeats)
+	}
+	return nil
+}
+
+func (s *crewService) GetTeamMembers(ctx context.Context, ownerID int64) ([]models.TeamMember, error) {
+	return s.repo.GetTeamMembers(ctx, ownerID)
+}
+
+func (s *crewService) RemoveTeamMember(ctx context.Context, ownerID, memberID int64) error {
+	return s.repo.RemoveTeamMember(ctx, ownerID, memberID)
+}
+
+func (s *crewService) GetTeamInvites(ctx context.Context, memberID int64) ([]models.TeamInvite, error) {
+	return s.repo.GetTeamInvites(ctx, memberID)
+}
+
+// AcceptTeamInvite - пользователь принимает приглашение и становится участником команды ownerID
+func (s *crewService) AcceptTeamInvite(ctx context.Context, memberID, ownerID int64) (*models.TeamMember, error) {
+	if err := s.repo.AcceptTeamInvite(ctx, ownerID, memberID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTeamInviteNotFound
+		}
+		return nil, fmt.Errorf("failed to accept team invite: %w", err)
+	}
+
+	member, err := s.repo.GetTeamMember(ctx, ownerID, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team member: %w", err)
+	}
+
+	if s.notificationService != nil {
+		s.notificationService.NotifySystemMessage(ownerID, fmt.Sprintf("%s has joined your team as %s", member.Username, member.Role), "info")
+	}
+
+	return member, nil
+}
+
+func (s *crewService) DeclineTeamInvite(ctx context.Context, memberID, ownerID int64) error {
+	return s.repo.DeclineTeamInvite(ctx, ownerID, memberID)
+}
+
+func (s *crewService) AssignJobCrew(ctx context.Context, userID, jobID int64, req *models.AssignCrewRequest) (*models.JobCrew, error) {
+	job, err := s.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+
+	// Назначать команду может только компания, взявшая работу
+	if job.ExecutorID == nil || *job.ExecutorID != userID {
+		return nil, fmt.Errorf("only the company that claimed this job can assign a crew")
+	}
+
+	if job.JobStatus != "claimed" && job.JobStatus != "pending" && job.JobStatus != "in_progress" {
+		return nil, fmt.Errorf("crew can only be assigned to claimed jobs, current status: %s", job.JobStatus)
+	}
+
+	if req.TruckID != nil {
+		t, err := s.truckRepo.GetTruckByID(ctx, *req.TruckID)
+		if err != nil {
+			return nil, fmt.Errorf("truck not found: %w", err)
+		}
+		if t.UserID != userID {
+			return nil, fmt.Errorf("truck %d does not belong to your company", *req.TruckID)
+		}
+	}
+
+	hasDriver := false
+	seen := make(map[int64]bool)
+	for _, m := range req.Members {
+		if seen[m.UserID] {
+			return nil, fmt.Errorf("user %d is listed more than once", m.UserID)
+		}
+		seen[m.UserID] = true
+
+		// Владелец компании может сам выехать на работу
+		if m.UserID != userID {
+			member, err := s.repo.GetTeamMember(ctx, userID, m.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("user %d is not a member of your team", m.UserID)
+			}
+			if member.Status != models.TeamMemberActive {
+				return nil, fmt.Errorf("user %d has not accepted your team invite yet", m.UserID)
+			}
+		}
+		if m.Role == models.CrewRoleDriver {
+			hasDriver = true
+		}
+	}
+
+	if !hasDriver {
+		return nil, fmt.Errorf("at least one driver must be assigned")
+	}
+
+	if err := s.repo.AssignJobCrew(ctx, jobID, userID, req.TruckID, req.Members); err != nil {
+		return nil, fmt.Errorf("failed to assign crew: %w", err)
+	}
+
+	if s.notificationService != nil {
+		for _, m := range req.Members {
+			if m.UserID == userID {
+				continue
+			}
+			s.notifyCrewMember(ctx, m.UserID, userID, job, m.Role)
+		}
+		s.notificationService.NotifyJobUpdate(job.ContractorID, jobID, job.JobStatus, "A crew has been assigned to your job")
+	}
+
+	return s.GetJobCrew(ctx, jobID)
+}
+
+func (s *crewService) notifyCrewMember(ctx context.Context, memberID, assignedBy int64, job *models.Job, role string) {
+	jobID := job.ID
+	req := &models.NotificationRequest{
+		UserID:        memberID,
+		Type:          models.NotificationTypeJobUpdate,
+		Title:         "New Job Assignment",
+		Message:       fmt.Sprintf("You have been assigned as %s for '%s' on %s (%s → %s).", role, job.JobType, job.PickupDate.Format("Jan 2, 2006"), job.PickupAddress, job.DeliveryAddress),
+		JobID:         &jobID,
+		RelatedUserID: &assignedBy,
+		Priority:      models.NotificationPriorityHigh,
+		Actions: []models.NotificationAction{
+			{Label: "View Assignment", Action: "view_job", URL: fmt.Sprintf("/jobs/%d", jobID), Primary: true},
+			{Label: "Mark as Read", Action: "mark_read"},
+		},
+		Metadata: map[string]interface{}{
+			"crew_role":   role,
+			"assigned_by": assignedBy,
+		},
+	}
+
+	if _, err := s.notificationService.CreateNotification(ctx, req); err != nil {
+		fmt.Printf("Failed to notify crew member %d about job %d: %v\n", memberID, jobID, err)
+	}
+	s.notificationService.NotifyJobUpdate(memberID, jobID, job.JobStatus, "You have been assigned to a job")
+}
+
+func (s *crewService) GetJobCrew(ctx context.Context, jobID int64) (*models.JobCrew, error) {
+	jobCrew, err := s.repo.GetJobCrew(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if jobCrew.TruckID != nil {
+		t, err := s.truckRepo.GetTruckByID(ctx, *jobCrew.TruckID)
+		if err != nil {
+			fmt.Printf("Failed to get truck %d for job %d: %v\n", *jobCrew.TruckID, jobID, err)
+		} else {
+			jobCrew.Truck = t
+		}
+	}
+
+	return jobCrew, nil
+}
+
+func (s *crewService) GetMyAssignments(ctx context.Context, memberID int64, page, limit int) ([]models.AssignedJob, int, error) {
+	offset := (page - 1) * limit
+	assignments, err := s.repo.GetMemberAssignments(ctx, memberID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.GetCountMemberAssignments(ctx, memberID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return assignments, total, nil
+}
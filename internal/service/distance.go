@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"moveshare/internal/config"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/distance"
+	"moveshare/internal/utils"
+	"strings"
+)
+
+// DistanceProvider считает расстояние между двумя точками маршрута
+type DistanceProvider interface {
+	Name() string
+	Distance(ctx context.Context, origin, destination models.RouteEndpoint) (*models.DistanceEstimate, error)
+}
+
+type DistanceService interface {
+	Calculate(ctx context.Context, origin, destination models.RouteEndpoint) (*models.DistanceEstimate, error)
+}
+
+type distanceService struct {
+	repo      distance.DistanceRepository
+	providers []DistanceProvider
+}
+
+// NewDistanceService пробует провайдеров по порядку; repo может быть nil (без кэша)
+func NewDistanceService(repo distance.DistanceRepository, providers ...DistanceProvider) DistanceService {
+	return &distanceService{
+		repo:      repo,
+		providers: providers,
+	}
+}
+
+// NewDistanceProviders собирает цепочку провайдеров согласно конфигурации:
+// google -> offline при сбое, либо только offline
+func NewDistanceProviders(cfg *config.Config, repo distance.DistanceRepository) []DistanceProvider {
+	offline := NewOfflineDistanceProvider(repo)
+	if cfg.Distance.Provider == models.DistanceProviderOffline {
+		return []DistanceProvider{offline}
+	}
+	return []DistanceProvider{NewGoogleDistanceProvider(&cfg.GoogleMaps), offline}
+}
+
+func (s *distanceService) Calculate(ctx context.Context, origin, destination models.RouteEndpoint) (*models.DistanceEstimate, error) {
+	originKey := routeEndpointKey(origin)
+	destinationKey := routeEndpointKey(destination)
+
+	if s.repo != nil {
+		cached, err := s.repo.GetCachedDistance(ctx, originKey, destinationKey)
+		if err != nil {
+			fmt.Printf("Failed to read distance cache: %v\n", err)
+		} else if cached != nil {
+			return cached, nil
+		}
+	}
+
+	var errs []string
+	for _, provider := range s.providers {
+		estimate, err := provider.Distance(ctx, origin, destination)
+		if err != nil {
+			fmt.Printf("Distance provider %s failed: %v\n", provider.Name(), err)
+			errs = append(errs, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+
+		// Кэшируем только точные (дорожные) расстояния, офлайн-оценку дешевле пересчитать
+		if s.repo != nil && estimate.Provider != models.DistanceProviderOffline {
+			if err := s.repo.SaveCachedDistance(ctx, originKey, destinationKey, estimate); err != nil {
+				fmt.Printf("Failed to save distance cache: %v\n", err)
+			}
+		}
+
+		return estimate, nil
+	}
+
+	return nil, fmt.Errorf("all distance providers failed: %s", strings.Join(errs, "; "))
+}
+
+func routeEndpointKey(e models.RouteEndpoint) string {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+	return normalize(e.Address) + "|" + normalize(e.City) + "|" + normalize(utils.StateFullName(e.State))
+}
+
+// Google Distance Matrix
+
+type googleDistanceProvider struct {
+	cfg *config.GoogleMapsConfig
+}
+
+func NewGoogleDistanceProvider(cfg *config.GoogleMapsConfig) DistanceProvider {
+	return &googleDistanceProvider{cfg: cfg}
+}
+
+func (p *googleDistanceProvider) Name() string {
+	return models.DistanceProviderGoogle
+}
+
+func (p *googleDistanceProvider) Distance(ctx context.Context, origin, destination models.RouteEndpoint) (*models.DistanceEstimate, error) {
+	if p.cfg == nil || p.cfg.APIKey == "" {
+		return nil, fmt.Errorf("google maps API key is not configured")
+	}
+
+	result, err := utils.GetDistanceFromAddresses(origin.Address, destination.Address, p.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DistanceEstimate{
+		Meters:          result.DistanceValue,
+		DurationSeconds: result.DurationValue,
+		Provider:        models.DistanceProviderGoogle,
+	}, nil
+}
+
+// Offline haversine по центроидам городов (с фолбэком на центроид штата)
+
+const (
+	// Дорожное расстояние в среднем длиннее прямой примерно на 20%
+	offlineRoadCircuityFactor = 1.2
+	// Средняя скорость грузовика для оценки времени в пути, м/с (~45 mph)
+	offlineAverageSpeedMps = 20.0
+)
+
+type offlineDistanceProvider struct {
+	repo distance.DistanceRepository
+}
+
+// NewOfflineDistanceProvider не требует сети; repo может быть nil, тогда используются только центроиды штатов
+func NewOfflineDistanceProvider(repo distance.DistanceRepository) DistanceProvider {
+	return &offlineDistanceProvider{repo: repo}
+}
+
+func (p *offlineDistanceProvider) Name() string {
+	return models.DistanceProviderOffline
+}
+
+func (p *offlineDistanceProvider) Distance(ctx context.Context, origin, destination models.RouteEndpoint) (*models.DistanceEstimate, error) {
+	from, err := p.centroid(ctx, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := p.centroid(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	meters := utils.HaversineMeters(from, to) * offlineRoadCircuityFactor
+
+	return &models.DistanceEstimate{
+		Meters:          int(meters),
+		DurationSeconds: int(meters / offlineAverageSpeedMps),
+		Provider:        models.DistanceProviderOffline,
+	}, nil
+}
+
+func (p *offlineDistanceProvider) centroid(ctx context.Context, e models.RouteEndpoint) (utils.Point, error) {
+	if p.repo != nil && e.City != "" {
+		lat, lng, found, err := p.repo.GetCityCentroid(ctx, e.City, utils.StateFullName(e.State))
+		if err != nil {
+			fmt.Printf("Failed to look up centroid for %s, %s: %v\n", e.City, e.State, err)
+		} else if found {
+			return utils.Point{Lat: lat, Lng: lng}, nil
+		}
+	}
+
+	if point, ok := utils.StateCentroid(e.State); ok {
+		return point, nil
+	}
+
+	return utils.Point{}, fmt.Errorf("no centroid known for %q, %q", e.City, e.State)
+}
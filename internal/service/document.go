@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"moveshare/internal/models"
+	"moveshare/internal/repository"
+	"moveshare/internal/repository/company"
+	"moveshare/internal/utils"
+	"time"
+)
+
+type DocumentService interface {
+	GenerateBillOfLading(ctx context.Context, userID, jobID int64) (*models.JobFile, error)
+	GetBillOfLading(ctx context.Context, userID, jobID int64) (*models.JobFile, io.ReadCloser, error)
+}
+
+type documentService struct {
+	jobRepo     *repository.JobRepository
+	companyRepo company.CompanyRepository
+	crewService CrewService
+	minioRepo   *repository.Repository
+}
+
+func NewDocumentService(jobRepo *repository.JobRepository, companyRepo company.CompanyRepository, crewService CrewService, minioRepo *repository.Repository) DocumentService {
+	return &documentService{
+		jobRepo:     jobRepo,
+		companyRepo: companyRepo,
+		crewService: crewService,
+		minioRepo:   minioRepo,
+	}
+}
+
+func (s *documentService) getAccessibleJob(ctx context.Context, userID, jobID int64) (*models.Job, error) {
+	job, err := s.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+
+	if job.ContractorID != userID && (job.ExecutorID == nil || *job.ExecutorID != userID) {
+		return nil, fmt.Errorf("access denied: you can only access documents of your own jobs")
+	}
+
+	// Bill of lading требует перевозчика, поэтому работа должна быть взята
+	if job.ExecutorID == nil {
+		return nil, fmt.Errorf("bill of lading is available only after the job is claimed")
+	}
+
+	return job, nil
+}
+
+func (s *documentService) GenerateBillOfLading(ctx context.Context, userID, jobID int64) (*models.JobFile, error) {
+	job, err := s.getAccessibleJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	shipper, err := s.companyRepo.GetCompany(ctx, job.ContractorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipper company: %w", err)
+	}
+
+	carrier, err := s.companyRepo.GetCompany(ctx, *job.ExecutorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get carrier company: %w", err)
+	}
+
+	crew, err := s.crewService.GetJobCrew(ctx, jobID)
+	if err != nil {
+		// Команда не обязательна для документа
+		fmt.Printf("Failed to get crew for bill of lading, job %d: %v\n", jobID, err)
+		crew = nil
+	}
+
+	issuedAt := time.Now()
+	data := &models.BillOfLadingData{
+		Number:   fmt.Sprintf("BOL-%d-%s", job.ID, issuedAt.Format("20060102150405")),
+		IssuedAt: issuedAt,
+		Job:      job,
+		Shipper:  shipper,
+		Carrier:  carrier,
+		Crew:     crew,
+	}
+
+	pdfBytes, err := utils.GenerateBillOfLadingPDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	objectName := fmt.Sprintf("jobs/%d/bill_of_lading/%s.pdf", job.ID, data.Number)
+	if err := s.minioRepo.UploadBytes(ctx, "job-files", objectName, pdfBytes, "application/pdf"); err != nil {
+		return nil, fmt.Errorf("failed to upload bill of lading: %w", err)
+	}
+
+	fileName := data.Number + ".pdf"
+	if err := s.jobRepo.InsertJobFileWithType(ctx, job.ID, objectName, fileName, int64(len(pdfBytes)), "application/pdf", models.JobFileTypeBillOfLading); err != nil {
+		s.minioRepo.DeleteObject(ctx, "job-files", objectName)
+		return nil, fmt.Errorf("failed to save bill of lading record: %w", err)
+	}
+
+	file := &models.JobFile{
+		JobID:       job.ID,
+		FileID:      objectName,
+		FileName:    fileName,
+		FileSize:    int64(len(pdfBytes)),
+		ContentType: "application/pdf",
+		FileType:    models.JobFileTypeBillOfLading,
+		UploadedAt:  issuedAt,
+	}
+
+	fileURL, err := s.minioRepo.GetFileURL(ctx, "job-files", objectName, 24*time.Hour)
+	if err != nil {
+		fmt.Printf("Failed to get URL for file %s: %v\n", objectName, err)
+	} else {
+		file.FileURL = fileURL
+	}
+
+	return file, nil
+}
+
+// GetBillOfLading возвращает последний сгенерированный документ, создавая его при отсутствии
+func (s *documentService) GetBillOfLading(ctx context.Context, userID, jobID int64) (*models.JobFile, io.ReadCloser, error) {
+	if _, err := s.getAccessibleJob(ctx, userID, jobID); err != nil {
+		return nil, nil, err
+	}
+
+	files, err := s.jobRepo.GetJobFilesByType(ctx, jobID, models.JobFileTypeBillOfLading)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get bill of lading: %w", err)
+	}
+
+	var file *models.JobFile
+	if len(files) > 0 {
+		file = &files[0]
+	} else {
+		file, err = s.GenerateBillOfLading(ctx, userID, jobID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stream, err := s.minioRepo.DownloadStream(ctx, "job-files", file.FileID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download bill of lading: %w", err)
+	}
+
+	return file, stream, nil
+}
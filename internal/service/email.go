@@ -10,6 +10,7 @@ import (
 type EmailService interface {
 	SendPasswordResetCode(to, code string) error
 	SendEmail(to, subject, body string) error
+	SendEmailWithAttachment(to, subject, body, fileName string, content []byte) error
 }
 
 type emailService struct {
@@ -146,6 +147,25 @@ func (e *emailService) SendEmail(to, subject, body string) error {
 	return nil
 }
 
+func (e *emailService) SendEmailWithAttachment(to, subject, body, fileName string, content []byte) error {
+	params := &resend.SendEmailRequest{
+		From:    e.from,
+		To:      []string{to},
+		Subject: subject,
+		Html:    body,
+		Attachments: []*resend.Attachment{
+			{Filename: fileName, Content: content},
+		},
+	}
+
+	_, err := e.client.Emails.Send(params)
+	if err != nil {
+		return fmt.Errorf("failed to send email via Resend: %w", err)
+	}
+
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/escrow_capture"
+	"net/http"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+const (
+	// escrowConfirmationWindow - сколько подрядчик может подтвердить или оспорить выполнение
+	// до автоматического подтверждения
+	escrowConfirmationWindow = 72 * time.Hour
+	// escrowCaptureLease - сколько списание занято одним обработчиком
+	escrowCaptureLease = 2 * time.Minute
+	// escrowCaptureRetryBase, escrowCaptureRetryMax - экспоненциальная пауза между попытками списания
+	escrowCaptureRetryBase = time.Minute
+	escrowCaptureRetryMax  = time.Hour
+	// escrowCaptureAlertAttempts - после стольких неудачных попыток администраторы получают уведомление
+	escrowCaptureAlertAttempts = 5
+	// escrowCaptureMaxAttempts - после стольких временных ошибок списание больше не повторяется
+	escrowCaptureMaxAttempts = 10
+	// escrowCaptureBatchSize - сколько списаний обрабатывается за проход
+	escrowCaptureBatchSize = 50
+)
+
+var (
+	ErrEscrowCaptureNotFound = errors.New("job completion not found")
+	ErrEscrowCaptureConflict = errors.New("job completion has already been confirmed, disputed or resolved")
+)
+
+// EscrowCaptureService списывает escrow выполненной работы. Исполнитель отмечает работу выполненной,
+// подрядчик подтверждает выполнение или открывает спор; без ответа выполнение подтверждается
+// автоматически через escrowConfirmationWindow. Подтвержденное списание выполняется сразу,
+// а при временной ошибке повторяется фоновым обработчиком, после списания исполнителю начисляется
+// выплата. Окончательно отклоненное списание ждет решения администратора
+type EscrowCaptureService interface {
+	CompleteJob(ctx context.Context, jobID, executorID int64) (*models.EscrowCapture, error)
+	GetEscrowCapture(ctx context.Context, userID int64, isAdmin bool, jobID int64) (*models.EscrowCapture, error)
+	ConfirmCompletion(ctx context.Context, userID, jobID int64) (*models.EscrowCapture, error)
+	DisputeCompletion(ctx context.Context, userID, jobID int64, req *models.DisputeCompletionRequest) (*models.EscrowCapture, error)
+	ResolveDispute(ctx context.Context, jobID int64, req *models.ResolveCompletionDisputeRequest) (*models.EscrowCapture, error)
+	ProcessDueCaptures(ctx context.Context) (int, error)
+	StartCaptureProcessor(ctx context.Context, interval time.Duration)
+}
+
+type escrowCaptureService struct {
+	repo                escrow_capture.EscrowCaptureRepository
+	paymentService      PaymentService
+	payoutService       PayoutService
+	adminService        AdminService
+	notificationService NotificationService
+}
+
+func NewEscrowCaptureService(repo escrow_capture.EscrowCaptureRepository, paymentService PaymentService, payoutService PayoutService, adminService AdminService, notificationService NotificationService) EscrowCaptureService {
+	return &escrowCaptureService{
+		repo:                repo,
+		paymentService:      paymentService,
+		payoutService:       payoutService,
+		adminService:        adminService,
+		notificationService: notificationService,
+	}
+}
+
+// CompleteJob отмечает работу выполненной; оплата остается заблокированной до подтверждения подрядчиком
+func (s *escrowCaptureService) CompleteJob(ctx context.Context, jobID, executorID int64) (*models.EscrowCapture, error) {
+	return s.repo.CompleteJob(ctx, jobID, executorID, time.Now().Add(escrowConfirmationWindow))
+}
+
+func (s *escrowCaptureService) GetEscrowCapture(ctx context.Context, userID int64, isAdmin bool, jobID int64) (*models.EscrowCapture, error) {
+	capture, err := s.repo.GetEscrowCapture(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job completion: %w", err)
+	}
+	if capture == nil || (!isAdmin && capture.ContractorID != userID && capture.ExecutorID != userID) {
+		return nil, ErrEscrowCaptureNotFound
+	}
+	return capture, nil
+}
+
+// ConfirmCompletion - подрядчик подтверждает выполнение (в том числе снимает свой спор),
+// оплата списывается сразу. Ошибка списания не возвращается: его повторит фоновый обработчик
+func (s *escrowCaptureService) ConfirmCompletion(ctx context.Context, userID, jobID int64) (*models.EscrowCapture, error) {
+	capture, err := s.GetEscrowCapture(ctx, userID, false, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if capture.ContractorID != userID {
+		return nil, ErrEscrowCaptureNotFound
+	}
+	if capture.Status == models.EscrowCaptureFailed {
+		return nil, ErrEscrowCaptureConflict
+	}
+
+	return s.confirm(ctx, capture)
+}
+
+// DisputeCompletion - подрядчик оспаривает выполнение до автоматического подтверждения.
+// Списание останавливается, пока спор не решит администратор
+func (s *escrowCaptureService) DisputeCompletion(ctx context.Context, userID, jobID int64, req *models.DisputeCompletionRequest) (*models.EscrowCapture, error) {
+	capture, err := s.GetEscrowCapture(ctx, userID, false, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if capture.ContractorID != userID {
+		return nil, ErrEscrowCaptureNotFound
+	}
+
+	disputed, err := s.repo.DisputeEscrowCapture(ctx, jobID, req.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dispute job completion: %w", err)
+	}
+	if disputed == nil {
+		return nil, ErrEscrowCaptureConflict
+	}
+
+	s.notificationService.NotifyJobUpdate(disputed.ExecutorID, jobID, models.EscrowCaptureDisputed, "The customer has disputed the completion of your job")
+	s.alertAdmins(ctx, fmt.Sprintf("Completion of job %d was disputed: %s", jobID, req.Reason), "warning")
+
+	return disputed, nil
+}
+
+// ResolveDispute - решение администратора по спору или неудавшемуся списанию: списать оплату
+// исполнителю (повторить списание) или снять блокировку с карты подрядчика
+func (s *escrowCaptureService) ResolveDispute(ctx context.Context, jobID int64, req *models.ResolveCompletionDisputeRequest) (*models.EscrowCapture, error) {
+	capture, err := s.GetEscrowCapture(ctx, 0, true, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if capture.Status != models.EscrowCaptureDisputed && capture.Status != models.EscrowCaptureFailed {
+		return nil, ErrEscrowCaptureConflict
+	}
+
+	if req.Resolution == "capture" {
+		return s.confirm(ctx, capture)
+	}
+
+	if _, err := s.paymentService.ReleaseJobPayment(ctx, jobID); err != nil {
+		return nil, fmt.Errorf("failed to release job payment: %w", err)
+	}
+	capture.Status = models.EscrowCaptureReleased
+	capture.LastError = ""
+	if err := s.repo.UpdateEscrowCapture(ctx, capture); err != nil {
+		return nil, fmt.Errorf("failed to save job completion: %w", err)
+	}
+
+	s.notificationService.NotifyJobUpdate(capture.ContractorID, jobID, capture.Status, "Your dispute was resolved: the job payment has been released")
+	s.notificationService.NotifyJobUpdate(capture.ExecutorID, jobID, capture.Status, "The dispute was resolved in the customer's favor: the job payment has been released")
+
+	return capture, nil
+}
+
+// confirm ставит списание в очередь и сразу пытается его выполнить
+func (s *escrowCaptureService) confirm(ctx context.Context, capture *models.EscrowCapture) (*models.EscrowCapture, error) {
+	if capture.Status != models.EscrowCaptureAwaitingConfirmation && capture.Status != models.EscrowCaptureDisputed &&
+		capture.Status != models.EscrowCaptureFailed {
+		return nil, ErrEscrowCaptureConflict
+	}
+
+	// Обработчик подхватит запись после lease, если запрос не дойдет до конца
+	confirmed, err := s.repo.ConfirmEscrowCapture(ctx, capture.JobID, capture.Status, escrowCaptureLease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm job completion: %w", err)
+	}
+	if confirmed == nil {
+		return nil, ErrEscrowCaptureConflict
+	}
+
+	s.capture(ctx, confirmed)
+	return confirmed, nil
+}
+
+func (s *escrowCaptureService) ProcessDueCaptures(ctx context.Context) (int, error) {
+	captures, err := s.repo.ClaimDueEscrowCaptures(ctx, escrowCaptureLease, escrowCaptureBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim escrow captures: %w", err)
+	}
+
+	captured := 0
+	for i := range captures {
+		capture := &captures[i]
+		if capture.AutoConfirmed && capture.Attempts == 0 {
+			s.notificationService.NotifyJobUpdate(capture.ContractorID, capture.JobID, models.EscrowCapturePending, "Job completion was confirmed automatically")
+		}
+
+		s.capture(ctx, capture)
+		if capture.Status == models.EscrowCaptureCaptured {
+			captured++
+		}
+	}
+
+	return captured, nil
+}
+
+func (s *escrowCaptureService) StartCaptureProcessor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		captured, err := s.ProcessDueCaptures(ctx)
+		if err != nil {
+			fmt.Printf("Escrow capture processor error: %v\n", err)
+		} else if captured > 0 {
+			fmt.Printf("Escrow capture processor: captured %d job payments\n", captured)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// capture списывает оплату и начисляет выплату исполнителю. Оба шага идемпотентны,
+// поэтому при временной ошибке списание просто повторяется позже
+func (s *escrowCaptureService) capture(ctx context.Context, capture *models.EscrowCapture) {
+	payment, err := s.paymentService.CaptureJobPayment(ctx, capture.JobID)
+	if err == nil {
+		_, err = s.payoutService.RecordJobPayout(ctx, capture.JobID, capture.ExecutorID, payment)
+	}
+	if err != nil {
+		s.retry(ctx, capture, err)
+		return
+	}
+
+	now := time.Now()
+	capture.Status = models.EscrowCaptureCaptured
+	capture.CapturedAt = &now
+	capture.LastError = ""
+	s.save(ctx, capture)
+
+	s.notificationService.NotifyJobUpdate(capture.ExecutorID, capture.JobID, capture.Status, "Job completion was confirmed and your payout has been recorded")
+}
+
+// retry откладывает списание с экспоненциальной паузой; о затянувшихся ошибках узнают администраторы.
+// Окончательная ошибка или исчерпанные попытки останавливают списание до решения администратора
+func (s *escrowCaptureService) retry(ctx context.Context, capture *models.EscrowCapture, cause error) {
+	capture.Attempts++
+	capture.LastError = cause.Error()
+
+	if isPermanentCaptureError(cause) || capture.Attempts >= escrowCaptureMaxAttempts {
+		capture.Status = models.EscrowCaptureFailed
+		s.save(ctx, capture)
+		fmt.Printf("Escrow capture for job %d failed after %d attempts: %v\n", capture.JobID, capture.Attempts, cause)
+
+		s.alertAdmins(ctx, fmt.Sprintf("Escrow capture for job %d failed and needs attention: %v", capture.JobID, cause), "error")
+		return
+	}
+
+	capture.NextAttemptAt = time.Now().Add(escrowCaptureBackoff(capture.Attempts))
+	s.save(ctx, capture)
+	fmt.Printf("Escrow capture for job %d: attempt %d failed, retrying at %s: %v\n", capture.JobID, capture.Attempts, capture.NextAttemptAt.Format(time.RFC3339), cause)
+
+	if capture.Attempts == escrowCaptureAlertAttempts {
+		s.alertAdmins(ctx, fmt.Sprintf("Escrow capture for job %d keeps failing: %v", capture.JobID, cause), "error")
+	}
+}
+
+// save сохраняет ход списания. Если сохранить не удалось, запись обработается заново после lease
+func (s *escrowCaptureService) save(ctx context.Context, capture *models.EscrowCapture) {
+	if err := s.repo.UpdateEscrowCapture(ctx, capture); err != nil {
+		fmt.Printf("Failed to save escrow capture for job %d: %v\n", capture.JobID, err)
+	}
+}
+
+func (s *escrowCaptureService) alertAdmins(ctx context.Context, message, level string) {
+	adminIDs, err := s.adminService.GetAdminUserIDs(ctx)
+	if err != nil {
+		fmt.Printf("Failed to get admins for escrow capture alert: %v\n", err)
+		return
+	}
+
+	for _, adminID := range adminIDs {
+		s.notificationService.NotifySystemMessage(adminID, message, level)
+	}
+}
+
+// isPermanentCaptureError - ошибки, которые повтор не исправит: авторизация уже не действует,
+// карта отклонена или Stripe отклонил запрос. Сбои сети, БД, лимиты запросов и ошибки Stripe API временные
+func isPermanentCaptureError(err error) bool {
+	if errors.Is(err, ErrPaymentNotCapturable) {
+		return true
+	}
+
+	var stripeErr *stripe.Error
+	if !errors.As(err, &stripeErr) {
+		return false
+	}
+	switch stripeErr.Type {
+	case stripe.ErrorTypeCard:
+		return true
+	case stripe.ErrorTypeInvalidRequest:
+		return stripeErr.HTTPStatusCode != http.StatusTooManyRequests &&
+			stripeErr.Code != stripe.ErrorCodeLockTimeout && stripeErr.Code != stripe.ErrorCodeRateLimit
+	}
+	return false
+}
+
+func escrowCaptureBackoff(attempts int) time.Duration {
+	delay := escrowCaptureRetryBase
+	for i := 1; i < attempts && delay < escrowCaptureRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, escrowCaptureRetryMax)
+}
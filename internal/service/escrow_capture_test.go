@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+const (
+	testContractorID = int64(1)
+	testExecutorID   = int64(2)
+	testAdminID      = int64(99)
+	testJobID        = int64(10)
+)
+
+var (
+	errTestCardDeclined = fmt.Errorf("failed to capture payment: %w",
+		fakeCardError(stripe.ErrorCodeCardDeclined, stripe.DeclineCodeGenericDecline, "Your card was declined."))
+	errTestRateLimited = fmt.Errorf("failed to capture payment: %w", &stripe.Error{
+		Type:           stripe.ErrorTypeInvalidRequest,
+		Code:           stripe.ErrorCodeRateLimit,
+		HTTPStatusCode: http.StatusTooManyRequests,
+	})
+)
+
+type escrowCaptureTestEnv struct {
+	captures      *memEscrowCaptureRepo
+	payments      *stubPaymentService
+	payouts       *stubPayoutService
+	notifications *stubNotificationService
+	service       EscrowCaptureService
+}
+
+// newEscrowCaptureTestEnv - работа с заблокированной оплатой, которую исполнитель отметил выполненной
+func newEscrowCaptureTestEnv(t *testing.T) *escrowCaptureTestEnv {
+	t.Helper()
+
+	env := &escrowCaptureTestEnv{
+		captures:      newMemEscrowCaptureRepo(),
+		payments:      &stubPaymentService{},
+		payouts:       &stubPayoutService{},
+		notifications: &stubNotificationService{},
+	}
+	env.service = NewEscrowCaptureService(env.captures, env.payments, env.payouts,
+		&stubAdminService{adminIDs: []int64{testAdminID}}, env.notifications)
+
+	env.captures.contractors[testJobID] = testContractorID
+	if _, err := env.service.CompleteJob(context.Background(), testJobID, testExecutorID); err != nil {
+		t.Fatalf("CompleteJob: %v", err)
+	}
+	return env
+}
+
+// makeDue переносит срок подтверждения и следующей попытки в прошлое
+func (e *escrowCaptureTestEnv) makeDue() {
+	c := e.captures.captures[testJobID]
+	c.ConfirmBy = time.Now().Add(-time.Minute)
+	c.NextAttemptAt = c.ConfirmBy
+}
+
+func TestEscrowCaptureLifecycle(t *testing.T) {
+	dispute := &models.DisputeCompletionRequest{Reason: "Two boxes were damaged"}
+
+	tests := []struct {
+		name           string
+		act            func(ctx context.Context, env *escrowCaptureTestEnv) error
+		wantErr        error
+		wantStatus     string
+		wantCaptured   bool
+		wantReleased   bool
+		wantAuto       bool
+		wantAlerts     int
+		wantNotifiedTo []int64
+	}{
+		{
+			name: "contractor confirms",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				_, err := env.service.ConfirmCompletion(ctx, testContractorID, testJobID)
+				return err
+			},
+			wantStatus:     models.EscrowCaptureCaptured,
+			wantCaptured:   true,
+			wantNotifiedTo: []int64{testExecutorID},
+		},
+		{
+			name: "executor cannot confirm",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				_, err := env.service.ConfirmCompletion(ctx, testExecutorID, testJobID)
+				return err
+			},
+			wantErr:    ErrEscrowCaptureNotFound,
+			wantStatus: models.EscrowCaptureAwaitingConfirmation,
+		},
+		{
+			name: "not captured before the confirmation deadline",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				_, err := env.service.ProcessDueCaptures(ctx)
+				return err
+			},
+			wantStatus: models.EscrowCaptureAwaitingConfirmation,
+		},
+		{
+			name: "auto-confirmed after the deadline",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				env.makeDue()
+				_, err := env.service.ProcessDueCaptures(ctx)
+				return err
+			},
+			wantStatus:     models.EscrowCaptureCaptured,
+			wantCaptured:   true,
+			wantAuto:       true,
+			wantNotifiedTo: []int64{testContractorID, testExecutorID},
+		},
+		{
+			name: "disputed completion is not auto-confirmed",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				if _, err := env.service.DisputeCompletion(ctx, testContractorID, testJobID, dispute); err != nil {
+					return err
+				}
+				env.makeDue()
+				_, err := env.service.ProcessDueCaptures(ctx)
+				return err
+			},
+			wantStatus:     models.EscrowCaptureDisputed,
+			wantAlerts:     1,
+			wantNotifiedTo: []int64{testExecutorID},
+		},
+		{
+			name: "dispute after confirmation",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				if _, err := env.service.ConfirmCompletion(ctx, testContractorID, testJobID); err != nil {
+					return err
+				}
+				_, err := env.service.DisputeCompletion(ctx, testContractorID, testJobID, dispute)
+				return err
+			},
+			wantErr:        ErrEscrowCaptureConflict,
+			wantStatus:     models.EscrowCaptureCaptured,
+			wantCaptured:   true,
+			wantNotifiedTo: []int64{testExecutorID},
+		},
+		{
+			name: "dispute resolved with capture",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				if _, err := env.service.DisputeCompletion(ctx, testContractorID, testJobID, dispute); err != nil {
+					return err
+				}
+				_, err := env.service.ResolveDispute(ctx, testJobID, &models.ResolveCompletionDisputeRequest{Resolution: "capture"})
+				return err
+			},
+			wantStatus:     models.EscrowCaptureCaptured,
+			wantCaptured:   true,
+			wantAlerts:     1,
+			wantNotifiedTo: []int64{testExecutorID, testExecutorID},
+		},
+		{
+			name: "dispute resolved with release",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				if _, err := env.service.DisputeCompletion(ctx, testContractorID, testJobID, dispute); err != nil {
+					return err
+				}
+				_, err := env.service.ResolveDispute(ctx, testJobID, &models.ResolveCompletionDisputeRequest{Resolution: "release"})
+				return err
+			},
+			wantStatus:     models.EscrowCaptureReleased,
+			wantReleased:   true,
+			wantAlerts:     1,
+			wantNotifiedTo: []int64{testExecutorID, testContractorID, testExecutorID},
+		},
+		{
+			name: "contractor cannot retry a failed capture",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				env.payments.captureErrs = []error{errTestCardDeclined}
+				if _, err := env.service.ConfirmCompletion(ctx, testContractorID, testJobID); err != nil {
+					return err
+				}
+				_, err := env.service.ConfirmCompletion(ctx, testContractorID, testJobID)
+				return err
+			},
+			wantErr:    ErrEscrowCaptureConflict,
+			wantStatus: models.EscrowCaptureFailed,
+			wantAlerts: 1,
+		},
+		{
+			name: "admin retries a failed capture",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				env.payments.captureErrs = []error{errTestCardDeclined}
+				if _, err := env.service.ConfirmCompletion(ctx, testContractorID, testJobID); err != nil {
+					return err
+				}
+				_, err := env.service.ResolveDispute(ctx, testJobID, &models.ResolveCompletionDisputeRequest{Resolution: "capture"})
+				return err
+			},
+			wantStatus:     models.EscrowCaptureCaptured,
+			wantCaptured:   true,
+			wantAlerts:     1,
+			wantNotifiedTo: []int64{testExecutorID},
+		},
+		{
+			name: "resolving an undisputed completion",
+			act: func(ctx context.Context, env *escrowCaptureTestEnv) error {
+				_, err := env.service.ResolveDispute(ctx, testJobID, &models.ResolveCompletionDisputeRequest{Resolution: "release"})
+				return err
+			},
+			wantErr:    ErrEscrowCaptureConflict,
+			wantStatus: models.EscrowCaptureAwaitingConfirmation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newEscrowCaptureTestEnv(t)
+
+			if err := tt.act(ctx, env); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			c := env.captures.captures[testJobID]
+			if c.Status != tt.wantStatus || c.AutoConfirmed != tt.wantAuto {
+				t.Errorf("completion = %s (auto %v), want %s (auto %v)", c.Status, c.AutoConfirmed, tt.wantStatus, tt.wantAuto)
+			}
+			if (c.CapturedAt != nil) != (tt.wantStatus == models.EscrowCaptureCaptured) {
+				t.Errorf("captured at = %v", c.CapturedAt)
+			}
+			if captured := slices.Contains(env.payments.captured, testJobID); captured != tt.wantCaptured {
+				t.Errorf("payment captured = %v, want %v", captured, tt.wantCaptured)
+			}
+			if payout := slices.Contains(env.payouts.recorded, testJobID); payout != tt.wantCaptured {
+				t.Errorf("payout recorded = %v, want %v", payout, tt.wantCaptured)
+			}
+			if released := slices.Contains(env.payments.released, testJobID); released != tt.wantReleased {
+				t.Errorf("payment released = %v, want %v", released, tt.wantReleased)
+			}
+			if len(env.notifications.systemMessages) != tt.wantAlerts {
+				t.Errorf("admin alerts = %v, want %d", env.notifications.systemMessages, tt.wantAlerts)
+			}
+			if !slices.Equal(env.notifications.jobUpdates, tt.wantNotifiedTo) {
+				t.Errorf("notified %v, want %v", env.notifications.jobUpdates, tt.wantNotifiedTo)
+			}
+		})
+	}
+}
+
+func TestEscrowCaptureRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		captureErrs  []error
+		payoutErr    error
+		runs         int
+		wantStatus   string
+		wantAttempts int
+		wantAlerts   int
+	}{
+		{
+			name:         "temporary capture failure is retried",
+			captureErrs:  []error{errTestDB},
+			runs:         1,
+			wantStatus:   models.EscrowCaptureCaptured,
+			wantAttempts: 1,
+		},
+		{
+			name:         "rate-limited capture is retried",
+			captureErrs:  []error{errTestRateLimited},
+			runs:         1,
+			wantStatus:   models.EscrowCaptureCaptured,
+			wantAttempts: 1,
+		},
+		{
+			name:         "payout failure is retried",
+			payoutErr:    errTestDB,
+			runs:         1,
+			wantStatus:   models.EscrowCaptureCaptured,
+			wantAttempts: 1,
+		},
+		{
+			name:         "repeated capture failures alert admins",
+			captureErrs:  slices.Repeat([]error{errTestDB}, escrowCaptureAlertAttempts),
+			runs:         escrowCaptureAlertAttempts,
+			wantStatus:   models.EscrowCapturePending,
+			wantAttempts: escrowCaptureAlertAttempts,
+			wantAlerts:   1,
+		},
+		{
+			name:         "retries stop after the attempt limit",
+			captureErrs:  slices.Repeat([]error{errTestDB}, escrowCaptureMaxAttempts),
+			runs:         escrowCaptureMaxAttempts,
+			wantStatus:   models.EscrowCaptureFailed,
+			wantAttempts: escrowCaptureMaxAttempts,
+			wantAlerts:   2,
+		},
+		{
+			name:         "declined capture is not retried",
+			captureErrs:  []error{errTestCardDeclined},
+			runs:         1,
+			wantStatus:   models.EscrowCaptureFailed,
+			wantAttempts: 1,
+			wantAlerts:   1,
+		},
+		{
+			name:         "lapsed authorization is not retried",
+			captureErrs:  []error{fmt.Errorf("%w: payment 1 authorization is expired", ErrPaymentNotCapturable)},
+			runs:         1,
+			wantStatus:   models.EscrowCaptureFailed,
+			wantAttempts: 1,
+			wantAlerts:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newEscrowCaptureTestEnv(t)
+			env.payments.captureErrs = tt.captureErrs
+			env.payouts.err = tt.payoutErr
+
+			// Ошибка первой попытки не возвращается подрядчику
+			confirmed, err := env.service.ConfirmCompletion(ctx, testContractorID, testJobID)
+			if err != nil {
+				t.Fatalf("ConfirmCompletion: %v", err)
+			}
+			if confirmed.Attempts != 1 || confirmed.LastError == "" {
+				t.Fatalf("after failed attempt: %s, %d attempts, error %q", confirmed.Status, confirmed.Attempts, confirmed.LastError)
+			}
+			if confirmed.Status == models.EscrowCapturePending {
+				if wait := time.Until(confirmed.NextAttemptAt); wait <= 0 || wait > escrowCaptureRetryBase {
+					t.Errorf("next attempt in %s, want within %s", wait, escrowCaptureRetryBase)
+				}
+			}
+
+			// До следующей попытки обработчик запись не трогает
+			if captured, err := env.service.ProcessDueCaptures(ctx); captured != 0 || err != nil {
+				t.Fatalf("ProcessDueCaptures before retry = %d, %v", captured, err)
+			}
+
+			for i := 1; i < tt.runs; i++ {
+				env.makeDue()
+				if _, err := env.service.ProcessDueCaptures(ctx); err != nil {
+					t.Fatalf("ProcessDueCaptures: %v", err)
+				}
+			}
+			if tt.wantStatus == models.EscrowCaptureCaptured {
+				env.makeDue()
+				if captured, err := env.service.ProcessDueCaptures(ctx); captured != 1 || err != nil {
+					t.Fatalf("ProcessDueCaptures = %d, %v; want 1", captured, err)
+				}
+			}
+
+			c := env.captures.captures[testJobID]
+			if c.Status != tt.wantStatus || c.Attempts != tt.wantAttempts {
+				t.Errorf("completion = %s after %d attempts, want %s after %d", c.Status, c.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if (c.LastError == "") != (tt.wantStatus == models.EscrowCaptureCaptured) {
+				t.Errorf("last error = %q", c.LastError)
+			}
+			if paid := len(env.payouts.recorded) == 1; paid != (tt.wantStatus == models.EscrowCaptureCaptured) {
+				t.Errorf("recorded payouts = %v", env.payouts.recorded)
+			}
+			if len(env.notifications.systemMessages) != tt.wantAlerts {
+				t.Errorf("admin alerts = %v, want %d", env.notifications.systemMessages, tt.wantAlerts)
+			}
+		})
+	}
+}
+
+func TestEscrowCaptureBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 4, want: 8 * time.Minute},
+		{attempts: 7, want: time.Hour},
+		{attempts: 30, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := escrowCaptureBackoff(tt.attempts); got != tt.want {
+			t.Errorf("escrowCaptureBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,1365 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+	"github.com/stripe/stripe-go/v82/webhook"
+)
+
+// Тестовые карты фейкового шлюза (совпадают с тестовыми PaymentMethod Stripe).
+// Любой другой ID ведет себя как FakeCardSuccess
+const (
+	FakeCardSuccess                = "pm_card_visa"
+	FakeCardMastercard             = "pm_card_mastercard"
+	FakeCardDeclined               = "pm_card_chargeDeclined"
+	FakeCardInsufficientFunds      = "pm_card_chargeDeclinedInsufficientFunds"
+	FakeCardAuthenticationRequired = "pm_card_authenticationRequired"
+)
+
+// Тестовые банковские счета (ConfirmSetupIntent). Списание с них остается в processing до SettlePaymentIntent
+const (
+	FakeBankAccountSuccess           = "pm_usBankAccount_success"
+	FakeBankAccountInsufficientFunds = "pm_usBankAccount_insufficientFunds"
+	FakeBankAccountMicrodeposits     = "pm_usBankAccount_microdeposits"
+)
+
+// fakeWebhookSecret - секрет подписи событий, если STRIPE_WEBHOOK_SECRET не задан
+const fakeWebhookSecret = "whsec_fake"
+
+// fakeSubscriptionRetries - сколько попыток оплатить счет подписки до ее отмены
+const fakeSubscriptionRetries = 4
+
+type fakeCardOutcome int
+
+const (
+	fakeOutcomeSuccess fakeCardOutcome = iota
+	fakeOutcomeDecline
+	fakeOutcomeInsufficientFunds
+	fakeOutcomeAuthenticationRequired
+)
+
+type fakeCard struct {
+	brand   stripe.PaymentMethodCardBrand
+	last4   string
+	outcome fakeCardOutcome
+}
+
+var fakeCards = map[string]fakeCard{
+	FakeCardSuccess:                {brand: stripe.PaymentMethodCardBrandVisa, last4: "4242", outcome: fakeOutcomeSuccess},
+	FakeCardMastercard:             {brand: stripe.PaymentMethodCardBrandMastercard, last4: "4444", outcome: fakeOutcomeSuccess},
+	FakeCardDeclined:               {brand: stripe.PaymentMethodCardBrandVisa, last4: "0002", outcome: fakeOutcomeDecline},
+	FakeCardInsufficientFunds:      {brand: stripe.PaymentMethodCardBrandVisa, last4: "9995", outcome: fakeOutcomeInsufficientFunds},
+	FakeCardAuthenticationRequired: {brand: stripe.PaymentMethodCardBrandVisa, last4: "3184", outcome: fakeOutcomeAuthenticationRequired},
+}
+
+type fakeBankAccount struct {
+	bankName      string
+	last4         string
+	microdeposits bool
+	outcome       fakeCardOutcome
+}
+
+var fakeBankAccounts = map[string]fakeBankAccount{
+	FakeBankAccountSuccess:           {bankName: "STRIPE TEST BANK", last4: "6789", outcome: fakeOutcomeSuccess},
+	FakeBankAccountInsufficientFunds: {bankName: "STRIPE TEST BANK", last4: "2227", outcome: fakeOutcomeInsufficientFunds},
+	FakeBankAccountMicrodeposits:     {bankName: "STRIPE TEST BANK", last4: "6789", microdeposits: true, outcome: fakeOutcomeSuccess},
+}
+
+// WebhookHandler принимает подписанное webhook-событие (PaymentService.HandleWebhook)
+type WebhookHandler func(ctx context.Context, payload []byte, signature string) error
+
+// FakePaymentGateway - детерминированный PaymentGateway в памяти для локального запуска и тестов.
+// Исход платежа определяется тестовой картой, события копятся в очереди и доставляются в WebhookHandler
+type FakePaymentGateway interface {
+	PaymentGateway
+
+	// CompleteAuthentication имитирует прохождение (или провал) 3-D Secure клиентом
+	CompleteAuthentication(ctx context.Context, paymentIntentID string, approve bool) (*stripe.PaymentIntent, error)
+	// ConfirmSetupIntent имитирует привязку банковского счета клиентом в Stripe.js
+	ConfirmSetupIntent(ctx context.Context, setupIntentID, paymentMethodID string) (*stripe.SetupIntent, error)
+	// VerifyMicrodeposits имитирует ввод клиентом сумм микродепозитов (approve = false - неверные суммы)
+	VerifyMicrodeposits(ctx context.Context, setupIntentID string, approve bool) (*stripe.SetupIntent, error)
+	// SettlePaymentIntent завершает расчет списания с банковского счета
+	SettlePaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
+	// SetPrice заводит месячную цену для подписок
+	SetPrice(priceID string, amountCents int64)
+	// RenewSubscription имитирует окончание расчетного периода: новый период и счет за него.
+	// Для просроченной подписки - повторная попытка оплатить открытый счет (dunning)
+	RenewSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+
+	SetWebhookHandler(handler WebhookHandler)
+	DeliverWebhooks(ctx context.Context) (int, error)
+	StartWebhookDelivery(ctx context.Context, interval time.Duration)
+}
+
+type fakePaymentGateway struct {
+	mu            sync.Mutex
+	webhookSecret string
+	sequence      map[string]int
+
+	customers      map[string]*stripe.Customer
+	paymentMethods map[string]*stripe.PaymentMethod
+	outcomes       map[string]fakeCardOutcome
+	intents        map[string]*stripe.PaymentIntent
+	setupIntents   map[string]*stripe.SetupIntent
+	prices         map[string]int64
+	subscriptions  map[string]*stripe.Subscription
+	refunds        map[string]*stripe.Refund
+	accounts       map[string]*stripe.Account
+	transfers      map[string]*stripe.Transfer
+
+	// PaymentIntent счета подписки -> ID счета
+	invoiceIntents map[string]string
+
+	// Ключ идемпотентности -> ID созданного PaymentIntent, возврата или перевода
+	idempotencyKeys map[string]string
+
+	// Неотправленные webhook-события в порядке возникновения
+	pending [][]byte
+	handler WebhookHandler
+}
+
+func NewFakePaymentGateway(webhookSecret string) FakePaymentGateway {
+	if webhookSecret == "" {
+		webhookSecret = fakeWebhookSecret
+	}
+
+	return &fakePaymentGateway{
+		webhookSecret:  webhookSecret,
+		sequence:       make(map[string]int),
+		customers:      make(map[string]*stripe.Customer),
+		paymentMethods: make(map[string]*stripe.PaymentMethod),
+		outcomes:       make(map[string]fakeCardOutcome),
+		intents:        make(map[string]*stripe.PaymentIntent),
+		setupIntents:   make(map[string]*stripe.SetupIntent),
+		prices:         make(map[string]int64),
+		subscriptions:  make(map[string]*stripe.Subscription),
+		refunds:        make(map[string]*stripe.Refund),
+		accounts:       make(map[string]*stripe.Account),
+		transfers:      make(map[string]*stripe.Transfer),
+		invoiceIntents: make(map[string]string),
+
+		idempotencyKeys: make(map[string]string),
+	}
+}
+
+// Customer management
+func (g *fakePaymentGateway) CreateCustomer(ctx context.Context, userID int64, email, name string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c := &stripe.Customer{
+		ID:              g.newID("cus"),
+		Object:          "customer",
+		Email:           email,
+		Name:            name,
+		Created:         time.Now().Unix(),
+		InvoiceSettings: &stripe.CustomerInvoiceSettings{},
+		Metadata: map[string]string{
+			"user_id": fmt.Sprintf("%d", userID),
+		},
+	}
+	g.customers[c.ID] = c
+
+	return c.ID, nil
+}
+
+func (g *fakePaymentGateway) GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, ok := g.customers[customerID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get Stripe customer: %w", fakeNotFound("customer", customerID))
+	}
+
+	copied := *c
+	return &copied, nil
+}
+
+// Payment Methods
+func (g *fakePaymentGateway) CreateSetupIntent(ctx context.Context, customerID, paymentMethodType string) (*stripe.SetupIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.customers[customerID]; !ok {
+		return nil, fmt.Errorf("failed to create setup intent: %w", fakeNotFound("customer", customerID))
+	}
+
+	id := g.newID("seti")
+	si := &stripe.SetupIntent{
+		ID:                 id,
+		Object:             "setup_intent",
+		ClientSecret:       id + "_secret_fake",
+		Customer:           &stripe.Customer{ID: customerID},
+		PaymentMethodTypes: []string{paymentMethodType},
+		Status:             stripe.SetupIntentStatusRequiresPaymentMethod,
+		Usage:              stripe.SetupIntentUsageOffSession,
+		Created:            time.Now().Unix(),
+	}
+	g.setupIntents[si.ID] = si
+
+	copied := *si
+	return &copied, nil
+}
+
+// GetSetupIntent возвращает SetupIntent с развернутым PaymentMethod, как stripeService
+func (g *fakePaymentGateway) GetSetupIntent(ctx context.Context, setupIntentID string) (*stripe.SetupIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	si, ok := g.setupIntents[setupIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get setup intent: %w", fakeNotFound("setup_intent", setupIntentID))
+	}
+
+	copied := *si
+	if si.PaymentMethod != nil {
+		if pm, ok := g.paymentMethods[si.PaymentMethod.ID]; ok {
+			pmCopy := *pm
+			copied.PaymentMethod = &pmCopy
+		}
+	}
+	return &copied, nil
+}
+
+// AttachPaymentMethod привязывает карту к клиенту. Как и в Stripe, тестовая карта (pm_card_*)
+// при привязке превращается в новый PaymentMethod
+func (g *fakePaymentGateway) AttachPaymentMethod(ctx context.Context, paymentMethodID, customerID string) (*stripe.PaymentMethod, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.customers[customerID]; !ok {
+		return nil, fmt.Errorf("failed to attach payment method: %w", fakeNotFound("customer", customerID))
+	}
+
+	pm, ok := g.paymentMethods[paymentMethodID]
+	if ok {
+		if pm.Customer != nil && pm.Customer.ID != customerID {
+			return nil, fmt.Errorf("failed to attach payment method: %w",
+				fakeInvalidRequest("", "The payment method you provided has already been attached to a customer."))
+		}
+		pm.Customer = &stripe.Customer{ID: customerID}
+		copied := *pm
+		return &copied, nil
+	}
+
+	card, ok := fakeCards[paymentMethodID]
+	if !ok {
+		card = fakeCards[FakeCardSuccess]
+	}
+
+	pm = &stripe.PaymentMethod{
+		ID:     g.newID("pm"),
+		Object: "payment_method",
+		Type:   stripe.PaymentMethodTypeCard,
+		Card: &stripe.PaymentMethodCard{
+			Brand:    card.brand,
+			Last4:    card.last4,
+			ExpMonth: 12,
+			ExpYear:  int64(time.Now().Year() + 3),
+			Funding:  stripe.CardFundingCredit,
+		},
+		Customer: &stripe.Customer{ID: customerID},
+		Created:  time.Now().Unix(),
+	}
+	g.paymentMethods[pm.ID] = pm
+	g.outcomes[pm.ID] = card.outcome
+
+	copied := *pm
+	return &copied, nil
+}
+
+func (g *fakePaymentGateway) DetachPaymentMethod(ctx context.Context, paymentMethodID string) (*stripe.PaymentMethod, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pm, ok := g.paymentMethods[paymentMethodID]
+	if !ok {
+		return nil, fmt.Errorf("failed to detach payment method: %w", fakeNotFound("payment_method", paymentMethodID))
+	}
+	if pm.Customer == nil {
+		return nil, fmt.Errorf("failed to detach payment method: %w",
+			fakeInvalidRequest("", "The payment method you provided is not attached to a customer so detachment is impossible."))
+	}
+
+	pm.Customer = nil
+	g.emit("payment_method.detached", pm)
+
+	copied := *pm
+	return &copied, nil
+}
+
+func (g *fakePaymentGateway) ListPaymentMethods(ctx context.Context, customerID string) ([]*stripe.PaymentMethod, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var paymentMethods []*stripe.PaymentMethod
+	for _, pm := range g.paymentMethods {
+		if pm.Customer != nil && pm.Customer.ID == customerID {
+			copied := *pm
+			paymentMethods = append(paymentMethods, &copied)
+		}
+	}
+
+	sort.Slice(paymentMethods, func(i, j int) bool {
+		return paymentMethods[i].ID < paymentMethods[j].ID
+	})
+
+	return paymentMethods, nil
+}
+
+func (g *fakePaymentGateway) SetDefaultPaymentMethod(ctx context.Context, customerID, paymentMethodID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, ok := g.customers[customerID]
+	if !ok {
+		return fmt.Errorf("failed to set default payment method: %w", fakeNotFound("customer", customerID))
+	}
+	pm, ok := g.paymentMethods[paymentMethodID]
+	if !ok || pm.Customer == nil || pm.Customer.ID != customerID {
+		return fmt.Errorf("failed to set default payment method: %w", fakeNotFound("payment_method", paymentMethodID))
+	}
+
+	c.InvoiceSettings = &stripe.CustomerInvoiceSettings{DefaultPaymentMethod: &stripe.PaymentMethod{ID: paymentMethodID}}
+
+	return nil
+}
+
+// Payments
+func (g *fakePaymentGateway) CreatePaymentIntent(ctx context.Context, amount int64, currency, customerID string, method PaymentMethodRef, description, idempotencyKey string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Как в Stripe: повтор с тем же ключом возвращает созданный PaymentIntent, другие параметры - ошибка
+	if existingID, ok := g.idempotencyKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		existing := g.intents[existingID]
+		if existing.Amount != amount || existing.Customer.ID != customerID || existing.PaymentMethod.ID != method.ID {
+			return nil, fmt.Errorf("failed to create payment intent: %w", &stripe.Error{
+				Type:           stripe.ErrorTypeIdempotency,
+				Msg:            "Keys for idempotent requests can only be used with the same parameters they were first used with.",
+				HTTPStatusCode: http.StatusBadRequest,
+			})
+		}
+		return copyPaymentIntent(existing), nil
+	}
+
+	pi, err := g.newPaymentIntent(amount, currency, customerID, method, description, stripe.PaymentIntentCaptureMethodAutomatic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+	pi.ConfirmationMethod = stripe.PaymentIntentConfirmationMethodManual
+	if idempotencyKey != "" {
+		g.idempotencyKeys[idempotencyKey] = pi.ID
+	}
+
+	return copyPaymentIntent(pi), nil
+}
+
+func (g *fakePaymentGateway) ConfirmPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, ok := g.intents[paymentIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to confirm payment intent: %w", fakeNotFound("payment_intent", paymentIntentID))
+	}
+
+	switch pi.Status {
+	case stripe.PaymentIntentStatusRequiresConfirmation, stripe.PaymentIntentStatusRequiresPaymentMethod:
+	case stripe.PaymentIntentStatusRequiresAction:
+		// Без прохождения 3DS клиентом повторное подтверждение снова требует аутентификации
+		return copyPaymentIntent(pi), nil
+	default:
+		return nil, fmt.Errorf("failed to confirm payment intent: %w", fakeUnexpectedState(pi))
+	}
+
+	if err := g.attempt(pi, false); err != nil {
+		return nil, fmt.Errorf("failed to confirm payment intent: %w", err)
+	}
+
+	return copyPaymentIntent(pi), nil
+}
+
+func (g *fakePaymentGateway) GetPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, ok := g.intents[paymentIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get payment intent: %w", fakeNotFound("payment_intent", paymentIntentID))
+	}
+
+	return copyPaymentIntent(pi), nil
+}
+
+func (g *fakePaymentGateway) CancelPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, ok := g.intents[paymentIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to cancel payment intent: %w", fakeNotFound("payment_intent", paymentIntentID))
+	}
+	if pi.Status == stripe.PaymentIntentStatusSucceeded || pi.Status == stripe.PaymentIntentStatusCanceled ||
+		pi.Status == stripe.PaymentIntentStatusProcessing {
+		return nil, fmt.Errorf("failed to cancel payment intent: %w", fakeUnexpectedState(pi))
+	}
+
+	pi.Status = stripe.PaymentIntentStatusCanceled
+	pi.AmountCapturable = 0
+	pi.NextAction = nil
+	pi.CanceledAt = time.Now().Unix()
+	g.emit("payment_intent.canceled", pi)
+
+	return copyPaymentIntent(pi), nil
+}
+
+func (g *fakePaymentGateway) ListPaymentIntents(ctx context.Context, createdFrom, createdTo time.Time) ([]*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var paymentIntents []*stripe.PaymentIntent
+	for _, pi := range g.intents {
+		if pi.Created >= createdFrom.Unix() && pi.Created < createdTo.Unix() {
+			paymentIntents = append(paymentIntents, copyPaymentIntent(pi))
+		}
+	}
+
+	// Как в Stripe: сначала новые
+	sort.Slice(paymentIntents, func(i, j int) bool {
+		if paymentIntents[i].Created != paymentIntents[j].Created {
+			return paymentIntents[i].Created > paymentIntents[j].Created
+		}
+		return paymentIntents[i].ID > paymentIntents[j].ID
+	})
+
+	return paymentIntents, nil
+}
+
+func (g *fakePaymentGateway) GetPaymentIntentInvoice(ctx context.Context, paymentIntentID string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.intents[paymentIntentID]; !ok {
+		return "", fakeNotFound("payment_intent", paymentIntentID)
+	}
+
+	return g.invoiceIntents[paymentIntentID], nil
+}
+
+// Escrow (manual capture)
+func (g *fakePaymentGateway) CreateAuthorizationIntent(ctx context.Context, amount int64, currency, customerID string, method PaymentMethodRef, description string, offSession bool, idempotencyKey string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if existingID, ok := g.idempotencyKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		existing := g.intents[existingID]
+		if existing.Amount != amount || existing.Customer.ID != customerID || existing.PaymentMethod.ID != method.ID {
+			return nil, fmt.Errorf("failed to create authorization intent: %w", &stripe.Error{
+				Type:           stripe.ErrorTypeIdempotency,
+				Msg:            "Keys for idempotent requests can only be used with the same parameters they were first used with.",
+				HTTPStatusCode: http.StatusBadRequest,
+			})
+		}
+		return copyPaymentIntent(existing), nil
+	}
+
+	// Как и stripeService: с банковского счета списываем сразу
+	captureMethod := stripe.PaymentIntentCaptureMethodManual
+	if method.IsBankAccount() {
+		captureMethod = stripe.PaymentIntentCaptureMethodAutomatic
+	}
+
+	pi, err := g.newPaymentIntent(amount, currency, customerID, method, description, captureMethod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authorization intent: %w", err)
+	}
+	pi.ConfirmationMethod = stripe.PaymentIntentConfirmationMethodAutomatic
+	if idempotencyKey != "" {
+		g.idempotencyKeys[idempotencyKey] = pi.ID
+	}
+
+	if err := g.attempt(pi, offSession); err != nil {
+		return nil, fmt.Errorf("failed to create authorization intent: %w", err)
+	}
+
+	return copyPaymentIntent(pi), nil
+}
+
+func (g *fakePaymentGateway) CapturePaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, ok := g.intents[paymentIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to capture payment intent: %w", fakeNotFound("payment_intent", paymentIntentID))
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresCapture {
+		return nil, fmt.Errorf("failed to capture payment intent: %w", fakeUnexpectedState(pi))
+	}
+
+	pi.Status = stripe.PaymentIntentStatusSucceeded
+	pi.AmountReceived = pi.AmountCapturable
+	pi.AmountCapturable = 0
+	pi.LatestCharge.Captured = true
+	pi.LatestCharge.AmountCaptured = pi.AmountReceived
+	g.emit("payment_intent.succeeded", pi)
+
+	return copyPaymentIntent(pi), nil
+}
+
+// CreateRefund сразу проводит возврат; без суммы возвращается весь остаток списания
+func (g *fakePaymentGateway) CreateRefund(ctx context.Context, params *RefundParams) (*stripe.Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if refundID, ok := g.idempotencyKeys[params.IdempotencyKey]; ok && params.IdempotencyKey != "" {
+		copied := *g.refunds[refundID]
+		return &copied, nil
+	}
+
+	pi, ok := g.intents[params.PaymentIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to create refund: %w", fakeNotFound("payment_intent", params.PaymentIntentID))
+	}
+	if pi.Status != stripe.PaymentIntentStatusSucceeded || pi.LatestCharge == nil {
+		return nil, fmt.Errorf("failed to create refund: %w", fakeUnexpectedState(pi))
+	}
+
+	charge := pi.LatestCharge
+	remaining := charge.AmountCaptured - charge.AmountRefunded
+	if remaining <= 0 {
+		return nil, fmt.Errorf("failed to create refund: %w",
+			fakeInvalidRequest(stripe.ErrorCodeChargeAlreadyRefunded, fmt.Sprintf("Charge %s has already been refunded.", charge.ID)))
+	}
+
+	amount := params.AmountCents
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return nil, fmt.Errorf("failed to create refund: %w",
+			fakeInvalidRequest(stripe.ErrorCodeAmountTooLarge, fmt.Sprintf("Refund amount (%d) is greater than unrefunded amount on charge (%d)", amount, remaining)))
+	}
+
+	charge.AmountRefunded += amount
+	charge.Refunded = charge.AmountRefunded >= charge.AmountCaptured
+
+	refund := &stripe.Refund{
+		ID:            g.newID("re"),
+		Object:        "refund",
+		Amount:        amount,
+		Currency:      pi.Currency,
+		Reason:        stripe.RefundReason(params.Reason),
+		Metadata:      params.Metadata,
+		Status:        stripe.RefundStatusSucceeded,
+		PaymentIntent: &stripe.PaymentIntent{ID: pi.ID},
+		Charge:        &stripe.Charge{ID: charge.ID},
+		Created:       time.Now().Unix(),
+	}
+	g.refunds[refund.ID] = refund
+	if params.IdempotencyKey != "" {
+		g.idempotencyKeys[params.IdempotencyKey] = refund.ID
+	}
+
+	g.emit("refund.created", refund)
+	g.emit("charge.refunded", charge)
+
+	copied := *refund
+	return &copied, nil
+}
+
+// Connect
+func (g *fakePaymentGateway) CreateConnectAccount(ctx context.Context, userID int64, email string) (*stripe.Account, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	acct := &stripe.Account{
+		ID:      g.newID("acct"),
+		Object:  "account",
+		Type:    stripe.AccountTypeExpress,
+		Country: "US",
+		Email:   email,
+		Created: time.Now().Unix(),
+		Metadata: map[string]string{
+			"user_id": fmt.Sprintf("%d", userID),
+		},
+	}
+	g.accounts[acct.ID] = acct
+
+	copied := *acct
+	return &copied, nil
+}
+
+func (g *fakePaymentGateway) GetConnectAccount(ctx context.Context, accountID string) (*stripe.Account, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	acct, ok := g.accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get connect account: %w", fakeNotFound("account", accountID))
+	}
+
+	copied := *acct
+	return &copied, nil
+}
+
+// CreateAccountOnboardingLink сразу завершает онбординг и ведет на returnURL
+func (g *fakePaymentGateway) CreateAccountOnboardingLink(ctx context.Context, accountID, refreshURL, returnURL string) (*stripe.AccountLink, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	acct, ok := g.accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("failed to create account link: %w", fakeNotFound("account", accountID))
+	}
+
+	acct.DetailsSubmitted = true
+	acct.PayoutsEnabled = true
+
+	now := time.Now()
+	return &stripe.AccountLink{
+		Object:    "account_link",
+		URL:       returnURL,
+		Created:   now.Unix(),
+		ExpiresAt: now.Add(5 * time.Minute).Unix(),
+	}, nil
+}
+
+func (g *fakePaymentGateway) CreateTransfer(ctx context.Context, amount int64, currency, destinationAccountID, transferGroup, description, idempotencyKey string) (*stripe.Transfer, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if transferID, ok := g.idempotencyKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		copied := *g.transfers[transferID]
+		return &copied, nil
+	}
+
+	acct, ok := g.accounts[destinationAccountID]
+	if !ok {
+		return nil, fmt.Errorf("failed to create transfer: %w", fakeNotFound("account", destinationAccountID))
+	}
+	if !acct.PayoutsEnabled {
+		return nil, fmt.Errorf("failed to create transfer: %w",
+			fakeInvalidRequest("", fmt.Sprintf("Account %s does not have the transfers capability enabled.", acct.ID)))
+	}
+
+	t := &stripe.Transfer{
+		ID:            g.newID("tr"),
+		Object:        "transfer",
+		Amount:        amount,
+		Currency:      stripe.Currency(currency),
+		Destination:   &stripe.Account{ID: acct.ID},
+		TransferGroup: transferGroup,
+		Description:   description,
+		Created:       time.Now().Unix(),
+	}
+	g.transfers[t.ID] = t
+	if idempotencyKey != "" {
+		g.idempotencyKeys[idempotencyKey] = t.ID
+	}
+
+	copied := *t
+	return &copied, nil
+}
+
+// Subscriptions
+func (g *fakePaymentGateway) CreateSubscription(ctx context.Context, params *SubscriptionParams) (*stripe.Subscription, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if existingID, ok := g.idempotencyKeys[params.IdempotencyKey]; ok && params.IdempotencyKey != "" {
+		if existing, ok := g.subscriptions[existingID]; ok {
+			return copySubscription(existing), nil
+		}
+	}
+
+	if _, ok := g.customers[params.CustomerID]; !ok {
+		return nil, fmt.Errorf("failed to create subscription: %w", fakeNotFound("customer", params.CustomerID))
+	}
+	amount, ok := g.prices[params.PriceID]
+	if !ok {
+		return nil, fmt.Errorf("failed to create subscription: %w", fakeNotFound("price", params.PriceID))
+	}
+	pm, ok := g.paymentMethods[params.PaymentMethod.ID]
+	if !ok || pm.Customer == nil || pm.Customer.ID != params.CustomerID {
+		return nil, fmt.Errorf("failed to create subscription: %w", fakeNotFound("payment_method", params.PaymentMethod.ID))
+	}
+
+	now := time.Now()
+	sub := &stripe.Subscription{
+		ID:                   g.newID("sub"),
+		Object:               "subscription",
+		Customer:             &stripe.Customer{ID: params.CustomerID},
+		DefaultPaymentMethod: &stripe.PaymentMethod{ID: pm.ID},
+		Currency:             stripe.CurrencyUSD,
+		Status:               stripe.SubscriptionStatusIncomplete,
+		Metadata:             params.Metadata,
+		Created:              now.Unix(),
+		StartDate:            now.Unix(),
+		Items: &stripe.SubscriptionItemList{
+			Data: []*stripe.SubscriptionItem{{
+				ID:                 g.newID("si"),
+				Price:              &stripe.Price{ID: params.PriceID, UnitAmount: amount, Currency: stripe.CurrencyUSD},
+				Quantity:           1,
+				CurrentPeriodStart: now.Unix(),
+				CurrentPeriodEnd:   now.AddDate(0, 1, 0).Unix(),
+			}},
+		},
+	}
+	g.subscriptions[sub.ID] = sub
+	if params.IdempotencyKey != "" {
+		g.idempotencyKeys[params.IdempotencyKey] = sub.ID
+	}
+
+	if g.chargeSubscription(sub, amount, stripe.InvoiceBillingReasonSubscriptionCreate) {
+		sub.Status = stripe.SubscriptionStatusActive
+	}
+	g.emit("customer.subscription.created", sub)
+
+	return copySubscription(sub), nil
+}
+
+func (g *fakePaymentGateway) GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sub, ok := g.subscriptions[subscriptionID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get subscription: %w", fakeNotFound("subscription", subscriptionID))
+	}
+
+	return copySubscription(sub), nil
+}
+
+// ChangeSubscriptionPrice пересчитывает остаток периода пропорционально. Как и pending_if_incomplete в Stripe:
+// если доплату списать не удалось, цена не меняется, а в подписке остается PendingUpdate
+func (g *fakePaymentGateway) ChangeSubscriptionPrice(ctx context.Context, subscriptionID, priceID string, invoiceNow bool) (*stripe.Subscription, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sub, ok := g.subscriptions[subscriptionID]
+	if !ok {
+		return nil, fmt.Errorf("failed to change subscription price: %w", fakeNotFound("subscription", subscriptionID))
+	}
+	if sub.Status == stripe.SubscriptionStatusCanceled {
+		return nil, fmt.Errorf("failed to change subscription price: %w",
+			fakeInvalidRequest("", "A canceled subscription can only update its cancellation_details and metadata."))
+	}
+	amount, ok := g.prices[priceID]
+	if !ok {
+		return nil, fmt.Errorf("failed to change subscription price: %w", fakeNotFound("price", priceID))
+	}
+
+	item := sub.Items.Data[0]
+	sub.PendingUpdate = nil
+	if invoiceNow {
+		now := time.Now().Unix()
+		remaining := float64(item.CurrentPeriodEnd-now) / float64(item.CurrentPeriodEnd-item.CurrentPeriodStart)
+		proration := int64(math.Round(float64(amount-item.Price.UnitAmount) * remaining))
+		if proration > 0 && !g.chargeSubscription(sub, proration, stripe.InvoiceBillingReasonSubscriptionUpdate) {
+			sub.PendingUpdate = &stripe.SubscriptionPendingUpdate{ExpiresAt: time.Now().Add(23 * time.Hour).Unix()}
+			return copySubscription(sub), nil
+		}
+	}
+
+	item.Price = &stripe.Price{ID: priceID, UnitAmount: amount, Currency: stripe.CurrencyUSD}
+	g.emit("customer.subscription.updated", sub)
+
+	return copySubscription(sub), nil
+}
+
+func (g *fakePaymentGateway) SetSubscriptionCancelAtPeriodEnd(ctx context.Context, subscriptionID string, cancel bool) (*stripe.Subscription, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sub, ok := g.subscriptions[subscriptionID]
+	if !ok {
+		return nil, fmt.Errorf("failed to update subscription: %w", fakeNotFound("subscription", subscriptionID))
+	}
+	if sub.Status == stripe.SubscriptionStatusCanceled {
+		return nil, fmt.Errorf("failed to update subscription: %w",
+			fakeInvalidRequest("", "A canceled subscription can only update its cancellation_details and metadata."))
+	}
+
+	sub.CancelAtPeriodEnd = cancel
+	sub.CancelAt = 0
+	if cancel {
+		sub.CancelAt = sub.Items.Data[0].CurrentPeriodEnd
+	}
+	g.emit("customer.subscription.updated", sub)
+
+	return copySubscription(sub), nil
+}
+
+func (g *fakePaymentGateway) CancelSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sub, ok := g.subscriptions[subscriptionID]
+	if !ok {
+		return nil, fmt.Errorf("failed to cancel subscription: %w", fakeNotFound("subscription", subscriptionID))
+	}
+	if sub.Status == stripe.SubscriptionStatusCanceled {
+		return nil, fmt.Errorf("failed to cancel subscription: %w", fakeInvalidRequest("", "This subscription is already canceled."))
+	}
+
+	g.cancelSubscription(sub)
+	return copySubscription(sub), nil
+}
+
+// Webhook
+func (g *fakePaymentGateway) ConstructEvent(payload []byte, header string) (stripe.Event, error) {
+	event, err := webhook.ConstructEvent(payload, header, g.webhookSecret)
+	if err != nil {
+		return event, fmt.Errorf("failed to construct stripe event: %w", err)
+	}
+
+	return event, nil
+}
+
+func (g *fakePaymentGateway) CompleteAuthentication(ctx context.Context, paymentIntentID string, approve bool) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, ok := g.intents[paymentIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to complete authentication: %w", fakeNotFound("payment_intent", paymentIntentID))
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresAction {
+		return nil, fmt.Errorf("failed to complete authentication: %w", fakeUnexpectedState(pi))
+	}
+
+	pi.NextAction = nil
+	if !approve {
+		g.fail(pi, fakeCardError(stripe.ErrorCodePaymentIntentAuthenticationFailure, "",
+			"We are unable to authenticate your payment method. Please choose a different payment method and try again."))
+		return copyPaymentIntent(pi), nil
+	}
+
+	g.authorize(pi)
+	return copyPaymentIntent(pi), nil
+}
+
+func (g *fakePaymentGateway) ConfirmSetupIntent(ctx context.Context, setupIntentID, paymentMethodID string) (*stripe.SetupIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	si, ok := g.setupIntents[setupIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to confirm setup intent: %w", fakeNotFound("setup_intent", setupIntentID))
+	}
+	if si.Status != stripe.SetupIntentStatusRequiresPaymentMethod {
+		return nil, fmt.Errorf("failed to confirm setup intent: %w", fakeUnexpectedSetupState(si))
+	}
+
+	account, ok := fakeBankAccounts[paymentMethodID]
+	if !ok {
+		account = fakeBankAccounts[FakeBankAccountSuccess]
+	}
+
+	pm := &stripe.PaymentMethod{
+		ID:     g.newID("pm"),
+		Object: "payment_method",
+		Type:   stripe.PaymentMethodTypeUSBankAccount,
+		USBankAccount: &stripe.PaymentMethodUSBankAccount{
+			AccountHolderType: stripe.PaymentMethodUSBankAccountAccountHolderTypeCompany,
+			AccountType:       stripe.PaymentMethodUSBankAccountAccountTypeChecking,
+			BankName:          account.bankName,
+			Last4:             account.last4,
+			RoutingNumber:     "110000000",
+		},
+		Customer: si.Customer,
+		Created:  time.Now().Unix(),
+	}
+	g.paymentMethods[pm.ID] = pm
+	g.outcomes[pm.ID] = account.outcome
+	si.PaymentMethod = &stripe.PaymentMethod{ID: pm.ID}
+	si.LastSetupError = nil
+
+	if account.microdeposits {
+		si.Status = stripe.SetupIntentStatusRequiresAction
+		si.NextAction = &stripe.SetupIntentNextAction{Type: stripe.SetupIntentNextActionTypeVerifyWithMicrodeposits}
+		g.emit("setup_intent.requires_action", si)
+	} else {
+		g.succeedSetupIntent(si)
+	}
+
+	copied := *si
+	return &copied, nil
+}
+
+func (g *fakePaymentGateway) VerifyMicrodeposits(ctx context.Context, setupIntentID string, approve bool) (*stripe.SetupIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	si, ok := g.setupIntents[setupIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to verify microdeposits: %w", fakeNotFound("setup_intent", setupIntentID))
+	}
+	if si.Status != stripe.SetupIntentStatusRequiresAction {
+		return nil, fmt.Errorf("failed to verify microdeposits: %w", fakeUnexpectedSetupState(si))
+	}
+
+	si.NextAction = nil
+	if !approve {
+		si.Status = stripe.SetupIntentStatusRequiresPaymentMethod
+		si.LastSetupError = fakeInvalidRequest(stripe.ErrorCodePaymentMethodMicrodepositVerificationAttemptsExceeded,
+			"You have exceeded the number of allowed verification attempts.")
+		g.emit("setup_intent.setup_failed", si)
+	} else {
+		g.succeedSetupIntent(si)
+	}
+
+	copied := *si
+	return &copied, nil
+}
+
+// SettlePaymentIntent проводит списание с банковского счета, которое находится в processing
+func (g *fakePaymentGateway) SettlePaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, ok := g.intents[paymentIntentID]
+	if !ok {
+		return nil, fmt.Errorf("failed to settle payment intent: %w", fakeNotFound("payment_intent", paymentIntentID))
+	}
+	if pi.Status != stripe.PaymentIntentStatusProcessing {
+		return nil, fmt.Errorf("failed to settle payment intent: %w", fakeUnexpectedState(pi))
+	}
+
+	if g.outcomes[pi.PaymentMethod.ID] == fakeOutcomeInsufficientFunds {
+		g.fail(pi, fakeInvalidRequest(stripe.ErrorCodeInsufficientFunds, "The customer's account has insufficient funds to cover this payment."))
+		return copyPaymentIntent(pi), nil
+	}
+
+	g.authorize(pi)
+	return copyPaymentIntent(pi), nil
+}
+
+func (g *fakePaymentGateway) SetPrice(priceID string, amountCents int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.prices[priceID] = amountCents
+}
+
+func (g *fakePaymentGateway) RenewSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sub, ok := g.subscriptions[subscriptionID]
+	if !ok {
+		return nil, fmt.Errorf("failed to renew subscription: %w", fakeNotFound("subscription", subscriptionID))
+	}
+
+	item := sub.Items.Data[0]
+	switch sub.Status {
+	case stripe.SubscriptionStatusActive:
+		if sub.CancelAtPeriodEnd {
+			g.cancelSubscription(sub)
+			return copySubscription(sub), nil
+		}
+		item.CurrentPeriodStart = item.CurrentPeriodEnd
+		item.CurrentPeriodEnd = time.Unix(item.CurrentPeriodEnd, 0).AddDate(0, 1, 0).Unix()
+	case stripe.SubscriptionStatusPastDue:
+		// Последняя попытка не прошла - подписка отменяется, как настроено в Stripe по умолчанию
+		if sub.LatestInvoice != nil && sub.LatestInvoice.AttemptCount >= fakeSubscriptionRetries {
+			g.cancelSubscription(sub)
+			return copySubscription(sub), nil
+		}
+	default:
+		return nil, fmt.Errorf("failed to renew subscription: %w",
+			fakeInvalidRequest("", fmt.Sprintf("This subscription's status is %s.", sub.Status)))
+	}
+
+	if g.chargeSubscription(sub, item.Price.UnitAmount, stripe.InvoiceBillingReasonSubscriptionCycle) {
+		sub.Status = stripe.SubscriptionStatusActive
+	} else {
+		sub.Status = stripe.SubscriptionStatusPastDue
+	}
+	g.emit("customer.subscription.updated", sub)
+
+	return copySubscription(sub), nil
+}
+
+func (g *fakePaymentGateway) SetWebhookHandler(handler WebhookHandler) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.handler = handler
+}
+
+// DeliverWebhooks по порядку отправляет накопившиеся события в обработчик.
+// Событие, которое обработчик не принял, не доставляется повторно - его можно переиграть из журнала событий
+func (g *fakePaymentGateway) DeliverWebhooks(ctx context.Context) (int, error) {
+	delivered := 0
+	var firstErr error
+
+	for {
+		g.mu.Lock()
+		if g.handler == nil || len(g.pending) == 0 {
+			g.mu.Unlock()
+			return delivered, firstErr
+		}
+		payload, handler := g.pending[0], g.handler
+		g.pending = g.pending[1:]
+		g.mu.Unlock()
+
+		signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+			Payload: payload,
+			Secret:  g.webhookSecret,
+		})
+
+		delivered++
+		if err := handler(ctx, signed.Payload, signed.Header); err != nil {
+			fmt.Printf("Fake gateway webhook delivery failed: %v\n", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+}
+
+func (g *fakePaymentGateway) StartWebhookDelivery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.DeliverWebhooks(ctx)
+		}
+	}
+}
+
+// newID выдает последовательные ID, чтобы прогоны были воспроизводимыми. Вызывается под g.mu
+func (g *fakePaymentGateway) newID(prefix string) string {
+	g.sequence[prefix]++
+	return fmt.Sprintf("%s_fake_%06d", prefix, g.sequence[prefix])
+}
+
+func (g *fakePaymentGateway) newPaymentIntent(amount int64, currency, customerID string, method PaymentMethodRef, description string, captureMethod stripe.PaymentIntentCaptureMethod) (*stripe.PaymentIntent, error) {
+	if amount <= 0 {
+		return nil, fakeInvalidRequest("", "Amount must be greater than zero.")
+	}
+	if _, ok := g.customers[customerID]; !ok {
+		return nil, fakeNotFound("customer", customerID)
+	}
+	pm, ok := g.paymentMethods[method.ID]
+	if !ok {
+		return nil, fakeNotFound("payment_method", method.ID)
+	}
+	if pm.Customer == nil || pm.Customer.ID != customerID {
+		return nil, fakeInvalidRequest("", fmt.Sprintf("The payment method %s does not belong to customer %s.", method.ID, customerID))
+	}
+	if pm.Type == stripe.PaymentMethodTypeUSBankAccount && method.MandateID == "" {
+		return nil, fakeInvalidRequest("", "A mandate is required to debit a us_bank_account payment method.")
+	}
+
+	id := g.newID("pi")
+	pi := &stripe.PaymentIntent{
+		ID:                 id,
+		Object:             "payment_intent",
+		Amount:             amount,
+		Currency:           stripe.Currency(currency),
+		Customer:           &stripe.Customer{ID: customerID},
+		PaymentMethod:      &stripe.PaymentMethod{ID: method.ID},
+		PaymentMethodTypes: []string{string(pm.Type)},
+		Description:        description,
+		CaptureMethod:      captureMethod,
+		ClientSecret:       id + "_secret_fake",
+		Status:             stripe.PaymentIntentStatusRequiresConfirmation,
+		Created:            time.Now().Unix(),
+	}
+	g.intents[pi.ID] = pi
+
+	return pi, nil
+}
+
+// attempt проводит подтверждение PaymentIntent по сценарию тестовой карты.
+// Списание с банковского счета уходит в processing до SettlePaymentIntent. Вызывается под g.mu
+func (g *fakePaymentGateway) attempt(pi *stripe.PaymentIntent, offSession bool) error {
+	if g.paymentMethods[pi.PaymentMethod.ID].Type == stripe.PaymentMethodTypeUSBankAccount {
+		pi.Status = stripe.PaymentIntentStatusProcessing
+		pi.LastPaymentError = nil
+		g.emit("payment_intent.processing", pi)
+		return nil
+	}
+
+	switch g.outcomes[pi.PaymentMethod.ID] {
+	case fakeOutcomeDecline:
+		return g.fail(pi, fakeCardError(stripe.ErrorCodeCardDeclined, stripe.DeclineCodeGenericDecline, "Your card was declined."))
+	case fakeOutcomeInsufficientFunds:
+		return g.fail(pi, fakeCardError(stripe.ErrorCodeCardDeclined, stripe.DeclineCodeInsufficientFunds, "Your card has insufficient funds."))
+	case fakeOutcomeAuthenticationRequired:
+		if offSession {
+			return g.fail(pi, fakeCardError(stripe.ErrorCodeAuthenticationRequired, stripe.DeclineCodeAuthenticationRequired,
+				"Your card was declined. This transaction requires authentication."))
+		}
+		pi.Status = stripe.PaymentIntentStatusRequiresAction
+		pi.NextAction = &stripe.PaymentIntentNextAction{Type: stripe.PaymentIntentNextActionTypeUseStripeSDK}
+		return nil
+	default:
+		g.authorize(pi)
+		return nil
+	}
+}
+
+// authorize создает успешное списание: с ручным списанием деньги только блокируются. Вызывается под g.mu
+func (g *fakePaymentGateway) authorize(pi *stripe.PaymentIntent) {
+	manual := pi.CaptureMethod == stripe.PaymentIntentCaptureMethodManual
+
+	charge := &stripe.Charge{
+		ID:            g.newID("ch"),
+		Object:        "charge",
+		Amount:        pi.Amount,
+		Currency:      pi.Currency,
+		Customer:      pi.Customer,
+		PaymentIntent: &stripe.PaymentIntent{ID: pi.ID},
+		Paid:          true,
+		Captured:      !manual,
+		Status:        stripe.ChargeStatusSucceeded,
+		Created:       time.Now().Unix(),
+	}
+	pi.LatestCharge = charge
+	pi.LastPaymentError = nil
+	pi.NextAction = nil
+
+	if manual {
+		pi.Status = stripe.PaymentIntentStatusRequiresCapture
+		pi.AmountCapturable = pi.Amount
+		g.emit("payment_intent.amount_capturable_updated", pi)
+		return
+	}
+
+	charge.AmountCaptured = pi.Amount
+	pi.Status = stripe.PaymentIntentStatusSucceeded
+	pi.AmountReceived = pi.Amount
+	g.emit("payment_intent.succeeded", pi)
+}
+
+// succeedSetupIntent подтверждает банковский счет и выдает мандат на списания. Вызывается под g.mu
+func (g *fakePaymentGateway) succeedSetupIntent(si *stripe.SetupIntent) {
+	si.Status = stripe.SetupIntentStatusSucceeded
+	si.NextAction = nil
+	si.Mandate = &stripe.Mandate{ID: g.newID("mandate")}
+	g.emit("setup_intent.succeeded", si)
+}
+
+// chargeSubscription списывает счет подписки с ее способа оплаты по сценарию тестовой карты.
+// Повторная попытка по открытому счету подписки увеличивает AttemptCount. Вызывается под g.mu
+func (g *fakePaymentGateway) chargeSubscription(sub *stripe.Subscription, amount int64, reason stripe.InvoiceBillingReason) bool {
+	item := sub.Items.Data[0]
+
+	invoice := sub.LatestInvoice
+	if invoice == nil || invoice.Status != stripe.InvoiceStatusOpen || reason != stripe.InvoiceBillingReasonSubscriptionCycle {
+		invoice = &stripe.Invoice{
+			ID:            g.newID("in"),
+			Object:        "invoice",
+			Customer:      sub.Customer,
+			Currency:      stripe.CurrencyUSD,
+			AmountDue:     amount,
+			BillingReason: reason,
+			PeriodStart:   item.CurrentPeriodStart,
+			PeriodEnd:     item.CurrentPeriodEnd,
+			Parent: &stripe.InvoiceParent{
+				Type:                "subscription_details",
+				SubscriptionDetails: &stripe.InvoiceParentSubscriptionDetails{Subscription: &stripe.Subscription{ID: sub.ID}},
+			},
+			Created: time.Now().Unix(),
+		}
+		invoice.HostedInvoiceURL = "https://invoice.stripe.test/" + invoice.ID
+	}
+	invoice.AttemptCount++
+	invoice.ConfirmationSecret = nil
+	sub.LatestInvoice = invoice
+	pi := g.invoicePaymentIntent(invoice, sub)
+
+	switch g.outcomes[sub.DefaultPaymentMethod.ID] {
+	case fakeOutcomeDecline, fakeOutcomeInsufficientFunds, fakeOutcomeAuthenticationRequired:
+		pi.Status = stripe.PaymentIntentStatusRequiresPaymentMethod
+		if g.outcomes[sub.DefaultPaymentMethod.ID] == fakeOutcomeAuthenticationRequired {
+			pi.Status = stripe.PaymentIntentStatusRequiresAction
+		}
+		invoice.Status = stripe.InvoiceStatusOpen
+		invoice.NextPaymentAttempt = time.Now().Add(72 * time.Hour).Unix()
+		if g.outcomes[sub.DefaultPaymentMethod.ID] == fakeOutcomeAuthenticationRequired {
+			invoice.ConfirmationSecret = &stripe.InvoiceConfirmationSecret{ClientSecret: invoice.ID + "_secret_fake", Type: "payment_intent"}
+		}
+		g.emit("invoice.payment_failed", invoice)
+		return false
+	default:
+		pi.Status = stripe.PaymentIntentStatusSucceeded
+		pi.AmountReceived = pi.Amount
+		invoice.Status = stripe.InvoiceStatusPaid
+		invoice.AmountPaid = invoice.AmountDue
+		invoice.NextPaymentAttempt = 0
+		g.emit("invoice.paid", invoice)
+		return true
+	}
+}
+
+// invoicePaymentIntent возвращает PaymentIntent, которым оплачивается счет. Как и Stripe,
+// создает его при первой попытке оплаты и переиспользует при повторных. Вызывается под g.mu
+func (g *fakePaymentGateway) invoicePaymentIntent(invoice *stripe.Invoice, sub *stripe.Subscription) *stripe.PaymentIntent {
+	for piID, invoiceID := range g.invoiceIntents {
+		if invoiceID == invoice.ID {
+			return g.intents[piID]
+		}
+	}
+
+	id := g.newID("pi")
+	pi := &stripe.PaymentIntent{
+		ID:                 id,
+		Object:             "payment_intent",
+		Amount:             invoice.AmountDue,
+		Currency:           invoice.Currency,
+		Customer:           sub.Customer,
+		PaymentMethod:      &stripe.PaymentMethod{ID: sub.DefaultPaymentMethod.ID},
+		PaymentMethodTypes: []string{string(stripe.PaymentMethodTypeCard)},
+		Description:        "Payment for invoice " + invoice.ID,
+		CaptureMethod:      stripe.PaymentIntentCaptureMethodAutomatic,
+		ClientSecret:       id + "_secret_fake",
+		Status:             stripe.PaymentIntentStatusRequiresConfirmation,
+		Created:            time.Now().Unix(),
+	}
+	g.intents[id] = pi
+	g.invoiceIntents[id] = invoice.ID
+
+	return pi
+}
+
+// cancelSubscription немедленно завершает подписку. Вызывается под g.mu
+func (g *fakePaymentGateway) cancelSubscription(sub *stripe.Subscription) {
+	now := time.Now().Unix()
+	sub.Status = stripe.SubscriptionStatusCanceled
+	sub.CanceledAt = now
+	sub.EndedAt = now
+	sub.PendingUpdate = nil
+	g.emit("customer.subscription.deleted", sub)
+}
+
+// fail отклоняет попытку оплаты. Вызывается под g.mu
+func (g *fakePaymentGateway) fail(pi *stripe.PaymentIntent, cardErr *stripe.Error) error {
+	pi.Status = stripe.PaymentIntentStatusRequiresPaymentMethod
+	pi.LastPaymentError = cardErr
+	g.emit("payment_intent.payment_failed", pi)
+
+	return cardErr
+}
+
+// emit ставит событие в очередь доставки. Вызывается под g.mu
+func (g *fakePaymentGateway) emit(eventType string, object interface{}) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		fmt.Printf("Fake gateway failed to encode %s: %v\n", eventType, err)
+		return
+	}
+
+	payload, err := json.Marshal(stripe.Event{
+		ID:         g.newID("evt"),
+		Object:     "event",
+		APIVersion: stripe.APIVersion,
+		Created:    time.Now().Unix(),
+		Type:       stripe.EventType(eventType),
+		Data:       &stripe.EventData{Raw: raw},
+	})
+	if err != nil {
+		fmt.Printf("Fake gateway failed to encode %s: %v\n", eventType, err)
+		return
+	}
+
+	g.pending = append(g.pending, payload)
+}
+
+func copyPaymentIntent(pi *stripe.PaymentIntent) *stripe.PaymentIntent {
+	copied := *pi
+	if pi.LatestCharge != nil {
+		charge := *pi.LatestCharge
+		copied.LatestCharge = &charge
+	}
+	if pi.LastPaymentError != nil {
+		lastErr := *pi.LastPaymentError
+		copied.LastPaymentError = &lastErr
+	}
+	return &copied
+}
+
+func copySubscription(sub *stripe.Subscription) *stripe.Subscription {
+	copied := *sub
+	if sub.Items != nil {
+		items := &stripe.SubscriptionItemList{Data: make([]*stripe.SubscriptionItem, len(sub.Items.Data))}
+		for i, item := range sub.Items.Data {
+			itemCopy := *item
+			items.Data[i] = &itemCopy
+		}
+		copied.Items = items
+	}
+	if sub.LatestInvoice != nil {
+		invoice := *sub.LatestInvoice
+		copied.LatestInvoice = &invoice
+	}
+	return &copied
+}
+
+func fakeCardError(code stripe.ErrorCode, declineCode stripe.DeclineCode, message string) *stripe.Error {
+	return &stripe.Error{
+		Type:           stripe.ErrorTypeCard,
+		Code:           code,
+		DeclineCode:    declineCode,
+		Msg:            message,
+		HTTPStatusCode: http.StatusPaymentRequired,
+		Err:            errors.New(message),
+	}
+}
+
+func fakeInvalidRequest(code stripe.ErrorCode, message string) *stripe.Error {
+	return &stripe.Error{
+		Type:           stripe.ErrorTypeInvalidRequest,
+		Code:           code,
+		Msg:            message,
+		HTTPStatusCode: http.StatusBadRequest,
+		Err:            errors.New(message),
+	}
+}
+
+func fakeNotFound(resource, id string) *stripe.Error {
+	message := fmt.Sprintf("No such %s: '%s'", resource, id)
+	return &stripe.Error{
+		Type:           stripe.ErrorTypeInvalidRequest,
+		Code:           stripe.ErrorCodeResourceMissing,
+		Msg:            message,
+		HTTPStatusCode: http.StatusNotFound,
+		Err:            errors.New(message),
+	}
+}
+
+func fakeUnexpectedSetupState(si *stripe.SetupIntent) *stripe.Error {
+	message := fmt.Sprintf("This SetupIntent's status is %s, which does not allow the requested operation.", si.Status)
+	return fakeInvalidRequest(stripe.ErrorCodeSetupIntentUnexpectedState, message)
+}
+
+func fakeUnexpectedState(pi *stripe.PaymentIntent) *stripe.Error {
+	message := fmt.Sprintf("This PaymentIntent's status is %s, which does not allow the requested operation.", pi.Status)
+	return &stripe.Error{
+		Type:           stripe.ErrorTypeInvalidRequest,
+		Code:           stripe.ErrorCodePaymentIntentUnexpectedState,
+		Msg:            message,
+		HTTPStatusCode: http.StatusBadRequest,
+		Err:            errors.New(message),
+	}
+}
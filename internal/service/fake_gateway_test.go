@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+// newGatewayCustomer создает customer с привязанной тестовой картой
+func newGatewayCustomer(t *testing.T, g FakePaymentGateway, card string) (customerID string, method PaymentMethodRef) {
+	t.Helper()
+	ctx := context.Background()
+
+	customerID, err := g.CreateCustomer(ctx, 1, "user1@example.com", "Test User")
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	pm, err := g.AttachPaymentMethod(ctx, card, customerID)
+	if err != nil {
+		t.Fatalf("AttachPaymentMethod: %v", err)
+	}
+
+	return customerID, PaymentMethodRef{ID: pm.ID, Type: string(pm.Type)}
+}
+
+// recordWebhooks проверяет подпись доставленных событий и записывает их типы
+func recordWebhooks(g FakePaymentGateway) *[]string {
+	var received []string
+	g.SetWebhookHandler(func(ctx context.Context, payload []byte, signature string) error {
+		event, err := g.ConstructEvent(payload, signature)
+		if err != nil {
+			return err
+		}
+		received = append(received, string(event.Type))
+		return nil
+	})
+	return &received
+}
+
+func TestFakeGatewayCardOutcomes(t *testing.T) {
+	tests := []struct {
+		name         string
+		card         string
+		manual       bool
+		offSession   bool
+		wantDecline  stripe.DeclineCode
+		wantStatus   stripe.PaymentIntentStatus
+		wantCaptured int64
+		wantEvents   []string
+	}{
+		{
+			name:         "success",
+			card:         FakeCardSuccess,
+			wantStatus:   stripe.PaymentIntentStatusSucceeded,
+			wantCaptured: 5000,
+			wantEvents:   []string{"payment_intent.succeeded"},
+		},
+		{
+			name:       "authorization holds funds",
+			card:       FakeCardSuccess,
+			manual:     true,
+			wantStatus: stripe.PaymentIntentStatusRequiresCapture,
+			wantEvents: []string{"payment_intent.amount_capturable_updated"},
+		},
+		{
+			name:        "declined",
+			card:        FakeCardDeclined,
+			wantDecline: stripe.DeclineCodeGenericDecline,
+			wantStatus:  stripe.PaymentIntentStatusRequiresPaymentMethod,
+			wantEvents:  []string{"payment_intent.payment_failed"},
+		},
+		{
+			name:        "insufficient funds",
+			card:        FakeCardInsufficientFunds,
+			manual:      true,
+			wantDecline: stripe.DeclineCodeInsufficientFunds,
+			wantStatus:  stripe.PaymentIntentStatusRequiresPaymentMethod,
+			wantEvents:  []string{"payment_intent.payment_failed"},
+		},
+		{
+			name:       "3-D Secure on session",
+			card:       FakeCardAuthenticationRequired,
+			manual:     true,
+			wantStatus: stripe.PaymentIntentStatusRequiresAction,
+		},
+		{
+			name:        "3-D Secure off session",
+			card:        FakeCardAuthenticationRequired,
+			manual:      true,
+			offSession:  true,
+			wantDecline: stripe.DeclineCodeAuthenticationRequired,
+			wantStatus:  stripe.PaymentIntentStatusRequiresPaymentMethod,
+			wantEvents:  []string{"payment_intent.payment_failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			g := NewFakePaymentGateway("")
+			received := recordWebhooks(g)
+			customerID, method := newGatewayCustomer(t, g, tt.card)
+
+			var pi *stripe.PaymentIntent
+			var err error
+			if tt.manual {
+				pi, err = g.CreateAuthorizationIntent(ctx, 5000, "usd", customerID, method, "Escrow", tt.offSession, "")
+			} else {
+				pi, err = g.CreatePaymentIntent(ctx, 5000, "usd", customerID, method, "Fee", "")
+				if err == nil {
+					pi, err = g.ConfirmPaymentIntent(ctx, pi.ID)
+				}
+			}
+
+			if tt.wantDecline != "" {
+				var stripeErr *stripe.Error
+				if !errors.As(err, &stripeErr) || stripeErr.Type != stripe.ErrorTypeCard || stripeErr.DeclineCode != tt.wantDecline {
+					t.Fatalf("error = %v, want card error %s", err, tt.wantDecline)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Отклоненный PaymentIntent остается в шлюзе, как в Stripe
+			pi, err = g.GetPaymentIntent(ctx, "pi_fake_000001")
+			if err != nil {
+				t.Fatalf("GetPaymentIntent: %v", err)
+			}
+			if pi.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", pi.Status, tt.wantStatus)
+			}
+			if pi.AmountReceived != tt.wantCaptured {
+				t.Errorf("amount received = %d, want %d", pi.AmountReceived, tt.wantCaptured)
+			}
+
+			if _, err := g.DeliverWebhooks(ctx); err != nil {
+				t.Fatalf("DeliverWebhooks: %v", err)
+			}
+			if !reflect.DeepEqual(*received, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", *received, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestFakeGatewayAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		approve    bool
+		wantStatus stripe.PaymentIntentStatus
+		wantEvents []string
+	}{
+		{
+			name:       "approved",
+			approve:    true,
+			wantStatus: stripe.PaymentIntentStatusRequiresCapture,
+			wantEvents: []string{"payment_intent.amount_capturable_updated"},
+		},
+		{
+			name:       "rejected",
+			wantStatus: stripe.PaymentIntentStatusRequiresPaymentMethod,
+			wantEvents: []string{"payment_intent.payment_failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			g := NewFakePaymentGateway("")
+			received := recordWebhooks(g)
+			customerID, method := newGatewayCustomer(t, g, FakeCardAuthenticationRequired)
+
+			pi, err := g.CreateAuthorizationIntent(ctx, 5000, "usd", customerID, method, "Escrow", false, "")
+			if err != nil {
+				t.Fatalf("CreateAuthorizationIntent: %v", err)
+			}
+			if pi.NextAction == nil {
+				t.Fatal("expected a 3-D Secure next action")
+			}
+
+			// Повторное подтверждение без прохождения 3DS ничего не меняет
+			if pi, err = g.ConfirmPaymentIntent(ctx, pi.ID); err != nil || pi.Status != stripe.PaymentIntentStatusRequiresAction {
+				t.Fatalf("ConfirmPaymentIntent = %v, %v; want requires_action", pi.Status, err)
+			}
+
+			pi, err = g.CompleteAuthentication(ctx, pi.ID, tt.approve)
+			if err != nil {
+				t.Fatalf("CompleteAuthentication: %v", err)
+			}
+			if pi.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", pi.Status, tt.wantStatus)
+			}
+			if pi.NextAction != nil {
+				t.Error("next action should be cleared after authentication")
+			}
+
+			if _, err := g.CompleteAuthentication(ctx, pi.ID, tt.approve); err == nil {
+				t.Error("second CompleteAuthentication should fail")
+			}
+
+			if _, err := g.DeliverWebhooks(ctx); err != nil {
+				t.Fatalf("DeliverWebhooks: %v", err)
+			}
+			if !reflect.DeepEqual(*received, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", *received, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestFakeGatewayWebhookDelivery(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakePaymentGateway("whsec_test")
+
+	// Без обработчика события копятся в очереди
+	customerID, method := newGatewayCustomer(t, g, FakeCardSuccess)
+	pi, err := g.CreateAuthorizationIntent(ctx, 5000, "usd", customerID, method, "Escrow", false, "")
+	if err != nil {
+		t.Fatalf("CreateAuthorizationIntent: %v", err)
+	}
+	if _, err := g.CapturePaymentIntent(ctx, pi.ID); err != nil {
+		t.Fatalf("CapturePaymentIntent: %v", err)
+	}
+	if _, err := g.CreateRefund(ctx, &RefundParams{PaymentIntentID: pi.ID, AmountCents: 2000}); err != nil {
+		t.Fatalf("CreateRefund: %v", err)
+	}
+	if delivered, err := g.DeliverWebhooks(ctx); delivered != 0 || err != nil {
+		t.Fatalf("DeliverWebhooks without handler = %d, %v; want 0, nil", delivered, err)
+	}
+
+	// Событие, подписанное чужим секретом, отклоняется
+	other := NewFakePaymentGateway("whsec_other")
+	if _, err := other.ConstructEvent([]byte(`{"id":"evt_1"}`), "t=1,v1=bad"); err == nil {
+		t.Fatal("ConstructEvent accepted an invalid signature")
+	}
+
+	received := recordWebhooks(g)
+	delivered, err := g.DeliverWebhooks(ctx)
+	if err != nil {
+		t.Fatalf("DeliverWebhooks: %v", err)
+	}
+	wantEvents := []string{
+		"payment_intent.amount_capturable_updated",
+		"payment_intent.succeeded",
+		"refund.created",
+		"charge.refunded",
+	}
+	if delivered != len(wantEvents) || !reflect.DeepEqual(*received, wantEvents) {
+		t.Fatalf("delivered %d events %v, want %v", delivered, *received, wantEvents)
+	}
+
+	// Доставленные события не отправляются повторно
+	if delivered, _ := g.DeliverWebhooks(ctx); delivered != 0 {
+		t.Errorf("redelivered %d events", delivered)
+	}
+
+	// Ошибка обработчика возвращается, но очередь продолжает доставляться
+	if _, err := g.CreateRefund(ctx, &RefundParams{PaymentIntentID: pi.ID}); err != nil {
+		t.Fatalf("CreateRefund: %v", err)
+	}
+	calls := 0
+	handlerErr := errors.New("handler failed")
+	g.SetWebhookHandler(func(ctx context.Context, payload []byte, signature string) error {
+		calls++
+		return handlerErr
+	})
+	if delivered, err := g.DeliverWebhooks(ctx); delivered != 2 || calls != 2 || !errors.Is(err, handlerErr) {
+		t.Errorf("DeliverWebhooks = %d, %v after %d calls; want 2, handler error", delivered, err, calls)
+	}
+}
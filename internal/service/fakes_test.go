@@ -0,0 +1,1454 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/escrow_capture"
+	"moveshare/internal/repository/job_posting"
+	"moveshare/internal/repository/ledger"
+	"moveshare/internal/repository/payment"
+	"moveshare/internal/repository/payout"
+	"moveshare/internal/repository/promo"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stripe/stripe-go/v82"
+)
+
+// Репозитории в памяти повторяют семантику SQL-запросов только для методов, которые нужны тестам;
+// остальные методы возвращают errNotStubbed, чтобы неожиданный вызов было видно по ошибке теста
+
+var (
+	errTestDB     = errors.New("database is unavailable")
+	errNotStubbed = errors.New("method is not stubbed in tests")
+)
+
+// memPaymentRepo - платежи, методы оплаты, возвраты и журнал webhook-событий
+type memPaymentRepo struct {
+	customers   map[int64]string
+	methods     []*models.UserPaymentMethod
+	payments    []*models.Payment
+	refunds     []*models.PaymentRefund
+	events      map[string]*models.StripeEvent
+	jobStatuses map[int64]string
+}
+
+var _ payment.PaymentRepository = (*memPaymentRepo)(nil)
+
+func newMemPaymentRepo() *memPaymentRepo {
+	return &memPaymentRepo{
+		customers:   make(map[int64]string),
+		events:      make(map[string]*models.StripeEvent),
+		jobStatuses: make(map[int64]string),
+	}
+}
+
+func (r *memPaymentRepo) GetUserStripeCustomerID(ctx context.Context, userID int64) (string, error) {
+	return r.customers[userID], nil
+}
+
+func (r *memPaymentRepo) GetPaymentMethodByID(ctx context.Context, userID, paymentMethodID int64) (*models.UserPaymentMethod, error) {
+	for _, m := range r.methods {
+		if m.ID == paymentMethodID && m.UserID == userID && m.IsActive {
+			copied := *m
+			return &copied, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r *memPaymentRepo) GetDefaultPaymentMethod(ctx context.Context, userID int64) (*models.UserPaymentMethod, error) {
+	for _, m := range r.methods {
+		if m.UserID == userID && m.IsDefault && m.IsActive {
+			copied := *m
+			return &copied, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r *memPaymentRepo) SavePayment(ctx context.Context, p *models.Payment) error {
+	if p.CaptureMethod == "" {
+		p.CaptureMethod = models.PaymentCaptureAutomatic
+	}
+	if p.AuthorizationStatus == "" {
+		p.AuthorizationStatus = models.AuthorizationStatusNone
+	}
+	if p.CaptureStatus == "" {
+		p.CaptureStatus = models.CaptureStatusNone
+	}
+	if p.PaymentType == "" {
+		p.PaymentType = models.PaymentTypeCharge
+	}
+	p.ID = int64(len(r.payments) + 1)
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+
+	copied := *p
+	r.payments = append(r.payments, &copied)
+	return nil
+}
+
+// payment возвращает сохраненную запись без копирования - для проверок в тестах
+func (r *memPaymentRepo) payment(t *testing.T, paymentIntentID string) *models.Payment {
+	t.Helper()
+	for _, p := range r.payments {
+		if p.StripePaymentIntentID == paymentIntentID {
+			return p
+		}
+	}
+	t.Fatalf("payment %s was not saved", paymentIntentID)
+	return nil
+}
+
+func (r *memPaymentRepo) find(paymentID int64) *models.Payment {
+	for _, p := range r.payments {
+		if p.ID == paymentID {
+			return p
+		}
+	}
+	return nil
+}
+
+func (r *memPaymentRepo) GetPaymentByStripeIntentID(ctx context.Context, stripePaymentIntentID string) (*models.Payment, error) {
+	for _, p := range r.payments {
+		if p.StripePaymentIntentID == stripePaymentIntentID {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r *memPaymentRepo) GetPaymentByID(ctx context.Context, paymentID int64) (*models.Payment, error) {
+	p := r.find(paymentID)
+	if p == nil {
+		return nil, nil
+	}
+	copied := *p
+	return &copied, nil
+}
+
+func (r *memPaymentRepo) UpdatePaymentStatus(ctx context.Context, paymentID int64, status, failureReason string) error {
+	if p := r.find(paymentID); p != nil {
+		p.Status = status
+		p.FailureReason = failureReason
+	}
+	return nil
+}
+
+func (r *memPaymentRepo) GetJobEscrowPayment(ctx context.Context, jobID int64) (*models.Payment, error) {
+	for i := len(r.payments) - 1; i >= 0; i-- {
+		p := r.payments[i]
+		if p.JobID != nil && *p.JobID == jobID && p.CaptureMethod == models.PaymentCaptureManual &&
+			p.AuthorizationStatus != models.AuthorizationStatusReplaced {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memPaymentRepo) UpdateAuthorizationState(ctx context.Context, paymentID int64, authorizationStatus, captureStatus string, authorizedAt, expiresAt *time.Time) error {
+	if p := r.find(paymentID); p != nil {
+		p.AuthorizationStatus = authorizationStatus
+		p.CaptureStatus = captureStatus
+		if authorizedAt != nil {
+			p.AuthorizedAt = authorizedAt
+		}
+		if expiresAt != nil {
+			p.AuthorizationExpiresAt = expiresAt
+		}
+	}
+	return nil
+}
+
+func (r *memPaymentRepo) MarkPaymentCaptured(ctx context.Context, paymentID, amountCapturedCents int64, status string) error {
+	if p := r.find(paymentID); p != nil {
+		now := time.Now()
+		p.Status = status
+		p.CaptureStatus = models.CaptureStatusCaptured
+		p.AmountCapturedCents = amountCapturedCents
+		p.CapturedAt = &now
+	}
+	return nil
+}
+
+func (r *memPaymentRepo) MarkPaymentReleased(ctx context.Context, paymentID int64, status, authorizationStatus string) error {
+	if p := r.find(paymentID); p != nil {
+		now := time.Now()
+		p.Status = status
+		p.AuthorizationStatus = authorizationStatus
+		p.CaptureStatus = models.CaptureStatusCanceled
+		p.ReleasedAt = &now
+	}
+	return nil
+}
+
+func (r *memPaymentRepo) UpdatePaymentRefund(ctx context.Context, paymentID, amountRefundedCents int64, fullyRefunded bool) error {
+	if p := r.find(paymentID); p != nil {
+		p.AmountRefundedCents = amountRefundedCents
+		if fullyRefunded {
+			if p.CaptureMethod == models.PaymentCaptureManual {
+				p.CaptureStatus = models.CaptureStatusRefunded
+			}
+			if p.RefundedAt == nil {
+				now := time.Now()
+				p.RefundedAt = &now
+			}
+		}
+	}
+	return nil
+}
+
+func (r *memPaymentRepo) ApplySucceededRefunds(ctx context.Context, paymentID, refundableCents int64) (int64, int64, error) {
+	p := r.find(paymentID)
+	if p == nil {
+		return 0, 0, pgx.ErrNoRows
+	}
+
+	var succeeded int64
+	for _, stored := range r.refunds {
+		if stored.PaymentID == paymentID && stored.Status == models.RefundStatusSucceeded {
+			succeeded += stored.AmountCents
+		}
+	}
+
+	previous := p.AmountRefundedCents
+	if succeeded <= previous {
+		return previous, previous, nil
+	}
+	return previous, succeeded, r.UpdatePaymentRefund(ctx, paymentID, succeeded, succeeded >= refundableCents)
+}
+
+func (r *memPaymentRepo) ReserveRefund(ctx context.Context, refund *models.PaymentRefund, refundableCents int64) (bool, int64, error) {
+	p := r.find(refund.PaymentID)
+	if p == nil {
+		return false, 0, pgx.ErrNoRows
+	}
+
+	remaining := refundableCents - max(r.reservedRefundCents(refund.PaymentID), p.AmountRefundedCents)
+	if remaining <= 0 || refund.AmountCents > remaining {
+		return false, remaining, nil
+	}
+	if refund.AmountCents == 0 {
+		refund.AmountCents = remaining
+	}
+
+	r.saveRefund(refund)
+	return true, remaining - refund.AmountCents, nil
+}
+
+func (r *memPaymentRepo) saveRefund(refund *models.PaymentRefund) {
+	refund.ID = int64(len(r.refunds) + 1)
+	refund.CreatedAt = time.Now()
+	refund.UpdatedAt = refund.CreatedAt
+
+	copied := *refund
+	r.refunds = append(r.refunds, &copied)
+}
+
+func (r *memPaymentRepo) UpdateRefund(ctx context.Context, refund *models.PaymentRefund) error {
+	for _, stored := range r.refunds {
+		if stored.ID == refund.ID {
+			if refund.StripeRefundID != nil {
+				stored.StripeRefundID = refund.StripeRefundID
+			}
+			stored.Status = refund.Status
+			stored.FailureReason = refund.FailureReason
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (r *memPaymentRepo) UpsertStripeRefund(ctx context.Context, refund *models.PaymentRefund) error {
+	for _, stored := range r.refunds {
+		if stored.StripeRefundID != nil && refund.StripeRefundID != nil && *stored.StripeRefundID == *refund.StripeRefundID {
+			stored.Status = refund.Status
+			stored.FailureReason = refund.FailureReason
+			refund.ID = stored.ID
+			return nil
+		}
+	}
+	r.saveRefund(refund)
+	return nil
+}
+
+func (r *memPaymentRepo) GetRefundByID(ctx context.Context, refundID int64) (*models.PaymentRefund, error) {
+	for _, stored := range r.refunds {
+		if stored.ID == refundID {
+			copied := *stored
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memPaymentRepo) GetRefundByStripeID(ctx context.Context, stripeRefundID string) (*models.PaymentRefund, error) {
+	for _, stored := range r.refunds {
+		if stored.StripeRefundID != nil && *stored.StripeRefundID == stripeRefundID {
+			copied := *stored
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// reservedRefundCents - сумма возвратов по платежу, которые выполнены или еще выполняются
+func (r *memPaymentRepo) reservedRefundCents(paymentID int64) int64 {
+	var total int64
+	for _, stored := range r.refunds {
+		switch stored.Status {
+		case models.RefundStatusPending, models.RefundStatusRequiresAction, models.RefundStatusSucceeded:
+			if stored.PaymentID == paymentID {
+				total += stored.AmountCents
+			}
+		}
+	}
+	return total
+}
+
+func (r *memPaymentRepo) GetJobStatus(ctx context.Context, jobID int64) (string, error) {
+	status, ok := r.jobStatuses[jobID]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return status, nil
+}
+
+func (r *memPaymentRepo) SaveStripeEvent(ctx context.Context, event *models.StripeEvent) (bool, error) {
+	if _, ok := r.events[event.StripeEventID]; ok {
+		return false, nil
+	}
+	event.ID = int64(len(r.events) + 1)
+	event.Status = models.StripeEventStatusReceived
+	event.ReceivedAt = time.Now()
+
+	copied := *event
+	r.events[event.StripeEventID] = &copied
+	return true, nil
+}
+
+func (r *memPaymentRepo) ClaimStripeEvent(ctx context.Context, stripeEventID string) (bool, error) {
+	event, ok := r.events[stripeEventID]
+	if !ok || (event.Status != models.StripeEventStatusReceived && event.Status != models.StripeEventStatusFailed) {
+		return false, nil
+	}
+	event.Status = models.StripeEventStatusProcessing
+	event.Attempts++
+	return true, nil
+}
+
+func (r *memPaymentRepo) FinishStripeEvent(ctx context.Context, stripeEventID, status, lastError string) error {
+	if event, ok := r.events[stripeEventID]; ok {
+		event.Status = status
+		event.LastError = nil
+		if lastError != "" {
+			event.LastError = &lastError
+		}
+	}
+	return nil
+}
+
+func (r *memPaymentRepo) UpdateUserStripeCustomerID(ctx context.Context, userID int64, customerID string) error {
+	return errNotStubbed
+}
+
+func (r *memPaymentRepo) SavePaymentMethod(ctx context.Context, paymentMethod *models.UserPaymentMethod) error {
+	return errNotStubbed
+}
+
+func (r *memPaymentRepo) GetUserPaymentMethods(ctx context.Context, userID int64) ([]models.UserPaymentMethod, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetPaymentMethodByStripeID(ctx context.Context, userID int64, stripePaymentMethodID string) (*models.UserPaymentMethod, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) DeletePaymentMethod(ctx context.Context, userID, paymentMethodID int64) error {
+	return errNotStubbed
+}
+
+func (r *memPaymentRepo) SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID int64) error {
+	return errNotStubbed
+}
+
+func (r *memPaymentRepo) DeactivatePaymentMethodByStripeID(ctx context.Context, stripePaymentMethodID string) error {
+	return errNotStubbed
+}
+
+func (r *memPaymentRepo) UpdatePaymentMethodCard(ctx context.Context, stripePaymentMethodID, brand, last4 string, expMonth, expYear int) error {
+	return errNotStubbed
+}
+
+func (r *memPaymentRepo) UpdateBankAccountVerification(ctx context.Context, setupIntentID, status, mandateID string) (*models.UserPaymentMethod, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetUserPayments(ctx context.Context, userID int64, limit, offset int) ([]models.Payment, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetPaymentsCreatedBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetExpiringAuthorizations(ctx context.Context, before time.Time, limit int) ([]models.Payment, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) SaveDispute(ctx context.Context, dispute *models.PaymentDispute) error {
+	return errNotStubbed
+}
+
+func (r *memPaymentRepo) GetPaymentRefunds(ctx context.Context, paymentIDs []int64) ([]models.PaymentRefund, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetJobTip(ctx context.Context, jobID int64) (*models.Payment, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetUnconfirmedJobTips(ctx context.Context, jobID int64) ([]models.Payment, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetJobParticipants(ctx context.Context, jobID int64) (*models.JobParticipants, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetStripeEvent(ctx context.Context, stripeEventID string) (*models.StripeEvent, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPaymentRepo) GetStripeEventsByStatus(ctx context.Context, status string, limit int) ([]models.StripeEvent, error) {
+	return nil, errNotStubbed
+}
+
+// memEscrowCaptureRepo - списания escrow выполненных работ
+type memEscrowCaptureRepo struct {
+	captures map[int64]*models.EscrowCapture
+	// contractors - подрядчик работы, которую можно отметить выполненной
+	contractors map[int64]int64
+}
+
+var _ escrow_capture.EscrowCaptureRepository = (*memEscrowCaptureRepo)(nil)
+
+func newMemEscrowCaptureRepo() *memEscrowCaptureRepo {
+	return &memEscrowCaptureRepo{
+		captures:    make(map[int64]*models.EscrowCapture),
+		contractors: make(map[int64]int64),
+	}
+}
+
+func (r *memEscrowCaptureRepo) CompleteJob(ctx context.Context, jobID, executorID int64, confirmBy time.Time) (*models.EscrowCapture, error) {
+	contractorID, ok := r.contractors[jobID]
+	if !ok {
+		return nil, fmt.Errorf("job not found")
+	}
+	if _, ok := r.captures[jobID]; ok {
+		return nil, fmt.Errorf("job is already completed")
+	}
+
+	now := time.Now()
+	r.captures[jobID] = &models.EscrowCapture{
+		JobID:         jobID,
+		ContractorID:  contractorID,
+		ExecutorID:    executorID,
+		Status:        models.EscrowCaptureAwaitingConfirmation,
+		ConfirmBy:     confirmBy,
+		NextAttemptAt: confirmBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	return r.copy(jobID), nil
+}
+
+func (r *memEscrowCaptureRepo) copy(jobID int64) *models.EscrowCapture {
+	copied := *r.captures[jobID]
+	return &copied
+}
+
+func (r *memEscrowCaptureRepo) GetEscrowCapture(ctx context.Context, jobID int64) (*models.EscrowCapture, error) {
+	if _, ok := r.captures[jobID]; !ok {
+		return nil, nil
+	}
+	return r.copy(jobID), nil
+}
+
+func (r *memEscrowCaptureRepo) ConfirmEscrowCapture(ctx context.Context, jobID int64, fromStatus string, lease time.Duration) (*models.EscrowCapture, error) {
+	c, ok := r.captures[jobID]
+	if !ok || c.Status != fromStatus {
+		return nil, nil
+	}
+	now := time.Now()
+	if c.Status == models.EscrowCaptureFailed {
+		c.Attempts = 0
+	}
+	c.Status = models.EscrowCapturePending
+	if c.ConfirmedAt == nil {
+		c.ConfirmedAt = &now
+	}
+	c.NextAttemptAt = now.Add(lease)
+	return r.copy(jobID), nil
+}
+
+func (r *memEscrowCaptureRepo) DisputeEscrowCapture(ctx context.Context, jobID int64, reason string) (*models.EscrowCapture, error) {
+	c, ok := r.captures[jobID]
+	if !ok || c.Status != models.EscrowCaptureAwaitingConfirmation {
+		return nil, nil
+	}
+	now := time.Now()
+	c.Status = models.EscrowCaptureDisputed
+	c.DisputedAt = &now
+	c.DisputeReason = reason
+	return r.copy(jobID), nil
+}
+
+func (r *memEscrowCaptureRepo) ClaimDueEscrowCaptures(ctx context.Context, lease time.Duration, limit int) ([]models.EscrowCapture, error) {
+	now := time.Now()
+	var claimed []models.EscrowCapture
+	for jobID, c := range r.captures {
+		due := (c.Status == models.EscrowCapturePending && !c.NextAttemptAt.After(now)) ||
+			(c.Status == models.EscrowCaptureAwaitingConfirmation && !c.ConfirmBy.After(now))
+		if !due || len(claimed) == limit {
+			continue
+		}
+		c.AutoConfirmed = c.AutoConfirmed || c.Status == models.EscrowCaptureAwaitingConfirmation
+		c.Status = models.EscrowCapturePending
+		if c.ConfirmedAt == nil {
+			c.ConfirmedAt = &now
+		}
+		c.NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, *r.copy(jobID))
+	}
+	return claimed, nil
+}
+
+func (r *memEscrowCaptureRepo) UpdateEscrowCapture(ctx context.Context, capture *models.EscrowCapture) error {
+	c, ok := r.captures[capture.JobID]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	c.Status = capture.Status
+	c.Attempts = capture.Attempts
+	c.NextAttemptAt = capture.NextAttemptAt
+	c.LastError = capture.LastError
+	c.CapturedAt = capture.CapturedAt
+	return nil
+}
+
+// memPromoRepo - промокоды, скидки на сбор и кредиты
+type memPromoRepo struct {
+	codes       []*models.PromoCode
+	redemptions []*models.FeeRedemption
+	credits     map[int64]int64
+	// userJobs - сколько других работ у пользователя (для промокодов на первую работу)
+	userJobs map[int64]int
+
+	// failGetJobRedemption - ошибка следующего GetJobRedemption
+	failGetJobRedemption error
+}
+
+var _ promo.PromoRepository = (*memPromoRepo)(nil)
+
+func newMemPromoRepo() *memPromoRepo {
+	return &memPromoRepo{
+		credits:  make(map[int64]int64),
+		userJobs: make(map[int64]int),
+	}
+}
+
+func (r *memPromoRepo) addCode(code *models.PromoCode) *models.PromoCode {
+	code.ID = int64(len(r.codes) + 1)
+	code.IsActive = true
+	r.codes = append(r.codes, code)
+	return code
+}
+
+func (r *memPromoRepo) GetPromoCodeByCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	for _, c := range r.codes {
+		if c.Code == code {
+			copied := *c
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memPromoRepo) countApplied(promoCodeID, userID int64) (total, byUser int) {
+	for _, red := range r.redemptions {
+		if red.PromoCodeID != nil && *red.PromoCodeID == promoCodeID && red.Status == models.FeeRedemptionApplied {
+			total++
+			if red.UserID == userID {
+				byUser++
+			}
+		}
+	}
+	return total, byUser
+}
+
+func (r *memPromoRepo) CountRedemptions(ctx context.Context, promoCodeID, userID int64) (int, int, error) {
+	total, byUser := r.countApplied(promoCodeID, userID)
+	return total, byUser, nil
+}
+
+func (r *memPromoRepo) CountUserJobs(ctx context.Context, userID, excludeJobID int64) (int, error) {
+	return r.userJobs[userID], nil
+}
+
+func (r *memPromoRepo) CreateRedemption(ctx context.Context, redemption *models.FeeRedemption, code *models.PromoCode) (bool, error) {
+	if code != nil {
+		total, byUser := r.countApplied(code.ID, redemption.UserID)
+		if (code.MaxRedemptions != nil && total >= *code.MaxRedemptions) ||
+			(code.PerUserLimit != nil && byUser >= *code.PerUserLimit) {
+			return false, nil
+		}
+	}
+	if redemption.CreditsAppliedCents > r.credits[redemption.UserID] {
+		return false, nil
+	}
+	r.credits[redemption.UserID] -= redemption.CreditsAppliedCents
+
+	redemption.ID = int64(len(r.redemptions) + 1)
+	redemption.Status = models.FeeRedemptionApplied
+	redemption.CreatedAt = time.Now()
+
+	copied := *redemption
+	r.redemptions = append(r.redemptions, &copied)
+	return true, nil
+}
+
+func (r *memPromoRepo) ReverseJobRedemption(ctx context.Context, jobID int64) (*models.FeeRedemption, error) {
+	for _, red := range r.redemptions {
+		if red.JobID != nil && *red.JobID == jobID && red.Status == models.FeeRedemptionApplied {
+			now := time.Now()
+			red.Status = models.FeeRedemptionReversed
+			red.ReversedAt = &now
+			r.credits[red.UserID] += red.CreditsAppliedCents
+
+			copied := *red
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memPromoRepo) GetJobRedemption(ctx context.Context, jobID int64) (*models.FeeRedemption, error) {
+	if err := r.failGetJobRedemption; err != nil {
+		r.failGetJobRedemption = nil
+		return nil, err
+	}
+	for _, red := range r.redemptions {
+		if red.JobID != nil && *red.JobID == jobID && red.Status == models.FeeRedemptionApplied {
+			copied := *red
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memPromoRepo) GetCreditBalance(ctx context.Context, userID int64) (int64, error) {
+	return r.credits[userID], nil
+}
+
+func (r *memPromoRepo) CreatePromoCode(ctx context.Context, promo *models.PromoCode) error {
+	return errNotStubbed
+}
+
+func (r *memPromoRepo) UpdatePromoCode(ctx context.Context, promo *models.PromoCode) error {
+	return errNotStubbed
+}
+
+func (r *memPromoRepo) GetPromoCodeByID(ctx context.Context, id int64) (*models.PromoCode, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPromoRepo) ListPromoCodes(ctx context.Context, limit, offset int) ([]models.PromoCode, int, error) {
+	return nil, 0, errNotStubbed
+}
+
+func (r *memPromoRepo) GetPromoRedemptions(ctx context.Context, promoCodeID int64, limit, offset int) ([]models.FeeRedemption, int, error) {
+	return nil, 0, errNotStubbed
+}
+
+func (r *memPromoRepo) GetCreditTransactions(ctx context.Context, userID int64, limit int) ([]models.CreditTransaction, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPromoRepo) AdjustCredits(ctx context.Context, txn *models.CreditTransaction) (bool, error) {
+	return false, errNotStubbed
+}
+
+// memJobFees - сохраненный в работах сбор
+type memJobFees map[int64]models.FeeBreakdown
+
+func (f memJobFees) UpdateJobFeeBreakdown(ctx context.Context, jobID int64, breakdown *models.FeeBreakdown) error {
+	f[jobID] = *breakdown
+	return nil
+}
+
+// memJobPostingRepo - outbox публикации работ; jobs - статусы работ
+type memJobPostingRepo struct {
+	postings map[int64]*models.JobPosting
+	jobs     map[int64]string
+
+	// failFailJobPosting - ошибка следующего FailJobPosting
+	failFailJobPosting error
+}
+
+var _ job_posting.JobPostingRepository = (*memJobPostingRepo)(nil)
+
+func newMemJobPostingRepo() *memJobPostingRepo {
+	return &memJobPostingRepo{
+		postings: make(map[int64]*models.JobPosting),
+		jobs:     make(map[int64]string),
+	}
+}
+
+func (r *memJobPostingRepo) UpdateJobPosting(ctx context.Context, posting *models.JobPosting) error {
+	copied := *posting
+	copied.UpdatedAt = time.Now()
+	r.postings[posting.ID] = &copied
+	return nil
+}
+
+func (r *memJobPostingRepo) CompleteJobPosting(ctx context.Context, posting *models.JobPosting) (bool, error) {
+	if r.jobs[*posting.JobID] != models.JobStatusPendingPayment {
+		return false, nil
+	}
+	r.jobs[*posting.JobID] = "active"
+
+	posting.Status = models.JobPostingCompleted
+	posting.LastError = ""
+	return true, r.UpdateJobPosting(ctx, posting)
+}
+
+func (r *memJobPostingRepo) FailJobPosting(ctx context.Context, posting *models.JobPosting) error {
+	if err := r.failFailJobPosting; err != nil {
+		r.failFailJobPosting = nil
+		return err
+	}
+	if posting.JobID != nil && r.jobs[*posting.JobID] == models.JobStatusPendingPayment {
+		delete(r.jobs, *posting.JobID)
+	}
+
+	posting.Status = models.JobPostingFailed
+	return r.UpdateJobPosting(ctx, posting)
+}
+
+func (r *memJobPostingRepo) CreateJobPosting(ctx context.Context, job *models.Job, posting *models.JobPosting) error {
+	return errNotStubbed
+}
+
+func (r *memJobPostingRepo) GetJobPostingByJobID(ctx context.Context, userID, jobID int64) (*models.JobPosting, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memJobPostingRepo) ClaimDueJobPostings(ctx context.Context, lease time.Duration, limit int) ([]models.JobPosting, error) {
+	return nil, errNotStubbed
+}
+
+// memPayoutRepo - Connect-аккаунты исполнителей и журнал выплат
+type memPayoutRepo struct {
+	accounts map[int64]*models.ConnectAccount
+	payouts  []*models.Payout
+}
+
+var _ payout.PayoutRepository = (*memPayoutRepo)(nil)
+
+func newMemPayoutRepo() *memPayoutRepo {
+	return &memPayoutRepo{accounts: make(map[int64]*models.ConnectAccount)}
+}
+
+// add записывает выплату в статусе status, последний раз измененную updatedAt
+func (r *memPayoutRepo) add(userID, netCents int64, currency, status string, updatedAt time.Time) *models.Payout {
+	p := &models.Payout{
+		ID:               int64(len(r.payouts) + 1),
+		UserID:           userID,
+		GrossAmountCents: netCents,
+		NetAmountCents:   netCents,
+		Currency:         currency,
+		Status:           status,
+		CreatedAt:        updatedAt,
+		UpdatedAt:        updatedAt,
+	}
+	r.payouts = append(r.payouts, p)
+	return p
+}
+
+func (r *memPayoutRepo) GetConnectAccount(ctx context.Context, userID int64) (*models.ConnectAccount, error) {
+	if a, ok := r.accounts[userID]; ok {
+		copied := *a
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *memPayoutRepo) SaveConnectAccount(ctx context.Context, account *models.ConnectAccount) error {
+	copied := *account
+	r.accounts[account.UserID] = &copied
+	return nil
+}
+
+func (r *memPayoutRepo) CreatePayout(ctx context.Context, p *models.Payout) (bool, error) {
+	for _, stored := range r.payouts {
+		if p.PaymentID != nil && stored.PaymentID != nil && *stored.PaymentID == *p.PaymentID {
+			return false, nil
+		}
+	}
+
+	p.ID = int64(len(r.payouts) + 1)
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	copied := *p
+	r.payouts = append(r.payouts, &copied)
+	return true, nil
+}
+
+func (r *memPayoutRepo) GetPaymentPayout(ctx context.Context, paymentID int64) (*models.Payout, error) {
+	for _, stored := range r.payouts {
+		if stored.PaymentID != nil && *stored.PaymentID == paymentID {
+			copied := *stored
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memPayoutRepo) UpdatePayoutAmounts(ctx context.Context, p *models.Payout, expectedGrossCents int64) (bool, error) {
+	for _, stored := range r.payouts {
+		if stored.ID != p.ID {
+			continue
+		}
+		if stored.GrossAmountCents != expectedGrossCents ||
+			stored.Status != models.PayoutStatusPending && stored.Status != models.PayoutStatusCanceled {
+			return false, nil
+		}
+		stored.GrossAmountCents = p.GrossAmountCents
+		stored.CommissionCents = p.CommissionCents
+		stored.NetAmountCents = p.NetAmountCents
+		stored.Status = p.Status
+		stored.UpdatedAt = time.Now()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *memPayoutRepo) GetUserPayouts(ctx context.Context, userID int64, limit, offset int) ([]models.Payout, int, error) {
+	return nil, 0, errNotStubbed
+}
+
+func (r *memPayoutRepo) GetPayoutBalance(ctx context.Context, userID int64) (int64, int64, error) {
+	return 0, 0, errNotStubbed
+}
+
+func (r *memPayoutRepo) GetUsersWithPendingPayouts(ctx context.Context, staleAfter time.Duration) ([]int64, error) {
+	var userIDs []int64
+	for _, p := range r.payouts {
+		if r.claimable(p, staleAfter) && !slices.Contains(userIDs, p.UserID) {
+			userIDs = append(userIDs, p.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+func (r *memPayoutRepo) GetEarningsLines(ctx context.Context, userID int64, from, to time.Time) ([]models.EarningsLine, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memPayoutRepo) ClaimPendingPayouts(ctx context.Context, userID int64) ([]models.Payout, error) {
+	var claimed []models.Payout
+	for _, p := range r.payouts {
+		if p.UserID == userID && p.Status == models.PayoutStatusPending {
+			p.Status = models.PayoutStatusProcessing
+			p.UpdatedAt = time.Now()
+			claimed = append(claimed, *p)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *memPayoutRepo) ReclaimStalePayouts(ctx context.Context, userID int64, staleAfter time.Duration) ([]models.Payout, error) {
+	var claimed []models.Payout
+	for _, p := range r.payouts {
+		if p.UserID == userID && p.Status == models.PayoutStatusProcessing && r.claimable(p, staleAfter) {
+			p.UpdatedAt = time.Now()
+			claimed = append(claimed, *p)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *memPayoutRepo) claimable(p *models.Payout, staleAfter time.Duration) bool {
+	return p.Status == models.PayoutStatusPending ||
+		p.Status == models.PayoutStatusProcessing && p.UpdatedAt.Before(time.Now().Add(-staleAfter))
+}
+
+func (r *memPayoutRepo) MarkPayoutsTransferred(ctx context.Context, payoutIDs []int64, stripeTransferID string) error {
+	now := time.Now()
+	for _, p := range r.payouts {
+		if slices.Contains(payoutIDs, p.ID) {
+			p.Status = models.PayoutStatusTransferred
+			p.StripeTransferID = &stripeTransferID
+			p.FailureReason = nil
+			p.TransferredAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memPayoutRepo) ReleaseClaimedPayouts(ctx context.Context, payoutIDs []int64, failureReason string) error {
+	for _, p := range r.payouts {
+		if slices.Contains(payoutIDs, p.ID) && p.Status == models.PayoutStatusProcessing {
+			p.Status = models.PayoutStatusPending
+			p.FailureReason = nil
+			if failureReason != "" {
+				p.FailureReason = &failureReason
+			}
+		}
+	}
+	return nil
+}
+
+// stubPaymentService записывает списания и снятия блокировок escrow.
+// captureErrs - ошибки следующих списаний по порядку
+type stubPaymentService struct {
+	captured    []int64
+	released    []int64
+	captureErrs []error
+}
+
+var _ PaymentService = (*stubPaymentService)(nil)
+
+func (s *stubPaymentService) CaptureJobPayment(ctx context.Context, jobID int64) (*models.Payment, error) {
+	if len(s.captureErrs) > 0 {
+		err := s.captureErrs[0]
+		s.captureErrs = s.captureErrs[1:]
+		return nil, err
+	}
+	s.captured = append(s.captured, jobID)
+	return &models.Payment{JobID: &jobID, CaptureMethod: models.PaymentCaptureManual, CaptureStatus: models.CaptureStatusCaptured}, nil
+}
+
+func (s *stubPaymentService) ReleaseJobPayment(ctx context.Context, jobID int64) (*models.Payment, error) {
+	s.released = append(s.released, jobID)
+	return &models.Payment{JobID: &jobID, CaptureMethod: models.PaymentCaptureManual, CaptureStatus: models.CaptureStatusCanceled}, nil
+}
+
+func (s *stubPaymentService) AddPaymentMethod(ctx context.Context, userID int64, paymentMethodID string) (*models.UserPaymentMethod, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) GetUserPaymentMethods(ctx context.Context, userID int64) ([]models.UserPaymentMethod, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) DeletePaymentMethod(ctx context.Context, userID, paymentMethodID int64) error {
+	return errNotStubbed
+}
+
+func (s *stubPaymentService) SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID int64) error {
+	return errNotStubbed
+}
+
+func (s *stubPaymentService) GetDefaultPaymentMethod(ctx context.Context, userID int64) (*models.UserPaymentMethod, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) CreateBankAccountSetupIntent(ctx context.Context, userID int64) (*models.BankAccountSetupResponse, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) AddBankAccount(ctx context.Context, userID int64, setupIntentID string) (*models.UserPaymentMethod, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) EnsureStripeCustomer(ctx context.Context, userID int64) (string, error) {
+	return "", errNotStubbed
+}
+
+func (s *stubPaymentService) GetOrCreateStripeCustomer(ctx context.Context, userID int64, email, name string) (string, error) {
+	return "", errNotStubbed
+}
+
+func (s *stubPaymentService) CreatePayment(ctx context.Context, userID int64, req *models.CreatePaymentRequest) (*models.CreatePaymentResponse, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) ConfirmPayment(ctx context.Context, paymentIntentID string) (*models.ConfirmPaymentResponse, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) GetUserPayments(ctx context.Context, userID int64, limit, offset int) ([]models.Payment, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) AuthorizeJobPayment(ctx context.Context, userID int64, req *models.CreatePaymentRequest) (*models.CreatePaymentResponse, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) VoidPayment(ctx context.Context, paymentIntentID string) error {
+	return errNotStubbed
+}
+
+func (s *stubPaymentService) ReauthorizeExpiringAuthorizations(ctx context.Context) (int, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubPaymentService) StartAuthorizationRenewer(ctx context.Context, interval time.Duration) {}
+
+func (s *stubPaymentService) RefundPayment(ctx context.Context, actorID int64, isAdmin bool, paymentID int64, req *models.CreateRefundRequest) (*models.PaymentRefund, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) GetPaymentRefunds(ctx context.Context, actorID int64, isAdmin bool, paymentID int64) ([]models.PaymentRefund, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) TipJob(ctx context.Context, userID, jobID int64, req *models.CreateTipRequest) (*models.CreatePaymentResponse, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPaymentService) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	return errNotStubbed
+}
+
+func (s *stubPaymentService) ReplayStripeEvent(ctx context.Context, stripeEventID string) error {
+	return errNotStubbed
+}
+
+func (s *stubPaymentService) ReplayFailedStripeEvents(ctx context.Context, limit int) (int, int, error) {
+	return 0, 0, errNotStubbed
+}
+
+func (s *stubPaymentService) ApplyPaymentIntent(ctx context.Context, payment *models.Payment, pi *stripe.PaymentIntent) error {
+	return errNotStubbed
+}
+
+func (s *stubPaymentService) OnPaymentSucceeded(handler PaymentSucceededHandler) {}
+
+// stubPayoutService записывает начисленные выплаты; err - ошибка следующего начисления
+type stubPayoutService struct {
+	recorded []int64
+	err      error
+}
+
+var _ PayoutService = (*stubPayoutService)(nil)
+
+func (s *stubPayoutService) RecordJobPayout(ctx context.Context, jobID, executorID int64, p *models.Payment) (*models.Payout, error) {
+	if err := s.err; err != nil {
+		s.err = nil
+		return nil, err
+	}
+	s.recorded = append(s.recorded, jobID)
+	return &models.Payout{}, nil
+}
+
+func (s *stubPayoutService) RecordTipPayout(ctx context.Context, p *models.Payment) (*models.Payout, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPayoutService) ReducePayoutForRefund(ctx context.Context, refund *models.PaymentRefund) error {
+	return errNotStubbed
+}
+
+func (s *stubPayoutService) RestorePayoutForRefund(ctx context.Context, refund *models.PaymentRefund) error {
+	return errNotStubbed
+}
+
+func (s *stubPayoutService) StartOnboarding(ctx context.Context, userID int64, req *models.ConnectOnboardingRequest) (*models.ConnectOnboardingResponse, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPayoutService) GetConnectAccount(ctx context.Context, userID int64) (*models.ConnectAccount, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubPayoutService) ProcessUserPayouts(ctx context.Context, userID int64) (int64, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubPayoutService) ProcessPendingPayouts(ctx context.Context) (int, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubPayoutService) StartPayoutProcessor(ctx context.Context, interval time.Duration) {}
+
+func (s *stubPayoutService) GetUserPayouts(ctx context.Context, userID int64, limit, offset int) ([]models.Payout, int, error) {
+	return nil, 0, errNotStubbed
+}
+
+func (s *stubPayoutService) GetPayoutBalance(ctx context.Context, userID int64) (*models.PayoutBalance, error) {
+	return nil, errNotStubbed
+}
+
+// stubAdminService отдает список администраторов для уведомлений и системные настройки
+type stubAdminService struct {
+	adminIDs []int64
+	settings *models.SystemSettings
+}
+
+var _ AdminService = (*stubAdminService)(nil)
+
+func (s *stubAdminService) GetAdminUserIDs(ctx context.Context) ([]int64, error) {
+	return s.adminIDs, nil
+}
+
+func (s *stubAdminService) GetUserCount(ctx context.Context) (int, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubAdminService) GetPendingUsersCount(ctx context.Context) (int, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubAdminService) GetChatConversationCount(ctx context.Context) (int, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubAdminService) GetActiveJobsCount(ctx context.Context) (int, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubAdminService) GetUsersList(ctx context.Context, limit, offset int) ([]models.UserCompanyInfo, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubAdminService) GetUsersListTotal(ctx context.Context) (int, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubAdminService) GetJobsList(ctx context.Context, limit, offset int, statuses []string) ([]models.JobManagementInfo, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubAdminService) GetJobsListTotal(ctx context.Context, statuses []string) (int, error) {
+	return 0, errNotStubbed
+}
+
+func (s *stubAdminService) ChangeUserStatus(ctx context.Context, userID int, newStatus string) error {
+	return errNotStubbed
+}
+
+func (s *stubAdminService) GetUserRole(ctx context.Context, userID int64) (string, error) {
+	return "", errNotStubbed
+}
+
+func (s *stubAdminService) ChangeVerificationFileStatus(ctx context.Context, fileID int, newStatus string) error {
+	return errNotStubbed
+}
+
+func (s *stubAdminService) GetUserFullInfo(ctx context.Context, userID int64) (*models.UserFullInfo, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubAdminService) GetPlatformAnalytics(ctx context.Context, days int) (*models.PlatformAnalytics, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubAdminService) GetSystemSettings(ctx context.Context) (*models.SystemSettings, error) {
+	if s.settings == nil {
+		return nil, errNotStubbed
+	}
+	return s.settings, nil
+}
+
+func (s *stubAdminService) UpdateSystemSettings(ctx context.Context, settings *models.SystemSettings) error {
+	return errNotStubbed
+}
+
+// memLedgerRepo - проведенные транзакции журнала по источнику
+type memLedgerRepo struct {
+	transactions map[string]models.LedgerTransaction
+}
+
+var _ ledger.LedgerRepository = (*memLedgerRepo)(nil)
+
+func newMemLedgerRepo() *memLedgerRepo {
+	return &memLedgerRepo{transactions: make(map[string]models.LedgerTransaction)}
+}
+
+// balance - дебет минус кредит счета по всем транзакциям
+func (r *memLedgerRepo) balance(accountCode string) int64 {
+	var balance int64
+	for _, txn := range r.transactions {
+		for _, e := range txn.Entries {
+			if e.AccountCode == accountCode {
+				balance += e.DebitCents - e.CreditCents
+			}
+		}
+	}
+	return balance
+}
+
+func (r *memLedgerRepo) PostTransaction(ctx context.Context, txn *models.LedgerTransaction) (bool, error) {
+	key := txn.ReferenceType + "/" + txn.ReferenceID
+	if _, ok := r.transactions[key]; ok {
+		return false, nil
+	}
+	txn.ID = int64(len(r.transactions) + 1)
+	r.transactions[key] = *txn
+	return true, nil
+}
+
+func (r *memLedgerRepo) GetAccountBalance(ctx context.Context, accountCode string) (*models.LedgerAccountBalance, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memLedgerRepo) GetAccountEntries(ctx context.Context, accountCode string, limit, offset int) ([]models.LedgerEntry, int, error) {
+	return nil, 0, errNotStubbed
+}
+
+func (r *memLedgerRepo) GetTrialBalance(ctx context.Context) ([]models.LedgerAccountBalance, error) {
+	return nil, errNotStubbed
+}
+
+func (r *memLedgerRepo) GetUnbalancedTransactions(ctx context.Context) ([]int64, error) {
+	return nil, errNotStubbed
+}
+
+// stubLedgerService записывает переводы выплат, проведенные по журналу
+type stubLedgerService struct {
+	transfers []string
+}
+
+var _ LedgerService = (*stubLedgerService)(nil)
+
+func (s *stubLedgerService) PostPaymentCharge(ctx context.Context, p *models.Payment) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) PostPaymentCapture(ctx context.Context, p *models.Payment) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) PostTipCharge(ctx context.Context, p *models.Payment) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) PostRefund(ctx context.Context, p *models.Payment, refundedTotalCents, refundCents int64) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) PostPayout(ctx context.Context, p *models.Payout) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) PostPayoutRefund(ctx context.Context, referenceID string, before, after *models.Payout) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) PostPayoutTransfer(ctx context.Context, userID int64, stripeTransferID string, amountCents int64) error {
+	s.transfers = append(s.transfers, stripeTransferID)
+	return nil
+}
+
+func (s *stubLedgerService) PostFeeRedemption(ctx context.Context, redemption *models.FeeRedemption) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) PostFeeRedemptionReversal(ctx context.Context, redemption *models.FeeRedemption) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) PostCreditAdjustment(ctx context.Context, credit *models.CreditTransaction) error {
+	return errNotStubbed
+}
+
+func (s *stubLedgerService) GetUserBalance(ctx context.Context, userID int64) (*models.LedgerAccountBalance, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubLedgerService) GetAccountBalance(ctx context.Context, accountCode string) (*models.LedgerAccountBalance, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubLedgerService) GetAccountEntries(ctx context.Context, accountCode string, limit, offset int) ([]models.LedgerEntry, int, error) {
+	return nil, 0, errNotStubbed
+}
+
+func (s *stubLedgerService) GetTrialBalance(ctx context.Context) (*models.TrialBalance, error) {
+	return nil, errNotStubbed
+}
+
+// stubNotificationService записывает отправленные уведомления
+type stubNotificationService struct {
+	// Получатели уведомлений по порядку
+	jobUpdates     []int64
+	systemMessages []int64
+	created        []*models.NotificationRequest
+}
+
+var _ NotificationService = (*stubNotificationService)(nil)
+
+func (s *stubNotificationService) NotifyJobUpdate(userID int64, jobID int64, status string, message string) {
+	s.jobUpdates = append(s.jobUpdates, userID)
+}
+
+func (s *stubNotificationService) NotifySystemMessage(userID int64, message string, level string) {
+	s.systemMessages = append(s.systemMessages, userID)
+}
+
+func (s *stubNotificationService) CreateNotification(ctx context.Context, req *models.NotificationRequest) (*models.Notification, error) {
+	s.created = append(s.created, req)
+	return &models.Notification{}, nil
+}
+
+func (s *stubNotificationService) GetUserNotifications(ctx context.Context, userID int64, limit, offset int, typeFilter string, unreadOnly bool) (*models.NotificationListResponse, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubNotificationService) GetNotificationByID(ctx context.Context, id, userID int64) (*models.Notification, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubNotificationService) MarkAsRead(ctx context.Context, id, userID int64) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) MarkAllAsRead(ctx context.Context, userID int64) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) DeleteNotification(ctx context.Context, id, userID int64) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) DeleteAllNotifications(ctx context.Context, userID int64) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) GetNotificationStats(ctx context.Context, userID int64) (*models.NotificationStats, error) {
+	return nil, errNotStubbed
+}
+
+func (s *stubNotificationService) NotifyJobApplication(ctx context.Context, jobOwnerID, applicantID, jobID int64, applicantName string) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) NotifyJobClaimed(ctx context.Context, jobOwnerID, contractorID, jobID int64, contractorName, jobTitle string) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) NotifyJobCompleted(ctx context.Context, jobOwnerID, contractorID, jobID int64, jobTitle string) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) NotifyDocumentUploaded(ctx context.Context, recipientID, uploaderID, jobID int64, uploaderName, documentType string) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) NotifyPaymentRequired(ctx context.Context, userID, jobID int64, amount float64, dueDate time.Time) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) NotifyNewReview(ctx context.Context, userID, reviewerID, jobID int64, reviewerName string, rating int) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) NotifyNewMatchingJob(ctx context.Context, userID, jobID int64, jobTitle, route string, estimatedPay float64) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) NotifySystemAnnouncement(ctx context.Context, userID int64, title, message string, priority models.NotificationPriority) error {
+	return errNotStubbed
+}
+
+func (s *stubNotificationService) NotifyNewMessage(userID int64, chatID int64, senderName string, messageText string) {
+}
+
+func (s *stubNotificationService) NotifyUnreadCountChange(userID int64, newUnreadCount int) {}
+
+func (s *stubNotificationService) CleanupExpiredNotifications(ctx context.Context) (int, error) {
+	return 0, errNotStubbed
+}
+
+// paymentTestEnv - paymentService поверх платежного шлюза и репозитория в памяти.
+// Webhook-события шлюза доставляются в HandleWebhook вызовом deliver
+type paymentTestEnv struct {
+	gateway FakePaymentGateway
+	repo    *memPaymentRepo
+	service *paymentService
+}
+
+func newPaymentTestEnv() *paymentTestEnv {
+	gateway := NewFakePaymentGateway("")
+	repo := newMemPaymentRepo()
+	service := NewPaymentService(repo, gateway, nil, nil, nil, nil, nil).(*paymentService)
+	gateway.SetWebhookHandler(service.HandleWebhook)
+
+	return &paymentTestEnv{gateway: gateway, repo: repo, service: service}
+}
+
+// addCard привязывает тестовую карту к Stripe customer пользователя и делает ее основной
+func (e *paymentTestEnv) addCard(t *testing.T, userID int64, card string) *models.UserPaymentMethod {
+	t.Helper()
+	ctx := context.Background()
+
+	customerID := e.repo.customers[userID]
+	if customerID == "" {
+		var err error
+		customerID, err = e.gateway.CreateCustomer(ctx, userID, fmt.Sprintf("user%d@example.com", userID), "Test User")
+		if err != nil {
+			t.Fatalf("CreateCustomer: %v", err)
+		}
+		e.repo.customers[userID] = customerID
+	}
+
+	pm, err := e.gateway.AttachPaymentMethod(ctx, card, customerID)
+	if err != nil {
+		t.Fatalf("AttachPaymentMethod: %v", err)
+	}
+
+	for _, m := range e.repo.methods {
+		if m.UserID == userID {
+			m.IsDefault = false
+		}
+	}
+	method := &models.UserPaymentMethod{
+		ID:                    int64(len(e.repo.methods) + 1),
+		UserID:                userID,
+		StripePaymentMethodID: pm.ID,
+		StripeCustomerID:      customerID,
+		CardLast4:             pm.Card.Last4,
+		CardBrand:             string(pm.Card.Brand),
+		IsDefault:             true,
+		IsActive:              true,
+	}
+	e.repo.methods = append(e.repo.methods, method)
+
+	return method
+}
+
+// authorizeEscrow блокирует оплату работы на основной карте пользователя
+func (e *paymentTestEnv) authorizeEscrow(t *testing.T, userID, jobID, amountCents int64) *models.CreatePaymentResponse {
+	t.Helper()
+
+	resp, err := e.service.AuthorizeJobPayment(context.Background(), userID, &models.CreatePaymentRequest{
+		JobID:       &jobID,
+		AmountCents: amountCents,
+		Description: "Escrow for test job",
+	})
+	if err != nil {
+		t.Fatalf("AuthorizeJobPayment: %v", err)
+	}
+	return resp
+}
+
+// deliver доставляет накопившиеся webhook-события; все должны обработаться без ошибок
+func (e *paymentTestEnv) deliver(t *testing.T) int {
+	t.Helper()
+
+	delivered, err := e.gateway.DeliverWebhooks(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverWebhooks: %v", err)
+	}
+	return delivered
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"moveshare/internal/models"
+	"moveshare/internal/utils"
+	"strings"
+	"time"
+)
+
+type FeeService interface {
+	GetFeeSchedule(ctx context.Context) (*models.FeeSchedule, error)
+	// QuoteJobFee - userID заказчика для скидки по плану подписки; 0 - расчет без учета плана
+	QuoteJobFee(ctx context.Context, userID, payoutCents int64, distanceMiles float64, pickupState string) (*models.FeeBreakdown, error)
+}
+
+type feeService struct {
+	adminService        AdminService
+	subscriptionService SubscriptionService
+}
+
+func NewFeeService(adminService AdminService, subscriptionService SubscriptionService) FeeService {
+	return &feeService{adminService: adminService, subscriptionService: subscriptionService}
+}
+
+func (s *feeService) GetFeeSchedule(ctx context.Context) (*models.FeeSchedule, error) {
+	settings, err := s.adminService.GetSystemSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system settings: %w", err)
+	}
+
+	if settings.FeeSchedule == nil {
+		return models.DefaultFeeSchedule(), nil
+	}
+	return settings.FeeSchedule, nil
+}
+
+// QuoteJobFee рассчитывает сбор за публикацию работы по расписанию из системных настроек
+// и комиссию платформы, которая будет удержана из выплаты исполнителю
+func (s *feeService) QuoteJobFee(ctx context.Context, userID, payoutCents int64, distanceMiles float64, pickupState string) (*models.FeeBreakdown, error) {
+	settings, err := s.adminService.GetSystemSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system settings: %w", err)
+	}
+
+	schedule := settings.FeeSchedule
+	if schedule == nil {
+		schedule = models.DefaultFeeSchedule()
+	}
+
+	breakdown := calculateJobFee(schedule, payoutCents, distanceMiles, pickupState, time.Now())
+
+	if userID > 0 && s.subscriptionService != nil {
+		entitlements, err := s.subscriptionService.GetEntitlements(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get plan entitlements: %w", err)
+		}
+		applyPlanDiscount(breakdown, entitlements)
+	}
+
+	breakdown.CommissionRate = settings.CommissionRate
+	breakdown.CommissionCents = percentOfCents(payoutCents, settings.CommissionRate)
+	breakdown.MoverNetCents = payoutCents - breakdown.CommissionCents
+
+	return breakdown, nil
+}
+
+func calculateJobFee(schedule *models.FeeSchedule, payoutCents int64, distanceMiles float64, pickupState string, now time.Time) *models.FeeBreakdown {
+	stateCode := utils.StateCode(pickupState)
+
+	rule, appliedRule := schedule.Default, "default"
+	for state, override := range schedule.StateOverrides {
+		if utils.StateCode(state) == stateCode {
+			rule, appliedRule = override, "state:"+stateCode
+			break
+		}
+	}
+
+	baseFee, tier := applyFeeRule(&rule, payoutCents, distanceMiles)
+
+	breakdown := &models.FeeBreakdown{
+		PayoutCents:        payoutCents,
+		DistanceMiles:      distanceMiles,
+		FeeType:            rule.Type,
+		AppliedRule:        appliedRule,
+		Tier:               tier,
+		BaseFeeCents:       baseFee,
+		ProcessingFeeCents: baseFee,
+	}
+
+	// Из активных промо-периодов применяется самый выгодный для заказчика
+	for _, promo := range schedule.Promotions {
+		if now.Before(promo.StartsAt) || !now.Before(promo.EndsAt) || !promotionCoversState(&promo, stateCode) {
+			continue
+		}
+
+		fee := baseFee - percentOfCents(baseFee, promo.DiscountPercent)
+		if promo.FixedFeeCents != nil && *promo.FixedFeeCents < fee {
+			fee = *promo.FixedFeeCents
+		}
+		if fee < breakdown.ProcessingFeeCents {
+			breakdown.ProcessingFeeCents = fee
+			breakdown.Promotion = promo.Name
+		}
+	}
+
+	breakdown.DiscountCents = baseFee - breakdown.ProcessingFeeCents
+	breakdown.TotalChargeCents = payoutCents + breakdown.ProcessingFeeCents
+
+	return breakdown
+}
+
+// applyPlanDiscount уменьшает сбор (уже с учетом промо-периода) на скидку плана подписки
+func applyPlanDiscount(breakdown *models.FeeBreakdown, entitlements *models.Entitlements) {
+	if entitlements.PostingFeeDiscountPercent <= 0 {
+		return
+	}
+
+	discount := percentOfCents(breakdown.ProcessingFeeCents, entitlements.PostingFeeDiscountPercent)
+	breakdown.ProcessingFeeCents -= discount
+	breakdown.Plan = entitlements.Plan
+	breakdown.PlanDiscountCents = discount
+	breakdown.DiscountCents = breakdown.BaseFeeCents - breakdown.ProcessingFeeCents
+	breakdown.TotalChargeCents = breakdown.PayoutCents + breakdown.ProcessingFeeCents
+}
+
+// applyFeeRule возвращает сбор по правилу и выбранную ступень (для tiered)
+func applyFeeRule(rule *models.FeeRule, payoutCents int64, distanceMiles float64) (int64, *models.FeeTier) {
+	var fee int64
+	var tier *models.FeeTier
+
+	switch rule.Type {
+	case models.FeeTypePercentage:
+		fee = percentOfCents(payoutCents, rule.Percent)
+	case models.FeeTypeTieredPayout, models.FeeTypeTieredDistance:
+		value := float64(payoutCents) / 100
+		if rule.Type == models.FeeTypeTieredDistance {
+			value = distanceMiles
+		}
+		for i := range rule.Tiers {
+			if rule.Tiers[i].UpTo == 0 || value <= rule.Tiers[i].UpTo {
+				tier = &rule.Tiers[i]
+				break
+			}
+		}
+		// Значение выше последней ограниченной ступени - берем последнюю
+		if tier == nil && len(rule.Tiers) > 0 {
+			tier = &rule.Tiers[len(rule.Tiers)-1]
+		}
+		if tier != nil {
+			copied := *tier
+			tier = &copied
+			fee = tier.FlatCents + percentOfCents(payoutCents, tier.Percent)
+		}
+	default:
+		fee = rule.FlatCents
+	}
+
+	if rule.MinCents > 0 && fee < rule.MinCents {
+		fee = rule.MinCents
+	}
+	if rule.MaxCents > 0 && fee > rule.MaxCents {
+		fee = rule.MaxCents
+	}
+
+	return fee, tier
+}
+
+func promotionCoversState(promo *models.FeePromotion, stateCode string) bool {
+	if len(promo.States) == 0 {
+		return true
+	}
+	for _, state := range promo.States {
+		if strings.EqualFold(utils.StateCode(state), stateCode) {
+			return true
+		}
+	}
+	return false
+}
+
+func percentOfCents(cents int64, percent float64) int64 {
+	return int64(math.Round(float64(cents) * percent / 100))
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/idempotency"
+	"time"
+)
+
+const (
+	idempotencyKeyMaxLength = 255
+	// Сколько хранится ответ на запрос с ключом идемпотентности (как в Stripe)
+	idempotencyKeyTTL = 24 * time.Hour
+	// Запрос, который обрабатывается дольше, считается оборвавшимся - ключ можно использовать снова
+	idempotencyLockTimeout = 5 * time.Minute
+)
+
+var (
+	ErrIdempotencyKeyInvalid    = errors.New("idempotency key must be 1-255 characters")
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// IdempotencyService сохраняет первый ответ на запрос с заголовком Idempotency-Key
+// и возвращает его на повторы, чтобы ретраи не создавали дубликаты работ и платежей
+type IdempotencyService interface {
+	// Begin занимает ключ за запросом. replay = true - запрос уже выполнен, record содержит сохраненный ответ
+	Begin(ctx context.Context, userID int64, key, method, path string, body []byte) (record *models.IdempotencyKey, replay bool, err error)
+	Complete(ctx context.Context, record *models.IdempotencyKey, responseStatus int, contentType string, body []byte) error
+	Release(ctx context.Context, record *models.IdempotencyKey) error
+	StartCleanup(ctx context.Context, interval time.Duration)
+}
+
+type idempotencyService struct {
+	repo idempotency.IdempotencyRepository
+}
+
+func NewIdempotencyService(repo idempotency.IdempotencyRepository) IdempotencyService {
+	return &idempotencyService{repo: repo}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, userID int64, key, method, path string, body []byte) (*models.IdempotencyKey, bool, error) {
+	if key == "" || len(key) > idempotencyKeyMaxLength {
+		return nil, false, ErrIdempotencyKeyInvalid
+	}
+
+	now := time.Now()
+	record := &models.IdempotencyKey{
+		UserID:        userID,
+		Key:           key,
+		RequestMethod: method,
+		RequestPath:   path,
+		RequestHash:   requestHash(method, path, body),
+		ExpiresAt:     now.Add(idempotencyKeyTTL),
+	}
+
+	reserved, err := s.repo.Reserve(ctx, record, now.Add(-idempotencyLockTimeout))
+	if err != nil {
+		return nil, false, err
+	}
+	if reserved {
+		return record, false, nil
+	}
+
+	existing, err := s.repo.GetKey(ctx, userID, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		// Ключ освободили между попытками - первый запрос завершился ошибкой
+		return nil, false, ErrIdempotencyKeyInProgress
+	}
+
+	if existing.RequestHash != record.RequestHash {
+		return nil, false, ErrIdempotencyKeyMismatch
+	}
+	if existing.Status != models.IdempotencyStatusCompleted {
+		return nil, false, ErrIdempotencyKeyInProgress
+	}
+
+	return existing, true, nil
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, record *models.IdempotencyKey, responseStatus int, contentType string, body []byte) error {
+	return s.repo.Complete(ctx, record.ID, responseStatus, contentType, body)
+}
+
+// Release освобождает ключ: запрос не выполнен, клиент может повторить его с тем же ключом
+func (s *idempotencyService) Release(ctx context.Context, record *models.IdempotencyKey) error {
+	return s.repo.Delete(ctx, record.ID)
+}
+
+func (s *idempotencyService) StartCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpired(ctx)
+			if err != nil {
+				fmt.Printf("Idempotency key cleanup error: %v\n", err)
+			} else if deleted > 0 {
+				fmt.Printf("Idempotency key cleanup: %d expired keys deleted\n", deleted)
+			}
+		}
+	}
+}
+
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
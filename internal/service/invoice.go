@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"moveshare/internal/models"
+	"moveshare/internal/repository"
+	"moveshare/internal/repository/company"
+	"moveshare/internal/repository/invoice"
+	"moveshare/internal/repository/payment"
+	"moveshare/internal/utils"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+const invoiceBucket = "invoices"
+
+type InvoiceService interface {
+	IssuePaymentInvoice(ctx context.Context, paymentID int64) (*models.Invoice, error)
+	GetUserInvoices(ctx context.Context, userID int64, limit, offset int) ([]models.Invoice, int, error)
+	DownloadInvoice(ctx context.Context, userID, invoiceID int64) (*models.Invoice, io.ReadCloser, error)
+}
+
+type invoiceService struct {
+	repo         invoice.InvoiceRepository
+	paymentRepo  payment.PaymentRepository
+	companyRepo  company.CompanyRepository
+	userService  UserService
+	adminService AdminService
+	minioRepo    *repository.Repository
+	emailService EmailService
+}
+
+func NewInvoiceService(
+	repo invoice.InvoiceRepository,
+	paymentRepo payment.PaymentRepository,
+	companyRepo company.CompanyRepository,
+	userService UserService,
+	adminService AdminService,
+	minioRepo *repository.Repository,
+	emailService EmailService,
+) InvoiceService {
+	return &invoiceService{
+		repo:         repo,
+		paymentRepo:  paymentRepo,
+		companyRepo:  companyRepo,
+		userService:  userService,
+		adminService: adminService,
+		minioRepo:    minioRepo,
+		emailService: emailService,
+	}
+}
+
+// IssuePaymentInvoice выставляет счет по успешному платежу: сохраняет его с очередным номером,
+// генерирует PDF в MinIO и отправляет его на email компании. Повторный вызов возвращает уже выставленный счет
+func (s *invoiceService) IssuePaymentInvoice(ctx context.Context, paymentID int64) (*models.Invoice, error) {
+	existing, err := s.repo.GetInvoiceByPaymentID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	p, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("payment %d not found", paymentID)
+	}
+	if p.Status != string(stripe.PaymentIntentStatusSucceeded) {
+		return nil, fmt.Errorf("payment %d has not succeeded: status is %s", paymentID, p.Status)
+	}
+
+	settings, err := s.adminService.GetSystemSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system settings: %w", err)
+	}
+
+	inv := buildInvoice(p, settings.InvoiceTaxRate)
+	created, err := s.repo.CreateInvoice(ctx, inv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save invoice: %w", err)
+	}
+	if !created {
+		// Счет уже выставлен параллельным вызовом (webhook и подтверждение платежа)
+		return s.repo.GetInvoiceByPaymentID(ctx, paymentID)
+	}
+	inv.PaymentStatus = p.Status
+	inv.Status = models.InvoiceStatus(inv.TotalCents, p.AmountRefundedCents)
+
+	pdfBytes, recipient, err := s.renderInvoice(ctx, inv, p)
+	if err != nil {
+		return inv, err
+	}
+
+	if recipient == "" {
+		fmt.Printf("Invoice %s not emailed: user %d has no email\n", inv.InvoiceNumber, inv.UserID)
+		return inv, nil
+	}
+
+	subject := fmt.Sprintf("Invoice %s - MoveShare", inv.InvoiceNumber)
+	body := fmt.Sprintf(`<p>Hello,</p>
+<p>Thank you for your payment. Your invoice <strong>%s</strong> for <strong>$%.2f</strong> is attached.</p>
+<p>You can also download it any time from the payments section of your MoveShare account.</p>
+<p>Best regards,<br>The MoveShare Team</p>`, inv.InvoiceNumber, float64(inv.TotalCents)/100)
+
+	if err := s.emailService.SendEmailWithAttachment(recipient, subject, body, inv.FileName(), pdfBytes); err != nil {
+		return inv, fmt.Errorf("failed to email invoice: %w", err)
+	}
+
+	if err := s.repo.MarkInvoiceEmailed(ctx, inv.ID, recipient); err != nil {
+		fmt.Printf("Failed to mark invoice %s as emailed: %v\n", inv.InvoiceNumber, err)
+	} else {
+		inv.EmailedTo = &recipient
+	}
+
+	return inv, nil
+}
+
+// renderInvoice генерирует PDF счета и сохраняет его в MinIO.
+// Возвращает PDF и email, на который нужно отправить счет
+func (s *invoiceService) renderInvoice(ctx context.Context, inv *models.Invoice, p *models.Payment) ([]byte, string, error) {
+	billTo, err := s.companyRepo.GetCompany(ctx, inv.UserID)
+	if err != nil {
+		// Реквизиты компании не обязательны для счета
+		fmt.Printf("Failed to get company for invoice %s: %v\n", inv.InvoiceNumber, err)
+		billTo = nil
+	}
+
+	var email string
+	if billTo != nil && billTo.EmailAddress != "" {
+		email = billTo.EmailAddress
+	} else if user, err := s.userService.FindUserByID(ctx, inv.UserID); err == nil && user != nil {
+		email = user.Email
+	}
+
+	pdfBytes, err := utils.GenerateInvoicePDF(&models.InvoiceData{
+		Invoice: inv,
+		Payment: p,
+		BillTo:  billTo,
+		Email:   email,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	objectName := fmt.Sprintf("users/%d/%s", inv.UserID, inv.FileName())
+	if err := s.minioRepo.UploadBytes(ctx, invoiceBucket, objectName, pdfBytes, "application/pdf"); err != nil {
+		return nil, "", fmt.Errorf("failed to upload invoice: %w", err)
+	}
+
+	if err := s.repo.SetInvoiceFile(ctx, inv.ID, objectName, int64(len(pdfBytes))); err != nil {
+		return nil, "", fmt.Errorf("failed to save invoice file: %w", err)
+	}
+	inv.ObjectName = &objectName
+	inv.FileSize = int64(len(pdfBytes))
+
+	return pdfBytes, email, nil
+}
+
+func (s *invoiceService) GetUserInvoices(ctx context.Context, userID int64, limit, offset int) ([]models.Invoice, int, error) {
+	return s.repo.GetUserInvoices(ctx, userID, limit, offset)
+}
+
+// DownloadInvoice возвращает PDF счета, генерируя его заново, если файл не был сохранен
+func (s *invoiceService) DownloadInvoice(ctx context.Context, userID, invoiceID int64) (*models.Invoice, io.ReadCloser, error) {
+	inv, err := s.repo.GetInvoiceByID(ctx, invoiceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if inv == nil || inv.UserID != userID {
+		return nil, nil, fmt.Errorf("invoice not found")
+	}
+
+	if inv.ObjectName == nil {
+		p, err := s.paymentRepo.GetPaymentByID(ctx, inv.PaymentID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get invoice payment: %w", err)
+		}
+		if p == nil {
+			return nil, nil, fmt.Errorf("invoice payment %d not found", inv.PaymentID)
+		}
+		if _, _, err := s.renderInvoice(ctx, inv, p); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stream, err := s.minioRepo.DownloadStream(ctx, invoiceBucket, *inv.ObjectName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download invoice: %w", err)
+	}
+
+	return inv, stream, nil
+}
+
+// buildInvoice раскладывает платеж на строки счета.
+// Налог включен в сборы платформы; оплата работы передается исполнителю и налогом не облагается
+func buildInvoice(p *models.Payment, taxRate float64) *models.Invoice {
+	amount := p.AmountCents
+	if p.IsEscrow() && p.AmountCapturedCents > 0 {
+		amount = p.AmountCapturedCents
+	}
+
+	var items []models.InvoiceLineItem
+	switch {
+	case p.IsEscrow():
+		items = append(items, models.InvoiceLineItem{Description: "Job payout (held in escrow until the job is completed)", AmountCents: amount})
+	case p.FeeBreakdown != nil:
+		fee := min(p.FeeBreakdown.ProcessingFeeCents, amount)
+		description := "Processing fee"
+		switch {
+		case p.FeeBreakdown.PromoCode != "":
+			description = fmt.Sprintf("Processing fee (promo code: %s)", p.FeeBreakdown.PromoCode)
+		case p.FeeBreakdown.Promotion != "":
+			description = fmt.Sprintf("Processing fee (promotion: %s)", p.FeeBreakdown.Promotion)
+		}
+		items = append(items, models.InvoiceLineItem{Description: description, AmountCents: fee, Taxable: true})
+		if boosts := amount - fee; boosts > 0 {
+			items = append(items, models.InvoiceLineItem{Description: "Job boosts", AmountCents: boosts, Taxable: true})
+		}
+	default:
+		description := p.Description
+		if description == "" {
+			description = "Payment"
+		}
+		items = append(items, models.InvoiceLineItem{Description: description, AmountCents: amount, Taxable: true})
+	}
+
+	inv := &models.Invoice{
+		PaymentID:  p.ID,
+		UserID:     p.UserID,
+		JobID:      p.JobID,
+		TaxRate:    taxRate,
+		TotalCents: amount,
+		Currency:   p.Currency,
+	}
+	for i := range items {
+		if items[i].Taxable && taxRate > 0 {
+			tax := int64(math.Round(float64(items[i].AmountCents) * taxRate / (100 + taxRate)))
+			items[i].AmountCents -= tax
+			inv.TaxCents += tax
+		}
+		inv.SubtotalCents += items[i].AmountCents
+	}
+	inv.LineItems = items
+
+	return inv
+}
@@ -2,31 +2,78 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"moveshare/internal/config"
+	"math"
 	"moveshare/internal/models"
 	"moveshare/internal/repository"
 	"moveshare/internal/utils"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type JobService struct {
-	jobRepo             *repository.JobRepository
-	googleMapsCfg       *config.GoogleMapsConfig
-	minioRepo           *repository.Repository
-	notificationService NotificationService
+	jobRepo              *repository.JobRepository
+	distanceService      DistanceService
+	minioRepo            *repository.Repository
+	notificationService  NotificationService
+	paymentService       PaymentService
+	escrowCaptureService EscrowCaptureService
+	feeService           FeeService
 }
 
-func NewJobService(jobRepo *repository.JobRepository, googleMapsCfg *config.GoogleMapsConfig, minioRepo *repository.Repository, notificationService NotificationService) *JobService {
+func NewJobService(jobRepo *repository.JobRepository, distanceService DistanceService, minioRepo *repository.Repository, notificationService NotificationService, paymentService PaymentService, escrowCaptureService EscrowCaptureService, feeService FeeService) *JobService {
 	return &JobService{
-		jobRepo:             jobRepo,
-		googleMapsCfg:       googleMapsCfg,
-		minioRepo:           minioRepo,
-		notificationService: notificationService,
+		jobRepo:              jobRepo,
+		distanceService:      distanceService,
+		minioRepo:            minioRepo,
+		notificationService:  notificationService,
+		paymentService:       paymentService,
+		escrowCaptureService: escrowCaptureService,
+		feeService:           feeService,
 	}
 }
 
+// ErrDistanceUnavailable - ни один провайдер не посчитал маршрут, а клиент не передал расстояние
+var ErrDistanceUnavailable = errors.New("route distance could not be calculated, provide distance_miles")
+
+// ErrJobNotDeletable - удалить можно только свою работу, которую еще не взял исполнитель
+var ErrJobNotDeletable = errors.New("job not found or already claimed")
+
+const (
+	// duplicateJobWindowHours - за сколько часов назад искать похожие работы
+	duplicateJobWindowHours = 24
+	// duplicateJobPayoutTolerance - допустимое отличие оплаты (5%)
+	duplicateJobPayoutTolerance = 0.05
+)
+
+// DuplicateJobError возвращается, когда заказчик недавно опубликовал почти такую же работу
+type DuplicateJobError struct {
+	Candidates []models.DuplicateJobCandidate
+}
+
+func (e *DuplicateJobError) Error() string {
+	return fmt.Sprintf("a similar job (ID: %d) was posted recently; set confirm_duplicate to true to post anyway", e.Candidates[0].ID)
+}
+
 func (s *JobService) CreateJob(userID int64, req *models.CreateJobRequest) (*models.Job, error) {
+	job, err := s.PrepareJob(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	err = s.jobRepo.CreateJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// PrepareJob проверяет запрос, считает маршрут и сбор за публикацию, но не сохраняет работу
+func (s *JobService) PrepareJob(userID int64, req *models.CreateJobRequest) (*models.Job, error) {
 	pickupDate, err := time.Parse("2006-01-02", req.PickupDate)
 	if err != nil {
 		return nil, err
@@ -57,22 +104,67 @@ func (s *JobService) CreateJob(userID int64, req *models.CreateJobRequest) (*mod
 		return nil, err
 	}
 
-	// Calculate distance using Google Maps API
-	fmt.Printf("Calculating distance from '%s' to '%s'\n", req.PickupAddress, req.DeliveryAddress)
-	distanceResult, err := utils.GetDistanceFromAddresses(req.PickupAddress, req.DeliveryAddress, s.googleMapsCfg)
+	// Защита от случайной повторной публикации той же работы
+	if !req.ConfirmDuplicate {
+		tolerance := req.PaymentAmount * duplicateJobPayoutTolerance
+		duplicates, err := s.jobRepo.FindDuplicateJobs(context.Background(), userID,
+			req.PickupAddress, req.DeliveryAddress, pickupDate, deliveryDate,
+			req.PaymentAmount-tolerance, req.PaymentAmount+tolerance, duplicateJobWindowHours)
+		if err != nil {
+			return nil, err
+		}
+		if len(duplicates) > 0 {
+			return nil, &DuplicateJobError{Candidates: duplicates}
+		}
+	}
+
+	// Calculate distance using the configured provider chain
+	stops, err := buildJobStops(req.Stops, pickupDate, deliveryDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Маршрут: погрузка → промежуточные остановки → доставка
+	route := []models.RouteEndpoint{{Address: req.PickupAddress, City: req.PickupCity, State: req.PickupState}}
+	for _, stop := range stops {
+		route = append(route, models.RouteEndpoint{Address: stop.Address, City: stop.City, State: stop.State})
+	}
+	route = append(route, models.RouteEndpoint{Address: req.DeliveryAddress, City: req.DeliveryCity, State: req.DeliveryState})
+
+	fmt.Printf("Calculating distance from '%s' to '%s' (%d stops)\n", req.PickupAddress, req.DeliveryAddress, len(stops))
+	var distanceProvider string
+	estimate, err := s.routeDistance(context.Background(), route)
 	if err != nil {
 		fmt.Printf("ERROR: Failed to calculate distance: %v\n", err)
-		// Use provided distance or default to 0
-		if req.DistanceMiles == 0 {
-			req.DistanceMiles = 0
+		// Use distance provided by the client; without it the fee can't be quoted
+		if req.DistanceMiles <= 0 {
+			return nil, fmt.Errorf("%w: %v", ErrDistanceUnavailable, err)
 		}
+		distanceProvider = models.DistanceProviderClient
 	} else {
-		// Convert meters to miles (1 meter = 0.000621371 miles)
-		req.DistanceMiles = float64(distanceResult.DistanceValue) * 0.000621371
-		fmt.Printf("SUCCESS: Distance calculated: %s (%d meters) = %.2f miles\n", 
-			distanceResult.Distance, distanceResult.DistanceValue, req.DistanceMiles)
+		req.DistanceMiles = estimate.Miles()
+		distanceProvider = estimate.Provider
+		fmt.Printf("SUCCESS: Distance calculated by %s (cached: %t): %d meters = %.2f miles\n",
+			estimate.Provider, estimate.Cached, estimate.Meters, req.DistanceMiles)
 	}
 
+	// Сбор за публикацию зависит от оплаты, расстояния и штата погрузки
+	var feeBreakdown *models.FeeBreakdown
+	if s.feeService != nil {
+		feeBreakdown, err = s.feeService.QuoteJobFee(context.Background(), userID, int64(math.Round(req.PaymentAmount*100)), req.DistanceMiles, req.PickupState)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate job fee: %w", err)
+		}
+	}
+
+	// Окна храним как моменты времени в зонах мест погрузки и доставки
+	pickupTimeZone := utils.TimeZoneForLocation(req.PickupCity, req.PickupState)
+	deliveryTimeZone := utils.TimeZoneForLocation(req.DeliveryCity, req.DeliveryState)
+	pickupWindowStart := utils.ZonedTime(pickupDate, pickupTimeFrom, pickupTimeZone)
+	pickupWindowEnd := utils.ZonedTime(pickupDate, pickupTimeTo, pickupTimeZone)
+	deliveryWindowStart := utils.ZonedTime(deliveryDate, deliveryTimeFrom, deliveryTimeZone)
+	deliveryWindowEnd := utils.ZonedTime(deliveryDate, deliveryTimeTo, deliveryTimeZone)
+
 	job := &models.Job{
 		ContractorID:                  userID,
 		JobType:                       req.JobType,
@@ -97,6 +189,7 @@ func (s *JobService) CreateJob(userID int64, req *models.CreateJobRequest) (*mod
 		DeliveryBuildingType:          req.DeliveryBuildingType,
 		DeliveryWalkDistance:          req.DeliveryWalkDistance,
 		DistanceMiles:                 req.DistanceMiles,
+		DistanceProvider:              distanceProvider,
 		JobStatus:                     "active",
 		PickupDate:                    pickupDate,
 		PickupTimeFrom:                pickupTimeFrom,
@@ -104,24 +197,102 @@ func (s *JobService) CreateJob(userID int64, req *models.CreateJobRequest) (*mod
 		DeliveryDate:                  deliveryDate,
 		DeliveryTimeFrom:              deliveryTimeFrom,
 		DeliveryTimeTo:                deliveryTimeTo,
+		PickupTimeZone:                pickupTimeZone,
+		DeliveryTimeZone:              deliveryTimeZone,
+		PickupWindowStart:             &pickupWindowStart,
+		PickupWindowEnd:               &pickupWindowEnd,
+		DeliveryWindowStart:           &deliveryWindowStart,
+		DeliveryWindowEnd:             &deliveryWindowEnd,
 		CutAmount:                     req.CutAmount,
 		PaymentAmount:                 req.PaymentAmount,
 		WeightLbs:                     req.WeightLbs,
 		VolumeCuFt:                    req.VolumeCuFt,
+		Stops:                         stops,
+		FeeBreakdown:                  feeBreakdown,
 	}
 
-	ctx := context.Background()
-	err = s.jobRepo.CreateJob(ctx, job)
-	if err != nil {
-		return nil, err
+	return job, nil
+}
+
+// routeDistance суммирует расстояние по всем участкам маршрута
+func (s *JobService) routeDistance(ctx context.Context, route []models.RouteEndpoint) (*models.DistanceEstimate, error) {
+	total := &models.DistanceEstimate{Cached: true}
+	for i := 0; i+1 < len(route); i++ {
+		leg, err := s.distanceService.Calculate(ctx, route[i], route[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate leg %d: %w", i+1, err)
+		}
+		total.Meters += leg.Meters
+		total.DurationSeconds += leg.DurationSeconds
+		total.Cached = total.Cached && leg.Cached
+
+		// Итоговый провайдер - наименее точный из участков
+		if total.Provider == "" || leg.Provider == models.DistanceProviderOffline {
+			total.Provider = leg.Provider
+		}
 	}
+	return total, nil
+}
 
-	return job, nil
+// buildJobStops проверяет промежуточные остановки и считает их окна в зоне остановки
+func buildJobStops(reqs []models.JobStopRequest, pickupDate, deliveryDate time.Time) ([]models.JobStop, error) {
+	stops := make([]models.JobStop, 0, len(reqs))
+	for i, r := range reqs {
+		stop := models.JobStop{
+			StopOrder:    i + 1,
+			StopType:     r.StopType,
+			Address:      r.Address,
+			City:         r.City,
+			State:        r.State,
+			Floor:        r.Floor,
+			BuildingType: r.BuildingType,
+			WalkDistance: r.WalkDistance,
+			TimeZone:     utils.TimeZoneForLocation(r.City, r.State),
+		}
+
+		if r.Date != "" {
+			date, err := time.Parse("2006-01-02", r.Date)
+			if err != nil {
+				return nil, fmt.Errorf("stop %d: invalid date, expected YYYY-MM-DD", stop.StopOrder)
+			}
+			if date.Before(pickupDate) || date.After(deliveryDate) {
+				return nil, fmt.Errorf("stop %d: date must be between pickup and delivery dates", stop.StopOrder)
+			}
+			stop.Date = &date
+		}
+
+		if (r.TimeFrom == "") != (r.TimeTo == "") {
+			return nil, fmt.Errorf("stop %d: both time_from and time_to are required for a time window", stop.StopOrder)
+		}
+		if r.TimeFrom != "" {
+			if stop.Date == nil {
+				return nil, fmt.Errorf("stop %d: date is required when a time window is set", stop.StopOrder)
+			}
+			timeFrom, err := time.Parse("15:04", r.TimeFrom)
+			if err != nil {
+				return nil, fmt.Errorf("stop %d: invalid time_from, expected HH:MM", stop.StopOrder)
+			}
+			timeTo, err := time.Parse("15:04", r.TimeTo)
+			if err != nil {
+				return nil, fmt.Errorf("stop %d: invalid time_to, expected HH:MM", stop.StopOrder)
+			}
+			windowStart := utils.ZonedTime(*stop.Date, timeFrom, stop.TimeZone)
+			windowEnd := utils.ZonedTime(*stop.Date, timeTo, stop.TimeZone)
+			stop.TimeFrom = r.TimeFrom
+			stop.TimeTo = r.TimeTo
+			stop.WindowStart = &windowStart
+			stop.WindowEnd = &windowEnd
+		}
+
+		stops = append(stops, stop)
+	}
+
+	return stops, nil
 }
 
 // internal/service/job.go - обновить метод GetAvailableJobs
 
-func (s *JobService) GetAvailableJobs(userID int64, filters *models.JobFilters) ([]models.AvailableJobDTO, int, error) {
+func (s *JobService) GetAvailableJobs(userID int64, filters *models.JobFilters, viewerTZ *time.Location) ([]models.AvailableJobDTO, int, error) {
 	ctx := context.Background()
 
 	// Валидация фильтров
@@ -134,6 +305,9 @@ func (s *JobService) GetAvailableJobs(userID int64, filters *models.JobFilters)
 	if err != nil {
 		return nil, 0, err
 	}
+	for i := range jobs {
+		jobs[i].LocalizeWindows(viewerTZ)
+	}
 
 	return jobs, total, nil
 }
@@ -151,6 +325,26 @@ func (s *JobService) GetJobByID(jobID int64) (*models.Job, error) {
 
 func (s *JobService) DeleteJob(jobID, userID int64) error {
 	ctx := context.Background()
+
+	// Сначала снимаем работу с биржи, чтобы ее не взяли, пока возвращаются деньги
+	previousStatus, err := s.jobRepo.WithdrawJob(ctx, jobID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrJobNotDeletable
+	}
+	if err != nil {
+		return fmt.Errorf("failed to withdraw job: %w", err)
+	}
+
+	// После удаления платеж теряет связь с работой, поэтому блокировку снимаем заранее
+	if s.paymentService != nil {
+		if _, releaseErr := s.paymentService.ReleaseJobPayment(ctx, jobID); releaseErr != nil {
+			if restoreErr := s.jobRepo.UpdateJobStatus(ctx, jobID, previousStatus); restoreErr != nil {
+				return fmt.Errorf("failed to release job payment: %w (restore status: %v)", releaseErr, restoreErr)
+			}
+			return fmt.Errorf("failed to release job payment: %w", releaseErr)
+		}
+	}
+
 	return s.jobRepo.DeleteJob(ctx, jobID, userID)
 }
 
@@ -174,13 +368,14 @@ func (s *JobService) ClaimJob(jobID, userID int64) error {
 	return nil
 }
 
-func (s *JobService) GetMyJobs(userID int64, page, limit int) ([]models.Job, int, error) {
+func (s *JobService) GetMyJobs(userID int64, page, limit int, viewerTZ *time.Location) ([]models.Job, int, error) {
 	ctx := context.Background()
 	offset := (page - 1) * limit
 	jobs, err := s.jobRepo.GetMyJobs(ctx, userID, offset, limit)
 	if err != nil {
 		return nil, 0, err
 	}
+	localizeJobWindows(jobs, viewerTZ)
 
 	total, err := s.jobRepo.GetCountMyJobs(ctx, userID)
 	if err != nil {
@@ -195,13 +390,14 @@ func (s *JobService) JobExists(jobID int64) (bool, error) {
 	return s.jobRepo.JobExists(ctx, jobID)
 }
 
-func (s *JobService) GetClaimedJobs(userID int64, page, limit int) ([]models.Job, int, error) {
+func (s *JobService) GetClaimedJobs(userID int64, page, limit int, viewerTZ *time.Location) ([]models.Job, int, error) {
 	ctx := context.Background()
 	offset := (page - 1) * limit
 	jobs, err := s.jobRepo.GetClaimedJobs(ctx, userID, offset, limit)
 	if err != nil {
 		return nil, 0, err
 	}
+	localizeJobWindows(jobs, viewerTZ)
 
 	// Получаем файлы для каждой работы
 	for i := range jobs {
@@ -222,23 +418,23 @@ func (s *JobService) GetClaimedJobs(userID int64, page, limit int) ([]models.Job
 	return jobs, total, nil
 }
 
-func (s *JobService) MarkJobCompleted(jobID, userID int64) error {
+// MarkJobCompleted отмечает работу выполненной. Оплата, заблокированная при публикации,
+// списывается только после подтверждения подрядчиком или по истечении срока на спор
+func (s *JobService) MarkJobCompleted(jobID, userID int64) (*models.EscrowCapture, error) {
 	ctx := context.Background()
-	err := s.jobRepo.MarkJobCompleted(ctx, jobID, userID)
+	capture, err := s.escrowCaptureService.CompleteJob(ctx, jobID, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Отправляем уведомление о завершении работы
 	if s.notificationService != nil {
-		// Получаем информацию о работе
-		job, getJobErr := s.jobRepo.GetJobByID(ctx, jobID)
-		if getJobErr == nil {
-			s.notificationService.NotifyJobUpdate(job.ContractorID, jobID, "completed", "Your job has been marked as completed")
-		}
+		message := fmt.Sprintf("Your job has been marked as completed. Confirm it or open a dispute before %s, otherwise it will be confirmed automatically",
+			capture.ConfirmBy.UTC().Format("Jan 2, 15:04 MST"))
+		s.notificationService.NotifyJobUpdate(capture.ContractorID, jobID, "completed", message)
 	}
 
-	return nil
+	return capture, nil
 }
 
 func (s *JobService) CancelJobs(jobIDs []int64, userID int64) (int, error) {
@@ -248,15 +444,24 @@ func (s *JobService) CancelJobs(jobIDs []int64, userID int64) (int, error) {
 		return 0, err
 	}
 
-	// Отправляем уведомления об отмене работ
-	if s.notificationService != nil {
-		for _, jobID := range jobIDs {
-			// Получаем информацию о работе
-			job, getJobErr := s.jobRepo.GetJobByID(ctx, jobID)
-			if getJobErr == nil && job.JobStatus == "canceled" {
-				s.notificationService.NotifyJobUpdate(job.ContractorID, jobID, "canceled", "Your job has been canceled")
+	for _, jobID := range jobIDs {
+		// Получаем информацию о работе
+		job, getJobErr := s.jobRepo.GetJobByID(ctx, jobID)
+		if getJobErr != nil || job.JobStatus != "canceled" {
+			continue
+		}
+
+		// Освобождаем (или возвращаем) оплату, заблокированную при публикации
+		if s.paymentService != nil {
+			if _, releaseErr := s.paymentService.ReleaseJobPayment(ctx, jobID); releaseErr != nil {
+				fmt.Printf("Failed to release escrow payment for job %d: %v\n", jobID, releaseErr)
 			}
 		}
+
+		// Отправляем уведомление об отмене работы
+		if s.notificationService != nil {
+			s.notificationService.NotifyJobUpdate(job.ContractorID, jobID, "canceled", "Your job has been canceled")
+		}
 	}
 
 	return cancelledCount, nil
@@ -278,25 +483,33 @@ func (s *JobService) GetUserWorkStats(userID int64) (models.UserWorkStats, error
 	return s.jobRepo.GetUserWorkStats(ctx, userID)
 }
 
-func (s *JobService) GetPendingJobs(userID int64, limit int) ([]models.Job, error) {
+func (s *JobService) GetPendingJobs(userID int64, limit int, viewerTZ *time.Location) ([]models.Job, error) {
 	ctx := context.Background()
 	jobs, err := s.jobRepo.GetPendingJobs(ctx, userID, limit)
 	if err != nil {
 		return nil, err
 	}
+	localizeJobWindows(jobs, viewerTZ)
 
 	return jobs, nil
 }
 
-func (s *JobService) GetTodayScheduleJobs(userID int64, page, limit int) ([]models.Job, int, error) {
+// GetTodayScheduleJobs возвращает расписание на "сегодня" в часовом поясе пользователя
+func (s *JobService) GetTodayScheduleJobs(userID int64, page, limit int, viewerTZ *time.Location) ([]models.Job, int, error) {
 	ctx := context.Background()
 	offset := (page - 1) * limit
-	jobs, err := s.jobRepo.GetTodayScheduleJobs(ctx, userID, offset, limit)
+
+	now := time.Now().In(viewerTZ)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, viewerTZ)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	jobs, err := s.jobRepo.GetTodayScheduleJobs(ctx, userID, dayStart, dayEnd, offset, limit)
 	if err != nil {
 		return nil, 0, err
 	}
+	localizeJobWindows(jobs, viewerTZ)
 
-	total, err := s.jobRepo.GetCountTodayScheduleJobs(ctx, userID)
+	total, err := s.jobRepo.GetCountTodayScheduleJobs(ctx, userID, dayStart, dayEnd)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -304,6 +517,13 @@ func (s *JobService) GetTodayScheduleJobs(userID int64, page, limit int) ([]mode
 	return jobs, total, nil
 }
 
+// localizeJobWindows показывает окна в зонах мест, а окна без зоны - в зоне пользователя
+func localizeJobWindows(jobs []models.Job, viewerTZ *time.Location) {
+	for i := range jobs {
+		jobs[i].LocalizeWindows(viewerTZ)
+	}
+}
+
 func (s *JobService) UploadJobFile(jobID int64, fileID, fileName string, fileSize int64, contentType string) error {
 	ctx := context.Background()
 	return s.jobRepo.InsertJobFile(ctx, jobID, fileID, fileName, fileSize, contentType)
@@ -374,3 +594,131 @@ func (s *JobService) GetJobFilesByType(jobID int64, fileType string) ([]models.J
 
 	return files, nil
 }
+
+func (s *JobService) RecordJobMilestone(jobID, userID int64, milestone string) (*models.JobMilestone, error) {
+	ctx := context.Background()
+
+	if !models.IsValidMilestone(milestone) {
+		return nil, fmt.Errorf("invalid milestone: %s", milestone)
+	}
+
+	result, err := s.jobRepo.RecordJobMilestone(ctx, jobID, userID, milestone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сообщаем заказчику о ходе переезда
+	if s.notificationService != nil {
+		job, getJobErr := s.jobRepo.GetJobByID(ctx, jobID)
+		if getJobErr == nil {
+			s.notificationService.NotifyJobUpdate(job.ContractorID, jobID, job.JobStatus, milestoneMessage(milestone))
+		}
+	}
+
+	return result, nil
+}
+
+func milestoneMessage(milestone string) string {
+	switch milestone {
+	case models.MilestoneArrivedPickup:
+		return "The mover has arrived at the pickup location"
+	case models.MilestoneLoadingFinished:
+		return "Loading has been finished"
+	case models.MilestoneDepartedPickup:
+		return "The mover has departed from the pickup location"
+	case models.MilestoneArrivedDelivery:
+		return "The mover has arrived at the delivery location"
+	case models.MilestoneUnloadFinished:
+		return "Unloading has been finished"
+	}
+	return "Job progress has been updated"
+}
+
+func (s *JobService) GetJobTimeline(jobID, userID int64) (*models.JobTimeline, error) {
+	ctx := context.Background()
+
+	job, err := s.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.ContractorID != userID && (job.ExecutorID == nil || *job.ExecutorID != userID) {
+		isCrew, err := s.jobRepo.IsJobCrewMember(ctx, jobID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !isCrew {
+			return nil, fmt.Errorf("access denied: you can only view timelines of your own jobs")
+		}
+	}
+
+	milestones, err := s.jobRepo.GetJobMilestones(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &models.JobTimeline{
+		JobID:                 job.ID,
+		JobStatus:             job.JobStatus,
+		Milestones:            milestones,
+		ScheduledPickupFrom:   scheduledTime(job.PickupWindowStart, job.PickupDate, job.PickupTimeFrom),
+		ScheduledPickupTo:     scheduledTime(job.PickupWindowEnd, job.PickupDate, job.PickupTimeTo),
+		ScheduledDeliveryFrom: scheduledTime(job.DeliveryWindowStart, job.DeliveryDate, job.DeliveryTimeFrom),
+		ScheduledDeliveryTo:   scheduledTime(job.DeliveryWindowEnd, job.DeliveryDate, job.DeliveryTimeTo),
+	}
+	timeline.EstimatedTotalMinutes = timeline.ScheduledDeliveryTo.Sub(timeline.ScheduledPickupFrom).Minutes()
+
+	recorded := make(map[string]time.Time)
+	for _, m := range milestones {
+		recorded[m.Milestone] = m.RecordedAt
+	}
+
+	minutesBetween := func(from, to string) *float64 {
+		start, ok1 := recorded[from]
+		end, ok2 := recorded[to]
+		if !ok1 || !ok2 {
+			return nil
+		}
+		minutes := end.Sub(start).Minutes()
+		return &minutes
+	}
+
+	if arrived, ok := recorded[models.MilestoneArrivedPickup]; ok {
+		delay, onTime := delayAfter(arrived, timeline.ScheduledPickupTo)
+		timeline.PickupDelayMinutes = &delay
+		timeline.OnTimePickup = &onTime
+	}
+	if arrived, ok := recorded[models.MilestoneArrivedDelivery]; ok {
+		delay, onTime := delayAfter(arrived, timeline.ScheduledDeliveryTo)
+		timeline.DeliveryDelayMinutes = &delay
+		timeline.OnTimeDelivery = &onTime
+	}
+
+	timeline.LoadingMinutes = minutesBetween(models.MilestoneArrivedPickup, models.MilestoneLoadingFinished)
+	timeline.TransitMinutes = minutesBetween(models.MilestoneDepartedPickup, models.MilestoneArrivedDelivery)
+	timeline.UnloadMinutes = minutesBetween(models.MilestoneArrivedDelivery, models.MilestoneUnloadFinished)
+	timeline.ActualTotalMinutes = minutesBetween(models.MilestoneArrivedPickup, models.MilestoneUnloadFinished)
+
+	return timeline, nil
+}
+
+// scheduledTime берет окно с зоной, а для старых работ без окон собирает момент
+// из колонок DATE и TIME по локальному времени сервера
+func scheduledTime(window *time.Time, date, clock time.Time) time.Time {
+	if window != nil {
+		return *window
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, time.Local)
+}
+
+func delayAfter(actual, deadline time.Time) (float64, bool) {
+	if !actual.After(deadline) {
+		return 0, true
+	}
+	return actual.Sub(deadline).Minutes(), false
+}
+
+func (s *JobService) GetMoverPerformance(userID int64) (*models.MoverPerformance, error) {
+	ctx := context.Background()
+	return s.jobRepo.GetMoverPerformance(ctx, userID)
+}
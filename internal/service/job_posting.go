@@ -0,0 +1,503 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"moveshare/internal/models"
+	"moveshare/internal/repository"
+	"moveshare/internal/repository/job_posting"
+	"moveshare/internal/repository/payment"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+const (
+	// jobPostingLease - сколько запись outbox занята одним обработчиком
+	jobPostingLease = 2 * time.Minute
+	// jobPostingMaxAttempts - после стольких временных ошибок публикация отменяется
+	jobPostingMaxAttempts = 8
+	// jobPostingRetryBase, jobPostingRetryMax - экспоненциальная пауза между попытками
+	jobPostingRetryBase = 30 * time.Second
+	jobPostingRetryMax  = 30 * time.Minute
+	// jobPostingPollInterval - как часто проверять платежи, которые ждут подтверждения клиентом
+	jobPostingPollInterval = 30 * time.Second
+	// jobPostingConfirmationTimeout - сколько ждать подтверждения оплаты (3DS) до отмены публикации
+	jobPostingConfirmationTimeout = 24 * time.Hour
+	// jobPostingBatchSize - сколько записей outbox обрабатывается за проход
+	jobPostingBatchSize = 50
+)
+
+var (
+	ErrJobPostingFailed   = errors.New("job posting failed")
+	ErrJobPostingNotFound = errors.New("job posting not found")
+	ErrJobPostingCanceled = errors.New("job was deleted or canceled before the payment was confirmed")
+	ErrJobPostingExpired  = errors.New("payment was not confirmed in time")
+)
+
+// JobPostingService публикует работу вместе с оплатой через outbox: работа и запись outbox
+// создаются одной транзакцией, платежи создаются по записи с повторами, а работа появляется
+// на доске только после подтверждения оплаты. Неудачная публикация откатывается автоматически
+type JobPostingService interface {
+	// PostJob создает работу в статусе pending_payment и сразу обрабатывает ее запись outbox.
+	// Ошибка с ErrJobPostingFailed - оплата не прошла, работа удалена
+	PostJob(ctx context.Context, userID int64, req *models.CreateJobRequest, posting *models.JobPosting) (*models.JobPostingResult, error)
+	GetJobPosting(ctx context.Context, userID, jobID int64) (*models.JobPostingResult, error)
+	ProcessDueJobPostings(ctx context.Context) (int, error)
+	StartOutboxProcessor(ctx context.Context, interval time.Duration)
+}
+
+type jobPostingService struct {
+	repo                job_posting.JobPostingRepository
+	jobService          *JobService
+	jobRepo             *repository.JobRepository
+	paymentService      PaymentService
+	paymentRepo         payment.PaymentRepository
+	promoService        PromoService
+	boostService        BoostService
+	notificationService NotificationService
+}
+
+func NewJobPostingService(repo job_posting.JobPostingRepository, jobService *JobService, jobRepo *repository.JobRepository, paymentService PaymentService, paymentRepo payment.PaymentRepository, promoService PromoService, boostService BoostService, notificationService NotificationService) JobPostingService {
+	return &jobPostingService{
+		repo:                repo,
+		jobService:          jobService,
+		jobRepo:             jobRepo,
+		paymentService:      paymentService,
+		paymentRepo:         paymentRepo,
+		promoService:        promoService,
+		boostService:        boostService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *jobPostingService) PostJob(ctx context.Context, userID int64, req *models.CreateJobRequest, posting *models.JobPosting) (*models.JobPostingResult, error) {
+	job, err := s.jobService.PrepareJob(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	job.JobStatus = models.JobStatusPendingPayment
+
+	posting.UserID = userID
+	posting.Status = models.JobPostingPending
+	// Запись обрабатывается прямо в запросе; фоновый обработчик подхватит ее, если запрос не дойдет до конца
+	posting.NextAttemptAt = time.Now().Add(jobPostingLease)
+
+	if err := s.repo.CreateJobPosting(ctx, job, posting); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	if err := s.process(ctx, posting, job); err != nil {
+		return nil, err
+	}
+
+	return s.result(ctx, posting, job), nil
+}
+
+func (s *jobPostingService) GetJobPosting(ctx context.Context, userID, jobID int64) (*models.JobPostingResult, error) {
+	posting, err := s.repo.GetJobPostingByJobID(ctx, userID, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job posting: %w", err)
+	}
+	if posting == nil {
+		return nil, ErrJobPostingNotFound
+	}
+
+	job, err := s.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return s.result(ctx, posting, job), nil
+}
+
+// ProcessDueJobPostings обрабатывает записи outbox, срок которых наступил: повторяет создание платежей,
+// публикует работы с подтвержденной оплатой и откатывает неудачные публикации
+func (s *jobPostingService) ProcessDueJobPostings(ctx context.Context) (int, error) {
+	postings, err := s.repo.ClaimDueJobPostings(ctx, jobPostingLease, jobPostingBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim job postings: %w", err)
+	}
+
+	finished := 0
+	for i := range postings {
+		posting := &postings[i]
+
+		var job *models.Job
+		if posting.JobID != nil {
+			job, err = s.jobRepo.GetJobByID(ctx, *posting.JobID)
+			if err != nil {
+				s.retry(ctx, posting, fmt.Errorf("failed to get job: %w", err))
+				continue
+			}
+		}
+
+		// Ошибка означает отмену публикации, она уже записана в outbox
+		_ = s.process(ctx, posting, job)
+
+		if posting.IsFinal() {
+			finished++
+			s.notifyPosting(ctx, posting, job)
+		}
+	}
+
+	return finished, nil
+}
+
+// StartOutboxProcessor периодически обрабатывает outbox публикаций работ
+func (s *jobPostingService) StartOutboxProcessor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		finished, err := s.ProcessDueJobPostings(ctx)
+		if err != nil {
+			fmt.Printf("Job posting outbox error: %v\n", err)
+		} else if finished > 0 {
+			fmt.Printf("Job posting outbox: finished %d postings\n", finished)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process продвигает публикацию на следующий шаг. Временные ошибки откладывают запись на повтор;
+// ошибка возвращается, только если публикация отменена. job - nil, если работа уже удалена
+func (s *jobPostingService) process(ctx context.Context, posting *models.JobPosting, job *models.Job) error {
+	switch posting.Status {
+	case models.JobPostingPending:
+		if err := s.createPayments(ctx, posting, job); err != nil {
+			if isPermanentPostingError(err) || posting.Attempts+1 >= jobPostingMaxAttempts {
+				return s.cancel(ctx, posting, err)
+			}
+			s.retry(ctx, posting, err)
+			return nil
+		}
+		posting.Status = models.JobPostingAwaitingConfirmation
+		posting.Attempts = 0
+		posting.LastError = ""
+		return s.checkPayments(ctx, posting, job)
+	case models.JobPostingAwaitingConfirmation:
+		return s.checkPayments(ctx, posting, job)
+	case models.JobPostingCanceling:
+		return s.cancel(ctx, posting, nil)
+	}
+	return nil
+}
+
+// createPayments применяет промокод и кредиты, блокирует оплату работы и списывает сбор с продвижениями.
+// Ключи идемпотентности привязаны к записи outbox, поэтому повтор не создает второй платеж
+func (s *jobPostingService) createPayments(ctx context.Context, posting *models.JobPosting, job *models.Job) error {
+	if job == nil {
+		return ErrJobPostingCanceled
+	}
+
+	if _, err := s.promoService.RedeemForJob(ctx, posting.UserID, job, posting.PromoCode); err != nil {
+		return err
+	}
+
+	var source string
+	if posting.TemplateName != "" {
+		source = fmt.Sprintf(" (template: %s)", posting.TemplateName)
+	}
+
+	// Оплата работы только блокируется и списывается после выполнения
+	if posting.EscrowPaymentIntentID == "" {
+		escrow, err := s.paymentService.AuthorizeJobPayment(ctx, posting.UserID, &models.CreatePaymentRequest{
+			JobID:           &job.ID,
+			PaymentMethodID: posting.PaymentMethodID,
+			AmountCents:     int64(math.Round(job.PaymentAmount * 100)),
+			Description:     fmt.Sprintf("Escrow for %s job%s", job.JobType, source),
+			FeeBreakdown:    job.FeeBreakdown,
+			IdempotencyKey:  fmt.Sprintf("job-posting:%d:escrow", posting.ID),
+		})
+		if err != nil {
+			return fmt.Errorf("payment authorization failed: %w", err)
+		}
+		posting.EscrowPaymentIntentID = escrow.PaymentIntentID
+		posting.EscrowClientSecret = escrow.ClientSecret
+	}
+
+	// Сбор за публикацию и продвижения (нечего списывать, если сбор покрыт промокодом и кредитами)
+	chargeCents := job.FeeBreakdown.JobProcessingFeeCents()
+	if posting.Boosts != nil {
+		chargeCents += posting.Boosts.TotalCents
+	}
+	if chargeCents > 0 && posting.FeePaymentIntentID == "" {
+		fee, err := s.paymentService.CreatePayment(ctx, posting.UserID, &models.CreatePaymentRequest{
+			JobID:           &job.ID,
+			PaymentMethodID: posting.PaymentMethodID,
+			AmountCents:     chargeCents,
+			Description:     fmt.Sprintf("Payment for %s job posting%s", job.JobType, source),
+			FeeBreakdown:    job.FeeBreakdown,
+			IdempotencyKey:  fmt.Sprintf("job-posting:%d:fee", posting.ID),
+		})
+		if err != nil {
+			return fmt.Errorf("payment processing failed: %w", err)
+		}
+		posting.FeePaymentIntentID = fee.PaymentIntentID
+		posting.FeeClientSecret = fee.ClientSecret
+	}
+
+	// Сбор списываем сразу по сохраненному методу оплаты; если банк требует 3DS, клиент подтверждает его сам
+	if posting.FeePaymentIntentID != "" {
+		fee, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, posting.FeePaymentIntentID)
+		if err != nil {
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+		if fee.Status == string(stripe.PaymentIntentStatusRequiresConfirmation) {
+			if _, err := s.paymentService.ConfirmPayment(ctx, posting.FeePaymentIntentID); err != nil {
+				return fmt.Errorf("payment processing failed: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPayments публикует работу, когда оплата подтверждена, и отменяет публикацию,
+// если платеж отклонен или клиент не подтвердил его вовремя
+func (s *jobPostingService) checkPayments(ctx context.Context, posting *models.JobPosting, job *models.Job) error {
+	escrow, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, posting.EscrowPaymentIntentID)
+	if err != nil {
+		s.retry(ctx, posting, fmt.Errorf("failed to get escrow payment: %w", err))
+		return nil
+	}
+
+	switch escrow.AuthorizationStatus {
+	case models.AuthorizationStatusFailed, models.AuthorizationStatusReleased, models.AuthorizationStatusExpired:
+		return s.cancel(ctx, posting, fmt.Errorf("payment authorization failed: %w (status: %s)", ErrPaymentDeclined, escrow.Status))
+	}
+	confirmed := escrow.AuthorizationStatus == models.AuthorizationStatusAuthorized ||
+		escrow.Status == string(stripe.PaymentIntentStatusProcessing)
+
+	if posting.FeePaymentIntentID != "" {
+		fee, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, posting.FeePaymentIntentID)
+		if err != nil {
+			s.retry(ctx, posting, fmt.Errorf("failed to get payment: %w", err))
+			return nil
+		}
+
+		switch stripe.PaymentIntentStatus(fee.Status) {
+		case stripe.PaymentIntentStatusSucceeded, stripe.PaymentIntentStatusProcessing:
+		case stripe.PaymentIntentStatusCanceled, stripe.PaymentIntentStatusRequiresPaymentMethod:
+			return s.cancel(ctx, posting, fmt.Errorf("payment processing failed: %w (status: %s)", ErrPaymentDeclined, fee.Status))
+		default:
+			confirmed = false
+		}
+	}
+
+	if confirmed {
+		return s.complete(ctx, posting, job)
+	}
+
+	if time.Since(posting.CreatedAt) > jobPostingConfirmationTimeout {
+		return s.cancel(ctx, posting, ErrJobPostingExpired)
+	}
+
+	posting.NextAttemptAt = time.Now().Add(jobPostingPollInterval)
+	s.save(ctx, posting)
+	return nil
+}
+
+// complete публикует работу и включает купленные продвижения
+func (s *jobPostingService) complete(ctx context.Context, posting *models.JobPosting, job *models.Job) error {
+	activated, err := s.repo.CompleteJobPosting(ctx, posting)
+	if err != nil {
+		s.retry(ctx, posting, fmt.Errorf("failed to publish job: %w", err))
+		return nil
+	}
+	if !activated {
+		return s.cancel(ctx, posting, ErrJobPostingCanceled)
+	}
+	if job != nil {
+		job.JobStatus = "active"
+	}
+
+	if posting.Boosts != nil && len(posting.Boosts.Options) > 0 {
+		if _, err := s.boostService.ActivateBoosts(ctx, posting.UserID, *posting.JobID, posting.Boosts, posting.FeePaymentIntentID); err != nil {
+			fmt.Printf("Failed to activate boosts for job %d: %v\n", *posting.JobID, err)
+		}
+	}
+
+	fmt.Printf("New job posted (ID: %d) after payment confirmation\n", *posting.JobID)
+	return nil
+}
+
+// cancel откатывает публикацию: снимает блокировку оплаты, отменяет или возвращает сбор,
+// возвращает промокод и кредиты и удаляет работу. Если откат не удался, он повторяется позже
+func (s *jobPostingService) cancel(ctx context.Context, posting *models.JobPosting, cause error) error {
+	if cause != nil {
+		posting.LastError = cause.Error()
+	} else {
+		cause = errors.New(posting.LastError)
+	}
+	if posting.Status != models.JobPostingCanceling {
+		posting.Status = models.JobPostingCanceling
+		posting.Attempts = 0
+	}
+
+	err := s.rollbackPayments(ctx, posting)
+	if err == nil {
+		err = s.repo.FailJobPosting(ctx, posting)
+	}
+	if err != nil {
+		posting.Attempts++
+		posting.NextAttemptAt = time.Now().Add(jobPostingBackoff(posting.Attempts))
+		s.save(ctx, posting)
+		fmt.Printf("Failed to roll back job posting %d, retrying at %s: %v\n", posting.ID, posting.NextAttemptAt.Format(time.RFC3339), err)
+	}
+
+	return fmt.Errorf("%w: %w", ErrJobPostingFailed, cause)
+}
+
+func (s *jobPostingService) rollbackPayments(ctx context.Context, posting *models.JobPosting) error {
+	if posting.JobID != nil {
+		if _, err := s.paymentService.ReleaseJobPayment(ctx, *posting.JobID); err != nil {
+			return err
+		}
+	}
+
+	if posting.FeePaymentIntentID != "" {
+		if err := s.paymentService.VoidPayment(ctx, posting.FeePaymentIntentID); err != nil {
+			return err
+		}
+	}
+
+	if posting.JobID != nil {
+		if err := s.promoService.ReleaseJobRedemption(ctx, *posting.JobID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retry откладывает запись на повтор с экспоненциальной паузой
+func (s *jobPostingService) retry(ctx context.Context, posting *models.JobPosting, cause error) {
+	posting.Attempts++
+	posting.LastError = cause.Error()
+	posting.NextAttemptAt = time.Now().Add(jobPostingBackoff(posting.Attempts))
+	s.save(ctx, posting)
+	fmt.Printf("Job posting %d: attempt %d failed, retrying at %s: %v\n", posting.ID, posting.Attempts, posting.NextAttemptAt.Format(time.RFC3339), cause)
+}
+
+// save сохраняет ход обработки. Если сохранить не удалось, запись обработается заново после lease
+func (s *jobPostingService) save(ctx context.Context, posting *models.JobPosting) {
+	if err := s.repo.UpdateJobPosting(ctx, posting); err != nil {
+		fmt.Printf("Failed to save job posting %d: %v\n", posting.ID, err)
+	}
+}
+
+func jobPostingBackoff(attempts int) time.Duration {
+	delay := jobPostingRetryBase
+	for i := 1; i < attempts && delay < jobPostingRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, jobPostingRetryMax)
+}
+
+// isPermanentPostingError - ошибка, которую повтор не исправит: банк отклонил платеж,
+// метод оплаты не найден или не подтвержден, промокод не применим
+func isPermanentPostingError(err error) bool {
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) {
+		return stripeErr.Type == stripe.ErrorTypeCard || stripeErr.Type == stripe.ErrorTypeInvalidRequest ||
+			stripeErr.Type == stripe.ErrorTypeIdempotency
+	}
+
+	switch {
+	case errors.Is(err, ErrPaymentDeclined), errors.Is(err, ErrPaymentMethodNotVerified),
+		errors.Is(err, ErrPromoCodeNotFound), errors.Is(err, ErrPromoCodeUnavailable),
+		errors.Is(err, ErrJobPostingCanceled):
+		return true
+	}
+
+	// resolvePaymentMethod не различает отсутствие метода оплаты и ошибку БД
+	return strings.Contains(err.Error(), "payment method not found") ||
+		strings.Contains(err.Error(), "no default payment method found")
+}
+
+// result собирает состояние публикации с данными для подтверждения платежей клиентом
+func (s *jobPostingService) result(ctx context.Context, posting *models.JobPosting, job *models.Job) *models.JobPostingResult {
+	result := &models.JobPostingResult{Job: job, Posting: posting}
+	if posting.EscrowPaymentIntentID != "" {
+		result.Escrow = s.paymentResponse(ctx, posting.EscrowPaymentIntentID, posting.EscrowClientSecret)
+	}
+	if posting.FeePaymentIntentID != "" {
+		result.Payment = s.paymentResponse(ctx, posting.FeePaymentIntentID, posting.FeeClientSecret)
+	}
+
+	if posting.Status == models.JobPostingCompleted && posting.JobID != nil {
+		boosts, err := s.boostService.GetJobBoosts(ctx, *posting.JobID)
+		if err != nil {
+			fmt.Printf("Failed to get boosts for job %d: %v\n", *posting.JobID, err)
+		}
+		result.Boosts = boosts
+	}
+
+	return result
+}
+
+func (s *jobPostingService) paymentResponse(ctx context.Context, paymentIntentID, clientSecret string) *models.CreatePaymentResponse {
+	response := &models.CreatePaymentResponse{PaymentIntentID: paymentIntentID, ClientSecret: clientSecret}
+	if payment, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, paymentIntentID); err == nil && payment != nil {
+		response.Status = payment.Status
+	}
+
+	switch stripe.PaymentIntentStatus(response.Status) {
+	case stripe.PaymentIntentStatusRequiresAction:
+		response.RequiresConfirmation = true
+		response.Success = true
+	case stripe.PaymentIntentStatusRequiresCapture, stripe.PaymentIntentStatusSucceeded, stripe.PaymentIntentStatusProcessing:
+		response.Success = true
+	}
+
+	return response
+}
+
+// notifyPosting сообщает заказчику, чем закончилась публикация, обработанная в фоне
+func (s *jobPostingService) notifyPosting(ctx context.Context, posting *models.JobPosting, job *models.Job) {
+	if s.notificationService == nil {
+		return
+	}
+
+	req := &models.NotificationRequest{
+		UserID:   posting.UserID,
+		Type:     models.NotificationTypeJobUpdate,
+		Priority: models.NotificationPriorityNormal,
+		Metadata: map[string]interface{}{
+			"job_posting_id": posting.ID,
+		},
+	}
+
+	route := "your job"
+	if job != nil {
+		route = fmt.Sprintf("%s → %s", job.PickupAddress, job.DeliveryAddress)
+	}
+
+	if posting.Status == models.JobPostingCompleted {
+		jobID := *posting.JobID
+		req.JobID = &jobID
+		req.Title = "Job Posted"
+		req.Message = fmt.Sprintf("Payment for %s is confirmed and the job is now visible to movers.", route)
+		req.Actions = []models.NotificationAction{
+			{Label: "View Job", Action: "view_job", URL: fmt.Sprintf("/jobs/%d", jobID), Primary: true},
+		}
+	} else {
+		req.Title = "Job Not Posted"
+		req.Message = fmt.Sprintf("We couldn't post %s: %s. Any payment hold has been released.", route, posting.LastError)
+		req.Priority = models.NotificationPriorityHigh
+	}
+
+	if _, err := s.notificationService.CreateNotification(ctx, req); err != nil {
+		fmt.Printf("Failed to notify user %d about job posting %d: %v\n", posting.UserID, posting.ID, err)
+	}
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+// jobPostingTestEnv - публикация работы с промокодом на 25% и кредитами $3:
+// сбор $20 - $5 - $3 = $12, оплата работы $500 блокируется на карте
+type jobPostingTestEnv struct {
+	payments *paymentTestEnv
+	postings *memJobPostingRepo
+	promos   *memPromoRepo
+	service  *jobPostingService
+	posting  *models.JobPosting
+	job      *models.Job
+}
+
+func newJobPostingTestEnv(t *testing.T, card string) *jobPostingTestEnv {
+	t.Helper()
+
+	payments := newPaymentTestEnv()
+	payments.addCard(t, testContractorID, card)
+
+	postings := newMemJobPostingRepo()
+	postings.jobs[testJobID] = models.JobStatusPendingPayment
+
+	promos := newMemPromoRepo()
+	promos.addCode(&models.PromoCode{Code: "SPRING25", DiscountType: models.PromoDiscountPercentage, PercentOff: 25})
+	promos.credits[testContractorID] = 300
+
+	job := newPromoTestJob()
+	job.JobType = "Local"
+	job.PaymentAmount = 500
+	jobID := job.ID
+
+	return &jobPostingTestEnv{
+		payments: payments,
+		postings: postings,
+		promos:   promos,
+		service: &jobPostingService{
+			repo:           postings,
+			paymentService: payments.service,
+			paymentRepo:    payments.repo,
+			promoService:   &promoService{repo: promos, jobRepo: memJobFees{}},
+		},
+		posting: &models.JobPosting{
+			ID:        1,
+			JobID:     &jobID,
+			UserID:    testContractorID,
+			PromoCode: "SPRING25",
+			Status:    models.JobPostingPending,
+			CreatedAt: time.Now(),
+		},
+		job: job,
+	}
+}
+
+func (e *jobPostingTestEnv) process(t *testing.T) error {
+	t.Helper()
+	return e.service.process(context.Background(), e.posting, e.job)
+}
+
+// authenticate проходит или отклоняет 3DS по обоим платежам публикации и доставляет webhook-события
+func (e *jobPostingTestEnv) authenticate(t *testing.T, approve bool) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, id := range []string{e.posting.EscrowPaymentIntentID, e.posting.FeePaymentIntentID} {
+		if _, err := e.payments.gateway.CompleteAuthentication(ctx, id, approve); err != nil {
+			t.Fatalf("CompleteAuthentication(%s): %v", id, err)
+		}
+	}
+	e.payments.deliver(t)
+}
+
+// assertCompleted проверяет, что работа опубликована, оплата заблокирована, а сбор списан
+func (e *jobPostingTestEnv) assertCompleted(t *testing.T) {
+	t.Helper()
+
+	if e.posting.Status != models.JobPostingCompleted || e.postings.jobs[testJobID] != "active" {
+		t.Fatalf("posting %s, job %q; want completed and active", e.posting.Status, e.postings.jobs[testJobID])
+	}
+	escrow := e.payments.repo.payment(t, e.posting.EscrowPaymentIntentID)
+	if escrow.AuthorizationStatus != models.AuthorizationStatusAuthorized || escrow.AmountCents != 50000 {
+		t.Errorf("escrow = %s for %d cents, want authorized 50000", escrow.AuthorizationStatus, escrow.AmountCents)
+	}
+	fee := e.payments.repo.payment(t, e.posting.FeePaymentIntentID)
+	if fee.Status != string(stripe.PaymentIntentStatusSucceeded) || fee.AmountCents != 1200 {
+		t.Errorf("fee = %s for %d cents, want succeeded 1200", fee.Status, fee.AmountCents)
+	}
+	if e.promos.credits[testContractorID] != 0 {
+		t.Errorf("credit balance = %d, want 0", e.promos.credits[testContractorID])
+	}
+}
+
+// assertRolledBack проверяет, что работа снята с публикации, а платежи, промокод и кредиты возвращены
+func (e *jobPostingTestEnv) assertRolledBack(t *testing.T) {
+	t.Helper()
+
+	if e.posting.Status != models.JobPostingFailed {
+		t.Fatalf("posting status = %s, want failed", e.posting.Status)
+	}
+	if saved := e.postings.postings[e.posting.ID]; saved == nil || saved.Status != models.JobPostingFailed {
+		t.Errorf("saved posting = %+v, want failed", saved)
+	}
+	// Неоплаченная работа удаляется, отмененная заказчиком остается отмененной
+	if status := e.postings.jobs[testJobID]; status == models.JobStatusPendingPayment || status == "active" {
+		t.Errorf("job is still %s, want deleted", status)
+	}
+
+	if e.posting.EscrowPaymentIntentID != "" {
+		escrow := e.payments.repo.payment(t, e.posting.EscrowPaymentIntentID)
+		if escrow.AuthorizationStatus != models.AuthorizationStatusReleased && escrow.AuthorizationStatus != models.AuthorizationStatusFailed {
+			t.Errorf("escrow authorization = %s, want released", escrow.AuthorizationStatus)
+		}
+	}
+	if e.posting.FeePaymentIntentID != "" {
+		fee := e.payments.repo.payment(t, e.posting.FeePaymentIntentID)
+		refunded := fee.Status == string(stripe.PaymentIntentStatusSucceeded) && fee.AmountRefundedCents == fee.AmountCents
+		if fee.Status != string(stripe.PaymentIntentStatusCanceled) && fee.Status != string(stripe.PaymentIntentStatusRequiresPaymentMethod) && !refunded {
+			t.Errorf("fee = %s, refunded %d of %d; want canceled or refunded", fee.Status, fee.AmountRefundedCents, fee.AmountCents)
+		}
+	}
+
+	for _, r := range e.promos.redemptions {
+		if r.Status != models.FeeRedemptionReversed {
+			t.Errorf("redemption %d is %s, want reversed", r.ID, r.Status)
+		}
+	}
+	if e.promos.credits[testContractorID] != 300 {
+		t.Errorf("credit balance = %d, want 300 restored", e.promos.credits[testContractorID])
+	}
+}
+
+func TestJobPostingProcess(t *testing.T) {
+	tests := []struct {
+		name        string
+		card        string
+		prepare     func(e *jobPostingTestEnv)
+		wantStatus  string
+		wantErr     error
+		wantDecline stripe.DeclineCode
+	}{
+		{
+			name:       "payments succeed",
+			card:       FakeCardSuccess,
+			wantStatus: models.JobPostingCompleted,
+		},
+		{
+			name:        "card declined",
+			card:        FakeCardDeclined,
+			wantStatus:  models.JobPostingFailed,
+			wantDecline: stripe.DeclineCodeGenericDecline,
+		},
+		{
+			name:        "insufficient funds",
+			card:        FakeCardInsufficientFunds,
+			wantStatus:  models.JobPostingFailed,
+			wantDecline: stripe.DeclineCodeInsufficientFunds,
+		},
+		{
+			name: "unknown promo code",
+			card: FakeCardSuccess,
+			prepare: func(e *jobPostingTestEnv) {
+				e.posting.PromoCode = "NOPE"
+			},
+			wantStatus: models.JobPostingFailed,
+			wantErr:    ErrPromoCodeNotFound,
+		},
+		{
+			name: "job deleted before payment",
+			card: FakeCardSuccess,
+			prepare: func(e *jobPostingTestEnv) {
+				e.job = nil
+				delete(e.postings.jobs, testJobID)
+			},
+			wantStatus: models.JobPostingFailed,
+			wantErr:    ErrJobPostingCanceled,
+		},
+		{
+			name: "job canceled while payments were created",
+			card: FakeCardSuccess,
+			prepare: func(e *jobPostingTestEnv) {
+				e.postings.jobs[testJobID] = "canceled"
+			},
+			wantStatus: models.JobPostingFailed,
+			wantErr:    ErrJobPostingCanceled,
+		},
+		{
+			name:       "3-D Secure required",
+			card:       FakeCardAuthenticationRequired,
+			wantStatus: models.JobPostingAwaitingConfirmation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newJobPostingTestEnv(t, tt.card)
+			if tt.prepare != nil {
+				tt.prepare(e)
+			}
+
+			err := e.process(t)
+			switch {
+			case tt.wantDecline != "":
+				var stripeErr *stripe.Error
+				if !errors.Is(err, ErrJobPostingFailed) || !errors.As(err, &stripeErr) || stripeErr.DeclineCode != tt.wantDecline {
+					t.Fatalf("error = %v, want ErrJobPostingFailed caused by %s", err, tt.wantDecline)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, ErrJobPostingFailed) || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want ErrJobPostingFailed caused by %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("process: %v", err)
+			}
+			if e.posting.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s (last error: %s)", e.posting.Status, tt.wantStatus, e.posting.LastError)
+			}
+
+			switch tt.wantStatus {
+			case models.JobPostingCompleted:
+				e.assertCompleted(t)
+			case models.JobPostingFailed:
+				if e.posting.LastError == "" {
+					t.Error("last error should explain why the posting failed")
+				}
+				e.assertRolledBack(t)
+			case models.JobPostingAwaitingConfirmation:
+				if e.postings.jobs[testJobID] != models.JobStatusPendingPayment || !e.posting.NextAttemptAt.After(time.Now()) {
+					t.Errorf("job %q, next attempt %s; want pending_payment and a poll scheduled", e.postings.jobs[testJobID], e.posting.NextAttemptAt)
+				}
+			}
+		})
+	}
+}
+
+func TestJobPostingAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		approve    bool
+		wantStatus string
+	}{
+		{name: "approved", approve: true, wantStatus: models.JobPostingCompleted},
+		{name: "rejected", wantStatus: models.JobPostingFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newJobPostingTestEnv(t, FakeCardAuthenticationRequired)
+			if err := e.process(t); err != nil || e.posting.Status != models.JobPostingAwaitingConfirmation {
+				t.Fatalf("process = %v, status %s; want awaiting_confirmation", err, e.posting.Status)
+			}
+
+			// Пока клиент не прошел 3DS, публикация продолжает ждать
+			if err := e.process(t); err != nil || e.posting.Status != models.JobPostingAwaitingConfirmation {
+				t.Fatalf("second process = %v, status %s; want awaiting_confirmation", err, e.posting.Status)
+			}
+
+			e.authenticate(t, tt.approve)
+			err := e.process(t)
+			if e.posting.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s (error: %v)", e.posting.Status, tt.wantStatus, err)
+			}
+
+			if tt.approve {
+				if err != nil {
+					t.Fatalf("process: %v", err)
+				}
+				e.assertCompleted(t)
+				return
+			}
+			if !errors.Is(err, ErrPaymentDeclined) {
+				t.Errorf("error = %v, want ErrPaymentDeclined", err)
+			}
+			e.assertRolledBack(t)
+		})
+	}
+}
+
+func TestJobPostingExpiresWithoutConfirmation(t *testing.T) {
+	e := newJobPostingTestEnv(t, FakeCardAuthenticationRequired)
+	if err := e.process(t); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	e.posting.CreatedAt = time.Now().Add(-jobPostingConfirmationTimeout - time.Minute)
+	if err := e.process(t); !errors.Is(err, ErrJobPostingExpired) {
+		t.Fatalf("error = %v, want ErrJobPostingExpired", err)
+	}
+	e.assertRolledBack(t)
+}
+
+func TestJobPostingRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempts   int
+		wantStatus string
+	}{
+		{name: "transient error is retried", wantStatus: models.JobPostingCompleted},
+		{name: "last attempt cancels the posting", attempts: jobPostingMaxAttempts - 1, wantStatus: models.JobPostingFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newJobPostingTestEnv(t, FakeCardSuccess)
+			e.posting.Attempts = tt.attempts
+			e.promos.failGetJobRedemption = errTestDB
+
+			err := e.process(t)
+			if tt.wantStatus == models.JobPostingFailed {
+				if !errors.Is(err, ErrJobPostingFailed) || !errors.Is(err, errTestDB) {
+					t.Fatalf("error = %v, want ErrJobPostingFailed caused by the database error", err)
+				}
+				e.assertRolledBack(t)
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("process: %v", err)
+			}
+			if e.posting.Status != models.JobPostingPending || e.posting.Attempts != 1 || e.posting.LastError == "" {
+				t.Fatalf("posting = %s, attempts %d, last error %q; want pending retry", e.posting.Status, e.posting.Attempts, e.posting.LastError)
+			}
+			if wait := time.Until(e.posting.NextAttemptAt); wait <= 0 || wait > jobPostingRetryBase {
+				t.Errorf("next attempt in %s, want within %s", wait, jobPostingRetryBase)
+			}
+			if saved := e.postings.postings[e.posting.ID]; saved == nil || saved.Attempts != 1 {
+				t.Errorf("retry was not saved: %+v", saved)
+			}
+			if len(e.payments.repo.payments) != 0 {
+				t.Errorf("payments were created before the promo code was applied: %d", len(e.payments.repo.payments))
+			}
+
+			if err := e.process(t); err != nil {
+				t.Fatalf("retry: %v", err)
+			}
+			if e.posting.Attempts != 0 || e.posting.LastError != "" {
+				t.Errorf("attempts %d, last error %q; want reset after success", e.posting.Attempts, e.posting.LastError)
+			}
+			e.assertCompleted(t)
+		})
+	}
+}
+
+func TestJobPostingRollbackRetry(t *testing.T) {
+	e := newJobPostingTestEnv(t, FakeCardSuccess)
+	e.postings.jobs[testJobID] = "canceled"
+	e.postings.failFailJobPosting = errTestDB
+
+	if err := e.process(t); !errors.Is(err, ErrJobPostingCanceled) {
+		t.Fatalf("error = %v, want ErrJobPostingCanceled", err)
+	}
+	if e.posting.Status != models.JobPostingCanceling || e.posting.Attempts != 1 {
+		t.Fatalf("posting = %s, attempts %d; want canceling retry", e.posting.Status, e.posting.Attempts)
+	}
+	if saved := e.postings.postings[e.posting.ID]; saved == nil || saved.Status != models.JobPostingCanceling {
+		t.Errorf("canceling state was not saved: %+v", saved)
+	}
+
+	// Повторный откат не трогает уже отмененные платежи и не возвращает кредиты дважды;
+	// причина отмены восстанавливается из записи outbox
+	err := e.process(t)
+	if !errors.Is(err, ErrJobPostingFailed) || !strings.Contains(err.Error(), ErrJobPostingCanceled.Error()) {
+		t.Errorf("error = %v, want the original cause", err)
+	}
+	e.assertRolledBack(t)
+
+	fee := e.payments.repo.payment(t, e.posting.FeePaymentIntentID)
+	refunds := 0
+	for _, r := range e.payments.repo.refunds {
+		if r.PaymentID == fee.ID {
+			refunds++
+		}
+	}
+	if refunds != 1 || fee.AmountRefundedCents != fee.AmountCents {
+		t.Errorf("fee refunded %d of %d cents in %d refunds, want one full refund", fee.AmountRefundedCents, fee.AmountCents, refunds)
+	}
+}
+
+func TestJobPostingBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 6, want: 16 * time.Minute},
+		{attempts: 7, want: 30 * time.Minute},
+		{attempts: 20, want: 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := jobPostingBackoff(tt.attempts); got != tt.want {
+			t.Errorf("jobPostingBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/job_template"
+	"strings"
+	"time"
+)
+
+type JobTemplateService interface {
+	CreateTemplate(ctx context.Context, userID int64, req *models.JobTemplateRequest) (*models.JobTemplate, error)
+	SaveJobAsTemplate(ctx context.Context, userID, jobID int64, name string) (*models.JobTemplate, error)
+	GetTemplates(ctx context.Context, userID int64) ([]models.JobTemplate, error)
+	GetTemplate(ctx context.Context, userID, templateID int64) (*models.JobTemplate, error)
+	DeleteTemplate(ctx context.Context, userID, templateID int64) error
+	CreateJobFromTemplate(ctx context.Context, userID, templateID int64, req *models.CreateJobFromTemplateRequest) (*models.CreateJobFromTemplateResponse, error)
+	SetRecurrence(ctx context.Context, userID, templateID int64, req *models.SetTemplateRecurrenceRequest) (*models.JobTemplate, error)
+	ClearRecurrence(ctx context.Context, userID, templateID int64) error
+	PostDueRecurringJobs(ctx context.Context) (int, error)
+	StartRecurringPoster(ctx context.Context, interval time.Duration)
+}
+
+type jobTemplateService struct {
+	repo                job_template.JobTemplateRepository
+	jobService          *JobService
+	jobPostingService   JobPostingService
+	notificationService NotificationService
+}
+
+func NewJobTemplateService(repo job_template.JobTemplateRepository, jobService *JobService, jobPostingService JobPostingService, notificationService NotificationService) JobTemplateService {
+	return &jobTemplateService{
+		repo:                repo,
+		jobService:          jobService,
+		jobPostingService:   jobPostingService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *jobTemplateService) CreateTemplate(ctx context.Context, userID int64, req *models.JobTemplateRequest) (*models.JobTemplate, error) {
+	for _, clock := range []string{req.PickupTimeFrom, req.PickupTimeTo, req.DeliveryTimeFrom, req.DeliveryTimeTo} {
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return nil, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+		}
+	}
+
+	template := &models.JobTemplate{
+		UserID:                        userID,
+		Name:                          strings.TrimSpace(req.Name),
+		JobType:                       req.JobType,
+		NumberOfBedrooms:              req.NumberOfBedrooms,
+		PackingBoxes:                  req.PackingBoxes,
+		BulkyItems:                    req.BulkyItems,
+		InventoryList:                 req.InventoryList,
+		Hoisting:                      req.Hoisting,
+		AdditionalServicesDescription: req.AdditionalServicesDescription,
+		EstimatedCrewAssistants:       req.EstimatedCrewAssistants,
+		TruckSize:                     req.TruckSize,
+		PickupAddress:                 req.PickupAddress,
+		PickupCity:                    req.PickupCity,
+		PickupState:                   req.PickupState,
+		PickupFloor:                   req.PickupFloor,
+		PickupBuildingType:            req.PickupBuildingType,
+		PickupWalkDistance:            req.PickupWalkDistance,
+		DeliveryAddress:               req.DeliveryAddress,
+		DeliveryCity:                  req.DeliveryCity,
+		DeliveryState:                 req.DeliveryState,
+		DeliveryFloor:                 req.DeliveryFloor,
+		DeliveryBuildingType:          req.DeliveryBuildingType,
+		DeliveryWalkDistance:          req.DeliveryWalkDistance,
+		PickupTimeFrom:                req.PickupTimeFrom,
+		PickupTimeTo:                  req.PickupTimeTo,
+		DeliveryTimeFrom:              req.DeliveryTimeFrom,
+		DeliveryTimeTo:                req.DeliveryTimeTo,
+		DeliveryOffsetDays:            req.DeliveryOffsetDays,
+		CutAmount:                     req.CutAmount,
+		PaymentAmount:                 req.PaymentAmount,
+		WeightLbs:                     req.WeightLbs,
+		VolumeCuFt:                    req.VolumeCuFt,
+	}
+
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return template, nil
+}
+
+func (s *jobTemplateService) SaveJobAsTemplate(ctx context.Context, userID, jobID int64, name string) (*models.JobTemplate, error) {
+	job, err := s.jobService.GetJobByID(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+	if job.ContractorID != userID {
+		return nil, fmt.Errorf("you can only save your own jobs as templates")
+	}
+
+	deliveryOffset := int(job.DeliveryDate.Sub(job.PickupDate).Hours() / 24)
+	if deliveryOffset < 0 {
+		deliveryOffset = 0
+	}
+
+	return s.CreateTemplate(ctx, userID, &models.JobTemplateRequest{
+		Name:                          name,
+		JobType:                       job.JobType,
+		NumberOfBedrooms:              job.NumberOfBedrooms,
+		PackingBoxes:                  job.PackingBoxes,
+		BulkyItems:                    job.BulkyItems,
+		InventoryList:                 job.InventoryList,
+		Hoisting:                      job.Hoisting,
+		AdditionalServicesDescription: job.AdditionalServicesDescription,
+		EstimatedCrewAssistants:       job.EstimatedCrewAssistants,
+		TruckSize:                     job.TruckSize,
+		PickupAddress:                 job.PickupAddress,
+		PickupCity:                    job.PickupCity,
+		PickupState:                   job.PickupState,
+		PickupFloor:                   job.PickupFloor,
+		PickupBuildingType:            job.PickupBuildingType,
+		PickupWalkDistance:            job.PickupWalkDistance,
+		DeliveryAddress:               job.DeliveryAddress,
+		DeliveryCity:                  job.DeliveryCity,
+		DeliveryState:                 job.DeliveryState,
+		DeliveryFloor:                 job.DeliveryFloor,
+		DeliveryBuildingType:          job.DeliveryBuildingType,
+		DeliveryWalkDistance:          job.DeliveryWalkDistance,
+		PickupTimeFrom:                job.PickupTimeFrom.Format("15:04"),
+		PickupTimeTo:                  job.PickupTimeTo.Format("15:04"),
+		DeliveryTimeFrom:              job.DeliveryTimeFrom.Format("15:04"),
+		DeliveryTimeTo:                job.DeliveryTimeTo.Format("15:04"),
+		DeliveryOffsetDays:            deliveryOffset,
+		CutAmount:                     job.CutAmount,
+		PaymentAmount:                 job.PaymentAmount,
+		WeightLbs:                     job.WeightLbs,
+		VolumeCuFt:                    job.VolumeCuFt,
+	})
+}
+
+func (s *jobTemplateService) GetTemplates(ctx context.Context, userID int64) ([]models.JobTemplate, error) {
+	return s.repo.GetTemplates(ctx, userID)
+}
+
+func (s *jobTemplateService) GetTemplate(ctx context.Context, userID, templateID int64) (*models.JobTemplate, error) {
+	template, err := s.repo.GetTemplateByID(ctx, userID, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+	return template, nil
+}
+
+func (s *jobTemplateService) DeleteTemplate(ctx context.Context, userID, templateID int64) error {
+	return s.repo.DeleteTemplate(ctx, userID, templateID)
+}
+
+func (s *jobTemplateService) CreateJobFromTemplate(ctx context.Context, userID, templateID int64, req *models.CreateJobFromTemplateRequest) (*models.CreateJobFromTemplateResponse, error) {
+	template, err := s.GetTemplate(ctx, userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	pickupDate, err := time.Parse("2006-01-02", req.PickupDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pickup_date, expected YYYY-MM-DD: %w", err)
+	}
+
+	deliveryDate := pickupDate.AddDate(0, 0, template.DeliveryOffsetDays)
+	if req.DeliveryDate != "" {
+		deliveryDate, err = time.Parse("2006-01-02", req.DeliveryDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid delivery_date, expected YYYY-MM-DD: %w", err)
+		}
+		if deliveryDate.Before(pickupDate) {
+			return nil, fmt.Errorf("delivery_date cannot be before pickup_date")
+		}
+	}
+
+	return s.postJob(ctx, template, pickupDate, deliveryDate, req.PaymentMethodID, req.ConfirmDuplicate)
+}
+
+// postJob создает работу по шаблону и оплачивает ее через outbox публикации, как PostNewJob
+func (s *jobTemplateService) postJob(ctx context.Context, template *models.JobTemplate, pickupDate, deliveryDate time.Time, paymentMethodID *int64, confirmDuplicate bool) (*models.CreateJobFromTemplateResponse, error) {
+	result, err := s.jobPostingService.PostJob(ctx, template.UserID, &models.CreateJobRequest{
+		JobType:                       template.JobType,
+		NumberOfBedrooms:              template.NumberOfBedrooms,
+		PackingBoxes:                  template.PackingBoxes,
+		BulkyItems:                    template.BulkyItems,
+		InventoryList:                 template.InventoryList,
+		Hoisting:                      template.Hoisting,
+		AdditionalServicesDescription: template.AdditionalServicesDescription,
+		EstimatedCrewAssistants:       template.EstimatedCrewAssistants,
+		TruckSize:                     template.TruckSize,
+		PickupAddress:                 template.PickupAddress,
+		PickupCity:                    template.PickupCity,
+		PickupState:                   template.PickupState,
+		PickupFloor:                   template.PickupFloor,
+		PickupBuildingType:            template.PickupBuildingType,
+		PickupWalkDistance:            template.PickupWalkDistance,
+		DeliveryAddress:               template.DeliveryAddress,
+		DeliveryCity:                  template.DeliveryCity,
+		DeliveryState:                 template.DeliveryState,
+		DeliveryFloor:                 template.DeliveryFloor,
+		DeliveryBuildingType:          template.DeliveryBuildingType,
+		DeliveryWalkDistance:          template.DeliveryWalkDistance,
+		PickupDate:                    pickupDate.Format("2006-01-02"),
+		PickupTimeFrom:                template.PickupTimeFrom,
+		PickupTimeTo:                  template.PickupTimeTo,
+		DeliveryDate:                  deliveryDate.Format("2006-01-02"),
+		DeliveryTimeFrom:              template.DeliveryTimeFrom,
+		DeliveryTimeTo:                template.DeliveryTimeTo,
+		CutAmount:                     template.CutAmount,
+		PaymentAmount:                 template.PaymentAmount,
+		WeightLbs:                     template.WeightLbs,
+		VolumeCuFt:                    template.VolumeCuFt,
+		ConfirmDuplicate:              confirmDuplicate,
+	}, &models.JobPosting{
+		PaymentMethodID: paymentMethodID,
+		TemplateName:    template.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	job := result.Job
+
+	if err := s.repo.SetLastPostedJob(ctx, template.ID, job.ID); err != nil {
+		fmt.Printf("Failed to save last posted job for template %d: %v\n", template.ID, err)
+	}
+
+	return &models.CreateJobFromTemplateResponse{Job: job, Payment: result.Payment, Escrow: result.Escrow}, nil
+}
+
+func (s *jobTemplateService) SetRecurrence(ctx context.Context, userID, templateID int64, req *models.SetTemplateRecurrenceRequest) (*models.JobTemplate, error) {
+	if _, err := s.GetTemplate(ctx, userID, templateID); err != nil {
+		return nil, err
+	}
+
+	weekdays := make([]string, 0, len(req.Weekdays))
+	for _, day := range req.Weekdays {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if _, ok := parseWeekday(day); !ok {
+			return nil, fmt.Errorf("invalid weekday: %s", day)
+		}
+		weekdays = append(weekdays, day)
+	}
+
+	rec := &models.TemplateRecurrence{
+		Weekdays:        weekdays,
+		IntervalWeeks:   req.IntervalWeeks,
+		LeadDays:        req.LeadDays,
+		PaymentMethodID: req.PaymentMethodID,
+	}
+	if rec.IntervalWeeks == 0 {
+		rec.IntervalWeeks = 1
+	}
+	if rec.LeadDays == 0 {
+		rec.LeadDays = 7
+	}
+
+	today := truncateToDate(time.Now())
+	rec.StartsOn = today
+	if req.StartsOn != "" {
+		startsOn, err := time.Parse("2006-01-02", req.StartsOn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid starts_on, expected YYYY-MM-DD: %w", err)
+		}
+		rec.StartsOn = startsOn
+	}
+	if req.EndsOn != "" {
+		endsOn, err := time.Parse("2006-01-02", req.EndsOn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ends_on, expected YYYY-MM-DD: %w", err)
+		}
+		rec.EndsOn = &endsOn
+	}
+
+	// Первая дата погрузки - не раньше starts_on и не в прошлом
+	from := rec.StartsOn.AddDate(0, 0, -1)
+	if from.Before(today.AddDate(0, 0, -1)) {
+		from = today.AddDate(0, 0, -1)
+	}
+	rec.NextPickupDate = nextRecurrenceDate(rec, from)
+	if rec.NextPickupDate == nil {
+		return nil, fmt.Errorf("recurrence has no occurrences before ends_on")
+	}
+
+	if err := s.repo.SetRecurrence(ctx, userID, templateID, rec); err != nil {
+		return nil, fmt.Errorf("failed to save recurrence: %w", err)
+	}
+
+	return s.GetTemplate(ctx, userID, templateID)
+}
+
+func (s *jobTemplateService) ClearRecurrence(ctx context.Context, userID, templateID int64) error {
+	return s.repo.SetRecurrence(ctx, userID, templateID, nil)
+}
+
+// PostDueRecurringJobs публикует работы по шаблонам, у которых подошла дата публикации
+func (s *jobTemplateService) PostDueRecurringJobs(ctx context.Context) (int, error) {
+	today := truncateToDate(time.Now())
+	templates, err := s.repo.GetDueRecurringTemplates(ctx, today)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due templates: %w", err)
+	}
+
+	posted := 0
+	for i := range templates {
+		template := &templates[i]
+		rec := template.Recurrence
+		if rec == nil || rec.NextPickupDate == nil {
+			continue
+		}
+		pickupDate := *rec.NextPickupDate
+
+		// Сначала переносим дату, чтобы один и тот же слот не опубликовали дважды
+		next := nextRecurrenceDate(rec, pickupDate)
+		advanced, err := s.repo.AdvanceRecurrence(ctx, template.ID, pickupDate, next)
+		if err != nil {
+			fmt.Printf("Failed to advance recurrence for template %d: %v\n", template.ID, err)
+			continue
+		}
+		if !advanced {
+			continue
+		}
+
+		// Пропущенные слоты (например, сервис был остановлен) не публикуем задним числом
+		if pickupDate.Before(today) {
+			continue
+		}
+
+		deliveryDate := pickupDate.AddDate(0, 0, template.DeliveryOffsetDays)
+		result, err := s.postJob(ctx, template, pickupDate, deliveryDate, rec.PaymentMethodID, true)
+		if err != nil {
+			fmt.Printf("Failed to auto-post job from template %d: %v\n", template.ID, err)
+			s.notifyRecurringPost(ctx, template, nil, pickupDate, err)
+			continue
+		}
+
+		posted++
+		s.notifyRecurringPost(ctx, template, result.Job, pickupDate, nil)
+	}
+
+	return posted, nil
+}
+
+// StartRecurringPoster периодически публикует работы по расписанию шаблонов
+func (s *jobTemplateService) StartRecurringPoster(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		posted, err := s.PostDueRecurringJobs(ctx)
+		if err != nil {
+			fmt.Printf("Recurring job poster error: %v\n", err)
+		} else if posted > 0 {
+			fmt.Printf("Recurring job poster: posted %d jobs\n", posted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *jobTemplateService) notifyRecurringPost(ctx context.Context, template *models.JobTemplate, job *models.Job, pickupDate time.Time, postErr error) {
+	req := &models.NotificationRequest{
+		UserID:   template.UserID,
+		Type:     models.NotificationTypeJobUpdate,
+		Priority: models.NotificationPriorityNormal,
+		Metadata: map[string]interface{}{
+			"template_id": template.ID,
+			"pickup_date": pickupDate.Format("2006-01-02"),
+		},
+	}
+
+	if postErr != nil {
+		req.Title = "Scheduled Job Not Posted"
+		req.Message = fmt.Sprintf("We couldn't post the '%s' job for %s: %v", template.Name, pickupDate.Format("Jan 2, 2006"), postErr)
+		req.Priority = models.NotificationPriorityHigh
+	} else {
+		jobID := job.ID
+		req.JobID = &jobID
+		req.Title = "Scheduled Job Posted"
+		req.Message = fmt.Sprintf("Your '%s' job for %s has been posted.", template.Name, pickupDate.Format("Jan 2, 2006"))
+		if job.JobStatus == models.JobStatusPendingPayment {
+			req.Title = "Scheduled Job Awaiting Payment"
+			req.Message = fmt.Sprintf("Your '%s' job for %s was created and will be posted once the payment is confirmed.", template.Name, pickupDate.Format("Jan 2, 2006"))
+		}
+		req.Actions = []models.NotificationAction{
+			{Label: "View Job", Action: "view_job", URL: fmt.Sprintf("/jobs/%d", jobID), Primary: true},
+		}
+	}
+
+	if _, err := s.notificationService.CreateNotification(ctx, req); err != nil {
+		fmt.Printf("Failed to notify user %d about template %d: %v\n", template.UserID, template.ID, err)
+	}
+}
+
+// nextRecurrenceDate возвращает первую дату после after, подходящую под правило, или nil
+func nextRecurrenceDate(rec *models.TemplateRecurrence, after time.Time) *time.Time {
+	days := make(map[time.Weekday]bool)
+	for _, name := range rec.Weekdays {
+		if day, ok := parseWeekday(name); ok {
+			days[day] = true
+		}
+	}
+	if len(days) == 0 {
+		return nil
+	}
+
+	interval := rec.IntervalWeeks
+	if interval < 1 {
+		interval = 1
+	}
+	anchorWeek := weekStart(truncateToDate(rec.StartsOn))
+
+	candidate := truncateToDate(after)
+	for i := 0; i < 7*interval+7; i++ {
+		candidate = candidate.AddDate(0, 0, 1)
+		if rec.EndsOn != nil && candidate.After(*rec.EndsOn) {
+			return nil
+		}
+		if !days[candidate.Weekday()] {
+			continue
+		}
+		weeks := int(weekStart(candidate).Sub(anchorWeek).Hours()/24) / 7
+		if weeks%interval == 0 {
+			next := candidate
+			return &next
+		}
+	}
+
+	return nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, true
+		}
+	}
+	return time.Sunday, false
+}
+
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart - понедельник недели, в которую попадает дата
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
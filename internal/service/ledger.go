@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/ledger"
+	"strconv"
+	"time"
+)
+
+// LedgerService ведет журнал двойной записи: каждая денежная операция платформы
+// проводится сбалансированными проводками между счетами Stripe, escrow, выручки и пользователей
+type LedgerService interface {
+	PostPaymentCharge(ctx context.Context, payment *models.Payment) error
+	PostPaymentCapture(ctx context.Context, payment *models.Payment) error
+	PostTipCharge(ctx context.Context, payment *models.Payment) error
+	PostRefund(ctx context.Context, payment *models.Payment, refundedTotalCents, refundCents int64) error
+	PostPayout(ctx context.Context, payout *models.Payout) error
+	PostPayoutRefund(ctx context.Context, referenceID string, before, after *models.Payout) error
+	PostPayoutTransfer(ctx context.Context, userID int64, stripeTransferID string, amountCents int64) error
+	PostFeeRedemption(ctx context.Context, redemption *models.FeeRedemption) error
+	PostFeeRedemptionReversal(ctx context.Context, redemption *models.FeeRedemption) error
+	PostCreditAdjustment(ctx context.Context, txn *models.CreditTransaction) error
+
+	GetUserBalance(ctx context.Context, userID int64) (*models.LedgerAccountBalance, error)
+	GetAccountBalance(ctx context.Context, accountCode string) (*models.LedgerAccountBalance, error)
+	GetAccountEntries(ctx context.Context, accountCode string, limit, offset int) ([]models.LedgerEntry, int, error)
+	GetTrialBalance(ctx context.Context) (*models.TrialBalance, error)
+}
+
+type ledgerService struct {
+	repo ledger.LedgerRepository
+}
+
+func NewLedgerService(repo ledger.LedgerRepository) LedgerService {
+	return &ledgerService{repo: repo}
+}
+
+func (s *ledgerService) post(ctx context.Context, txn *models.LedgerTransaction) error {
+	if err := txn.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.PostTransaction(ctx, txn); err != nil {
+		return fmt.Errorf("failed to post ledger transaction %s/%s: %w", txn.ReferenceType, txn.ReferenceID, err)
+	}
+
+	return nil
+}
+
+// PostPaymentCharge - списанный сбор за публикацию, продвижения и прочие платежи платформы
+func (s *ledgerService) PostPaymentCharge(ctx context.Context, payment *models.Payment) error {
+	if payment.AmountCents <= 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefPaymentCharge,
+		ReferenceID:   strconv.FormatInt(payment.ID, 10),
+		Description:   payment.Description,
+	}
+	txn.Debit(models.LedgerStripeClearing, payment.AmountCents).
+		Credit(models.LedgerPlatformRevenue, payment.AmountCents)
+
+	return s.post(ctx, txn)
+}
+
+// PostPaymentCapture - оплата работы списана с карты и удерживается до начисления исполнителю
+func (s *ledgerService) PostPaymentCapture(ctx context.Context, payment *models.Payment) error {
+	amount := payment.AmountCapturedCents
+	if amount <= 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefPaymentCapture,
+		ReferenceID:   strconv.FormatInt(payment.ID, 10),
+		Description:   payment.Description,
+	}
+	txn.Debit(models.LedgerStripeClearing, amount).
+		Credit(models.LedgerEscrow, amount)
+
+	return s.post(ctx, txn)
+}
+
+// PostTipCharge - чаевые списаны с карты и удерживаются до начисления исполнителю
+func (s *ledgerService) PostTipCharge(ctx context.Context, payment *models.Payment) error {
+	if payment.AmountCents <= 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefTipCharge,
+		ReferenceID:   strconv.FormatInt(payment.ID, 10),
+		Description:   payment.Description,
+	}
+	txn.Debit(models.LedgerStripeClearing, payment.AmountCents).
+		Credit(models.LedgerEscrow, payment.AmountCents)
+
+	return s.post(ctx, txn)
+}
+
+// PostRefund проводит возврат refundCents, после которого всего возвращено refundedTotalCents.
+// Источник транзакции включает общую сумму, поэтому повторное уведомление о том же возврате не проводится дважды
+func (s *ledgerService) PostRefund(ctx context.Context, payment *models.Payment, refundedTotalCents, refundCents int64) error {
+	if refundCents <= 0 {
+		return nil
+	}
+
+	// Возврат оплаты работы и чаевых уменьшает escrow, возврат сбора - относится на расходы
+	debitAccount := models.LedgerRefunds
+	if payment.IsEscrow() || payment.IsTip() {
+		debitAccount = models.LedgerEscrow
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefRefund,
+		ReferenceID:   fmt.Sprintf("%d:%d", payment.ID, refundedTotalCents),
+		Description:   fmt.Sprintf("Refund of payment %d", payment.ID),
+	}
+	txn.Debit(debitAccount, refundCents).
+		Credit(models.LedgerStripeClearing, refundCents)
+
+	return s.post(ctx, txn)
+}
+
+// PostPayout - оплата выполненной работы начислена исполнителю за вычетом комиссии платформы
+func (s *ledgerService) PostPayout(ctx context.Context, payout *models.Payout) error {
+	if payout.GrossAmountCents <= 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefPayout,
+		ReferenceID:   strconv.FormatInt(payout.ID, 10),
+		Description:   fmt.Sprintf("Payout to user %d", payout.UserID),
+	}
+	txn.Debit(models.LedgerEscrow, payout.GrossAmountCents)
+	if payout.NetAmountCents > 0 {
+		txn.Credit(models.LedgerUserAccount(payout.UserID), payout.NetAmountCents)
+	}
+	if payout.CommissionCents > 0 {
+		txn.Credit(models.LedgerPlatformRevenue, payout.CommissionCents)
+	}
+
+	return s.post(ctx, txn)
+}
+
+// PostPayoutRefund - выплата исполнителю изменена из-за возврата оплаты: уменьшение возвращает
+// начисленное в escrow, из которого уходит возврат, восстановление после неудавшегося возврата начисляет снова
+func (s *ledgerService) PostPayoutRefund(ctx context.Context, referenceID string, before, after *models.Payout) error {
+	gross := before.GrossAmountCents - after.GrossAmountCents
+	commission := before.CommissionCents - after.CommissionCents
+	net := before.NetAmountCents - after.NetAmountCents
+	if gross == 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefPayoutRefund,
+		ReferenceID:   referenceID,
+		Description:   fmt.Sprintf("Payout %d adjusted for refund", before.ID),
+	}
+	if gross > 0 {
+		txn.Credit(models.LedgerEscrow, gross)
+		if net > 0 {
+			txn.Debit(models.LedgerUserAccount(before.UserID), net)
+		}
+		if commission > 0 {
+			txn.Debit(models.LedgerPlatformRevenue, commission)
+		}
+	} else {
+		txn.Debit(models.LedgerEscrow, -gross)
+		if net < 0 {
+			txn.Credit(models.LedgerUserAccount(before.UserID), -net)
+		}
+		if commission < 0 {
+			txn.Credit(models.LedgerPlatformRevenue, -commission)
+		}
+	}
+
+	return s.post(ctx, txn)
+}
+
+// PostPayoutTransfer - начисленные выплаты переведены исполнителю через Stripe Connect
+func (s *ledgerService) PostPayoutTransfer(ctx context.Context, userID int64, stripeTransferID string, amountCents int64) error {
+	if amountCents <= 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefTransfer,
+		ReferenceID:   stripeTransferID,
+		Description:   fmt.Sprintf("Stripe transfer to user %d", userID),
+	}
+	txn.Debit(models.LedgerUserAccount(userID), amountCents).
+		Credit(models.LedgerStripeClearing, amountCents)
+
+	return s.post(ctx, txn)
+}
+
+// PostFeeRedemption - сбор за публикацию уменьшен промокодом и кредитами. Выручка признается в полной сумме:
+// скидка относится на расходы на промо, а использованные кредиты списываются со счета неиспользованных кредитов
+func (s *ledgerService) PostFeeRedemption(ctx context.Context, redemption *models.FeeRedemption) error {
+	total := redemption.DiscountCents + redemption.CreditsAppliedCents
+	if total <= 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefFeeRedemption,
+		ReferenceID:   strconv.FormatInt(redemption.ID, 10),
+		Description:   fmt.Sprintf("Fee discount and credits of redemption %d", redemption.ID),
+	}
+	if redemption.DiscountCents > 0 {
+		txn.Debit(models.LedgerPromoExpense, redemption.DiscountCents)
+	}
+	if redemption.CreditsAppliedCents > 0 {
+		txn.Debit(models.LedgerCustomerCredits, redemption.CreditsAppliedCents)
+	}
+	txn.Credit(models.LedgerPlatformRevenue, total)
+
+	return s.post(ctx, txn)
+}
+
+// PostFeeRedemptionReversal - публикация не прошла: скидка отменена, кредиты возвращены пользователю
+func (s *ledgerService) PostFeeRedemptionReversal(ctx context.Context, redemption *models.FeeRedemption) error {
+	total := redemption.DiscountCents + redemption.CreditsAppliedCents
+	if total <= 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefFeeRedemptionReversal,
+		ReferenceID:   strconv.FormatInt(redemption.ID, 10),
+		Description:   fmt.Sprintf("Reversal of redemption %d", redemption.ID),
+	}
+	txn.Debit(models.LedgerPlatformRevenue, total)
+	if redemption.DiscountCents > 0 {
+		txn.Credit(models.LedgerPromoExpense, redemption.DiscountCents)
+	}
+	if redemption.CreditsAppliedCents > 0 {
+		txn.Credit(models.LedgerCustomerCredits, redemption.CreditsAppliedCents)
+	}
+
+	return s.post(ctx, txn)
+}
+
+// PostCreditAdjustment - администратор начислил кредиты (расход на промо) или списал их
+func (s *ledgerService) PostCreditAdjustment(ctx context.Context, credit *models.CreditTransaction) error {
+	if credit.AmountCents == 0 {
+		return nil
+	}
+
+	txn := &models.LedgerTransaction{
+		ReferenceType: models.LedgerRefCreditAdjustment,
+		ReferenceID:   strconv.FormatInt(credit.ID, 10),
+		Description:   fmt.Sprintf("Credit adjustment for user %d", credit.UserID),
+	}
+	if credit.AmountCents > 0 {
+		txn.Debit(models.LedgerPromoExpense, credit.AmountCents).
+			Credit(models.LedgerCustomerCredits, credit.AmountCents)
+	} else {
+		txn.Debit(models.LedgerCustomerCredits, -credit.AmountCents).
+			Credit(models.LedgerPromoExpense, -credit.AmountCents)
+	}
+
+	return s.post(ctx, txn)
+}
+
+// GetUserBalance возвращает сумму, которую платформа должна пользователю
+func (s *ledgerService) GetUserBalance(ctx context.Context, userID int64) (*models.LedgerAccountBalance, error) {
+	code := models.LedgerUserAccount(userID)
+	balance, err := s.GetAccountBalance(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if balance == nil {
+		return &models.LedgerAccountBalance{
+			AccountCode: code,
+			Name:        fmt.Sprintf("User %d balance", userID),
+			Type:        models.LedgerAccountLiability,
+			UserID:      &userID,
+		}, nil
+	}
+
+	return balance, nil
+}
+
+func (s *ledgerService) GetAccountBalance(ctx context.Context, accountCode string) (*models.LedgerAccountBalance, error) {
+	balance, err := s.repo.GetAccountBalance(ctx, accountCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger balance: %w", err)
+	}
+	return balance, nil
+}
+
+func (s *ledgerService) GetAccountEntries(ctx context.Context, accountCode string, limit, offset int) ([]models.LedgerEntry, int, error) {
+	return s.repo.GetAccountEntries(ctx, accountCode, limit, offset)
+}
+
+// GetTrialBalance строит оборотно-сальдовую ведомость. Ведомость сходится,
+// если общие обороты по дебету и кредиту равны и нет несбалансированных транзакций
+func (s *ledgerService) GetTrialBalance(ctx context.Context) (*models.TrialBalance, error) {
+	accounts, err := s.repo.GetTrialBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trial balance: %w", err)
+	}
+
+	unbalanced, err := s.repo.GetUnbalancedTransactions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check ledger transactions: %w", err)
+	}
+
+	report := &models.TrialBalance{
+		Accounts:               accounts,
+		UnbalancedTransactions: unbalanced,
+		GeneratedAt:            time.Now(),
+	}
+	for _, a := range accounts {
+		report.TotalDebitCents += a.DebitCents
+		report.TotalCreditCents += a.CreditCents
+	}
+	report.Balanced = report.TotalDebitCents == report.TotalCreditCents && len(unbalanced) == 0
+
+	return report, nil
+}
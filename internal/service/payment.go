@@ -4,10 +4,13 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"moveshare/internal/models"
 	"moveshare/internal/repository/payment"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/stripe/stripe-go/v82"
 )
@@ -20,6 +23,10 @@ type PaymentService interface {
 	SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID int64) error
 	GetDefaultPaymentMethod(ctx context.Context, userID int64) (*models.UserPaymentMethod, error)
 
+	// Bank accounts (ACH debit): привязываются через SetupIntent
+	CreateBankAccountSetupIntent(ctx context.Context, userID int64) (*models.BankAccountSetupResponse, error)
+	AddBankAccount(ctx context.Context, userID int64, setupIntentID string) (*models.UserPaymentMethod, error)
+
 	// Customer Management
 	EnsureStripeCustomer(ctx context.Context, userID int64) (string, error)
 	GetOrCreateStripeCustomer(ctx context.Context, userID int64, email, name string) (string, error)
@@ -29,32 +36,98 @@ type PaymentService interface {
 	ConfirmPayment(ctx context.Context, paymentIntentID string) (*models.ConfirmPaymentResponse, error)
 	GetUserPayments(ctx context.Context, userID int64, limit, offset int) ([]models.Payment, error)
 
+	// Escrow: оплата работы блокируется на карте при публикации и списывается после выполнения
+	AuthorizeJobPayment(ctx context.Context, userID int64, req *models.CreatePaymentRequest) (*models.CreatePaymentResponse, error)
+	CaptureJobPayment(ctx context.Context, jobID int64) (*models.Payment, error)
+	ReleaseJobPayment(ctx context.Context, jobID int64) (*models.Payment, error)
+	VoidPayment(ctx context.Context, paymentIntentID string) error
+	ReauthorizeExpiringAuthorizations(ctx context.Context) (int, error)
+	StartAuthorizationRenewer(ctx context.Context, interval time.Duration)
+
+	// Refunds: администратор может вернуть любой платеж, подрядчик - свой платеж за отмененную работу
+	RefundPayment(ctx context.Context, actorID int64, isAdmin bool, paymentID int64, req *models.CreateRefundRequest) (*models.PaymentRefund, error)
+	GetPaymentRefunds(ctx context.Context, actorID int64, isAdmin bool, paymentID int64) ([]models.PaymentRefund, error)
+
+	// Tips: подрядчик может оставить чаевые исполнителю выполненной работы
+	TipJob(ctx context.Context, userID, jobID int64, req *models.CreateTipRequest) (*models.CreatePaymentResponse, error)
+
 	// Webhook
 	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+	ReplayStripeEvent(ctx context.Context, stripeEventID string) error
+	ReplayFailedStripeEvents(ctx context.Context, limit int) (replayed, failed int, err error)
+
+	// Reconciliation
+	ApplyPaymentIntent(ctx context.Context, payment *models.Payment, pi *stripe.PaymentIntent) error
+
+	// OnPaymentSucceeded регистрирует обработчик успешных платежей (например, включение оплаченных продвижений).
+	// Обработчик может вызываться повторно для того же платежа. Регистрировать до запуска сервера
+	OnPaymentSucceeded(handler PaymentSucceededHandler)
 }
 
+// PaymentSucceededHandler вызывается после проводки успешного платежа
+type PaymentSucceededHandler func(ctx context.Context, payment *models.Payment) error
+
+// ErrInvalidWebhookSignature - тело webhook не подписано нашим секретом
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+var (
+	ErrPaymentNotFound  = errors.New("payment not found")
+	ErrRefundNotAllowed = errors.New("refund is not allowed")
+	ErrRefundInvalid    = errors.New("invalid refund")
+
+	ErrJobNotFound    = errors.New("job not found")
+	ErrTipNotAllowed  = errors.New("tip is not allowed")
+	ErrTipAlreadyPaid = errors.New("tip has already been paid for this job")
+
+	ErrPaymentMethodNotVerified = errors.New("payment method is not verified")
+	ErrBankAccountSetupInvalid  = errors.New("invalid bank account setup")
+	ErrPaymentSettling          = errors.New("bank payment is still settling")
+	ErrPaymentDeclined          = errors.New("payment authorization was declined")
+	ErrPaymentNotCapturable     = errors.New("payment authorization cannot be captured")
+)
+
 type paymentService struct {
-	paymentRepo   payment.PaymentRepository
-	stripeService StripeService
-	userService   UserService
+	paymentRepo    payment.PaymentRepository
+	gateway        PaymentGateway
+	userService    UserService
+	invoiceService InvoiceService
+	ledgerService  LedgerService
+	payoutService  PayoutService
+
+	subscriptionService SubscriptionService
+
+	succeededHandlers []PaymentSucceededHandler
 }
 
 func NewPaymentService(
 	paymentRepo payment.PaymentRepository,
-	stripeService StripeService,
+	gateway PaymentGateway,
 	userService UserService,
+	invoiceService InvoiceService,
+	ledgerService LedgerService,
+	payoutService PayoutService,
+	subscriptionService SubscriptionService,
 ) PaymentService {
 	return &paymentService{
-		paymentRepo:   paymentRepo,
-		stripeService: stripeService,
-		userService:   userService,
+		paymentRepo:         paymentRepo,
+		gateway:             gateway,
+		userService:         userService,
+		invoiceService:      invoiceService,
+		ledgerService:       ledgerService,
+		payoutService:       payoutService,
+		subscriptionService: subscriptionService,
 	}
 }
 
 // Customer Management
 func (s *paymentService) EnsureStripeCustomer(ctx context.Context, userID int64) (string, error) {
+	return ensureStripeCustomer(ctx, s.paymentRepo, s.gateway, s.userService, userID)
+}
+
+// ensureStripeCustomer возвращает Stripe customer пользователя, создавая его при первом обращении
+func ensureStripeCustomer(ctx context.Context, paymentRepo payment.PaymentRepository, gateway PaymentGateway, userService UserService, userID int64) (string, error) {
 	// Проверяем есть ли уже Stripe customer
-	customerID, err := s.paymentRepo.GetUserStripeCustomerID(ctx, userID)
+	customerID, err := paymentRepo.GetUserStripeCustomerID(ctx, userID)
 	if err != nil {
 		return "", err
 	}
@@ -64,19 +137,19 @@ func (s *paymentService) EnsureStripeCustomer(ctx context.Context, userID int64)
 	}
 
 	// Получаем информацию о пользователе
-	user, err := s.userService.FindUserByID(ctx, userID)
+	user, err := userService.FindUserByID(ctx, userID)
 	if err != nil {
 		return "", fmt.Errorf("failed to find user: %w", err)
 	}
 
 	// Создаем Stripe customer
-	customerID, err = s.stripeService.CreateCustomer(ctx, userID, user.Email, user.Username)
+	customerID, err = gateway.CreateCustomer(ctx, userID, user.Email, user.Username)
 	if err != nil {
 		return "", fmt.Errorf("failed to create Stripe customer: %w", err)
 	}
 
 	// Сохраняем customer ID в БД
-	err = s.paymentRepo.UpdateUserStripeCustomerID(ctx, userID, customerID)
+	err = paymentRepo.UpdateUserStripeCustomerID(ctx, userID, customerID)
 	if err != nil {
 		return "", fmt.Errorf("failed to save customer ID: %w", err)
 	}
@@ -103,7 +176,7 @@ func (s *paymentService) AddPaymentMethod(ctx context.Context, userID int64, pay
 	}
 
 	// Привязываем payment method к customer в Stripe
-	stripePaymentMethod, err := s.stripeService.AttachPaymentMethod(ctx, paymentMethodID, customerID)
+	stripePaymentMethod, err := s.gateway.AttachPaymentMethod(ctx, paymentMethodID, customerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to attach payment method to customer: %w", err)
 	}
@@ -118,6 +191,8 @@ func (s *paymentService) AddPaymentMethod(ctx context.Context, userID int64, pay
 		UserID:                userID,
 		StripePaymentMethodID: stripePaymentMethod.ID,
 		StripeCustomerID:      customerID,
+		Type:                  models.PaymentMethodTypeCard,
+		Status:                models.PaymentMethodStatusActive,
 		CardLast4:             stripePaymentMethod.Card.Last4,
 		CardBrand:             strings.Title(string(stripePaymentMethod.Card.Brand)),
 		CardExpMonth:          int(stripePaymentMethod.Card.ExpMonth),
@@ -129,7 +204,7 @@ func (s *paymentService) AddPaymentMethod(ctx context.Context, userID int64, pay
 	err = s.paymentRepo.SavePaymentMethod(ctx, userPaymentMethod)
 	if err != nil {
 		// Откатываем изменения в Stripe если не удалось сохранить в БД
-		_, detachErr := s.stripeService.DetachPaymentMethod(ctx, paymentMethodID)
+		_, detachErr := s.gateway.DetachPaymentMethod(ctx, paymentMethodID)
 		if detachErr != nil {
 			// Логируем ошибку, но возвращаем оригинальную
 			fmt.Printf("Failed to detach payment method after DB error: %v\n", detachErr)
@@ -140,6 +215,96 @@ func (s *paymentService) AddPaymentMethod(ctx context.Context, userID int64, pay
 	return userPaymentMethod, nil
 }
 
+// CreateBankAccountSetupIntent создает SetupIntent, через который клиент привязывает банковский счет в Stripe.js
+func (s *paymentService) CreateBankAccountSetupIntent(ctx context.Context, userID int64) (*models.BankAccountSetupResponse, error) {
+	customerID, err := s.EnsureStripeCustomer(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure Stripe customer: %w", err)
+	}
+
+	setupIntent, err := s.gateway.CreateSetupIntent(ctx, customerID, models.PaymentMethodTypeUSBankAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BankAccountSetupResponse{
+		SetupIntentID: setupIntent.ID,
+		ClientSecret:  setupIntent.ClientSecret,
+		Status:        string(setupIntent.Status),
+	}, nil
+}
+
+// AddBankAccount сохраняет банковский счет по SetupIntent, подтвержденному клиентом.
+// Если банк подтверждает счет микродепозитами, счет сохраняется в статусе pending_verification
+// и становится доступен для оплаты после webhook setup_intent.succeeded
+func (s *paymentService) AddBankAccount(ctx context.Context, userID int64, setupIntentID string) (*models.UserPaymentMethod, error) {
+	customerID, err := s.EnsureStripeCustomer(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure Stripe customer: %w", err)
+	}
+
+	setupIntent, err := s.gateway.GetSetupIntent(ctx, setupIntentID)
+	if err != nil {
+		return nil, err
+	}
+	if setupIntent.Customer == nil || setupIntent.Customer.ID != customerID {
+		return nil, fmt.Errorf("%w: setup intent belongs to another customer", ErrBankAccountSetupInvalid)
+	}
+
+	pm := setupIntent.PaymentMethod
+	if pm == nil || pm.Type != stripe.PaymentMethodTypeUSBankAccount || pm.USBankAccount == nil {
+		return nil, fmt.Errorf("%w: setup intent has no bank account attached", ErrBankAccountSetupInvalid)
+	}
+
+	status := bankAccountStatus(setupIntent)
+	if status == "" {
+		return nil, fmt.Errorf("%w: bank account setup is not complete (status: %s)", ErrBankAccountSetupInvalid, setupIntent.Status)
+	}
+
+	existingPM, err := s.paymentRepo.GetPaymentMethodByStripeID(ctx, userID, pm.ID)
+	if err == nil && existingPM != nil {
+		return nil, fmt.Errorf("payment method already added")
+	}
+
+	bankAccount := &models.UserPaymentMethod{
+		UserID:                userID,
+		StripePaymentMethodID: pm.ID,
+		StripeCustomerID:      customerID,
+		Type:                  models.PaymentMethodTypeUSBankAccount,
+		Status:                status,
+		BankName:              pm.USBankAccount.BankName,
+		BankLast4:             pm.USBankAccount.Last4,
+		BankAccountType:       string(pm.USBankAccount.AccountType),
+		StripeSetupIntentID:   setupIntent.ID,
+		IsActive:              true,
+	}
+	if setupIntent.Mandate != nil {
+		bankAccount.StripeMandateID = setupIntent.Mandate.ID
+	}
+
+	if err := s.paymentRepo.SavePaymentMethod(ctx, bankAccount); err != nil {
+		return nil, fmt.Errorf("failed to save bank account: %w", err)
+	}
+
+	return bankAccount, nil
+}
+
+// bankAccountStatus сопоставляет статус SetupIntent со статусом банковского счета.
+// Пустая строка - клиент еще не привязал счет
+func bankAccountStatus(si *stripe.SetupIntent) string {
+	switch si.Status {
+	case stripe.SetupIntentStatusSucceeded:
+		return models.PaymentMethodStatusActive
+	case stripe.SetupIntentStatusProcessing:
+		return models.PaymentMethodStatusPendingVerification
+	case stripe.SetupIntentStatusRequiresAction:
+		if si.NextAction != nil && si.NextAction.Type == stripe.SetupIntentNextActionTypeVerifyWithMicrodeposits {
+			return models.PaymentMethodStatusPendingVerification
+		}
+	}
+	return ""
+}
+
 func (s *paymentService) GetUserPaymentMethods(ctx context.Context, userID int64) ([]models.UserPaymentMethod, error) {
 	return s.paymentRepo.GetUserPaymentMethods(ctx, userID)
 }
@@ -152,7 +317,7 @@ func (s *paymentService) DeletePaymentMethod(ctx context.Context, userID, paymen
 	}
 
 	// Отвязываем от Stripe customer
-	_, err = s.stripeService.DetachPaymentMethod(ctx, paymentMethod.StripePaymentMethodID)
+	_, err = s.gateway.DetachPaymentMethod(ctx, paymentMethod.StripePaymentMethodID)
 	if err != nil {
 		// Логируем ошибку, но продолжаем удаление из БД
 		fmt.Printf("Failed to detach payment method from Stripe: %v\n", err)
@@ -173,9 +338,12 @@ func (s *paymentService) SetDefaultPaymentMethod(ctx context.Context, userID, pa
 	if err != nil {
 		return fmt.Errorf("payment method not found: %w", err)
 	}
+	if !paymentMethod.IsUsable() {
+		return ErrPaymentMethodNotVerified
+	}
 
 	// Устанавливаем default в Stripe
-	err = s.stripeService.SetDefaultPaymentMethod(ctx, paymentMethod.StripeCustomerID, paymentMethod.StripePaymentMethodID)
+	err = s.gateway.SetDefaultPaymentMethod(ctx, paymentMethod.StripeCustomerID, paymentMethod.StripePaymentMethodID)
 	if err != nil {
 		// Логируем ошибку, но продолжаем обновление в БД
 		fmt.Printf("Failed to set default payment method in Stripe: %v\n", err)
@@ -196,41 +364,44 @@ func (s *paymentService) GetDefaultPaymentMethod(ctx context.Context, userID int
 
 // Payments
 func (s *paymentService) CreatePayment(ctx context.Context, userID int64, req *models.CreatePaymentRequest) (*models.CreatePaymentResponse, error) {
-	// Убеждаемся что у пользователя есть Stripe customer
-	customerID, err := s.EnsureStripeCustomer(ctx, userID)
+	customerID, paymentMethod, err := s.resolvePaymentMethod(ctx, userID, req.PaymentMethodID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to ensure Stripe customer: %w", err)
+		return nil, err
 	}
 
-	// Определяем payment method
-	var paymentMethod *models.UserPaymentMethod
-	if req.PaymentMethodID != nil {
-		// Используем указанный payment method
-		paymentMethod, err = s.paymentRepo.GetPaymentMethodByID(ctx, userID, *req.PaymentMethodID)
-		if err != nil {
-			return nil, fmt.Errorf("payment method not found: %w", err)
-		}
-	} else {
-		// Используем default payment method
-		paymentMethod, err = s.paymentRepo.GetDefaultPaymentMethod(ctx, userID)
-		if err != nil {
-			return nil, fmt.Errorf("no default payment method found: %w", err)
-		}
+	// Ключ клиента уникален только в пределах пользователя
+	var idempotencyKey string
+	if req.IdempotencyKey != "" {
+		idempotencyKey = fmt.Sprintf("payment-intent:%d:%s", userID, req.IdempotencyKey)
 	}
 
 	// Создаем Payment Intent в Stripe
-	paymentIntent, err := s.stripeService.CreatePaymentIntent(
+	paymentIntent, err := s.gateway.CreatePaymentIntent(
 		ctx,
 		req.AmountCents,
 		"usd", // TODO: сделать конфигурируемым
 		customerID,
-		paymentMethod.StripePaymentMethodID,
+		newPaymentMethodRef(paymentMethod),
 		req.Description,
+		idempotencyKey,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create payment intent: %w", err)
 	}
 
+	// Повтор запроса: Stripe вернул уже сохраненный PaymentIntent
+	if idempotencyKey != "" {
+		if existing, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, paymentIntent.ID); err == nil && existing != nil {
+			return &models.CreatePaymentResponse{
+				PaymentIntentID:      paymentIntent.ID,
+				ClientSecret:         paymentIntent.ClientSecret,
+				Status:               string(paymentIntent.Status),
+				RequiresConfirmation: paymentIntent.Status == stripe.PaymentIntentStatusRequiresConfirmation,
+				Success:              true,
+			}, nil
+		}
+	}
+
 	// Сохраняем платеж в БД
 	payment := &models.Payment{
 		UserID:                userID,
@@ -242,18 +413,23 @@ func (s *paymentService) CreatePayment(ctx context.Context, userID int64, req *m
 		Currency:              "usd",
 		Status:                string(paymentIntent.Status),
 		Description:           req.Description,
+		FeeBreakdown:          req.FeeBreakdown,
+		PaymentType:           req.PaymentType,
+		TipRecipientID:        req.TipRecipientID,
 	}
 
 	err = s.paymentRepo.SavePayment(ctx, payment)
 	if err != nil {
 		// Отменяем payment intent если не удалось сохранить в БД
-		_, cancelErr := s.stripeService.CancelPaymentIntent(ctx, paymentIntent.ID)
+		_, cancelErr := s.gateway.CancelPaymentIntent(ctx, paymentIntent.ID)
 		if cancelErr != nil {
 			fmt.Printf("Failed to cancel payment intent after DB error: %v\n", cancelErr)
 		}
 		return nil, fmt.Errorf("failed to save payment: %w", err)
 	}
 
+	s.paymentSucceeded(ctx, payment.ID, paymentIntent.Status)
+
 	return &models.CreatePaymentResponse{
 		PaymentIntentID:      paymentIntent.ID,
 		ClientSecret:         paymentIntent.ClientSecret,
@@ -263,9 +439,39 @@ func (s *paymentService) CreatePayment(ctx context.Context, userID int64, req *m
 	}, nil
 }
 
+// resolvePaymentMethod возвращает Stripe customer пользователя и карту для списания
+func (s *paymentService) resolvePaymentMethod(ctx context.Context, userID int64, paymentMethodID *int64) (string, *models.UserPaymentMethod, error) {
+	// Убеждаемся что у пользователя есть Stripe customer
+	customerID, err := s.EnsureStripeCustomer(ctx, userID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to ensure Stripe customer: %w", err)
+	}
+
+	// Определяем payment method
+	var paymentMethod *models.UserPaymentMethod
+	if paymentMethodID != nil {
+		// Используем указанный payment method
+		paymentMethod, err = s.paymentRepo.GetPaymentMethodByID(ctx, userID, *paymentMethodID)
+		if err != nil {
+			return "", nil, fmt.Errorf("payment method not found: %w", err)
+		}
+	} else {
+		// Используем default payment method
+		paymentMethod, err = s.paymentRepo.GetDefaultPaymentMethod(ctx, userID)
+		if err != nil {
+			return "", nil, fmt.Errorf("no default payment method found: %w", err)
+		}
+	}
+	if !paymentMethod.IsUsable() {
+		return "", nil, ErrPaymentMethodNotVerified
+	}
+
+	return customerID, paymentMethod, nil
+}
+
 func (s *paymentService) ConfirmPayment(ctx context.Context, paymentIntentID string) (*models.ConfirmPaymentResponse, error) {
 	// Подтверждаем платеж в Stripe
-	paymentIntent, err := s.stripeService.ConfirmPaymentIntent(ctx, paymentIntentID)
+	paymentIntent, err := s.gateway.ConfirmPaymentIntent(ctx, paymentIntentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to confirm payment intent: %w", err)
 	}
@@ -286,6 +492,12 @@ func (s *paymentService) ConfirmPayment(ctx context.Context, paymentIntentID str
 		return nil, fmt.Errorf("failed to update payment status: %w", err)
 	}
 
+	if err := s.syncEscrowState(ctx, payment, paymentIntent); err != nil {
+		return nil, err
+	}
+
+	s.paymentSucceeded(ctx, payment.ID, paymentIntent.Status)
+
 	return &models.ConfirmPaymentResponse{
 		PaymentID: payment.ID,
 		Status:    string(paymentIntent.Status),
@@ -295,29 +507,179 @@ func (s *paymentService) ConfirmPayment(ctx context.Context, paymentIntentID str
 }
 
 func (s *paymentService) GetUserPayments(ctx context.Context, userID int64, limit, offset int) ([]models.Payment, error) {
-	return s.paymentRepo.GetUserPayments(ctx, userID, limit, offset)
+	payments, err := s.paymentRepo.GetUserPayments(ctx, userID, limit, offset)
+	if err != nil || len(payments) == 0 {
+		return payments, err
+	}
+
+	paymentIDs := make([]int64, len(payments))
+	for i, p := range payments {
+		paymentIDs[i] = p.ID
+	}
+
+	refunds, err := s.paymentRepo.GetPaymentRefunds(ctx, paymentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+
+	byPayment := make(map[int64][]models.PaymentRefund)
+	for _, refund := range refunds {
+		byPayment[refund.PaymentID] = append(byPayment[refund.PaymentID], refund)
+	}
+	for i := range payments {
+		payments[i].Refunds = byPayment[payments[i].ID]
+	}
+
+	return payments, nil
+}
+
+// ApplyPaymentIntent приводит платеж к состоянию PaymentIntent в Stripe так же, как это сделал бы
+// пропущенный webhook: статус, escrow, сумма возврата, журнал и счет
+func (s *paymentService) ApplyPaymentIntent(ctx context.Context, payment *models.Payment, pi *stripe.PaymentIntent) error {
+	failureReason := payment.FailureReason
+	if pi.LastPaymentError != nil && pi.LastPaymentError.Err != nil {
+		failureReason = pi.LastPaymentError.Err.Error()
+	}
+
+	if string(pi.Status) != payment.Status || failureReason != payment.FailureReason {
+		if err := s.paymentRepo.UpdatePaymentStatus(ctx, payment.ID, string(pi.Status), failureReason); err != nil {
+			return fmt.Errorf("failed to update payment status: %w", err)
+		}
+	}
+
+	if err := s.syncEscrowState(ctx, payment, pi); err != nil {
+		return err
+	}
+
+	if charge := pi.LatestCharge; charge != nil && charge.AmountRefunded > payment.AmountRefundedCents {
+		if err := s.paymentRepo.UpdatePaymentRefund(ctx, payment.ID, charge.AmountRefunded, charge.Refunded); err != nil {
+			return fmt.Errorf("failed to update payment refund: %w", err)
+		}
+		s.postRefundToLedger(ctx, payment, charge.AmountRefunded)
+	}
+
+	s.paymentSucceeded(ctx, payment.ID, pi.Status)
+
+	return nil
 }
 
 // Webhook
 func (s *paymentService) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
-	event, err := s.stripeService.ConstructEvent(payload, signature)
+	event, err := s.gateway.ConstructEvent(payload, signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookSignature, err)
+	}
+
+	// Сохраняем событие до обработки: повторная доставка того же event ID не обработается дважды
+	stored := &models.StripeEvent{
+		StripeEventID: event.ID,
+		EventType:     string(event.Type),
+		Payload:       payload,
+	}
+	if _, err := s.paymentRepo.SaveStripeEvent(ctx, stored); err != nil {
+		return fmt.Errorf("failed to save webhook event: %w", err)
+	}
+
+	return s.processStripeEvent(ctx, event)
+}
+
+// ReplayStripeEvent повторно обрабатывает сохраненное событие, которое не удалось обработать
+func (s *paymentService) ReplayStripeEvent(ctx context.Context, stripeEventID string) error {
+	stored, err := s.paymentRepo.GetStripeEvent(ctx, stripeEventID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook event: %w", err)
+	}
+	if stored == nil {
+		return fmt.Errorf("webhook event %s not found", stripeEventID)
+	}
+	if stored.Status == models.StripeEventStatusProcessed || stored.Status == models.StripeEventStatusIgnored {
+		return fmt.Errorf("webhook event %s is already %s", stripeEventID, stored.Status)
+	}
+
+	var event stripe.Event
+	if err := json.Unmarshal(stored.Payload, &event); err != nil {
+		return fmt.Errorf("failed to parse stored webhook event: %w", err)
+	}
+
+	return s.processStripeEvent(ctx, event)
+}
+
+func (s *paymentService) ReplayFailedStripeEvents(ctx context.Context, limit int) (int, int, error) {
+	events, err := s.paymentRepo.GetStripeEventsByStatus(ctx, models.StripeEventStatusFailed, limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get failed webhook events: %w", err)
+	}
+
+	replayed, failed := 0, 0
+	for _, event := range events {
+		if err := s.ReplayStripeEvent(ctx, event.StripeEventID); err != nil {
+			fmt.Printf("Failed to replay webhook event %s (%s): %v\n", event.StripeEventID, event.EventType, err)
+			failed++
+			continue
+		}
+		replayed++
+	}
+
+	return replayed, failed, nil
+}
+
+// processStripeEvent обрабатывает событие ровно один раз: захватывает его в журнале,
+// выполняет обработчик и сохраняет результат
+func (s *paymentService) processStripeEvent(ctx context.Context, event stripe.Event) error {
+	claimed, err := s.paymentRepo.ClaimStripeEvent(ctx, event.ID)
 	if err != nil {
-		return fmt.Errorf("failed to construct webhook event: %w", err)
+		return fmt.Errorf("failed to claim webhook event: %w", err)
+	}
+	if !claimed {
+		// Уже обработано или обрабатывается параллельной доставкой
+		return nil
+	}
+
+	handled, handleErr := s.dispatchStripeEvent(ctx, event)
+
+	status, lastError := models.StripeEventStatusProcessed, ""
+	switch {
+	case handleErr != nil:
+		status, lastError = models.StripeEventStatusFailed, handleErr.Error()
+	case !handled:
+		status = models.StripeEventStatusIgnored
+	}
+
+	if err := s.paymentRepo.FinishStripeEvent(ctx, event.ID, status, lastError); err != nil {
+		fmt.Printf("Failed to save webhook event %s result: %v\n", event.ID, err)
 	}
 
+	return handleErr
+}
+
+func (s *paymentService) dispatchStripeEvent(ctx context.Context, event stripe.Event) (bool, error) {
 	switch event.Type {
 	case "payment_intent.succeeded":
-		return s.handlePaymentIntentSucceeded(ctx, event)
+		return true, s.handlePaymentIntentSucceeded(ctx, event)
 	case "payment_intent.payment_failed":
-		return s.handlePaymentIntentFailed(ctx, event)
-	// case "payment_method.attached":
-	// 	return s.handlePaymentMethodAttached(ctx, event)
-	// case "payment_method.detached":
-	// 	return s.handlePaymentMethodDetached(ctx, event)
+		return true, s.handlePaymentIntentFailed(ctx, event)
+	case "payment_intent.amount_capturable_updated", "payment_intent.canceled", "payment_intent.processing":
+		return true, s.handleEscrowIntentUpdated(ctx, event)
+	case "setup_intent.succeeded", "setup_intent.setup_failed":
+		return true, s.handleSetupIntentUpdated(ctx, event)
+	case "charge.refunded":
+		return true, s.handleChargeRefunded(ctx, event)
+	case "refund.created", "refund.updated", "refund.failed", "charge.refund.updated":
+		return true, s.handleRefundUpdated(ctx, event)
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed",
+		"charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		return true, s.handleDisputeEvent(ctx, event)
+	case "payment_method.detached":
+		return true, s.handlePaymentMethodDetached(ctx, event)
+	case "payment_method.updated", "payment_method.automatically_updated":
+		return true, s.handlePaymentMethodUpdated(ctx, event)
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted",
+		"invoice.paid", "invoice.payment_failed":
+		return s.subscriptionService.HandleStripeEvent(ctx, event)
 	default:
 		// Игнорируем неизвестные события
 		fmt.Printf("Received unhandled webhook event: %s\n", event.Type)
-		return nil
+		return false, nil
 	}
 }
 
@@ -330,7 +692,7 @@ func (s *paymentService) handlePaymentIntentSucceeded(ctx context.Context, event
 	}
 
 	// Получаем полную информацию о payment intent
-	fullPaymentIntent, err := s.stripeService.GetPaymentIntent(ctx, paymentIntent.ID)
+	fullPaymentIntent, err := s.gateway.GetPaymentIntent(ctx, paymentIntent.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get payment intent: %w", err)
 	}
@@ -346,13 +708,11 @@ func (s *paymentService) handlePaymentIntentSucceeded(ctx context.Context, event
 		return fmt.Errorf("failed to update payment status: %w", err)
 	}
 
-	// TODO: Активировать job если это платеж за размещение
-	// if payment.JobID > 0 {
-	//     err = s.jobService.ActivateJob(ctx, payment.JobID)
-	//     if err != nil {
-	//         log.Printf("Failed to activate job %d: %v", payment.JobID, err)
-	//     }
-	// }
+	if err := s.syncEscrowState(ctx, payment, fullPaymentIntent); err != nil {
+		return err
+	}
+
+	s.paymentSucceeded(ctx, payment.ID, fullPaymentIntent.Status)
 
 	return nil
 }
@@ -364,7 +724,7 @@ func (s *paymentService) handlePaymentIntentFailed(ctx context.Context, event st
 		return fmt.Errorf("failed to parse payment intent from webhook: %w", err)
 	}
 
-	fullPaymentIntent, err := s.stripeService.GetPaymentIntent(ctx, paymentIntent.ID)
+	fullPaymentIntent, err := s.gateway.GetPaymentIntent(ctx, paymentIntent.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get payment intent: %w", err)
 	}
@@ -384,20 +744,895 @@ func (s *paymentService) handlePaymentIntentFailed(ctx context.Context, event st
 		return fmt.Errorf("failed to update payment status: %w", err)
 	}
 
+	return s.syncEscrowState(ctx, payment, fullPaymentIntent)
+}
+
+// handleEscrowIntentUpdated - авторизация подтверждена клиентом (3DS),
+// отменена нами или истекла на стороне Stripe, либо списание с банковского счета ожидает расчета
+func (s *paymentService) handleEscrowIntentUpdated(ctx context.Context, event stripe.Event) error {
+	var paymentIntent stripe.PaymentIntent
+	err := json.Unmarshal(event.Data.Raw, &paymentIntent)
+	if err != nil {
+		return fmt.Errorf("failed to parse payment intent from webhook: %w", err)
+	}
+
+	fullPaymentIntent, err := s.gateway.GetPaymentIntent(ctx, paymentIntent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get payment intent: %w", err)
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, fullPaymentIntent.ID)
+	if err != nil {
+		return fmt.Errorf("payment not found: %w", err)
+	}
+
+	err = s.paymentRepo.UpdatePaymentStatus(ctx, payment.ID, string(fullPaymentIntent.Status), payment.FailureReason)
+	if err != nil {
+		return fmt.Errorf("failed to update payment status: %w", err)
+	}
+
+	return s.syncEscrowState(ctx, payment, fullPaymentIntent)
+}
+
+// handleSetupIntentUpdated - банковский счет подтвержден (в т.ч. микродепозитами) или не прошел проверку
+func (s *paymentService) handleSetupIntentUpdated(ctx context.Context, event stripe.Event) error {
+	var setupIntent stripe.SetupIntent
+	if err := json.Unmarshal(event.Data.Raw, &setupIntent); err != nil {
+		return fmt.Errorf("failed to parse setup intent from webhook: %w", err)
+	}
+
+	status := models.PaymentMethodStatusVerificationFailed
+	if setupIntent.Status == stripe.SetupIntentStatusSucceeded {
+		status = models.PaymentMethodStatusActive
+	}
+	var mandateID string
+	if setupIntent.Mandate != nil {
+		mandateID = setupIntent.Mandate.ID
+	}
+
+	// Счет, который клиент еще не сохранил, получит актуальный статус в AddBankAccount
+	if _, err := s.paymentRepo.UpdateBankAccountVerification(ctx, setupIntent.ID, status, mandateID); err != nil {
+		return fmt.Errorf("failed to update bank account: %w", err)
+	}
+
+	return nil
+}
+
+// handleChargeRefunded - возврат (в т.ч. сделанный в Stripe Dashboard)
+func (s *paymentService) handleChargeRefunded(ctx context.Context, event stripe.Event) error {
+	var charge stripe.Charge
+	if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+		return fmt.Errorf("failed to parse charge from webhook: %w", err)
+	}
+	if charge.PaymentIntent == nil {
+		return nil
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, charge.PaymentIntent.ID)
+	if err != nil {
+		return fmt.Errorf("payment not found: %w", err)
+	}
+
+	err = s.paymentRepo.UpdatePaymentRefund(ctx, payment.ID, charge.AmountRefunded, charge.Refunded)
+	if err != nil {
+		return fmt.Errorf("failed to update payment refund: %w", err)
+	}
+
+	s.postRefundToLedger(ctx, payment, charge.AmountRefunded)
+
+	return nil
+}
+
+// handleRefundUpdated отслеживает статус возврата; возврат, сделанный в Stripe Dashboard, сохраняется
+func (s *paymentService) handleRefundUpdated(ctx context.Context, event stripe.Event) error {
+	var stripeRefund stripe.Refund
+	if err := json.Unmarshal(event.Data.Raw, &stripeRefund); err != nil {
+		return fmt.Errorf("failed to parse refund from webhook: %w", err)
+	}
+
+	// Наш возврат ищем по metadata: событие может прийти раньше, чем сохранен stripe_refund_id
+	var refund *models.PaymentRefund
+	var err error
+	if refundID, parseErr := strconv.ParseInt(stripeRefund.Metadata["refund_id"], 10, 64); parseErr == nil {
+		refund, err = s.paymentRepo.GetRefundByID(ctx, refundID)
+	} else {
+		refund, err = s.paymentRepo.GetRefundByStripeID(ctx, stripeRefund.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get refund: %w", err)
+	}
+
+	if refund != nil {
+		wasActive := refund.Status != models.RefundStatusFailed && refund.Status != models.RefundStatusCanceled
+		refund.StripeRefundID = &stripeRefund.ID
+		refund.Status = string(stripeRefund.Status)
+		refund.FailureReason = string(stripeRefund.FailureReason)
+		if err := s.paymentRepo.UpdateRefund(ctx, refund); err != nil {
+			return fmt.Errorf("failed to update refund: %w", err)
+		}
+
+		// Не прошедший возврат больше не уменьшает выплату исполнителю
+		if wasActive && (refund.Status == models.RefundStatusFailed || refund.Status == models.RefundStatusCanceled) {
+			payment, err := s.paymentRepo.GetPaymentByID(ctx, refund.PaymentID)
+			if err != nil {
+				return fmt.Errorf("failed to get payment: %w", err)
+			}
+			if payment != nil {
+				s.restorePayout(ctx, payment, refund)
+			}
+		}
+		return nil
+	}
+
+	if stripeRefund.PaymentIntent == nil {
+		return nil
+	}
+
+	// Платеж может быть создан вне платформы - такой возврат не сохраняем
+	payment, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, stripeRefund.PaymentIntent.ID)
+	if err != nil {
+		return nil
+	}
+
+	external := &models.PaymentRefund{
+		PaymentID:      payment.ID,
+		StripeRefundID: &stripeRefund.ID,
+		AmountCents:    stripeRefund.Amount,
+		Currency:       string(stripeRefund.Currency),
+		Reason:         models.RefundReasonExternal,
+		Status:         string(stripeRefund.Status),
+		FailureReason:  string(stripeRefund.FailureReason),
+	}
+	if err := s.paymentRepo.UpsertStripeRefund(ctx, external); err != nil {
+		return fmt.Errorf("failed to save refund: %w", err)
+	}
+
+	return nil
+}
+
+// handleDisputeEvent сохраняет спор (chargeback) и его текущий статус
+func (s *paymentService) handleDisputeEvent(ctx context.Context, event stripe.Event) error {
+	var dispute stripe.Dispute
+	if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+		return fmt.Errorf("failed to parse dispute from webhook: %w", err)
+	}
+
+	record := &models.PaymentDispute{
+		StripeDisputeID: dispute.ID,
+		AmountCents:     dispute.Amount,
+		Currency:        string(dispute.Currency),
+		Reason:          string(dispute.Reason),
+		Status:          string(dispute.Status),
+	}
+	if dispute.EvidenceDetails != nil && dispute.EvidenceDetails.DueBy > 0 {
+		dueBy := time.Unix(dispute.EvidenceDetails.DueBy, 0)
+		record.EvidenceDueBy = &dueBy
+	}
+	if dispute.PaymentIntent != nil {
+		record.StripePaymentIntentID = dispute.PaymentIntent.ID
+		// Платеж может быть создан вне платформы - тогда спор сохраняется без привязки
+		if payment, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, dispute.PaymentIntent.ID); err == nil {
+			record.PaymentID = &payment.ID
+		}
+	}
+
+	if err := s.paymentRepo.SaveDispute(ctx, record); err != nil {
+		return fmt.Errorf("failed to save dispute: %w", err)
+	}
+
+	return nil
+}
+
+// handlePaymentMethodDetached - карта отвязана в Stripe (Dashboard, истечение и т.п.)
+func (s *paymentService) handlePaymentMethodDetached(ctx context.Context, event stripe.Event) error {
+	var paymentMethod stripe.PaymentMethod
+	if err := json.Unmarshal(event.Data.Raw, &paymentMethod); err != nil {
+		return fmt.Errorf("failed to parse payment method from webhook: %w", err)
+	}
+
+	if err := s.paymentRepo.DeactivatePaymentMethodByStripeID(ctx, paymentMethod.ID); err != nil {
+		return fmt.Errorf("failed to deactivate payment method: %w", err)
+	}
+
 	return nil
 }
 
-// func (s *paymentService) handlePaymentMethodAttached(ctx context.Context, event stripe.Event) error {
-// 	// Этот webhook можно использовать для дополнительной валидации
-// 	// или логирования, но основная логика уже в AddPaymentMethod
-// 	return nil
-// }
+// handlePaymentMethodUpdated - банк перевыпустил карту или обновил срок действия
+func (s *paymentService) handlePaymentMethodUpdated(ctx context.Context, event stripe.Event) error {
+	var paymentMethod stripe.PaymentMethod
+	if err := json.Unmarshal(event.Data.Raw, &paymentMethod); err != nil {
+		return fmt.Errorf("failed to parse payment method from webhook: %w", err)
+	}
+	if paymentMethod.Card == nil {
+		return nil
+	}
 
-// func (s *paymentService) handlePaymentMethodDetached(ctx context.Context, event stripe.Event) error {
-// 	// Этот webhook можно использовать для дополнительной валидации
-// 	// или логирования, но основная логика уже в DeletePaymentMethod
-// 	return nil
-// }
+	err := s.paymentRepo.UpdatePaymentMethodCard(
+		ctx,
+		paymentMethod.ID,
+		string(paymentMethod.Card.Brand),
+		paymentMethod.Card.Last4,
+		int(paymentMethod.Card.ExpMonth),
+		int(paymentMethod.Card.ExpYear),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update payment method: %w", err)
+	}
+
+	return nil
+}
+
+// Escrow
+const (
+	// escrowAuthorizationValidity - сколько Stripe держит авторизацию карты без списания
+	escrowAuthorizationValidity = 7 * 24 * time.Hour
+	// escrowReauthorizeBefore - за сколько до истечения авторизация продлевается
+	escrowReauthorizeBefore = 24 * time.Hour
+	// escrowReauthorizeBatchSize - сколько авторизаций продлевается за один проход
+	escrowReauthorizeBatchSize = 50
+)
+
+// AuthorizeJobPayment блокирует оплату работы на карте заказчика (capture_method = manual).
+// Деньги списываются в CaptureJobPayment после выполнения работы
+func (s *paymentService) AuthorizeJobPayment(ctx context.Context, userID int64, req *models.CreatePaymentRequest) (*models.CreatePaymentResponse, error) {
+	if req.JobID == nil {
+		return nil, fmt.Errorf("escrow payment requires a job")
+	}
+
+	customerID, paymentMethod, err := s.resolvePaymentMethod(ctx, userID, req.PaymentMethodID)
+	if err != nil {
+		return nil, err
+	}
+
+	var idempotencyKey string
+	if req.IdempotencyKey != "" {
+		idempotencyKey = fmt.Sprintf("authorization:%d:%s", userID, req.IdempotencyKey)
+	}
+
+	paymentIntent, err := s.gateway.CreateAuthorizationIntent(
+		ctx,
+		req.AmountCents,
+		"usd",
+		customerID,
+		newPaymentMethodRef(paymentMethod),
+		req.Description,
+		false,
+		idempotencyKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize payment: %w", err)
+	}
+
+	// Повтор запроса: авторизация уже сохранена, ее состояние обновляют webhook-события
+	if idempotencyKey != "" {
+		if existing, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, paymentIntent.ID); err == nil && existing != nil {
+			if existing.AuthorizationStatus == models.AuthorizationStatusFailed {
+				return nil, fmt.Errorf("%w (status: %s)", ErrPaymentDeclined, existing.Status)
+			}
+			return &models.CreatePaymentResponse{
+				PaymentIntentID:      paymentIntent.ID,
+				ClientSecret:         paymentIntent.ClientSecret,
+				Status:               string(paymentIntent.Status),
+				RequiresConfirmation: paymentIntent.Status == stripe.PaymentIntentStatusRequiresAction,
+				Success:              true,
+			}, nil
+		}
+	}
+
+	payment := &models.Payment{
+		UserID:                userID,
+		JobID:                 req.JobID,
+		StripePaymentIntentID: paymentIntent.ID,
+		StripePaymentMethodID: paymentMethod.StripePaymentMethodID,
+		StripeCustomerID:      customerID,
+		AmountCents:           req.AmountCents,
+		Currency:              "usd",
+		Status:                string(paymentIntent.Status),
+		Description:           req.Description,
+		CaptureMethod:         models.PaymentCaptureManual,
+		FeeBreakdown:          req.FeeBreakdown,
+	}
+	applyEscrowState(payment, paymentIntent, time.Now())
+
+	if payment.AuthorizationStatus == models.AuthorizationStatusFailed {
+		return nil, fmt.Errorf("%w (status: %s)", ErrPaymentDeclined, paymentIntent.Status)
+	}
+
+	err = s.paymentRepo.SavePayment(ctx, payment)
+	if err != nil {
+		_, cancelErr := s.gateway.CancelPaymentIntent(ctx, paymentIntent.ID)
+		if cancelErr != nil {
+			fmt.Printf("Failed to cancel authorization after DB error: %v\n", cancelErr)
+		}
+		return nil, fmt.Errorf("failed to save payment: %w", err)
+	}
+
+	return &models.CreatePaymentResponse{
+		PaymentIntentID:      paymentIntent.ID,
+		ClientSecret:         paymentIntent.ClientSecret,
+		Status:               string(paymentIntent.Status),
+		RequiresConfirmation: paymentIntent.Status == stripe.PaymentIntentStatusRequiresAction,
+		Success:              true,
+	}, nil
+}
+
+// CaptureJobPayment списывает авторизованную оплату работы.
+// Возвращает nil, если работа была оплачена без escrow
+func (s *paymentService) CaptureJobPayment(ctx context.Context, jobID int64) (*models.Payment, error) {
+	payment, err := s.paymentRepo.GetJobEscrowPayment(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escrow payment: %w", err)
+	}
+	if payment == nil || payment.CaptureStatus == models.CaptureStatusCaptured {
+		return payment, nil
+	}
+
+	if payment.AuthorizationStatus != models.AuthorizationStatusAuthorized {
+		return nil, fmt.Errorf("%w: payment %d authorization is %s", ErrPaymentNotCapturable, payment.ID, payment.AuthorizationStatus)
+	}
+
+	paymentIntent, err := s.gateway.CapturePaymentIntent(ctx, payment.StripePaymentIntentID)
+	if err != nil {
+		if updateErr := s.paymentRepo.UpdatePaymentStatus(ctx, payment.ID, payment.Status, err.Error()); updateErr != nil {
+			fmt.Printf("Failed to save capture error for payment %d: %v\n", payment.ID, updateErr)
+		}
+		return nil, fmt.Errorf("failed to capture payment: %w", err)
+	}
+
+	err = s.paymentRepo.MarkPaymentCaptured(ctx, payment.ID, paymentIntent.AmountReceived, string(paymentIntent.Status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payment capture: %w", err)
+	}
+
+	s.paymentSucceeded(ctx, payment.ID, paymentIntent.Status)
+
+	return s.paymentRepo.GetJobEscrowPayment(ctx, jobID)
+}
+
+// ReleaseJobPayment снимает блокировку с карты при отмене работы,
+// а если оплата уже была списана - возвращает ее
+func (s *paymentService) ReleaseJobPayment(ctx context.Context, jobID int64) (*models.Payment, error) {
+	payment, err := s.paymentRepo.GetJobEscrowPayment(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escrow payment: %w", err)
+	}
+	if payment == nil {
+		return nil, nil
+	}
+
+	switch payment.CaptureStatus {
+	case models.CaptureStatusCaptured:
+		// Платеж станет refunded, когда возврат пройдет: сразу или по webhook charge.refunded.
+		// Если весь остаток уже возвращается, повторный вызов ничего не делает
+		_, err := s.refundPayment(ctx, payment, 0, models.RefundReasonJobCanceled, "Escrow returned after job cancellation", nil)
+		if err != nil && !errors.Is(err, ErrRefundInvalid) {
+			return nil, fmt.Errorf("failed to refund payment: %w", err)
+		}
+	case models.CaptureStatusUncaptured:
+		// Списание с банковского счета нельзя отменить, пока идет расчет; после него оплата возвращается
+		if payment.Status == string(stripe.PaymentIntentStatusProcessing) {
+			return nil, ErrPaymentSettling
+		}
+		paymentIntent, err := s.gateway.CancelPaymentIntent(ctx, payment.StripePaymentIntentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to release authorization: %w", err)
+		}
+		err = s.paymentRepo.MarkPaymentReleased(ctx, payment.ID, string(paymentIntent.Status), models.AuthorizationStatusReleased)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update payment release: %w", err)
+		}
+	default:
+		// Уже освобождена, возвращена или не была авторизована
+		return payment, nil
+	}
+
+	return s.paymentRepo.GetJobEscrowPayment(ctx, jobID)
+}
+
+// VoidPayment отменяет платеж, который больше не нужен (публикация работы не прошла):
+// неподтвержденный PaymentIntent отменяется, уже списанный платеж возвращается
+func (s *paymentService) VoidPayment(ctx context.Context, paymentIntentID string) error {
+	payment, err := s.paymentRepo.GetPaymentByStripeIntentID(ctx, paymentIntentID)
+	if err != nil {
+		return fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	switch stripe.PaymentIntentStatus(payment.Status) {
+	case stripe.PaymentIntentStatusCanceled:
+		return nil
+	case stripe.PaymentIntentStatusProcessing:
+		return ErrPaymentSettling
+	case stripe.PaymentIntentStatusSucceeded:
+		_, err := s.refundPayment(ctx, payment, 0, models.RefundReasonJobCanceled, "Job posting was not completed", nil)
+		if err != nil && !errors.Is(err, ErrRefundInvalid) {
+			return fmt.Errorf("failed to refund payment: %w", err)
+		}
+		return nil
+	}
+
+	paymentIntent, err := s.gateway.CancelPaymentIntent(ctx, paymentIntentID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel payment: %w", err)
+	}
+	if err := s.paymentRepo.UpdatePaymentStatus(ctx, payment.ID, string(paymentIntent.Status), ""); err != nil {
+		return fmt.Errorf("failed to update payment status: %w", err)
+	}
+
+	return nil
+}
+
+// ReauthorizeExpiringAuthorizations продлевает авторизации, срок которых скоро истекает:
+// создает новую авторизацию по сохраненной карте и освобождает старую
+func (s *paymentService) ReauthorizeExpiringAuthorizations(ctx context.Context) (int, error) {
+	payments, err := s.paymentRepo.GetExpiringAuthorizations(ctx, time.Now().Add(escrowReauthorizeBefore), escrowReauthorizeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expiring authorizations: %w", err)
+	}
+
+	renewed := 0
+	for i := range payments {
+		if err := s.reauthorize(ctx, &payments[i]); err != nil {
+			fmt.Printf("Failed to reauthorize payment %d: %v\n", payments[i].ID, err)
+			continue
+		}
+		renewed++
+	}
+
+	return renewed, nil
+}
+
+func (s *paymentService) reauthorize(ctx context.Context, old *models.Payment) error {
+	paymentIntent, err := s.gateway.CreateAuthorizationIntent(
+		ctx,
+		old.AmountCents,
+		old.Currency,
+		old.StripeCustomerID,
+		PaymentMethodRef{ID: old.StripePaymentMethodID},
+		old.Description,
+		true,
+		"",
+	)
+	if err != nil {
+		// Старая авторизация действует до истечения срока, попробуем еще раз на следующем проходе
+		if updateErr := s.paymentRepo.UpdatePaymentStatus(ctx, old.ID, old.Status, fmt.Sprintf("reauthorization failed: %v", err)); updateErr != nil {
+			fmt.Printf("Failed to save reauthorization error for payment %d: %v\n", old.ID, updateErr)
+		}
+		return err
+	}
+
+	if paymentIntent.Status != stripe.PaymentIntentStatusRequiresCapture {
+		if _, cancelErr := s.gateway.CancelPaymentIntent(ctx, paymentIntent.ID); cancelErr != nil {
+			fmt.Printf("Failed to cancel incomplete reauthorization %s: %v\n", paymentIntent.ID, cancelErr)
+		}
+		return fmt.Errorf("reauthorization was not approved (status: %s)", paymentIntent.Status)
+	}
+
+	renewed := &models.Payment{
+		UserID:                    old.UserID,
+		JobID:                     old.JobID,
+		StripePaymentIntentID:     paymentIntent.ID,
+		StripePaymentMethodID:     old.StripePaymentMethodID,
+		StripeCustomerID:          old.StripeCustomerID,
+		AmountCents:               old.AmountCents,
+		Currency:                  old.Currency,
+		Status:                    string(paymentIntent.Status),
+		Description:               old.Description,
+		CaptureMethod:             models.PaymentCaptureManual,
+		ReauthorizedFromPaymentID: &old.ID,
+		FeeBreakdown:              old.FeeBreakdown,
+	}
+	applyEscrowState(renewed, paymentIntent, time.Now())
+
+	err = s.paymentRepo.SavePayment(ctx, renewed)
+	if err != nil {
+		if _, cancelErr := s.gateway.CancelPaymentIntent(ctx, paymentIntent.ID); cancelErr != nil {
+			fmt.Printf("Failed to cancel reauthorization after DB error: %v\n", cancelErr)
+		}
+		return fmt.Errorf("failed to save reauthorized payment: %w", err)
+	}
+
+	// Старую авторизацию освобождаем, чтобы не держать сумму на карте дважды
+	status := string(stripe.PaymentIntentStatusCanceled)
+	if _, err := s.gateway.CancelPaymentIntent(ctx, old.StripePaymentIntentID); err != nil {
+		fmt.Printf("Failed to release replaced authorization %s: %v\n", old.StripePaymentIntentID, err)
+		status = old.Status
+	}
+
+	return s.paymentRepo.MarkPaymentReleased(ctx, old.ID, status, models.AuthorizationStatusReplaced)
+}
+
+// StartAuthorizationRenewer периодически продлевает истекающие escrow-авторизации
+func (s *paymentService) StartAuthorizationRenewer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		renewed, err := s.ReauthorizeExpiringAuthorizations(ctx)
+		if err != nil {
+			fmt.Printf("Authorization renewer error: %v\n", err)
+		} else if renewed > 0 {
+			fmt.Printf("Authorization renewer: reauthorized %d payments\n", renewed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// escrowState сопоставляет статус PaymentIntent с состояниями авторизации и списания
+func escrowState(pi *stripe.PaymentIntent) (authorizationStatus, captureStatus string) {
+	switch pi.Status {
+	case stripe.PaymentIntentStatusRequiresCapture:
+		return models.AuthorizationStatusAuthorized, models.CaptureStatusUncaptured
+	case stripe.PaymentIntentStatusSucceeded:
+		return models.AuthorizationStatusAuthorized, models.CaptureStatusCaptured
+	case stripe.PaymentIntentStatusCanceled:
+		if pi.CancellationReason == stripe.PaymentIntentCancellationReasonAutomatic {
+			return models.AuthorizationStatusExpired, models.CaptureStatusCanceled
+		}
+		return models.AuthorizationStatusReleased, models.CaptureStatusCanceled
+	case stripe.PaymentIntentStatusRequiresPaymentMethod:
+		return models.AuthorizationStatusFailed, models.CaptureStatusNone
+	default:
+		return models.AuthorizationStatusPending, models.CaptureStatusUncaptured
+	}
+}
+
+func applyEscrowState(payment *models.Payment, pi *stripe.PaymentIntent, now time.Time) {
+	payment.AuthorizationStatus, payment.CaptureStatus = escrowState(pi)
+	if payment.AuthorizationStatus == models.AuthorizationStatusAuthorized && payment.AuthorizedAt == nil {
+		expiresAt := now.Add(escrowAuthorizationValidity)
+		payment.AuthorizedAt = &now
+		payment.AuthorizationExpiresAt = &expiresAt
+	}
+}
+
+// syncEscrowState обновляет состояния escrow-платежа по данным Stripe (подтверждение, webhook)
+func (s *paymentService) syncEscrowState(ctx context.Context, payment *models.Payment, pi *stripe.PaymentIntent) error {
+	if !payment.IsEscrow() {
+		return nil
+	}
+
+	// Освобожденные и замененные авторизации больше не меняются
+	switch payment.AuthorizationStatus {
+	case models.AuthorizationStatusReleased, models.AuthorizationStatusExpired, models.AuthorizationStatusReplaced:
+		return nil
+	}
+
+	previousCapture := payment.CaptureStatus
+	previousAuthorizedAt := payment.AuthorizedAt
+	applyEscrowState(payment, pi, time.Now())
+
+	var err error
+	switch {
+	case payment.CaptureStatus == models.CaptureStatusCaptured && previousCapture != models.CaptureStatusCaptured:
+		err = s.paymentRepo.MarkPaymentCaptured(ctx, payment.ID, pi.AmountReceived, string(pi.Status))
+	case payment.CaptureStatus == models.CaptureStatusCanceled:
+		err = s.paymentRepo.MarkPaymentReleased(ctx, payment.ID, string(pi.Status), payment.AuthorizationStatus)
+	case previousAuthorizedAt == nil && payment.AuthorizedAt != nil:
+		err = s.paymentRepo.UpdateAuthorizationState(ctx, payment.ID, payment.AuthorizationStatus, payment.CaptureStatus, payment.AuthorizedAt, payment.AuthorizationExpiresAt)
+	default:
+		err = s.paymentRepo.UpdateAuthorizationState(ctx, payment.ID, payment.AuthorizationStatus, payment.CaptureStatus, nil, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update escrow state: %w", err)
+	}
+
+	return nil
+}
+
+// paymentSucceeded проводит успешный платеж по журналу и выставляет по нему счет.
+// Ошибки только логируются: платеж в Stripe уже прошел
+func (s *paymentService) paymentSucceeded(ctx context.Context, paymentID int64, status stripe.PaymentIntentStatus) {
+	if status != stripe.PaymentIntentStatusSucceeded {
+		return
+	}
+
+	if s.ledgerService != nil {
+		if err := s.postPaymentToLedger(ctx, paymentID); err != nil {
+			fmt.Printf("Failed to post payment %d to ledger: %v\n", paymentID, err)
+		}
+	}
+
+	if s.payoutService != nil {
+		if err := s.recordTipPayout(ctx, paymentID); err != nil {
+			fmt.Printf("Failed to record tip payout for payment %d: %v\n", paymentID, err)
+		}
+	}
+
+	s.issueInvoice(paymentID)
+
+	if len(s.succeededHandlers) > 0 {
+		payment, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+		if err != nil || payment == nil {
+			fmt.Printf("Failed to get payment %d for success handlers: %v\n", paymentID, err)
+			return
+		}
+		for _, handler := range s.succeededHandlers {
+			if err := handler(ctx, payment); err != nil {
+				fmt.Printf("Payment %d success handler failed: %v\n", paymentID, err)
+			}
+		}
+	}
+}
+
+func (s *paymentService) OnPaymentSucceeded(handler PaymentSucceededHandler) {
+	s.succeededHandlers = append(s.succeededHandlers, handler)
+}
+
+func (s *paymentService) postPaymentToLedger(ctx context.Context, paymentID int64) error {
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment == nil {
+		return fmt.Errorf("payment %d not found", paymentID)
+	}
+
+	if payment.IsTip() {
+		return s.ledgerService.PostTipCharge(ctx, payment)
+	}
+	if payment.IsEscrow() {
+		if payment.CaptureStatus != models.CaptureStatusCaptured {
+			return nil
+		}
+		return s.ledgerService.PostPaymentCapture(ctx, payment)
+	}
+	return s.ledgerService.PostPaymentCharge(ctx, payment)
+}
+
+// Tips
+
+func (s *paymentService) TipJob(ctx context.Context, userID, jobID int64, req *models.CreateTipRequest) (*models.CreatePaymentResponse, error) {
+	job, err := s.paymentRepo.GetJobParticipants(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if job == nil || job.ContractorID != userID {
+		return nil, ErrJobNotFound
+	}
+	if job.JobStatus != "completed" || job.ExecutorID == nil {
+		return nil, fmt.Errorf("%w: job is %s, not completed", ErrTipNotAllowed, job.JobStatus)
+	}
+
+	existing, err := s.paymentRepo.GetJobTip(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job tip: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrTipAlreadyPaid
+	}
+
+	// Чаевые списываются как обычный платеж; исполнитель получает их после успешного списания
+	response, err := s.CreatePayment(ctx, userID, &models.CreatePaymentRequest{
+		JobID:           &jobID,
+		PaymentMethodID: req.PaymentMethodID,
+		AmountCents:     req.AmountCents,
+		Description:     fmt.Sprintf("Tip for job #%d", jobID),
+		PaymentType:     models.PaymentTypeTip,
+		TipRecipientID:  job.ExecutorID,
+		IdempotencyKey:  req.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cancelStaleTips(ctx, jobID, response.PaymentIntentID)
+
+	return response, nil
+}
+
+// cancelStaleTips отменяет брошенные неподтвержденные чаевые по работе, кроме текущих,
+// чтобы клиент не мог позже подтвердить их вместе с новыми
+func (s *paymentService) cancelStaleTips(ctx context.Context, jobID int64, currentIntentID string) {
+	tips, err := s.paymentRepo.GetUnconfirmedJobTips(ctx, jobID)
+	if err != nil {
+		fmt.Printf("Failed to get unconfirmed tips for job %d: %v\n", jobID, err)
+		return
+	}
+
+	for i := range tips {
+		if tips[i].StripePaymentIntentID == currentIntentID {
+			continue
+		}
+		if err := s.VoidPayment(ctx, tips[i].StripePaymentIntentID); err != nil {
+			fmt.Printf("Failed to cancel stale tip %d for job %d: %v\n", tips[i].ID, jobID, err)
+		}
+	}
+}
+
+// recordTipPayout начисляет исполнителю прошедшие чаевые. Повторный вызов ничего не делает
+func (s *paymentService) recordTipPayout(ctx context.Context, paymentID int64) error {
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment == nil || !payment.IsTip() {
+		return nil
+	}
+
+	_, err = s.payoutService.RecordTipPayout(ctx, payment)
+	return err
+}
+
+// Refunds
+
+// stripeRefundReasons - причины, которые принимает Stripe; остальные передаются только в metadata
+var stripeRefundReasons = map[string]bool{
+	models.RefundReasonRequestedByCustomer: true,
+	models.RefundReasonDuplicate:           true,
+	models.RefundReasonFraudulent:          true,
+}
+
+func (s *paymentService) RefundPayment(ctx context.Context, actorID int64, isAdmin bool, paymentID int64, req *models.CreateRefundRequest) (*models.PaymentRefund, error) {
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment == nil || (!isAdmin && payment.UserID != actorID) {
+		return nil, ErrPaymentNotFound
+	}
+
+	if !isAdmin {
+		if req.Reason != models.RefundReasonJobCanceled || payment.JobID == nil {
+			return nil, fmt.Errorf("%w: contractors can only refund payments for canceled jobs", ErrRefundNotAllowed)
+		}
+		jobStatus, err := s.paymentRepo.GetJobStatus(ctx, *payment.JobID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job status: %w", err)
+		}
+		if jobStatus != "canceled" {
+			return nil, fmt.Errorf("%w: job is %s, not canceled", ErrRefundNotAllowed, jobStatus)
+		}
+	}
+
+	return s.refundPayment(ctx, payment, req.AmountCents, req.Reason, req.Note, &actorID)
+}
+
+func (s *paymentService) GetPaymentRefunds(ctx context.Context, actorID int64, isAdmin bool, paymentID int64) ([]models.PaymentRefund, error) {
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment == nil || (!isAdmin && payment.UserID != actorID) {
+		return nil, ErrPaymentNotFound
+	}
+
+	return s.paymentRepo.GetPaymentRefunds(ctx, []int64{paymentID})
+}
+
+// refundPayment резервирует сумму возврата, уменьшает на нее выплату исполнителю, проводит возврат
+// через платежный шлюз и сразу отражает успешный возврат в платеже и журнале. amountCents = 0 - вернуть весь остаток
+func (s *paymentService) refundPayment(ctx context.Context, payment *models.Payment, amountCents int64, reason, note string, requestedBy *int64) (*models.PaymentRefund, error) {
+	if payment.Status != string(stripe.PaymentIntentStatusSucceeded) {
+		return nil, fmt.Errorf("%w: payment is %s", ErrRefundInvalid, payment.Status)
+	}
+
+	refund := &models.PaymentRefund{
+		PaymentID:   payment.ID,
+		AmountCents: amountCents,
+		Currency:    payment.Currency,
+		Reason:      reason,
+		Note:        note,
+		Status:      models.RefundStatusPending,
+		RequestedBy: requestedBy,
+	}
+	reserved, remaining, err := s.paymentRepo.ReserveRefund(ctx, refund, payment.RefundableCents())
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve refund: %w", err)
+	}
+	if !reserved {
+		if remaining <= 0 {
+			return nil, fmt.Errorf("%w: payment is already fully refunded", ErrRefundInvalid)
+		}
+		return nil, fmt.Errorf("%w: amount %d exceeds refundable balance %d", ErrRefundInvalid, amountCents, remaining)
+	}
+
+	// Возврат оплаты работы или чаевых уменьшает еще не переведенную выплату исполнителю
+	if s.holdsPayout(payment) {
+		if err := s.payoutService.ReducePayoutForRefund(ctx, refund); err != nil {
+			s.failRefund(ctx, refund, err)
+			if errors.Is(err, ErrPayoutAlreadyTransferred) {
+				return nil, fmt.Errorf("%w: %w", ErrRefundNotAllowed, err)
+			}
+			return nil, fmt.Errorf("failed to reduce payout: %w", err)
+		}
+	}
+
+	params := &RefundParams{
+		PaymentIntentID: payment.StripePaymentIntentID,
+		AmountCents:     refund.AmountCents,
+		Metadata: map[string]string{
+			"refund_id":  strconv.FormatInt(refund.ID, 10),
+			"payment_id": strconv.FormatInt(payment.ID, 10),
+			"reason":     reason,
+		},
+		IdempotencyKey: fmt.Sprintf("refund:%d", refund.ID),
+	}
+	if stripeRefundReasons[reason] {
+		params.Reason = reason
+	}
+
+	stripeRefund, err := s.gateway.CreateRefund(ctx, params)
+	if err != nil {
+		s.failRefund(ctx, refund, err)
+		s.restorePayout(ctx, payment, refund)
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	refund.StripeRefundID = &stripeRefund.ID
+	refund.Status = string(stripeRefund.Status)
+	refund.FailureReason = string(stripeRefund.FailureReason)
+	if err := s.paymentRepo.UpdateRefund(ctx, refund); err != nil {
+		return nil, fmt.Errorf("failed to update refund: %w", err)
+	}
+
+	// Незавершенный возврат отразится по webhook charge.refunded. Сумма считается по сохраненным
+	// возвратам: платеж мог измениться, пока шел этот возврат
+	if refund.Status == models.RefundStatusSucceeded {
+		previous, refundedTotal, err := s.paymentRepo.ApplySucceededRefunds(ctx, payment.ID, payment.RefundableCents())
+		if err != nil {
+			return nil, fmt.Errorf("failed to update payment refund: %w", err)
+		}
+		refunded := *payment
+		refunded.AmountRefundedCents = previous
+		s.postRefundToLedger(ctx, &refunded, refundedTotal)
+	}
+
+	return refund, nil
+}
+
+// failRefund отмечает возврат неудавшимся, освобождая зарезервированную сумму
+func (s *paymentService) failRefund(ctx context.Context, refund *models.PaymentRefund, cause error) {
+	refund.Status = models.RefundStatusFailed
+	refund.FailureReason = cause.Error()
+	if err := s.paymentRepo.UpdateRefund(ctx, refund); err != nil {
+		fmt.Printf("Failed to mark refund %d as failed: %v\n", refund.ID, err)
+	}
+}
+
+// holdsPayout - деньги платежа причитаются исполнителю: оплата работы или чаевые
+func (s *paymentService) holdsPayout(payment *models.Payment) bool {
+	return s.payoutService != nil && (payment.IsEscrow() || payment.IsTip())
+}
+
+// restorePayout возвращает выплате исполнителя сумму возврата, который не прошел
+func (s *paymentService) restorePayout(ctx context.Context, payment *models.Payment, refund *models.PaymentRefund) {
+	if !s.holdsPayout(payment) {
+		return
+	}
+	if err := s.payoutService.RestorePayoutForRefund(ctx, refund); err != nil {
+		fmt.Printf("Failed to restore payout after refund %d failed: %v\n", refund.ID, err)
+	}
+}
+
+// postRefundToLedger проводит возврат, если общая сумма возврата по платежу выросла
+func (s *paymentService) postRefundToLedger(ctx context.Context, payment *models.Payment, refundedTotalCents int64) {
+	if s.ledgerService == nil || refundedTotalCents <= payment.AmountRefundedCents {
+		return
+	}
+
+	refund := refundedTotalCents - payment.AmountRefundedCents
+	if err := s.ledgerService.PostRefund(ctx, payment, refundedTotalCents, refund); err != nil {
+		fmt.Printf("Failed to post refund of payment %d to ledger: %v\n", payment.ID, err)
+	}
+}
+
+// issueInvoice выставляет счет по успешному платежу в фоне, чтобы PDF и письмо не задерживали оплату
+func (s *paymentService) issueInvoice(paymentID int64) {
+	if s.invoiceService == nil {
+		return
+	}
+
+	go func() {
+		if _, err := s.invoiceService.IssuePaymentInvoice(context.Background(), paymentID); err != nil {
+			fmt.Printf("Failed to issue invoice for payment %d: %v\n", paymentID, err)
+		}
+	}()
+}
 
 func getPaymentStatusMessage(status stripe.PaymentIntentStatus) string {
 	switch status {
@@ -410,7 +1645,9 @@ func getPaymentStatusMessage(status stripe.PaymentIntentStatus) string {
 	case stripe.PaymentIntentStatusRequiresAction:
 		return "Payment requires additional action"
 	case stripe.PaymentIntentStatusProcessing:
-		return "Payment is processing"
+		return "Payment is processing, bank payments usually settle within 4 business days"
+	case stripe.PaymentIntentStatusRequiresCapture:
+		return "Payment authorized, it will be charged when the job is completed"
 	case stripe.PaymentIntentStatusCanceled:
 		return "Payment was canceled"
 	default:
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"moveshare/internal/config"
+	"moveshare/internal/models"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+// PaymentGateway - платежный провайдер: клиенты, карты, PaymentIntent, возвраты, переводы и webhook-события.
+// Типы объектов - из stripe-go, Stripe является основной реализацией
+type PaymentGateway interface {
+	// Customer management
+	CreateCustomer(ctx context.Context, userID int64, email, name string) (string, error)
+	GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error)
+
+	// Payment Methods
+	CreateSetupIntent(ctx context.Context, customerID, paymentMethodType string) (*stripe.SetupIntent, error)
+	GetSetupIntent(ctx context.Context, setupIntentID string) (*stripe.SetupIntent, error)
+	AttachPaymentMethod(ctx context.Context, paymentMethodID, customerID string) (*stripe.PaymentMethod, error)
+	DetachPaymentMethod(ctx context.Context, paymentMethodID string) (*stripe.PaymentMethod, error)
+	ListPaymentMethods(ctx context.Context, customerID string) ([]*stripe.PaymentMethod, error)
+	SetDefaultPaymentMethod(ctx context.Context, customerID, paymentMethodID string) error
+
+	// Payments
+	CreatePaymentIntent(ctx context.Context, amount int64, currency, customerID string, method PaymentMethodRef, description, idempotencyKey string) (*stripe.PaymentIntent, error)
+	ConfirmPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
+	GetPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
+	CancelPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
+	ListPaymentIntents(ctx context.Context, createdFrom, createdTo time.Time) ([]*stripe.PaymentIntent, error)
+	// GetPaymentIntentInvoice возвращает ID счета, который оплачивает PaymentIntent ("" - PaymentIntent создан не счетом)
+	GetPaymentIntentInvoice(ctx context.Context, paymentIntentID string) (string, error)
+
+	// Escrow (manual capture)
+	CreateAuthorizationIntent(ctx context.Context, amount int64, currency, customerID string, method PaymentMethodRef, description string, offSession bool, idempotencyKey string) (*stripe.PaymentIntent, error)
+	CapturePaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
+	CreateRefund(ctx context.Context, params *RefundParams) (*stripe.Refund, error)
+
+	// Connect (выплаты исполнителям)
+	CreateConnectAccount(ctx context.Context, userID int64, email string) (*stripe.Account, error)
+	GetConnectAccount(ctx context.Context, accountID string) (*stripe.Account, error)
+	CreateAccountOnboardingLink(ctx context.Context, accountID, refreshURL, returnURL string) (*stripe.AccountLink, error)
+	CreateTransfer(ctx context.Context, amount int64, currency, destinationAccountID, transferGroup, description, idempotencyKey string) (*stripe.Transfer, error)
+
+	// Subscriptions (планы компаний)
+	CreateSubscription(ctx context.Context, params *SubscriptionParams) (*stripe.Subscription, error)
+	GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+	ChangeSubscriptionPrice(ctx context.Context, subscriptionID, priceID string, invoiceNow bool) (*stripe.Subscription, error)
+	SetSubscriptionCancelAtPeriodEnd(ctx context.Context, subscriptionID string, cancel bool) (*stripe.Subscription, error)
+	CancelSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+
+	// Webhook
+	ConstructEvent(payload []byte, header string) (stripe.Event, error)
+}
+
+// PaymentMethodRef - сохраненный способ оплаты, с которого списывается PaymentIntent.
+// Для банковского счета передается мандат, полученный при его привязке
+type PaymentMethodRef struct {
+	ID        string
+	Type      string // models.PaymentMethodType*; пусто - карта
+	MandateID string
+}
+
+// IsBankAccount - списание с банковского счета (ACH debit): без ручного списания, с отложенным расчетом
+func (m PaymentMethodRef) IsBankAccount() bool {
+	return m.Type == models.PaymentMethodTypeUSBankAccount
+}
+
+func (m PaymentMethodRef) paymentMethodType() string {
+	if m.Type == "" {
+		return models.PaymentMethodTypeCard
+	}
+	return m.Type
+}
+
+func newPaymentMethodRef(pm *models.UserPaymentMethod) PaymentMethodRef {
+	return PaymentMethodRef{
+		ID:        pm.StripePaymentMethodID,
+		Type:      pm.Type,
+		MandateID: pm.StripeMandateID,
+	}
+}
+
+// RefundParams - параметры возврата по PaymentIntent
+type RefundParams struct {
+	PaymentIntentID string
+	AmountCents     int64  // 0 - вернуть весь остаток
+	Reason          string // duplicate, fraudulent или requested_by_customer; пусто - без причины
+	Metadata        map[string]string
+	IdempotencyKey  string
+}
+
+// SubscriptionParams - параметры новой подписки. Первый счет списывается сразу
+// с PaymentMethod; если списание требует подтверждения, подписка остается incomplete
+type SubscriptionParams struct {
+	CustomerID     string
+	PriceID        string
+	PaymentMethod  PaymentMethodRef
+	Metadata       map[string]string
+	IdempotencyKey string
+}
+
+// NewPaymentGateway выбирает платежный провайдер по конфигурации (PAYMENT_GATEWAY)
+func NewPaymentGateway(cfg *config.Config) PaymentGateway {
+	if cfg.Payment.Gateway == models.PaymentGatewayFake {
+		fakeGateway := NewFakePaymentGateway(cfg.Stripe.WebhookSecret)
+		fakeGateway.SetPrice(cfg.Stripe.ProPlanPriceID, models.GetPlan(models.PlanPro).MonthlyPriceCents)
+		fakeGateway.SetPrice(cfg.Stripe.FleetPlanPriceID, models.GetPlan(models.PlanFleet).MonthlyPriceCents)
+		return fakeGateway
+	}
+	return NewStripeService(&cfg.Stripe)
+}
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+	"testing"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+func TestAuthorizeJobPayment(t *testing.T) {
+	tests := []struct {
+		name         string
+		card         string
+		authenticate *bool // прохождение 3DS клиентом
+		wantDecline  bool
+		wantAction   bool
+		wantStatus   stripe.PaymentIntentStatus
+		wantAuth     string
+	}{
+		{
+			name:       "authorized",
+			card:       FakeCardSuccess,
+			wantStatus: stripe.PaymentIntentStatusRequiresCapture,
+			wantAuth:   models.AuthorizationStatusAuthorized,
+		},
+		{
+			name:        "declined",
+			card:        FakeCardDeclined,
+			wantDecline: true,
+		},
+		{
+			name:       "awaiting 3-D Secure",
+			card:       FakeCardAuthenticationRequired,
+			wantAction: true,
+			wantStatus: stripe.PaymentIntentStatusRequiresAction,
+			wantAuth:   models.AuthorizationStatusPending,
+		},
+		{
+			name:         "3-D Secure approved",
+			card:         FakeCardAuthenticationRequired,
+			authenticate: func() *bool { v := true; return &v }(),
+			wantAction:   true,
+			wantStatus:   stripe.PaymentIntentStatusRequiresCapture,
+			wantAuth:     models.AuthorizationStatusAuthorized,
+		},
+		{
+			name:         "3-D Secure rejected",
+			card:         FakeCardAuthenticationRequired,
+			authenticate: func() *bool { v := false; return &v }(),
+			wantAction:   true,
+			wantStatus:   stripe.PaymentIntentStatusRequiresPaymentMethod,
+			wantAuth:     models.AuthorizationStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newPaymentTestEnv()
+			env.addCard(t, 1, tt.card)
+			jobID := int64(10)
+
+			resp, err := env.service.AuthorizeJobPayment(ctx, 1, &models.CreatePaymentRequest{
+				JobID:       &jobID,
+				AmountCents: 5000,
+				Description: "Escrow for test job",
+			})
+			if tt.wantDecline {
+				var stripeErr *stripe.Error
+				if !errors.As(err, &stripeErr) || stripeErr.Type != stripe.ErrorTypeCard {
+					t.Fatalf("error = %v, want card error", err)
+				}
+				if len(env.repo.payments) != 0 {
+					t.Error("declined authorization should not be saved")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AuthorizeJobPayment: %v", err)
+			}
+			if resp.RequiresConfirmation != tt.wantAction {
+				t.Errorf("requires confirmation = %v, want %v", resp.RequiresConfirmation, tt.wantAction)
+			}
+
+			if tt.authenticate != nil {
+				if _, err := env.gateway.CompleteAuthentication(ctx, resp.PaymentIntentID, *tt.authenticate); err != nil {
+					t.Fatalf("CompleteAuthentication: %v", err)
+				}
+			}
+			env.deliver(t)
+
+			p := env.repo.payment(t, resp.PaymentIntentID)
+			if stripe.PaymentIntentStatus(p.Status) != tt.wantStatus || p.AuthorizationStatus != tt.wantAuth {
+				t.Errorf("payment = %s/%s, want %s/%s", p.Status, p.AuthorizationStatus, tt.wantStatus, tt.wantAuth)
+			}
+			if p.CaptureMethod != models.PaymentCaptureManual {
+				t.Errorf("capture method = %s, want manual", p.CaptureMethod)
+			}
+			if authorized := p.AuthorizedAt != nil && p.AuthorizationExpiresAt != nil; authorized != (tt.wantAuth == models.AuthorizationStatusAuthorized) {
+				t.Errorf("authorized at = %v, expires at = %v", p.AuthorizedAt, p.AuthorizationExpiresAt)
+			}
+		})
+	}
+}
+
+func TestJobPaymentCaptureAndRelease(t *testing.T) {
+	tests := []struct {
+		name           string
+		card           string
+		capture        bool
+		release        bool
+		wantCaptureErr bool
+		wantStatus     stripe.PaymentIntentStatus
+		wantAuth       string
+		wantCapture    string
+		wantCaptured   int64
+		wantRefunded   int64
+	}{
+		{
+			name:         "capture",
+			card:         FakeCardSuccess,
+			capture:      true,
+			wantStatus:   stripe.PaymentIntentStatusSucceeded,
+			wantAuth:     models.AuthorizationStatusAuthorized,
+			wantCapture:  models.CaptureStatusCaptured,
+			wantCaptured: 5000,
+		},
+		{
+			name:        "release before capture",
+			card:        FakeCardSuccess,
+			release:     true,
+			wantStatus:  stripe.PaymentIntentStatusCanceled,
+			wantAuth:    models.AuthorizationStatusReleased,
+			wantCapture: models.CaptureStatusCanceled,
+		},
+		{
+			name:         "release after capture refunds",
+			card:         FakeCardSuccess,
+			capture:      true,
+			release:      true,
+			wantStatus:   stripe.PaymentIntentStatusSucceeded,
+			wantAuth:     models.AuthorizationStatusAuthorized,
+			wantCapture:  models.CaptureStatusRefunded,
+			wantCaptured: 5000,
+			wantRefunded: 5000,
+		},
+		{
+			name:           "capture waits for 3-D Secure",
+			card:           FakeCardAuthenticationRequired,
+			capture:        true,
+			wantCaptureErr: true,
+			wantStatus:     stripe.PaymentIntentStatusRequiresAction,
+			wantAuth:       models.AuthorizationStatusPending,
+			wantCapture:    models.CaptureStatusUncaptured,
+		},
+		{
+			name:        "release pending 3-D Secure",
+			card:        FakeCardAuthenticationRequired,
+			release:     true,
+			wantStatus:  stripe.PaymentIntentStatusCanceled,
+			wantAuth:    models.AuthorizationStatusReleased,
+			wantCapture: models.CaptureStatusCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newPaymentTestEnv()
+			env.addCard(t, 1, tt.card)
+			jobID := int64(10)
+			resp := env.authorizeEscrow(t, 1, jobID, 5000)
+
+			// Каждый шаг повторяется: повтор не должен ничего менять
+			for i := 0; i < 2 && tt.capture; i++ {
+				_, err := env.service.CaptureJobPayment(ctx, jobID)
+				if (err != nil) != tt.wantCaptureErr {
+					t.Fatalf("CaptureJobPayment error = %v, want error %v", err, tt.wantCaptureErr)
+				}
+			}
+			for i := 0; i < 2 && tt.release; i++ {
+				if _, err := env.service.ReleaseJobPayment(ctx, jobID); err != nil {
+					t.Fatalf("ReleaseJobPayment: %v", err)
+				}
+			}
+			env.deliver(t)
+
+			p := env.repo.payment(t, resp.PaymentIntentID)
+			if stripe.PaymentIntentStatus(p.Status) != tt.wantStatus || p.AuthorizationStatus != tt.wantAuth || p.CaptureStatus != tt.wantCapture {
+				t.Errorf("payment = %s/%s/%s, want %s/%s/%s",
+					p.Status, p.AuthorizationStatus, p.CaptureStatus, tt.wantStatus, tt.wantAuth, tt.wantCapture)
+			}
+			if p.AmountCapturedCents != tt.wantCaptured || p.AmountRefundedCents != tt.wantRefunded {
+				t.Errorf("captured %d, refunded %d; want %d, %d", p.AmountCapturedCents, p.AmountRefundedCents, tt.wantCaptured, tt.wantRefunded)
+			}
+
+			pi, err := env.gateway.GetPaymentIntent(ctx, resp.PaymentIntentID)
+			if err != nil {
+				t.Fatalf("GetPaymentIntent: %v", err)
+			}
+			if pi.Status != tt.wantStatus {
+				t.Errorf("gateway status = %s, want %s", pi.Status, tt.wantStatus)
+			}
+			if pi.LatestCharge != nil && pi.LatestCharge.AmountRefunded != tt.wantRefunded {
+				t.Errorf("gateway refunded %d, want %d", pi.LatestCharge.AmountRefunded, tt.wantRefunded)
+			}
+		})
+	}
+}
+
+func TestJobPaymentWithoutEscrow(t *testing.T) {
+	ctx := context.Background()
+	env := newPaymentTestEnv()
+
+	if p, err := env.service.CaptureJobPayment(ctx, 10); p != nil || err != nil {
+		t.Errorf("CaptureJobPayment = %v, %v; want nil, nil", p, err)
+	}
+	if p, err := env.service.ReleaseJobPayment(ctx, 10); p != nil || err != nil {
+		t.Errorf("ReleaseJobPayment = %v, %v; want nil, nil", p, err)
+	}
+}
+
+func TestRefundPayment(t *testing.T) {
+	const contractorID, otherUserID, adminID = int64(1), int64(2), int64(99)
+
+	tests := []struct {
+		name          string
+		actorID       int64
+		isAdmin       bool
+		jobStatus     string
+		escrow        bool  // платеж - несписанная авторизация
+		previousCents int64 // ранее возвращено администратором
+		req           models.CreateRefundRequest
+		wantErr       error
+		wantRefunded  int64
+	}{
+		{
+			name:         "admin partial refund",
+			actorID:      adminID,
+			isAdmin:      true,
+			jobStatus:    "active",
+			req:          models.CreateRefundRequest{AmountCents: 2000, Reason: models.RefundReasonRequestedByCustomer},
+			wantRefunded: 2000,
+		},
+		{
+			name:         "admin full refund",
+			actorID:      adminID,
+			isAdmin:      true,
+			jobStatus:    "active",
+			req:          models.CreateRefundRequest{Reason: models.RefundReasonDuplicate},
+			wantRefunded: 5000,
+		},
+		{
+			name:          "refund of the remaining balance",
+			actorID:       adminID,
+			isAdmin:       true,
+			jobStatus:     "active",
+			previousCents: 3000,
+			req:           models.CreateRefundRequest{Reason: models.RefundReasonRequestedByCustomer},
+			wantRefunded:  5000,
+		},
+		{
+			name:          "refund over the remaining balance",
+			actorID:       adminID,
+			isAdmin:       true,
+			jobStatus:     "active",
+			previousCents: 3000,
+			req:           models.CreateRefundRequest{AmountCents: 3000, Reason: models.RefundReasonRequestedByCustomer},
+			wantErr:       ErrRefundInvalid,
+			wantRefunded:  3000,
+		},
+		{
+			name:         "uncaptured escrow",
+			actorID:      adminID,
+			isAdmin:      true,
+			jobStatus:    "active",
+			escrow:       true,
+			req:          models.CreateRefundRequest{Reason: models.RefundReasonRequestedByCustomer},
+			wantErr:      ErrRefundInvalid,
+			wantRefunded: 0,
+		},
+		{
+			name:         "contractor refund for canceled job",
+			actorID:      contractorID,
+			jobStatus:    "canceled",
+			req:          models.CreateRefundRequest{Reason: models.RefundReasonJobCanceled},
+			wantRefunded: 5000,
+		},
+		{
+			name:      "contractor refund for active job",
+			actorID:   contractorID,
+			jobStatus: "active",
+			req:       models.CreateRefundRequest{Reason: models.RefundReasonJobCanceled},
+			wantErr:   ErrRefundNotAllowed,
+		},
+		{
+			name:      "contractor refund with other reason",
+			actorID:   contractorID,
+			jobStatus: "canceled",
+			req:       models.CreateRefundRequest{Reason: models.RefundReasonRequestedByCustomer},
+			wantErr:   ErrRefundNotAllowed,
+		},
+		{
+			name:      "someone else's payment",
+			actorID:   otherUserID,
+			jobStatus: "canceled",
+			req:       models.CreateRefundRequest{Reason: models.RefundReasonJobCanceled},
+			wantErr:   ErrPaymentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newPaymentTestEnv()
+			env.addCard(t, contractorID, FakeCardSuccess)
+			jobID := int64(10)
+			env.repo.jobStatuses[jobID] = tt.jobStatus
+
+			var paymentIntentID string
+			if tt.escrow {
+				paymentIntentID = env.authorizeEscrow(t, contractorID, jobID, 5000).PaymentIntentID
+			} else {
+				resp, err := env.service.CreatePayment(ctx, contractorID, &models.CreatePaymentRequest{
+					JobID:       &jobID,
+					AmountCents: 5000,
+					Description: "Payment for test job posting",
+				})
+				if err != nil {
+					t.Fatalf("CreatePayment: %v", err)
+				}
+				if _, err := env.service.ConfirmPayment(ctx, resp.PaymentIntentID); err != nil {
+					t.Fatalf("ConfirmPayment: %v", err)
+				}
+				paymentIntentID = resp.PaymentIntentID
+			}
+			paymentID := env.repo.payment(t, paymentIntentID).ID
+
+			if tt.previousCents > 0 {
+				_, err := env.service.RefundPayment(ctx, adminID, true, paymentID, &models.CreateRefundRequest{
+					AmountCents: tt.previousCents,
+					Reason:      models.RefundReasonRequestedByCustomer,
+				})
+				if err != nil {
+					t.Fatalf("previous refund: %v", err)
+				}
+			}
+
+			refund, err := env.service.RefundPayment(ctx, tt.actorID, tt.isAdmin, paymentID, &tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("RefundPayment: %v", err)
+				}
+				if refund.Status != models.RefundStatusSucceeded || refund.StripeRefundID == nil {
+					t.Errorf("refund = %s (%v), want succeeded with Stripe ID", refund.Status, refund.StripeRefundID)
+				}
+			}
+			env.deliver(t)
+
+			p := env.repo.payment(t, paymentIntentID)
+			if p.AmountRefundedCents != tt.wantRefunded {
+				t.Errorf("refunded %d, want %d", p.AmountRefundedCents, tt.wantRefunded)
+			}
+			if reserved := env.repo.reservedRefundCents(paymentID); reserved != tt.wantRefunded {
+				t.Errorf("reserved refunds %d, want %d", reserved, tt.wantRefunded)
+			}
+			if !tt.escrow {
+				pi, _ := env.gateway.GetPaymentIntent(ctx, paymentIntentID)
+				if pi.LatestCharge.AmountRefunded != tt.wantRefunded {
+					t.Errorf("gateway refunded %d, want %d", pi.LatestCharge.AmountRefunded, tt.wantRefunded)
+				}
+			}
+		})
+	}
+}
+
+func TestRefundCapturedJobPayment(t *testing.T) {
+	const (
+		contractorID = int64(1)
+		adminID      = int64(99)
+		jobID        = int64(10)
+	)
+
+	tests := []struct {
+		name         string
+		payoutStatus string
+		amountCents  int64
+		// refundedInStripe - оплата уже возвращена в Stripe Dashboard, и возврат через шлюз не пройдет
+		refundedInStripe bool
+		wantErr          bool
+		wantErrIs        error
+		wantRefunded     int64
+		wantPayout       models.Payout
+	}{
+		{
+			name:         "partial refund reduces pending payout",
+			payoutStatus: models.PayoutStatusPending,
+			amountCents:  2000,
+			wantRefunded: 2000,
+			wantPayout:   models.Payout{GrossAmountCents: 8000, CommissionCents: 800, NetAmountCents: 7200, Status: models.PayoutStatusPending},
+		},
+		{
+			name:         "full refund cancels pending payout",
+			payoutStatus: models.PayoutStatusPending,
+			wantRefunded: 10000,
+			wantPayout:   models.Payout{Status: models.PayoutStatusCanceled},
+		},
+		{
+			name:         "payout is being transferred",
+			payoutStatus: models.PayoutStatusProcessing,
+			amountCents:  2000,
+			wantErr:      true,
+			wantErrIs:    ErrPayoutTransferInProgress,
+			wantPayout:   models.Payout{GrossAmountCents: 10000, CommissionCents: 1000, NetAmountCents: 9000, Status: models.PayoutStatusProcessing},
+		},
+		{
+			name:         "payout has been transferred",
+			payoutStatus: models.PayoutStatusTransferred,
+			amountCents:  2000,
+			wantErr:      true,
+			wantErrIs:    ErrRefundNotAllowed,
+			wantPayout:   models.Payout{GrossAmountCents: 10000, CommissionCents: 1000, NetAmountCents: 9000, Status: models.PayoutStatusTransferred},
+		},
+		{
+			name:             "failed refund restores payout",
+			payoutStatus:     models.PayoutStatusPending,
+			amountCents:      2000,
+			refundedInStripe: true,
+			wantErr:          true,
+			wantPayout:       models.Payout{GrossAmountCents: 10000, CommissionCents: 1000, NetAmountCents: 9000, Status: models.PayoutStatusPending},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newPaymentTestEnv()
+			ledgerRepo := newMemLedgerRepo()
+			ledgerService := NewLedgerService(ledgerRepo)
+			payoutRepo := newMemPayoutRepo()
+			admin := &stubAdminService{settings: &models.SystemSettings{CommissionRate: 10, MinimumPayout: 10}}
+			payouts := NewPayoutService(payoutRepo, env.gateway, admin, nil, ledgerService, nil)
+			env.service = NewPaymentService(env.repo, env.gateway, nil, nil, ledgerService, payouts, nil).(*paymentService)
+
+			env.addCard(t, contractorID, FakeCardSuccess)
+			resp := env.authorizeEscrow(t, contractorID, jobID, 10000)
+			captured, err := env.service.CaptureJobPayment(ctx, jobID)
+			if err != nil {
+				t.Fatalf("CaptureJobPayment: %v", err)
+			}
+			if _, err := payouts.RecordJobPayout(ctx, jobID, testMoverID, captured); err != nil {
+				t.Fatalf("RecordJobPayout: %v", err)
+			}
+			payoutRepo.payouts[0].Status = tt.payoutStatus
+
+			if tt.refundedInStripe {
+				if _, err := env.gateway.CreateRefund(ctx, &RefundParams{PaymentIntentID: resp.PaymentIntentID}); err != nil {
+					t.Fatalf("CreateRefund: %v", err)
+				}
+			}
+
+			_, err = env.service.RefundPayment(ctx, adminID, true, captured.ID, &models.CreateRefundRequest{
+				AmountCents: tt.amountCents,
+				Reason:      models.RefundReasonRequestedByCustomer,
+			})
+			if (err != nil) != tt.wantErr || tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("RefundPayment error = %v, want error %v (%v)", err, tt.wantErr, tt.wantErrIs)
+			}
+
+			if p := env.repo.payment(t, resp.PaymentIntentID); p.AmountRefundedCents != tt.wantRefunded {
+				t.Errorf("refunded %d, want %d", p.AmountRefundedCents, tt.wantRefunded)
+			}
+			if reserved := env.repo.reservedRefundCents(captured.ID); reserved != tt.wantRefunded {
+				t.Errorf("reserved refunds %d, want %d", reserved, tt.wantRefunded)
+			}
+
+			got := payoutRepo.payouts[0]
+			if got.GrossAmountCents != tt.wantPayout.GrossAmountCents || got.CommissionCents != tt.wantPayout.CommissionCents ||
+				got.NetAmountCents != tt.wantPayout.NetAmountCents || got.Status != tt.wantPayout.Status {
+				t.Errorf("payout = %d/%d/%d %s, want %d/%d/%d %s",
+					got.GrossAmountCents, got.CommissionCents, got.NetAmountCents, got.Status,
+					tt.wantPayout.GrossAmountCents, tt.wantPayout.CommissionCents, tt.wantPayout.NetAmountCents, tt.wantPayout.Status)
+			}
+
+			// Все, что осталось в escrow после возврата, начислено исполнителю и платформе
+			if balance := ledgerRepo.balance(models.LedgerEscrow); balance != 0 {
+				t.Errorf("escrow balance = %d, want 0", balance)
+			}
+			if balance := ledgerRepo.balance(models.LedgerUserAccount(testMoverID)); balance != -tt.wantPayout.NetAmountCents {
+				t.Errorf("mover balance = %d, want %d", balance, -tt.wantPayout.NetAmountCents)
+			}
+		})
+	}
+}
+
+func TestHandleWebhookProcessesEventOnce(t *testing.T) {
+	ctx := context.Background()
+	env := newPaymentTestEnv()
+	env.addCard(t, 1, FakeCardSuccess)
+	resp := env.authorizeEscrow(t, 1, 10, 5000)
+
+	// Перехватываем подписанное событие, чтобы доставить его дважды
+	var payload []byte
+	var signature string
+	env.gateway.SetWebhookHandler(func(ctx context.Context, p []byte, s string) error {
+		payload, signature = p, s
+		return env.service.HandleWebhook(ctx, p, s)
+	})
+	env.deliver(t)
+
+	if err := env.service.HandleWebhook(ctx, payload, signature); err != nil {
+		t.Fatalf("repeated delivery: %v", err)
+	}
+	if err := env.service.HandleWebhook(ctx, payload, "t=1,v1=forged"); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Errorf("forged signature error = %v, want %v", err, ErrInvalidWebhookSignature)
+	}
+
+	if len(env.repo.events) != 1 {
+		t.Fatalf("saved %d events, want 1", len(env.repo.events))
+	}
+	for _, event := range env.repo.events {
+		if event.Status != models.StripeEventStatusProcessed || event.Attempts != 1 {
+			t.Errorf("event %s is %s after %d attempts, want processed once", event.StripeEventID, event.Status, event.Attempts)
+		}
+	}
+	if p := env.repo.payment(t, resp.PaymentIntentID); p.AuthorizationStatus != models.AuthorizationStatusAuthorized {
+		t.Errorf("authorization = %s, want authorized", p.AuthorizationStatus)
+	}
+}
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/payout"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+// payoutProcessingTimeout - через сколько выплата, зависшая в processing, забирается повторно
+const payoutProcessingTimeout = 10 * time.Minute
+
+var (
+	ErrPayoutTransferInProgress = errors.New("mover payout is being transferred, try again later")
+	ErrPayoutAlreadyTransferred = errors.New("mover payout has already been transferred")
+)
+
+type PayoutService interface {
+	// Stripe Connect
+	StartOnboarding(ctx context.Context, userID int64, req *models.ConnectOnboardingRequest) (*models.ConnectOnboardingResponse, error)
+	GetConnectAccount(ctx context.Context, userID int64) (*models.ConnectAccount, error)
+
+	// Payouts
+	RecordJobPayout(ctx context.Context, jobID, executorID int64, payment *models.Payment) (*models.Payout, error)
+	RecordTipPayout(ctx context.Context, payment *models.Payment) (*models.Payout, error)
+	ReducePayoutForRefund(ctx context.Context, refund *models.PaymentRefund) error
+	RestorePayoutForRefund(ctx context.Context, refund *models.PaymentRefund) error
+	ProcessUserPayouts(ctx context.Context, userID int64) (int64, error)
+	ProcessPendingPayouts(ctx context.Context) (int, error)
+	StartPayoutProcessor(ctx context.Context, interval time.Duration)
+	GetUserPayouts(ctx context.Context, userID int64, limit, offset int) ([]models.Payout, int, error)
+	GetPayoutBalance(ctx context.Context, userID int64) (*models.PayoutBalance, error)
+}
+
+type payoutService struct {
+	repo          payout.PayoutRepository
+	gateway       PaymentGateway
+	adminService  AdminService
+	userService   UserService
+	ledgerService LedgerService
+
+	subscriptionService SubscriptionService
+}
+
+func NewPayoutService(repo payout.PayoutRepository, gateway PaymentGateway, adminService AdminService, userService UserService, ledgerService LedgerService, subscriptionService SubscriptionService) PayoutService {
+	return &payoutService{
+		repo:                repo,
+		gateway:             gateway,
+		adminService:        adminService,
+		userService:         userService,
+		ledgerService:       ledgerService,
+		subscriptionService: subscriptionService,
+	}
+}
+
+// StartOnboarding создает Express-аккаунт исполнителя (если его еще нет)
+// и возвращает ссылку на анкету Stripe
+func (s *payoutService) StartOnboarding(ctx context.Context, userID int64, req *models.ConnectOnboardingRequest) (*models.ConnectOnboardingResponse, error) {
+	account, err := s.repo.GetConnectAccount(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connect account: %w", err)
+	}
+
+	if account == nil {
+		user, err := s.userService.FindUserByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+
+		stripeAccount, err := s.gateway.CreateConnectAccount(ctx, userID, user.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		account = &models.ConnectAccount{
+			UserID:           userID,
+			StripeAccountID:  stripeAccount.ID,
+			DetailsSubmitted: stripeAccount.DetailsSubmitted,
+			PayoutsEnabled:   stripeAccount.PayoutsEnabled,
+		}
+		if err := s.repo.SaveConnectAccount(ctx, account); err != nil {
+			return nil, fmt.Errorf("failed to save connect account: %w", err)
+		}
+	}
+
+	link, err := s.gateway.CreateAccountOnboardingLink(ctx, account.StripeAccountID, req.RefreshURL, req.ReturnURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ConnectOnboardingResponse{
+		StripeAccountID: account.StripeAccountID,
+		OnboardingURL:   link.URL,
+		ExpiresAt:       time.Unix(link.ExpiresAt, 0),
+	}, nil
+}
+
+// GetConnectAccount обновляет состояние аккаунта из Stripe. Когда выплаты
+// становятся доступны, сразу переводит накопленный баланс
+func (s *payoutService) GetConnectAccount(ctx context.Context, userID int64) (*models.ConnectAccount, error) {
+	account, err := s.repo.GetConnectAccount(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connect account: %w", err)
+	}
+	if account == nil {
+		return nil, nil
+	}
+
+	stripeAccount, err := s.gateway.GetConnectAccount(ctx, account.StripeAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	payoutsEnabledNow := stripeAccount.PayoutsEnabled && !account.PayoutsEnabled
+	if stripeAccount.PayoutsEnabled != account.PayoutsEnabled || stripeAccount.DetailsSubmitted != account.DetailsSubmitted {
+		account.PayoutsEnabled = stripeAccount.PayoutsEnabled
+		account.DetailsSubmitted = stripeAccount.DetailsSubmitted
+		if err := s.repo.SaveConnectAccount(ctx, account); err != nil {
+			return nil, fmt.Errorf("failed to save connect account: %w", err)
+		}
+	}
+
+	if payoutsEnabledNow {
+		if _, err := s.ProcessUserPayouts(ctx, userID); err != nil {
+			fmt.Printf("Failed to process payouts for user %d: %v\n", userID, err)
+		}
+	}
+
+	return account, nil
+}
+
+// RecordJobPayout записывает в журнал выплату исполнителю за работу:
+// списанная оплата минус комиссия платформы из системных настроек (или меньшая комиссия плана исполнителя)
+func (s *payoutService) RecordJobPayout(ctx context.Context, jobID, executorID int64, payment *models.Payment) (*models.Payout, error) {
+	if payment == nil || payment.CaptureStatus != models.CaptureStatusCaptured {
+		return nil, nil
+	}
+
+	settings, err := s.adminService.GetSystemSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system settings: %w", err)
+	}
+
+	gross := payment.AmountCapturedCents
+	if gross == 0 {
+		gross = payment.AmountCents
+	}
+	// Возврат, прошедший до начисления, исполнителю не причитается
+	gross -= payment.AmountRefundedCents
+	if gross <= 0 {
+		return nil, nil
+	}
+
+	// Комиссия фиксируется при публикации работы; для старых платежей берем текущую
+	commissionRate := settings.CommissionRate
+	if payment.FeeBreakdown != nil {
+		commissionRate = payment.FeeBreakdown.CommissionRate
+	}
+
+	// План подписки исполнителя может снижать комиссию
+	if s.subscriptionService != nil {
+		entitlements, err := s.subscriptionService.GetEntitlements(ctx, executorID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get plan entitlements: %w", err)
+		}
+		if entitlements.CommissionRate != nil && *entitlements.CommissionRate < commissionRate {
+			commissionRate = *entitlements.CommissionRate
+		}
+	}
+	commission := percentOfCents(gross, commissionRate)
+
+	entry := &models.Payout{
+		UserID:           executorID,
+		JobID:            &jobID,
+		PaymentID:        &payment.ID,
+		GrossAmountCents: gross,
+		CommissionRate:   commissionRate,
+		CommissionCents:  commission,
+		NetAmountCents:   gross - commission,
+		Currency:         payment.Currency,
+		Status:           models.PayoutStatusPending,
+	}
+
+	return s.recordPayout(ctx, entry)
+}
+
+// RecordTipPayout записывает в журнал выплату чаевых исполнителю: вся сумма, без комиссии
+func (s *payoutService) RecordTipPayout(ctx context.Context, payment *models.Payment) (*models.Payout, error) {
+	if payment == nil || !payment.IsTip() || payment.TipRecipientID == nil {
+		return nil, nil
+	}
+
+	entry := &models.Payout{
+		UserID:           *payment.TipRecipientID,
+		JobID:            payment.JobID,
+		PaymentID:        &payment.ID,
+		GrossAmountCents: payment.AmountCents,
+		NetAmountCents:   payment.AmountCents,
+		Currency:         payment.Currency,
+		Status:           models.PayoutStatusPending,
+	}
+
+	return s.recordPayout(ctx, entry)
+}
+
+// ReducePayoutForRefund уменьшает еще не переведенную выплату по платежу на сумму возврата,
+// пересчитывая комиссию по прежней ставке; полный возврат отменяет выплату.
+// Выплату, которая переводится или уже переведена, уменьшить нельзя
+func (s *payoutService) ReducePayoutForRefund(ctx context.Context, refund *models.PaymentRefund) error {
+	return s.adjustPayoutForRefund(ctx, refund.PaymentID, -refund.AmountCents, strconv.FormatInt(refund.ID, 10))
+}
+
+// RestorePayoutForRefund возвращает выплате сумму возврата, который не прошел
+func (s *payoutService) RestorePayoutForRefund(ctx context.Context, refund *models.PaymentRefund) error {
+	return s.adjustPayoutForRefund(ctx, refund.PaymentID, refund.AmountCents, fmt.Sprintf("%d:restored", refund.ID))
+}
+
+func (s *payoutService) adjustPayoutForRefund(ctx context.Context, paymentID, deltaCents int64, referenceID string) error {
+	entry, err := s.repo.GetPaymentPayout(ctx, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get payout: %w", err)
+	}
+	// Выплата еще не начислена: RecordJobPayout учтет возврат сам
+	if entry == nil {
+		return nil
+	}
+
+	switch entry.Status {
+	case models.PayoutStatusProcessing:
+		return ErrPayoutTransferInProgress
+	case models.PayoutStatusTransferred:
+		return ErrPayoutAlreadyTransferred
+	}
+
+	before := *entry
+	entry.GrossAmountCents = max(entry.GrossAmountCents+deltaCents, 0)
+	entry.CommissionCents = percentOfCents(entry.GrossAmountCents, entry.CommissionRate)
+	entry.NetAmountCents = entry.GrossAmountCents - entry.CommissionCents
+	entry.Status = models.PayoutStatusPending
+	if entry.GrossAmountCents == 0 {
+		entry.Status = models.PayoutStatusCanceled
+	}
+
+	// Выплату могли забрать на перевод после чтения
+	updated, err := s.repo.UpdatePayoutAmounts(ctx, entry, before.GrossAmountCents)
+	if err != nil {
+		return fmt.Errorf("failed to update payout: %w", err)
+	}
+	if !updated {
+		return ErrPayoutTransferInProgress
+	}
+
+	if err := s.ledgerService.PostPayoutRefund(ctx, referenceID, &before, entry); err != nil {
+		fmt.Printf("Failed to post payout %d adjustment to ledger: %v\n", entry.ID, err)
+	}
+
+	return nil
+}
+
+// recordPayout сохраняет выплату, проводит ее по журналу и пробует сразу перевести баланс.
+// Повторная запись по тому же платежу возвращает nil
+func (s *payoutService) recordPayout(ctx context.Context, entry *models.Payout) (*models.Payout, error) {
+	created, err := s.repo.CreatePayout(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record payout: %w", err)
+	}
+	if !created {
+		return nil, nil
+	}
+
+	if err := s.ledgerService.PostPayout(ctx, entry); err != nil {
+		fmt.Printf("Failed to post payout %d to ledger: %v\n", entry.ID, err)
+	}
+
+	if _, err := s.ProcessUserPayouts(ctx, entry.UserID); err != nil {
+		fmt.Printf("Failed to process payouts for user %d: %v\n", entry.UserID, err)
+	}
+
+	return entry, nil
+}
+
+// ProcessUserPayouts переводит накопленные выплаты на Connect-аккаунт исполнителя отдельным
+// переводом на каждую валюту, если сумма достигла минимальной выплаты. Выплаты, зависшие в processing,
+// переводятся прежними партиями с тем же ключом идемпотентности. Возвращает переведенную сумму в центах
+func (s *payoutService) ProcessUserPayouts(ctx context.Context, userID int64) (int64, error) {
+	account, err := s.repo.GetConnectAccount(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get connect account: %w", err)
+	}
+	if account == nil || !account.PayoutsEnabled {
+		return 0, nil
+	}
+
+	settings, err := s.adminService.GetSystemSettings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get system settings: %w", err)
+	}
+	// MinimumPayout в настройках хранится в долларах
+	minimumCents := int64(settings.MinimumPayout) * 100
+
+	stale, err := s.repo.ReclaimStalePayouts(ctx, userID, payoutProcessingTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reclaim stale payouts: %w", err)
+	}
+	pending, err := s.repo.ClaimPendingPayouts(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim pending payouts: %w", err)
+	}
+
+	// Зависшие выплаты не смешиваются с новыми: иначе изменится ключ идемпотентности
+	// и уже созданный перевод может повториться
+	batches := append(groupPayoutsByCurrency(stale), groupPayoutsByCurrency(pending)...)
+
+	var total int64
+	var errs []error
+	for _, batch := range batches {
+		amount, err := s.transferPayouts(ctx, userID, account.StripeAccountID, batch, minimumCents)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		total += amount
+	}
+
+	return total, errors.Join(errs...)
+}
+
+// transferPayouts переводит одну партию выплат в одной валюте. Если Stripe отклонил перевод,
+// выплаты возвращаются в pending; при сбое связи остаются в processing и повторяются
+// после payoutProcessingTimeout с тем же ключом, чтобы не перевести деньги дважды
+func (s *payoutService) transferPayouts(ctx context.Context, userID int64, stripeAccountID string, payouts []models.Payout, minimumCents int64) (int64, error) {
+	var total int64
+	payoutIDs := make([]int64, 0, len(payouts))
+	for _, p := range payouts {
+		total += p.NetAmountCents
+		payoutIDs = append(payoutIDs, p.ID)
+	}
+	slices.Sort(payoutIDs)
+
+	if total < minimumCents {
+		if err := s.repo.ReleaseClaimedPayouts(ctx, payoutIDs, ""); err != nil {
+			return 0, fmt.Errorf("failed to release payouts: %w", err)
+		}
+		return 0, nil
+	}
+
+	transfer, err := s.gateway.CreateTransfer(
+		ctx,
+		total,
+		payouts[0].Currency,
+		stripeAccountID,
+		fmt.Sprintf("payout_user_%d_%d", userID, payoutIDs[0]),
+		fmt.Sprintf("MoveShare payout for %d job(s)", len(payouts)),
+		payoutTransferIdempotencyKey(userID, payoutIDs),
+	)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.HTTPStatusCode < 500 {
+			if releaseErr := s.repo.ReleaseClaimedPayouts(ctx, payoutIDs, err.Error()); releaseErr != nil {
+				fmt.Printf("Failed to release payouts after transfer error: %v\n", releaseErr)
+			}
+		}
+		return 0, err
+	}
+
+	if err := s.repo.MarkPayoutsTransferred(ctx, payoutIDs, transfer.ID); err != nil {
+		return 0, fmt.Errorf("failed to mark payouts transferred (transfer %s): %w", transfer.ID, err)
+	}
+
+	if err := s.ledgerService.PostPayoutTransfer(ctx, userID, transfer.ID, total); err != nil {
+		fmt.Printf("Failed to post transfer %s to ledger: %v\n", transfer.ID, err)
+	}
+
+	return total, nil
+}
+
+// groupPayoutsByCurrency делит выплаты на партии по валюте в устойчивом порядке
+func groupPayoutsByCurrency(payouts []models.Payout) [][]models.Payout {
+	byCurrency := make(map[string][]models.Payout)
+	for _, p := range payouts {
+		currency := strings.ToLower(p.Currency)
+		byCurrency[currency] = append(byCurrency[currency], p)
+	}
+
+	batches := make([][]models.Payout, 0, len(byCurrency))
+	for _, batch := range byCurrency {
+		batches = append(batches, batch)
+	}
+	slices.SortFunc(batches, func(a, b []models.Payout) int {
+		return cmp.Compare(strings.ToLower(a[0].Currency), strings.ToLower(b[0].Currency))
+	})
+
+	return batches
+}
+
+// payoutTransferIdempotencyKey зависит только от пользователя и состава партии (ID по возрастанию)
+func payoutTransferIdempotencyKey(userID int64, sortedPayoutIDs []int64) string {
+	ids := make([]string, len(sortedPayoutIDs))
+	for i, id := range sortedPayoutIDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	return fmt.Sprintf("payout_transfer:%d:%x", userID, sha256.Sum256([]byte(strings.Join(ids, ","))))
+}
+
+// ProcessPendingPayouts проходит по всем исполнителям с ожидающими выплатами
+// (например, после подключения Connect или снижения минимальной суммы)
+func (s *payoutService) ProcessPendingPayouts(ctx context.Context) (int, error) {
+	userIDs, err := s.repo.GetUsersWithPendingPayouts(ctx, payoutProcessingTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending payouts: %w", err)
+	}
+
+	paid := 0
+	for _, userID := range userIDs {
+		amount, err := s.ProcessUserPayouts(ctx, userID)
+		if err != nil {
+			fmt.Printf("Failed to process payouts for user %d: %v\n", userID, err)
+			continue
+		}
+		if amount > 0 {
+			paid++
+		}
+	}
+
+	return paid, nil
+}
+
+// StartPayoutProcessor периодически переводит накопленные выплаты
+func (s *payoutService) StartPayoutProcessor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		paid, err := s.ProcessPendingPayouts(ctx)
+		if err != nil {
+			fmt.Printf("Payout processor error: %v\n", err)
+		} else if paid > 0 {
+			fmt.Printf("Payout processor: paid out %d movers\n", paid)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *payoutService) GetUserPayouts(ctx context.Context, userID int64, limit, offset int) ([]models.Payout, int, error) {
+	return s.repo.GetUserPayouts(ctx, userID, limit, offset)
+}
+
+func (s *payoutService) GetPayoutBalance(ctx context.Context, userID int64) (*models.PayoutBalance, error) {
+	pending, transferred, err := s.repo.GetPayoutBalance(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payout balance: %w", err)
+	}
+
+	settings, err := s.adminService.GetSystemSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system settings: %w", err)
+	}
+
+	return &models.PayoutBalance{
+		PendingCents:       pending,
+		TransferredCents:   transferred,
+		MinimumPayoutCents: int64(settings.MinimumPayout) * 100,
+	}, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"moveshare/internal/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+const testMoverID = int64(2)
+
+type payoutTestEnv struct {
+	gateway FakePaymentGateway
+	repo    *memPayoutRepo
+	ledger  *stubLedgerService
+	service PayoutService
+}
+
+// newPayoutTestEnv - исполнитель с подключенным Connect-аккаунтом и минимальной выплатой $10
+func newPayoutTestEnv(t *testing.T) *payoutTestEnv {
+	t.Helper()
+	ctx := context.Background()
+
+	env := &payoutTestEnv{
+		gateway: NewFakePaymentGateway(""),
+		repo:    newMemPayoutRepo(),
+		ledger:  &stubLedgerService{},
+	}
+	admin := &stubAdminService{settings: &models.SystemSettings{MinimumPayout: 10}}
+	env.service = NewPayoutService(env.repo, env.gateway, admin, nil, env.ledger, nil)
+
+	account, err := env.gateway.CreateConnectAccount(ctx, testMoverID, "mover@example.com")
+	if err != nil {
+		t.Fatalf("CreateConnectAccount: %v", err)
+	}
+	if _, err := env.gateway.CreateAccountOnboardingLink(ctx, account.ID, "", ""); err != nil {
+		t.Fatalf("CreateAccountOnboardingLink: %v", err)
+	}
+	env.repo.accounts[testMoverID] = &models.ConnectAccount{UserID: testMoverID, StripeAccountID: account.ID, PayoutsEnabled: true}
+
+	return env
+}
+
+func TestProcessUserPayouts(t *testing.T) {
+	stale := time.Now().Add(-2 * payoutProcessingTimeout)
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, env *payoutTestEnv)
+		// wantTotal - переведенная сумма, wantTransfers - число проведенных переводов
+		wantTotal     int64
+		wantTransfers int
+		wantErr       bool
+		wantStatus    []string
+		// wantTransferID - перевод, которым должна закрыться первая выплата
+		wantTransferID string
+	}{
+		{
+			name: "one transfer per currency",
+			setup: func(t *testing.T, env *payoutTestEnv) {
+				env.repo.add(testMoverID, 3000, "usd", models.PayoutStatusPending, time.Now())
+				env.repo.add(testMoverID, 4000, "eur", models.PayoutStatusPending, time.Now())
+				env.repo.add(testMoverID, 2000, "usd", models.PayoutStatusPending, time.Now())
+			},
+			wantTotal:     9000,
+			wantTransfers: 2,
+			wantStatus:    []string{models.PayoutStatusTransferred, models.PayoutStatusTransferred, models.PayoutStatusTransferred},
+		},
+		{
+			name: "currency below the minimum waits",
+			setup: func(t *testing.T, env *payoutTestEnv) {
+				env.repo.add(testMoverID, 1500, "usd", models.PayoutStatusPending, time.Now())
+				env.repo.add(testMoverID, 500, "eur", models.PayoutStatusPending, time.Now())
+			},
+			wantTotal:     1500,
+			wantTransfers: 1,
+			wantStatus:    []string{models.PayoutStatusTransferred, models.PayoutStatusPending},
+		},
+		{
+			name: "payout in progress is left alone",
+			setup: func(t *testing.T, env *payoutTestEnv) {
+				env.repo.add(testMoverID, 3000, "usd", models.PayoutStatusProcessing, time.Now())
+			},
+			wantStatus: []string{models.PayoutStatusProcessing},
+		},
+		{
+			name: "stale payout reuses the transfer created before the crash",
+			setup: func(t *testing.T, env *payoutTestEnv) {
+				p := env.repo.add(testMoverID, 3000, "usd", models.PayoutStatusProcessing, stale)
+				env.repo.add(testMoverID, 2000, "usd", models.PayoutStatusPending, time.Now())
+
+				// Перевод прошел, но обработчик упал до MarkPayoutsTransferred
+				_, err := env.gateway.CreateTransfer(context.Background(), p.NetAmountCents, p.Currency,
+					env.repo.accounts[testMoverID].StripeAccountID, "", "", payoutTransferIdempotencyKey(testMoverID, []int64{p.ID}))
+				if err != nil {
+					t.Fatalf("CreateTransfer: %v", err)
+				}
+			},
+			wantTotal:      5000,
+			wantTransfers:  2,
+			wantStatus:     []string{models.PayoutStatusTransferred, models.PayoutStatusTransferred},
+			wantTransferID: "tr_fake_000001",
+		},
+		{
+			name: "rejected transfer returns payouts to pending",
+			setup: func(t *testing.T, env *payoutTestEnv) {
+				account, err := env.gateway.CreateConnectAccount(context.Background(), testMoverID, "mover@example.com")
+				if err != nil {
+					t.Fatalf("CreateConnectAccount: %v", err)
+				}
+				env.repo.accounts[testMoverID].StripeAccountID = account.ID
+				env.repo.add(testMoverID, 3000, "usd", models.PayoutStatusPending, time.Now())
+			},
+			wantErr:    true,
+			wantStatus: []string{models.PayoutStatusPending},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newPayoutTestEnv(t)
+			tt.setup(t, env)
+
+			total, err := env.service.ProcessUserPayouts(context.Background(), testMoverID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProcessUserPayouts error = %v, want error %v", err, tt.wantErr)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("transferred %d, want %d", total, tt.wantTotal)
+			}
+
+			var statuses []string
+			for _, p := range env.repo.payouts {
+				statuses = append(statuses, p.Status)
+			}
+			if !slices.Equal(statuses, tt.wantStatus) {
+				t.Errorf("payout statuses = %v, want %v", statuses, tt.wantStatus)
+			}
+			if first := env.repo.payouts[0]; tt.wantTransferID != "" && (first.StripeTransferID == nil || *first.StripeTransferID != tt.wantTransferID) {
+				t.Errorf("first payout transfer = %v, want %s", first.StripeTransferID, tt.wantTransferID)
+			}
+
+			// Каждый перевод проводится по журналу один раз, повтор перевода возвращает прежний ID
+			transfers := slices.Clone(env.ledger.transfers)
+			slices.Sort(transfers)
+			if len(slices.Compact(transfers)) != tt.wantTransfers || len(env.ledger.transfers) != tt.wantTransfers {
+				t.Errorf("ledger transfers = %v, want %d distinct", env.ledger.transfers, tt.wantTransfers)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository"
+	"moveshare/internal/repository/promo"
+	"time"
+)
+
+// creditHistoryLimit - сколько последних операций с кредитами показывать
+const creditHistoryLimit = 50
+
+var (
+	ErrPromoCodeNotFound    = errors.New("promo code not found")
+	ErrPromoCodeInvalid     = errors.New("invalid promo code")
+	ErrPromoCodeExists      = errors.New("promo code already exists")
+	ErrPromoCodeUnavailable = errors.New("promo code cannot be applied")
+	ErrInsufficientCredits  = errors.New("insufficient credit balance")
+)
+
+// PromoService - промокоды и кредиты на счету, уменьшающие сбор за публикацию работы
+type PromoService interface {
+	// Promo codes (администратор)
+	CreatePromoCode(ctx context.Context, adminID int64, req *models.CreatePromoCodeRequest) (*models.PromoCode, error)
+	UpdatePromoCode(ctx context.Context, id int64, req *models.UpdatePromoCodeRequest) (*models.PromoCode, error)
+	ListPromoCodes(ctx context.Context, limit, offset int) ([]models.PromoCode, int, error)
+	GetPromoRedemptions(ctx context.Context, promoCodeID int64, limit, offset int) ([]models.FeeRedemption, int, error)
+
+	// Скидка на сбор при публикации работы
+	RedeemForJob(ctx context.Context, userID int64, job *models.Job, code string) (*models.FeeRedemption, error)
+	ReleaseJobRedemption(ctx context.Context, jobID int64) error
+
+	// Credits
+	GetCreditBalance(ctx context.Context, userID int64) (*models.CreditBalance, error)
+	AdjustCredits(ctx context.Context, adminID, userID int64, req *models.AdjustCreditsRequest) (*models.CreditBalance, error)
+}
+
+// jobFeeRepository сохраняет пересчитанный сбор в работе (реализует *repository.JobRepository)
+type jobFeeRepository interface {
+	UpdateJobFeeBreakdown(ctx context.Context, jobID int64, breakdown *models.FeeBreakdown) error
+}
+
+type promoService struct {
+	repo          promo.PromoRepository
+	jobRepo       jobFeeRepository
+	ledgerService LedgerService
+}
+
+func NewPromoService(repo promo.PromoRepository, jobRepo *repository.JobRepository, ledgerService LedgerService) PromoService {
+	return &promoService{repo: repo, jobRepo: jobRepo, ledgerService: ledgerService}
+}
+
+func (s *promoService) CreatePromoCode(ctx context.Context, adminID int64, req *models.CreatePromoCodeRequest) (*models.PromoCode, error) {
+	code := &models.PromoCode{
+		Code:           models.NormalizePromoCode(req.Code),
+		Description:    req.Description,
+		DiscountType:   req.DiscountType,
+		MaxRedemptions: req.MaxRedemptions,
+		PerUserLimit:   req.PerUserLimit,
+		FirstJobOnly:   req.FirstJobOnly,
+		StartsAt:       req.StartsAt,
+		ExpiresAt:      req.ExpiresAt,
+		IsActive:       true,
+		CreatedBy:      &adminID,
+	}
+	// Сохраняется только размер скидки выбранного типа
+	if req.DiscountType == models.PromoDiscountPercentage {
+		code.PercentOff = req.PercentOff
+	} else {
+		code.AmountOffCents = req.AmountOffCents
+	}
+
+	if err := code.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPromoCodeInvalid, err)
+	}
+
+	existing, err := s.repo.GetPromoCodeByCode(ctx, code.Code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check promo code: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrPromoCodeExists
+	}
+
+	if err := s.repo.CreatePromoCode(ctx, code); err != nil {
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+
+	return code, nil
+}
+
+func (s *promoService) UpdatePromoCode(ctx context.Context, id int64, req *models.UpdatePromoCodeRequest) (*models.PromoCode, error) {
+	code, err := s.repo.GetPromoCodeByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	if code == nil {
+		return nil, ErrPromoCodeNotFound
+	}
+
+	if req.Description != nil {
+		code.Description = *req.Description
+	}
+	if req.MaxRedemptions != nil {
+		code.MaxRedemptions = req.MaxRedemptions
+	}
+	if req.PerUserLimit != nil {
+		code.PerUserLimit = req.PerUserLimit
+	}
+	if req.FirstJobOnly != nil {
+		code.FirstJobOnly = *req.FirstJobOnly
+	}
+	if req.StartsAt != nil {
+		code.StartsAt = req.StartsAt
+	}
+	if req.ExpiresAt != nil {
+		code.ExpiresAt = req.ExpiresAt
+	}
+	if req.IsActive != nil {
+		code.IsActive = *req.IsActive
+	}
+
+	if err := code.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPromoCodeInvalid, err)
+	}
+
+	if err := s.repo.UpdatePromoCode(ctx, code); err != nil {
+		return nil, fmt.Errorf("failed to update promo code: %w", err)
+	}
+
+	return code, nil
+}
+
+func (s *promoService) ListPromoCodes(ctx context.Context, limit, offset int) ([]models.PromoCode, int, error) {
+	return s.repo.ListPromoCodes(ctx, limit, offset)
+}
+
+func (s *promoService) GetPromoRedemptions(ctx context.Context, promoCodeID int64, limit, offset int) ([]models.FeeRedemption, int, error) {
+	code, err := s.repo.GetPromoCodeByID(ctx, promoCodeID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	if code == nil {
+		return nil, 0, ErrPromoCodeNotFound
+	}
+
+	return s.repo.GetPromoRedemptions(ctx, promoCodeID, limit, offset)
+}
+
+// RedeemForJob уменьшает сбор за публикацию только что созданной работы: сначала скидка по промокоду,
+// затем кредиты на счету. Скидка записывается, а пересчитанный сбор сохраняется в работе.
+// Возвращает nil, если уменьшать нечего
+func (s *promoService) RedeemForJob(ctx context.Context, userID int64, job *models.Job, code string) (*models.FeeRedemption, error) {
+	code = models.NormalizePromoCode(code)
+	breakdown := job.FeeBreakdown
+	if breakdown == nil {
+		if code != "" {
+			return nil, fmt.Errorf("%w: job has no processing fee", ErrPromoCodeUnavailable)
+		}
+		return nil, nil
+	}
+
+	// Повторная обработка публикации: скидка уже применена, сохраняем ее в сборе еще раз
+	existing, err := s.repo.GetJobRedemption(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job redemption: %w", err)
+	}
+	if existing != nil {
+		applyRedemption(breakdown, existing)
+		if err := s.jobRepo.UpdateJobFeeBreakdown(ctx, job.ID, breakdown); err != nil {
+			return nil, fmt.Errorf("failed to save job fee: %w", err)
+		}
+		s.postRedemptionToLedger(ctx, existing)
+		return existing, nil
+	}
+
+	fee := breakdown.ProcessingFeeCents
+
+	var promoCode *models.PromoCode
+	var discount int64
+	if code != "" {
+		promoCode, err = s.repo.GetPromoCodeByCode(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get promo code: %w", err)
+		}
+		if promoCode == nil {
+			return nil, ErrPromoCodeNotFound
+		}
+		if err := s.checkPromoCode(ctx, promoCode, userID, job.ID); err != nil {
+			return nil, err
+		}
+		discount = promoCode.DiscountCents(fee)
+	}
+
+	balance, err := s.repo.GetCreditBalance(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit balance: %w", err)
+	}
+	credits := min(balance, fee-discount)
+
+	if discount == 0 && credits == 0 {
+		if promoCode != nil {
+			return nil, fmt.Errorf("%w: there is no processing fee to discount", ErrPromoCodeUnavailable)
+		}
+		return nil, nil
+	}
+
+	// Остаток меньше минимального платежа Stripe не списывается
+	if rest := fee - discount - credits; rest > 0 && rest < models.MinimumChargeCents {
+		discount += rest
+	}
+
+	redemption := &models.FeeRedemption{
+		UserID:              userID,
+		JobID:               &job.ID,
+		FeeBeforeCents:      fee,
+		DiscountCents:       discount,
+		CreditsAppliedCents: credits,
+		FeeAfterCents:       fee - discount - credits,
+	}
+	if promoCode != nil {
+		redemption.PromoCodeID = &promoCode.ID
+		redemption.PromoCode = promoCode.Code
+	}
+
+	created, err := s.repo.CreateRedemption(ctx, redemption, promoCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record redemption: %w", err)
+	}
+	if !created {
+		return nil, fmt.Errorf("%w: usage limit reached or credit balance changed, please try again", ErrPromoCodeUnavailable)
+	}
+
+	applyRedemption(breakdown, redemption)
+
+	if err := s.jobRepo.UpdateJobFeeBreakdown(ctx, job.ID, breakdown); err != nil {
+		if releaseErr := s.ReleaseJobRedemption(ctx, job.ID); releaseErr != nil {
+			fmt.Printf("Failed to release redemption for job %d: %v\n", job.ID, releaseErr)
+		}
+		return nil, fmt.Errorf("failed to save job fee: %w", err)
+	}
+
+	s.postRedemptionToLedger(ctx, redemption)
+
+	return redemption, nil
+}
+
+// postRedemptionToLedger проводит скидку по журналу. Источник транзакции - скидка,
+// поэтому повторная обработка публикации не проводит ее дважды
+func (s *promoService) postRedemptionToLedger(ctx context.Context, redemption *models.FeeRedemption) {
+	if s.ledgerService == nil {
+		return
+	}
+	if err := s.ledgerService.PostFeeRedemption(ctx, redemption); err != nil {
+		fmt.Printf("Failed to post redemption %d to ledger: %v\n", redemption.ID, err)
+	}
+}
+
+// applyRedemption переносит скидку и кредиты в сбор работы
+func applyRedemption(breakdown *models.FeeBreakdown, redemption *models.FeeRedemption) {
+	breakdown.PromoCode = redemption.PromoCode
+	breakdown.PromoCodeDiscountCents = redemption.DiscountCents
+	breakdown.CreditsAppliedCents = redemption.CreditsAppliedCents
+	breakdown.ProcessingFeeCents = redemption.FeeAfterCents
+	breakdown.DiscountCents = breakdown.BaseFeeCents - breakdown.ProcessingFeeCents
+	breakdown.TotalChargeCents = breakdown.PayoutCents + breakdown.ProcessingFeeCents
+}
+
+// checkPromoCode проверяет срок действия и ограничения промокода для пользователя
+func (s *promoService) checkPromoCode(ctx context.Context, code *models.PromoCode, userID, jobID int64) error {
+	now := time.Now()
+	switch {
+	case !code.IsActive:
+		return fmt.Errorf("%w: promo code is not active", ErrPromoCodeUnavailable)
+	case code.StartsAt != nil && now.Before(*code.StartsAt):
+		return fmt.Errorf("%w: promo code is not active yet", ErrPromoCodeUnavailable)
+	case code.ExpiresAt != nil && !now.Before(*code.ExpiresAt):
+		return fmt.Errorf("%w: promo code has expired", ErrPromoCodeUnavailable)
+	}
+
+	total, byUser, err := s.repo.CountRedemptions(ctx, code.ID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to count promo code redemptions: %w", err)
+	}
+	if code.MaxRedemptions != nil && total >= *code.MaxRedemptions {
+		return fmt.Errorf("%w: promo code usage limit reached", ErrPromoCodeUnavailable)
+	}
+	if code.PerUserLimit != nil && byUser >= *code.PerUserLimit {
+		return fmt.Errorf("%w: you have already used this promo code", ErrPromoCodeUnavailable)
+	}
+
+	if code.FirstJobOnly {
+		jobs, err := s.repo.CountUserJobs(ctx, userID, jobID)
+		if err != nil {
+			return fmt.Errorf("failed to count user jobs: %w", err)
+		}
+		if jobs > 0 {
+			return fmt.Errorf("%w: promo code is only valid for your first job", ErrPromoCodeUnavailable)
+		}
+	}
+
+	return nil
+}
+
+// ReleaseJobRedemption отменяет скидку работы, публикация которой не прошла, и возвращает кредиты
+func (s *promoService) ReleaseJobRedemption(ctx context.Context, jobID int64) error {
+	reversed, err := s.repo.ReverseJobRedemption(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to reverse redemption: %w", err)
+	}
+
+	if reversed != nil && s.ledgerService != nil {
+		if err := s.ledgerService.PostFeeRedemptionReversal(ctx, reversed); err != nil {
+			fmt.Printf("Failed to post reversal of redemption %d to ledger: %v\n", reversed.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *promoService) GetCreditBalance(ctx context.Context, userID int64) (*models.CreditBalance, error) {
+	balance, err := s.repo.GetCreditBalance(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit balance: %w", err)
+	}
+
+	transactions, err := s.repo.GetCreditTransactions(ctx, userID, creditHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit transactions: %w", err)
+	}
+
+	return &models.CreditBalance{UserID: userID, BalanceCents: balance, Transactions: transactions}, nil
+}
+
+func (s *promoService) AdjustCredits(ctx context.Context, adminID, userID int64, req *models.AdjustCreditsRequest) (*models.CreditBalance, error) {
+	reason := models.CreditReasonAdminGrant
+	if req.AmountCents < 0 {
+		reason = models.CreditReasonAdminDeduction
+	}
+
+	credit := &models.CreditTransaction{
+		UserID:      userID,
+		AmountCents: req.AmountCents,
+		Reason:      reason,
+		Note:        req.Note,
+		CreatedBy:   &adminID,
+	}
+	applied, err := s.repo.AdjustCredits(ctx, credit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust credits: %w", err)
+	}
+	if !applied {
+		return nil, ErrInsufficientCredits
+	}
+
+	if s.ledgerService != nil {
+		if err := s.ledgerService.PostCreditAdjustment(ctx, credit); err != nil {
+			fmt.Printf("Failed to post credit adjustment %d to ledger: %v\n", credit.ID, err)
+		}
+	}
+
+	return s.GetCreditBalance(ctx, userID)
+}
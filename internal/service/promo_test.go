@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"moveshare/internal/models"
+	"testing"
+	"time"
+)
+
+// newPromoTestJob - работа со сбором $20 и оплатой $500
+func newPromoTestJob() *models.Job {
+	return &models.Job{
+		ID: testJobID,
+		FeeBreakdown: &models.FeeBreakdown{
+			PayoutCents:        50000,
+			BaseFeeCents:       2000,
+			ProcessingFeeCents: 2000,
+			TotalChargeCents:   52000,
+		},
+	}
+}
+
+func TestRedeemForJob(t *testing.T) {
+	const userID = testContractorID
+	one := 1
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		setup        func(repo *memPromoRepo)
+		noFee        bool
+		code         string
+		wantErr      error
+		wantRedeemed bool
+		wantDiscount int64
+		wantCredits  int64
+		wantFee      int64
+		wantBalance  int64
+	}{
+		{
+			name:    "nothing to apply",
+			wantFee: 2000,
+		},
+		{
+			name: "percentage code",
+			setup: func(repo *memPromoRepo) {
+				repo.addCode(&models.PromoCode{Code: "SPRING25", DiscountType: models.PromoDiscountPercentage, PercentOff: 25})
+			},
+			code:         " spring25 ",
+			wantRedeemed: true,
+			wantDiscount: 500,
+			wantFee:      1500,
+		},
+		{
+			name: "fixed code larger than the fee",
+			setup: func(repo *memPromoRepo) {
+				repo.addCode(&models.PromoCode{Code: "FREEPOST", DiscountType: models.PromoDiscountFixed, AmountOffCents: 2500})
+			},
+			code:         "FREEPOST",
+			wantRedeemed: true,
+			wantDiscount: 2000,
+		},
+		{
+			name: "credits only",
+			setup: func(repo *memPromoRepo) {
+				repo.credits[userID] = 700
+			},
+			wantRedeemed: true,
+			wantCredits:  700,
+			wantFee:      1300,
+		},
+		{
+			name: "code and credits",
+			setup: func(repo *memPromoRepo) {
+				repo.addCode(&models.PromoCode{Code: "SPRING25", DiscountType: models.PromoDiscountPercentage, PercentOff: 25})
+				repo.credits[userID] = 5000
+			},
+			code:         "SPRING25",
+			wantRedeemed: true,
+			wantDiscount: 500,
+			wantCredits:  1500,
+			wantBalance:  3500,
+		},
+		{
+			name: "remainder below the minimum charge is waived",
+			setup: func(repo *memPromoRepo) {
+				repo.addCode(&models.PromoCode{Code: "ALMOST", DiscountType: models.PromoDiscountFixed, AmountOffCents: 1970})
+			},
+			code:         "ALMOST",
+			wantRedeemed: true,
+			wantDiscount: 2000,
+		},
+		{
+			name:    "unknown code",
+			code:    "NOPE",
+			wantErr: ErrPromoCodeNotFound,
+			wantFee: 2000,
+		},
+		{
+			name: "expired code",
+			setup: func(repo *memPromoRepo) {
+				repo.addCode(&models.PromoCode{Code: "OLD", DiscountType: models.PromoDiscountFixed, AmountOffCents: 500, ExpiresAt: &past})
+			},
+			code:    "OLD",
+			wantErr: ErrPromoCodeUnavailable,
+			wantFee: 2000,
+		},
+		{
+			name: "usage limit reached",
+			setup: func(repo *memPromoRepo) {
+				code := repo.addCode(&models.PromoCode{Code: "ONCE", DiscountType: models.PromoDiscountFixed, AmountOffCents: 500, MaxRedemptions: &one})
+				otherJob := int64(11)
+				repo.redemptions = append(repo.redemptions, &models.FeeRedemption{
+					PromoCodeID: &code.ID, UserID: testExecutorID, JobID: &otherJob, Status: models.FeeRedemptionApplied,
+				})
+			},
+			code:    "ONCE",
+			wantErr: ErrPromoCodeUnavailable,
+			wantFee: 2000,
+		},
+		{
+			name: "first job only",
+			setup: func(repo *memPromoRepo) {
+				repo.addCode(&models.PromoCode{Code: "WELCOME", DiscountType: models.PromoDiscountFixed, AmountOffCents: 500, FirstJobOnly: true})
+				repo.userJobs[userID] = 1
+			},
+			code:    "WELCOME",
+			wantErr: ErrPromoCodeUnavailable,
+			wantFee: 2000,
+		},
+		{
+			name:    "job without a processing fee",
+			noFee:   true,
+			code:    "SPRING25",
+			wantErr: ErrPromoCodeUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newMemPromoRepo()
+			fees := memJobFees{}
+			svc := &promoService{repo: repo, jobRepo: fees}
+			if tt.setup != nil {
+				tt.setup(repo)
+			}
+			job := newPromoTestJob()
+			if tt.noFee {
+				job.FeeBreakdown = nil
+			}
+
+			redemption, err := svc.RedeemForJob(ctx, userID, job, tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if (redemption != nil) != tt.wantRedeemed {
+				t.Fatalf("redemption = %+v, want redeemed %v", redemption, tt.wantRedeemed)
+			}
+			if redemption != nil {
+				if redemption.DiscountCents != tt.wantDiscount || redemption.CreditsAppliedCents != tt.wantCredits || redemption.FeeAfterCents != tt.wantFee {
+					t.Errorf("redemption = discount %d, credits %d, fee %d; want %d, %d, %d",
+						redemption.DiscountCents, redemption.CreditsAppliedCents, redemption.FeeAfterCents, tt.wantDiscount, tt.wantCredits, tt.wantFee)
+				}
+				if saved, ok := fees[job.ID]; !ok || saved != *job.FeeBreakdown {
+					t.Errorf("saved fee %+v, want %+v", saved, *job.FeeBreakdown)
+				}
+			} else if len(fees) != 0 {
+				t.Errorf("fee was saved without a redemption: %+v", fees)
+			}
+
+			if job.FeeBreakdown != nil {
+				b := job.FeeBreakdown
+				if b.ProcessingFeeCents != tt.wantFee || b.TotalChargeCents != b.PayoutCents+tt.wantFee || b.DiscountCents != b.BaseFeeCents-tt.wantFee {
+					t.Errorf("fee breakdown = fee %d, total %d, discount %d", b.ProcessingFeeCents, b.TotalChargeCents, b.DiscountCents)
+				}
+			}
+			if repo.credits[userID] != tt.wantBalance {
+				t.Errorf("credit balance = %d, want %d", repo.credits[userID], tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestReleaseJobRedemption(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemPromoRepo()
+	fees := memJobFees{}
+	ledgerRepo := newMemLedgerRepo()
+	svc := &promoService{repo: repo, jobRepo: fees, ledgerService: NewLedgerService(ledgerRepo)}
+	one := 1
+	repo.addCode(&models.PromoCode{Code: "ONCE", DiscountType: models.PromoDiscountPercentage, PercentOff: 50, MaxRedemptions: &one})
+	repo.credits[testContractorID] = 600
+
+	job := newPromoTestJob()
+	redemption, err := svc.RedeemForJob(ctx, testContractorID, job, "ONCE")
+	if err != nil {
+		t.Fatalf("RedeemForJob: %v", err)
+	}
+
+	// Повторная обработка публикации не списывает скидку и кредиты второй раз
+	replayed, err := svc.RedeemForJob(ctx, testContractorID, newPromoTestJob(), "ONCE")
+	if err != nil || replayed.ID != redemption.ID || len(repo.redemptions) != 1 {
+		t.Fatalf("replayed redemption = %+v, %v; want the existing one", replayed, err)
+	}
+	if repo.credits[testContractorID] != 0 {
+		t.Fatalf("credit balance = %d after redemption, want 0", repo.credits[testContractorID])
+	}
+
+	// Выручка признается в полном сборе: скидка - расход на промо, кредиты списаны со счета кредитов
+	wantLedger := map[string]int64{
+		models.LedgerPromoExpense:    1000,
+		models.LedgerCustomerCredits: 600,
+		models.LedgerPlatformRevenue: -1600,
+	}
+	for account, want := range wantLedger {
+		if got := ledgerRepo.balance(account); got != want {
+			t.Errorf("ledger %s = %d after redemption, want %d", account, got, want)
+		}
+	}
+	if len(ledgerRepo.transactions) != 1 {
+		t.Errorf("ledger transactions = %d after replay, want 1", len(ledgerRepo.transactions))
+	}
+
+	// Откат возвращает кредиты и освобождает промокод; повторный откат ничего не делает
+	for i := 0; i < 2; i++ {
+		if err := svc.ReleaseJobRedemption(ctx, job.ID); err != nil {
+			t.Fatalf("ReleaseJobRedemption: %v", err)
+		}
+	}
+	if repo.redemptions[0].Status != models.FeeRedemptionReversed || repo.redemptions[0].ReversedAt == nil {
+		t.Errorf("redemption status = %s, want reversed", repo.redemptions[0].Status)
+	}
+	if repo.credits[testContractorID] != 600 {
+		t.Errorf("credit balance = %d after release, want 600", repo.credits[testContractorID])
+	}
+	for account := range wantLedger {
+		if got := ledgerRepo.balance(account); got != 0 {
+			t.Errorf("ledger %s = %d after release, want 0", account, got)
+		}
+	}
+	if len(ledgerRepo.transactions) != 2 {
+		t.Errorf("ledger transactions = %d after release, want 2", len(ledgerRepo.transactions))
+	}
+
+	otherJob := newPromoTestJob()
+	otherJob.ID = 11
+	if _, err := svc.RedeemForJob(ctx, testContractorID, otherJob, "ONCE"); err != nil {
+		t.Errorf("promo code is still used up after release: %v", err)
+	}
+}
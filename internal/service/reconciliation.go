@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/payment"
+	"moveshare/internal/repository/reconciliation"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+const (
+	// Сверяем PaymentIntent за последние трое суток
+	reconcileLookback = 72 * time.Hour
+	// Свежие PaymentIntent не сверяем: платеж сохраняется в БД после создания PaymentIntent в Stripe
+	reconcileGracePeriod = 10 * time.Minute
+	// Количество недавних автоисправлений в отчете
+	reconcileRecentFixesLimit = 20
+)
+
+// ReconciliationService сверяет платежи с PaymentIntent в Stripe: безопасные расхождения
+// (пропущенные обновления статуса и возвратов) исправляет, остальные выносит в отчет для администраторов
+type ReconciliationService interface {
+	Reconcile(ctx context.Context) (*models.ReconciliationRun, error)
+	StartReconciler(ctx context.Context, interval time.Duration)
+	GetReport(ctx context.Context, limit, offset int) (*models.ReconciliationReport, error)
+	ResolveIssue(ctx context.Context, issueID, adminID int64, note string) error
+}
+
+type reconciliationService struct {
+	repo                reconciliation.ReconciliationRepository
+	paymentRepo         payment.PaymentRepository
+	paymentService      PaymentService
+	gateway             PaymentGateway
+	adminService        AdminService
+	notificationService NotificationService
+}
+
+func NewReconciliationService(
+	repo reconciliation.ReconciliationRepository,
+	paymentRepo payment.PaymentRepository,
+	paymentService PaymentService,
+	gateway PaymentGateway,
+	adminService AdminService,
+	notificationService NotificationService,
+) ReconciliationService {
+	return &reconciliationService{
+		repo:                repo,
+		paymentRepo:         paymentRepo,
+		paymentService:      paymentService,
+		gateway:             gateway,
+		adminService:        adminService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *reconciliationService) Reconcile(ctx context.Context) (*models.ReconciliationRun, error) {
+	run, err := s.repo.CreateRun(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start reconciliation run: %w", err)
+	}
+
+	now := time.Now()
+	newIssues, runErr := s.reconcileWindow(ctx, run, now.Add(-reconcileLookback), now.Add(-reconcileGracePeriod))
+	if runErr != nil {
+		message := runErr.Error()
+		run.Error = &message
+	}
+
+	if err := s.repo.FinishRun(ctx, run); err != nil {
+		fmt.Printf("Failed to finish reconciliation run %d: %v\n", run.ID, err)
+	}
+
+	switch {
+	case runErr != nil:
+		s.alertAdmins(ctx, fmt.Sprintf("Stripe reconciliation run #%d failed: %v", run.ID, runErr), "error")
+	case newIssues > 0:
+		s.alertAdmins(ctx, fmt.Sprintf("Stripe reconciliation run #%d flagged %d new payment issue(s) for review", run.ID, newIssues), "warning")
+	}
+
+	return run, runErr
+}
+
+// reconcileWindow сверяет PaymentIntent и платежи, созданные в интервале. Возвращает число новых открытых расхождений
+func (s *reconciliationService) reconcileWindow(ctx context.Context, run *models.ReconciliationRun, from, to time.Time) (int, error) {
+	intents, err := s.gateway.ListPaymentIntents(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	// Платежи берем с запасом: запись в БД появляется чуть позже PaymentIntent
+	payments, err := s.paymentRepo.GetPaymentsCreatedBetween(ctx, from.Add(-reconcileGracePeriod), to.Add(reconcileGracePeriod))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get payments: %w", err)
+	}
+
+	byIntent := make(map[string]*models.Payment, len(payments))
+	for i := range payments {
+		byIntent[payments[i].StripePaymentIntentID] = &payments[i]
+	}
+
+	run.IntentsChecked = len(intents)
+	newIssues := 0
+	seen := make(map[string]bool, len(intents))
+
+	for _, pi := range intents {
+		seen[pi.ID] = true
+
+		p, ok := byIntent[pi.ID]
+		if !ok {
+			// Счета подписок Stripe оплачивает своими PaymentIntent, записей в payments для них нет
+			invoiceID, err := s.gateway.GetPaymentIntentInvoice(ctx, pi.ID)
+			if err != nil {
+				// Проверим в следующий запуск: PaymentIntent останется в окне сверки
+				fmt.Printf("Failed to check invoice of PaymentIntent %s: %v\n", pi.ID, err)
+				continue
+			}
+			if invoiceID != "" {
+				continue
+			}
+
+			issue := &models.ReconciliationIssue{
+				IssueType:         models.ReconciliationMissingPayment,
+				StripeStatus:      string(pi.Status),
+				StripeAmountCents: pi.Amount,
+				Details:           fmt.Sprintf("PaymentIntent %s (%s) has no matching payment record", pi.ID, pi.Description),
+			}
+			newIssues += s.flag(ctx, run, pi.ID, issue)
+			continue
+		}
+
+		run.PaymentsChecked++
+		newIssues += s.reconcilePayment(ctx, run, p, pi)
+	}
+
+	// Платежи, PaymentIntent которых не попал в выборку, проверяем по одному
+	for i := range payments {
+		p := &payments[i]
+		if seen[p.StripePaymentIntentID] || p.CreatedAt.Before(from) || !p.CreatedAt.Before(to) {
+			continue
+		}
+
+		run.PaymentsChecked++
+		pi, err := s.gateway.GetPaymentIntent(ctx, p.StripePaymentIntentID)
+		if err != nil {
+			issue := &models.ReconciliationIssue{
+				PaymentID:        &p.ID,
+				IssueType:        models.ReconciliationMissingIntent,
+				LocalStatus:      p.Status,
+				LocalAmountCents: p.AmountCents,
+				Details:          fmt.Sprintf("Payment %d references a PaymentIntent that could not be loaded from Stripe: %v", p.ID, err),
+			}
+			newIssues += s.flag(ctx, run, p.StripePaymentIntentID, issue)
+			continue
+		}
+
+		newIssues += s.reconcilePayment(ctx, run, p, pi)
+	}
+
+	return newIssues, nil
+}
+
+// reconcilePayment сравнивает платеж с PaymentIntent. Возвращает число новых открытых расхождений
+func (s *reconciliationService) reconcilePayment(ctx context.Context, run *models.ReconciliationRun, p *models.Payment, pi *stripe.PaymentIntent) int {
+	issue := &models.ReconciliationIssue{
+		PaymentID:         &p.ID,
+		LocalStatus:       p.Status,
+		StripeStatus:      string(pi.Status),
+		LocalAmountCents:  p.AmountCents,
+		StripeAmountCents: pi.Amount,
+	}
+
+	// Сумма не совпадает - платеж мог быть связан не с тем PaymentIntent, ничего не меняем
+	if pi.Amount != p.AmountCents {
+		issue.IssueType = models.ReconciliationAmountMismatch
+		issue.Details = fmt.Sprintf("Payment %d amount %d differs from PaymentIntent amount %d", p.ID, p.AmountCents, pi.Amount)
+		return s.flag(ctx, run, pi.ID, issue)
+	}
+
+	// Успешный платеж уже проведен по журналу и по нему выставлен счет - откатывать автоматически нельзя
+	succeeded := string(stripe.PaymentIntentStatusSucceeded)
+	if p.Status == succeeded && string(pi.Status) != succeeded {
+		issue.IssueType = models.ReconciliationStatusConflict
+		issue.Details = fmt.Sprintf("Payment %d is recorded as succeeded but PaymentIntent is %s", p.ID, pi.Status)
+		return s.flag(ctx, run, pi.ID, issue)
+	}
+
+	statusChanged := string(pi.Status) != p.Status
+	refundChanged := pi.LatestCharge != nil && pi.LatestCharge.AmountRefunded > p.AmountRefundedCents
+	if !statusChanged && !refundChanged {
+		return 0
+	}
+
+	if err := s.paymentService.ApplyPaymentIntent(ctx, p, pi); err != nil {
+		issue.IssueType = models.ReconciliationFixFailed
+		issue.Details = fmt.Sprintf("Failed to apply PaymentIntent state to payment %d: %v", p.ID, err)
+		return s.flag(ctx, run, pi.ID, issue)
+	}
+
+	if statusChanged {
+		fix := *issue
+		fix.IssueType = models.ReconciliationStatusUpdated
+		fix.Details = fmt.Sprintf("Payment %d status updated from %s to %s", p.ID, p.Status, pi.Status)
+		s.recordFix(ctx, run, pi.ID, &fix)
+	}
+	if refundChanged {
+		fix := *issue
+		fix.IssueType = models.ReconciliationRefundUpdated
+		fix.Details = fmt.Sprintf("Payment %d refunded amount updated from %d to %d", p.ID, p.AmountRefundedCents, pi.LatestCharge.AmountRefunded)
+		s.recordFix(ctx, run, pi.ID, &fix)
+	}
+
+	return 0
+}
+
+// flag сохраняет открытое расхождение. Возвращает 1, если оно найдено впервые
+func (s *reconciliationService) flag(ctx context.Context, run *models.ReconciliationRun, paymentIntentID string, issue *models.ReconciliationIssue) int {
+	issue.RunID = &run.ID
+	issue.StripePaymentIntentID = paymentIntentID
+	issue.Status = models.ReconciliationIssueOpen
+	run.Flagged++
+
+	inserted, err := s.repo.SaveIssue(ctx, issue)
+	if err != nil {
+		fmt.Printf("Failed to save reconciliation issue for %s: %v\n", paymentIntentID, err)
+		return 0
+	}
+	if inserted {
+		return 1
+	}
+	return 0
+}
+
+func (s *reconciliationService) recordFix(ctx context.Context, run *models.ReconciliationRun, paymentIntentID string, issue *models.ReconciliationIssue) {
+	issue.RunID = &run.ID
+	issue.StripePaymentIntentID = paymentIntentID
+	issue.Status = models.ReconciliationIssueAutoFixed
+	run.AutoFixed++
+
+	if _, err := s.repo.SaveIssue(ctx, issue); err != nil {
+		fmt.Printf("Failed to save reconciliation fix for %s: %v\n", paymentIntentID, err)
+	}
+}
+
+func (s *reconciliationService) alertAdmins(ctx context.Context, message, level string) {
+	adminIDs, err := s.adminService.GetAdminUserIDs(ctx)
+	if err != nil {
+		fmt.Printf("Failed to get admins for reconciliation alert: %v\n", err)
+		return
+	}
+
+	for _, adminID := range adminIDs {
+		s.notificationService.NotifySystemMessage(adminID, message, level)
+	}
+}
+
+func (s *reconciliationService) StartReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run, err := s.Reconcile(ctx)
+		if err != nil {
+			fmt.Printf("Stripe reconciler error: %v\n", err)
+		} else if run.AutoFixed > 0 || run.Flagged > 0 {
+			fmt.Printf("Stripe reconciler: %d auto-fixed, %d flagged\n", run.AutoFixed, run.Flagged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *reconciliationService) GetReport(ctx context.Context, limit, offset int) (*models.ReconciliationReport, error) {
+	latestRun, err := s.repo.GetLatestRun(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest reconciliation run: %w", err)
+	}
+
+	openIssues, total, err := s.repo.GetIssuesByStatus(ctx, models.ReconciliationIssueOpen, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open reconciliation issues: %w", err)
+	}
+
+	fixes, _, err := s.repo.GetIssuesByStatus(ctx, models.ReconciliationIssueAutoFixed, reconcileRecentFixesLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliation fixes: %w", err)
+	}
+
+	return &models.ReconciliationReport{
+		LatestRun:       latestRun,
+		OpenIssues:      openIssues,
+		OpenIssuesTotal: total,
+		RecentFixes:     fixes,
+	}, nil
+}
+
+func (s *reconciliationService) ResolveIssue(ctx context.Context, issueID, adminID int64, note string) error {
+	resolved, err := s.repo.ResolveIssue(ctx, issueID, adminID, note)
+	if err != nil {
+		return fmt.Errorf("failed to resolve reconciliation issue: %w", err)
+	}
+	if !resolved {
+		return fmt.Errorf("reconciliation issue not found or already resolved")
+	}
+	return nil
+}
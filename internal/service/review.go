@@ -8,11 +8,12 @@ import (
 )
 
 type ReviewService struct {
-	reviewRepo *review.ReviewRepository
+	reviewRepo     *review.ReviewRepository
+	paymentService PaymentService
 }
 
-func NewReviewService(reviewRepo *review.ReviewRepository) *ReviewService {
-	return &ReviewService{reviewRepo: reviewRepo}
+func NewReviewService(reviewRepo *review.ReviewRepository, paymentService PaymentService) *ReviewService {
+	return &ReviewService{reviewRepo: reviewRepo, paymentService: paymentService}
 }
 
 func (s *ReviewService) CreateReview(userID int64, req *models.CreateReviewRequest) (*models.Review, error) {
@@ -45,6 +46,15 @@ func (s *ReviewService) CreateReview(userID int64, req *models.CreateReviewReque
 		return nil, fmt.Errorf("you are not authorized to review this job")
 	}
 
+	if req.TipCents > 0 {
+		if userID != contractorID {
+			return nil, fmt.Errorf("only the job owner can leave a tip")
+		}
+		if s.paymentService == nil {
+			return nil, fmt.Errorf("tips are not available")
+		}
+	}
+
 	reviewModel := &models.Review{
 		JobID:      req.JobID,
 		ReviewerID: userID,
@@ -58,6 +68,20 @@ func (s *ReviewService) CreateReview(userID int64, req *models.CreateReviewReque
 		return nil, fmt.Errorf("failed to create review: %w", err)
 	}
 
+	// Чаевые списываются после сохранения отзыва: при отклоненной карте отзыв остается,
+	// а чаевые можно повторить отдельно (POST /payment/jobs/{id}/tip)
+	if req.TipCents > 0 {
+		tip, err := s.paymentService.TipJob(ctx, userID, req.JobID, &models.CreateTipRequest{
+			AmountCents:     req.TipCents,
+			PaymentMethodID: req.PaymentMethodID,
+		})
+		if err != nil {
+			reviewModel.TipError = err.Error()
+		} else {
+			reviewModel.Tip = tip
+		}
+	}
+
 	return reviewModel, nil
 }
 
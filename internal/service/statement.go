@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/company"
+	"moveshare/internal/repository/payout"
+	"time"
+)
+
+// firstStatementYear - самый ранний год, за который можно запросить выписку
+const firstStatementYear = 2020
+
+var ErrInvalidStatementPeriod = errors.New("invalid statement period")
+
+// StatementService - выписки о заработке исполнителя по журналу выплат
+type StatementService interface {
+	GetMonthlyStatement(ctx context.Context, userID int64, year, month int) (*models.EarningsStatement, error)
+	GetAnnualSummary(ctx context.Context, userID int64, year int) (*models.AnnualEarningsSummary, error)
+}
+
+type statementService struct {
+	payoutRepo  payout.PayoutRepository
+	companyRepo company.CompanyRepository
+}
+
+func NewStatementService(payoutRepo payout.PayoutRepository, companyRepo company.CompanyRepository) StatementService {
+	return &statementService{
+		payoutRepo:  payoutRepo,
+		companyRepo: companyRepo,
+	}
+}
+
+// GetMonthlyStatement собирает выписку за календарный месяц (UTC): каждая выполненная работа
+// с оплатой, комиссией платформы, чаевыми и суммой к выплате
+func (s *statementService) GetMonthlyStatement(ctx context.Context, userID int64, year, month int) (*models.EarningsStatement, error) {
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("%w: month must be between 1 and 12", ErrInvalidStatementPeriod)
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	if err := validateStatementStart(start); err != nil {
+		return nil, err
+	}
+	end := start.AddDate(0, 1, 0)
+
+	lines, err := s.payoutRepo.GetEarningsLines(ctx, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings: %w", err)
+	}
+
+	payee, err := s.companyRepo.GetCompany(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get company: %w", err)
+	}
+
+	statement := &models.EarningsStatement{
+		UserID:      userID,
+		Year:        year,
+		Month:       month,
+		PeriodStart: start,
+		PeriodEnd:   end.Add(-time.Second),
+		Payee:       payee,
+		Lines:       lines,
+		Currency:    statementCurrency(lines),
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, line := range lines {
+		statement.Totals.Add(line)
+	}
+
+	return statement, nil
+}
+
+// GetAnnualSummary собирает годовую сводку выплат по месяцам для налоговой отчетности
+func (s *statementService) GetAnnualSummary(ctx context.Context, userID int64, year int) (*models.AnnualEarningsSummary, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := validateStatementStart(start); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.payoutRepo.GetEarningsLines(ctx, userID, start, start.AddDate(1, 0, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings: %w", err)
+	}
+
+	payee, err := s.companyRepo.GetCompany(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get company: %w", err)
+	}
+
+	summary := &models.AnnualEarningsSummary{
+		UserID:      userID,
+		Year:        year,
+		Payee:       payee,
+		Months:      make([]models.MonthlyEarnings, 12),
+		Currency:    statementCurrency(lines),
+		GeneratedAt: time.Now().UTC(),
+	}
+	for i := range summary.Months {
+		summary.Months[i].Month = i + 1
+	}
+	for _, line := range lines {
+		summary.Months[line.EarnedAt.UTC().Month()-1].Add(line)
+		summary.Totals.Add(line)
+	}
+
+	return summary, nil
+}
+
+func validateStatementStart(start time.Time) error {
+	if start.Year() < firstStatementYear {
+		return fmt.Errorf("%w: statements are available from %d", ErrInvalidStatementPeriod, firstStatementYear)
+	}
+	if start.After(time.Now()) {
+		return fmt.Errorf("%w: period has not started yet", ErrInvalidStatementPeriod)
+	}
+	return nil
+}
+
+func statementCurrency(lines []models.EarningsLine) string {
+	for _, line := range lines {
+		if line.Currency != "" {
+			return line.Currency
+		}
+	}
+	return "usd"
+}
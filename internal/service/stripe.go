@@ -5,42 +5,29 @@ import (
 	"fmt"
 	"log"
 	"moveshare/internal/config"
+	"moveshare/internal/models"
+	"time"
 
 	"github.com/stripe/stripe-go/v82"
+	"github.com/stripe/stripe-go/v82/account"
+	"github.com/stripe/stripe-go/v82/accountlink"
 	"github.com/stripe/stripe-go/v82/customer"
+	"github.com/stripe/stripe-go/v82/invoicepayment"
 	"github.com/stripe/stripe-go/v82/paymentintent"
 	"github.com/stripe/stripe-go/v82/paymentmethod"
+	"github.com/stripe/stripe-go/v82/refund"
 	"github.com/stripe/stripe-go/v82/setupintent"
+	"github.com/stripe/stripe-go/v82/subscription"
+	"github.com/stripe/stripe-go/v82/transfer"
 	"github.com/stripe/stripe-go/v82/webhook"
 )
 
-type StripeService interface {
-	// Customer management
-	CreateCustomer(ctx context.Context, userID int64, email, name string) (string, error)
-	GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error)
-
-	// Payment Methods
-	CreateSetupIntent(ctx context.Context, customerID string) (*stripe.SetupIntent, error)
-	AttachPaymentMethod(ctx context.Context, paymentMethodID, customerID string) (*stripe.PaymentMethod, error)
-	DetachPaymentMethod(ctx context.Context, paymentMethodID string) (*stripe.PaymentMethod, error)
-	ListPaymentMethods(ctx context.Context, customerID string) ([]*stripe.PaymentMethod, error)
-	SetDefaultPaymentMethod(ctx context.Context, customerID, paymentMethodID string) error
-
-	// Payments
-	CreatePaymentIntent(ctx context.Context, amount int64, currency, customerID, paymentMethodID, description string) (*stripe.PaymentIntent, error)
-	ConfirmPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
-	GetPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
-	CancelPaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error)
-
-	// Webhook
-	ConstructEvent(payload []byte, header string) (stripe.Event, error)
-}
-
 type stripeService struct {
 	config *config.StripeConfig
 }
 
-func NewStripeService(cfg *config.StripeConfig) StripeService {
+// NewStripeService - PaymentGateway поверх Stripe API
+func NewStripeService(cfg *config.StripeConfig) PaymentGateway {
 	// Устанавливаем API ключ Stripe
 	stripe.Key = cfg.PrivateKey
 
@@ -79,14 +66,25 @@ func (s *stripeService) GetCustomer(ctx context.Context, customerID string) (*st
 }
 
 // Payment Methods
-func (s *stripeService) CreateSetupIntent(ctx context.Context, customerID string) (*stripe.SetupIntent, error) {
+func (s *stripeService) CreateSetupIntent(ctx context.Context, customerID, paymentMethodType string) (*stripe.SetupIntent, error) {
 	params := &stripe.SetupIntentParams{
 		Customer: stripe.String(customerID),
 		PaymentMethodTypes: stripe.StringSlice([]string{
-			"card",
+			paymentMethodType,
 		}),
 		Usage: stripe.String("off_session"),
 	}
+	// Банковский счет подтверждается через Financial Connections, а если банк не поддерживается - микродепозитами
+	if paymentMethodType == models.PaymentMethodTypeUSBankAccount {
+		params.PaymentMethodOptions = &stripe.SetupIntentPaymentMethodOptionsParams{
+			USBankAccount: &stripe.SetupIntentPaymentMethodOptionsUSBankAccountParams{
+				VerificationMethod: stripe.String("automatic"),
+				FinancialConnections: &stripe.SetupIntentPaymentMethodOptionsUSBankAccountFinancialConnectionsParams{
+					Permissions: stripe.StringSlice([]string{"payment_method"}),
+				},
+			},
+		}
+	}
 
 	si, err := setupintent.New(params)
 	if err != nil {
@@ -96,6 +94,19 @@ func (s *stripeService) CreateSetupIntent(ctx context.Context, customerID string
 	return si, nil
 }
 
+// GetSetupIntent возвращает SetupIntent вместе с привязанным PaymentMethod
+func (s *stripeService) GetSetupIntent(ctx context.Context, setupIntentID string) (*stripe.SetupIntent, error) {
+	params := &stripe.SetupIntentParams{}
+	params.AddExpand("payment_method")
+
+	si, err := setupintent.Get(setupIntentID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get setup intent: %w", err)
+	}
+
+	return si, nil
+}
+
 func (s *stripeService) AttachPaymentMethod(ctx context.Context, paymentMethodID, customerID string) (*stripe.PaymentMethod, error) {
 	params := &stripe.PaymentMethodAttachParams{
 		Customer: stripe.String(customerID),
@@ -154,19 +165,26 @@ func (s *stripeService) SetDefaultPaymentMethod(ctx context.Context, customerID,
 }
 
 // Payments
-func (s *stripeService) CreatePaymentIntent(ctx context.Context, amount int64, currency, customerID, paymentMethodID, description string) (*stripe.PaymentIntent, error) {
+func (s *stripeService) CreatePaymentIntent(ctx context.Context, amount int64, currency, customerID string, method PaymentMethodRef, description, idempotencyKey string) (*stripe.PaymentIntent, error) {
 	params := &stripe.PaymentIntentParams{
 		Amount:   stripe.Int64(amount),
 		Currency: stripe.String(currency),
 		Customer: stripe.String(customerID),
 		PaymentMethodTypes: stripe.StringSlice([]string{
-			"card",
+			method.paymentMethodType(),
 		}),
-		PaymentMethod:      stripe.String(paymentMethodID),
+		PaymentMethod:      stripe.String(method.ID),
 		Description:        stripe.String(description),
 		ConfirmationMethod: stripe.String("manual"),
 		Confirm:            stripe.Bool(false), // ✅ ИЗМЕНЕНИЕ: не подтверждаем автоматически
 	}
+	if method.MandateID != "" {
+		params.Mandate = stripe.String(method.MandateID)
+	}
+	// Повтор с тем же ключом вернет уже созданный PaymentIntent
+	if idempotencyKey != "" {
+		params.SetIdempotencyKey(idempotencyKey)
+	}
 
 	pi, err := paymentintent.New(params)
 	if err != nil {
@@ -205,6 +223,285 @@ func (s *stripeService) CancelPaymentIntent(ctx context.Context, paymentIntentID
 	return pi, nil
 }
 
+// ListPaymentIntents возвращает PaymentIntent, созданные в интервале, вместе с последним списанием (для сверки возвратов)
+func (s *stripeService) ListPaymentIntents(ctx context.Context, createdFrom, createdTo time.Time) ([]*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: createdFrom.Unix(),
+			LesserThan:         createdTo.Unix(),
+		},
+	}
+	params.Limit = stripe.Int64(100)
+	params.AddExpand("data.latest_charge")
+
+	iter := paymentintent.List(params)
+	var paymentIntents []*stripe.PaymentIntent
+
+	for iter.Next() {
+		paymentIntents = append(paymentIntents, iter.PaymentIntent())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list payment intents: %w", err)
+	}
+
+	return paymentIntents, nil
+}
+
+// GetPaymentIntentInvoice ищет счет (подписки), к которому Stripe привязал PaymentIntent
+func (s *stripeService) GetPaymentIntentInvoice(ctx context.Context, paymentIntentID string) (string, error) {
+	params := &stripe.InvoicePaymentListParams{
+		Payment: &stripe.InvoicePaymentListPaymentParams{
+			Type:          stripe.String("payment_intent"),
+			PaymentIntent: stripe.String(paymentIntentID),
+		},
+	}
+	params.Limit = stripe.Int64(1)
+
+	iter := invoicepayment.List(params)
+	for iter.Next() {
+		if invoicePayment := iter.InvoicePayment(); invoicePayment.Invoice != nil {
+			return invoicePayment.Invoice.ID, nil
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return "", fmt.Errorf("failed to list invoice payments: %w", err)
+	}
+
+	return "", nil
+}
+
+// CreateAuthorizationIntent создает и сразу подтверждает PaymentIntent с ручным списанием:
+// деньги блокируются на карте до CapturePaymentIntent или CancelPaymentIntent.
+// offSession - клиент не участвует (повторная авторизация по сохраненной карте).
+// Повтор с тем же idempotencyKey вернет уже созданный PaymentIntent.
+// ACH не поддерживает ручное списание: с банковского счета деньги списываются сразу
+// и держатся на платформе до выполнения работы
+func (s *stripeService) CreateAuthorizationIntent(ctx context.Context, amount int64, currency, customerID string, method PaymentMethodRef, description string, offSession bool, idempotencyKey string) (*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentParams{
+		Amount:   stripe.Int64(amount),
+		Currency: stripe.String(currency),
+		Customer: stripe.String(customerID),
+		PaymentMethodTypes: stripe.StringSlice([]string{
+			method.paymentMethodType(),
+		}),
+		PaymentMethod: stripe.String(method.ID),
+		Description:   stripe.String(description),
+		CaptureMethod: stripe.String(string(stripe.PaymentIntentCaptureMethodManual)),
+		Confirm:       stripe.Bool(true),
+	}
+	if method.IsBankAccount() {
+		params.CaptureMethod = stripe.String(string(stripe.PaymentIntentCaptureMethodAutomatic))
+		params.Mandate = stripe.String(method.MandateID)
+	}
+	if offSession {
+		params.OffSession = stripe.Bool(true)
+	}
+	if idempotencyKey != "" {
+		params.SetIdempotencyKey(idempotencyKey)
+	}
+
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authorization intent: %w", err)
+	}
+
+	return pi, nil
+}
+
+func (s *stripeService) CapturePaymentIntent(ctx context.Context, paymentIntentID string) (*stripe.PaymentIntent, error) {
+	pi, err := paymentintent.Capture(paymentIntentID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture payment intent: %w", err)
+	}
+
+	return pi, nil
+}
+
+func (s *stripeService) CreateRefund(ctx context.Context, refundParams *RefundParams) (*stripe.Refund, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(refundParams.PaymentIntentID),
+		Metadata:      refundParams.Metadata,
+	}
+	if refundParams.AmountCents > 0 {
+		params.Amount = stripe.Int64(refundParams.AmountCents)
+	}
+	if refundParams.Reason != "" {
+		params.Reason = stripe.String(refundParams.Reason)
+	}
+	if refundParams.IdempotencyKey != "" {
+		params.SetIdempotencyKey(refundParams.IdempotencyKey)
+	}
+
+	r, err := refund.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	return r, nil
+}
+
+// Connect
+func (s *stripeService) CreateConnectAccount(ctx context.Context, userID int64, email string) (*stripe.Account, error) {
+	params := &stripe.AccountParams{
+		Type:    stripe.String(string(stripe.AccountTypeExpress)),
+		Country: stripe.String("US"),
+		Email:   stripe.String(email),
+		Capabilities: &stripe.AccountCapabilitiesParams{
+			Transfers: &stripe.AccountCapabilitiesTransfersParams{
+				Requested: stripe.Bool(true),
+			},
+		},
+		Metadata: map[string]string{
+			"user_id": fmt.Sprintf("%d", userID),
+		},
+	}
+
+	acct, err := account.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connect account: %w", err)
+	}
+
+	return acct, nil
+}
+
+func (s *stripeService) GetConnectAccount(ctx context.Context, accountID string) (*stripe.Account, error) {
+	acct, err := account.GetByID(accountID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connect account: %w", err)
+	}
+
+	return acct, nil
+}
+
+func (s *stripeService) CreateAccountOnboardingLink(ctx context.Context, accountID, refreshURL, returnURL string) (*stripe.AccountLink, error) {
+	params := &stripe.AccountLinkParams{
+		Account:    stripe.String(accountID),
+		RefreshURL: stripe.String(refreshURL),
+		ReturnURL:  stripe.String(returnURL),
+		Type:       stripe.String("account_onboarding"),
+	}
+
+	link, err := accountlink.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account link: %w", err)
+	}
+
+	return link, nil
+}
+
+func (s *stripeService) CreateTransfer(ctx context.Context, amount int64, currency, destinationAccountID, transferGroup, description, idempotencyKey string) (*stripe.Transfer, error) {
+	params := &stripe.TransferParams{
+		Amount:        stripe.Int64(amount),
+		Currency:      stripe.String(currency),
+		Destination:   stripe.String(destinationAccountID),
+		TransferGroup: stripe.String(transferGroup),
+		Description:   stripe.String(description),
+	}
+	// Повтор с тем же ключом вернет уже созданный перевод
+	if idempotencyKey != "" {
+		params.SetIdempotencyKey(idempotencyKey)
+	}
+
+	t, err := transfer.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	return t, nil
+}
+
+// Subscriptions
+func (s *stripeService) CreateSubscription(ctx context.Context, subscriptionParams *SubscriptionParams) (*stripe.Subscription, error) {
+	params := &stripe.SubscriptionParams{
+		Customer: stripe.String(subscriptionParams.CustomerID),
+		Items: []*stripe.SubscriptionItemsParams{
+			{Price: stripe.String(subscriptionParams.PriceID)},
+		},
+		DefaultPaymentMethod: stripe.String(subscriptionParams.PaymentMethod.ID),
+		// Если первый счет требует 3DS, подписка создается incomplete, а клиент подтверждает оплату
+		PaymentBehavior: stripe.String("allow_incomplete"),
+		Metadata:        subscriptionParams.Metadata,
+	}
+	params.AddExpand("latest_invoice.confirmation_secret")
+	if subscriptionParams.IdempotencyKey != "" {
+		params.SetIdempotencyKey(subscriptionParams.IdempotencyKey)
+	}
+
+	sub, err := subscription.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (s *stripeService) GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	params := &stripe.SubscriptionParams{}
+	params.AddExpand("latest_invoice")
+
+	sub, err := subscription.Get(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// ChangeSubscriptionPrice переводит подписку на другую цену с пропорциональным пересчетом.
+// invoiceNow - доплата за остаток периода списывается сразу, и смена применяется только
+// после успешной оплаты; иначе перерасчет (кредит) попадает в следующий счет
+func (s *stripeService) ChangeSubscriptionPrice(ctx context.Context, subscriptionID, priceID string, invoiceNow bool) (*stripe.Subscription, error) {
+	current, err := subscription.Get(subscriptionID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if current.Items == nil || len(current.Items.Data) == 0 {
+		return nil, fmt.Errorf("subscription %s has no items", subscriptionID)
+	}
+
+	params := &stripe.SubscriptionParams{
+		Items: []*stripe.SubscriptionItemsParams{
+			{ID: stripe.String(current.Items.Data[0].ID), Price: stripe.String(priceID)},
+		},
+		ProrationBehavior: stripe.String("create_prorations"),
+	}
+	if invoiceNow {
+		params.ProrationBehavior = stripe.String("always_invoice")
+		params.PaymentBehavior = stripe.String("pending_if_incomplete")
+	}
+
+	sub, err := subscription.Update(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to change subscription price: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (s *stripeService) SetSubscriptionCancelAtPeriodEnd(ctx context.Context, subscriptionID string, cancel bool) (*stripe.Subscription, error) {
+	params := &stripe.SubscriptionParams{
+		CancelAtPeriodEnd: stripe.Bool(cancel),
+	}
+
+	sub, err := subscription.Update(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (s *stripeService) CancelSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	sub, err := subscription.Cancel(subscriptionID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
 // Webhook
 func (s *stripeService) ConstructEvent(payload []byte, header string) (stripe.Event, error) {
 	// ✅ ИСПРАВЛЕНИЕ: Используем webhook.ConstructEvent для v82
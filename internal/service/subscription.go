@@ -0,0 +1,506 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"moveshare/internal/config"
+	"moveshare/internal/models"
+	"moveshare/internal/repository/payment"
+	"moveshare/internal/repository/subscription"
+	"time"
+
+	"github.com/stripe/stripe-go/v82"
+)
+
+type SubscriptionService interface {
+	GetPlans(ctx context.Context) []models.Plan
+	GetSubscription(ctx context.Context, userID int64) (*models.SubscriptionResponse, error)
+	// GetEntitlements - возможности плана, действующего для пользователя сейчас (без подписки - free)
+	GetEntitlements(ctx context.Context, userID int64) (*models.Entitlements, error)
+
+	Subscribe(ctx context.Context, userID int64, req *models.SubscribeRequest) (*models.SubscribeResponse, error)
+	// ChangePlan: повышение оплачивается сразу за остаток периода, понижение - кредитом в следующем счете
+	ChangePlan(ctx context.Context, userID int64, req *models.ChangePlanRequest) (*models.Subscription, error)
+	CancelSubscription(ctx context.Context, userID int64) (*models.Subscription, error)
+	ResumeSubscription(ctx context.Context, userID int64) (*models.Subscription, error)
+
+	// HandleStripeEvent обрабатывает события подписок и их счетов. false - событие не относится к подпискам
+	HandleStripeEvent(ctx context.Context, event stripe.Event) (bool, error)
+}
+
+var (
+	ErrPlanNotFound              = errors.New("subscription plan not found")
+	ErrPlanUnavailable           = errors.New("subscription plan is not available")
+	ErrPlanUnchanged             = errors.New("already subscribed to this plan")
+	ErrAlreadySubscribed         = errors.New("already subscribed to a plan")
+	ErrNoSubscription            = errors.New("no active subscription")
+	ErrSubscriptionPaymentFailed = errors.New("subscription payment failed")
+
+	ErrPlanRequired  = errors.New("feature is not available on the current plan")
+	ErrTeamSeatLimit = errors.New("team seat limit of the current plan reached")
+)
+
+type subscriptionService struct {
+	repo         subscription.SubscriptionRepository
+	paymentRepo  payment.PaymentRepository
+	gateway      PaymentGateway
+	userService  UserService
+	emailService EmailService
+	config       *config.StripeConfig
+}
+
+func NewSubscriptionService(
+	repo subscription.SubscriptionRepository,
+	paymentRepo payment.PaymentRepository,
+	gateway PaymentGateway,
+	userService UserService,
+	emailService EmailService,
+	cfg *config.StripeConfig,
+) SubscriptionService {
+	return &subscriptionService{
+		repo:         repo,
+		paymentRepo:  paymentRepo,
+		gateway:      gateway,
+		userService:  userService,
+		emailService: emailService,
+		config:       cfg,
+	}
+}
+
+// GetPlans возвращает бесплатный план и платные планы, для которых настроена цена в Stripe
+func (s *subscriptionService) GetPlans(ctx context.Context) []models.Plan {
+	plans := []models.Plan{}
+	for _, plan := range models.SubscriptionPlans() {
+		if plan.Code == models.PlanFree || s.priceID(plan.Code) != "" {
+			plans = append(plans, plan)
+		}
+	}
+	return plans
+}
+
+func (s *subscriptionService) GetSubscription(ctx context.Context, userID int64) (*models.SubscriptionResponse, error) {
+	sub, err := s.repo.GetCurrentSubscription(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return &models.SubscriptionResponse{
+		Subscription: sub,
+		Entitlements: entitlementsFor(sub, time.Now()),
+	}, nil
+}
+
+func (s *subscriptionService) GetEntitlements(ctx context.Context, userID int64) (*models.Entitlements, error) {
+	sub, err := s.repo.GetCurrentSubscription(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return entitlementsFor(sub, time.Now()), nil
+}
+
+func (s *subscriptionService) Subscribe(ctx context.Context, userID int64, req *models.SubscribeRequest) (*models.SubscribeResponse, error) {
+	plan := models.GetPlan(req.Plan)
+	if plan == nil {
+		return nil, ErrPlanNotFound
+	}
+	if plan.Code == models.PlanFree {
+		return nil, fmt.Errorf("%w: the free plan does not need a subscription", ErrPlanUnavailable)
+	}
+	priceID := s.priceID(plan.Code)
+	if priceID == "" {
+		return nil, ErrPlanUnavailable
+	}
+
+	existing, err := s.repo.GetCurrentSubscription(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if existing != nil {
+		if existing.Status != models.SubscriptionStatusIncomplete {
+			return nil, ErrAlreadySubscribed
+		}
+		// Первая оплата прежней подписки так и не прошла - оформляем заново
+		if err := s.abandonIncomplete(ctx, existing); err != nil {
+			return nil, err
+		}
+	}
+
+	customerID, err := ensureStripeCustomer(ctx, s.paymentRepo, s.gateway, s.userService, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure Stripe customer: %w", err)
+	}
+
+	var paymentMethod *models.UserPaymentMethod
+	if req.PaymentMethodID != nil {
+		paymentMethod, err = s.paymentRepo.GetPaymentMethodByID(ctx, userID, *req.PaymentMethodID)
+		if err != nil {
+			return nil, fmt.Errorf("payment method not found: %w", err)
+		}
+	} else {
+		paymentMethod, err = s.paymentRepo.GetDefaultPaymentMethod(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("no default payment method found: %w", err)
+		}
+	}
+	if !paymentMethod.IsUsable() {
+		return nil, ErrPaymentMethodNotVerified
+	}
+
+	var idempotencyKey string
+	if req.IdempotencyKey != "" {
+		idempotencyKey = fmt.Sprintf("subscription:%d:%s", userID, req.IdempotencyKey)
+	}
+
+	stripeSub, err := s.gateway.CreateSubscription(ctx, &SubscriptionParams{
+		CustomerID:    customerID,
+		PriceID:       priceID,
+		PaymentMethod: newPaymentMethodRef(paymentMethod),
+		Metadata: map[string]string{
+			"user_id": fmt.Sprintf("%d", userID),
+			"plan":    plan.Code,
+		},
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Первый счет отклонен и подтверждать нечего - подписку не оставляем
+	clientSecret := invoiceClientSecret(stripeSub.LatestInvoice)
+	if stripeSub.Status == stripe.SubscriptionStatusIncomplete && clientSecret == "" {
+		if _, err := s.gateway.CancelSubscription(ctx, stripeSub.ID); err != nil {
+			fmt.Printf("Failed to cancel unpaid subscription %s: %v\n", stripeSub.ID, err)
+		}
+		return nil, ErrSubscriptionPaymentFailed
+	}
+
+	// Повтор запроса с тем же ключом: подписка уже сохранена
+	sub, err := s.repo.GetSubscriptionByStripeID(ctx, stripeSub.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if sub == nil {
+		sub = &models.Subscription{
+			UserID:               userID,
+			Plan:                 plan.Code,
+			StripeSubscriptionID: stripeSub.ID,
+			StripeCustomerID:     customerID,
+		}
+		s.applyStripeSubscription(sub, stripeSub)
+		if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+			return nil, fmt.Errorf("failed to save subscription: %w", err)
+		}
+	}
+
+	response := &models.SubscribeResponse{Subscription: sub}
+	if sub.Status == models.SubscriptionStatusIncomplete {
+		response.ClientSecret = clientSecret
+		response.RequiresConfirmation = true
+	}
+
+	return response, nil
+}
+
+func (s *subscriptionService) ChangePlan(ctx context.Context, userID int64, req *models.ChangePlanRequest) (*models.Subscription, error) {
+	target := models.GetPlan(req.Plan)
+	if target == nil {
+		return nil, ErrPlanNotFound
+	}
+
+	sub, err := s.activeSubscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Переход на бесплатный план - отмена подписки в конце оплаченного периода
+	if target.Code == models.PlanFree {
+		return s.CancelSubscription(ctx, userID)
+	}
+	if target.Code == sub.Plan {
+		if sub.CancelAtPeriodEnd {
+			return s.ResumeSubscription(ctx, userID)
+		}
+		return nil, ErrPlanUnchanged
+	}
+
+	priceID := s.priceID(target.Code)
+	if priceID == "" {
+		return nil, ErrPlanUnavailable
+	}
+
+	current := models.GetPlan(sub.Plan)
+	upgrade := current == nil || target.Rank > current.Rank
+
+	stripeSub, err := s.gateway.ChangeSubscriptionPrice(ctx, sub.StripeSubscriptionID, priceID, upgrade)
+	if err != nil {
+		return nil, err
+	}
+	// Доплату за повышение списать не удалось - Stripe оставил прежний план
+	if stripeSub.PendingUpdate != nil {
+		return nil, ErrSubscriptionPaymentFailed
+	}
+
+	return s.saveStripeSubscription(ctx, sub, stripeSub)
+}
+
+// CancelSubscription отменяет подписку в конце оплаченного периода; до этого план продолжает действовать
+func (s *subscriptionService) CancelSubscription(ctx context.Context, userID int64) (*models.Subscription, error) {
+	sub, err := s.repo.GetCurrentSubscription(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if sub == nil {
+		return nil, ErrNoSubscription
+	}
+
+	// Неоплаченную подписку отменять до конца периода незачем
+	if sub.Status == models.SubscriptionStatusIncomplete {
+		if err := s.abandonIncomplete(ctx, sub); err != nil {
+			return nil, err
+		}
+		return sub, nil
+	}
+	if sub.CancelAtPeriodEnd {
+		return sub, nil
+	}
+
+	stripeSub, err := s.gateway.SetSubscriptionCancelAtPeriodEnd(ctx, sub.StripeSubscriptionID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveStripeSubscription(ctx, sub, stripeSub)
+}
+
+func (s *subscriptionService) ResumeSubscription(ctx context.Context, userID int64) (*models.Subscription, error) {
+	sub, err := s.activeSubscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.CancelAtPeriodEnd {
+		return sub, nil
+	}
+
+	stripeSub, err := s.gateway.SetSubscriptionCancelAtPeriodEnd(ctx, sub.StripeSubscriptionID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveStripeSubscription(ctx, sub, stripeSub)
+}
+
+// Webhook
+func (s *subscriptionService) HandleStripeEvent(ctx context.Context, event stripe.Event) (bool, error) {
+	switch event.Type {
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		var stripeSub stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &stripeSub); err != nil {
+			return true, fmt.Errorf("failed to parse subscription from webhook: %w", err)
+		}
+		_, err := s.syncSubscription(ctx, stripeSub.ID)
+		return true, err
+	case "invoice.paid", "invoice.payment_failed":
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			return true, fmt.Errorf("failed to parse invoice from webhook: %w", err)
+		}
+		subscriptionID := invoiceSubscriptionID(&invoice)
+		if subscriptionID == "" {
+			// Счет не относится к подписке
+			return false, nil
+		}
+
+		sub, err := s.syncSubscription(ctx, subscriptionID)
+		if err != nil {
+			return true, err
+		}
+		if sub != nil && event.Type == "invoice.payment_failed" {
+			s.notifyPaymentFailed(ctx, sub, &invoice)
+		}
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// syncSubscription загружает актуальное состояние подписки из Stripe и сохраняет его.
+// Подписки, которых нет в БД (еще не сохранены при оформлении), пропускаются
+func (s *subscriptionService) syncSubscription(ctx context.Context, stripeSubscriptionID string) (*models.Subscription, error) {
+	sub, err := s.repo.GetSubscriptionByStripeID(ctx, stripeSubscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if sub == nil {
+		return nil, nil
+	}
+
+	stripeSub, err := s.gateway.GetSubscription(ctx, stripeSubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveStripeSubscription(ctx, sub, stripeSub)
+}
+
+func (s *subscriptionService) saveStripeSubscription(ctx context.Context, sub *models.Subscription, stripeSub *stripe.Subscription) (*models.Subscription, error) {
+	s.applyStripeSubscription(sub, stripeSub)
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// applyStripeSubscription переносит в подписку план, период и статус из Stripe.
+// Отсчет льготного периода начинается с первого неудачного списания и сбрасывается после оплаты
+func (s *subscriptionService) applyStripeSubscription(sub *models.Subscription, stripeSub *stripe.Subscription) {
+	if stripeSub.Items != nil && len(stripeSub.Items.Data) > 0 {
+		item := stripeSub.Items.Data[0]
+		if item.Price != nil {
+			sub.StripePriceID = item.Price.ID
+			if plan := s.planForPrice(item.Price.ID); plan != "" {
+				sub.Plan = plan
+			}
+		}
+		periodStart, periodEnd := time.Unix(item.CurrentPeriodStart, 0), time.Unix(item.CurrentPeriodEnd, 0)
+		sub.CurrentPeriodStart, sub.CurrentPeriodEnd = &periodStart, &periodEnd
+	}
+
+	sub.Status = string(stripeSub.Status)
+	sub.CancelAtPeriodEnd = stripeSub.CancelAtPeriodEnd
+
+	switch stripeSub.Status {
+	case stripe.SubscriptionStatusPastDue, stripe.SubscriptionStatusUnpaid:
+		if sub.PastDueSince == nil {
+			now := time.Now()
+			sub.PastDueSince = &now
+		}
+	case stripe.SubscriptionStatusActive, stripe.SubscriptionStatusTrialing:
+		sub.PastDueSince = nil
+	case stripe.SubscriptionStatusCanceled:
+		canceledAt := time.Now()
+		if stripeSub.EndedAt > 0 {
+			canceledAt = time.Unix(stripeSub.EndedAt, 0)
+		}
+		sub.CanceledAt = &canceledAt
+	}
+}
+
+func (s *subscriptionService) activeSubscription(ctx context.Context, userID int64) (*models.Subscription, error) {
+	sub, err := s.repo.GetCurrentSubscription(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if sub == nil || sub.Status == models.SubscriptionStatusIncomplete {
+		return nil, ErrNoSubscription
+	}
+	return sub, nil
+}
+
+// abandonIncomplete отменяет подписку, первая оплата которой не была подтверждена
+func (s *subscriptionService) abandonIncomplete(ctx context.Context, sub *models.Subscription) error {
+	stripeSub, err := s.gateway.CancelSubscription(ctx, sub.StripeSubscriptionID)
+	if err != nil {
+		return err
+	}
+	_, err = s.saveStripeSubscription(ctx, sub, stripeSub)
+	return err
+}
+
+func (s *subscriptionService) notifyPaymentFailed(ctx context.Context, sub *models.Subscription, invoice *stripe.Invoice) {
+	user, err := s.userService.FindUserByID(ctx, sub.UserID)
+	if err != nil {
+		fmt.Printf("Failed to find user %d for subscription payment notice: %v\n", sub.UserID, err)
+		return
+	}
+
+	planName := sub.Plan
+	if plan := models.GetPlan(sub.Plan); plan != nil {
+		planName = plan.Name
+	}
+
+	retry := "Please update your payment method to keep your plan."
+	if invoice.NextPaymentAttempt > 0 {
+		retry = fmt.Sprintf("We will try again on %s. Please make sure your payment method is up to date.",
+			time.Unix(invoice.NextPaymentAttempt, 0).Format("Jan 2, 2006"))
+	}
+	payLink := ""
+	if invoice.HostedInvoiceURL != "" {
+		payLink = fmt.Sprintf(`<p>You can also <a href="%s">pay the invoice online</a>.</p>`, invoice.HostedInvoiceURL)
+	}
+
+	subject := fmt.Sprintf("Payment for your MoveShare %s plan failed", planName)
+	body := fmt.Sprintf(`<p>Hello,</p>
+<p>We could not charge $%.2f for your MoveShare %s plan (attempt %d).</p>
+<p>%s Your plan features stay available for %d days after the first failed payment.</p>
+%s
+<p>Best regards,<br>The MoveShare Team</p>`,
+		float64(invoice.AmountDue)/100, planName, invoice.AttemptCount,
+		retry, int(models.SubscriptionGracePeriod.Hours()/24), payLink)
+
+	if err := s.emailService.SendEmail(user.Email, subject, body); err != nil {
+		fmt.Printf("Failed to send subscription payment notice to user %d: %v\n", sub.UserID, err)
+	}
+}
+
+func (s *subscriptionService) priceID(plan string) string {
+	switch plan {
+	case models.PlanPro:
+		return s.config.ProPlanPriceID
+	case models.PlanFleet:
+		return s.config.FleetPlanPriceID
+	}
+	return ""
+}
+
+func (s *subscriptionService) planForPrice(priceID string) string {
+	switch {
+	case priceID == "":
+		return ""
+	case priceID == s.config.ProPlanPriceID:
+		return models.PlanPro
+	case priceID == s.config.FleetPlanPriceID:
+		return models.PlanFleet
+	}
+	return ""
+}
+
+// entitlementsFor - возможности по подписке на момент now. Для бесплатного плана
+// расчетным периодом считается календарный месяц
+func entitlementsFor(sub *models.Subscription, now time.Time) *models.Entitlements {
+	code := models.PlanFree
+	if sub != nil {
+		code = sub.EffectivePlan(now)
+	}
+	plan := models.GetPlan(code)
+
+	entitlements := &models.Entitlements{
+		Plan:             plan.Code,
+		PlanEntitlements: plan.Entitlements,
+	}
+	if code != models.PlanFree && sub.CurrentPeriodStart != nil && sub.CurrentPeriodEnd != nil {
+		entitlements.PeriodStart, entitlements.PeriodEnd = *sub.CurrentPeriodStart, *sub.CurrentPeriodEnd
+	} else {
+		utcNow := now.UTC()
+		entitlements.PeriodStart = time.Date(utcNow.Year(), utcNow.Month(), 1, 0, 0, 0, 0, time.UTC)
+		entitlements.PeriodEnd = entitlements.PeriodStart.AddDate(0, 1, 0)
+	}
+
+	return entitlements
+}
+
+func invoiceSubscriptionID(invoice *stripe.Invoice) string {
+	if invoice.Parent == nil || invoice.Parent.SubscriptionDetails == nil || invoice.Parent.SubscriptionDetails.Subscription == nil {
+		return ""
+	}
+	return invoice.Parent.SubscriptionDetails.Subscription.ID
+}
+
+func invoiceClientSecret(invoice *stripe.Invoice) string {
+	if invoice == nil || invoice.ConfirmationSecret == nil {
+		return ""
+	}
+	return invoice.ConfirmationSecret.ClientSecret
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"moveshare/internal/models"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfPageWidth = 191.9 // Letter (215.9mm) minus 12mm margins
+	pdfLabelW    = 55.0
+	pdfLineH     = 6.0
+)
+
+// GenerateBillOfLadingPDF renders a bill of lading / order for service document
+func GenerateBillOfLadingPDF(data *models.BillOfLadingData) ([]byte, error) {
+	job := data.Job
+
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "BILL OF LADING / ORDER FOR SERVICE", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, pdfLineH, tr(fmt.Sprintf("No. %s    Issued: %s    Job #%d", data.Number, data.IssuedAt.Format("Jan 2, 2006 15:04 MST"), job.ID)), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	section := func(title string) {
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(0, 7, tr(title), "", 1, "L", true, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+	}
+	row := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(pdfLabelW, pdfLineH, tr(label), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, pdfLineH, tr(value), "", "L", false)
+	}
+	company := func(title string, c *models.Company) {
+		section(title)
+		if c == nil {
+			row("Company", "Not provided")
+			return
+		}
+		row("Company", c.CompanyName)
+		row("Address", joinNonEmpty(", ", c.Address, c.City, strings.TrimSpace(c.State+" "+c.ZipCode)))
+		row("Contact", c.ContactPerson)
+		row("Phone", c.PhoneNumber)
+		row("Email", c.EmailAddress)
+		row("USDOT Number", c.DotNumber)
+		row("MC License Number", c.MCLicenseNumber)
+	}
+
+	company("SHIPPER", data.Shipper)
+	company("CARRIER", data.Carrier)
+
+	section("PICKUP")
+	row("Address", joinNonEmpty(", ", job.PickupAddress, job.PickupCity, job.PickupState))
+	row("Floor / Building", joinNonEmpty(" / ", formatFloor(job.PickupFloor), job.PickupBuildingType))
+	row("Walk Distance", job.PickupWalkDistance)
+	row("Date / Window", fmt.Sprintf("%s, %s - %s", job.PickupDate.Format("Jan 2, 2006"), job.PickupTimeFrom.Format("15:04"), job.PickupTimeTo.Format("15:04")))
+
+	section("DELIVERY")
+	row("Address", joinNonEmpty(", ", job.DeliveryAddress, job.DeliveryCity, job.DeliveryState))
+	row("Floor / Building", joinNonEmpty(" / ", formatFloor(job.DeliveryFloor), job.DeliveryBuildingType))
+	row("Walk Distance", job.DeliveryWalkDistance)
+	row("Date / Window", fmt.Sprintf("%s, %s - %s", job.DeliveryDate.Format("Jan 2, 2006"), job.DeliveryTimeFrom.Format("15:04"), job.DeliveryTimeTo.Format("15:04")))
+
+	section("SERVICES")
+	row("Move Type", job.JobType)
+	row("Bedrooms", job.NumberOfBedrooms)
+	var services []string
+	if job.PackingBoxes {
+		services = append(services, "Packing boxes")
+	}
+	if job.BulkyItems {
+		services = append(services, "Bulky items")
+	}
+	if job.InventoryList {
+		services = append(services, "Inventory list")
+	}
+	if job.Hoisting {
+		services = append(services, "Hoisting")
+	}
+	row("Additional Services", strings.Join(services, ", "))
+	if job.AdditionalServicesDescription != nil {
+		row("Service Notes", *job.AdditionalServicesDescription)
+	}
+	row("Crew Assistants", job.EstimatedCrewAssistants)
+	row("Truck Size", job.TruckSize)
+
+	section("INVENTORY / LOAD")
+	row("Estimated Weight", fmt.Sprintf("%.0f lbs", job.WeightLbs))
+	row("Estimated Volume", fmt.Sprintf("%.0f cu ft", job.VolumeCuFt))
+	row("Distance", fmt.Sprintf("%.1f miles", job.DistanceMiles))
+
+	if data.Crew != nil && (data.Crew.Truck != nil || len(data.Crew.Members) > 0) {
+		section("ASSIGNED CREW")
+		if t := data.Crew.Truck; t != nil {
+			row("Truck", fmt.Sprintf("%s %s %d (%s), plate %s", t.Make, t.Model, t.Year, t.TruckName, t.LicensePlate))
+		}
+		for _, m := range data.Crew.Members {
+			row(strings.ToUpper(m.Role[:1])+m.Role[1:], m.Username)
+		}
+	}
+
+	section("CHARGES")
+	row("Agreed Price", fmt.Sprintf("$%.2f USD", job.PaymentAmount))
+
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.MultiCell(0, 4, tr("The carrier acknowledges receipt of the goods described above in apparent good order, except as noted, "+
+		"and agrees to transport and deliver them to the delivery address under the terms agreed on the MoveShare platform."), "", "L", false)
+
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "", 10)
+	half := (pdfPageWidth - 10) / 2
+	pdf.CellFormat(half, pdfLineH, "______________________________", "", 0, "L", false, 0, "")
+	pdf.CellFormat(10, pdfLineH, "", "", 0, "L", false, 0, "")
+	pdf.CellFormat(half, pdfLineH, "______________________________", "", 1, "L", false, 0, "")
+	pdf.CellFormat(half, pdfLineH, "Shipper signature / date", "", 0, "L", false, 0, "")
+	pdf.CellFormat(10, pdfLineH, "", "", 0, "L", false, 0, "")
+	pdf.CellFormat(half, pdfLineH, "Carrier signature / date", "", 1, "L", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render bill of lading: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func formatFloor(floor *int) string {
+	if floor == nil {
+		return ""
+	}
+	return fmt.Sprintf("Floor %d", *floor)
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if strings.TrimSpace(p) != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
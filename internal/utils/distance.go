@@ -146,3 +146,19 @@ func GetDistanceFromAddresses(pickupAddress, deliveryAddress string, cfg *config
 		DurationValue: element.Duration.Value,
 	}, nil
 }
+
+const earthRadiusMeters = 6371000.0
+
+// HaversineMeters returns the great-circle distance between two points in meters
+func HaversineMeters(pointA, pointB Point) float64 {
+	lat1 := pointA.Lat * math.Pi / 180
+	lat2 := pointB.Lat * math.Pi / 180
+	dLat := (pointB.Lat - pointA.Lat) * math.Pi / 180
+	dLng := (pointB.Lng - pointA.Lng) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return earthRadiusMeters * c
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"moveshare/internal/models"
+	"time"
+)
+
+// GenerateEarningsStatementCSV exports a monthly earnings statement, one row per job plus a total row
+func GenerateEarningsStatementCSV(s *models.EarningsStatement) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	headers := []string{
+		"date", "job_id", "pickup", "delivery",
+		"gross", "commission", "tips", "net", "currency",
+	}
+	if err := writer.Write(headers); err != nil {
+		return nil, err
+	}
+
+	for _, line := range s.Lines {
+		jobID := ""
+		if line.JobID != nil {
+			jobID = fmt.Sprintf("%d", *line.JobID)
+		}
+		record := []string{
+			line.EarnedAt.Format("2006-01-02"),
+			jobID,
+			joinNonEmpty(", ", line.PickupCity, line.PickupState),
+			joinNonEmpty(", ", line.DeliveryCity, line.DeliveryState),
+			csvAmount(line.GrossCents),
+			csvAmount(line.CommissionCents),
+			csvAmount(line.TipsCents),
+			csvAmount(line.NetCents),
+			line.Currency,
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	total := []string{
+		"total", fmt.Sprintf("%d jobs", s.Totals.Jobs), "", "",
+		csvAmount(s.Totals.GrossCents),
+		csvAmount(s.Totals.CommissionCents),
+		csvAmount(s.Totals.TipsCents),
+		csvAmount(s.Totals.NetCents),
+		s.Currency,
+	}
+	if err := writer.Write(total); err != nil {
+		return nil, err
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// GenerateAnnualEarningsSummaryCSV exports an annual earnings summary: payer and payee details,
+// then monthly totals and the year total
+func GenerateAnnualEarningsSummaryCSV(s *models.AnnualEarningsSummary) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	payee := s.Payee
+	if payee == nil {
+		payee = &models.Company{}
+	}
+	details := [][]string{
+		{"tax_year", fmt.Sprintf("%d", s.Year)},
+		{"payer", "MoveShare"},
+		{"payee_company", payee.CompanyName},
+		{"payee_tax_id", MaskTaxID(payee.TaxID)},
+		{"payee_address", joinNonEmpty(", ", payee.Address, payee.City, payee.State, payee.ZipCode)},
+		{"payee_contact", payee.ContactPerson},
+		{"payee_phone", payee.PhoneNumber},
+		{"payee_email", payee.EmailAddress},
+		{"payee_dot_number", payee.DotNumber},
+		{"payee_mc_license_number", payee.MCLicenseNumber},
+		{},
+	}
+	if err := writer.WriteAll(details); err != nil {
+		return nil, err
+	}
+
+	headers := []string{"month", "jobs", "gross", "commission", "tips", "net", "currency"}
+	if err := writer.Write(headers); err != nil {
+		return nil, err
+	}
+
+	row := func(label string, t models.EarningsTotals) error {
+		return writer.Write([]string{
+			label,
+			fmt.Sprintf("%d", t.Jobs),
+			csvAmount(t.GrossCents),
+			csvAmount(t.CommissionCents),
+			csvAmount(t.TipsCents),
+			csvAmount(t.NetCents),
+			s.Currency,
+		})
+	}
+	for _, m := range s.Months {
+		if err := row(time.Month(m.Month).String(), m.EarningsTotals); err != nil {
+			return nil, err
+		}
+	}
+	if err := row("total", s.Totals); err != nil {
+		return nil, err
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func csvAmount(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
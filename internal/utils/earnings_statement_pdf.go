@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"moveshare/internal/models"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Ширины колонок таблицы выписки; маршрут занимает оставшееся место
+const (
+	statementDateW   = 22.0
+	statementJobW    = 16.0
+	statementAmountW = 24.0
+	statementRouteW  = pdfPageWidth - statementDateW - statementJobW - 4*statementAmountW
+)
+
+// GenerateEarningsStatementPDF renders a monthly earnings statement for a mover
+func GenerateEarningsStatementPDF(s *models.EarningsStatement) ([]byte, error) {
+	pdf, tr := newStatementPDF(
+		"EARNINGS STATEMENT",
+		fmt.Sprintf("%s - %s    Generated: %s", s.PeriodStart.Format("Jan 2, 2006"), s.PeriodEnd.Format("Jan 2, 2006"), s.GeneratedAt.Format("Jan 2, 2006")),
+	)
+	section, row := statementHelpers(pdf, tr)
+
+	section("PAYEE")
+	statementPayee(row, s.Payee)
+
+	section("COMPLETED JOBS")
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(statementDateW, pdfLineH, "Date", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(statementJobW, pdfLineH, "Job", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(statementRouteW, pdfLineH, "Route", "B", 0, "L", false, 0, "")
+	for _, title := range []string{"Gross", "Commission", "Tips", "Net"} {
+		pdf.CellFormat(statementAmountW, pdfLineH, title, "B", 0, "R", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	if len(s.Lines) == 0 {
+		pdf.CellFormat(0, pdfLineH, "No earnings in this period", "", 1, "L", false, 0, "")
+	}
+	for _, line := range s.Lines {
+		job := "-"
+		if line.JobID != nil {
+			job = fmt.Sprintf("#%d", *line.JobID)
+		}
+		pdf.CellFormat(statementDateW, pdfLineH, line.EarnedAt.Format("Jan 2, 2006"), "", 0, "L", false, 0, "")
+		pdf.CellFormat(statementJobW, pdfLineH, job, "", 0, "L", false, 0, "")
+		pdf.CellFormat(statementRouteW, pdfLineH, tr(fitText(pdf, line.Route(), statementRouteW)), "", 0, "L", false, 0, "")
+		statementAmounts(pdf, line.GrossCents, -line.CommissionCents, line.TipsCents, line.NetCents)
+	}
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(statementDateW+statementJobW+statementRouteW, pdfLineH, fmt.Sprintf("Total (%d jobs)", s.Totals.Jobs), "T", 0, "L", false, 0, "")
+	statementAmounts(pdf, s.Totals.GrossCents, -s.Totals.CommissionCents, s.Totals.TipsCents, s.Totals.NetCents)
+
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.MultiCell(0, 4, tr(fmt.Sprintf("Amounts in %s. Gross is the job payout before the MoveShare commission; "+
+		"tips are paid in full without commission. Net is the amount paid out to your connected account.", strings.ToUpper(s.Currency))), "", "L", false)
+
+	return outputStatementPDF(pdf)
+}
+
+// GenerateAnnualEarningsSummaryPDF renders a 1099-style annual earnings summary for a mover
+func GenerateAnnualEarningsSummaryPDF(s *models.AnnualEarningsSummary) ([]byte, error) {
+	pdf, tr := newStatementPDF(
+		fmt.Sprintf("ANNUAL EARNINGS SUMMARY %d", s.Year),
+		fmt.Sprintf("Tax year %d    Generated: %s", s.Year, s.GeneratedAt.Format("Jan 2, 2006")),
+	)
+	section, row := statementHelpers(pdf, tr)
+
+	section("PAYER")
+	row("Company", "MoveShare")
+	row("Email", "admin@themoveshare.com")
+
+	section("PAYEE")
+	statementPayee(row, s.Payee)
+
+	section("SUMMARY")
+	row("Completed jobs", fmt.Sprintf("%d", s.Totals.Jobs))
+	row("Gross job payouts", formatCents(s.Totals.GrossCents, s.Currency))
+	row("Platform commission", formatCents(-s.Totals.CommissionCents, s.Currency))
+	row("Tips", formatCents(s.Totals.TipsCents, s.Currency))
+	row("Total paid to payee", formatCents(s.Totals.NetCents, s.Currency))
+
+	section("BY MONTH")
+	monthW := statementDateW + statementJobW + statementRouteW
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(monthW, pdfLineH, "Month", "B", 0, "L", false, 0, "")
+	for _, title := range []string{"Gross", "Commission", "Tips", "Net"} {
+		pdf.CellFormat(statementAmountW, pdfLineH, title, "B", 0, "R", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 9)
+	for _, m := range s.Months {
+		pdf.CellFormat(monthW, pdfLineH, fmt.Sprintf("%s (%d jobs)", time.Month(m.Month), m.Jobs), "", 0, "L", false, 0, "")
+		statementAmounts(pdf, m.GrossCents, -m.CommissionCents, m.TipsCents, m.NetCents)
+	}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(monthW, pdfLineH, "Total", "T", 0, "L", false, 0, "")
+	statementAmounts(pdf, s.Totals.GrossCents, -s.Totals.CommissionCents, s.Totals.TipsCents, s.Totals.NetCents)
+
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.MultiCell(0, 4, tr("This summary lists payments made to you through MoveShare during the tax year for your records. "+
+		"It is not an IRS form; keep it with your tax documents and consult a tax professional about how to report this income."), "", "L", false)
+
+	return outputStatementPDF(pdf)
+}
+
+func newStatementPDF(title, subtitle string) (*fpdf.Fpdf, func(string) string) {
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr(title), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, pdfLineH, tr(subtitle), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	return pdf, tr
+}
+
+func statementHelpers(pdf *fpdf.Fpdf, tr func(string) string) (func(string), func(string, string)) {
+	section := func(title string) {
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(0, 7, tr(title), "", 1, "L", true, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+	}
+	row := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(pdfLabelW, pdfLineH, tr(label), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, pdfLineH, tr(value), "", "L", false)
+	}
+	return section, row
+}
+
+func statementPayee(row func(string, string), c *models.Company) {
+	if c == nil {
+		row("Company", "Not provided")
+		return
+	}
+	row("Company", c.CompanyName)
+	row("Tax ID", MaskTaxID(c.TaxID))
+	row("Address", joinNonEmpty(", ", c.Address, c.City, strings.TrimSpace(c.State+" "+c.ZipCode)))
+	row("Contact", c.ContactPerson)
+	row("Phone", c.PhoneNumber)
+	row("Email", c.EmailAddress)
+	row("USDOT Number", c.DotNumber)
+	row("MC License Number", c.MCLicenseNumber)
+}
+
+func statementAmounts(pdf *fpdf.Fpdf, amounts ...int64) {
+	for i, cents := range amounts {
+		ln := 0
+		if i == len(amounts)-1 {
+			ln = 1
+		}
+		pdf.CellFormat(statementAmountW, pdfLineH, formatDollars(cents), "", ln, "R", false, 0, "")
+	}
+}
+
+// fitText обрезает текст, чтобы он поместился в колонку
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width-2 {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width-2 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func outputStatementPDF(pdf *fpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render earnings statement: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// MaskTaxID оставляет видимыми только последние 4 цифры налогового номера
+func MaskTaxID(taxID string) string {
+	var digits []rune
+	for _, r := range taxID {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) <= 4 {
+		return strings.Repeat("*", len(digits))
+	}
+	return strings.Repeat("*", len(digits)-4) + string(digits[len(digits)-4:])
+}
+
+// formatDollars форматирует сумму без кода валюты для колонок таблицы
+func formatDollars(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"errors"
+	"moveshare/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EntitlementsContextKey - ключ, под которым middleware.LoadEntitlements сохраняет возможности плана
+const EntitlementsContextKey = "entitlements"
+
+var errMissingEntitlements = errors.New("entitlements not found in context")
+
+func GetEntitlementsFromContext(c *gin.Context) (*models.Entitlements, error) {
+	raw, exists := c.Get(EntitlementsContextKey)
+	if !exists {
+		return nil, errMissingEntitlements
+	}
+
+	entitlements, ok := raw.(*models.Entitlements)
+	if !ok {
+		return nil, errMissingEntitlements
+	}
+	return entitlements, nil
+}
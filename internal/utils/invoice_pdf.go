@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"moveshare/internal/models"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// GenerateInvoicePDF renders an invoice / receipt for a successful payment
+func GenerateInvoicePDF(data *models.InvoiceData) ([]byte, error) {
+	inv := data.Invoice
+	payment := data.Payment
+
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "INVOICE", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, pdfLineH, tr(fmt.Sprintf("No. %s    Issued: %s", inv.InvoiceNumber, inv.IssuedAt.Format("Jan 2, 2006"))), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	section := func(title string) {
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(0, 7, tr(title), "", 1, "L", true, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+	}
+	row := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(pdfLabelW, pdfLineH, tr(label), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, pdfLineH, tr(value), "", "L", false)
+	}
+
+	section("FROM")
+	row("Company", "MoveShare")
+	row("Email", "admin@themoveshare.com")
+
+	section("BILL TO")
+	if c := data.BillTo; c != nil && c.CompanyName != "" {
+		row("Company", c.CompanyName)
+		row("Address", joinNonEmpty(", ", c.Address, c.City, strings.TrimSpace(c.State+" "+c.ZipCode)))
+		row("Contact", c.ContactPerson)
+		row("Phone", c.PhoneNumber)
+		row("Email", data.Email)
+		row("USDOT Number", c.DotNumber)
+		row("MC License Number", c.MCLicenseNumber)
+	} else {
+		row("Email", data.Email)
+	}
+
+	section("PAYMENT")
+	row("Description", payment.Description)
+	if inv.JobID != nil {
+		row("Job", fmt.Sprintf("#%d", *inv.JobID))
+	}
+	row("Payment Date", payment.CreatedAt.Format("Jan 2, 2006 15:04 MST"))
+	row("Reference", payment.StripePaymentIntentID)
+	row("Status", invoiceStatusLabel(inv.Status))
+
+	section("CHARGES")
+	amountW := 40.0
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(pdfPageWidth-amountW, pdfLineH, "Description", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(amountW, pdfLineH, "Amount", "B", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, item := range inv.LineItems {
+		pdf.CellFormat(pdfPageWidth-amountW, pdfLineH, tr(item.Description), "", 0, "L", false, 0, "")
+		pdf.CellFormat(amountW, pdfLineH, formatCents(item.AmountCents, inv.Currency), "", 1, "R", false, 0, "")
+	}
+
+	total := func(label string, cents int64, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.CellFormat(pdfPageWidth-amountW, pdfLineH, tr(label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(amountW, pdfLineH, formatCents(cents, inv.Currency), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+	total("Subtotal", inv.SubtotalCents, false)
+	total(fmt.Sprintf("Taxes (%.2f%%)", inv.TaxRate), inv.TaxCents, false)
+	total("Total", inv.TotalCents, true)
+	if payment.AmountRefundedCents > 0 {
+		total("Refunded", -payment.AmountRefundedCents, false)
+	}
+
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.MultiCell(0, 4, tr("Job payouts are held by MoveShare and released to the carrier once the job is completed. "+
+		"Taxes apply to platform fees only and are included in the amount charged."), "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render invoice: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func formatCents(cents int64, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d %s", sign, cents/100, cents%100, strings.ToUpper(currency))
+}
+
+func invoiceStatusLabel(status string) string {
+	switch status {
+	case models.InvoiceStatusPaid:
+		return "Paid"
+	case models.InvoiceStatusPartiallyRefunded:
+		return "Partially refunded"
+	case models.InvoiceStatusRefunded:
+		return "Refunded"
+	default:
+		return status
+	}
+}
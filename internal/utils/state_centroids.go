@@ -0,0 +1,103 @@
+package utils
+
+import "strings"
+
+type usState struct {
+	Code     string
+	Name     string
+	Centroid Point
+	TimeZone string // основная IANA зона штата
+}
+
+// usStates - центроиды и основные часовые пояса штатов США. Центроиды используются
+// офлайн-провайдером расстояний, когда координаты города неизвестны
+var usStates = []usState{
+	{"AL", "Alabama", Point{32.806671, -86.791130}, "America/Chicago"},
+	{"AK", "Alaska", Point{61.370716, -152.404419}, "America/Anchorage"},
+	{"AZ", "Arizona", Point{33.729759, -111.431221}, "America/Phoenix"},
+	{"AR", "Arkansas", Point{34.969704, -92.373123}, "America/Chicago"},
+	{"CA", "California", Point{36.116203, -119.681564}, "America/Los_Angeles"},
+	{"CO", "Colorado", Point{39.059811, -105.311104}, "America/Denver"},
+	{"CT", "Connecticut", Point{41.597782, -72.755371}, "America/New_York"},
+	{"DE", "Delaware", Point{39.318523, -75.507141}, "America/New_York"},
+	{"DC", "District of Columbia", Point{38.897438, -77.026817}, "America/New_York"},
+	{"FL", "Florida", Point{27.766279, -81.686783}, "America/New_York"},
+	{"GA", "Georgia", Point{33.040619, -83.643074}, "America/New_York"},
+	{"HI", "Hawaii", Point{21.094318, -157.498337}, "Pacific/Honolulu"},
+	{"ID", "Idaho", Point{44.240459, -114.478828}, "America/Boise"},
+	{"IL", "Illinois", Point{40.349457, -88.986137}, "America/Chicago"},
+	{"IN", "Indiana", Point{39.849426, -86.258278}, "America/New_York"},
+	{"IA", "Iowa", Point{42.011539, -93.210526}, "America/Chicago"},
+	{"KS", "Kansas", Point{38.526600, -96.726486}, "America/Chicago"},
+	{"KY", "Kentucky", Point{37.668140, -84.670067}, "America/New_York"},
+	{"LA", "Louisiana", Point{31.169546, -91.867805}, "America/Chicago"},
+	{"ME", "Maine", Point{44.693947, -69.381927}, "America/New_York"},
+	{"MD", "Maryland", Point{39.063946, -76.802101}, "America/New_York"},
+	{"MA", "Massachusetts", Point{42.230171, -71.530106}, "America/New_York"},
+	{"MI", "Michigan", Point{43.326618, -84.536095}, "America/New_York"},
+	{"MN", "Minnesota", Point{45.694454, -93.900192}, "America/Chicago"},
+	{"MS", "Mississippi", Point{32.741646, -89.678696}, "America/Chicago"},
+	{"MO", "Missouri", Point{38.456085, -92.288368}, "America/Chicago"},
+	{"MT", "Montana", Point{46.921925, -110.454353}, "America/Denver"},
+	{"NE", "Nebraska", Point{41.125370, -98.268082}, "America/Chicago"},
+	{"NV", "Nevada", Point{38.313515, -117.055374}, "America/Los_Angeles"},
+	{"NH", "New Hampshire", Point{43.452492, -71.563896}, "America/New_York"},
+	{"NJ", "New Jersey", Point{40.298904, -74.521011}, "America/New_York"},
+	{"NM", "New Mexico", Point{34.840515, -106.248482}, "America/Denver"},
+	{"NY", "New York", Point{42.165726, -74.948051}, "America/New_York"},
+	{"NC", "North Carolina", Point{35.630066, -79.806419}, "America/New_York"},
+	{"ND", "North Dakota", Point{47.528912, -99.784012}, "America/Chicago"},
+	{"OH", "Ohio", Point{40.388783, -82.764915}, "America/New_York"},
+	{"OK", "Oklahoma", Point{35.565342, -96.928917}, "America/Chicago"},
+	{"OR", "Oregon", Point{44.572021, -122.070938}, "America/Los_Angeles"},
+	{"PA", "Pennsylvania", Point{40.590752, -77.209755}, "America/New_York"},
+	{"RI", "Rhode Island", Point{41.680893, -71.511780}, "America/New_York"},
+	{"SC", "South Carolina", Point{33.856892, -80.945007}, "America/New_York"},
+	{"SD", "South Dakota", Point{44.299782, -99.438828}, "America/Chicago"},
+	{"TN", "Tennessee", Point{35.747845, -86.692345}, "America/Chicago"},
+	{"TX", "Texas", Point{31.054487, -97.563461}, "America/Chicago"},
+	{"UT", "Utah", Point{40.150032, -111.862434}, "America/Denver"},
+	{"VT", "Vermont", Point{44.045876, -72.710686}, "America/New_York"},
+	{"VA", "Virginia", Point{37.769337, -78.169968}, "America/New_York"},
+	{"WA", "Washington", Point{47.400902, -121.490494}, "America/Los_Angeles"},
+	{"WV", "West Virginia", Point{38.491226, -80.954453}, "America/New_York"},
+	{"WI", "Wisconsin", Point{44.268543, -89.616508}, "America/Chicago"},
+	{"WY", "Wyoming", Point{42.755966, -107.302490}, "America/Denver"},
+}
+
+func findUSState(state string) (*usState, bool) {
+	state = strings.TrimSpace(state)
+	for i := range usStates {
+		if strings.EqualFold(usStates[i].Code, state) || strings.EqualFold(usStates[i].Name, state) {
+			return &usStates[i], true
+		}
+	}
+	return nil, false
+}
+
+// StateCentroid принимает код штата ("CA") или полное название ("California")
+func StateCentroid(state string) (Point, bool) {
+	s, ok := findUSState(state)
+	if !ok {
+		return Point{}, false
+	}
+	return s.Centroid, true
+}
+
+// StateFullName возвращает полное название штата по коду или названию
+func StateFullName(state string) string {
+	s, ok := findUSState(state)
+	if !ok {
+		return strings.TrimSpace(state)
+	}
+	return s.Name
+}
+
+// StateCode возвращает двухбуквенный код штата по коду или названию
+func StateCode(state string) string {
+	s, ok := findUSState(state)
+	if !ok {
+		return strings.ToUpper(strings.TrimSpace(state))
+	}
+	return s.Code
+}
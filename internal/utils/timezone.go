@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"strings"
+	"time"
+
+	// Встроенная база часовых поясов, чтобы не зависеть от tzdata в контейнере
+	_ "time/tzdata"
+)
+
+// DefaultTimeZone используется, если зону по штату определить не удалось
+const DefaultTimeZone = "UTC"
+
+// cityTimeZoneOverrides - города в штатах, разделенных между часовыми поясами
+var cityTimeZoneOverrides = map[string]string{
+	"el paso|TX":           "America/Denver",
+	"pensacola|FL":         "America/Chicago",
+	"panama city|FL":       "America/Chicago",
+	"knoxville|TN":         "America/New_York",
+	"chattanooga|TN":       "America/New_York",
+	"bowling green|KY":     "America/Chicago",
+	"paducah|KY":           "America/Chicago",
+	"owensboro|KY":         "America/Chicago",
+	"gary|IN":              "America/Chicago",
+	"evansville|IN":        "America/Chicago",
+	"coeur d'alene|ID":     "America/Los_Angeles",
+	"lewiston|ID":          "America/Los_Angeles",
+	"ontario|OR":           "America/Boise",
+	"rapid city|SD":        "America/Denver",
+	"scottsbluff|NE":       "America/Denver",
+	"menominee|MI":         "America/Chicago",
+	"iron mountain|MI":     "America/Chicago",
+	"dickinson|ND":         "America/Denver",
+	"goodland|KS":          "America/Denver",
+	"window rock|AZ":       "America/Denver",
+	"tuba city|AZ":         "America/Denver",
+	"kayenta|AZ":           "America/Denver",
+	"chinle|AZ":            "America/Denver",
+	"adak|AK":              "America/Adak",
+	"west wendover|NV":     "America/Denver",
+	"fort walton beach|FL": "America/Chicago",
+}
+
+// TimeZoneForLocation определяет IANA зону по городу и штату (код или название)
+func TimeZoneForLocation(city, state string) string {
+	s, ok := findUSState(state)
+	if !ok {
+		return DefaultTimeZone
+	}
+
+	key := strings.ToLower(strings.TrimSpace(city)) + "|" + s.Code
+	if tz, ok := cityTimeZoneOverrides[key]; ok {
+		return tz
+	}
+
+	return s.TimeZone
+}
+
+// LoadTimeZone загружает зону, при ошибке возвращает UTC
+func LoadTimeZone(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ZonedTime собирает момент из даты, времени суток и IANA зоны места
+func ZonedTime(date, clock time.Time, timeZone string) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, LoadTimeZone(timeZone))
+}
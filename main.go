@@ -9,19 +9,33 @@ import (
 	"moveshare/internal/handlers/review"
 	"moveshare/internal/repository"
 	"moveshare/internal/repository/admin"
+	"moveshare/internal/repository/boost"
 	chatRepo "moveshare/internal/repository/chat"
 	"moveshare/internal/repository/company"
+	"moveshare/internal/repository/crew"
+	"moveshare/internal/repository/distance"
+	"moveshare/internal/repository/escrow_capture"
+	"moveshare/internal/repository/idempotency"
+	"moveshare/internal/repository/invoice"
+	"moveshare/internal/repository/job_posting"
+	"moveshare/internal/repository/job_template"
+	"moveshare/internal/repository/ledger"
 	notificationRepo "moveshare/internal/repository/notifications"
 	"moveshare/internal/repository/password_reset"
 	"moveshare/internal/repository/payment"
+	"moveshare/internal/repository/payout"
+	"moveshare/internal/repository/promo"
+	"moveshare/internal/repository/reconciliation"
 	reviewRepo "moveshare/internal/repository/review"
 	sessionRepo "moveshare/internal/repository/session"
+	"moveshare/internal/repository/subscription"
 	"moveshare/internal/repository/truck"
 	"moveshare/internal/repository/user"
 	"moveshare/internal/repository/verification"
 	"moveshare/internal/repository/email_verification"
 	"moveshare/internal/websocket"
 	"strings"
+	"time"
 
 	"moveshare/internal/router"
 	"moveshare/internal/service"
@@ -87,14 +101,41 @@ func main() {
 	verificationRepo := verification.NewVerificationRepository(db)
 	verificationService := service.NewVerificationService(verificationRepo, minioRepo)
 
-	stripeService := service.NewStripeService(&cfg.Stripe)
+	paymentGateway := service.NewPaymentGateway(cfg)
+	log.Printf("Payment gateway: %s", cfg.Payment.Gateway)
+
+	emailService := service.NewEmailService()
+
+	ledgerRepo := ledger.NewLedgerRepository(db)
+	ledgerService := service.NewLedgerService(ledgerRepo)
 
 	paymentRepo := payment.NewPaymentRepository(db)
-	paymentService := service.NewPaymentService(paymentRepo, stripeService, userService)
+
+	subscriptionRepo := subscription.NewSubscriptionRepository(db)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, paymentRepo, paymentGateway, userService, emailService, &cfg.Stripe)
+
+	payoutRepo := payout.NewPayoutRepository(db)
+	payoutService := service.NewPayoutService(payoutRepo, paymentGateway, adminService, userService, ledgerService, subscriptionService)
+	go payoutService.StartPayoutProcessor(context.Background(), time.Hour)
+	statementService := service.NewStatementService(payoutRepo, companyRepo)
+
+	invoiceRepo := invoice.NewInvoiceRepository(db)
+	invoiceService := service.NewInvoiceService(invoiceRepo, paymentRepo, companyRepo, userService, adminService, minioRepo, emailService)
+	paymentService := service.NewPaymentService(paymentRepo, paymentGateway, userService, invoiceService, ledgerService, payoutService, subscriptionService)
+	go paymentService.StartAuthorizationRenewer(context.Background(), time.Hour)
+
+	// Фейковый шлюз доставляет свои события прямо в обработчик webhook
+	if fakeGateway, ok := paymentGateway.(service.FakePaymentGateway); ok {
+		fakeGateway.SetWebhookHandler(paymentService.HandleWebhook)
+		go fakeGateway.StartWebhookDelivery(context.Background(), time.Second)
+	}
+
+	idempotencyRepo := idempotency.NewIdempotencyRepository(db)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo)
+	go idempotencyService.StartCleanup(context.Background(), time.Hour)
 
 	// Password reset services
 	passwordResetRepo := password_reset.NewPasswordResetRepository(db)
-	emailService := service.NewEmailService()
 	passwordResetService := service.NewPasswordResetService(passwordResetRepo, emailService)
 
 	// Email verification services
@@ -141,6 +182,10 @@ func main() {
 		"Accept",
 		"Authorization",
 		"X-Requested-With",
+		"Idempotency-Key",
+	}
+	config.ExposeHeaders = []string{
+		"Idempotent-Replayed",
 	}
 	config.AllowMethods = []string{
 		"GET",
@@ -164,7 +209,16 @@ func main() {
 	notificationRepoInstance := notificationRepo.NewNotificationRepository(db)
 	notificationService := service.NewNotificationService(notificationHub, notificationRepoInstance)
 
-	jobService := service.NewJobService(jobRepo, &cfg.GoogleMaps, minioRepo, notificationService)
+	distanceRepo := distance.NewDistanceRepository(db)
+	distanceService := service.NewDistanceService(distanceRepo, service.NewDistanceProviders(cfg, distanceRepo)...)
+	log.Printf("Distance provider: %s", cfg.Distance.Provider)
+
+	feeService := service.NewFeeService(adminService, subscriptionService)
+	escrowCaptureRepo := escrow_capture.NewEscrowCaptureRepository(db)
+	escrowCaptureService := service.NewEscrowCaptureService(escrowCaptureRepo, paymentService, payoutService, adminService, notificationService)
+	go escrowCaptureService.StartCaptureProcessor(context.Background(), 5*time.Minute)
+
+	jobService := service.NewJobService(jobRepo, distanceService, minioRepo, notificationService, paymentService, escrowCaptureService, feeService)
 
 	locationRepo := repository.NewLocationRepository(db)
 	locationService := service.NewLocationService(locationRepo)
@@ -173,10 +227,34 @@ func main() {
 	chatRepo := chatRepo.NewChatRepository(db)
 	chatService := service.NewChatService(chatRepo)
 
-	jobHandler := handlers.NewJobHandler(jobService, chatService, notificationService, minioRepo, paymentService, adminService)
+	crewRepo := crew.NewCrewRepository(db)
+	crewService := service.NewCrewService(crewRepo, jobRepo, truckRepo, userRepo, notificationService, subscriptionService)
+
+	documentService := service.NewDocumentService(jobRepo, companyRepo, crewService, minioRepo)
+
+	boostRepo := boost.NewBoostRepository(db)
+	boostService := service.NewBoostService(boostRepo, jobRepo, adminService, paymentService, notificationService, subscriptionService)
+	paymentService.OnPaymentSucceeded(boostService.HandlePaymentSucceeded)
+
+	promoRepo := promo.NewPromoRepository(db)
+	promoService := service.NewPromoService(promoRepo, jobRepo, ledgerService)
+
+	jobPostingRepo := job_posting.NewJobPostingRepository(db)
+	jobPostingService := service.NewJobPostingService(jobPostingRepo, jobService, jobRepo, paymentService, paymentRepo, promoService, boostService, notificationService)
+	go jobPostingService.StartOutboxProcessor(context.Background(), 30*time.Second)
+
+	jobTemplateRepo := job_template.NewJobTemplateRepository(db)
+	jobTemplateService := service.NewJobTemplateService(jobTemplateRepo, jobService, jobPostingService, notificationService)
+	go jobTemplateService.StartRecurringPoster(context.Background(), time.Hour)
+
+	reconciliationRepo := reconciliation.NewReconciliationRepository(db)
+	reconciliationService := service.NewReconciliationService(reconciliationRepo, paymentRepo, paymentService, paymentGateway, adminService, notificationService)
+	go reconciliationService.StartReconciler(context.Background(), time.Hour)
+
+	jobHandler := handlers.NewJobHandler(jobService, chatService, notificationService, minioRepo, jobPostingService, adminService, crewService, boostService, escrowCaptureService)
 
 	reviewRepo := reviewRepo.NewReviewRepository(db)
-	reviewService := service.NewReviewService(reviewRepo)
+	reviewService := service.NewReviewService(reviewRepo, paymentService)
 	reviewHandler := review.NewReviewHandler(reviewService, notificationService, jobService)
 
 	// Инициализация WebSocket hub для чата
@@ -187,13 +265,18 @@ func main() {
 
 	apiGroup := r.Group("/api")
 	{
-		router.AdminRouter(apiGroup, jwtAuth, adminService)
+		router.AdminRouter(apiGroup, jwtAuth, adminService, ledgerService, reconciliationService, paymentService, promoService, escrowCaptureService)
 		router.UserRouter(apiGroup, userService, minioService, jwtAuth, passwordResetService, sessionService, emailVerificationService)
 		router.CompanyRouter(apiGroup, companyService, jwtAuth)
 		router.TruckRouter(apiGroup, truckService, jwtAuth)
+		router.CrewRouter(apiGroup, crewService, jwtAuth)
 		router.VerificationRouter(apiGroup, verificationService, jwtAuth)
-		router.PaymentRouter(apiGroup, paymentService, jwtAuth)
-		router.SetupJobRoutes(apiGroup, jobHandler, jwtAuth)
+		router.PaymentRouter(apiGroup, paymentService, payoutService, invoiceService, ledgerService, promoService, statementService, idempotencyService, jwtAuth)
+		router.SetupJobRoutes(apiGroup, jobHandler, jwtAuth, idempotencyService)
+		router.DocumentRouter(apiGroup, documentService, jwtAuth)
+		router.BoostRouter(apiGroup, boostService, subscriptionService, jwtAuth)
+		router.SubscriptionRouter(apiGroup, subscriptionService, idempotencyService, jwtAuth)
+		router.JobTemplateRouter(apiGroup, jobTemplateService, jwtAuth)
 		router.SetupLocationRoutes(apiGroup, locationHandler)
 		router.SetupChatRoutes(apiGroup, chatService, *jobService, jwtAuth, hub, notificationService)
 		router.SetupNotificationRoutes(apiGroup, jwtAuth, notificationHub, notificationService)
@@ -201,6 +284,7 @@ func main() {
 
 		// Public system settings route
 		apiGroup.GET("/commission-rate", handlers.GetCommissionRate(adminService))
+		apiGroup.GET("/fee-quote", handlers.GetFeeQuote(feeService))
 	}
 
 	log.Println("Starting server on :8080")
@@ -0,0 +1,9 @@
+-- Участник попадает в команду только после того, как примет приглашение владельца.
+-- Уже добавленные участники тоже должны подтвердить участие, поэтому по умолчанию 'pending'
+ALTER TABLE company_team_members
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'active')),
+    ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_company_team_members_pending_invites ON company_team_members(member_id)
+    WHERE status = 'pending';
@@ -0,0 +1,30 @@
+-- Команда компании: водители и помощники, которые фактически выполняют переезды
+CREATE TABLE IF NOT EXISTS company_team_members (
+    id BIGSERIAL PRIMARY KEY,
+    owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    member_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('driver', 'helper')),
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_team_member UNIQUE (owner_id, member_id)
+);
+
+-- Назначение членов команды на конкретную работу
+CREATE TABLE IF NOT EXISTS job_crew_assignments (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    member_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('driver', 'helper')),
+    assigned_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT unique_job_crew_member UNIQUE (job_id, member_id)
+);
+
+-- Грузовик, назначенный на работу
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS assigned_truck_id BIGINT REFERENCES trucks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_company_team_members_owner_id ON company_team_members(owner_id);
+CREATE INDEX IF NOT EXISTS idx_company_team_members_member_id ON company_team_members(member_id);
+CREATE INDEX IF NOT EXISTS idx_job_crew_assignments_job_id ON job_crew_assignments(job_id);
+CREATE INDEX IF NOT EXISTS idx_job_crew_assignments_member_id ON job_crew_assignments(member_id);
@@ -0,0 +1,7 @@
+-- Разрешаем хранить сгенерированный bill of lading вместе с файлами работы
+ALTER TABLE job_files
+DROP CONSTRAINT IF EXISTS check_file_type;
+
+ALTER TABLE job_files
+ADD CONSTRAINT check_file_type
+CHECK (file_type IN ('verification_document', 'work_photo', 'legacy', 'bill_of_lading'));
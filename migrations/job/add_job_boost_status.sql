@@ -0,0 +1,7 @@
+-- Платное продвижение включается только после успешной оплаты: до этого оно pending
+ALTER TABLE job_boosts
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('pending', 'active'));
+
+CREATE INDEX IF NOT EXISTS idx_job_boosts_pending_intent ON job_boosts(stripe_payment_intent_id)
+    WHERE status = 'pending';
@@ -0,0 +1,53 @@
+-- IANA часовые пояса мест погрузки/доставки и окна как моменты времени (timestamptz)
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS pickup_timezone VARCHAR(64);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS delivery_timezone VARCHAR(64);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS pickup_window_start TIMESTAMPTZ;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS pickup_window_end TIMESTAMPTZ;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS delivery_window_start TIMESTAMPTZ;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS delivery_window_end TIMESTAMPTZ;
+
+-- Заполняем зоны существующих работ по основной зоне штата
+WITH state_zones(code, name, tz) AS (VALUES
+    ('AL', 'Alabama', 'America/Chicago'), ('AK', 'Alaska', 'America/Anchorage'),
+    ('AZ', 'Arizona', 'America/Phoenix'), ('AR', 'Arkansas', 'America/Chicago'),
+    ('CA', 'California', 'America/Los_Angeles'), ('CO', 'Colorado', 'America/Denver'),
+    ('CT', 'Connecticut', 'America/New_York'), ('DE', 'Delaware', 'America/New_York'),
+    ('DC', 'District of Columbia', 'America/New_York'), ('FL', 'Florida', 'America/New_York'),
+    ('GA', 'Georgia', 'America/New_York'), ('HI', 'Hawaii', 'Pacific/Honolulu'),
+    ('ID', 'Idaho', 'America/Boise'), ('IL', 'Illinois', 'America/Chicago'),
+    ('IN', 'Indiana', 'America/New_York'), ('IA', 'Iowa', 'America/Chicago'),
+    ('KS', 'Kansas', 'America/Chicago'), ('KY', 'Kentucky', 'America/New_York'),
+    ('LA', 'Louisiana', 'America/Chicago'), ('ME', 'Maine', 'America/New_York'),
+    ('MD', 'Maryland', 'America/New_York'), ('MA', 'Massachusetts', 'America/New_York'),
+    ('MI', 'Michigan', 'America/New_York'), ('MN', 'Minnesota', 'America/Chicago'),
+    ('MS', 'Mississippi', 'America/Chicago'), ('MO', 'Missouri', 'America/Chicago'),
+    ('MT', 'Montana', 'America/Denver'), ('NE', 'Nebraska', 'America/Chicago'),
+    ('NV', 'Nevada', 'America/Los_Angeles'), ('NH', 'New Hampshire', 'America/New_York'),
+    ('NJ', 'New Jersey', 'America/New_York'), ('NM', 'New Mexico', 'America/Denver'),
+    ('NY', 'New York', 'America/New_York'), ('NC', 'North Carolina', 'America/New_York'),
+    ('ND', 'North Dakota', 'America/Chicago'), ('OH', 'Ohio', 'America/New_York'),
+    ('OK', 'Oklahoma', 'America/Chicago'), ('OR', 'Oregon', 'America/Los_Angeles'),
+    ('PA', 'Pennsylvania', 'America/New_York'), ('RI', 'Rhode Island', 'America/New_York'),
+    ('SC', 'South Carolina', 'America/New_York'), ('SD', 'South Dakota', 'America/Chicago'),
+    ('TN', 'Tennessee', 'America/Chicago'), ('TX', 'Texas', 'America/Chicago'),
+    ('UT', 'Utah', 'America/Denver'), ('VT', 'Vermont', 'America/New_York'),
+    ('VA', 'Virginia', 'America/New_York'), ('WA', 'Washington', 'America/Los_Angeles'),
+    ('WV', 'West Virginia', 'America/New_York'), ('WI', 'Wisconsin', 'America/Chicago'),
+    ('WY', 'Wyoming', 'America/Denver')
+)
+UPDATE jobs j SET
+    pickup_timezone = COALESCE((SELECT tz FROM state_zones WHERE UPPER(code) = UPPER(TRIM(j.pickup_state)) OR LOWER(name) = LOWER(TRIM(j.pickup_state))), 'UTC'),
+    delivery_timezone = COALESCE((SELECT tz FROM state_zones WHERE UPPER(code) = UPPER(TRIM(j.delivery_state)) OR LOWER(name) = LOWER(TRIM(j.delivery_state))), 'UTC')
+WHERE j.pickup_timezone IS NULL OR j.delivery_timezone IS NULL;
+
+UPDATE jobs SET
+    pickup_window_start = (pickup_date::date + pickup_time_from::time) AT TIME ZONE pickup_timezone,
+    pickup_window_end = (pickup_date::date + pickup_time_to::time) AT TIME ZONE pickup_timezone,
+    delivery_window_start = (delivery_date::date + delivery_time_from::time) AT TIME ZONE delivery_timezone,
+    delivery_window_end = (delivery_date::date + delivery_time_to::time) AT TIME ZONE delivery_timezone
+WHERE pickup_window_start IS NULL;
+
+-- Отметки исполнителя тоже храним как моменты времени
+ALTER TABLE job_milestones ALTER COLUMN recorded_at TYPE TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_pickup_window_start ON jobs(pickup_window_start);
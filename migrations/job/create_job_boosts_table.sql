@@ -0,0 +1,21 @@
+-- Платные продвижения работ: срочный бейдж, закрепление вверху списка, рассылка ближайшим перевозчикам
+CREATE TABLE IF NOT EXISTS job_boosts (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    boost_type VARCHAR(20) NOT NULL CHECK (boost_type IN ('urgent', 'pin_to_top', 'nearby_push')),
+    price_cents INTEGER NOT NULL,
+    stripe_payment_intent_id VARCHAR(255),
+    purchased_by BIGINT NOT NULL REFERENCES users(id),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_boosts_job_active ON job_boosts(job_id, boost_type, expires_at);
+
+-- Цены продвижений настраиваются администратором
+ALTER TABLE system_settings
+    ADD COLUMN IF NOT EXISTS urgent_boost_price_cents INTEGER NOT NULL DEFAULT 999,
+    ADD COLUMN IF NOT EXISTS pin_boost_price_cents INTEGER NOT NULL DEFAULT 1999,
+    ADD COLUMN IF NOT EXISTS nearby_push_boost_price_cents INTEGER NOT NULL DEFAULT 1499,
+    ADD COLUMN IF NOT EXISTS boost_duration_hours INTEGER NOT NULL DEFAULT 24;
@@ -0,0 +1,12 @@
+-- Фактические отметки исполнителя: прибытие, погрузка, выезд, доставка, разгрузка
+CREATE TABLE IF NOT EXISTS job_milestones (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    milestone VARCHAR(30) NOT NULL CHECK (milestone IN ('arrived_pickup', 'loading_finished', 'departed_pickup', 'arrived_delivery', 'unload_finished')),
+    recorded_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT unique_job_milestone UNIQUE (job_id, milestone)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_milestones_job_id ON job_milestones(job_id);
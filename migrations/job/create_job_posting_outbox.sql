@@ -0,0 +1,28 @@
+-- Outbox публикации работы: запись создается в одной транзакции с работой (job_status = 'pending_payment').
+-- Фоновый обработчик создает по ней escrow-авторизацию и платеж за сбор, публикует работу
+-- после подтверждения оплаты, а при отказе - отменяет платежи и удаляет работу
+CREATE TABLE IF NOT EXISTS job_posting_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT REFERENCES jobs(id) ON DELETE SET NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_method_id BIGINT,
+    promo_code VARCHAR(50),
+    boosts JSONB,
+    template_name VARCHAR(255),
+    status VARCHAR(30) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'awaiting_confirmation', 'canceling', 'completed', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(), -- до этого момента запись занята обработчиком
+    last_error TEXT,
+    escrow_payment_intent_id VARCHAR(255),
+    escrow_client_secret VARCHAR(255),
+    fee_payment_intent_id VARCHAR(255),
+    fee_client_secret VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_posting_outbox_due ON job_posting_outbox(next_attempt_at)
+    WHERE status IN ('pending', 'awaiting_confirmation', 'canceling');
+
+CREATE INDEX IF NOT EXISTS idx_job_posting_outbox_job ON job_posting_outbox(job_id);
@@ -0,0 +1,25 @@
+-- Промежуточные остановки работы между основной погрузкой и доставкой
+-- (склад, дополнительная погрузка, второй адрес выгрузки)
+CREATE TABLE IF NOT EXISTS job_stops (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    stop_order INTEGER NOT NULL,
+    stop_type VARCHAR(20) NOT NULL CHECK (stop_type IN ('pickup', 'dropoff', 'storage')),
+    address TEXT NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    state VARCHAR(50) NOT NULL,
+    floor INTEGER,
+    building_type TEXT,
+    walk_distance TEXT,
+    stop_date DATE,
+    time_from TIME,
+    time_to TIME,
+    timezone VARCHAR(64),
+    window_start TIMESTAMPTZ,
+    window_end TIMESTAMPTZ,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (job_id, stop_order)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_stops_job_id ON job_stops(job_id);
+CREATE INDEX IF NOT EXISTS idx_job_stops_city_state ON job_stops(city, state);
@@ -0,0 +1,58 @@
+-- Шаблоны работ для повторяющихся переездов
+CREATE TABLE IF NOT EXISTS job_templates (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+
+    job_type TEXT NOT NULL,
+    number_of_bedrooms TEXT,
+    packing_boxes BOOLEAN DEFAULT FALSE,
+    bulky_items BOOLEAN DEFAULT FALSE,
+    inventory_list BOOLEAN DEFAULT FALSE,
+    hoisting BOOLEAN DEFAULT FALSE,
+    additional_services_description TEXT,
+    estimated_crew_assistants TEXT,
+    truck_size TEXT NOT NULL,
+
+    pickup_address TEXT NOT NULL,
+    pickup_city VARCHAR(100) NOT NULL,
+    pickup_state VARCHAR(50) NOT NULL,
+    pickup_floor INTEGER,
+    pickup_building_type TEXT,
+    pickup_walk_distance TEXT,
+
+    delivery_address TEXT NOT NULL,
+    delivery_city VARCHAR(100) NOT NULL,
+    delivery_state VARCHAR(50) NOT NULL,
+    delivery_floor INTEGER,
+    delivery_building_type TEXT,
+    delivery_walk_distance TEXT,
+
+    pickup_time_from TIME NOT NULL,
+    pickup_time_to TIME NOT NULL,
+    delivery_time_from TIME NOT NULL,
+    delivery_time_to TIME NOT NULL,
+    delivery_offset_days INTEGER NOT NULL DEFAULT 0,
+
+    cut_amount DECIMAL DEFAULT 0,
+    payment_amount DECIMAL NOT NULL,
+    weight_lbs DECIMAL DEFAULT 0,
+    volume_cu_ft DECIMAL DEFAULT 0,
+
+    -- Правило повторения: дни недели, каждые N недель, публикация за lead_days до погрузки
+    recurrence_active BOOLEAN NOT NULL DEFAULT FALSE,
+    recurrence_weekdays TEXT[],
+    recurrence_interval_weeks INTEGER NOT NULL DEFAULT 1,
+    recurrence_lead_days INTEGER NOT NULL DEFAULT 7,
+    recurrence_starts_on DATE,
+    recurrence_ends_on DATE,
+    recurrence_next_pickup DATE,
+    recurrence_payment_method_id BIGINT REFERENCES user_payment_methods(id) ON DELETE SET NULL,
+    last_posted_job_id BIGINT REFERENCES jobs(id) ON DELETE SET NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_templates_user ON job_templates(user_id);
+CREATE INDEX IF NOT EXISTS idx_job_templates_recurrence ON job_templates(recurrence_next_pickup) WHERE recurrence_active;
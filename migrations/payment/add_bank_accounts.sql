@@ -0,0 +1,23 @@
+-- Банковские счета США (ACH debit) как способ оплаты наравне с картами.
+-- Счет добавляется через SetupIntent; до подтверждения (микродепозиты) он не используется для оплаты
+ALTER TABLE user_payment_methods
+    ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'card'
+        CHECK (type IN ('card', 'us_bank_account')),
+    ADD COLUMN IF NOT EXISTS status VARCHAR(30) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'pending_verification', 'verification_failed')),
+    ADD COLUMN IF NOT EXISTS bank_name VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS bank_last4 VARCHAR(4),
+    ADD COLUMN IF NOT EXISTS bank_account_type VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS stripe_setup_intent_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS stripe_mandate_id VARCHAR(255);
+
+-- У банковского счета нет данных карты
+ALTER TABLE user_payment_methods
+    ALTER COLUMN card_last4 DROP NOT NULL,
+    ALTER COLUMN card_brand DROP NOT NULL,
+    ALTER COLUMN card_exp_month DROP NOT NULL,
+    ALTER COLUMN card_exp_year DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_user_payment_methods_setup_intent
+    ON user_payment_methods(stripe_setup_intent_id)
+    WHERE stripe_setup_intent_id IS NOT NULL;
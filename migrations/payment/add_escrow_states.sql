@@ -0,0 +1,25 @@
+-- Escrow: оплата работы авторизуется при публикации (capture_method = 'manual'),
+-- списывается после подтверждения выполнения и освобождается при отмене.
+-- Состояния авторизации и списания хранятся отдельно от статуса Stripe.
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS capture_method VARCHAR(20) NOT NULL DEFAULT 'automatic'
+        CHECK (capture_method IN ('automatic', 'manual')),
+    ADD COLUMN IF NOT EXISTS authorization_status VARCHAR(20) NOT NULL DEFAULT 'none'
+        CHECK (authorization_status IN ('none', 'pending', 'authorized', 'released', 'expired', 'replaced', 'failed')),
+    ADD COLUMN IF NOT EXISTS capture_status VARCHAR(20) NOT NULL DEFAULT 'none'
+        CHECK (capture_status IN ('none', 'uncaptured', 'captured', 'refunded', 'canceled')),
+    ADD COLUMN IF NOT EXISTS authorized_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS authorization_expires_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS captured_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS amount_captured_cents INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS released_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS refunded_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS reauthorized_from_payment_id BIGINT REFERENCES payments(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_payments_job_escrow
+    ON payments(job_id, id DESC)
+    WHERE capture_method = 'manual';
+
+CREATE INDEX IF NOT EXISTS idx_payments_authorization_expires
+    ON payments(authorization_expires_at)
+    WHERE authorization_status = 'authorized' AND capture_status = 'uncaptured';
@@ -0,0 +1,7 @@
+-- Расписание сборов за публикацию работы (flat / percentage / tiered, переопределения
+-- по штатам, промо-периоды). NULL - прежний фиксированный сбор $15.
+ALTER TABLE system_settings ADD COLUMN IF NOT EXISTS fee_schedule JSONB;
+
+-- Расчет сбора и комиссии, примененный к работе и ее платежам
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS fee_breakdown JSONB;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS fee_breakdown JSONB;
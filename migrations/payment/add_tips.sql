@@ -0,0 +1,15 @@
+-- Чаевые исполнителю после выполнения работы: списываются с сохраненной карты подрядчика
+-- и начисляются исполнителю целиком, без комиссии платформы
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS payment_type VARCHAR(20) NOT NULL DEFAULT 'charge'
+        CHECK (payment_type IN ('charge', 'tip')),
+    ADD COLUMN IF NOT EXISTS tip_recipient_id BIGINT REFERENCES users(id) ON DELETE SET NULL;
+
+-- Одни чаевые на работу; неудавшуюся попытку можно повторить
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_payments_job_tip
+    ON payments(job_id)
+    WHERE payment_type = 'tip' AND status NOT IN ('canceled', 'requires_payment_method');
+
+CREATE INDEX IF NOT EXISTS idx_payments_tip_recipient
+    ON payments(tip_recipient_id)
+    WHERE payment_type = 'tip';
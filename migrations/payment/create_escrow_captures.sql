@@ -0,0 +1,28 @@
+-- Списание escrow после выполнения работы. Исполнитель отмечает работу выполненной, подрядчик
+-- подтверждает или открывает спор; без ответа до confirm_by выполнение подтверждается автоматически.
+-- Подтвержденное списание (capture_pending) выполняет фоновый обработчик с повторами; если списание
+-- отклонено окончательно или попытки исчерпаны, оно ждет решения администратора (capture_failed)
+CREATE TABLE IF NOT EXISTS escrow_captures (
+    job_id BIGINT PRIMARY KEY REFERENCES jobs(id) ON DELETE CASCADE,
+    contractor_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    executor_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(30) NOT NULL DEFAULT 'awaiting_confirmation'
+        CHECK (status IN ('awaiting_confirmation', 'disputed', 'capture_pending', 'captured', 'released', 'capture_failed')),
+    confirm_by TIMESTAMP WITH TIME ZONE NOT NULL, -- после этого момента выполнение подтверждается автоматически
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    auto_confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+    disputed_at TIMESTAMP WITH TIME ZONE,
+    dispute_reason TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(), -- до этого момента запись занята обработчиком
+    last_error TEXT,
+    captured_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_escrow_captures_confirm_by ON escrow_captures(confirm_by)
+    WHERE status = 'awaiting_confirmation';
+
+CREATE INDEX IF NOT EXISTS idx_escrow_captures_due ON escrow_captures(next_attempt_at)
+    WHERE status = 'capture_pending';
@@ -0,0 +1,22 @@
+-- Ключи идемпотентности (заголовок Idempotency-Key): первый ответ сохраняется
+-- и возвращается на повторные запросы с тем же ключом.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_method VARCHAR(10) NOT NULL,
+    request_path VARCHAR(255) NOT NULL,
+    -- sha256 метода, пути и тела запроса
+    request_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'processing'
+        CHECK (status IN ('processing', 'completed')),
+    response_status INTEGER,
+    response_content_type VARCHAR(255),
+    response_body BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
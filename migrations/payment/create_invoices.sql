@@ -0,0 +1,31 @@
+-- Сквозная нумерация счетов (INV-000001, INV-000002, ...)
+CREATE SEQUENCE IF NOT EXISTS invoice_number_seq;
+
+-- Счета по успешным платежам: один счет на платеж.
+-- PDF хранится в MinIO (bucket invoices), object_name пуст, пока файл не сгенерирован.
+CREATE TABLE IF NOT EXISTS invoices (
+    id BIGSERIAL PRIMARY KEY,
+    invoice_number VARCHAR(32) NOT NULL UNIQUE,
+    payment_id BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    job_id BIGINT REFERENCES jobs(id) ON DELETE SET NULL,
+    line_items JSONB NOT NULL DEFAULT '[]',
+    subtotal_cents INTEGER NOT NULL,
+    tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0,
+    tax_cents INTEGER NOT NULL DEFAULT 0,
+    total_cents INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'usd',
+    object_name VARCHAR(512),
+    file_size BIGINT NOT NULL DEFAULT 0,
+    emailed_to VARCHAR(255),
+    emailed_at TIMESTAMP WITH TIME ZONE,
+    issued_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (payment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_user_id ON invoices(user_id, issued_at DESC);
+
+-- Ставка налога (в процентах), включенного в сборы платформы
+ALTER TABLE system_settings ADD COLUMN IF NOT EXISTS invoice_tax_rate DECIMAL(5,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,47 @@
+-- Внутренний журнал двойной записи движения денег платформы.
+-- Каждая операция (списание, capture, возврат, начисление выплаты, перевод) -
+-- это транзакция со сбалансированными проводками: сумма дебета = сумме кредита.
+
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    account_type VARCHAR(20) NOT NULL
+        CHECK (account_type IN ('asset', 'liability', 'revenue', 'expense')),
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'usd',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Счета платформы
+INSERT INTO ledger_accounts (code, name, account_type) VALUES
+    ('stripe_clearing', 'Stripe clearing', 'asset'),
+    ('escrow', 'Job payments held in escrow', 'liability'),
+    ('platform_revenue', 'Platform revenue (fees and commissions)', 'revenue'),
+    ('refunds', 'Refunds of platform charges', 'expense'),
+    ('promo_expense', 'Promo code discounts and granted credits', 'expense'),
+    ('customer_credits', 'Unused customer credits', 'liability')
+ON CONFLICT (code) DO NOTHING;
+
+-- Транзакция уникальна по источнику, поэтому повторная проводка той же операции игнорируется
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    reference_type VARCHAR(50) NOT NULL,
+    reference_id VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (reference_type, reference_id)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES ledger_transactions(id) ON DELETE CASCADE,
+    account_id BIGINT NOT NULL REFERENCES ledger_accounts(id),
+    debit_cents BIGINT NOT NULL DEFAULT 0 CHECK (debit_cents >= 0),
+    credit_cents BIGINT NOT NULL DEFAULT 0 CHECK (credit_cents >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((debit_cents = 0) <> (credit_cents = 0))
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_id ON ledger_entries(account_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries(transaction_id);
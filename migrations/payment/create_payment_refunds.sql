@@ -0,0 +1,19 @@
+-- Возвраты по платежам: запрошенные подрядчиком или администратором,
+-- при отмене работы и сделанные в Stripe Dashboard. Статус обновляется по webhook
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id BIGSERIAL PRIMARY KEY,
+    payment_id BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    stripe_refund_id VARCHAR(255) UNIQUE,
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    note TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'requires_action', 'succeeded', 'failed', 'canceled')),
+    failure_reason TEXT,
+    requested_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment_id ON payment_refunds(payment_id);
@@ -0,0 +1,36 @@
+-- Stripe Connect аккаунты исполнителей для получения выплат
+CREATE TABLE IF NOT EXISTS connect_accounts (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    stripe_account_id VARCHAR(255) NOT NULL UNIQUE,
+    details_submitted BOOLEAN NOT NULL DEFAULT false,
+    payouts_enabled BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Журнал выплат: одна запись на выполненную работу.
+-- Записи копятся в статусе pending, пока баланс исполнителя не достигнет
+-- минимальной суммы выплаты, затем переводятся одним Stripe transfer.
+-- Возврат оплаты работы до перевода уменьшает запись, полный возврат отменяет ее (canceled).
+CREATE TABLE IF NOT EXISTS payouts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    job_id BIGINT REFERENCES jobs(id) ON DELETE SET NULL,
+    payment_id BIGINT REFERENCES payments(id) ON DELETE SET NULL,
+    gross_amount_cents INTEGER NOT NULL,
+    commission_rate NUMERIC(5,2) NOT NULL,
+    commission_cents INTEGER NOT NULL,
+    net_amount_cents INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'usd',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'transferred', 'canceled')),
+    stripe_transfer_id VARCHAR(255),
+    failure_reason TEXT,
+    transferred_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (payment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_user_id ON payouts(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payouts_pending ON payouts(user_id) WHERE status = 'pending';
@@ -0,0 +1,60 @@
+-- Промокоды на сбор за публикацию работы. Управляются администраторами
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE, -- хранится в верхнем регистре
+    description TEXT NOT NULL DEFAULT '',
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    percent_off NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (percent_off >= 0 AND percent_off <= 100),
+    amount_off_cents INTEGER NOT NULL DEFAULT 0 CHECK (amount_off_cents >= 0),
+    max_redemptions INTEGER CHECK (max_redemptions > 0),  -- NULL - без ограничения
+    per_user_limit INTEGER CHECK (per_user_limit > 0),    -- NULL - без ограничения
+    first_job_only BOOLEAN NOT NULL DEFAULT false,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Кредиты на счету пользователя, которыми оплачиваются сборы платформы
+CREATE TABLE IF NOT EXISTS user_credits (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    balance_cents INTEGER NOT NULL DEFAULT 0 CHECK (balance_cents >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- История начислений и списаний кредитов
+CREATE TABLE IF NOT EXISTS user_credit_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount_cents INTEGER NOT NULL CHECK (amount_cents <> 0), -- > 0 начисление, < 0 списание
+    reason VARCHAR(30) NOT NULL
+        CHECK (reason IN ('admin_grant', 'admin_deduction', 'job_fee', 'job_fee_reversal')),
+    job_id BIGINT REFERENCES jobs(id) ON DELETE SET NULL,
+    note TEXT,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Скидки на сбор при публикации работы: промокод и/или кредиты. Одна запись на работу
+CREATE TABLE IF NOT EXISTS fee_redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    promo_code_id BIGINT REFERENCES promo_codes(id) ON DELETE SET NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    job_id BIGINT REFERENCES jobs(id) ON DELETE SET NULL,
+    fee_before_cents INTEGER NOT NULL,
+    discount_cents INTEGER NOT NULL DEFAULT 0,
+    credits_applied_cents INTEGER NOT NULL DEFAULT 0,
+    fee_after_cents INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'applied' CHECK (status IN ('applied', 'reversed')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    reversed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_fee_redemptions_job
+    ON fee_redemptions(job_id)
+    WHERE status = 'applied';
+CREATE INDEX IF NOT EXISTS idx_fee_redemptions_promo ON fee_redemptions(promo_code_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fee_redemptions_user ON fee_redemptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_credit_transactions_user ON user_credit_transactions(user_id, created_at DESC);
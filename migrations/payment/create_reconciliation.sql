@@ -0,0 +1,38 @@
+-- Сверка платежей со Stripe: запуски сверки и найденные расхождения.
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id BIGSERIAL PRIMARY KEY,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE,
+    intents_checked INTEGER NOT NULL DEFAULT 0,
+    payments_checked INTEGER NOT NULL DEFAULT 0,
+    auto_fixed INTEGER NOT NULL DEFAULT 0,
+    flagged INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+-- auto_fixed - безопасное расхождение исправлено автоматически (запись для аудита),
+-- open - требует разбора администратором, resolved - разобрано
+CREATE TABLE IF NOT EXISTS reconciliation_issues (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT REFERENCES reconciliation_runs(id) ON DELETE SET NULL,
+    stripe_payment_intent_id VARCHAR(255) NOT NULL,
+    payment_id BIGINT REFERENCES payments(id) ON DELETE SET NULL,
+    issue_type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'auto_fixed', 'resolved')),
+    local_status VARCHAR(50),
+    stripe_status VARCHAR(50),
+    local_amount_cents BIGINT NOT NULL DEFAULT 0,
+    stripe_amount_cents BIGINT NOT NULL DEFAULT 0,
+    details TEXT,
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolved_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    resolution_note TEXT
+);
+
+-- Открытое расхождение одного типа по PaymentIntent хранится одной записью
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliation_issues_open
+    ON reconciliation_issues(stripe_payment_intent_id, issue_type) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_reconciliation_issues_status ON reconciliation_issues(status, detected_at DESC);
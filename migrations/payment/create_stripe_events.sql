@@ -0,0 +1,38 @@
+-- Журнал webhook-событий Stripe. Каждое событие сохраняется по stripe_event_id
+-- до обработки, поэтому повторная доставка не обрабатывается дважды,
+-- а упавшие события можно переиграть (cmd/replay-stripe-events).
+CREATE TABLE IF NOT EXISTS stripe_events (
+    id BIGSERIAL PRIMARY KEY,
+    stripe_event_id VARCHAR(255) NOT NULL UNIQUE,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'received'
+        CHECK (status IN ('received', 'processing', 'processed', 'ignored', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    processed_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stripe_events_status ON stripe_events(status, received_at);
+
+-- Возвраты, пришедшие из Stripe (в т.ч. сделанные в Dashboard)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS amount_refunded_cents INTEGER NOT NULL DEFAULT 0;
+
+-- Споры (chargeback) по платежам
+CREATE TABLE IF NOT EXISTS payment_disputes (
+    id BIGSERIAL PRIMARY KEY,
+    payment_id BIGINT REFERENCES payments(id) ON DELETE SET NULL,
+    stripe_dispute_id VARCHAR(255) NOT NULL UNIQUE,
+    stripe_payment_intent_id VARCHAR(255),
+    amount_cents INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(50),
+    status VARCHAR(50) NOT NULL,
+    evidence_due_by TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_disputes_payment_id ON payment_disputes(payment_id);
@@ -0,0 +1,32 @@
+-- Подписки компаний на платные планы (pro, fleet). Без подписки действует план free.
+-- Состояние синхронизируется с Stripe Subscription через webhook
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan VARCHAR(20) NOT NULL CHECK (plan IN ('pro', 'fleet')),
+    status VARCHAR(30) NOT NULL
+        CHECK (status IN ('incomplete', 'incomplete_expired', 'trialing', 'active',
+                          'past_due', 'unpaid', 'paused', 'canceled')),
+    stripe_subscription_id VARCHAR(255) NOT NULL UNIQUE,
+    stripe_customer_id VARCHAR(255) NOT NULL,
+    stripe_price_id VARCHAR(255) NOT NULL,
+    current_period_start TIMESTAMP WITH TIME ZONE,
+    current_period_end TIMESTAMP WITH TIME ZONE,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT false,
+    past_due_since TIMESTAMP WITH TIME ZONE, -- первое неудачное списание; отсчет льготного периода
+    canceled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- У пользователя не больше одной действующей подписки
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_subscriptions_user_current
+    ON subscriptions(user_id)
+    WHERE status NOT IN ('canceled', 'incomplete_expired');
+
+-- Продвижения, входящие в план, не оплачиваются отдельно
+ALTER TABLE job_boosts
+    ADD COLUMN IF NOT EXISTS included_in_plan BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_job_boosts_included ON job_boosts(purchased_by, created_at)
+    WHERE included_in_plan;
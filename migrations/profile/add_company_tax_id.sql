@@ -0,0 +1,2 @@
+-- Налоговый номер компании (EIN или SSN) для годовой сводки выплат исполнителю
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS tax_id VARCHAR(20);
@@ -0,0 +1,55 @@
+-- Центроиды городов для офлайн-расчета расстояния (haversine)
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+-- Кэш расстояний между парами адресов
+CREATE TABLE IF NOT EXISTS distance_cache (
+    origin_key TEXT NOT NULL,
+    destination_key TEXT NOT NULL,
+    distance_meters INT NOT NULL,
+    duration_seconds INT NOT NULL DEFAULT 0,
+    provider VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    PRIMARY KEY (origin_key, destination_key)
+);
+
+-- Каким провайдером посчитано расстояние работы
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS distance_provider VARCHAR(20);
+
+-- Центроиды крупных городов
+UPDATE cities c SET latitude = v.lat, longitude = v.lng
+FROM (VALUES
+    ('New York', 'New York', 40.7128, -74.0060),
+    ('Los Angeles', 'California', 34.0522, -118.2437),
+    ('Chicago', 'Illinois', 41.8781, -87.6298),
+    ('Houston', 'Texas', 29.7604, -95.3698),
+    ('Phoenix', 'Arizona', 33.4484, -112.0740),
+    ('Philadelphia', 'Pennsylvania', 39.9526, -75.1652),
+    ('San Antonio', 'Texas', 29.4241, -98.4936),
+    ('San Diego', 'California', 32.7157, -117.1611),
+    ('Dallas', 'Texas', 32.7767, -96.7970),
+    ('San Jose', 'California', 37.3382, -121.8863),
+    ('Austin', 'Texas', 30.2672, -97.7431),
+    ('Jacksonville', 'Florida', 30.3322, -81.6557),
+    ('Fort Worth', 'Texas', 32.7555, -97.3308),
+    ('Columbus', 'Ohio', 39.9612, -82.9988),
+    ('Charlotte', 'North Carolina', 35.2271, -80.8431),
+    ('San Francisco', 'California', 37.7749, -122.4194),
+    ('Indianapolis', 'Indiana', 39.7684, -86.1581),
+    ('Seattle', 'Washington', 47.6062, -122.3321),
+    ('Denver', 'Colorado', 39.7392, -104.9903),
+    ('Washington', 'District of Columbia', 38.9072, -77.0369),
+    ('Boston', 'Massachusetts', 42.3601, -71.0589),
+    ('Nashville', 'Tennessee', 36.1627, -86.7816),
+    ('Detroit', 'Michigan', 42.3314, -83.0458),
+    ('Portland', 'Oregon', 45.5152, -122.6784),
+    ('Las Vegas', 'Nevada', 36.1699, -115.1398),
+    ('Atlanta', 'Georgia', 33.7490, -84.3880),
+    ('Miami', 'Florida', 25.7617, -80.1918),
+    ('Minneapolis', 'Minnesota', 44.9778, -93.2650),
+    ('Salt Lake City', 'Utah', 40.7608, -111.8910),
+    ('Baltimore', 'Maryland', 39.2904, -76.6122)
+) AS v(city, state, lat, lng)
+JOIN states s ON s.name = v.state
+WHERE c.name = v.city AND c.state_id = s.id;